@@ -18,6 +18,7 @@ import (
 )
 
 func newShowConfigCmd() *cobra.Command {
+	noRedact := false
 	cmd := &cobra.Command{
 		Use:   "show-config <cluster-name>",
 		Short: "Show TiDB cluster config",
@@ -27,7 +28,7 @@ func newShowConfigCmd() *cobra.Command {
 			}
 
 			clusterName := args[0]
-			return cm.ShowConfig(clusterName)
+			return cm.ShowConfig(clusterName, noRedact)
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			switch len(args) {
@@ -39,5 +40,7 @@ func newShowConfigCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&noRedact, "no-redact", false, "Show the sensitive values, e.g. passwords, instead of hiding them")
+
 	return cmd
 }
@@ -15,12 +15,14 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"path"
 	"text/template"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/embed"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
 	"github.com/spf13/cobra"
 )
 
@@ -29,6 +31,7 @@ type TemplateOptions struct {
 	Full    bool // print full template
 	MultiDC bool // print template for deploying to multiple data center
 	Local   bool // print and render local template
+	Schema  bool // print the JSON Schema of the topology
 }
 
 // LocalTemplate contains the variables for print local template.
@@ -69,8 +72,16 @@ func newTemplateCmd() *cobra.Command {
 		Use:   "template",
 		Short: "Print topology template",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if sumBool(opt.Full, opt.MultiDC, opt.Local) > 1 {
-				return errors.New("at most one of 'full', 'multi-dc', or 'local' can be specified")
+			if sumBool(opt.Full, opt.MultiDC, opt.Local, opt.Schema) > 1 {
+				return errors.New("at most one of 'full', 'multi-dc', 'local', or 'schema' can be specified")
+			}
+			if opt.Schema {
+				data, err := json.MarshalIndent(spec.TopologySchema(), "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
 			}
 			name := "minimal.yaml"
 			switch {
@@ -119,6 +130,7 @@ func newTemplateCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&opt.Full, "full", false, "Print the full topology template for TiDB cluster.")
 	cmd.Flags().BoolVar(&opt.MultiDC, "multi-dc", false, "Print template for deploying to multiple data center.")
 	cmd.Flags().BoolVar(&opt.Local, "local", false, "Print and render template for deploying a simple cluster locally.")
+	cmd.Flags().BoolVar(&opt.Schema, "schema", false, "Print the JSON Schema of the topology file, for editors and CI to validate it.")
 
 	// template values for rendering
 	cmd.Flags().StringVar(&localOpt.GlobalUser, "user", "tidb", "The user who runs the tidb cluster.")
@@ -182,7 +182,7 @@ func (i *MasterInstance) InitConfig(
 	}
 
 	specConfig := spec.Config
-	return i.MergeServerConfig(ctx, e, i.topo.ServerConfigs.Master, specConfig, paths)
+	return i.MergeServerConfig(ctx, e, i.topo.ServerConfigs.Master, specConfig, i.topo.ServerConfigs.Comments["master"].Merge(spec.ConfigComments), paths)
 }
 
 // setTLSConfig set TLS Config to support enable/disable TLS
@@ -403,7 +403,7 @@ func (i *WorkerInstance) InitConfig(
 	}
 
 	specConfig := spec.Config
-	return i.MergeServerConfig(ctx, e, i.topo.ServerConfigs.Worker, specConfig, paths)
+	return i.MergeServerConfig(ctx, e, i.topo.ServerConfigs.Worker, specConfig, i.topo.ServerConfigs.Comments["worker"].Merge(spec.ConfigComments), paths)
 }
 
 // setTLSConfig set TLS Config to support enable/disable TLS
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tiup/pkg/meta"
 	"github.com/pingcap/tiup/pkg/set"
 	"github.com/pingcap/tiup/pkg/utils"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -94,6 +95,9 @@ type (
 		Master  map[string]any    `yaml:"master"`
 		Worker  map[string]any    `yaml:"worker"`
 		Grafana map[string]string `yaml:"grafana"`
+		// Comments are the comments of the config keys in the topology, by
+		// component, e.g. "master", they are written to the generated configs.
+		Comments map[string]spec.ConfigComments `yaml:"-" comments:"*"`
 	}
 
 	// ComponentSources represents the source of components
@@ -135,19 +139,20 @@ type MasterSpec struct {
 	Patched        bool   `yaml:"patched,omitempty"`
 	IgnoreExporter bool   `yaml:"ignore_exporter,omitempty"`
 	// Use Name to get the name with a default value if it's empty.
-	Name            string          `yaml:"name,omitempty"`
-	Port            int             `yaml:"port,omitempty" default:"8261"`
-	PeerPort        int             `yaml:"peer_port,omitempty" default:"8291"`
-	DeployDir       string          `yaml:"deploy_dir,omitempty"`
-	DataDir         string          `yaml:"data_dir,omitempty"`
-	LogDir          string          `yaml:"log_dir,omitempty"`
-	Source          string          `yaml:"source,omitempty" validate:"source:editable"`
-	NumaNode        string          `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
-	Config          map[string]any  `yaml:"config,omitempty" validate:"config:ignore"`
-	ResourceControl ResourceControl `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
-	Arch            string          `yaml:"arch,omitempty"`
-	OS              string          `yaml:"os,omitempty"`
-	V1SourcePath    string          `yaml:"v1_source_path,omitempty"`
+	Name            string              `yaml:"name,omitempty"`
+	Port            int                 `yaml:"port,omitempty" default:"8261"`
+	PeerPort        int                 `yaml:"peer_port,omitempty" default:"8291"`
+	DeployDir       string              `yaml:"deploy_dir,omitempty"`
+	DataDir         string              `yaml:"data_dir,omitempty"`
+	LogDir          string              `yaml:"log_dir,omitempty"`
+	Source          string              `yaml:"source,omitempty" validate:"source:editable"`
+	NumaNode        string              `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
+	Config          map[string]any      `yaml:"config,omitempty" validate:"config:ignore"`
+	ConfigComments  spec.ConfigComments `yaml:"-" validate:"config_comments:ignore" comments:"config"`
+	ResourceControl ResourceControl     `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
+	Arch            string              `yaml:"arch,omitempty"`
+	OS              string              `yaml:"os,omitempty"`
+	V1SourcePath    string              `yaml:"v1_source_path,omitempty"`
 }
 
 // Status queries current status of the instance
@@ -214,17 +219,18 @@ type WorkerSpec struct {
 	Patched        bool   `yaml:"patched,omitempty"`
 	IgnoreExporter bool   `yaml:"ignore_exporter,omitempty"`
 	// Use Name to get the name with a default value if it's empty.
-	Name            string          `yaml:"name,omitempty"`
-	Port            int             `yaml:"port,omitempty" default:"8262"`
-	DeployDir       string          `yaml:"deploy_dir,omitempty"`
-	DataDir         string          `yaml:"data_dir,omitempty"`
-	LogDir          string          `yaml:"log_dir,omitempty"`
-	Source          string          `yaml:"source,omitempty" validate:"source:editable"`
-	NumaNode        string          `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
-	Config          map[string]any  `yaml:"config,omitempty" validate:"config:ignore"`
-	ResourceControl ResourceControl `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
-	Arch            string          `yaml:"arch,omitempty"`
-	OS              string          `yaml:"os,omitempty"`
+	Name            string              `yaml:"name,omitempty"`
+	Port            int                 `yaml:"port,omitempty" default:"8262"`
+	DeployDir       string              `yaml:"deploy_dir,omitempty"`
+	DataDir         string              `yaml:"data_dir,omitempty"`
+	LogDir          string              `yaml:"log_dir,omitempty"`
+	Source          string              `yaml:"source,omitempty" validate:"source:editable"`
+	NumaNode        string              `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
+	Config          map[string]any      `yaml:"config,omitempty" validate:"config:ignore"`
+	ConfigComments  spec.ConfigComments `yaml:"-" validate:"config_comments:ignore" comments:"config"`
+	ResourceControl ResourceControl     `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
+	Arch            string              `yaml:"arch,omitempty"`
+	OS              string              `yaml:"os,omitempty"`
 }
 
 // Status queries current status of the instance
@@ -271,12 +277,27 @@ func (s *WorkerSpec) IgnoreMonitorAgent() bool {
 	return s.IgnoreExporter
 }
 
-// UnmarshalYAML sets default values when unmarshaling the topology file
+// MarshalYAML writes the comments of the configs back above their keys
+func (s Specification) MarshalYAML() (any, error) {
+	type topology Specification
+	var node yaml.Node
+	if err := node.Encode(topology(s)); err != nil {
+		return nil, err
+	}
+	spec.AttachConfigComments(&node, &s)
+	return &node, nil
+}
+
+// UnmarshalYAML sets default values when unmarshaling the topology file,
+// and keeps the comments of the configs (see spec.LoadConfigComments)
 func (s *Specification) UnmarshalYAML(unmarshal func(any) error) error {
 	type topology Specification
 	if err := unmarshal((*topology)(s)); err != nil {
 		return err
 	}
+	if err := spec.LoadConfigComments(unmarshal, s); err != nil {
+		return err
+	}
 
 	if err := defaults.Set(s); err != nil {
 		return errors.Trace(err)
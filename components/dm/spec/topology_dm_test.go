@@ -534,3 +534,31 @@ monitored:
 		require.Equal(t, "test-deploy", topo.MonitoredOptions.DeployDir)
 	})
 }
+
+func TestConfigComments(t *testing.T) {
+	topo := Specification{}
+	err := yaml.Unmarshal([]byte(`
+server_configs:
+  worker:
+    # shared by all the workers
+    log-level: info
+master_servers:
+  - host: 172.16.5.138
+    config:
+      rpc-timeout: 30s # slow network
+worker_servers:
+  - host: 172.16.5.53
+`), &topo)
+	require.NoError(t, err)
+	require.Equal(t, map[string]spec.ConfigComments{"worker": {"log-level": "shared by all the workers"}}, topo.ServerConfigs.Comments)
+	require.Equal(t, spec.ConfigComments{"rpc-timeout": "slow network"}, topo.Masters[0].ConfigComments)
+	require.Nil(t, topo.Workers[0].ConfigComments)
+
+	// The comments survive the meta file.
+	data, err := yaml.Marshal(topo)
+	require.NoError(t, err)
+	reloaded := Specification{}
+	require.NoError(t, yaml.Unmarshal(data, &reloaded))
+	require.Equal(t, topo.ServerConfigs.Comments, reloaded.ServerConfigs.Comments)
+	require.Equal(t, topo.Masters[0].ConfigComments, reloaded.Masters[0].ConfigComments)
+}
@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -18,6 +21,7 @@ import (
 	pgservice "github.com/pingcap/tiup/components/playground-ng/service"
 	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/tui/colorstr"
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
 	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
 	"github.com/pingcap/tiup/pkg/utils"
 )
@@ -99,6 +103,13 @@ func (p *Playground) printClusterInfoCallout(tidbSucc, tiproxySucc []string) boo
 	}
 
 	if rows := p.clusterInfoCalloutRows(mysql, dashboardURL, grafanaURL, tidbSucc, tiproxySucc); len(rows) > 0 {
+		// Make the URLs clickable in the terminals that render hyperlinks.
+		mode := tuiterm.Resolve(out)
+		for i, row := range rows {
+			if strings.HasPrefix(row[1], "http://") || strings.HasPrefix(row[1], "https://") {
+				rows[i][1] = mode.Link(row[1], row[1])
+			}
+		}
 		blocks = append(blocks, tuiv2output.Labels{Rows: rows}.Lines(out))
 	}
 
@@ -132,6 +143,9 @@ func (p *Playground) clusterInfoBasicRows() [][2]string {
 		}
 		rows = append(rows, [2]string{"Data dir:", value})
 	}
+	if p.latency != nil {
+		rows = append(rows, [2]string{"Latency:", p.latency.Summary()})
+	}
 	return rows
 }
 
@@ -141,10 +155,12 @@ func (p *Playground) clusterInfoCalloutRows(mysql, dashboardURL, grafanaURL stri
 	}
 
 	basic := p.clusterInfoBasicRows()
+	password := p.SharedOptions().RootPassword != ""
 
 	var rest [][2]string
-	rest = append(rest, clusterInfoMySQLConnectRows(mysql, "Connect TiDB:", tidbSucc)...)
-	rest = append(rest, clusterInfoMySQLConnectRows(mysql, "Connect TiProxy:", tiproxySucc)...)
+	rest = append(rest, clusterInfoMySQLConnectRows(mysql, "Connect TiDB:", tidbSucc, password)...)
+	rest = append(rest, p.clusterInfoTiDBLBRows(mysql, tidbSucc, password)...)
+	rest = append(rest, clusterInfoMySQLConnectRows(mysql, "Connect TiProxy:", tiproxySucc, password)...)
 	rest = append(rest, p.clusterInfoDMConnectRows()...)
 	if dashboardURL != "" {
 		rest = append(rest, [2]string{"TiDB Dashboard:", dashboardURL})
@@ -152,6 +168,13 @@ func (p *Playground) clusterInfoCalloutRows(mysql, dashboardURL, grafanaURL stri
 	if grafanaURL != "" {
 		rest = append(rest, [2]string{"Grafana:", grafanaURL})
 	}
+	if p.commandListener != nil {
+		ui := fmt.Sprintf("http://127.0.0.1:%d/ui", p.port)
+		if p.bootOptions != nil && p.bootOptions.ReadOnlyToken != "" {
+			ui += "?token=" + url.QueryEscape(p.bootOptions.ReadOnlyToken)
+		}
+		rest = append(rest, [2]string{"Playground UI:", ui})
+	}
 	rest = append(rest, p.clusterInfoTiKVSlimRows()...)
 
 	var rows [][2]string
@@ -163,6 +186,100 @@ func (p *Playground) clusterInfoCalloutRows(mysql, dashboardURL, grafanaURL stri
 	return rows
 }
 
+// playgroundReady is the content of the ready file, written to the data dir
+// once all instances are ready and the command server listens, so wrapper
+// scripts can wait for a single artifact instead of polling `display`.
+type playgroundReady struct {
+	PID     int      `json:"pid"`
+	Port    int      `json:"port"`
+	TiDB    []string `json:"tidb"`
+	TiProxy []string `json:"tiproxy,omitempty"`
+	PD      []string `json:"pd"`
+	// TiDBLB is the address of the TiDB load balancer (--db.lb), if any.
+	TiDBLB string `json:"tidb_lb,omitempty"`
+	// Version is the resolved version of PD, which tools driving the cluster
+	// (e.g. BR) should match.
+	Version string `json:"version,omitempty"`
+	// Dashboard, Grafana and Prometheus are the URLs of the monitoring
+	// services, if any.
+	Dashboard  string `json:"dashboard,omitempty"`
+	Grafana    string `json:"grafana,omitempty"`
+	Prometheus string `json:"prometheus,omitempty"`
+	// TLSCA is the CA of a cluster started with --tls, and TLSCert and TLSKey
+	// the client certificate and key to connect to it with.
+	TLSCA   string `json:"tls_ca,omitempty"`
+	TLSCert string `json:"tls_cert,omitempty"`
+	TLSKey  string `json:"tls_key,omitempty"`
+	// RootPassword is the --root-password of the TiDB root user.
+	RootPassword string    `json:"root_password,omitempty"`
+	ReadyAt      time.Time `json:"ready_at"`
+}
+
+// scheme returns the scheme of the HTTP APIs of the cluster.
+func (r *playgroundReady) scheme() string {
+	if r.TLSCA != "" {
+		return "https"
+	}
+	return "http"
+}
+
+// httpClient returns a client for the HTTP APIs of the cluster, presenting
+// the client certificate with --tls.
+func (r *playgroundReady) httpClient(timeout time.Duration) (*http.Client, error) {
+	client := &http.Client{Timeout: timeout}
+	if r.TLSCA == "" {
+		return client, nil
+	}
+	tlsCfg, err := proc.ClientTLSConfig(r.TLSCA, r.TLSCert, r.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	client.Transport = &http.Transport{TLSClientConfig: tlsCfg}
+	return client, nil
+}
+
+func (p *Playground) readyInfo(tidbSucc, tiproxySucc []string) *playgroundReady {
+	ready := &playgroundReady{
+		PID:     os.Getpid(),
+		Port:    p.port,
+		TiDB:    append([]string{}, tidbSucc...),
+		TiProxy: tiproxySucc,
+		PD:      []string{},
+		TiDBLB:  p.tidbLB.Addr(),
+	}
+	for _, pd := range pgservice.ProcsOf[*proc.PDInstance](p, proc.ServicePD, proc.ServicePDAPI) {
+		ready.PD = append(ready.PD, pd.Addr())
+		if ready.Version == "" && pd.Info() != nil {
+			ready.Version = pd.Info().Version.String()
+		}
+	}
+	ready.Dashboard, ready.Grafana = p.clusterInfoMonitorURLs()
+	if proms := pgservice.ProcsOf[*proc.PrometheusInstance](p, proc.ServicePrometheus); len(proms) > 0 && proms[0] != nil {
+		ready.Prometheus = fmt.Sprintf("http://%s", utils.JoinHostPort(proms[0].Host, proms[0].Port))
+	}
+	if shOpt := p.SharedOptions(); shOpt.TLS && shOpt.TLSDir != "" {
+		ready.TLSCA = proc.TLSCertPath(shOpt.TLSDir, proc.TLSCAName)
+		ready.TLSCert = proc.TLSCertPath(shOpt.TLSDir, proc.TLSClientName)
+		ready.TLSKey = proc.TLSKeyPath(shOpt.TLSDir, proc.TLSClientName)
+	}
+	ready.RootPassword = p.SharedOptions().RootPassword
+	return ready
+}
+
+// writeReadyFile writes ready to path atomically: readers never see a
+// partial file.
+func writeReadyFile(path string, ready *playgroundReady) error {
+	data, err := json.MarshalIndent(ready, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := utils.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
 func joinNonEmptyBlocks(blocks [][]string) string {
 	var lines []string
 	for _, b := range blocks {
@@ -186,9 +303,9 @@ func (p *Playground) clusterInfoMonitorURLs() (dashboardURL, grafanaURL string)
 	tidbInstances := pgservice.ProcsOf[*proc.TiDBInstance](p, proc.ServiceTiDBSystem, proc.ServiceTiDB)
 
 	if len(pdMembers) > 0 {
-		pdAddr := pdMembers[0].Addr()
-		if len(tidbInstances) > 0 && hasDashboard(pdAddr) {
-			dashboardURL = fmt.Sprintf("http://%s/dashboard", pdAddr)
+		url := fmt.Sprintf("%s://%s/dashboard", p.SharedOptions().Scheme(), pdMembers[0].Addr())
+		if len(tidbInstances) > 0 && hasDashboard(url, p.SharedOptions()) {
+			dashboardURL = url
 		}
 	}
 
@@ -199,7 +316,9 @@ func (p *Playground) clusterInfoMonitorURLs() (dashboardURL, grafanaURL string)
 	return dashboardURL, grafanaURL
 }
 
-func clusterInfoMySQLConnectRows(mysql, label string, addrs []string) [][2]string {
+// clusterInfoMySQLConnectRows lists the mysql commands connecting to addrs,
+// prompting for the password when root has one.
+func clusterInfoMySQLConnectRows(mysql, label string, addrs []string, password bool) [][2]string {
 	var rows [][2]string
 	for _, dbAddr := range addrs {
 		host, port, err := net.SplitHostPort(dbAddr)
@@ -207,11 +326,45 @@ func clusterInfoMySQLConnectRows(mysql, label string, addrs []string) [][2]strin
 			continue
 		}
 		cmd := fmt.Sprintf("%s --host %s --port %s -u root", mysql, host, port)
+		if password {
+			cmd += " -p"
+		}
 		rows = append(rows, [2]string{label, cmd})
 	}
 	return rows
 }
 
+// clusterInfoTiDBLBRows lists the DSNs of a multi-TiDB cluster, and how to
+// reach all of them through a single endpoint.
+func (p *Playground) clusterInfoTiDBLBRows(mysql string, tidbSucc []string, password bool) [][2]string {
+	if p == nil || len(tidbSucc) < 2 {
+		return nil
+	}
+	rows := [][2]string{{"TiDB DSNs:", strings.Join(tidbDSNs(tidbSucc, ""), ",")}}
+	if p.tidbLB != nil {
+		return append(rows, clusterInfoMySQLConnectRows(mysql, "Connect TiDB LB:", []string{p.tidbLB.Addr()}, password)...)
+	}
+	return append(rows, [2]string{"", colorstr.Sprintf("[dim]Start with [bold]--db.lb[reset][dim] for a single round-robin endpoint.[reset]")})
+}
+
+// tidbDSNs returns the DSNs of the TiDBs at addrs for root with password.
+func tidbDSNs(addrs []string, password string) []string {
+	dsns := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		dsns = append(dsns, tidbDSN(addr, password))
+	}
+	return dsns
+}
+
+// tidbDSN returns the DSN of the TiDB at addr for root with password.
+func tidbDSN(addr, password string) string {
+	user := url.User("root")
+	if password != "" {
+		user = url.UserPassword("root", password)
+	}
+	return fmt.Sprintf("mysql://%s@%s", user, addr)
+}
+
 func (p *Playground) clusterInfoDMConnectRows() [][2]string {
 	if p == nil {
 		return nil
@@ -255,17 +408,22 @@ func (p *Playground) clusterInfoTiKVSlimRows() [][2]string {
 	}
 }
 
-func hasDashboard(pdAddr string) bool {
+func hasDashboard(url string, shOpt proc.SharedOptions) bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	url := fmt.Sprintf("http://%s/dashboard", pdAddr)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return false
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	client := http.DefaultClient
+	if tlsCfg, err := shOpt.ClientTLSConfig(); err != nil {
+		return false
+	} else if tlsCfg != nil {
+		client = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}}
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return false
 	}
@@ -281,7 +439,11 @@ func (p *Playground) updateMonitorTopology(componentID string, info MonitorInfo)
 		return
 	}
 
-	client, err := newEtcdClient(pdMembers[0].Addr())
+	tlsCfg, err := p.SharedOptions().ClientTLSConfig()
+	if err != nil {
+		return
+	}
+	client, err := newEtcdClient(pdMembers[0].Addr(), tlsCfg)
 	if err != nil {
 		return
 	}
@@ -365,6 +527,117 @@ func (p *Playground) renderSDFile() error {
 	return renderPrometheusSDFile(prom, p.WalkProcs)
 }
 
+// instanceRecord is an entry of the instance registry.
+type instanceRecord struct {
+	Name    string `json:"name"`
+	Service string `json:"service"`
+	Dir     string `json:"dir"`
+	LogDir  string `json:"log_dir,omitempty"`
+	// PID, StartTime (ms since epoch) and BinPath identify the last process
+	// spawned for the instance, so it can be swept if the playground dies
+	// without stopping it (see findOrphanProcesses).
+	PID       int    `json:"pid,omitempty"`
+	StartTime int64  `json:"start_time,omitempty"`
+	BinPath   string `json:"bin_path,omitempty"`
+}
+
+// newInstanceRecord returns the record of an instance, with its dirs rebased
+// from dataDir onto realDir.
+func newInstanceRecord(serviceID proc.ServiceID, info *proc.ProcessInfo, dataDir, realDir string) instanceRecord {
+	rec := instanceRecord{
+		Name:    filepath.Base(info.Dir),
+		Service: serviceID.String(),
+		Dir:     rebaseDir(info.Dir, dataDir, realDir),
+		LogDir:  rebaseDir(info.LogDir, dataDir, realDir),
+	}
+	if info.Proc != nil && info.Proc.Pid() > 0 {
+		rec.PID = info.Proc.Pid()
+		rec.StartTime, _ = processStartTime(rec.PID)
+		rec.BinPath = info.BinPath
+	}
+	return rec
+}
+
+// writeInstanceRegistry merges the dirs of the walked instances into the
+// registry under dataDir. Records of instances that are gone are kept, since
+// their dirs still have to be cleaned up.
+func writeInstanceRegistry(dataDir string, walk procWalker) error {
+	if dataDir == "" || walk == nil {
+		return nil
+	}
+	// A retagged playground still runs from its original path, a symlink to
+	// the real data dir (see retagDataDir): record the real dirs.
+	realDir := realDataDir(dataDir)
+	records := readInstanceRegistry(dataDir)
+	byName := make(map[string]int, len(records))
+	for i, r := range records {
+		byName[r.Name] = i
+	}
+
+	_ = walk(func(serviceID proc.ServiceID, inst proc.Process) error {
+		if inst == nil || inst.Info() == nil {
+			return nil
+		}
+		rec := newInstanceRecord(serviceID, inst.Info(), dataDir, realDir)
+		if i, ok := byName[rec.Name]; ok {
+			if rec.PID == 0 {
+				// Not (re)started yet: keep tracking the previous process.
+				rec.PID, rec.StartTime, rec.BinPath = records[i].PID, records[i].StartTime, records[i].BinPath
+			}
+			records[i] = rec
+			return nil
+		}
+		byName[rec.Name] = len(records)
+		records = append(records, rec)
+		return nil
+	})
+	return writeInstanceRecords(dataDir, records)
+}
+
+// writeInstanceRecords atomically replaces the registry under dataDir.
+func writeInstanceRecords(dataDir string, records []instanceRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dataDir, playgroundInstancesFileName)
+	tmp := path + ".tmp"
+	if err := utils.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readInstanceRegistry returns the registry under dataDir, or nil if it is
+// missing or unreadable.
+func readInstanceRegistry(dataDir string) []instanceRecord {
+	data, err := os.ReadFile(filepath.Join(dataDir, playgroundInstancesFileName))
+	if err != nil {
+		return nil
+	}
+	var records []instanceRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil
+	}
+	return records
+}
+
+// removeExternalInstanceDirs removes the instance dirs recorded in the
+// registry that live outside dataDir (see instanceDirs). The dirs under
+// dataDir are removed along with it.
+func removeExternalInstanceDirs(dataDir string) {
+	for _, r := range readInstanceRegistry(dataDir) {
+		for _, dir := range []string{r.Dir, r.LogDir} {
+			if dir == "" || utils.IsSubDir(dataDir, dir) {
+				continue
+			}
+			_ = os.RemoveAll(dir)
+			// Drop the per-playground parent if it is empty now.
+			_ = os.Remove(filepath.Dir(dir))
+		}
+	}
+}
+
 func logIfErr(err error) {
 	if err != nil {
 		logprinter.Warnf("%v", err)
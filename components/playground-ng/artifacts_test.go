@@ -1,10 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
 	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
 	"github.com/stretchr/testify/require"
 )
@@ -65,3 +72,105 @@ func TestClusterInfoCalloutRows_AlignedWithSeparatorRow(t *testing.T) {
 	require.NotEqual(t, -1, idxMySQL, "expected values not found:\n%s\n%s", versionLine, connectLine)
 	require.Equal(t, idxVer, idxMySQL, "value columns not aligned:\n%s\n%s", versionLine, connectLine)
 }
+
+func TestClusterInfoCalloutRows_ListsTiDBDSNs(t *testing.T) {
+	pg := NewPlayground("/tmp/tiup-playground-test", 0)
+	pg.bootOptions = &BootOptions{Version: "v7.5.0"}
+	tidbs := []string{"127.0.0.1:4000", "127.0.0.1:4001"}
+
+	rows := pg.clusterInfoCalloutRows("mysql", "", "", tidbs, nil)
+	require.Contains(t, rows, [2]string{"TiDB DSNs:", "mysql://root@127.0.0.1:4000,mysql://root@127.0.0.1:4001"})
+	require.Contains(t, rows[len(rows)-1][1], "--db.lb")
+
+	lb, err := listenTiDBBalancer("127.0.0.1:0")
+	require.NoError(t, err)
+	defer lb.Close()
+	pg.tidbLB = lb
+	_, port, _ := net.SplitHostPort(lb.Addr())
+
+	rows = pg.clusterInfoCalloutRows("mysql", "", "", tidbs, nil)
+	require.Equal(t, [2]string{"Connect TiDB LB:", "mysql --host 127.0.0.1 --port " + port + " -u root"}, rows[len(rows)-1])
+
+	rows = pg.clusterInfoCalloutRows("mysql", "", "", tidbs[:1], nil)
+	for _, row := range rows {
+		require.NotEqual(t, "TiDB DSNs:", row[0])
+	}
+}
+
+// listeningPlayground returns a playground whose command server port is
+// bound, as boot leaves it before printing the cluster info.
+func listeningPlayground(t *testing.T) *Playground {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	pg := NewPlayground("/tmp/tiup-playground-test", ln.Addr().(*net.TCPAddr).Port)
+	pg.commandListener = ln
+	return pg
+}
+
+func TestClusterInfoCalloutRows_PlaygroundUI(t *testing.T) {
+	pg := NewPlayground("/tmp/tiup-playground-test", 9527)
+	rows := pg.clusterInfoCalloutRows("mysql", "", "", []string{"127.0.0.1:4000"}, nil)
+	for _, row := range rows {
+		require.NotEqual(t, "Playground UI:", row[0], "the command server does not listen")
+	}
+
+	pg = listeningPlayground(t)
+	rows = pg.clusterInfoCalloutRows("mysql", "", "", []string{"127.0.0.1:4000"}, nil)
+	require.Contains(t, rows, [2]string{"Playground UI:", fmt.Sprintf("http://127.0.0.1:%d/ui", pg.port)})
+}
+
+func TestPrintClusterInfoCallout_HyperlinkURLs(t *testing.T) {
+	t.Setenv(tuiterm.EnvNoColor, "")
+	t.Setenv(tuiterm.EnvForceHyperlink, "1")
+	var out bytes.Buffer
+	oldStdout := tuiv2output.Stdout.Get()
+	tuiv2output.Stdout.Set(&out)
+	defer tuiv2output.Stdout.Set(oldStdout)
+
+	pg := listeningPlayground(t)
+	require.True(t, pg.printClusterInfoCallout([]string{"127.0.0.1:4000"}, nil))
+	url := fmt.Sprintf("http://127.0.0.1:%d/ui", pg.port)
+	require.Contains(t, out.String(), tuiterm.OutputMode{Hyperlink: true}.Link(url, url))
+
+	t.Setenv(tuiterm.EnvForceHyperlink, "0")
+	out.Reset()
+	require.True(t, pg.printClusterInfoCallout([]string{"127.0.0.1:4000"}, nil))
+	require.Contains(t, out.String(), "Playground UI: "+url+"\n")
+}
+
+func TestInstanceRegistry_KeepsScaledInInstancesForCleanup(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "my-tag")
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "pd-0"), 0o755))
+	fastDir := filepath.Join(t.TempDir(), "my-tag", "tikv-0")
+	logDir := filepath.Join(t.TempDir(), "my-tag", "tikv-0")
+	require.NoError(t, os.MkdirAll(fastDir, 0o755))
+	require.NoError(t, os.MkdirAll(logDir, 0o755))
+
+	pd := &stubProcess{info: &proc.ProcessInfo{Service: proc.ServicePD, Dir: filepath.Join(dataDir, "pd-0")}}
+	kv := &stubProcess{info: &proc.ProcessInfo{Service: proc.ServiceTiKV, Dir: fastDir, LogDir: logDir}}
+	walk := func(procs ...proc.Process) procWalker {
+		return func(fn func(serviceID proc.ServiceID, inst proc.Process) error) error {
+			for _, inst := range procs {
+				if err := fn(inst.Info().Service, inst); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	require.NoError(t, writeInstanceRegistry(dataDir, walk(pd, kv)))
+	// TiKV is scaled in: it is no longer walked but must still be cleaned.
+	require.NoError(t, writeInstanceRegistry(dataDir, walk(pd)))
+	require.Equal(t, []instanceRecord{
+		{Name: "pd-0", Service: "pd", Dir: filepath.Join(dataDir, "pd-0")},
+		{Name: "tikv-0", Service: "tikv", Dir: fastDir, LogDir: logDir},
+	}, readInstanceRegistry(dataDir))
+
+	removeExternalInstanceDirs(dataDir)
+	require.NoDirExists(t, fastDir)
+	require.NoDirExists(t, filepath.Dir(fastDir))
+	require.NoDirExists(t, logDir)
+	require.DirExists(t, filepath.Join(dataDir, "pd-0"))
+}
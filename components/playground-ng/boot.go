@@ -4,9 +4,12 @@ import (
 	"context"
 	stdErrors "errors"
 	"fmt"
+	"net"
 	"net/url"
 	"path/filepath"
+	"runtime"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +21,7 @@ import (
 	"github.com/pingcap/tiup/pkg/repository"
 	"github.com/pingcap/tiup/pkg/tidbver"
 	"github.com/pingcap/tiup/pkg/utils"
+	"golang.org/x/mod/semver"
 )
 
 // BootOptions is the topology and options used to start a playground cluster.
@@ -31,6 +35,35 @@ type BootOptions struct {
 	Monitor     bool               `yaml:"monitor"`
 	GrafanaPort int                `yaml:"grafana_port"`
 
+	// DBLoadBalancer starts a TCP round-robin proxy in front of the TiDB
+	// instances (see tidbBalancer).
+	DBLoadBalancer bool `yaml:"db_lb,omitempty"`
+
+	// SnapshotEvery is the interval of the automatic snapshots, which keep
+	// the SnapshotKeep most recent ones (see handleSnapshot).
+	SnapshotEvery time.Duration `yaml:"snapshot_every,omitempty"`
+	SnapshotKeep  int           `yaml:"snapshot_keep,omitempty"`
+
+	// CORSOrigins are the browser origins allowed to use the command server,
+	// "*" for any (see withCORS).
+	CORSOrigins []string `yaml:"cors_origins,omitempty"`
+	// ReadOnlyToken, if set, makes the command server require a token: this
+	// one for the read-only commands, or the control token it writes in the
+	// data dir for all (see withCommandAuth).
+	ReadOnlyToken string `yaml:"read_only_token,omitempty"`
+	// StartQueueTimeout bounds the wait for the other playgrounds starting on
+	// the machine (see waitStartTurn).
+	StartQueueTimeout time.Duration `yaml:"start_queue_timeout,omitempty"`
+
+	// InitSQL is a file of SQL statements run on TiDB once it is ready, on
+	// the first start of the data dir (see initializeTiDB).
+	InitSQL string `yaml:"init_sql,omitempty"`
+	// TiFlashReplicas are the databases or db.table given to --tiflash.replica,
+	// which get a TiFlash replica after InitSQL ran.
+	TiFlashReplicas []string `yaml:"tiflash_replicas,omitempty"`
+	// InjectLatency are the <service>=<duration> items of --inject-latency.
+	InjectLatency []string `yaml:"inject_latency,omitempty"`
+
 	Services map[proc.ServiceID]*proc.Config `yaml:"services,omitempty"`
 }
 
@@ -204,6 +237,14 @@ func normalizeBootOptionPaths(options *BootOptions) error {
 			return errors.Annotatef(err, "cannot eval absolute directory: %s", cfg.ConfigPath)
 		}
 		cfg.ConfigPath = path
+
+		for _, dir := range []*string{&cfg.DataDir, &cfg.LogDir} {
+			abs, err := getAbsolutePath(*dir)
+			if err != nil {
+				return errors.Annotatef(err, "cannot eval absolute directory: %s", *dir)
+			}
+			*dir = abs
+		}
 	}
 
 	return nil
@@ -222,12 +263,115 @@ func ValidateBootOptionsPure(options *BootOptions) error {
 		return fmt.Errorf("host is empty")
 	}
 
+	if err := validateVersionConstraint("version", options.Version); err != nil {
+		return err
+	}
+	for _, serviceID := range options.SortedServiceIDs() {
+		spec, ok := pgservice.SpecFor(serviceID)
+		if cfg := options.Service(serviceID); ok && cfg != nil && spec.Catalog.FlagPrefix != "" {
+			if err := validateVersionConstraint("--"+spec.Catalog.FlagPrefix+".version", cfg.Version); err != nil {
+				return err
+			}
+		}
+	}
+
 	cfgPD := options.Service(proc.ServicePD)
 	cfgDMMaster := options.Service(proc.ServiceDMMaster)
 
 	if err := validateServiceCountLimits(options); err != nil {
 		return err
 	}
+	if err := validateServiceRequirements(options); err != nil {
+		return err
+	}
+	if err := validateTLS(options); err != nil {
+		return err
+	}
+
+	if options.SnapshotEvery < 0 {
+		return fmt.Errorf("--snapshot-every must not be negative")
+	}
+	if options.SnapshotKeep < 0 {
+		return fmt.Errorf("--snapshot-keep must not be negative")
+	}
+	if options.StartQueueTimeout < 0 {
+		return fmt.Errorf("--start-queue-timeout must not be negative")
+	}
+	for _, origin := range options.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("--cors-origin %q is not an origin, e.g. http://localhost:3000 or *", origin)
+		}
+	}
+	if token := options.ReadOnlyToken; token != "" && (len(token) < minReadOnlyTokenLen || strings.ContainsFunc(token, func(r rune) bool { return r <= ' ' || r > '~' })) {
+		return &bootOptionError{
+			msg:   fmt.Sprintf("--read-only-token must have at least %d printable ASCII characters and no space", minReadOnlyTokenLen),
+			hints: []string{"e.g. --read-only-token $(openssl rand -hex 16)"},
+		}
+	}
+
+	if options.DBLoadBalancer && options.Service(proc.ServiceTiDB).Num < 1 {
+		return &bootOptionError{
+			msg:   "--db.lb requires at least one TiDB instance",
+			hints: []string{"add --db=2 to balance over two TiDB instances", "or remove --db.lb"},
+		}
+	}
+
+	if (options.InitSQL != "" || len(options.TiFlashReplicas) > 0 || options.ShOpt.RootPassword != "") && options.Service(proc.ServiceTiDB).Num < 1 {
+		flag := "--init-sql"
+		switch {
+		case options.InitSQL != "":
+		case len(options.TiFlashReplicas) > 0:
+			flag = "--tiflash.replica"
+		default:
+			flag = "--root-password"
+		}
+		return &bootOptionError{
+			msg:   flag + " requires at least one TiDB instance",
+			hints: []string{"add --db=1", "or remove " + flag},
+		}
+	}
+	if delays, err := parseInjectLatency(options.InjectLatency); err != nil {
+		return err
+	} else if _, ok := delays[proc.ServicePD]; ok && options.ShOpt.PDMode == "ms" {
+		return &bootOptionError{
+			msg:   "--inject-latency pd is not supported with --pd.mode ms",
+			hints: []string{"use --pd.mode pd", "or remove pd from --inject-latency"},
+		}
+	}
+	if options.ShOpt.GCLifeTime != 0 {
+		if options.ShOpt.GCLifeTime < proc.MinGCLifeTime {
+			return &bootOptionError{
+				msg:   fmt.Sprintf("--gc-ttl %s is shorter than the minimum of TiDB", options.ShOpt.GCLifeTime),
+				hints: []string{"use --gc-ttl " + proc.MinGCLifeTime.String() + " or longer"},
+			}
+		}
+		if options.Service(proc.ServiceTiDB).Num < 1 {
+			return &bootOptionError{
+				msg:   "--gc-ttl requires at least one TiDB instance",
+				hints: []string{"add --db=1", "or remove --gc-ttl"},
+			}
+		}
+	}
+	if options.ShOpt.TestFriendly && options.ShOpt.Mode != proc.ModeNormal && options.ShOpt.Mode != proc.ModeTiKVSlim {
+		return &bootOptionError{
+			msg:   fmt.Sprintf("--test-friendly is not supported in --mode %s", options.ShOpt.Mode),
+			hints: []string{fmt.Sprintf("use --mode %s or %s", proc.ModeNormal, proc.ModeTiKVSlim), "or remove --test-friendly"},
+		}
+	}
+	if len(options.TiFlashReplicas) > 0 {
+		if _, err := parseSQLTargets(options.TiFlashReplicas); err != nil {
+			return errors.Annotate(err, "--tiflash.replica")
+		}
+		if options.Service(proc.ServiceTiFlash).Num < 1 {
+			return &bootOptionError{
+				msg:   "--tiflash.replica requires at least one TiFlash instance",
+				hints: []string{"add --tiflash=1", "or remove --tiflash.replica"},
+			}
+		}
+	}
 
 	// All other components depend on PD, except DM. Ensure PD count > 0 for the
 	// common modes.
@@ -293,6 +437,124 @@ func validateServiceCountLimits(options *BootOptions) error {
 	return nil
 }
 
+// bootOptionError is a boot option validation error with remediation hints
+// for the user (e.g. the flag to add).
+type bootOptionError struct {
+	msg   string
+	hints []string
+}
+
+func (e *bootOptionError) Error() string {
+	return e.msg
+}
+
+// errorHints returns the remediation hints attached to err, if any.
+func errorHints(err error) []string {
+	var optErr *bootOptionError
+	if stdErrors.As(err, &optErr) {
+		return optErr.hints
+	}
+	return nil
+}
+
+// tlsServices are the services --tls configures certificates for.
+var tlsServices = []proc.ServiceID{
+	proc.ServicePD,
+	proc.ServiceTiKV,
+	proc.ServiceTiDB,
+	proc.ServiceTiFlash,
+	proc.ServicePrometheus,
+	proc.ServiceGrafana,
+	proc.ServiceNGMonitoring,
+}
+
+// validateTLS rejects --tls with a mode, or a planned service, it does not
+// configure the certificates of.
+func validateTLS(options *BootOptions) error {
+	if !options.ShOpt.TLS {
+		return nil
+	}
+	if options.ShOpt.Mode != proc.ModeNormal {
+		return &bootOptionError{
+			msg:   fmt.Sprintf("--tls is not supported in --mode %s", options.ShOpt.Mode),
+			hints: []string{fmt.Sprintf("use --mode %s", proc.ModeNormal), "or remove --tls"},
+		}
+	}
+	if options.ShOpt.PDMode == "ms" {
+		return &bootOptionError{
+			msg:   "--tls is not supported with --pd.mode ms",
+			hints: []string{"use --pd.mode pd", "or remove --tls"},
+		}
+	}
+
+	_, cfgByService, err := planProcs(options)
+	if err != nil {
+		return err
+	}
+	for _, spec := range pgservice.AllSpecs() {
+		if cfgByService[spec.ServiceID].Num <= 0 || slices.Contains(tlsServices, spec.ServiceID) {
+			continue
+		}
+		name := proc.ServiceDisplayName(spec.ServiceID)
+		hints := []string{"or remove --tls"}
+		if def := spec.Catalog; def.FlagPrefix != "" && def.AllowModifyNum {
+			hints = append([]string{fmt.Sprintf("remove %s with --%s=0", name, def.FlagPrefix)}, hints...)
+		}
+		return &bootOptionError{
+			msg:   fmt.Sprintf("--tls does not support %s yet", name),
+			hints: hints,
+		}
+	}
+	return nil
+}
+
+// validateServiceRequirements rejects planned services whose required services
+// are not planned, or which the cluster version does not support (see
+// Catalog.Requires and Catalog.SupportsVersion), before anything is downloaded
+// or started.
+func validateServiceRequirements(options *BootOptions) error {
+	_, cfgByService, err := planProcs(options)
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range pgservice.AllSpecs() {
+		if cfgByService[spec.ServiceID].Num <= 0 {
+			continue
+		}
+		def := spec.Catalog
+		name := proc.ServiceDisplayName(spec.ServiceID)
+		var dropHint []string
+		if def.FlagPrefix != "" && def.AllowModifyNum {
+			dropHint = []string{fmt.Sprintf("or remove %s with --%s=0", name, def.FlagPrefix)}
+		}
+
+		for _, dep := range def.Requires {
+			if cfgByService[dep].Num > 0 {
+				continue
+			}
+			depName := proc.ServiceDisplayName(dep)
+			var hints []string
+			if depSpec, ok := pgservice.SpecFor(dep); ok && depSpec.Catalog.FlagPrefix != "" && depSpec.Catalog.AllowModifyNum {
+				hints = append(hints, fmt.Sprintf("add --%s=1 to start a %s instance", depSpec.Catalog.FlagPrefix, depName))
+			}
+			return &bootOptionError{
+				msg:   fmt.Sprintf("%s requires at least one %s instance", name, depName),
+				hints: append(hints, dropHint...),
+			}
+		}
+
+		if def.SupportsVersion != nil && utils.Version(options.Version).IsValid() && !def.SupportsVersion(options.Version) {
+			return &bootOptionError{
+				msg:   fmt.Sprintf("%s is not supported in cluster version %s", name, options.Version),
+				hints: append([]string{"use a newer cluster version (e.g. nightly)"}, dropHint...),
+			}
+		}
+	}
+
+	return nil
+}
+
 func planProcs(options *BootOptions) ([]proc.ServiceID, map[proc.ServiceID]proc.Config, error) {
 	if options == nil {
 		return nil, nil, nil
@@ -310,6 +572,9 @@ func planProcs(options *BootOptions) ([]proc.ServiceID, map[proc.ServiceID]proc.
 		cfg := proc.Config{}
 		if def.PlanConfig != nil {
 			cfg = def.PlanConfig(options)
+			if userCfg, ok := options.ServiceConfig(spec.ServiceID); ok {
+				cfg.DataDir, cfg.LogDir = userCfg.DataDir, userCfg.LogDir
+			}
 		} else {
 			cfg, _ = options.ServiceConfig(spec.ServiceID)
 		}
@@ -379,13 +644,78 @@ func (s *envComponentSource) ResolveVersion(component, constraint string) (strin
 	if s == nil || s.env == nil {
 		return "", errors.New("environment not initialized")
 	}
-	v, err := s.env.V1Repository().ResolveComponentVersion(component, constraint)
+	v, err := resolveComponentVersion(s.env.V1Repository(), component, constraint)
 	if err != nil {
 		return "", err
 	}
 	return v.String(), nil
 }
 
+// latestLTSVersionAlias resolves to the newest stable release of a long-term
+// support series: vX.1 and vX.5, since v6.
+const latestLTSVersionAlias = "latest-lts"
+
+// resolveComponentVersion resolves a version constraint of component: an
+// exact version, a range (^7.5, ~8.1.0, 8.x) or an alias (latest, nightly,
+// latest-lts).
+func resolveComponentVersion(repo repository.Repository, component, constraint string) (utils.Version, error) {
+	if strings.TrimSpace(constraint) != latestLTSVersionAlias {
+		return repo.ResolveComponentVersion(component, constraint)
+	}
+
+	manifest, err := repo.GetComponentManifest(component, false)
+	if err != nil {
+		return "", err
+	}
+	platform := repository.PlatformString(runtime.GOOS, runtime.GOARCH)
+	latest := ""
+	for v := range manifest.VersionList(platform) {
+		if isLTSVersion(v) && (latest == "" || semver.Compare(latest, v) < 0) {
+			latest = v
+		}
+	}
+	if latest == "" {
+		return "", errors.Annotatef(repository.ErrUnknownVersion, "no LTS version on %s for component %s", platform, component)
+	}
+	return utils.Version(latest), nil
+}
+
+func isLTSVersion(v string) bool {
+	if !semver.IsValid(v) || semver.Prerelease(v) != "" {
+		return false
+	}
+	major, minor, ok := strings.Cut(strings.TrimPrefix(semver.MajorMinor(v), "v"), ".")
+	if !ok {
+		return false
+	}
+	majorNum, err := strconv.Atoi(major)
+	if err != nil || majorNum < 6 {
+		return false
+	}
+	return minor == "1" || minor == "5"
+}
+
+// validateVersionConstraint rejects a version that is neither an exact
+// version, a range nor an alias (see resolveComponentVersion), before
+// anything is downloaded.
+func validateVersionConstraint(flag, v string) error {
+	v = strings.TrimSpace(v)
+	switch {
+	case v == "", v == latestLTSVersionAlias,
+		strings.HasPrefix(v, utils.LatestVersionAlias), strings.HasPrefix(v, utils.NightlyVersionAlias),
+		utils.Version(v).IsValid():
+		return nil
+	}
+	if _, err := utils.NewConstraint(v); err != nil {
+		return &bootOptionError{
+			msg: fmt.Sprintf("invalid %s %q", flag, v),
+			hints: []string{fmt.Sprintf("use an exact version (v8.5.0), a range (^7.5, ~8.1.0, 8.x) or an alias (%s, %s, %s)",
+				utils.LatestVersionAlias, latestLTSVersionAlias, utils.NightlyVersionAlias)},
+		}
+	}
+	return nil
+}
+
 func requiredBinaryPathForService(serviceID proc.ServiceID, baseBinPath string) string {
 	baseBinPath = strings.TrimSpace(baseBinPath)
 	if baseBinPath == "" {
@@ -449,7 +779,16 @@ func (s *envComponentSource) PlanInstall(serviceID proc.ServiceID, component, re
 
 	v := utils.Version(resolved)
 	binPath, err := s.env.BinaryPath(component, v)
-	return planInstallByResolvedBinaryPath(serviceID, component, resolved, binPath, err, forcePull), nil
+	dp := planInstallByResolvedBinaryPath(serviceID, component, resolved, binPath, err, forcePull)
+	if dp == nil {
+		return nil, nil
+	}
+	// The size is informational only, so a manifest lookup failure must not
+	// fail planning; the download itself reports a proper error later.
+	if item, err := s.env.V1Repository().ComponentVersion(component, resolved, false); err == nil && item != nil {
+		dp.Size = int64(item.Length)
+	}
+	return dp, nil
 }
 
 func (s *envComponentSource) EnsureInstalled(component, resolved string) error {
@@ -486,6 +825,9 @@ func (p *Playground) bootCluster(ctx context.Context, options *BootOptions) (err
 		return err
 	}
 
+	if options.ShOpt.TLS {
+		options.ShOpt.TLSDir = filepath.Join(p.dataDir, proc.TLSDirName)
+	}
 	p.bootOptions = options
 	// Start the controller early so instance lifecycle events (started/exited)
 	// can be handled via the actor loop during boot.
@@ -496,6 +838,28 @@ func (p *Playground) bootCluster(ctx context.Context, options *BootOptions) (err
 	if err := ValidateBootOptionsPure(options); err != nil {
 		return err
 	}
+	initSQL, err := readInitSQL(options.InitSQL)
+	if err != nil {
+		return err
+	}
+	replicas, err := parseSQLTargets(options.TiFlashReplicas)
+	if err != nil {
+		return err
+	}
+
+	// The ports are picked, and bound by the instances, in the turn of this
+	// playground.
+	if err := p.waitStartTurn(ctx, filepath.Base(p.dataDir), options.StartQueueTimeout); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			p.releaseStartTurn()
+		}
+	}()
+	if p.port == 0 {
+		p.port = utils.MustGetFreePort("127.0.0.1", 9527, options.ShOpt.PortOffset)
+	}
 
 	orderedServiceIDs, baseConfigs, err := planProcs(options)
 	if err != nil {
@@ -511,6 +875,11 @@ func (p *Playground) bootCluster(ctx context.Context, options *BootOptions) (err
 	if err != nil {
 		return err
 	}
+	if p.invocation != nil {
+		p.invocation.pinVersions(plan)
+		logIfErr(p.invocation.recordTuning(options))
+		logIfErr(writeStartInvocation(p.dataDir, p.invocation))
+	}
 	p.bootBaseConfigs = make(map[proc.ServiceID]proc.Config, len(baseConfigs))
 	for serviceID, cfg := range baseConfigs {
 		if serviceID == "" {
@@ -553,6 +922,14 @@ func (p *Playground) bootCluster(ctx context.Context, options *BootOptions) (err
 	if err := executor.PreRun(ctx, plan); err != nil {
 		return err
 	}
+	if delays, _ := parseInjectLatency(options.InjectLatency); len(delays) > 0 {
+		p.startLatencyInjector(delays)
+	}
+	if plan.TiDBLBPort > 0 {
+		if err := p.startTiDBBalancer(net.JoinHostPort(plan.Host, strconv.Itoa(plan.TiDBLBPort))); err != nil {
+			return err
+		}
+	}
 	if err := executor.AddProcs(ctx, plan); err != nil {
 		return err
 	}
@@ -575,6 +952,9 @@ func (p *Playground) bootCluster(ctx context.Context, options *BootOptions) (err
 
 	tidbSucc := starter.waitReadyAddrs(ready[proc.ServiceTiDB])
 	tiproxySucc := starter.waitReadyAddrs(ready[proc.ServiceTiProxy])
+	if len(replicas) > 0 && len(starter.waitReadyAddrs(ready[proc.ServiceTiFlash])) == 0 {
+		return errors.New("no TiFlash instance is ready to hold the replicas of --tiflash.replica")
+	}
 
 	if ctx.Err() != nil {
 		return ctx.Err()
@@ -593,12 +973,30 @@ func (p *Playground) bootCluster(ctx context.Context, options *BootOptions) (err
 		return ctx.Err()
 	}
 
+	if len(initSQL) > 0 || len(replicas) > 0 || options.ShOpt.RootPassword != "" {
+		if len(tidbSucc) == 0 {
+			return errors.New("no TiDB instance is ready to initialize")
+		}
+		if err := p.initializeTiDB(ctx, tidbSucc[0], initSQL, replicas, options.ShOpt.RootPassword); err != nil {
+			return err
+		}
+	}
+	if options.ShOpt.GCLifeTime != 0 && len(tidbSucc) > 0 {
+		if err := p.setGCLifeTime(ctx, tidbSucc[0], options.ShOpt.GCLifeTime, options.ShOpt.RootPassword); err != nil {
+			return err
+		}
+	}
+
 	if p.ui != nil {
 		p.ui.PrintLines([]string{""})
 	} else {
 		fmt.Fprintln(p.terminalWriter())
 	}
+	if err := p.listenCommandServer(); err != nil {
+		return err
+	}
 	_ = p.printClusterInfoCallout(tidbSucc, tiproxySucc)
+	p.ready = p.readyInfo(tidbSucc, tiproxySucc)
 
 	tidbDSN := pgservice.ProcsOf[*proc.TiDBInstance](p, proc.ServiceTiDB)
 	tiproxyDSN := pgservice.ProcsOf[*proc.TiProxyInstance](p, proc.ServiceTiProxy)
@@ -617,17 +1015,23 @@ func (p *Playground) bootCluster(ctx context.Context, options *BootOptions) (err
 	// subsequent scale-out operations can follow the "join" path.
 	p.setControllerBooted(context.Background(), true)
 
+	p.startSnapshotScheduler(options.SnapshotEvery, options.SnapshotKeep)
+	p.startUsageSampler()
+
 	// Start the HTTP command server last, after all post-start
 	// artifacts (sd file, dsn, topology hints) are ready.
 	if p.processGroup != nil {
-		_ = p.processGroup.Add("command server", func() error {
+		if err := p.processGroup.Add("command server", func() error {
 			// fmt.Printf("serve at :%d\n", p.port)
 			err := p.listenAndServeHTTP()
 			if err != nil {
 				fmt.Fprintf(p.terminalWriter(), "listenAndServeHTTP quit: %s\n", err)
 			}
 			return err
-		})
+		}); err != nil {
+			// The playground is terminating.
+			_ = p.commandListener.Close()
+		}
 	} else {
 		go func() {
 			// fmt.Printf("serve at :%d\n", p.port)
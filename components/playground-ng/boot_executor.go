@@ -19,6 +19,7 @@ import (
 	"github.com/pingcap/tiup/pkg/localdata"
 	"github.com/pingcap/tiup/pkg/repository"
 	"github.com/pingcap/tiup/pkg/repository/v1manifest"
+	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
 	"github.com/pingcap/tiup/pkg/utils"
 	"golang.org/x/sync/errgroup"
 )
@@ -175,6 +176,12 @@ func (e *bootExecutor) PreRun(ctx context.Context, plan BootPlan) error {
 		return err
 	}
 
+	if plan.Shared.TLS && plan.Shared.TLSDir != "" {
+		if err := proc.GenClusterCerts(plan.Shared.TLSDir, plan.Host); err != nil {
+			return errors.Annotate(err, "generate the TLS certificates")
+		}
+	}
+
 	enabledServices := make(map[proc.ServiceID][]ServicePlan, len(plan.Services))
 	for _, svc := range plan.Services {
 		id := proc.ServiceID(strings.TrimSpace(svc.ServiceID))
@@ -382,14 +389,7 @@ func downloadAndInstallComponent(ctx context.Context, mirrorSource string, profi
 	}
 
 	if !opt.disableDecompress {
-		reader, err := os.Open(target)
-		if err != nil {
-			_ = os.RemoveAll(installDir)
-			return err
-		}
-		err = utils.Untar(reader, installDir)
-		_ = reader.Close()
-		if err != nil {
+		if err := unpackComponent(target, installDir, d.item.URL, opt.progress); err != nil {
 			_ = os.RemoveAll(installDir)
 			return err
 		}
@@ -402,6 +402,55 @@ func downloadAndInstallComponent(ctx context.Context, mirrorSource string, profi
 	return nil
 }
 
+// unpackComponent extracts the downloaded tarball into installDir, reporting
+// the compressed bytes read as a sub-task of its download, so extraction on a
+// slow disk doesn't look hung once the download is done.
+func unpackComponent(tarball, installDir, rawURL string, progress repository.DownloadProgress) error {
+	f, err := os.Open(tarball)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	var task *progressv2.Task
+	if rp, ok := progress.(*repoDownloadProgress); ok {
+		var size int64
+		if fi, err := f.Stat(); err == nil {
+			size = fi.Size()
+		}
+		task = rp.UnpackTask(rawURL, size)
+	}
+
+	r := &unpackProgressReader{r: f, task: task}
+	if err := utils.Untar(r, installDir); err != nil {
+		task.Error(err.Error())
+		return err
+	}
+	task.SetCurrent(r.read)
+	task.Done()
+	return nil
+}
+
+// unpackProgressReader reports the bytes read through it to task, throttled
+// like download progress.
+type unpackProgressReader struct {
+	r    io.Reader
+	task *progressv2.Task
+
+	read         int64
+	lastUpdateAt time.Time
+}
+
+func (r *unpackProgressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.read += int64(n)
+	if now := time.Now(); now.Sub(r.lastUpdateAt) >= 100*time.Millisecond {
+		r.lastUpdateAt = now
+		r.task.SetCurrent(r.read)
+	}
+	return n, err
+}
+
 func verifySHA256(path string, expected string) error {
 	expected = strings.ToLower(strings.TrimSpace(expected))
 	if expected == "" {
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"io"
 	"os"
@@ -11,7 +14,9 @@ import (
 	"time"
 
 	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/repository"
 	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
+	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
 	"github.com/stretchr/testify/require"
 )
 
@@ -471,3 +476,68 @@ func TestBootExecutor_PreRun_HandlersRunOnceInSortedOrder(t *testing.T) {
 	require.NoError(t, executor.PreRun(context.Background(), plan))
 	require.Equal(t, []string{"a:2", "b:1"}, got)
 }
+
+func TestUnpackComponent_ReportsUnpackSubTask(t *testing.T) {
+	dir := t.TempDir()
+	tarball := filepath.Join(dir, "tidb-v7.1.0-linux-amd64.tar.gz")
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	content := []byte("#!/bin/sh\n")
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "tidb-server", Mode: 0o755, Size: int64(len(content))}))
+	_, err := tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	require.NoError(t, os.WriteFile(tarball, buf.Bytes(), 0o644))
+
+	var eventBuf bytes.Buffer
+	ui := progressv2.New(progressv2.Options{Mode: progressv2.ModePlain, Out: io.Discard, EventLog: &eventBuf})
+	g := ui.Group("Download components")
+	progress := newRepoDownloadProgress(context.Background(), g)
+	rawURL := "https://example.com/tidb-v7.1.0-linux-amd64.tar.gz"
+	progress.Start(rawURL, int64(buf.Len()))
+	progress.(repository.DownloadProgressReporter).Success(rawURL)
+
+	installDir := filepath.Join(dir, "install")
+	require.NoError(t, unpackComponent(tarball, installDir, rawURL, progress))
+	require.FileExists(t, filepath.Join(installDir, "tidb-server"))
+	g.Close()
+	require.NoError(t, ui.Close())
+
+	var (
+		downloadID, unpackID uint64
+		seenTransfer         bool
+		current              int64
+		seenDone             bool
+	)
+	for _, line := range bytes.Split(bytes.TrimSpace(eventBuf.Bytes()), []byte("\n")) {
+		e, err := progressv2.DecodeEvent(line)
+		require.NoError(t, err)
+		if e.Type == progressv2.EventTaskAdd && e.Title != nil {
+			switch *e.Title {
+			case "TiDB":
+				downloadID = e.TaskID
+			case "Unpack":
+				require.Equal(t, downloadID, e.ParentID)
+				unpackID = e.TaskID
+			}
+			continue
+		}
+		if unpackID == 0 || e.TaskID != unpackID {
+			continue
+		}
+		switch {
+		case e.Kind != nil:
+			seenTransfer = *e.Kind == progressv2.TaskKindTransfer
+		case e.Current != nil:
+			current = *e.Current
+		case e.Status != nil && *e.Status == progressv2.TaskStatusDone:
+			seenDone = true
+		}
+	}
+	require.NotZero(t, unpackID)
+	require.True(t, seenTransfer)
+	require.Equal(t, int64(buf.Len()), current)
+	require.True(t, seenDone)
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/pingcap/tiup/components/playground-ng/proc"
 	pgservice "github.com/pingcap/tiup/components/playground-ng/service"
@@ -131,6 +132,206 @@ func TestValidateBootOptionsPure_PDModeMS_RejectsOldVersion(t *testing.T) {
 	require.Contains(t, err.Error(), "microservices")
 }
 
+func TestValidateVersionConstraint(t *testing.T) {
+	for _, v := range []string{"", "v8.5.0", "8.5.0", "^7.5", "~8.1.0", "8.x", "nightly", "latest", "latest-lts", "nightly-nextgen"} {
+		require.NoError(t, validateVersionConstraint("version", v), v)
+	}
+
+	err := validateVersionConstraint("version", "stable")
+	require.ErrorContains(t, err, `invalid version "stable"`)
+	require.NotEmpty(t, errorHints(err))
+}
+
+func TestValidateBootOptionsPure_RejectsInvalidVersionConstraint(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt: proc.SharedOptions{
+			Mode:   proc.ModeNormal,
+			PDMode: "ms",
+		},
+		Version: "^7.5",
+		Host:    "127.0.0.1",
+	}
+	opts.Service(proc.ServiceTiProxy).Version = "stable"
+
+	err := ValidateBootOptionsPure(opts)
+	require.ErrorContains(t, err, `invalid --tiproxy.version "stable"`)
+}
+
+func TestValidateBootOptionsPure_DBLoadBalancerRequiresTiDB(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt: proc.SharedOptions{
+			Mode:   proc.ModeNormal,
+			PDMode: "ms",
+		},
+		Host:           "127.0.0.1",
+		DBLoadBalancer: true,
+	}
+
+	err := ValidateBootOptionsPure(opts)
+	require.ErrorContains(t, err, "--db.lb requires at least one TiDB instance")
+	require.Contains(t, errorHints(err), "or remove --db.lb")
+
+	opts.Service(proc.ServiceTiDB).Num = 2
+	require.NoError(t, ValidateBootOptionsPure(opts))
+}
+
+func TestValidateBootOptionsPure_InitSQLRequiresTiDB(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt: proc.SharedOptions{
+			Mode:         proc.ModeNormal,
+			PDMode:       "ms",
+			RootPassword: "secret",
+		},
+		Host: "127.0.0.1",
+	}
+
+	err := ValidateBootOptionsPure(opts)
+	require.EqualError(t, err, "--root-password requires at least one TiDB instance")
+	require.Equal(t, []string{"add --db=1", "or remove --root-password"}, errorHints(err))
+
+	opts.InitSQL = "init.sql"
+	require.EqualError(t, ValidateBootOptionsPure(opts), "--init-sql requires at least one TiDB instance")
+
+	opts.Service(proc.ServiceTiDB).Num = 1
+	require.NoError(t, ValidateBootOptionsPure(opts))
+}
+
+func TestValidateBootOptionsPure_TiFlashReplica(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt: proc.SharedOptions{
+			Mode:   proc.ModeNormal,
+			PDMode: "ms",
+		},
+		Host:            "127.0.0.1",
+		TiFlashReplicas: []string{"test"},
+	}
+
+	require.EqualError(t, ValidateBootOptionsPure(opts), "--tiflash.replica requires at least one TiDB instance")
+
+	opts.Service(proc.ServiceTiDB).Num = 1
+	err := ValidateBootOptionsPure(opts)
+	require.EqualError(t, err, "--tiflash.replica requires at least one TiFlash instance")
+	require.Equal(t, []string{"add --tiflash=1", "or remove --tiflash.replica"}, errorHints(err))
+
+	opts.Service(proc.ServiceTiFlash).Num = 1
+	opts.Service(proc.ServiceTiKV).Num = 1
+	require.NoError(t, ValidateBootOptionsPure(opts))
+
+	opts.TiFlashReplicas = []string{"test."}
+	require.ErrorContains(t, ValidateBootOptionsPure(opts), `invalid target "test."`)
+}
+
+func TestValidateBootOptionsPure_GCTuning(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt: proc.SharedOptions{
+			Mode:         proc.ModeNormal,
+			PDMode:       "pd",
+			TestFriendly: true,
+			GCLifeTime:   time.Minute,
+		},
+		Version: "nightly",
+		Host:    "127.0.0.1",
+	}
+	applyServiceDefaultsForTest(t, opts)
+
+	err := ValidateBootOptionsPure(opts)
+	require.EqualError(t, err, "--gc-ttl 1m0s is shorter than the minimum of TiDB")
+	require.Equal(t, []string{"use --gc-ttl 10m0s or longer"}, errorHints(err))
+
+	opts.ShOpt.GCLifeTime = 24 * time.Hour
+	require.NoError(t, ValidateBootOptionsPure(opts))
+
+	opts.Service(proc.ServiceTiDB).Num = 0
+	require.EqualError(t, ValidateBootOptionsPure(opts), "--gc-ttl requires at least one TiDB instance")
+
+	opts.ShOpt.GCLifeTime = 0
+	opts.ShOpt.Mode = proc.ModeNextGen
+	require.EqualError(t, ValidateBootOptionsPure(opts), "--test-friendly is not supported in --mode tidb-x")
+}
+
+func TestValidateBootOptionsPure_InjectLatency(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt: proc.SharedOptions{
+			Mode:   proc.ModeNormal,
+			PDMode: "ms",
+		},
+		Version:       "nightly",
+		Host:          "127.0.0.1",
+		InjectLatency: []string{"pd=5ms"},
+	}
+	applyServiceDefaultsForTest(t, opts)
+
+	err := ValidateBootOptionsPure(opts)
+	require.EqualError(t, err, "--inject-latency pd is not supported with --pd.mode ms")
+	require.Equal(t, []string{"use --pd.mode pd", "or remove pd from --inject-latency"}, errorHints(err))
+
+	opts.InjectLatency = []string{"tikv=20ms"}
+	require.NoError(t, ValidateBootOptionsPure(opts))
+
+	opts.InjectLatency = []string{"tidb=20ms"}
+	require.ErrorContains(t, ValidateBootOptionsPure(opts), `does not support "tidb"`)
+}
+
+func TestValidateBootOptionsPure_ReadOnlyToken(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt:         proc.SharedOptions{Mode: proc.ModeNormal, PDMode: "pd"},
+		Version:       "nightly",
+		Host:          "127.0.0.1",
+		ReadOnlyToken: "short",
+	}
+	applyServiceDefaultsForTest(t, opts)
+
+	err := ValidateBootOptionsPure(opts)
+	require.EqualError(t, err, "--read-only-token must have at least 8 printable ASCII characters and no space")
+	require.Equal(t, []string{"e.g. --read-only-token $(openssl rand -hex 16)"}, errorHints(err))
+
+	opts.ReadOnlyToken = "long enough"
+	require.Error(t, ValidateBootOptionsPure(opts))
+
+	opts.ReadOnlyToken = "0123456789abcdef"
+	require.NoError(t, ValidateBootOptionsPure(opts))
+}
+
+func TestValidateBootOptionsPure_TLS(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt: proc.SharedOptions{
+			Mode:   proc.ModeNormal,
+			PDMode: "pd",
+			TLS:    true,
+		},
+		Version: "nightly",
+		Host:    "127.0.0.1",
+	}
+	applyServiceDefaultsForTest(t, opts)
+	require.NoError(t, ValidateBootOptionsPure(opts))
+
+	opts.Service(proc.ServiceTiProxy).Num = 1
+	err := ValidateBootOptionsPure(opts)
+	require.EqualError(t, err, "--tls does not support TiProxy yet")
+	require.Equal(t, []string{"remove TiProxy with --tiproxy=0", "or remove --tls"}, errorHints(err))
+
+	opts.Service(proc.ServiceTiProxy).Num = 0
+	opts.ShOpt.PDMode = "ms"
+	require.ErrorContains(t, ValidateBootOptionsPure(opts), "--tls is not supported with --pd.mode ms")
+
+	opts.ShOpt.PDMode = "pd"
+	opts.ShOpt.Mode = proc.ModeNextGen
+	require.ErrorContains(t, ValidateBootOptionsPure(opts), "--tls is not supported in --mode")
+}
+
+func TestIsLTSVersion(t *testing.T) {
+	for v, lts := range map[string]bool{
+		"v8.5.2":        true,
+		"v7.1.0":        true,
+		"v8.4.0":        false,
+		"v5.1.0":        false,
+		"v8.5.0-beta.1": false,
+		"nightly":       false,
+	} {
+		require.Equal(t, lts, isLTSVersion(v), v)
+	}
+}
+
 func TestBootOptionsService_AllocatesAndReturnsStablePointer(t *testing.T) {
 	opts := &BootOptions{}
 
@@ -180,6 +381,44 @@ func TestValidateServiceCountLimits_EnforcedMaxNum(t *testing.T) {
 	require.Contains(t, err.Error(), "TiKV Worker")
 }
 
+func TestValidateServiceRequirements_RejectsMissingRequiredService(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt: proc.SharedOptions{
+			Mode:   proc.ModeNormal,
+			PDMode: "pd",
+		},
+		Version: "nightly",
+		Host:    "127.0.0.1",
+	}
+	applyServiceDefaultsForTest(t, opts, "--kv=0", "--tiflash=1")
+
+	err := ValidateBootOptionsPure(opts)
+	require.EqualError(t, err, "TiFlash requires at least one TiKV instance")
+	require.Equal(t, []string{
+		"add --kv=1 to start a TiKV instance",
+		"or remove TiFlash with --tiflash=0",
+	}, errorHints(err))
+}
+
+func TestValidateServiceRequirements_RejectsUnsupportedVersion(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt: proc.SharedOptions{
+			Mode:   proc.ModeNormal,
+			PDMode: "pd",
+		},
+		Version: "v6.1.0",
+		Host:    "127.0.0.1",
+	}
+	applyServiceDefaultsForTest(t, opts, "--tiproxy=1")
+
+	err := ValidateBootOptionsPure(opts)
+	require.EqualError(t, err, "TiProxy is not supported in cluster version v6.1.0")
+	require.NotEmpty(t, errorHints(err))
+
+	opts.Version = "v8.5.0"
+	require.NoError(t, ValidateBootOptionsPure(opts))
+}
+
 func TestPlanInstallByResolvedBinaryPath_TiKVWorker_MissingBinary(t *testing.T) {
 	dir := t.TempDir()
 	base := filepath.Join(dir, "tikv-server")
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/environment"
+	"github.com/pingcap/tiup/pkg/repository"
+	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	brComponentID = "br"
+	// playgroundBackupsDirName holds the local backups of a playground, one
+	// dir per backup named after its start time.
+	playgroundBackupsDirName = "backups"
+)
+
+// brProgressPattern matches the percentage of the BR progress bar, e.g.
+// "Full Backup <----/........> 45.23%".
+var brProgressPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)%`)
+
+func newBackup(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+	var storage, brVersion string
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up a running playground with BR",
+		Long: `Run a full backup of a running playground with BR, downloading the BR
+component of the cluster version first if needed.
+
+By default the backup goes to <data dir>/backups/<time> (local storage: every
+TiKV writes its part there, which works since they all run on this host). Use
+--storage for another target, e.g. s3://bucket/prefix.`,
+		Example: fmt.Sprintf("%[1]s backup --tag my-cluster\n%[1]s backup --tag my-cluster --storage s3://bucket/playground", arg0),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBR(cmd.OutOrStdout(), "backup", storage, brVersion, state)
+		},
+	}
+	cmd.Flags().StringVar(&storage, "storage", "", "BR storage URL of the backup, defaults to a new dir under <data dir>/backups")
+	cmd.Flags().StringVar(&brVersion, "br.version", "", "BR version, defaults to the cluster version")
+	return cmd
+}
+
+func newRestore(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+	var brVersion string
+
+	cmd := &cobra.Command{
+		Use:   "restore <backup>",
+		Short: "Restore a BR backup into a running playground",
+		Long: `Run a full restore with BR into a running playground. The backup is either
+the name of a backup of this playground (see 'backup') or a BR storage URL.
+
+BR refuses to restore tables that already exist: restore into a fresh
+playground, or drop them first.`,
+		Example: fmt.Sprintf("%[1]s restore --tag fresh-cluster 20260102-150405\n%[1]s restore --tag fresh-cluster s3://bucket/playground", arg0),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBR(cmd.OutOrStdout(), "restore", args[0], brVersion, state)
+		},
+	}
+	cmd.Flags().StringVar(&brVersion, "br.version", "", "BR version, defaults to the cluster version")
+	return cmd
+}
+
+// brStorage returns the BR storage URL of backup, which is a storage URL or
+// the name of a backup under backupsDir.
+func brStorage(backup, backupsDir string) (string, error) {
+	if strings.Contains(backup, "://") {
+		return backup, nil
+	}
+	if backup == "" || backup != filepath.Base(backup) || backup == "." || backup == ".." {
+		return "", fmt.Errorf("invalid backup name %q", backup)
+	}
+	dir := filepath.Join(backupsDir, backup)
+	if !utils.IsExist(dir) {
+		return "", fmt.Errorf("backup %q not found in %s", backup, prettifyUserPath(backupsDir))
+	}
+	return "local://" + dir, nil
+}
+
+// runBR runs `br <op> full` against the running playground, with the BR
+// download and progress reported on the progress UI.
+func runBR(out io.Writer, op, storage, brVersion string, state *cliState) error {
+	target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+	if err != nil {
+		printDisplayFailureWarning(out, err)
+		return renderedError{err: err}
+	}
+	ready, err := loadReadyFile(target)
+	if err != nil {
+		return err
+	}
+	if len(ready.PD) == 0 {
+		return fmt.Errorf("playground %q has no PD instance", target.tag)
+	}
+
+	backupsDir := filepath.Join(realDataDir(target.dir), playgroundBackupsDirName)
+	name := time.Now().Format(snapshotIDLayout)
+	switch {
+	case op == "restore":
+		if storage, err = brStorage(storage, backupsDir); err != nil {
+			return err
+		}
+	case storage == "":
+		storage = "local://" + filepath.Join(backupsDir, name)
+	}
+	if err := utils.MkdirAll(backupsDir, 0o755); err != nil {
+		return err
+	}
+	logPath := filepath.Join(backupsDir, fmt.Sprintf("br-%s-%s.log", op, name))
+
+	if brVersion == "" {
+		brVersion = ready.Version
+	}
+	if brVersion == "" {
+		brVersion = utils.LatestVersionAlias
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ui := progressv2.New(progressv2.Options{Mode: progressv2.ModeAuto, Out: os.Stderr})
+	defer ui.Close()
+	title := "Back up playground"
+	if op == "restore" {
+		title = "Restore playground"
+	}
+	group := ui.Group(title)
+
+	binPath, resolved, err := ensureComponent(ctx, group, brComponentID, brVersion, state.proxy)
+	if err != nil {
+		group.Close()
+		return err
+	}
+
+	task := group.Task("Full " + op)
+	task.SetMeta(storage)
+	task.SetTotal(10000)
+	task.Start()
+	args := []string{op, "full", "--pd", strings.Join(ready.PD, ","), "--storage", storage, "--log-file", logPath}
+	if ready.TLSCA != "" {
+		args = append(args, "--ca", ready.TLSCA, "--cert", ready.TLSCert, "--key", ready.TLSKey)
+	}
+	err = runBRCommand(ctx, binPath, args, func(percent float64) {
+		task.SetCurrent(int64(percent * 100))
+	})
+	if err != nil {
+		task.ErrorWithHint(err.Error(), "see "+prettifyUserPath(logPath))
+		group.Close()
+		return renderedError{err: err}
+	}
+	task.Done()
+	group.Close()
+	ui.Close()
+
+	if op == "backup" {
+		fmt.Fprintf(out, "Backup written to %s (BR %s)\n", storage, resolved)
+		if strings.HasPrefix(storage, "local://"+backupsDir) {
+			fmt.Fprintf(out, "Restore it into a fresh playground with: %s\n", playgroundCLICommand("restore --tag <tag> "+name))
+		}
+	} else {
+		fmt.Fprintf(out, "Restored %s (BR %s)\n", storage, resolved)
+	}
+	return nil
+}
+
+// ensureComponent resolves version of the component id (e.g. BR) and installs
+// it if needed, reporting the download in group.
+func ensureComponent(ctx context.Context, group *progressv2.Group, id, version, proxy string) (binPath, resolved string, err error) {
+	env, err := environment.InitEnv(repository.Options{}, repository.MirrorOptions{
+		Context:  ctx,
+		Progress: newRepoDownloadProgress(ctx, group),
+		Proxy:    proxy,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	defer func() { _ = env.Close() }()
+
+	v, err := resolveComponentVersion(env.V1Repository(), id, version)
+	if err != nil {
+		return "", "", errors.Annotatef(err, "resolve %s version %s", id, version)
+	}
+	if binPath, err = env.BinaryPath(id, v); err == nil && utils.IsExist(binPath) {
+		return binPath, v.String(), nil
+	}
+	spec := repository.ComponentSpec{ID: id, Version: v.String()}
+	if err := env.V1Repository().UpdateComponents([]repository.ComponentSpec{spec}); err != nil {
+		return "", "", errors.Annotatef(err, "install %s %s", id, v)
+	}
+	binPath, err = env.BinaryPath(id, v)
+	return binPath, v.String(), err
+}
+
+// runBRCommand runs BR and reports the percentage of its progress bar. On
+// failure, the error carries the last lines BR printed.
+func runBRCommand(ctx context.Context, binPath string, args []string, onProgress func(percent float64)) error {
+	c := exec.CommandContext(ctx, binPath, args...)
+	c.Cancel = func() error { return c.Process.Signal(os.Interrupt) }
+	c.WaitDelay = 10 * time.Second
+	pr, pw := io.Pipe()
+	c.Stdout, c.Stderr = pw, pw
+	if err := c.Start(); err != nil {
+		return errors.AddStack(err)
+	}
+
+	scanDone := make(chan []string)
+	go func() {
+		var tail []string
+		sc := bufio.NewScanner(pr)
+		// The progress bar redraws itself with '\r'.
+		sc.Split(scanLinesOrCR)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			if m := brProgressPattern.FindAllStringSubmatch(line, -1); m != nil {
+				if pct, err := strconv.ParseFloat(m[len(m)-1][1], 64); err == nil {
+					onProgress(pct)
+				}
+				continue
+			}
+			tail = append(tail, line)
+			if len(tail) > 5 {
+				tail = tail[1:]
+			}
+		}
+		_, _ = io.Copy(io.Discard, pr)
+		scanDone <- tail
+	}()
+
+	err := c.Wait()
+	_ = pw.Close()
+	tail := <-scanDone
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if len(tail) > 0 {
+			return errors.Errorf("br %s: %s\n%s", args[0], err, strings.Join(tail, "\n"))
+		}
+		return errors.Errorf("br %s: %s", args[0], err)
+	}
+	return nil
+}
+
+// scanLinesOrCR is a bufio.SplitFunc splitting on '\n' and '\r'.
+func scanLinesOrCR(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanLinesOrCR(t *testing.T) {
+	sc := bufio.NewScanner(strings.NewReader("start\nFull Backup 10.00%\rFull Backup 45.23%\r\ndone"))
+	sc.Split(scanLinesOrCR)
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	require.Equal(t, []string{"start", "Full Backup 10.00%", "Full Backup 45.23%", "", "done"}, lines)
+
+	m := brProgressPattern.FindAllStringSubmatch("Full Backup <---/....> 45.23%", -1)
+	require.Equal(t, "45.23", m[len(m)-1][1])
+}
+
+func TestBRStorage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "20260102-150405"), 0o755))
+
+	s, err := brStorage("20260102-150405", dir)
+	require.NoError(t, err)
+	require.Equal(t, "local://"+filepath.Join(dir, "20260102-150405"), s)
+
+	s, err = brStorage("s3://bucket/prefix", dir)
+	require.NoError(t, err)
+	require.Equal(t, "s3://bucket/prefix", s)
+
+	_, err = brStorage("missing", dir)
+	require.ErrorContains(t, err, "not found")
+	_, err = brStorage("../x", dir)
+	require.ErrorContains(t, err, "invalid backup name")
+}
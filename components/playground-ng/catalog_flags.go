@@ -45,6 +45,8 @@ func registerServiceFlags(flagSet *pflag.FlagSet, opts *BootOptions) {
 		binPathFlag := def.FlagPrefix + ".binpath"
 		timeoutFlag := def.FlagPrefix + ".timeout"
 		versionFlag := def.FlagPrefix + ".version"
+		dataDirFlag := def.FlagPrefix + ".data-dir"
+		logDirFlag := def.FlagPrefix + ".log-dir"
 
 		if def.AllowModifyNum {
 			flagSet.IntVar(&cfg.Num, countFlag, 0, displayName+" instance number")
@@ -67,6 +69,8 @@ func registerServiceFlags(flagSet *pflag.FlagSet, opts *BootOptions) {
 		if def.AllowModifyVersion {
 			flagSet.StringVar(&cfg.Version, versionFlag, "", displayName+" instance version (override)")
 		}
+		flagSet.StringVar(&cfg.DataDir, dataDirFlag, "", displayName+" data directory root (default: the playground data dir)")
+		flagSet.StringVar(&cfg.LogDir, logDirFlag, "", displayName+" log directory root (default: the instance data dir)")
 	}
 }
 
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/localdata"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// cloneSkipFiles are the runtime files, the snapshots and the crash
+// artifacts of the source playground, which are not copied to the clone.
+var cloneSkipFiles = map[string]bool{
+	playgroundSnapshotsDirName: true,
+	playgroundCrashesDirName:   true,
+	playgroundPIDFileName:      true,
+	playgroundPortFileName:     true,
+	playgroundReadyFileName:    true,
+	playgroundDaemonLogName:    true,
+	playgroundTUIEventLogName:  true,
+	playgroundNetemFileName:    true,
+}
+
+func newClone(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+	var noStart bool
+
+	cmd := &cobra.Command{
+		Use:   "clone <src-tag> <dst-tag>",
+		Short: "Copy a stopped playground to a new tag and start the copy",
+		Long: `Copy the data of a stopped playground, including the instance dirs moved
+out of its data dir with --<service>.data-dir, to a new tag, then start the
+copy in background with the same invocation (see show-config) and freshly
+allocated ports.
+
+Use it to branch a known good dataset for destructive experiments. Stop the
+source playground first; with --no-start, only the data is copied.`,
+		Example: fmt.Sprintf("%[1]s stop --tag golden\n%[1]s clone golden experiment-1", arg0),
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return clone(cmd.OutOrStdout(), args[0], args[1], noStart, state)
+		},
+	}
+	cmd.Flags().BoolVar(&noStart, "no-start", false, "Only copy the data, without starting the clone")
+	return cmd
+}
+
+func clone(out io.Writer, srcTag, dstTag string, noStart bool, state *cliState) error {
+	for _, tag := range []string{srcTag, dstTag} {
+		if tag == "" || tag != filepath.Base(tag) || tag == "." || tag == ".." {
+			return fmt.Errorf("invalid tag %q", tag)
+		}
+	}
+	dataParent := filepath.Join(state.tiupHome, localdata.DataParentDir)
+	srcDir := filepath.Join(dataParent, srcTag)
+	dstDir := filepath.Join(dataParent, dstTag)
+	if !utils.IsExist(srcDir) {
+		return fmt.Errorf("playground %q does not exist", srcTag)
+	}
+	if !state.ops.isPlaygroundStopped(srcDir) {
+		return fmt.Errorf("playground %q is running, stop it first: %s", srcTag, playgroundCLICommand("stop --tag "+srcTag))
+	}
+	if !noStart && !utils.IsExist(filepath.Join(srcDir, playgroundInvocationFileName)) {
+		return fmt.Errorf("playground %q has no recorded start invocation, clone it with --no-start", srcTag)
+	}
+
+	if err := clonePlaygroundData(srcDir, dstDir); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Playground %q cloned to %q\n", srcTag, dstTag)
+	if noStart {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	c := exec.Command(exe, "--tag", dstTag, "--like", srcTag, "--background")
+	// The starter renders its progress on the terminal.
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		// The starter printed the error already.
+		return renderedError{err: err}
+	}
+	return nil
+}
+
+// clonePlaygroundData copies the data dir of the stopped playground srcDir to
+// dstDir, leaving out its runtime files, along with the instance dirs that
+// live outside of it. The instance registry of the copy points to the copied
+// dirs.
+func clonePlaygroundData(srcDir, dstDir string) error {
+	srcTag, dstTag := filepath.Base(srcDir), filepath.Base(dstDir)
+	if _, err := os.Lstat(dstDir); err == nil {
+		return fmt.Errorf("tag %q is already in use", dstTag)
+	} else if !os.IsNotExist(err) {
+		return errors.AddStack(err)
+	}
+	if utils.IsExist(filepath.Join(srcDir, playgroundOperationFileName)) {
+		return fmt.Errorf("playground %q was killed during a scale-out, restart it with --interrupted-op first", srcTag)
+	}
+
+	records := readInstanceRegistry(srcDir)
+	// External dirs are laid out as <dir>/<tag>/<service>-<id> (see
+	// instanceDirs): the copy goes to <dir>/<dst-tag>/<service>-<id>.
+	external := make(map[string]string)
+	for _, r := range records {
+		for _, dir := range []string{r.Dir, r.LogDir} {
+			if dir == "" || utils.IsSubDir(srcDir, dir) {
+				continue
+			}
+			dst := filepath.Join(filepath.Dir(filepath.Dir(dir)), dstTag, filepath.Base(dir))
+			if utils.IsExist(dst) {
+				return fmt.Errorf("instance dir %s already exists", dst)
+			}
+			// Dirs removed since are mapped too, so the clone never owns a
+			// dir of the source.
+			external[dir] = dst
+		}
+	}
+
+	if _, err := copyDataTree(srcDir, dstDir, cloneSkipFiles); err != nil {
+		return err
+	}
+	for src, dst := range external {
+		if !utils.IsExist(src) || isUnderAny(src, external) {
+			continue
+		}
+		if _, err := copyDataTree(src, dst, nil); err != nil {
+			return err
+		}
+	}
+
+	if records == nil {
+		return nil
+	}
+	for i, r := range records {
+		records[i].Dir = cloneInstanceDir(r.Dir, srcDir, dstDir, external)
+		records[i].LogDir = cloneInstanceDir(r.LogDir, srcDir, dstDir, external)
+		// The processes of the source are none of the clone's business.
+		records[i].PID = 0
+		records[i].StartTime = 0
+	}
+	return writeInstanceRecords(dstDir, records)
+}
+
+// isUnderAny reports whether dir is inside another dir of dirs, which is
+// copied along with it.
+func isUnderAny(dir string, dirs map[string]string) bool {
+	for d := range dirs {
+		if d != dir && utils.IsSubDir(d, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneInstanceDir(dir, srcDir, dstDir string, external map[string]string) string {
+	if dst, ok := external[dir]; ok {
+		return dst
+	}
+	return rebaseDir(dir, srcDir, dstDir)
+}
+
+// copyDataTree copies the dir src to dst, keeping file modes and symlinks,
+// and returns the size of the files copied. The top-level files named in skip
+// are left out, and so are special files such as sockets.
+func copyDataTree(src, dst string, skip map[string]bool) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if skip[rel] {
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			size += info.Size()
+			return utils.Copy(path, target)
+		default:
+			return nil
+		}
+	})
+	return size, err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClonePlaygroundData(t *testing.T) {
+	base := t.TempDir()
+	srcDir := filepath.Join(base, "data", "golden")
+	dstDir := filepath.Join(base, "data", "experiment")
+	extDir := filepath.Join(base, "nvme", "golden", "tikv-0")
+
+	for path, content := range map[string]string{
+		filepath.Join(srcDir, playgroundPIDFileName):        "pid=1\n",
+		filepath.Join(srcDir, playgroundReadyFileName):      "{}",
+		filepath.Join(srcDir, playgroundInvocationFileName): "version: v8.5.0\n",
+		filepath.Join(srcDir, "pd-0", "data", "member"):     "pd",
+		filepath.Join(extDir, "db", "CURRENT"):              "MANIFEST-000001",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	require.NoError(t, os.Symlink("member", filepath.Join(srcDir, "pd-0", "data", "link")))
+	require.NoError(t, writeInstanceRecords(srcDir, []instanceRecord{
+		{Name: "pd-0", Service: "pd", Dir: filepath.Join(srcDir, "pd-0"), PID: 42, StartTime: 1},
+		{Name: "tikv-0", Service: "tikv", Dir: extDir, PID: 43, StartTime: 2},
+	}))
+
+	require.NoError(t, clonePlaygroundData(srcDir, dstDir))
+
+	data, err := os.ReadFile(filepath.Join(dstDir, "pd-0", "data", "member"))
+	require.NoError(t, err)
+	require.Equal(t, "pd", string(data))
+	link, err := os.Readlink(filepath.Join(dstDir, "pd-0", "data", "link"))
+	require.NoError(t, err)
+	require.Equal(t, "member", link)
+	require.FileExists(t, filepath.Join(dstDir, playgroundInvocationFileName))
+	require.NoFileExists(t, filepath.Join(dstDir, playgroundPIDFileName))
+	require.NoFileExists(t, filepath.Join(dstDir, playgroundReadyFileName))
+
+	dstExt := filepath.Join(base, "nvme", "experiment", "tikv-0")
+	require.FileExists(t, filepath.Join(dstExt, "db", "CURRENT"))
+	require.Equal(t, []instanceRecord{
+		{Name: "pd-0", Service: "pd", Dir: filepath.Join(dstDir, "pd-0")},
+		{Name: "tikv-0", Service: "tikv", Dir: dstExt},
+	}, readInstanceRegistry(dstDir))
+
+	// The source is left as is, and the tag can't be cloned onto twice.
+	require.Equal(t, 42, readInstanceRegistry(srcDir)[0].PID)
+	require.ErrorContains(t, clonePlaygroundData(srcDir, dstDir), `tag "experiment" is already in use`)
+}
+
+func TestClonePlaygroundData_RejectsInterruptedOperation(t *testing.T) {
+	base := t.TempDir()
+	srcDir := filepath.Join(base, "golden")
+	require.NoError(t, os.MkdirAll(srcDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, playgroundOperationFileName), []byte("{}"), 0o644))
+
+	err := clonePlaygroundData(srcDir, filepath.Join(base, "experiment"))
+	require.ErrorContains(t, err, "killed during a scale-out")
+	require.NoDirExists(t, filepath.Join(base, "experiment"))
+}
@@ -16,10 +16,14 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	stdErrors "errors"
 	"fmt"
 	"io"
+	"mime"
 	"net"
 	"net/http"
 	"os"
@@ -33,48 +37,25 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/components/playground-ng/proc"
 	pgservice "github.com/pingcap/tiup/components/playground-ng/service"
+	"github.com/pingcap/tiup/pkg/localdata"
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
 	"github.com/pingcap/tiup/pkg/tui/colorstr"
+	"github.com/pingcap/tiup/pkg/utils"
 	"github.com/spf13/cobra"
 
 	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
 )
 
-type playgroundNotRunningError struct {
-	err error
-}
-
-func (e playgroundNotRunningError) Error() string {
-	if e.err == nil {
-		return "no playground running"
-	}
-	return e.err.Error()
-}
-
-func (e playgroundNotRunningError) Unwrap() error { return e.err }
-
-func isPlaygroundNotRunning(err error) bool {
-	var notRunning playgroundNotRunningError
-	return stdErrors.As(err, &notRunning)
-}
-
-type playgroundUnreachableError struct {
-	err error
-}
-
-func (e playgroundUnreachableError) Error() string {
-	if e.err == nil {
-		return "playground is unreachable"
-	}
-	return e.err.Error()
-}
-
-func (e playgroundUnreachableError) Unwrap() error { return e.err }
+type (
+	playgroundNotRunningError  = client.NotRunningError
+	playgroundUnreachableError = client.UnreachableError
+)
 
 func shouldSuggestPlaygroundNotRunning(err error) bool {
 	if err == nil {
 		return false
 	}
-	if isPlaygroundNotRunning(err) {
+	if client.IsNotRunning(err) {
 		return true
 	}
 	// "Connection refused" for the local HTTP command server is a strong signal
@@ -82,34 +63,41 @@ func shouldSuggestPlaygroundNotRunning(err error) bool {
 	return stdErrors.Is(err, syscall.ECONNREFUSED)
 }
 
-// CommandType send to playground.
-type CommandType string
+// The wire types of the command server, shared with its client package.
+type (
+	CommandType      = client.CommandType
+	DisplayRequest   = client.DisplayRequest
+	ScaleInRequest   = client.ScaleInRequest
+	RetagRequest     = client.RetagRequest
+	SnapshotRequest  = client.SnapshotRequest
+	CommandRef       = client.CommandRef
+	LogsRequest      = client.LogsRequest
+	CommandReply     = client.Reply
+	TopologyChange   = client.TopologyChange
+	TopologyInstance = client.TopologyInstance
+)
 
 // types of CommandType
 const (
-	ScaleInCommandType  CommandType = "scale-in"
-	ScaleOutCommandType CommandType = "scale-out"
-	DisplayCommandType  CommandType = "display"
-	StopCommandType     CommandType = "stop"
+	ScaleInCommandType       = client.ScaleInCommandType
+	ScaleOutCommandType      = client.ScaleOutCommandType
+	DisplayCommandType       = client.DisplayCommandType
+	StopCommandType          = client.StopCommandType
+	RetagCommandType         = client.RetagCommandType
+	SnapshotCommandType      = client.SnapshotCommandType
+	CancelCommandType        = client.CancelCommandType
+	CommandStatusCommandType = client.CommandStatusCommandType
+	LogsCommandType          = client.LogsCommandType
 )
 
-// DisplayRequest is the request payload for the "display" command.
-type DisplayRequest struct {
-	Verbose bool `json:"verbose,omitempty"`
-	JSON    bool `json:"json,omitempty"`
-}
-
-// ScaleInRequest is the request payload for the "scale-in" command.
-type ScaleInRequest struct {
-	Name string `json:"name,omitempty"`
-	PID  int    `json:"pid,omitempty"`
-}
-
 // ScaleOutRequest is the request payload for the "scale-out" command.
 type ScaleOutRequest struct {
 	ServiceID proc.ServiceID `json:"service"`
 	Count     int            `json:"count"`
 	Config    proc.Config    `json:"config"`
+	// Wait selects what to wait for once the new instances are started. Empty
+	// means pgservice.ScaleOutWaitNone.
+	Wait pgservice.ScaleOutWait `json:"wait,omitempty"`
 }
 
 // Command sends a request to a running playground via its HTTP control server.
@@ -118,14 +106,47 @@ type Command struct {
 	Display  *DisplayRequest  `json:"display,omitempty"`
 	ScaleIn  *ScaleInRequest  `json:"scale_in,omitempty"`
 	ScaleOut *ScaleOutRequest `json:"scale_out,omitempty"`
+	Retag    *RetagRequest    `json:"retag,omitempty"`
+	Snapshot *SnapshotRequest `json:"snapshot,omitempty"`
+	Command  *CommandRef      `json:"command,omitempty"`
+	Logs     *LogsRequest     `json:"logs,omitempty"`
+	// Deadline, if set, bounds the command (see client.Command).
+	Deadline *time.Time `json:"deadline,omitempty"`
 }
 
-// CommandReply is the (optional) structured response returned by the playground
-// command server when the client asks for JSON output.
-type CommandReply struct {
-	OK      bool   `json:"ok"`
-	Message string `json:"message,omitempty"`
-	Error   string `json:"error,omitempty"`
+// clientCommand converts c to the request of the client package, which
+// carries the scale-out config without the proc types.
+func (c Command) clientCommand() client.Command {
+	out := client.Command{
+		Type:     c.Type,
+		Display:  c.Display,
+		ScaleIn:  c.ScaleIn,
+		Retag:    c.Retag,
+		Snapshot: c.Snapshot,
+		Command:  c.Command,
+		Logs:     c.Logs,
+		Deadline: c.Deadline,
+	}
+	if req := c.ScaleOut; req != nil {
+		cfg := req.Config
+		out.ScaleOut = &client.ScaleOutRequest{
+			Service: req.ServiceID.String(),
+			Count:   req.Count,
+			Config: client.InstanceConfig{
+				ConfigPath: cfg.ConfigPath,
+				BinPath:    cfg.BinPath,
+				Num:        cfg.Num,
+				Host:       cfg.Host,
+				Port:       cfg.Port,
+				UpTimeout:  cfg.UpTimeout,
+				Version:    cfg.Version,
+				DataDir:    cfg.DataDir,
+				LogDir:     cfg.LogDir,
+			},
+			Wait: string(req.Wait),
+		}
+	}
+	return out
 }
 
 // cliState holds process-level CLI state for both "tiup playground-ng" (boot) and
@@ -152,113 +173,68 @@ type cliState struct {
 
 	dryRun       bool
 	dryRunOutput string
+
+	// proxy is an explicit proxy URL for component downloads. Loopback probes
+	// and command-server requests are never proxied.
+	proxy string
+
+	// interruptedOp selects what to do with a scale-out the previous
+	// playground on the same data dir was killed in the middle of.
+	interruptedOp string
+
+	// profile names the profile to start with (see applyProfile), and like
+	// the recorded invocation (see startInvocation). presetVersion is the
+	// cluster version either sets.
+	profile        string
+	like           string
+	likeInvocation *startInvocation
+	presetVersion  string
+
+	tiupHome string
+
+	// ops are the clock and process operations of the commands.
+	ops runtimeOps
 }
 
 func newCLIState() *cliState {
-	return &cliState{options: BootOptions{Monitor: true}}
+	return &cliState{options: BootOptions{Monitor: true}, ops: newRuntimeOps()}
 }
 
+// resolvePlaygroundTarget resolves the playground a command controls, see
+// client.ResolveTarget. When several are running and none is named, the user
+// picks one in an interactive terminal.
 func resolvePlaygroundTarget(explicitTag, tiupDataDir, dataDir string) (playgroundTarget, error) {
-	// If the caller provides an explicit target (tag or TIUP_INSTANCE_DATA_DIR),
-	// do not guess.
-	if explicitTag != "" || tiupDataDir != "" {
-		port, err := loadPort(dataDir)
-		if err != nil {
-			tag := explicitTag
-			if tag == "" {
-				tag = filepath.Base(dataDir)
-			}
-			return playgroundTarget{}, playgroundNotRunningError{err: errors.Annotatef(err, "no playground running for tag %q", tag)}
-		}
-		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-		defer cancel()
-		ok, probeErr := probePlaygroundCommandServer(ctx, port)
-		if ok && probeErr == nil {
-			tag := explicitTag
-			if tag == "" {
-				tag = filepath.Base(dataDir)
-			}
-			return playgroundTarget{tag: tag, dir: dataDir, port: port}, nil
-		}
-
-		tag := explicitTag
-		if tag == "" {
-			tag = filepath.Base(dataDir)
-		}
-		switch {
-		case isTimeoutErr(probeErr):
-			return playgroundTarget{}, playgroundUnreachableError{err: errors.Annotatef(probeErr, "probe playground %q command server timed out (port=%d)", tag, port)}
-		case stdErrors.Is(probeErr, syscall.ECONNREFUSED):
-			return playgroundTarget{}, playgroundNotRunningError{err: errors.Errorf("no playground running for tag %q", tag)}
-		default:
-			return playgroundTarget{}, playgroundUnreachableError{err: errors.Annotatef(probeErr, "probe playground %q command server (port=%d)", tag, port)}
-		}
-	}
-
-	baseDir := dataDir
-	if baseDir == "" {
-		return playgroundTarget{}, playgroundNotRunningError{err: errors.Errorf("no playground running")}
-	}
-
-	targets, err := listPlaygroundTargets(baseDir)
+	t, err := client.ResolveTarget(explicitTag, tiupDataDir, dataDir)
 	if err != nil {
-		return playgroundTarget{}, errors.AddStack(err)
-	}
-	if len(targets) == 0 {
-		return playgroundTarget{}, playgroundNotRunningError{err: errors.Errorf("no playground running")}
+		return pickAmongPlaygrounds(err)
 	}
-	if len(targets) == 1 {
-		// Single running playground: implicit selection is unambiguous.
-		return targets[0], nil
-	}
-
-	var items []string
-	for _, t := range targets {
-		items = append(items, fmt.Sprintf("%s(%d)", t.tag, t.port))
-	}
-	slices.Sort(items)
-	return playgroundTarget{}, errors.Errorf("multiple playgrounds found: %s; please specify --tag", strings.Join(items, ", "))
+	return newPlaygroundTarget(t), nil
 }
 
 type playgroundTarget struct {
 	tag  string
 	dir  string
 	port int
+	// token is sent to the command server, see client.ReadToken.
+	token string
 }
 
-func listPlaygroundTargets(baseDir string) ([]playgroundTarget, error) {
-	entries, err := os.ReadDir(baseDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, errors.AddStack(err)
-	}
+func newPlaygroundTarget(t client.Target) playgroundTarget {
+	return playgroundTarget{tag: t.Tag, dir: t.Dir, port: t.Port, token: t.Token}
+}
 
-	var out []playgroundTarget
-	for _, ent := range entries {
-		if !ent.IsDir() {
-			continue
-		}
-		dir := filepath.Join(baseDir, ent.Name())
-		port, err := loadPort(dir)
-		if err != nil || port <= 0 {
-			continue
-		}
+// client returns a client of the command server of t.
+func (t playgroundTarget) client() *client.Client {
+	return client.New(client.Target{Port: t.port}.Addr()).WithToken(t.token)
+}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-		ok, probeErr := probePlaygroundCommandServer(ctx, port)
-		cancel()
-		if ok && probeErr == nil {
-			out = append(out, playgroundTarget{tag: ent.Name(), dir: dir, port: port})
-			continue
-		}
+func listPlaygroundTargets(baseDir string) ([]playgroundTarget, error) {
+	targets, err := client.ListTargets(baseDir)
+	out := make([]playgroundTarget, 0, len(targets))
+	for _, t := range targets {
+		out = append(out, newPlaygroundTarget(t))
 	}
-
-	slices.SortStableFunc(out, func(a, b playgroundTarget) int {
-		return strings.Compare(a.tag, b.tag)
-	})
-	return out, nil
+	return out, err
 }
 
 func scaleOutServiceIDs() []proc.ServiceID {
@@ -281,6 +257,7 @@ func newScaleOut(state *cliState) *cobra.Command {
 	var services []string
 	var counts []int
 	var cfg proc.Config
+	var waitFlag string
 	var legacy *legacyScaleOutFlags
 
 	supported := scaleOutServiceIDs()
@@ -298,6 +275,11 @@ func newScaleOut(state *cliState) *cobra.Command {
 		Short:   "Scale out instances in a running playground",
 		Example: fmt.Sprintf("%s scale-out --service tidb --count 1", arg0),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			wait, err := pgservice.ParseScaleOutWait(waitFlag)
+			if err != nil {
+				return err
+			}
+
 			var reqs []ScaleOutRequest
 			switch {
 			case len(services) > 0:
@@ -330,7 +312,6 @@ func newScaleOut(state *cliState) *cobra.Command {
 					})
 				}
 			default:
-				var err error
 				reqs, err = legacy.requests()
 				if err != nil {
 					return err
@@ -339,6 +320,9 @@ func newScaleOut(state *cliState) *cobra.Command {
 					return cmd.Help()
 				}
 			}
+			for i := range reqs {
+				reqs[i].Wait = wait
+			}
 
 			num, err := scaleOut(cmd.OutOrStdout(), reqs, state)
 			if err != nil {
@@ -361,6 +345,7 @@ func newScaleOut(state *cliState) *cobra.Command {
 	cmd.Flags().StringVar(&cfg.ConfigPath, "config", "", "Config file for new instances (default: inherit from boot config)")
 	cmd.Flags().StringVar(&cfg.BinPath, "binpath", "", "Binary path for new instances (default: inherit from boot config)")
 	cmd.Flags().IntVar(&cfg.UpTimeout, "timeout", 0, "Max wait time in seconds for starting, 0 means no limit")
+	cmd.Flags().StringVar(&waitFlag, "wait", string(pgservice.ScaleOutWaitUp), "What to wait for after new TiKV instances start: none, up (store is up in PD), balance (store received regions)")
 
 	// LEGACY: tiup playground-ng scale-out --db 1 --kv 2 ...
 	legacy = registerLegacyScaleOutFlags(cmd)
@@ -422,25 +407,49 @@ func newScaleIn(state *cliState) *cobra.Command {
 	return cmd
 }
 
+// tableOutputPlain is the --output value of the commands printing a table
+// that prints tab separated values instead, see tuiv2output.Table.
+const tableOutputPlain = "plain"
+
+// parseTableOutput validates the --output flag and reports whether it asks
+// for plain output.
+func parseTableOutput(output string) (bool, error) {
+	switch output {
+	case "", "table":
+		return false, nil
+	case tableOutputPlain:
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown output format %q, expect table or plain", output)
+}
+
 func newDisplay(state *cliState) *cobra.Command {
-	var verbose bool
-	var jsonOut bool
+	var req DisplayRequest
+	var output string
 	cmd := &cobra.Command{
 		Use:    "display",
 		Short:  "Display instances in the running playground",
 		Hidden: false,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := display(cmd.OutOrStdout(), verbose, jsonOut, state); err != nil {
+			plain, err := parseTableOutput(output)
+			if err != nil {
+				return err
+			}
+			req.Plain = plain
+			req.Width = tuiv2output.FitWidth(cmd.OutOrStdout())
+			if err := display(cmd.OutOrStdout(), req, state); err != nil {
 				return err
 			}
-			if !verbose && !jsonOut {
-				colorstr.Fprintf(tuiv2output.Stderr.Get(), "\n[dim]Tip: use --verbose to show more columns: COMPONENT, PID, VERSION, BINARY, LOG[reset]\n")
+			if !req.Verbose && !req.Wide && !req.JSON && !req.Plain {
+				colorstr.Fprintf(tuiv2output.Stderr.Get(), "\n[dim]Tip: use --verbose to show more columns: COMPONENT, PID, VERSION, BINARY, LOG; --wide for ports, data dirs and CPU/memory usage[reset]\n")
 			}
 			return nil
 		},
 	}
-	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show more details for each instance")
-	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output in JSON format")
+	cmd.Flags().BoolVarP(&req.Verbose, "verbose", "v", false, "Show more details for each instance")
+	cmd.Flags().BoolVarP(&req.Wide, "wide", "w", false, "Also show the client port, status port, data dir and CPU/memory usage of each instance, without clipping long values")
+	cmd.Flags().BoolVar(&req.JSON, "json", false, "Output in JSON format")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format of the table: table, or plain for tab separated values")
 	return cmd
 }
 
@@ -464,6 +473,270 @@ func newStop(state *cliState) *cobra.Command {
 	return cmd
 }
 
+func newRetag(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+
+	cmd := &cobra.Command{
+		Use:   "retag <new-tag>",
+		Short: "Rename the tag of a playground",
+		Long: `Rename the tag of a playground, moving its data dir to the new tag.
+
+A running playground keeps running: commands are held while its data dir
+moves, and later commands must use the new tag.`,
+		Example: fmt.Sprintf("%s retag --tag 3kQ8zX my-cluster", arg0),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return retag(cmd.OutOrStdout(), args[0], state)
+		},
+	}
+	return cmd
+}
+
+func newShowConfig(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+
+	cmd := &cobra.Command{
+		Use:   "show-config [tag]",
+		Short: "Show the recorded start invocation of a playground",
+		Long: `Show the fully resolved start invocation of a playground: its flags
+(including those set by a profile), the contents of its config files, the
+component versions it runs and the config values its tuning flags put in
+effect.
+
+Start an identical playground with --like <tag>, or save the output to a file
+and pass the file to --like on another machine.`,
+		Example: fmt.Sprintf("%[1]s show-config my-cluster > my-cluster.yaml\n%[1]s --like my-cluster.yaml", arg0),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tag, dataDir := state.tag, state.dataDir
+			if len(args) > 0 {
+				tag = args[0]
+				dataDir = filepath.Join(state.tiupHome, localdata.DataParentDir, tag)
+			} else if tag == "" && state.tiupDataDir == "" {
+				return fmt.Errorf("specify the tag of a playground")
+			}
+			data, err := os.ReadFile(filepath.Join(dataDir, playgroundInvocationFileName))
+			if os.IsNotExist(err) {
+				return fmt.Errorf("playground %q has no recorded start invocation", tag)
+			}
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+	return cmd
+}
+
+func retag(out io.Writer, newTag string, state *cliState) error {
+	target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+	if client.IsNotRunning(err) && state.tag != "" && utils.IsExist(state.dataDir) {
+		// A stopped playground only needs its data dir moved.
+		if err := state.ops.cleanupStaleRuntimeFiles(state.dataDir); err != nil {
+			return err
+		}
+		if _, err := retagDataDir(state.dataDir, newTag, false); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Playground %q retagged to %q\n", state.tag, newTag)
+		return nil
+	}
+	if err != nil {
+		printDisplayFailureWarning(out, err)
+		return renderedError{err: err}
+	}
+
+	c := Command{Type: RetagCommandType, Retag: &RetagRequest{Tag: newTag}}
+	if err := sendCommandsAndPrintResult(out, []Command{c}, target.client()); err != nil {
+		printDisplayFailureWarning(out, err)
+		return renderedError{err: err}
+	}
+	return nil
+}
+
+func newWait(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+
+	var until string
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "wait",
+		Short: "Wait until a playground is ready or stopped",
+		Long: `Block until the playground reaches the state given by --for:
+
+  ready    all instances are ready and the command server answers
+           (the ready.json file exists in the data dir)
+  stopped  the playground process has exited
+
+The command fails if the timeout elapses first, or if the playground exits
+while waiting for it to be ready.`,
+		Example: fmt.Sprintf("%s --tag ci -d\n%s wait --tag ci --for ready --timeout 120s", arg0, arg0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return wait(cmd.OutOrStdout(), playgroundWaitState(until), timeout, state)
+		},
+	}
+	cmd.Flags().StringVar(&until, "for", string(playgroundWaitReady), "State to wait for: ready or stopped")
+	cmd.Flags().DurationVar(&timeout, "timeout", 120*time.Second, "Max wait time")
+	return cmd
+}
+
+func newEnv(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Print shell exports of the endpoints of a running playground",
+		Long: `Print the endpoints of a running playground as shell exports:
+
+  TIDB_HOST, TIDB_PORT, TIDB_DSN  a single TiDB endpoint: the load balancer
+                                  if started with --db.lb, else the first TiDB
+  TIDB_DSNS                       the DSNs of all TiDB instances
+  TIPROXY_DSN                     the first TiProxy, if any
+  PD_ADDRS                        the PD addresses
+
+Lists are comma separated. The endpoints are those of the ready file, i.e.
+the instances that were ready at boot.`,
+		Example: fmt.Sprintf("eval \"$(%s env --tag my-cluster)\"", arg0),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printEnv(cmd.OutOrStdout(), state)
+		},
+	}
+	return cmd
+}
+
+func printEnv(out io.Writer, state *cliState) error {
+	target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+	if err != nil {
+		printDisplayFailureWarning(out, err)
+		return renderedError{err: err}
+	}
+	ready, err := loadReadyFile(target)
+	if err != nil {
+		return err
+	}
+	writeEnv(out, ready)
+	return nil
+}
+
+// loadReadyFile reads the ready file of the running playground target.
+func loadReadyFile(target playgroundTarget) (*playgroundReady, error) {
+	data, err := os.ReadFile(filepath.Join(target.dir, playgroundReadyFileName))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("playground %q is not ready yet", target.tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ready playgroundReady
+	if err := json.Unmarshal(data, &ready); err != nil {
+		return nil, errors.Annotatef(err, "parse %s", playgroundReadyFileName)
+	}
+	return &ready, nil
+}
+
+func writeEnv(out io.Writer, ready *playgroundReady) {
+	endpoint := ready.TiDBLB
+	if endpoint == "" && len(ready.TiDB) > 0 {
+		endpoint = ready.TiDB[0]
+	}
+	if host, port, err := net.SplitHostPort(endpoint); err == nil {
+		fmt.Fprintf(out, "export TIDB_HOST=%s\n", host)
+		fmt.Fprintf(out, "export TIDB_PORT=%s\n", port)
+		fmt.Fprintf(out, "export TIDB_DSN=%s\n", shellQuote(tidbDSN(endpoint, ready.RootPassword)))
+	}
+	if ready.RootPassword != "" {
+		fmt.Fprintf(out, "export TIDB_PASSWORD=%s\n", shellQuote(ready.RootPassword))
+	}
+	if len(ready.TiDB) > 0 {
+		fmt.Fprintf(out, "export TIDB_DSNS=%s\n", shellQuote(strings.Join(tidbDSNs(ready.TiDB, ready.RootPassword), ",")))
+	}
+	if len(ready.TiProxy) > 0 {
+		fmt.Fprintf(out, "export TIPROXY_DSN=mysql://root@%s\n", ready.TiProxy[0])
+	}
+	if len(ready.PD) > 0 {
+		fmt.Fprintf(out, "export PD_ADDRS=%s\n", strings.Join(ready.PD, ","))
+	}
+	if ready.TLSCA != "" {
+		fmt.Fprintf(out, "export TLS_CA=%s\n", ready.TLSCA)
+		fmt.Fprintf(out, "export TLS_CERT=%s\n", ready.TLSCert)
+		fmt.Fprintf(out, "export TLS_KEY=%s\n", ready.TLSKey)
+	}
+}
+
+// shellQuote returns s single-quoted for a POSIX shell, unless it has only
+// characters the shell takes literally.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./_-") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func newCancel(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+
+	cmd := &cobra.Command{
+		Use:   "cancel <command-id>",
+		Short: "Cancel a queued or running command of a playground",
+		Long: `Cancel a command sent to a running playground, e.g. a stuck scale-out.
+
+A queued command is dropped. A running command stops at its next
+interruptible step: a scale-out stops before starting its next instance, or
+while waiting for started instances to join the cluster. Instances already
+started keep running. Use command-status to find the command ID.`,
+		Example: fmt.Sprintf("%s command-status --tag my-cluster\n%s cancel --tag my-cluster 3", arg0, arg0),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil || id == 0 {
+				return fmt.Errorf("invalid command id %q", args[0])
+			}
+			return sendQueueCommand(cmd.OutOrStdout(), Command{Type: CancelCommandType, Command: &CommandRef{ID: id}}, state)
+		},
+	}
+	return cmd
+}
+
+func newCommandStatus(state *cliState) *cobra.Command {
+	var jsonOut bool
+	cmd := &cobra.Command{
+		Use:   "command-status [command-id]",
+		Short: "Show the status of commands sent to a playground",
+		Long: `Show the status of the commands sent to a running playground (queued,
+running, done, failed or canceled). Without an ID, all recent commands are
+listed.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref := &CommandRef{JSON: jsonOut}
+			if len(args) == 1 {
+				id, err := strconv.ParseUint(args[0], 10, 64)
+				if err != nil || id == 0 {
+					return fmt.Errorf("invalid command id %q", args[0])
+				}
+				ref.ID = id
+			}
+			return sendQueueCommand(cmd.OutOrStdout(), Command{Type: CommandStatusCommandType, Command: ref}, state)
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output in JSON format")
+	return cmd
+}
+
+func sendQueueCommand(out io.Writer, c Command, state *cliState) error {
+	target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+	if err != nil {
+		printDisplayFailureWarning(out, err)
+		return renderedError{err: err}
+	}
+	if err := sendCommandsAndPrintResult(out, []Command{c}, target.client()); err != nil {
+		printDisplayFailureWarning(out, err)
+		return renderedError{err: err}
+	}
+	return nil
+}
+
 func scaleIn(out io.Writer, reqs []ScaleInRequest, state *cliState) error {
 	target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
 	if err != nil {
@@ -484,8 +757,7 @@ func scaleIn(out io.Writer, reqs []ScaleInRequest, state *cliState) error {
 		cmds = append(cmds, c)
 	}
 
-	addr := "127.0.0.1:" + strconv.Itoa(target.port)
-	if err := sendCommandsAndPrintResult(out, cmds, addr); err != nil {
+	if err := sendCommandsAndPrintResult(out, cmds, target.client()); err != nil {
 		printDisplayFailureWarning(out, err)
 		return renderedError{err: err}
 	}
@@ -512,15 +784,14 @@ func scaleOut(out io.Writer, reqs []ScaleOutRequest, state *cliState) (num int,
 		})
 	}
 
-	addr := "127.0.0.1:" + strconv.Itoa(target.port)
-	if err := sendCommandsAndPrintResult(out, cmds, addr); err != nil {
+	if err := sendCommandsAndPrintResult(out, cmds, target.client()); err != nil {
 		printDisplayFailureWarning(out, err)
 		return 0, renderedError{err: err}
 	}
 	return len(cmds), nil
 }
 
-func display(out io.Writer, verbose, jsonOut bool, state *cliState) error {
+func display(out io.Writer, req DisplayRequest, state *cliState) error {
 	target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
 	if err != nil {
 		printDisplayFailureWarning(out, err)
@@ -528,11 +799,10 @@ func display(out io.Writer, verbose, jsonOut bool, state *cliState) error {
 	}
 	c := Command{
 		Type:    DisplayCommandType,
-		Display: &DisplayRequest{Verbose: verbose, JSON: jsonOut},
+		Display: &req,
 	}
 
-	addr := "127.0.0.1:" + strconv.Itoa(target.port)
-	if err := sendCommandsAndPrintResult(out, []Command{c}, addr); err != nil {
+	if err := sendCommandsAndPrintResult(out, []Command{c}, target.client()); err != nil {
 		printDisplayFailureWarning(out, err)
 		return renderedError{err: err}
 	}
@@ -546,13 +816,12 @@ func stop(out io.Writer, timeout time.Duration, state *cliState) error {
 		return renderedError{err: err}
 	}
 
-	addr := "127.0.0.1:" + strconv.Itoa(target.port)
-	if err := sendCommandsAndPrintResult(out, []Command{{Type: StopCommandType}}, addr); err != nil {
+	if err := sendCommandsAndPrintResult(out, []Command{{Type: StopCommandType}}, target.client()); err != nil {
 		printDisplayFailureWarning(out, err)
 		return renderedError{err: err}
 	}
 
-	if err := waitPlaygroundStopped(target.dir, timeout); err != nil {
+	if err := state.ops.waitPlayground(target.dir, playgroundWaitStopped, timeout); err != nil {
 		if out == nil {
 			out = io.Discard
 		}
@@ -566,6 +835,42 @@ func stop(out io.Writer, timeout time.Duration, state *cliState) error {
 	return nil
 }
 
+func wait(out io.Writer, until playgroundWaitState, timeout time.Duration, state *cliState) error {
+	if until != playgroundWaitReady && until != playgroundWaitStopped {
+		return fmt.Errorf("invalid --for %q: must be %q or %q", until, playgroundWaitReady, playgroundWaitStopped)
+	}
+
+	tag, dir := state.tag, state.dataDir
+	if tag == "" && state.tiupDataDir == "" {
+		// Without an explicit target, only waiting for the single running
+		// playground to stop is unambiguous.
+		if until == playgroundWaitReady {
+			return fmt.Errorf("please specify --tag of the playground to wait for")
+		}
+		target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+		if client.IsNotRunning(err) {
+			return nil
+		}
+		if err != nil {
+			printDisplayFailureWarning(out, err)
+			return renderedError{err: err}
+		}
+		tag, dir = target.tag, target.dir
+	}
+
+	if err := state.ops.waitPlayground(dir, until, timeout); err != nil {
+		if out == nil {
+			out = io.Discard
+		}
+		fmt.Fprint(out, tuiv2output.Callout{
+			Style:   tuiv2output.CalloutFailed,
+			Content: fmt.Sprintf("Wait for playground %q: %v", tag, err),
+		}.Render(out))
+		return renderedError{err: err}
+	}
+	return nil
+}
+
 func printDisplayFailureWarning(out io.Writer, err error) {
 	if err == nil || out == nil {
 		return
@@ -583,68 +888,81 @@ func printDisplayFailureWarning(out io.Writer, err error) {
 	}.Render(out))
 }
 
-func sendCommandsAndPrintResult(out io.Writer, cmds []Command, addr string) error {
+// defaultCommandTimeout bounds a command round trip, on both the client and
+// the command server side.
+const defaultCommandTimeout = 30 * time.Second
+
+// snapshotCommandTimeout bounds the "snapshot" command, which copies the data
+// of every instance.
+const snapshotCommandTimeout = 10 * time.Minute
+
+// stopCommandTimeout bounds the streamed reply of the "stop" command, which
+// lasts until every instance quit, force killed after forceKillAfterDuration.
+const stopCommandTimeout = forceKillAfterDuration + defaultCommandTimeout
+
+// commandTimeout returns how long the client waits for the reply to cmd.
+func commandTimeout(cmd *Command) time.Duration {
+	timeout := defaultCommandTimeout
+	switch cmd.Type {
+	case SnapshotCommandType:
+		return snapshotCommandTimeout
+	case StopCommandType:
+		return stopCommandTimeout
+	}
+	if cmd.Type == ScaleOutCommandType && cmd.ScaleOut != nil {
+		if wait := cmd.ScaleOut.Wait; wait != "" && wait != pgservice.ScaleOutWaitNone {
+			timeout += scaleOutWaitTimeout(cmd.ScaleOut.Config)
+		}
+	}
+	return timeout
+}
+
+func sendCommandsAndPrintResult(out io.Writer, cmds []Command, c *client.Client) error {
 	if out == nil {
 		out = io.Discard
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-
 	for _, cmd := range cmds {
-		data, err := json.Marshal(&cmd)
-		if err != nil {
-			return errors.AddStack(err)
-		}
-
-		url := fmt.Sprintf("http://%s/command", addr)
-
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
-		if err != nil {
-			cancel()
-			return errors.AddStack(err)
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
-
-		resp, err := client.Do(req)
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout(&cmd))
+		err := c.Send(ctx, cmd.clientCommand(), func(reply *CommandReply) {
+			if reply.Message != "" {
+				_, _ = io.WriteString(out, reply.Message)
+			}
+			reply.Topology.Print(out)
+			// Only print server-side stderr output when the command is
+			// successful. On failures, callers will render a single warning
+			// callout based on the returned error to avoid duplicated
+			// messages.
+			if reply.OK && reply.Error != "" {
+				_, _ = io.WriteString(out, reply.Error)
+				if reply.Error[len(reply.Error)-1] != '\n' {
+					_, _ = io.WriteString(out, "\n")
+				}
+			}
+		})
+		cancel()
 		if err != nil {
-			cancel()
-			return playgroundUnreachableError{err: err}
+			return err
 		}
+	}
 
-		body, readErr := io.ReadAll(resp.Body)
-		_ = resp.Body.Close()
-		cancel()
-		if readErr != nil {
-			return errors.AddStack(readErr)
-		}
+	return nil
+}
 
-		var reply CommandReply
-		if err := json.Unmarshal(body, &reply); err != nil {
-			return errors.Annotatef(err, "invalid command server response (status: %s)", resp.Status)
-		}
+// commandServerDrainTimeout bounds how long the command server waits for
+// in-flight replies (e.g. a streamed "stop") once the playground terminated.
+const commandServerDrainTimeout = 5 * time.Second
 
-		if reply.Message != "" {
-			_, _ = io.WriteString(out, reply.Message)
-		}
-		// Only print server-side stderr output when the command is successful.
-		// On failures, callers will render a single warning callout based on the
-		// returned error to avoid duplicated messages.
-		if reply.OK && reply.Error != "" {
-			_, _ = io.WriteString(out, reply.Error)
-			if reply.Error[len(reply.Error)-1] != '\n' {
-				_, _ = io.WriteString(out, "\n")
-			}
-		}
-		if !reply.OK {
-			if reply.Error != "" {
-				return errors.New(reply.Error)
-			}
-			return errors.Errorf("command failed (status: %s)", resp.Status)
-		}
+// listenCommandServer binds the port of the command server, so the cluster
+// info only lists the dashboard once it can be served.
+func (p *Playground) listenCommandServer() error {
+	ln, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(p.port))
+	// The ports of this playground are all bound: the next one may start.
+	p.releaseStartTurn()
+	if err != nil {
+		return errors.Annotate(err, "listen on the command server port")
 	}
-
+	p.commandListener = ln
 	return nil
 }
 
@@ -663,13 +981,38 @@ func (p *Playground) listenAndServeHTTP() error {
 		_ = json.NewEncoder(w).Encode(CommandReply{OK: true, Message: "pong"})
 	})
 	mux.HandleFunc("/command", p.commandHandler)
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(client.OpenAPI())
+	})
+	mux.HandleFunc("/ui", p.dashboardHandler)
+	mux.HandleFunc("/events", p.eventsHandler)
+	mux.HandleFunc("/metrics", p.metricsHandler)
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer p.recoverCrash("command server")
+		mux.ServeHTTP(w, r)
+	})
+	var controlToken string
+	if p != nil && p.bootOptions != nil && p.bootOptions.ReadOnlyToken != "" {
+		var err error
+		if controlToken, err = newControlToken(); err != nil {
+			return err
+		}
+		handler = withCommandAuth(controlToken, p.bootOptions.ReadOnlyToken, handler)
+	}
+	if p != nil && p.bootOptions != nil && len(p.bootOptions.CORSOrigins) > 0 {
+		handler = withCORS(p.bootOptions.CORSOrigins, handler)
+	}
 
 	srv := &http.Server{
 		Addr:              "127.0.0.1:" + strconv.Itoa(p.port),
-		Handler:           mux,
+		Handler:           handler,
 		ReadHeaderTimeout: 5 * time.Second,
 		ReadTimeout:       10 * time.Second,
-		WriteTimeout:      30 * time.Second,
+		WriteTimeout:      defaultCommandTimeout,
 		IdleTimeout:       time.Minute,
 	}
 
@@ -678,20 +1021,62 @@ func (p *Playground) listenAndServeHTTP() error {
 			return
 		}
 		<-p.processGroup.Closed()
-		_ = srv.Close()
+		// Keep serving while the instances stop: "stop" clients follow the
+		// termination, and the other commands get a "stopping" reply rather
+		// than a refused connection.
+		if p.terminateDoneCh != nil && p.Stopping() {
+			select {
+			case <-p.terminateDoneCh:
+			case <-time.After(stopCommandTimeout):
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), commandServerDrainTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			_ = srv.Close()
+		}
 	}()
 
-	ln, err := net.Listen("tcp", srv.Addr)
-	if err != nil {
-		return err
+	if p.commandListener == nil {
+		if err := p.listenCommandServer(); err != nil {
+			return err
+		}
 	}
+	ln := p.commandListener
 	if p != nil && p.dataDir != "" {
+		if controlToken != "" {
+			// Written before the port, so the CLI finding the playground can
+			// control it. Not with utils.WriteFile, which would let the group
+			// of the data dir read it, nor over a stale file keeping its mode.
+			tokenPath := filepath.Join(p.dataDir, client.TokenFileName)
+			_ = os.Remove(tokenPath)
+			if err := os.WriteFile(tokenPath, []byte(controlToken+"\n"), 0o600); err != nil {
+				_ = ln.Close()
+				return err
+			}
+			defer func() { _ = os.Remove(tokenPath) }()
+		}
 		portPath := filepath.Join(p.dataDir, playgroundPortFileName)
 		if err := dumpPort(portPath, p.port); err != nil {
 			_ = ln.Close()
 			return err
 		}
 		defer func() { _ = os.Remove(portPath) }()
+
+		if p.ready != nil {
+			readyPath := filepath.Join(p.dataDir, playgroundReadyFileName)
+			p.ready.ReadyAt = time.Now().UTC()
+			if err := writeReadyFile(readyPath, p.ready); err != nil {
+				_ = ln.Close()
+				return err
+			}
+			defer func() { _ = os.Remove(readyPath) }()
+			// A final sync barrier in the event log tells its tailers that
+			// the ready file exists.
+			if p.ui != nil {
+				p.ui.Sync()
+			}
+		}
 	}
 
 	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
@@ -700,6 +1085,58 @@ func (p *Playground) listenAndServeHTTP() error {
 	return nil
 }
 
+// minReadOnlyTokenLen is the length a --read-only-token needs at least.
+const minReadOnlyTokenLen = 8
+
+// commandAccess is what a request to the command server is allowed to do.
+type commandAccess int
+
+const (
+	accessControl commandAccess = iota
+	accessReadOnly
+)
+
+type commandAccessKey struct{}
+
+// newControlToken returns a random token granting full control of the
+// command server.
+func newControlToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Annotate(err, "generate the control token")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// withCommandAuth lets through the requests with the control token, and the
+// ones with the read-only token marked as such for commandHandler to reject
+// the commands changing the playground. The token is a bearer token, or the
+// token query parameter for the browser dashboard. /ping and /openapi.json
+// stay open, so clients can probe the playground.
+func withCommandAuth(controlToken, readOnlyToken string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" || r.URL.Path == "/openapi.json" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("token")
+		}
+		switch {
+		case subtle.ConstantTimeCompare([]byte(token), []byte(controlToken)) == 1:
+			next.ServeHTTP(w, r)
+		case subtle.ConstantTimeCompare([]byte(token), []byte(readOnlyToken)) == 1:
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), commandAccessKey{}, accessReadOnly)))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(CommandReply{Error: "missing or invalid token, see --read-only-token", Code: client.CodeUnauthorized})
+		}
+	})
+}
+
 func (p *Playground) commandHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -709,7 +1146,7 @@ func (p *Playground) commandHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(CommandReply{OK: false, Error: "content-type must be application/json"})
 		return
@@ -733,29 +1170,161 @@ func (p *Playground) commandHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if access, _ := r.Context().Value(commandAccessKey{}).(commandAccess); access == accessReadOnly && !cmd.Type.ReadOnly() {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(CommandReply{Error: fmt.Sprintf("%s is not allowed with a read-only token", cmd.Type), Code: client.CodeForbidden})
+		return
+	}
+
+	if anyOrigin, _ := r.Context().Value(anyOriginKey{}).(bool); anyOrigin && !cmd.Type.ReadOnly() {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(CommandReply{Error: fmt.Sprintf("%s is not allowed from an origin only allowed by --cors-origin '*', list the origin instead", cmd.Type), Code: client.CodeForbidden})
+		return
+	}
+
 	if cmd.Type == StopCommandType {
-		reply := CommandReply{OK: true, Message: "Stopping playground...\n"}
-		if p != nil && p.Stopping() {
-			reply.Message = "Playground is already stopping...\n"
+		p.handleStopCommand(w, r)
+		return
+	}
+	if cmd.Type != CancelCommandType && cmd.Type != CommandStatusCommandType && p.Stopping() {
+		writeStoppingReply(w)
+		return
+	}
+
+	timeout := commandTimeout(&cmd)
+	if cmd.Deadline != nil {
+		// The reply comes by the deadline.
+		timeout = max(timeout, time.Until(*cmd.Deadline)+time.Second)
+	}
+	if timeout > defaultCommandTimeout {
+		// Commands that wait (e.g. scale-out --wait) outlive the server-wide
+		// WriteTimeout.
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout))
+	}
+	var reply CommandReply
+	switch cmd.Type {
+	case CancelCommandType, CommandStatusCommandType:
+		// Answered from the command queue directly: the controller may be busy
+		// with the very command to cancel.
+		reply = p.handleQueueCommand(&cmd)
+	default:
+		resp := p.doCommand(r.Context(), &cmd)
+		if stdErrors.Is(resp.err, errPlaygroundStopping) {
+			writeStoppingReply(w)
+			return
+		}
+		reply = CommandReply{OK: resp.err == nil, Message: string(resp.output), Topology: resp.topology, CommandID: resp.id}
+		if resp.err != nil {
+			reply.Error = resp.err.Error()
 		}
-		_ = json.NewEncoder(w).Encode(&reply)
+		if resp.timedOut {
+			reply.Code = client.CodeTimeout
+		}
+	}
+	switch {
+	case reply.Code == client.CodeTimeout:
+		w.WriteHeader(http.StatusGatewayTimeout)
+	case !reply.OK:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	_ = json.NewEncoder(w).Encode(&reply)
+}
+
+func writeStoppingReply(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_ = json.NewEncoder(w).Encode(CommandReply{Error: errPlaygroundStopping.Error(), Code: client.CodeStopping})
+}
+
+// handleStopCommand starts the shutdown. A client accepting a streamed reply
+// then gets the termination progress, until every instance quit.
+func (p *Playground) handleStopCommand(w http.ResponseWriter, r *http.Request) {
+	reply := CommandReply{OK: true, Message: "Stopping playground...\n"}
+	if p != nil && p.Stopping() {
+		reply.Message = "Playground is already stopping...\n"
+	}
+	stream := p != nil && p.stopFeed != nil && strings.Contains(r.Header.Get("Accept"), client.StreamContentType)
+	if stream {
+		w.Header().Set("Content-Type", client.StreamContentType)
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(stopCommandTimeout))
+	}
+	enc := json.NewEncoder(w)
+	flush := func() {
 		if f, ok := w.(http.Flusher); ok {
 			f.Flush()
 		}
-		go func() {
-			if p != nil {
-				p.requestStopInternal()
-			}
-		}()
+	}
+	_ = enc.Encode(&reply)
+	flush()
+	go func() {
+		if p != nil {
+			p.requestStopInternal()
+		}
+	}()
+	if !stream {
 		return
 	}
 
-	output, err := p.doCommand(r.Context(), &cmd)
+	for from := 0; ; {
+		lines, to, done, changed := p.stopFeed.next(from)
+		from = to
+		for _, line := range lines {
+			_ = enc.Encode(CommandReply{OK: true, Message: line})
+		}
+		if done {
+			_ = enc.Encode(CommandReply{OK: true, Message: "All instances stopped\n"})
+			return
+		}
+		flush()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
 
-	reply := CommandReply{OK: err == nil, Message: string(output)}
+func (p *Playground) handleQueueCommand(cmd *Command) CommandReply {
+	if p == nil || p.commands == nil {
+		return CommandReply{Error: "playground is stopping"}
+	}
+	if cmd.Command == nil {
+		return CommandReply{Error: fmt.Sprintf("missing command for %s", cmd.Type)}
+	}
+	id := cmd.Command.ID
+
+	if cmd.Type == CancelCommandType {
+		if id == 0 {
+			return CommandReply{Error: "missing command id to cancel"}
+		}
+		status, err := p.commands.cancel(id)
+		if err != nil {
+			return CommandReply{Error: err.Error()}
+		}
+		switch status {
+		case CommandStatusQueued:
+			return CommandReply{OK: true, Message: fmt.Sprintf("Command %d canceled\n", id)}
+		case CommandStatusRunning:
+			return CommandReply{OK: true, Message: fmt.Sprintf("Cancel requested for running command %d\n", id)}
+		default:
+			return CommandReply{OK: true, Message: fmt.Sprintf("Command %d already %s\n", id, status)}
+		}
+	}
+
+	infos, err := p.commands.infos(id)
 	if err != nil {
-		reply.Error = err.Error()
-		w.WriteHeader(http.StatusBadRequest)
+		return CommandReply{Error: err.Error()}
 	}
-	_ = json.NewEncoder(w).Encode(&reply)
+	var buf bytes.Buffer
+	if cmd.Command.JSON {
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(infos)
+	} else {
+		td := utils.NewTableDisplayer(&buf, []string{"ID", "TYPE", "STATUS", "ERROR"})
+		for _, info := range infos {
+			td.AddRow(strconv.FormatUint(info.ID, 10), string(info.Type), string(info.Status), info.Error)
+		}
+		td.Display()
+	}
+	return CommandReply{OK: true, Message: buf.String()}
 }
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	pgservice "github.com/pingcap/tiup/components/playground-ng/service"
+	"github.com/pingcap/tiup/pkg/environment"
+	"github.com/pingcap/tiup/pkg/repository"
+	"github.com/pingcap/tiup/pkg/repository/v1manifest"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+)
+
+// componentVersions is the list-components entry of a repository component.
+type componentVersions struct {
+	Component string `json:"component"`
+	Name      string `json:"name"`
+	Latest    string `json:"latest,omitempty"`
+	Nightly   string `json:"nightly,omitempty"`
+	// Versions lists the versions available on this platform, oldest first.
+	Versions  []string `json:"versions,omitempty"`
+	Installed []string `json:"installed,omitempty"`
+	// Error is set when the manifest of the component could not be loaded.
+	Error string `json:"error,omitempty"`
+}
+
+// componentCatalog loads what list-components reports about a component.
+type componentCatalog interface {
+	// Manifest returns the repository manifest of the component, or nil if
+	// it is not known (e.g. not cached when offline).
+	Manifest(id proc.RepoComponentID) (*v1manifest.Component, error)
+	Installed(id proc.RepoComponentID) ([]string, error)
+}
+
+// envComponentCatalog reads component manifests from the TiUP repository.
+// Offline, it only uses the manifests cached under $TIUP_HOME.
+type envComponentCatalog struct {
+	env     *environment.Environment
+	offline bool
+}
+
+func (c envComponentCatalog) Manifest(id proc.RepoComponentID) (*v1manifest.Component, error) {
+	repo := c.env.V1Repository()
+	if !c.offline {
+		return repo.GetComponentManifest(id.String(), false)
+	}
+	var index v1manifest.Index
+	_, exists, err := repo.LocalLoadManifest(&index)
+	if err != nil || !exists {
+		return nil, err
+	}
+	item, ok := index.ComponentList()[id.String()]
+	if !ok {
+		return nil, nil
+	}
+	manifest, err := repo.LocalLoadComponentManifest(&item, v1manifest.ComponentManifestFilename(id.String()))
+	if err != nil {
+		// Not cached yet.
+		return nil, nil
+	}
+	return manifest, nil
+}
+
+func (c envComponentCatalog) Installed(id proc.RepoComponentID) ([]string, error) {
+	return c.env.Profile().InstalledVersions(id.String())
+}
+
+func listComponentVersions(catalog componentCatalog, ids []proc.RepoComponentID, platform string) []componentVersions {
+	out := make([]componentVersions, 0, len(ids))
+	for _, id := range ids {
+		entry := componentVersions{Component: id.String(), Name: proc.ComponentDisplayName(id)}
+		if installed, err := catalog.Installed(id); err == nil {
+			slices.SortFunc(installed, semver.Compare)
+			entry.Installed = installed
+		}
+		manifest, err := catalog.Manifest(id)
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		if manifest != nil {
+			entry.Latest = manifest.LatestVersion(platform)
+			if manifest.HasNightly(platform) {
+				entry.Nightly = manifest.Nightly
+			}
+			for v := range manifest.VersionList(platform) {
+				entry.Versions = append(entry.Versions, v)
+			}
+			slices.SortFunc(entry.Versions, semver.Compare)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func parseRepoComponentIDs(args []string) ([]proc.RepoComponentID, error) {
+	all := proc.RepoComponentIDs()
+	if len(args) == 0 {
+		return all, nil
+	}
+	ids := make([]proc.RepoComponentID, 0, len(args))
+	for _, arg := range args {
+		id := proc.RepoComponentID(arg)
+		if !slices.Contains(all, id) {
+			return nil, fmt.Errorf("unknown component %q (available: %s)", arg, joinComponentIDs(all))
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func joinComponentIDs(ids []proc.RepoComponentID) string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, id.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+func newListComponents(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+	var (
+		jsonOut       bool
+		offline       bool
+		installedOnly bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list-components [component...]",
+		Short: "List the components playground-ng runs and their versions",
+		Long: `List the components playground-ng runs with their latest, nightly and
+installed versions.
+
+With a single component, print its versions one per line (oldest first), so
+the output can feed scripts and shell completion of --<service>.version.`,
+		Example: fmt.Sprintf("%[1]s list-components\n%[1]s list-components tiproxy --installed\n%[1]s list-components --json --offline", arg0),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			var names []string
+			for _, id := range proc.RepoComponentIDs() {
+				if !slices.Contains(args, id.String()) {
+					names = append(names, id.String())
+				}
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseRepoComponentIDs(args)
+			if err != nil {
+				return err
+			}
+			env, err := environment.InitEnv(repository.Options{}, repository.MirrorOptions{Proxy: state.proxy})
+			if err != nil {
+				return err
+			}
+			defer func() { _ = env.Close() }()
+
+			platform := repository.PlatformString(runtime.GOOS, runtime.GOARCH)
+			list := listComponentVersions(envComponentCatalog{env: env, offline: offline}, ids, platform)
+			return writeComponentVersions(cmd.OutOrStdout(), list, jsonOut, installedOnly, len(args) == 1)
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output in JSON format")
+	cmd.Flags().BoolVar(&offline, "offline", false, "Only use the manifests cached locally, without querying the mirror")
+	cmd.Flags().BoolVar(&installedOnly, "installed", false, "Only list installed versions")
+	return cmd
+}
+
+func writeComponentVersions(out io.Writer, list []componentVersions, jsonOut, installedOnly, versionsOnly bool) error {
+	if installedOnly {
+		for i := range list {
+			list[i].Versions = slices.DeleteFunc(list[i].Versions, func(v string) bool {
+				return !slices.Contains(list[i].Installed, v)
+			})
+		}
+	}
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	if versionsOnly {
+		entry := list[0]
+		if entry.Error != "" {
+			return errors.Errorf("load %s versions: %s", entry.Component, entry.Error)
+		}
+		versions := entry.Versions
+		if installedOnly {
+			versions = entry.Installed
+		}
+		for _, v := range versions {
+			fmt.Fprintln(out, v)
+		}
+		return nil
+	}
+
+	td := utils.NewTableDisplayer(out, []string{"COMPONENT", "NAME", "LATEST", "NIGHTLY", "INSTALLED"})
+	for _, entry := range list {
+		if installedOnly && len(entry.Installed) == 0 {
+			continue
+		}
+		latest := entry.Latest
+		if entry.Error != "" {
+			latest = "unavailable"
+		}
+		td.AddRow(entry.Component, entry.Name, latest, entry.Nightly, strings.Join(entry.Installed, ","))
+	}
+	td.Display()
+	return nil
+}
+
+// completeComponentVersions completes a version of component from the
+// manifests cached locally, so completion never waits for the mirror.
+func completeComponentVersions(component proc.RepoComponentID) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		env, err := environment.InitEnv(repository.Options{}, repository.MirrorOptions{})
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		defer func() { _ = env.Close() }()
+
+		platform := repository.PlatformString(runtime.GOOS, runtime.GOARCH)
+		list := listComponentVersions(envComponentCatalog{env: env, offline: true}, []proc.RepoComponentID{component}, platform)
+		var versions []string
+		for _, v := range append([]string{utils.LatestVersionAlias, utils.NightlyVersionAlias}, list[0].Versions...) {
+			if strings.HasPrefix(v, toComplete) {
+				versions = append(versions, v)
+			}
+		}
+		return versions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// registerVersionCompletions completes the cluster version argument and the
+// --<service>.version flags. A service with a version override runs the
+// repository component of the same ID.
+func registerVersionCompletions(cmd *cobra.Command) {
+	cmd.ValidArgsFunction = func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeComponentVersions(proc.ComponentTiDB)(c, args, toComplete)
+	}
+	for _, spec := range pgservice.AllSpecs() {
+		if spec.Catalog.FlagPrefix == "" || !spec.Catalog.AllowModifyVersion {
+			continue
+		}
+		_ = cmd.RegisterFlagCompletionFunc(spec.Catalog.FlagPrefix+".version", completeComponentVersions(proc.RepoComponentID(spec.ServiceID)))
+	}
+}
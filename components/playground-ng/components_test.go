@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/repository/v1manifest"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeComponentCatalog struct {
+	manifests map[proc.RepoComponentID]*v1manifest.Component
+	installed map[proc.RepoComponentID][]string
+}
+
+func (c fakeComponentCatalog) Manifest(id proc.RepoComponentID) (*v1manifest.Component, error) {
+	if id == proc.ComponentTiKVWorker {
+		return nil, fmt.Errorf("component not found")
+	}
+	return c.manifests[id], nil
+}
+
+func (c fakeComponentCatalog) Installed(id proc.RepoComponentID) ([]string, error) {
+	return c.installed[id], nil
+}
+
+func TestListComponentVersions(t *testing.T) {
+	const platform = "linux/amd64"
+	catalog := fakeComponentCatalog{
+		manifests: map[proc.RepoComponentID]*v1manifest.Component{
+			proc.ComponentTiDB: {
+				Nightly: "v9.0.0-alpha-nightly-20261017",
+				Platforms: map[string]map[string]v1manifest.VersionItem{platform: {
+					"v8.5.0":                        {},
+					"v8.1.2":                        {},
+					"v9.0.0-alpha-nightly-20261017": {},
+					"v7.0.0":                        {Yanked: true},
+				}},
+			},
+		},
+		installed: map[proc.RepoComponentID][]string{proc.ComponentTiDB: {"v8.5.0", "v8.1.2"}},
+	}
+
+	ids, err := parseRepoComponentIDs([]string{"tidb", "tiproxy", "tikv-worker"})
+	require.NoError(t, err)
+	list := listComponentVersions(catalog, ids, platform)
+	require.Len(t, list, 3)
+	require.Equal(t, componentVersions{
+		Component: "tidb",
+		Name:      "TiDB",
+		Latest:    "v8.5.0",
+		Nightly:   "v9.0.0-alpha-nightly-20261017",
+		Versions:  []string{"v8.1.2", "v8.5.0", "v9.0.0-alpha-nightly-20261017"},
+		Installed: []string{"v8.1.2", "v8.5.0"},
+	}, list[0])
+	require.Equal(t, componentVersions{Component: "tiproxy", Name: "TiProxy"}, list[1], "not cached offline")
+	require.Equal(t, "component not found", list[2].Error)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeComponentVersions(&buf, list[:1], false, false, true))
+	require.Equal(t, "v8.1.2\nv8.5.0\nv9.0.0-alpha-nightly-20261017\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeComponentVersions(&buf, list, true, true, false))
+	var decoded []componentVersions
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, []string{"v8.1.2", "v8.5.0"}, decoded[0].Versions)
+
+	buf.Reset()
+	require.ErrorContains(t, writeComponentVersions(&buf, list[2:], false, false, true), "load tikv-worker versions: component not found")
+
+	_, err = parseRepoComponentIDs([]string{"mysql"})
+	require.ErrorContains(t, err, `unknown component "mysql"`)
+}
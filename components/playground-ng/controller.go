@@ -2,13 +2,18 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"sync"
 	"syscall"
 
 	"github.com/pingcap/tiup/components/playground-ng/proc"
 	pgservice "github.com/pingcap/tiup/components/playground-ng/service"
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
 	"github.com/pingcap/tiup/pkg/utils"
 )
 
@@ -26,6 +31,9 @@ type controllerState struct {
 
 	procByPID  map[int]*procRecord
 	procByName map[string]*procRecord
+	// crashDirs maps the instances that exited abnormally to the crash dir
+	// of their last crash, see recordInstanceCrash.
+	crashDirs map[string]string
 }
 
 type procExitedEvent struct {
@@ -114,16 +122,211 @@ type stopInternalEvent struct{}
 
 type forceKillEvent struct{}
 
+// CommandStatus is the state of a command sent to the controller.
+type CommandStatus = client.CommandStatus
+
+// Command statuses.
+const (
+	CommandStatusQueued   = client.CommandStatusQueued
+	CommandStatusRunning  = client.CommandStatusRunning
+	CommandStatusDone     = client.CommandStatusDone
+	CommandStatusFailed   = client.CommandStatusFailed
+	CommandStatusCanceled = client.CommandStatusCanceled
+)
+
+// maxFinishedCommands bounds how many finished commands stay queryable.
+const maxFinishedCommands = 64
+
+// commandRequest is a command queued for the controller.
 type commandRequest struct {
+	id     uint64
 	cmd    *Command
-	respCh chan commandResponse
+	ctx    context.Context
+	cancel context.CancelFunc
+	respCh chan commandResponse // buffered, receives exactly one response
+
+	// Guarded by commandQueue.mu.
+	status CommandStatus
+	err    string
+}
+
+// commandQueue holds the commands sent to the controller, which runs them one
+// at a time in submission order. Unlike the controller state, it is shared
+// with the command server goroutines so commands can be queried and canceled
+// while the controller is busy.
+type commandQueue struct {
+	mu       sync.Mutex
+	nextID   uint64
+	pending  []*commandRequest
+	byID     map[uint64]*commandRequest
+	finished []uint64 // oldest first
+	readyCh  chan struct{}
+}
+
+func newCommandQueue() *commandQueue {
+	return &commandQueue{
+		byID:    make(map[uint64]*commandRequest),
+		readyCh: make(chan struct{}, 1),
+	}
+}
+
+// ready is signaled when commands are pending.
+func (q *commandQueue) ready() <-chan struct{} {
+	if q == nil {
+		return nil
+	}
+	return q.readyCh
+}
+
+func (q *commandQueue) signal() {
+	select {
+	case q.readyCh <- struct{}{}:
+	default:
+	}
+}
+
+// submit queues cmd. The command is canceled when ctx is done, or once its
+// deadline is exceeded.
+func (q *commandQueue) submit(ctx context.Context, cmd *Command) *commandRequest {
+	cmdCtx, cancel := context.WithCancel(ctx)
+	if cmd.Deadline != nil {
+		cmdCtx, cancel = context.WithDeadline(ctx, *cmd.Deadline)
+	}
+	q.mu.Lock()
+	q.nextID++
+	req := &commandRequest{
+		id:     q.nextID,
+		cmd:    cmd,
+		ctx:    cmdCtx,
+		cancel: cancel,
+		respCh: make(chan commandResponse, 1),
+		status: CommandStatusQueued,
+	}
+	q.pending = append(q.pending, req)
+	q.byID[req.id] = req
+	q.mu.Unlock()
+
+	context.AfterFunc(cmdCtx, func() { q.cancel(req.id) })
+	q.signal()
+	return req
+}
+
+// next pops the oldest pending command and marks it running.
+func (q *commandQueue) next() *commandRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return nil
+	}
+	req := q.pending[0]
+	q.pending = q.pending[1:]
+	req.status = CommandStatusRunning
+	if len(q.pending) > 0 {
+		q.signal()
+	}
+	return req
+}
+
+// cancel cancels the command with the given id. A queued command is dropped
+// right away; a running one sees its context canceled, which stops it at the
+// next step that can be interrupted (e.g. between scale-out instances, or
+// while waiting for them to join the cluster).
+func (q *commandQueue) cancel(id uint64) (CommandStatus, error) {
+	q.mu.Lock()
+	req, ok := q.byID[id]
+	if !ok {
+		q.mu.Unlock()
+		return "", fmt.Errorf("command %d not found", id)
+	}
+	status := req.status
+	if status == CommandStatusQueued {
+		q.pending = slices.DeleteFunc(q.pending, func(r *commandRequest) bool { return r == req })
+		if errors.Is(req.ctx.Err(), context.DeadlineExceeded) {
+			q.finishLocked(req, CommandStatusCanceled, context.DeadlineExceeded)
+			req.respCh <- commandResponse{err: commandDeadlineError(id), timedOut: true}
+		} else {
+			q.finishLocked(req, CommandStatusCanceled, context.Canceled)
+			req.respCh <- commandResponse{err: fmt.Errorf("command %d canceled", id)}
+		}
+	}
+	q.mu.Unlock()
+
+	req.cancel()
+	return status, nil
+}
+
+// finish records the result of a command. It is a no-op for commands that
+// are already finished (e.g. canceled while queued).
+func (q *commandQueue) finish(req *commandRequest, err error) {
+	status := CommandStatusDone
+	switch {
+	case err != nil && req.ctx.Err() != nil:
+		status = CommandStatusCanceled
+	case err != nil:
+		status = CommandStatusFailed
+	}
+	q.mu.Lock()
+	if req.status == CommandStatusQueued || req.status == CommandStatusRunning {
+		q.finishLocked(req, status, err)
+	}
+	q.mu.Unlock()
+	req.cancel()
+}
+
+func (q *commandQueue) finishLocked(req *commandRequest, status CommandStatus, err error) {
+	req.status = status
+	if err != nil {
+		req.err = err.Error()
+	}
+	q.finished = append(q.finished, req.id)
+	if len(q.finished) > maxFinishedCommands {
+		delete(q.byID, q.finished[0])
+		q.finished = q.finished[1:]
+	}
+}
+
+// CommandInfo describes a command in the command queue.
+type CommandInfo = client.CommandInfo
+
+// infos returns the queried command, or all known commands by ID when id is 0.
+func (q *commandQueue) infos(id uint64) ([]CommandInfo, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	info := func(req *commandRequest) CommandInfo {
+		return CommandInfo{ID: req.id, Type: req.cmd.Type, Status: req.status, Error: req.err}
+	}
+	if id != 0 {
+		req, ok := q.byID[id]
+		if !ok {
+			return nil, fmt.Errorf("command %d not found", id)
+		}
+		return []CommandInfo{info(req)}, nil
+	}
+	out := make([]CommandInfo, 0, len(q.byID))
+	for _, req := range q.byID {
+		out = append(out, info(req))
+	}
+	slices.SortFunc(out, func(a, b CommandInfo) int { return cmp.Compare(a.ID, b.ID) })
+	return out, nil
 }
 
 type commandResponse struct {
+	id     uint64
 	output []byte
 	err    error
+	// timedOut is set when err is due to the command exceeding its deadline.
+	timedOut bool
+	// followUp, when set, is run by the requester after the controller replied.
+	followUp commandFollowUp
+	topology *TopologyChange
 }
 
+// commandFollowUp is a slow part of a command (e.g. waiting for a scaled-out
+// instance to join the cluster) that runs outside the controller goroutine so
+// it does not block events and other commands.
+type commandFollowUp func(ctx context.Context, w io.Writer) error
+
 func (p *Playground) startController() {
 	if p == nil {
 		return
@@ -135,7 +338,7 @@ func (p *Playground) startController() {
 		// to terminate processes.
 		controllerCtx, cancel := context.WithCancel(context.Background())
 		p.controllerCancel = cancel
-		p.cmdReqCh = make(chan commandRequest)
+		p.commands = newCommandQueue()
 		p.evtCh = make(chan controllerEvent, 64)
 		p.controllerDoneCh = make(chan struct{})
 		go p.controllerLoop(controllerCtx)
@@ -151,12 +354,16 @@ func (p *Playground) controllerLoop(ctx context.Context) {
 		idAlloc:          make(map[proc.ServiceID]int),
 		procByPID:        make(map[int]*procRecord),
 		procByName:       make(map[string]*procRecord),
+		crashDirs:        make(map[string]string),
 	}
 	defer func() {
 		if p != nil && p.controllerDoneCh != nil {
 			close(p.controllerDoneCh)
 		}
 	}()
+	// Runs first: the other goroutines must not see the controller done
+	// before the crash cleanup.
+	defer p.recoverCrash("controller")
 	for {
 		// Controller cancel is used to initiate shutdown. Drain any already
 		// queued events (e.g. a force-kill request sent right after Ctrl+C)
@@ -173,10 +380,10 @@ func (p *Playground) controllerLoop(ctx context.Context) {
 		}
 
 		select {
-		case req := <-p.cmdReqCh:
-			var buf bytes.Buffer
-			err := p.handleCommand(&state, req.cmd, &buf)
-			req.respCh <- commandResponse{output: buf.Bytes(), err: err}
+		case <-p.commands.ready():
+			if req := p.commands.next(); req != nil {
+				req.respCh <- p.runCommand(req.ctx, &state, req.cmd)
+			}
 		case evt := <-p.evtCh:
 			p.handleEvent(&state, evt)
 		case <-ctx.Done():
@@ -260,69 +467,107 @@ func (p *Playground) emitEvent(evt controllerEvent) bool {
 	}
 }
 
-func (p *Playground) doCommand(ctx context.Context, cmd *Command) ([]byte, error) {
-	if p == nil {
-		return nil, context.Canceled
+// errPlaygroundStopping rejects the commands received once shutdown started.
+var errPlaygroundStopping = errors.New("playground is stopping")
+
+func commandDeadlineError(id uint64) error {
+	return fmt.Errorf("command %d exceeded its deadline", id)
+}
+
+// doCommand queues cmd for the controller and waits for its result, including
+// any follow-up. resp.id is set once the command is queued.
+func (p *Playground) doCommand(ctx context.Context, cmd *Command) (resp commandResponse) {
+	if p == nil || cmd == nil || p.commands == nil {
+		return commandResponse{err: context.Canceled}
 	}
-	if cmd == nil {
-		return nil, context.Canceled
+
+	req := p.commands.submit(ctx, cmd)
+	ctxDone := req.ctx.Done()
+wait:
+	for {
+		select {
+		case resp = <-req.respCh:
+			break wait
+		case <-p.controllerDoneCh:
+			resp = commandResponse{err: errPlaygroundStopping}
+			break wait
+		case <-ctxDone:
+			if errors.Is(req.ctx.Err(), context.DeadlineExceeded) {
+				// Reply by the deadline even if the controller is in a
+				// step that cannot be interrupted; its result is dropped.
+				resp = commandResponse{err: commandDeadlineError(req.id), timedOut: true}
+				break wait
+			}
+			// Canceled: the controller replies once the command stopped.
+			ctxDone = nil
+		}
 	}
-	if p.cmdReqCh == nil {
-		return nil, context.Canceled
+	resp.id = req.id
+	if resp.err == nil && resp.followUp != nil {
+		buf := bytes.NewBuffer(resp.output)
+		resp.err = resp.followUp(req.ctx, buf)
+		resp.output = buf.Bytes()
 	}
-
-	respCh := make(chan commandResponse, 1)
-	req := commandRequest{
-		cmd:    cmd,
-		respCh: respCh,
+	if resp.err != nil && errors.Is(req.ctx.Err(), context.DeadlineExceeded) {
+		resp.timedOut = true
 	}
+	p.commands.finish(req, resp.err)
+	return resp
+}
 
-	select {
-	case p.cmdReqCh <- req:
-	case <-p.controllerDoneCh:
-		return nil, fmt.Errorf("playground is stopping")
-	case <-ctx.Done():
-		return nil, ctx.Err()
+// runCommand handles cmd in the controller. For scale commands, it records the
+// instances the command added or removed.
+func (p *Playground) runCommand(ctx context.Context, state *controllerState, cmd *Command) commandResponse {
+	if err := ctx.Err(); err != nil {
+		return commandResponse{err: err}
+	}
+	trackTopology := cmd != nil && (cmd.Type == ScaleInCommandType || cmd.Type == ScaleOutCommandType)
+	var before []TopologyInstance
+	if trackTopology {
+		before = state.topology()
 	}
 
-	select {
-	case resp := <-respCh:
-		return resp.output, resp.err
-	case <-p.controllerDoneCh:
-		return nil, fmt.Errorf("playground is stopping")
-	case <-ctx.Done():
-		return nil, ctx.Err()
+	var buf bytes.Buffer
+	followUp, err := p.handleCommand(ctx, state, cmd, &buf)
+	resp := commandResponse{output: buf.Bytes(), err: err, followUp: followUp}
+	if trackTopology {
+		resp.topology = diffTopology(before, state.topology())
 	}
+	return resp
 }
 
-func (p *Playground) handleCommand(state *controllerState, cmd *Command, w io.Writer) error {
+func (p *Playground) handleCommand(ctx context.Context, state *controllerState, cmd *Command, w io.Writer) (commandFollowUp, error) {
 	if cmd == nil {
-		return fmt.Errorf("command is nil")
+		return nil, fmt.Errorf("command is nil")
 	}
 
 	// Reject commands while stopping to keep lifecycle predictable.
 	if p.Stopping() {
-		return fmt.Errorf("playground is stopping")
+		return nil, errPlaygroundStopping
 	}
 
 	switch cmd.Type {
 	case DisplayCommandType:
-		verbose := false
-		jsonOut := false
+		var req DisplayRequest
 		if cmd.Display != nil {
-			verbose = cmd.Display.Verbose
-			jsonOut = cmd.Display.JSON
+			req = *cmd.Display
 		}
-		return p.handleDisplay(state, w, verbose, jsonOut)
+		return nil, p.handleDisplay(state, w, req)
 	case ScaleInCommandType:
 		if cmd.ScaleIn == nil {
-			return fmt.Errorf("missing scale_in request")
+			return nil, fmt.Errorf("missing scale_in request")
 		}
-		return p.handleScaleIn(state, w, cmd.ScaleIn)
+		return nil, p.handleScaleIn(state, w, cmd.ScaleIn)
 	case ScaleOutCommandType:
-		return p.handleScaleOut(state, w, cmd.ScaleOut)
+		return p.handleScaleOut(ctx, state, w, cmd.ScaleOut)
+	case RetagCommandType:
+		return nil, p.handleRetag(w, cmd.Retag)
+	case SnapshotCommandType:
+		return nil, p.handleSnapshot(state, w, cmd.Snapshot)
+	case LogsCommandType:
+		return nil, p.handleLogs(state, w, cmd.Logs)
 	default:
-		return fmt.Errorf("unknown command type: %s", cmd.Type)
+		return nil, fmt.Errorf("unknown command type: %s", cmd.Type)
 	}
 }
 
@@ -348,6 +593,14 @@ func (p *Playground) onProcsChangedInController(state *controllerState) {
 	p.progressMu.Unlock()
 
 	logIfErr(p.renderSDFileInController(state))
+	logIfErr(writeInstanceRegistry(p.dataDir, state.walkProcs))
+	p.tidbLB.SetBackends(tidbAddrs(state.procs[proc.ServiceTiDB]))
+	var procs []proc.Process
+	_ = state.walkProcs(func(_ proc.ServiceID, inst proc.Process) error {
+		procs = append(procs, inst)
+		return nil
+	})
+	p.latency.retain(procs)
 }
 
 func (p *Playground) renderSDFileInController(state *controllerState) error {
@@ -379,6 +632,7 @@ func (p *Playground) handleProcStarted(state *controllerState, inst proc.Process
 
 	if state != nil {
 		state.upsertProcRecord(inst)
+		logIfErr(writeInstanceRegistry(p.dataDir, state.walkProcs))
 	}
 	serviceID := info.Service
 	requiredMin := 0
@@ -456,6 +710,7 @@ func (p *Playground) handleProcExited(state *controllerState, inst proc.Process,
 
 	if !expectedExit {
 		if err != nil {
+			p.recordInstanceCrash(state, inst, pid, err)
 			p.printProcExitError(inst, err)
 		} else {
 			fmt.Fprintf(p.terminalWriter(), "%s quit\n", p.shutdownProcTitle(inst))
@@ -10,7 +10,7 @@ import (
 
 func TestControllerLoop_DrainsEventsAfterCancel(t *testing.T) {
 	p := NewPlayground("", 0)
-	p.cmdReqCh = make(chan commandRequest)
+	p.commands = newCommandQueue()
 	p.evtCh = make(chan controllerEvent, 1)
 	p.controllerDoneCh = make(chan struct{})
 
@@ -32,3 +32,100 @@ func TestControllerLoop_DrainsEventsAfterCancel(t *testing.T) {
 		require.FailNow(t, "controller did not exit after draining events")
 	}
 }
+
+func TestCommandQueue_RunsInOrderAndCancelsQueued(t *testing.T) {
+	q := newCommandQueue()
+	first := q.submit(context.Background(), &Command{Type: DisplayCommandType})
+	second := q.submit(context.Background(), &Command{Type: ScaleOutCommandType})
+	third := q.submit(context.Background(), &Command{Type: ScaleInCommandType})
+
+	status, err := q.cancel(second.id)
+	require.NoError(t, err)
+	require.Equal(t, CommandStatusQueued, status)
+	resp := <-second.respCh
+	require.EqualError(t, resp.err, "command 2 canceled")
+
+	require.Same(t, first, q.next())
+	require.Same(t, third, q.next())
+	require.Nil(t, q.next())
+
+	q.finish(first, nil)
+	infos, err := q.infos(0)
+	require.NoError(t, err)
+	require.Equal(t, []CommandInfo{
+		{ID: 1, Type: DisplayCommandType, Status: CommandStatusDone},
+		{ID: 2, Type: ScaleOutCommandType, Status: CommandStatusCanceled, Error: "context canceled"},
+		{ID: 3, Type: ScaleInCommandType, Status: CommandStatusRunning},
+	}, infos)
+
+	_, err = q.infos(42)
+	require.EqualError(t, err, "command 42 not found")
+}
+
+func TestCommandQueue_CancelRunningCancelsContext(t *testing.T) {
+	q := newCommandQueue()
+	req := q.submit(context.Background(), &Command{Type: ScaleOutCommandType})
+	require.Same(t, req, q.next())
+
+	status, err := q.cancel(req.id)
+	require.NoError(t, err)
+	require.Equal(t, CommandStatusRunning, status)
+	require.ErrorIs(t, req.ctx.Err(), context.Canceled)
+
+	q.finish(req, req.ctx.Err())
+	infos, err := q.infos(req.id)
+	require.NoError(t, err)
+	require.Equal(t, CommandStatusCanceled, infos[0].Status)
+}
+
+func TestCommandQueue_ClientDisconnectCancelsQueued(t *testing.T) {
+	q := newCommandQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	req := q.submit(ctx, &Command{Type: DisplayCommandType})
+	cancel()
+
+	select {
+	case resp := <-req.respCh:
+		require.Error(t, resp.err)
+	case <-time.After(time.Second):
+		require.FailNow(t, "queued command not canceled with its context")
+	}
+	require.Nil(t, q.next())
+}
+
+func TestDoCommand_RepliesByDeadlineWhileRunning(t *testing.T) {
+	p := NewPlayground("", 0)
+	p.commands = newCommandQueue()
+	p.controllerDoneCh = make(chan struct{})
+
+	// A controller stuck in a step that does not check the context.
+	running := make(chan *commandRequest, 1)
+	go func() {
+		<-p.commands.ready()
+		running <- p.commands.next()
+	}()
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	resp := p.doCommand(context.Background(), &Command{Type: SnapshotCommandType, Deadline: &deadline})
+	require.True(t, resp.timedOut)
+	require.EqualError(t, resp.err, "command 1 exceeded its deadline")
+
+	req := <-running
+	require.ErrorIs(t, req.ctx.Err(), context.DeadlineExceeded)
+	infos, err := p.commands.infos(req.id)
+	require.NoError(t, err)
+	require.Equal(t, CommandStatusCanceled, infos[0].Status)
+}
+
+func TestCommandQueue_KeepsBoundedHistory(t *testing.T) {
+	q := newCommandQueue()
+	for range maxFinishedCommands + 1 {
+		req := q.submit(context.Background(), &Command{Type: DisplayCommandType})
+		require.Same(t, req, q.next())
+		q.finish(req, nil)
+	}
+	infos, err := q.infos(0)
+	require.NoError(t, err)
+	require.Len(t, infos, maxFinishedCommands)
+	require.Equal(t, uint64(2), infos[0].ID)
+}
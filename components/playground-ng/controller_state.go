@@ -100,6 +100,59 @@ func (s *controllerState) walkProcs(fn func(serviceID proc.ServiceID, inst proc.
 	return nil
 }
 
+// topology lists the tracked instances in walkProcs order.
+func (s *controllerState) topology() []TopologyInstance {
+	var out []TopologyInstance
+	_ = s.walkProcs(func(serviceID proc.ServiceID, inst proc.Process) error {
+		if inst == nil || inst.Info() == nil {
+			return nil
+		}
+		info := inst.Info()
+		item := TopologyInstance{
+			Name:       info.Name(),
+			Service:    serviceID.String(),
+			Host:       proc.AdvertiseHost(info.Host),
+			Port:       info.Port,
+			StatusPort: info.StatusPort,
+		}
+		if info.Proc != nil {
+			item.PID = info.Proc.Pid()
+		}
+		out = append(out, item)
+		return nil
+	})
+	return out
+}
+
+// diffTopology returns the instances only in after (added) and only in before
+// (removed), matched by name. It returns nil when nothing changed.
+func diffTopology(before, after []TopologyInstance) *TopologyChange {
+	names := func(list []TopologyInstance) map[string]struct{} {
+		m := make(map[string]struct{}, len(list))
+		for _, inst := range list {
+			m[inst.Name] = struct{}{}
+		}
+		return m
+	}
+	beforeNames, afterNames := names(before), names(after)
+
+	var change TopologyChange
+	for _, inst := range after {
+		if _, ok := beforeNames[inst.Name]; !ok {
+			change.Added = append(change.Added, inst)
+		}
+	}
+	for _, inst := range before {
+		if _, ok := afterNames[inst.Name]; !ok {
+			change.Removed = append(change.Removed, inst)
+		}
+	}
+	if len(change.Added) == 0 && len(change.Removed) == 0 {
+		return nil
+	}
+	return &change
+}
+
 type procRecord struct {
 	inst      proc.Process
 	serviceID proc.ServiceID
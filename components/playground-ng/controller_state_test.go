@@ -200,14 +200,22 @@ type stubOSProcess struct {
 	pid    int
 	cmd    *exec.Cmd
 	uptime string
+	output []string
 }
 
-func (p *stubOSProcess) Start() error                     { return nil }
-func (p *stubOSProcess) Wait() error                      { return nil }
-func (p *stubOSProcess) Pid() int                         { return p.pid }
-func (p *stubOSProcess) Uptime() string                   { return p.uptime }
-func (p *stubOSProcess) SetOutputFile(fname string) error { return nil }
-func (p *stubOSProcess) Cmd() *exec.Cmd                   { return p.cmd }
+func (p *stubOSProcess) Start(func(*exec.Cmd) error) error { return nil }
+func (p *stubOSProcess) Wait() error                       { return nil }
+func (p *stubOSProcess) Pid() int                          { return p.pid }
+func (p *stubOSProcess) Uptime() string                    { return p.uptime }
+func (p *stubOSProcess) SetOutputFile(fname string) error  { return nil }
+func (p *stubOSProcess) Cmd() *exec.Cmd                    { return p.cmd }
+
+func (p *stubOSProcess) OutputTail(n int) []string {
+	if n > 0 && len(p.output) > n {
+		return p.output[len(p.output)-n:]
+	}
+	return p.output
+}
 
 type stubProcess struct {
 	info    *proc.ProcessInfo
@@ -217,3 +225,15 @@ type stubProcess struct {
 func (p *stubProcess) Info() *proc.ProcessInfo           { return p.info }
 func (p *stubProcess) Prepare(ctx context.Context) error { return nil }
 func (p *stubProcess) LogFile() string                   { return p.logFile }
+
+func TestDiffTopology(t *testing.T) {
+	pd := TopologyInstance{Name: "pd-0", Service: "pd", Port: 2379}
+	kv0 := TopologyInstance{Name: "tikv-0", Service: "tikv", Port: 20160}
+	kv1 := TopologyInstance{Name: "tikv-1", Service: "tikv", Port: 20161}
+
+	require.Nil(t, diffTopology([]TopologyInstance{pd, kv0}, []TopologyInstance{pd, kv0}))
+	require.Equal(t, &TopologyChange{
+		Added:   []TopologyInstance{kv1},
+		Removed: []TopologyInstance{kv0},
+	}, diffTopology([]TopologyInstance{pd, kv0}, []TopologyInstance{pd, kv1}))
+}
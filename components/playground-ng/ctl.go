@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pingcap/errors"
+	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// ctlComponentID is the component shipping the control tools of the cluster,
+// e.g. pd-ctl, next to its own binary.
+const ctlComponentID = "ctl"
+
+func newPDCtl(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+	var ctlVersion string
+
+	cmd := &cobra.Command{
+		Use:   "pd-ctl [args...]",
+		Short: "Run pd-ctl against a running playground",
+		Long: `Run pd-ctl of the cluster version against the first PD of a running
+playground, downloading the ctl component first if needed. The arguments are
+passed to pd-ctl; the flags of playground-ng go before them, and '--' passes
+pd-ctl flags such as -i.`,
+		Example: fmt.Sprintf("%[1]s pd-ctl --tag my-cluster store\n%[1]s pd-ctl -- -i", arg0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPDCtl(cmd.OutOrStdout(), args, ctlVersion, state)
+		},
+	}
+	// Everything after the first argument belongs to pd-ctl.
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().StringVar(&ctlVersion, "ctl.version", "", "ctl version, defaults to the cluster version")
+	return cmd
+}
+
+// runPDCtl runs pd-ctl with args and the PD address of the running
+// playground, attached to the terminal.
+func runPDCtl(out io.Writer, args []string, ctlVersion string, state *cliState) error {
+	target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+	if err != nil {
+		printDisplayFailureWarning(out, err)
+		return renderedError{err: err}
+	}
+	ready, err := loadReadyFile(target)
+	if err != nil {
+		return err
+	}
+	if len(ready.PD) == 0 {
+		return fmt.Errorf("playground %q has no PD instance", target.tag)
+	}
+	if ctlVersion == "" {
+		ctlVersion = ready.Version
+	}
+	if ctlVersion == "" {
+		ctlVersion = utils.LatestVersionAlias
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ui := progressv2.New(progressv2.Options{Mode: progressv2.ModeAuto, Out: os.Stderr})
+	group := ui.Group("Prepare pd-ctl")
+	binPath, _, err := ensureComponent(ctx, group, ctlComponentID, ctlVersion, state.proxy)
+	group.Close()
+	ui.Close()
+	if err != nil {
+		return err
+	}
+	stop()
+
+	ctlArgs := []string{"-u", ready.scheme() + "://" + ready.PD[0]}
+	if ready.TLSCA != "" {
+		ctlArgs = append(ctlArgs, "--cacert", ready.TLSCA, "--cert", ready.TLSCert, "--key", ready.TLSKey)
+	}
+	c := exec.Command(filepath.Join(filepath.Dir(binPath), "pd-ctl"), append(ctlArgs, args...)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, out, os.Stderr
+	if err := c.Run(); err != nil {
+		var ee *exec.ExitError
+		if stdErrors.As(err, &ee) {
+			// pd-ctl printed why.
+			return renderedError{err: err}
+		}
+		return errors.AddStack(err)
+	}
+	return nil
+}
+
+func newCurl(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+	var method, data string
+
+	cmd := &cobra.Command{
+		Use:   "curl <service|instance> <path>",
+		Short: "Call the HTTP API of an instance of a running playground",
+		Long: `Call the HTTP API of an instance of a running playground and print the
+response body: the status port of the instance, e.g. 10080 for TiDB, or the
+client port of PD.
+
+The instance is given by name (e.g. tikv-1) or by service (e.g. pd), which
+picks its first running instance. A response other than 2xx fails the
+command after printing its body.`,
+		Example: fmt.Sprintf("%[1]s curl pd /pd/api/v1/stores\n%[1]s curl tidb /status\n%[1]s curl pd /pd/api/v1/config -X POST -d '{\"max-merge-region-size\": 0}'", arg0),
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCurl(cmd.OutOrStdout(), args[0], args[1], method, data, state)
+		},
+	}
+	cmd.Flags().StringVarP(&method, "request", "X", "", "HTTP method, defaults to POST with --data and GET otherwise")
+	cmd.Flags().StringVarP(&data, "data", "d", "", "Request body, @<file> reads it from a file and @- from stdin")
+	return cmd
+}
+
+func runCurl(out io.Writer, instance, path, method, data string, state *cliState) error {
+	target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+	if err != nil {
+		printDisplayFailureWarning(out, err)
+		return renderedError{err: err}
+	}
+	ready, err := loadReadyFile(target)
+	if err != nil {
+		return err
+	}
+	client, err := ready.httpClient(0)
+	if err != nil {
+		return err
+	}
+	items, err := fetchDisplayJSON(target.client())
+	if err != nil {
+		return err
+	}
+	item, err := pickAPIInstance(items, instance)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	switch {
+	case data == "@-":
+		body = os.Stdin
+	case strings.HasPrefix(data, "@"):
+		f, err := os.Open(data[1:])
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		defer f.Close()
+		body = f
+	case data != "":
+		body = strings.NewReader(data)
+	}
+	if method == "" {
+		method = http.MethodGet
+		if body != nil {
+			method = http.MethodPost
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), statusURL(ready.scheme(), item, path), body)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return doCurl(out, client, req)
+}
+
+// doCurl sends req and copies the response body to out.
+func doCurl(out io.Writer, client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return errors.AddStack(err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL, resp.Status)
+	}
+	return nil
+}
+
+// pickAPIInstance returns the running instance named name, or else the first
+// running instance (by name) of the service name.
+func pickAPIInstance(items []displayItem, name string) (displayItem, error) {
+	var byService []displayItem
+	for _, item := range items {
+		if item.Status != "running" {
+			continue
+		}
+		if item.Name == name {
+			return item, nil
+		}
+		if item.ServiceID == name {
+			byService = append(byService, item)
+		}
+	}
+	if len(byService) == 0 {
+		var names []string
+		for _, item := range items {
+			if item.Status == "running" {
+				names = append(names, item.Name)
+			}
+		}
+		return displayItem{}, fmt.Errorf("no running instance or service named %q, the running instances are: %s", name, strings.Join(names, ", "))
+	}
+	slices.SortFunc(byService, func(a, b displayItem) int { return strings.Compare(a.Name, b.Name) })
+	return byService[0], nil
+}
+
+// statusURL returns the URL of path on the status port of item, or on its
+// client port if it has none.
+func statusURL(scheme string, item displayItem, path string) string {
+	host, _, err := net.SplitHostPort(item.Addr)
+	if err != nil || host == "" {
+		host = "127.0.0.1"
+	}
+	port := item.StatusPort
+	if port <= 0 {
+		port = item.ClientPort
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port)) + path
+}
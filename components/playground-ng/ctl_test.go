@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickAPIInstance(t *testing.T) {
+	items := []displayItem{
+		{Name: "tikv-1", ServiceID: "tikv", Addr: "127.0.0.1:20161", StatusPort: 20181, Status: "running"},
+		{Name: "tikv-0", ServiceID: "tikv", Addr: "127.0.0.1:20160", StatusPort: 20180, Status: "exited(1)"},
+		{Name: "tikv-2", ServiceID: "tikv", Addr: "127.0.0.1:20162", StatusPort: 20182, Status: "running"},
+		{Name: "pd-0", ServiceID: "pd", Addr: "127.0.0.1:2379", ClientPort: 2379, Status: "running"},
+	}
+
+	item, err := pickAPIInstance(items, "tikv")
+	require.NoError(t, err)
+	require.Equal(t, "tikv-1", item.Name, "first running instance of the service")
+
+	item, err = pickAPIInstance(items, "tikv-2")
+	require.NoError(t, err)
+	require.Equal(t, "tikv-2", item.Name)
+
+	_, err = pickAPIInstance(items, "tikv-0")
+	require.ErrorContains(t, err, `no running instance or service named "tikv-0", the running instances are: tikv-1, tikv-2, pd-0`)
+
+	require.Equal(t, "http://127.0.0.1:20182/metrics", statusURL("http", items[2], "/metrics"))
+	require.Equal(t, "https://127.0.0.1:2379/pd/api/v1/stores", statusURL("https", items[3], "pd/api/v1/stores"))
+}
+
+func TestDoCurl(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/missing" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(body)))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/pd/api/v1/config", strings.NewReader(`{"a":1}`))
+	require.NoError(t, err)
+	require.NoError(t, doCurl(&out, srv.Client(), req))
+	require.Equal(t, `POST /pd/api/v1/config {"a":1}`, out.String())
+
+	out.Reset()
+	req, err = http.NewRequest(http.MethodGet, srv.URL+"/missing", nil)
+	require.NoError(t, err)
+	require.ErrorContains(t, doCurl(&out, srv.Client(), req), "404 Not Found")
+	require.Equal(t, "not found\n", out.String(), "the body of a failed call is printed")
+}
@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	stdErrors "errors"
 	"fmt"
-	"net/http"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -14,18 +14,47 @@ import (
 	"time"
 
 	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
 	"github.com/pingcap/tiup/pkg/utils"
 )
 
 const (
 	playgroundPIDFileName     = "pid"
-	playgroundPortFileName    = "port"
+	playgroundPortFileName    = client.PortFileName
 	playgroundDaemonLogName   = "daemon.log"
 	playgroundTUIEventLogName = "tuiv2.events.jsonl"
+	playgroundReadyFileName   = "ready.json"
+	// playgroundInstancesFileName records the data/log dirs of every instance
+	// that ever ran, including those outside the data dir.
+	playgroundInstancesFileName = "instances.json"
+	// playgroundOperationFileName checkpoints a scale-out while it runs. It is
+	// only left behind when the playground is killed mid-operation.
+	playgroundOperationFileName = "operation.json"
+	// playgroundInvocationFileName records the resolved start invocation (see
+	// startInvocation).
+	playgroundInvocationFileName = "invocation.yaml"
+	// playgroundNetemFileName records the tc/netem qdisc of --inject-latency
+	// while it is on lo (see netemQdisc).
+	playgroundNetemFileName = "netem.json"
 )
 
 const pidFileWriteGracePeriod = 2 * time.Second
 
+// playgroundActiveTaskLimit caps the tasks shown per group in the TTY Active
+// area, so starting dozens of instances doesn't push the whole screen. It is
+// shared by the foreground UI and the daemon starter that replays its events.
+const playgroundActiveTaskLimit = 12
+
+// playgroundTUICheckpointInterval is how often the daemon snapshots its UI
+// state into the event log, bounding how much of the log a reader attaching
+// to a long-running playground has to replay.
+const playgroundTUICheckpointInterval = 30 * time.Second
+
+// playgroundTUIProgressInterval downsamples download progress in the daemon
+// event log, which would otherwise grow by thousands of lines per download.
+const playgroundTUIProgressInterval = 500 * time.Millisecond
+
 func isTimeoutErr(err error) bool {
 	if err == nil {
 		return false
@@ -107,78 +136,19 @@ func isPIDRunning(pid int) (running bool, err error) {
 	return false, err
 }
 
-func probePlaygroundCommandServer(ctx context.Context, port int) (bool, error) {
-	if port <= 0 {
-		return false, fmt.Errorf("invalid port %d", port)
-	}
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
-	client := &http.Client{}
-
-	pingReq, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/ping", port), nil)
-	if err != nil {
-		return false, errors.AddStack(err)
-	}
-	pingResp, err := client.Do(pingReq)
-	if err != nil {
-		if ctx.Err() != nil {
-			return false, err
-		}
-	} else {
-		defer pingResp.Body.Close()
-		if pingResp.StatusCode == http.StatusOK {
-			var reply CommandReply
-			if err := json.NewDecoder(pingResp.Body).Decode(&reply); err != nil {
-				return false, err
-			}
-			if reply.OK && strings.TrimSpace(reply.Message) == "pong" {
-				return true, nil
-			}
-			return false, fmt.Errorf("unexpected ping response")
-		}
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/command", port), nil)
-	if err != nil {
-		return false, errors.AddStack(err)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, err
-	}
-	defer resp.Body.Close()
-
-	var reply CommandReply
-	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
-		return false, err
-	}
-
-	if resp.StatusCode != http.StatusMethodNotAllowed {
-		return false, fmt.Errorf("unexpected probe status: %s", resp.Status)
-	}
-
-	if !reply.OK && reply.Error == "method not allowed" {
-		return true, nil
-	}
-
-	return false, fmt.Errorf("unexpected probe response")
-}
-
-func cleanupStaleRuntimeFiles(dataDir string) error {
+func (r runtimeOps) cleanupStaleRuntimeFiles(dataDir string) error {
 	if strings.TrimSpace(dataDir) == "" {
 		return fmt.Errorf("data dir is empty")
 	}
 
 	pidPath := filepath.Join(dataDir, playgroundPIDFileName)
 	portPath := filepath.Join(dataDir, playgroundPortFileName)
+	readyPath := filepath.Join(dataDir, playgroundReadyFileName)
 
 	pid, err := readPIDFile(pidPath)
 	switch {
 	case err == nil:
-		running, runErr := isPIDRunning(pid.pid)
+		running, runErr := r.procs.Running(pid.pid)
 		if runErr != nil {
 			return errors.Annotatef(runErr, "check pid %d", pid.pid)
 		}
@@ -187,6 +157,7 @@ func cleanupStaleRuntimeFiles(dataDir string) error {
 		}
 		_ = os.Remove(pidPath)
 		_ = os.Remove(portPath)
+		_ = os.Remove(readyPath)
 		return nil
 	case !os.IsNotExist(err):
 		info, statErr := os.Stat(pidPath)
@@ -194,17 +165,17 @@ func cleanupStaleRuntimeFiles(dataDir string) error {
 			return errors.AddStack(statErr)
 		}
 		if statErr == nil {
-			age := time.Since(info.ModTime())
+			age := r.clock.Now().Sub(info.ModTime())
 			if age >= 0 && age < pidFileWriteGracePeriod {
 				return fmt.Errorf("playground is starting (pid file is being written)")
 			}
 
 			// The pid file is present but invalid (corrupted/partial). Use the port
 			// probe as a safety net before treating it as stale.
-			port, portErr := loadPort(dataDir)
+			port, portErr := client.ReadPort(dataDir)
 			if portErr == nil && port > 0 {
 				ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-				ok, probeErr := probePlaygroundCommandServer(ctx, port)
+				ok, probeErr := r.procs.Probe(ctx, port)
 				cancel()
 				if ok && probeErr == nil {
 					return fmt.Errorf("playground already running (port=%d)", port)
@@ -216,13 +187,15 @@ func cleanupStaleRuntimeFiles(dataDir string) error {
 
 			_ = os.Remove(pidPath)
 			_ = os.Remove(portPath)
+			_ = os.Remove(readyPath)
 			return nil
 		}
 	}
 
-	port, err := loadPort(dataDir)
+	port, err := client.ReadPort(dataDir)
 	if err != nil {
 		if os.IsNotExist(err) {
+			_ = os.Remove(readyPath)
 			return nil
 		}
 		return errors.AddStack(err)
@@ -233,7 +206,7 @@ func cleanupStaleRuntimeFiles(dataDir string) error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
-	ok, probeErr := probePlaygroundCommandServer(ctx, port)
+	ok, probeErr := r.procs.Probe(ctx, port)
 	if ok && probeErr == nil {
 		return fmt.Errorf("playground already running (port=%d)", port)
 	}
@@ -246,13 +219,15 @@ func cleanupStaleRuntimeFiles(dataDir string) error {
 
 	if stdErrors.Is(probeErr, syscall.ECONNREFUSED) {
 		_ = os.Remove(portPath)
+		_ = os.Remove(readyPath)
 		return nil
 	}
 	_ = os.Remove(portPath)
+	_ = os.Remove(readyPath)
 	return nil
 }
 
-func claimPlaygroundPIDFile(dataDir, tag string) (release func(), err error) {
+func (r runtimeOps) claimPlaygroundPIDFile(dataDir, tag string) (release func(), err error) {
 	if strings.TrimSpace(dataDir) == "" {
 		return nil, fmt.Errorf("data dir is empty")
 	}
@@ -263,7 +238,7 @@ func claimPlaygroundPIDFile(dataDir, tag string) (release func(), err error) {
 		return nil, err
 	}
 
-	if err := cleanupStaleRuntimeFiles(dataDir); err != nil {
+	if err := r.cleanupStaleRuntimeFiles(dataDir); err != nil {
 		return nil, errors.Annotatef(err, "tag %q is already in use", tag)
 	}
 
@@ -271,7 +246,7 @@ func claimPlaygroundPIDFile(dataDir, tag string) (release func(), err error) {
 	for {
 		f, err := os.OpenFile(pidPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
 		if err == nil {
-			now := time.Now().UTC().Format(time.RFC3339)
+			now := r.clock.Now().UTC().Format(time.RFC3339)
 			_, writeErr := fmt.Fprintf(f, "pid=%d\nstarted_at=%s\ntag=%s\n", os.Getpid(), now, tag)
 			closeErr := f.Close()
 			if writeErr != nil {
@@ -288,64 +263,342 @@ func claimPlaygroundPIDFile(dataDir, tag string) (release func(), err error) {
 		if !os.IsExist(err) {
 			return nil, errors.AddStack(err)
 		}
-		if err := cleanupStaleRuntimeFiles(dataDir); err != nil {
+		if err := r.cleanupStaleRuntimeFiles(dataDir); err != nil {
 			return nil, errors.Annotatef(err, "tag %q is already in use", tag)
 		}
 	}
 }
 
-func waitPlaygroundStopped(dataDir string, timeout time.Duration) error {
+// playgroundWaitState is a state `wait --for` can block until.
+type playgroundWaitState string
+
+const (
+	// playgroundWaitReady is reached once the ready file is written and the
+	// command server answers.
+	playgroundWaitReady playgroundWaitState = "ready"
+	// playgroundWaitStopped is reached once the playground process is gone.
+	playgroundWaitStopped playgroundWaitState = "stopped"
+)
+
+// waitPlayground polls the runtime files and the command server of the
+// playground in dataDir until it reaches state. Waiting for ready fails early
+// if the playground is seen running and then stops.
+func (r runtimeOps) waitPlayground(dataDir string, state playgroundWaitState, timeout time.Duration) error {
 	if strings.TrimSpace(dataDir) == "" {
 		return fmt.Errorf("data dir is empty")
 	}
+	if state != playgroundWaitReady && state != playgroundWaitStopped {
+		return fmt.Errorf("unknown playground state %q", state)
+	}
 	if timeout <= 0 {
 		timeout = 60 * time.Second
 	}
 
-	deadline := time.Now().Add(timeout)
+	deadline := r.clock.Now().Add(timeout)
+	seenRunning := false
+	for {
+		stopped := r.isPlaygroundStopped(dataDir)
+		switch {
+		case state == playgroundWaitStopped && stopped:
+			return nil
+		case state == playgroundWaitReady && !stopped && r.isPlaygroundReady(dataDir):
+			return nil
+		case state == playgroundWaitReady && stopped && seenRunning:
+			return fmt.Errorf("playground exited before ready")
+		}
+		seenRunning = seenRunning || !stopped
+
+		if r.clock.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for playground to be %s", state)
+		}
+		<-r.clock.After(200 * time.Millisecond)
+	}
+}
+
+// isPlaygroundStopped reports whether no playground process owns dataDir,
+// removing the runtime files it left behind if it died.
+func (r runtimeOps) isPlaygroundStopped(dataDir string) bool {
 	pidPath := filepath.Join(dataDir, playgroundPIDFileName)
 	portPath := filepath.Join(dataDir, playgroundPortFileName)
 
-	for {
-		pid, err := readPIDFile(pidPath)
-		if err != nil {
-			if os.IsNotExist(err) {
-				return nil
-			}
-			info, statErr := os.Stat(pidPath)
-			if statErr != nil {
-				if os.IsNotExist(statErr) {
-					return nil
-				}
-			} else {
-				age := time.Since(info.ModTime())
-				if age >= pidFileWriteGracePeriod {
-					port, portErr := loadPort(dataDir)
-					stillRunning := false
-					if portErr == nil && port > 0 {
-						ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-						ok, probeErr := probePlaygroundCommandServer(ctx, port)
-						cancel()
-						stillRunning = (ok && probeErr == nil) || isTimeoutErr(probeErr)
-					}
-					if !stillRunning {
-						_ = os.Remove(pidPath)
-						_ = os.Remove(portPath)
-						return nil
-					}
+	pid, err := readPIDFile(pidPath)
+	if err == nil {
+		running, runErr := r.procs.Running(pid.pid)
+		if runErr == nil && !running {
+			_ = os.Remove(pidPath)
+			return true
+		}
+		return false
+	}
+	if os.IsNotExist(err) {
+		return true
+	}
+
+	info, statErr := os.Stat(pidPath)
+	if statErr != nil {
+		return os.IsNotExist(statErr)
+	}
+	if r.clock.Now().Sub(info.ModTime()) < pidFileWriteGracePeriod {
+		return false
+	}
+	port, portErr := client.ReadPort(dataDir)
+	if portErr == nil && port > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		ok, probeErr := r.procs.Probe(ctx, port)
+		cancel()
+		if (ok && probeErr == nil) || isTimeoutErr(probeErr) {
+			return false
+		}
+	}
+	_ = os.Remove(pidPath)
+	_ = os.Remove(portPath)
+	return true
+}
+
+// isPlaygroundReady reports whether the playground in dataDir wrote its ready
+// file and its command server answers.
+func (r runtimeOps) isPlaygroundReady(dataDir string) bool {
+	if _, err := os.Stat(filepath.Join(dataDir, playgroundReadyFileName)); err != nil {
+		return false
+	}
+	port, err := client.ReadPort(dataDir)
+	if err != nil || port <= 0 {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	ok, err := r.procs.Probe(ctx, port)
+	return ok && err == nil
+}
+
+// retagDataDir moves the playground data dir to the sibling dir named after
+// tag and rewrites the tag recorded in its files. It returns the new dir.
+//
+// A running playground keeps using its original path: it is replaced by a
+// symlink to the new dir, so its instances keep working, and removed when the
+// playground exits (see removeRetagLink). Retagging again moves the data dir
+// and re-points the same symlink.
+func retagDataDir(dataDir, tag string, running bool) (string, error) {
+	if tag == "" || tag != filepath.Base(tag) || tag == "." || tag == ".." {
+		return "", fmt.Errorf("invalid tag %q", tag)
+	}
+	cur := realDataDir(dataDir)
+	newDir := filepath.Join(filepath.Dir(dataDir), tag)
+	if _, err := os.Lstat(newDir); err == nil {
+		return "", fmt.Errorf("tag %q is already in use", tag)
+	} else if !os.IsNotExist(err) {
+		return "", errors.AddStack(err)
+	}
+
+	if err := os.Rename(cur, newDir); err != nil {
+		return "", errors.AddStack(err)
+	}
+	if running {
+		// The original path is briefly missing here: keep this window short.
+		if cur != dataDir {
+			_ = os.Remove(dataDir)
+		}
+		if err := os.Symlink(newDir, dataDir); err != nil {
+			_ = os.Rename(newDir, cur)
+			return "", errors.AddStack(err)
+		}
+	}
+
+	if err := rewritePIDFileTag(filepath.Join(newDir, playgroundPIDFileName), tag); err != nil && !os.IsNotExist(err) {
+		return newDir, err
+	}
+	if records := readInstanceRegistry(newDir); records != nil {
+		for i, r := range records {
+			records[i].Dir = rebaseDir(r.Dir, cur, newDir)
+			records[i].LogDir = rebaseDir(r.LogDir, cur, newDir)
+		}
+		if err := writeInstanceRecords(newDir, records); err != nil {
+			return newDir, err
+		}
+	}
+	return newDir, nil
+}
+
+// rewritePIDFileTag atomically replaces the tag field of the pid file.
+func rewritePIDFileTag(path, tag string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	found := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "tag=") {
+			lines[i] = "tag=" + tag
+			found = true
+		}
+	}
+	if !found {
+		lines = append(lines, "tag="+tag)
+	}
+	tmp := path + ".tmp"
+	if err := utils.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// rebaseDir moves dir from under oldBase to under newBase. Dirs outside
+// oldBase are returned as is.
+func rebaseDir(dir, oldBase, newBase string) string {
+	rel, err := filepath.Rel(oldBase, dir)
+	if dir == "" || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return dir
+	}
+	return filepath.Join(newBase, rel)
+}
+
+// realDataDir returns the dir the data of the playground in dataDir lives in,
+// following the symlink left by retagDataDir.
+func realDataDir(dataDir string) string {
+	if target, err := os.Readlink(dataDir); err == nil {
+		return target
+	}
+	return dataDir
+}
+
+// removeRetagLink removes the symlink left at dataDir by retagDataDir, if any.
+func removeRetagLink(dataDir string) {
+	if fi, err := os.Lstat(dataDir); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		_ = os.Remove(dataDir)
+	}
+}
+
+// handleRetag runs in the controller goroutine, so no other command is
+// handled while the data dir moves.
+func (p *Playground) handleRetag(w io.Writer, req *RetagRequest) error {
+	if req == nil {
+		return fmt.Errorf("missing retag request")
+	}
+	if p.dataDir == "" {
+		return fmt.Errorf("playground data dir is unknown")
+	}
+	oldTag := filepath.Base(realDataDir(p.dataDir))
+	if _, err := retagDataDir(p.dataDir, req.Tag, true); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Playground %q retagged to %q\n", oldTag, req.Tag)
+	return nil
+}
+
+// operationCheckpoint records an in-flight scale-out in the data dir, so a
+// playground restarted on it can tell the operation was interrupted and roll
+// it forward or back (see resolveInterruptedOperation).
+type operationCheckpoint struct {
+	ScaleOut *ScaleOutRequest `json:"scale_out"`
+	// Created lists the instances created so far, whether or not they
+	// finished starting.
+	Created []instanceRecord `json:"created,omitempty"`
+}
+
+// writeOperationCheckpoint atomically replaces the checkpoint under dataDir.
+func writeOperationCheckpoint(dataDir string, op *operationCheckpoint) error {
+	if dataDir == "" || op == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dataDir, playgroundOperationFileName)
+	tmp := path + ".tmp"
+	if err := utils.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readOperationCheckpoint returns the checkpoint under dataDir, or nil if
+// there is none.
+func readOperationCheckpoint(dataDir string) (*operationCheckpoint, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, playgroundOperationFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var op operationCheckpoint
+	if err := json.Unmarshal(data, &op); err != nil {
+		return nil, errors.Annotatef(err, "parse %s", playgroundOperationFileName)
+	}
+	if op.ScaleOut == nil {
+		return nil, fmt.Errorf("%s has no operation", playgroundOperationFileName)
+	}
+	return &op, nil
+}
+
+func removeOperationCheckpoint(dataDir string) {
+	if dataDir == "" {
+		return
+	}
+	_ = os.Remove(filepath.Join(dataDir, playgroundOperationFileName))
+}
+
+// Actions for an operation interrupted by a kill of the previous playground
+// on the same data dir (--interrupted-op).
+const (
+	interruptedOpForward  = "forward"
+	interruptedOpRollback = "rollback"
+)
+
+func validateInterruptedOpAction(action string) error {
+	switch action {
+	case "", interruptedOpForward, interruptedOpRollback:
+		return nil
+	}
+	return fmt.Errorf("invalid --interrupted-op %q (expected %s or %s)", action, interruptedOpForward, interruptedOpRollback)
+}
+
+// resolveInterruptedOperation handles a scale-out checkpoint left in dataDir
+// by a killed playground. Without an action it fails with hints, so the
+// half-created instances are not silently dropped. Rolling back removes their
+// dirs; rolling forward returns the scale-out to run again once booted, and
+// keeps the checkpoint until that run replaces it.
+func resolveInterruptedOperation(dataDir, action string) (*ScaleOutRequest, error) {
+	op, err := readOperationCheckpoint(dataDir)
+	if err != nil {
+		return nil, errors.Annotate(err, "read interrupted operation")
+	}
+	if op == nil {
+		return nil, nil
+	}
+
+	req := op.ScaleOut
+	names := make([]string, 0, len(op.Created))
+	for _, r := range op.Created {
+		names = append(names, r.Name)
+	}
+	switch action {
+	case interruptedOpForward:
+		return req, nil
+	case interruptedOpRollback:
+		for _, r := range op.Created {
+			for _, dir := range []string{r.Dir, r.LogDir} {
+				if dir != "" {
+					_ = os.RemoveAll(dir)
 				}
 			}
-		} else {
-			running, runErr := isPIDRunning(pid.pid)
-			if runErr == nil && !running {
-				_ = os.Remove(pidPath)
-				return nil
-			}
 		}
+		removeOperationCheckpoint(dataDir)
+		return nil, nil
+	}
 
-		if time.Now().After(deadline) {
-			return fmt.Errorf("timeout waiting for playground to stop")
-		}
-		time.Sleep(200 * time.Millisecond)
+	msg := fmt.Sprintf("the previous playground was stopped while scaling out %d %s instance(s)",
+		req.Count, proc.ServiceDisplayName(req.ServiceID))
+	if len(names) > 0 {
+		msg += fmt.Sprintf(" (created: %s)", strings.Join(names, ", "))
+	}
+	return nil, &bootOptionError{
+		msg: msg,
+		hints: []string{
+			fmt.Sprintf("add --interrupted-op=%s to run the scale-out again once booted", interruptedOpForward),
+			fmt.Sprintf("add --interrupted-op=%s to remove the instances it created", interruptedOpRollback),
+		},
 	}
 }
@@ -14,6 +14,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
 	"github.com/stretchr/testify/require"
 )
 
@@ -34,7 +36,7 @@ func TestReadPIDFile_ParsesStartedAtAndTag(t *testing.T) {
 func TestClaimPlaygroundPIDFile_CreatesAndReleases(t *testing.T) {
 	base := t.TempDir()
 
-	release, err := claimPlaygroundPIDFile(base, "test")
+	release, err := newRuntimeOps().claimPlaygroundPIDFile(base, "test")
 	require.NoError(t, err)
 	require.FileExists(t, filepath.Join(base, playgroundPIDFileName))
 
@@ -48,7 +50,7 @@ func TestClaimPlaygroundPIDFile_RunningPIDRejects(t *testing.T) {
 	pidPath := filepath.Join(base, playgroundPIDFileName)
 	require.NoError(t, os.WriteFile(pidPath, []byte("pid="+strconv.Itoa(os.Getpid())+"\n"), 0o644))
 
-	_, err := claimPlaygroundPIDFile(base, "test")
+	_, err := newRuntimeOps().claimPlaygroundPIDFile(base, "test")
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "already in use")
 }
@@ -73,7 +75,7 @@ func TestClaimPlaygroundPIDFile_PortOnlyRunningRejects(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, dumpPort(filepath.Join(base, playgroundPortFileName), port))
 
-	_, err = claimPlaygroundPIDFile(base, "test")
+	_, err = newRuntimeOps().claimPlaygroundPIDFile(base, "test")
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "already in use")
 	_, err = os.Stat(filepath.Join(base, playgroundPIDFileName))
@@ -99,7 +101,7 @@ func TestCleanupStaleRuntimeFiles_RemovesStalePIDAndPort(t *testing.T) {
 	require.NoError(t, os.WriteFile(filepath.Join(base, playgroundPIDFileName), []byte("pid="+strconv.Itoa(stalePID)+"\n"), 0o644))
 	require.NoError(t, os.WriteFile(filepath.Join(base, playgroundPortFileName), []byte("12345"), 0o644))
 
-	require.NoError(t, cleanupStaleRuntimeFiles(base))
+	require.NoError(t, newRuntimeOps().cleanupStaleRuntimeFiles(base))
 	_, err := os.Stat(filepath.Join(base, playgroundPIDFileName))
 	require.True(t, os.IsNotExist(err))
 	_, err = os.Stat(filepath.Join(base, playgroundPortFileName))
@@ -116,7 +118,7 @@ func TestCleanupStaleRuntimeFiles_RemovesStalePort(t *testing.T) {
 
 	require.NoError(t, os.WriteFile(filepath.Join(base, playgroundPortFileName), []byte(strconv.Itoa(port)), 0o644))
 
-	require.NoError(t, cleanupStaleRuntimeFiles(base))
+	require.NoError(t, newRuntimeOps().cleanupStaleRuntimeFiles(base))
 	_, err = os.Stat(filepath.Join(base, playgroundPortFileName))
 	require.True(t, os.IsNotExist(err))
 }
@@ -138,7 +140,7 @@ func TestCleanupStaleRuntimeFiles_PortProbeTimeoutTreatedAsInUse(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, dumpPort(filepath.Join(base, playgroundPortFileName), port))
 
-	err = cleanupStaleRuntimeFiles(base)
+	err = newRuntimeOps().cleanupStaleRuntimeFiles(base)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "timed out")
 	require.FileExists(t, filepath.Join(base, playgroundPortFileName))
@@ -166,7 +168,7 @@ func TestCleanupStaleRuntimeFiles_InvalidPIDTimeoutTreatedAsInUse(t *testing.T)
 	require.NoError(t, err)
 	require.NoError(t, dumpPort(filepath.Join(base, playgroundPortFileName), port))
 
-	err = cleanupStaleRuntimeFiles(base)
+	err = newRuntimeOps().cleanupStaleRuntimeFiles(base)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "timed out")
 	require.FileExists(t, pidPath)
@@ -184,7 +186,7 @@ func TestCleanupStaleRuntimeFiles_StaleInvalidPIDIsRemoved(t *testing.T) {
 	old := time.Now().Add(-time.Minute)
 	require.NoError(t, os.Chtimes(pidPath, old, old))
 
-	require.NoError(t, cleanupStaleRuntimeFiles(base))
+	require.NoError(t, newRuntimeOps().cleanupStaleRuntimeFiles(base))
 	_, err := os.Stat(pidPath)
 	require.True(t, os.IsNotExist(err))
 	_, err = os.Stat(portPath)
@@ -199,7 +201,7 @@ func TestCleanupStaleRuntimeFiles_RecentInvalidPIDIsTreatedAsInUse(t *testing.T)
 	now := time.Now()
 	require.NoError(t, os.Chtimes(pidPath, now, now))
 
-	err := cleanupStaleRuntimeFiles(base)
+	err := newRuntimeOps().cleanupStaleRuntimeFiles(base)
 	require.Error(t, err)
 	require.FileExists(t, pidPath)
 }
@@ -228,7 +230,7 @@ func TestProbePlaygroundCommandServer_PingSucceeds(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	ok, err := probePlaygroundCommandServer(ctx, port)
+	ok, err := client.Probe(ctx, port)
 	require.NoError(t, err)
 	require.True(t, ok)
 }
@@ -258,13 +260,13 @@ func TestProbePlaygroundCommandServer_FallbackToCommandWhenPingMissing(t *testin
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	ok, err := probePlaygroundCommandServer(ctx, port)
+	ok, err := client.Probe(ctx, port)
 	require.NoError(t, err)
 	require.True(t, ok)
 	require.True(t, commandCalled)
 }
 
-func TestWaitPlaygroundStopped_StaleInvalidPIDIsRemoved(t *testing.T) {
+func TestWaitPlayground_StoppedRemovesStaleInvalidPID(t *testing.T) {
 	base := t.TempDir()
 
 	pidPath := filepath.Join(base, playgroundPIDFileName)
@@ -273,7 +275,136 @@ func TestWaitPlaygroundStopped_StaleInvalidPIDIsRemoved(t *testing.T) {
 	old := time.Now().Add(-time.Minute)
 	require.NoError(t, os.Chtimes(pidPath, old, old))
 
-	require.NoError(t, waitPlaygroundStopped(base, time.Second))
+	require.NoError(t, newRuntimeOps().waitPlayground(base, playgroundWaitStopped, time.Second))
 	_, err := os.Stat(pidPath)
 	require.True(t, os.IsNotExist(err))
 }
+
+func TestWaitPlayground_Ready(t *testing.T) {
+	base := t.TempDir()
+	pidPath := filepath.Join(base, playgroundPIDFileName)
+	require.NoError(t, os.WriteFile(pidPath, []byte("pid="+strconv.Itoa(os.Getpid())+"\n"), 0o644))
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(CommandReply{OK: true, Message: "pong"})
+	}))
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	require.NoError(t, dumpPort(filepath.Join(base, playgroundPortFileName), port))
+
+	// The command server answers, but the playground is not ready yet.
+	require.ErrorContains(t, newRuntimeOps().waitPlayground(base, playgroundWaitReady, 300*time.Millisecond), "timeout")
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = writeReadyFile(filepath.Join(base, playgroundReadyFileName), &playgroundReady{Port: port})
+	}()
+	require.NoError(t, newRuntimeOps().waitPlayground(base, playgroundWaitReady, 2*time.Second))
+}
+
+func TestWaitPlayground_ReadyFailsWhenPlaygroundExits(t *testing.T) {
+	base := t.TempDir()
+	pidPath := filepath.Join(base, playgroundPIDFileName)
+	require.NoError(t, os.WriteFile(pidPath, []byte("pid="+strconv.Itoa(os.Getpid())+"\n"), 0o644))
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_ = os.Remove(pidPath)
+	}()
+	require.ErrorContains(t, newRuntimeOps().waitPlayground(base, playgroundWaitReady, 5*time.Second), "exited before ready")
+}
+
+func TestRetagDataDir_RunningKeepsOriginalPathWorking(t *testing.T) {
+	base := t.TempDir()
+	dataDir := filepath.Join(base, "3kQ8zX")
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "pd-0"), 0o755))
+	release, err := newRuntimeOps().claimPlaygroundPIDFile(dataDir, "3kQ8zX")
+	require.NoError(t, err)
+	require.NoError(t, writeInstanceRecords(dataDir, []instanceRecord{
+		{Name: "pd-0", Service: "pd", Dir: filepath.Join(dataDir, "pd-0")},
+	}))
+
+	newDir, err := retagDataDir(dataDir, "my-cluster", true)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(base, "my-cluster"), newDir)
+	require.DirExists(t, filepath.Join(newDir, "pd-0"))
+	// Instances keep writing through the original path.
+	require.DirExists(t, filepath.Join(dataDir, "pd-0"))
+
+	pid, err := readPIDFile(filepath.Join(newDir, playgroundPIDFileName))
+	require.NoError(t, err)
+	require.Equal(t, "my-cluster", pid.tag)
+	require.Equal(t, filepath.Join(newDir, "pd-0"), readInstanceRegistry(newDir)[0].Dir)
+
+	// Retagging again moves the data and re-points the original path.
+	newDir, err = retagDataDir(dataDir, "final", true)
+	require.NoError(t, err)
+	require.NoDirExists(t, filepath.Join(base, "my-cluster"))
+	require.DirExists(t, filepath.Join(dataDir, "pd-0"))
+	require.Equal(t, filepath.Join(newDir, "pd-0"), readInstanceRegistry(newDir)[0].Dir)
+
+	release()
+	removeRetagLink(dataDir)
+	require.NoFileExists(t, filepath.Join(newDir, playgroundPIDFileName))
+	_, err = os.Lstat(dataDir)
+	require.True(t, os.IsNotExist(err))
+	require.DirExists(t, filepath.Join(newDir, "pd-0"))
+}
+
+func TestRetagDataDir_RejectsInvalidOrUsedTag(t *testing.T) {
+	base := t.TempDir()
+	dataDir := filepath.Join(base, "old")
+	require.NoError(t, os.MkdirAll(dataDir, 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "used"), 0o755))
+
+	for _, tag := range []string{"", ".", "..", "a/b"} {
+		_, err := retagDataDir(dataDir, tag, false)
+		require.ErrorContains(t, err, "invalid tag", tag)
+	}
+	_, err := retagDataDir(dataDir, "used", false)
+	require.ErrorContains(t, err, `tag "used" is already in use`)
+
+	newDir, err := retagDataDir(dataDir, "new", false)
+	require.NoError(t, err)
+	require.DirExists(t, newDir)
+	_, err = os.Lstat(dataDir)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestResolveInterruptedOperation(t *testing.T) {
+	dataDir := t.TempDir()
+	req, err := resolveInterruptedOperation(dataDir, "")
+	require.NoError(t, err)
+	require.Nil(t, req)
+
+	instDir := filepath.Join(dataDir, "tikv-1")
+	require.NoError(t, os.MkdirAll(instDir, 0o755))
+	op := &operationCheckpoint{
+		ScaleOut: &ScaleOutRequest{ServiceID: proc.ServiceTiKV, Count: 2},
+		Created:  []instanceRecord{{Name: "tikv-1", Service: proc.ServiceTiKV.String(), Dir: instDir}},
+	}
+	require.NoError(t, writeOperationCheckpoint(dataDir, op))
+
+	_, err = resolveInterruptedOperation(dataDir, "")
+	require.ErrorContains(t, err, "stopped while scaling out 2 TiKV instance(s) (created: tikv-1)")
+	require.Len(t, errorHints(err), 2)
+	require.FileExists(t, filepath.Join(dataDir, playgroundOperationFileName))
+
+	req, err = resolveInterruptedOperation(dataDir, interruptedOpForward)
+	require.NoError(t, err)
+	require.Equal(t, op.ScaleOut, req)
+	require.FileExists(t, filepath.Join(dataDir, playgroundOperationFileName))
+	require.DirExists(t, instDir)
+
+	req, err = resolveInterruptedOperation(dataDir, interruptedOpRollback)
+	require.NoError(t, err)
+	require.Nil(t, req)
+	require.NoDirExists(t, instDir)
+	require.NoFileExists(t, filepath.Join(dataDir, playgroundOperationFileName))
+
+	require.Error(t, validateInterruptedOpAction("redo"))
+}
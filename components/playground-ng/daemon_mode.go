@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -14,10 +13,12 @@ import (
 	"time"
 
 	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
 	"github.com/pingcap/tiup/pkg/tui/colorstr"
 	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
 	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
 	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
+	"github.com/spf13/cobra"
 )
 
 func runBackgroundStarter(state *cliState) error {
@@ -34,7 +35,7 @@ func runBackgroundStarter(state *cliState) error {
 		return fmt.Errorf("data dir is empty")
 	}
 
-	if err := cleanupStaleRuntimeFiles(state.dataDir); err != nil {
+	if err := state.ops.cleanupStaleRuntimeFiles(state.dataDir); err != nil {
 		return errors.Annotatef(err, "tag %q is already in use", state.tag)
 	}
 
@@ -79,23 +80,27 @@ func runBackgroundStarter(state *cliState) error {
 
 	cmd.Env = daemonEnv()
 
-	ui := progressv2.New(progressv2.Options{Mode: progressv2.ModeAuto, Out: os.Stdout})
+	ui := progressv2.New(progressv2.Options{
+		Mode:            progressv2.ModeAuto,
+		Out:             os.Stdout,
+		ActiveTaskLimit: playgroundActiveTaskLimit,
+	})
 	defer ui.Close()
 	restore := attachUIOutput(ui)
 	defer restore()
 
 	tailCtx, cancelTail := context.WithCancel(context.Background())
 	defer cancelTail()
-	stopTailAtCh := make(chan int64, 1)
+	readyCh := make(chan struct{})
 	tailDoneCh := make(chan struct{})
 	go func() {
-		tailEventLog(tailCtx, eventLogPath, eventOffset, ui, stopTailAtCh)
+		tailEventLog(tailCtx, eventLogPath, eventOffset, ui, readyCh)
 		close(tailDoneCh)
 	}()
 
 	waitCh := make(chan error, 1)
 
-	if err := cmd.Start(); err != nil {
+	if err := state.ops.procs.Start(cmd); err != nil {
 		_ = logWriter.Close()
 		return errors.Annotate(err, "start playground daemon")
 	}
@@ -125,23 +130,16 @@ func runBackgroundStarter(state *cliState) error {
 			}
 			return errors.Annotate(err, "playground daemon exited before ready")
 		case <-ticker.C:
-			port, err := loadPort(state.dataDir)
+			port, err := client.ReadPort(state.dataDir)
 			if err != nil || port <= 0 {
 				continue
 			}
 
 			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-			ok, probeErr := probePlaygroundCommandServer(ctx, port)
+			ok, probeErr := state.ops.procs.Probe(ctx, port)
 			cancel()
 			if ok && probeErr == nil {
-				stopAt := int64(0)
-				if st, err := os.Stat(eventLogPath); err == nil {
-					stopAt = st.Size()
-				}
-				select {
-				case stopTailAtCh <- stopAt:
-				default:
-				}
+				close(readyCh)
 				select {
 				case <-tailDoneCh:
 				case <-time.After(5 * time.Second):
@@ -164,6 +162,9 @@ func daemonEnv() []string {
 	if os.Getenv(tuiterm.EnvNoColor) != "" {
 		return env
 	}
+	if os.Getenv(tuiterm.EnvTiUPColor) != "" {
+		return env
+	}
 	if os.Getenv(tuiterm.EnvForceTTY) != "" {
 		return env
 	}
@@ -203,75 +204,118 @@ func buildDaemonArgs(tag string) []string {
 	return out
 }
 
-func tailEventLog(ctx context.Context, path string, offset int64, ui *progressv2.UI, stopAtCh <-chan int64) {
+// tailEventLog replays the event log at path from offset into ui until ctx is
+// done. Once readyCh is closed, it returns at the end of the log as soon as it
+// has replayed a sync barrier: the daemon syncs its UI before exposing
+// readiness, so everything it printed until then has been replayed.
+func tailEventLog(ctx context.Context, path string, offset int64, ui *progressv2.UI, readyCh <-chan struct{}) {
 	if ui == nil {
 		return
 	}
 
-	f, err := os.Open(path)
+	r, err := progressv2.OpenEventLog(path)
 	if err != nil {
 		return
 	}
-	defer f.Close()
+	defer r.Close()
 
 	if offset > 0 {
-		_, _ = f.Seek(offset, io.SeekStart)
+		if err := r.SetOffset(offset); err != nil {
+			return
+		}
 	}
 
-	buf := make([]byte, 32*1024)
-	var pending []byte
-	pos := offset
-	stopAt := int64(-1)
+	synced, warned := false, false
 	for {
-		if ctx != nil {
-			select {
-			case <-ctx.Done():
-				return
-			default:
+		e, err := r.Next()
+		if err == nil {
+			if !warned && r.Gaps() > 0 {
+				warned = true
+				ui.WarnLines([]string{"Some daemon output is missing: the progress shown may be incomplete."})
 			}
+			synced = synced || e.Type == progressv2.EventSync
+			ui.ReplayEvent(e)
+			continue
+		}
+		if err != io.EOF {
+			return
 		}
 
-		if stopAtCh != nil && stopAt < 0 {
+		if synced && readyCh != nil {
 			select {
-			case stopAt = <-stopAtCh:
-				if stopAt < 0 {
-					stopAt = 0
-				}
+			case <-readyCh:
+				return
 			default:
 			}
 		}
-
-		n, err := f.Read(buf)
-		if n > 0 {
-			pending = append(pending, buf[:n]...)
-			pos += int64(n)
-			for {
-				i := bytes.IndexByte(pending, '\n')
-				if i < 0 {
-					break
-				}
-				line := bytes.TrimSpace(pending[:i])
-				pending = pending[i+1:]
-				if len(line) == 0 {
-					continue
-				}
-				if e, decErr := progressv2.DecodeEvent(line); decErr == nil {
-					ui.ReplayEvent(e)
-				}
-			}
+		if err := r.Wait(ctx); err != nil {
+			return
 		}
+	}
+}
 
-		switch err {
-		case nil:
-			continue
-		case io.EOF:
-			if stopAt >= 0 && pos >= stopAt && len(pending) == 0 {
-				return
+func newDebug() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "debug",
+		Short: "Debugging tools for playground-ng itself",
+	}
+	cmd.AddCommand(newDebugReplay())
+	cmd.AddCommand(newDebugConvertLog())
+	return cmd
+}
+
+func newDebugReplay() *cobra.Command {
+	arg0 := playgroundCLIArg0()
+
+	var speed float64
+	var maxDelay time.Duration
+	cmd := &cobra.Command{
+		Use:   "replay <event-log>",
+		Short: "Render a recorded TUI event log",
+		Long: fmt.Sprintf(`Render a recorded TUI event log (%s in the data dir of a background
+playground), e.g. to debug UI issues from a log submitted by a user.
+
+By default the final transcript is printed at once. With --speed, the log is
+played back in the terminal with its original timing, scaled by the speed.`, playgroundTUIEventLogName),
+		Example: fmt.Sprintf("%s debug replay %s --speed 2", arg0, playgroundTUIEventLogName),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mode := progressv2.ModePlain
+			if speed > 0 {
+				mode = progressv2.ModeAuto
 			}
-			time.Sleep(50 * time.Millisecond)
-			continue
-		default:
-			return
-		}
+			return progressv2.Replay(cmd.Context(), args[0], progressv2.ReplayOptions{
+				Options: progressv2.Options{
+					Mode:            mode,
+					Out:             cmd.OutOrStdout(),
+					ActiveTaskLimit: playgroundActiveTaskLimit,
+				},
+				Speed:    speed,
+				MaxDelay: maxDelay,
+			})
+		},
+	}
+	cmd.Flags().Float64Var(&speed, "speed", 0, "Play the log back in real time scaled by this factor (0 prints the final transcript at once)")
+	cmd.Flags().DurationVar(&maxDelay, "max-delay", 2*time.Second, "Max pause between two events during playback")
+	return cmd
+}
+
+func newDebugConvertLog() *cobra.Command {
+	arg0 := playgroundCLIArg0()
+
+	var encoding string
+	cmd := &cobra.Command{
+		Use:   "convert-log <event-log>",
+		Short: "Re-encode a recorded TUI event log",
+		Long: `Re-encode a recorded TUI event log, in any encoding and possibly compressed,
+to stdout. By default the log is converted to JSON lines, e.g. to inspect a
+binary log with standard tools.`,
+		Example: fmt.Sprintf("%s debug convert-log %s | jq .", arg0, playgroundTUIEventLogName),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return progressv2.ConvertEventLog(cmd.OutOrStdout(), args[0], progressv2.EventLogEncoding(encoding))
+		},
 	}
+	cmd.Flags().StringVar(&encoding, "encoding", string(progressv2.EventLogJSON), "Output encoding: json or binary")
+	return cmd
 }
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -160,3 +162,83 @@ func TestTailEventLog_ReplaysNewEventsAfterOffset(t *testing.T) {
 	require.NotContains(t, string(data), "not json")
 	require.Contains(t, string(data), "new\n")
 }
+
+func TestDebugReplay_PrintsTranscript(t *testing.T) {
+	eventLogPath := filepath.Join(t.TempDir(), playgroundTUIEventLogName)
+
+	f, err := os.Create(eventLogPath)
+	require.NoError(t, err)
+	ui := progressv2.New(progressv2.Options{Mode: progressv2.ModePlain, Out: io.Discard, EventLog: f})
+	g := ui.Group("Start instances")
+	g.Task("PD").Start()
+	g.Close()
+	require.NoError(t, ui.Close())
+	require.NoError(t, f.Close())
+
+	var out bytes.Buffer
+	cmd := newDebug()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"replay", eventLogPath})
+	require.NoError(t, cmd.Execute())
+	require.Contains(t, out.String(), "Start instances | PD")
+}
+
+func TestDebugConvertLog_BinaryToJSON(t *testing.T) {
+	eventLogPath := filepath.Join(t.TempDir(), playgroundTUIEventLogName)
+
+	f, err := os.Create(eventLogPath)
+	require.NoError(t, err)
+	ui := progressv2.New(progressv2.Options{
+		Mode:             progressv2.ModePlain,
+		Out:              io.Discard,
+		EventLog:         f,
+		EventLogEncoding: progressv2.EventLogBinary,
+	})
+	ui.Group("Start instances").Task("PD").Start()
+	require.NoError(t, ui.Close())
+	require.NoError(t, f.Close())
+
+	var out bytes.Buffer
+	cmd := newDebug()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"convert-log", eventLogPath})
+	require.NoError(t, cmd.Execute())
+	require.Contains(t, out.String(), `"title":"Start instances"`)
+}
+
+func TestTailEventLog_StopsAtSyncBarrierOnceReady(t *testing.T) {
+	eventLogPath := filepath.Join(t.TempDir(), playgroundTUIEventLogName)
+	f, err := os.Create(eventLogPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	var out bytes.Buffer
+	ui := progressv2.New(progressv2.Options{Mode: progressv2.ModePlain, Out: &out})
+	readyCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		tailEventLog(context.Background(), eventLogPath, 0, ui, readyCh)
+		close(done)
+	}()
+
+	daemon := progressv2.New(progressv2.Options{Mode: progressv2.ModePlain, Out: io.Discard, EventLog: f})
+	daemon.PrintLines([]string{"before ready"})
+	close(readyCh)
+
+	// Without a barrier, the tailer cannot know the daemon printed everything.
+	select {
+	case <-done:
+		t.Fatal("tailEventLog stopped before the sync barrier")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	daemon.Sync()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting tailEventLog to stop")
+	}
+	require.NoError(t, daemon.Close())
+	require.NoError(t, ui.Close())
+	require.Contains(t, out.String(), "before ready")
+}
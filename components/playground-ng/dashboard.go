@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
+)
+
+// dashboardHTML is the browser dashboard served at /ui. It polls "display"
+// and follows /events, both on the command server.
+//
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// dashboardEventLimit bounds the events kept for the dashboards connecting
+// later.
+const dashboardEventLimit = 500
+
+// dashboardKeepAlive is the interval of the comments keeping /events streams
+// alive, which also detects closed dashboards.
+const dashboardKeepAlive = 15 * time.Second
+
+// dashboardEvent is a line of the event list of the dashboard, derived from
+// the progress UI events of the playground.
+type dashboardEvent struct {
+	At time.Time `json:"at"`
+	// Level is "group" for a new progress group, "warn" or "error", or empty.
+	Level string `json:"level,omitempty"`
+	Text  string `json:"text"`
+}
+
+// dashboardEvents turns the progress UI events into dashboardEvents: the
+// output lines, the groups (e.g. "Start instances") and the tasks that finish
+// (e.g. "TiKV 0 done"). It is the progressv2 observer of the UI.
+type dashboardEvents struct {
+	feed *feed[dashboardEvent]
+
+	mu     sync.Mutex
+	groups map[uint64]string
+	tasks  map[uint64]*dashboardTask
+}
+
+type dashboardTask struct {
+	group   uint64
+	title   string
+	message string
+}
+
+func newDashboardEvents() *dashboardEvents {
+	return &dashboardEvents{
+		feed:   newFeed[dashboardEvent](dashboardEventLimit),
+		groups: make(map[uint64]string),
+		tasks:  make(map[uint64]*dashboardTask),
+	}
+}
+
+// observe is a progressv2.EventObserver.
+func (d *dashboardEvents) observe(at time.Time, e progressv2.Event) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch e.Type {
+	case progressv2.EventPrintLines:
+		for _, line := range e.Lines {
+			if line = strings.TrimSpace(ansi.Strip(line)); line != "" {
+				d.feed.publish(dashboardEvent{At: at, Level: string(e.Severity), Text: line})
+			}
+		}
+	case progressv2.EventGroupAdd:
+		if e.Title != nil {
+			d.groups[e.GroupID] = *e.Title
+			d.feed.publish(dashboardEvent{At: at, Level: "group", Text: *e.Title})
+		}
+	case progressv2.EventTaskAdd, progressv2.EventTaskUpdate:
+		t := d.tasks[e.TaskID]
+		if t == nil {
+			t = &dashboardTask{group: e.GroupID}
+			d.tasks[e.TaskID] = t
+		}
+		if e.Title != nil {
+			t.title = *e.Title
+		}
+		if e.Message != nil {
+			t.message = *e.Message
+		}
+	case progressv2.EventTaskState:
+		t := d.tasks[e.TaskID]
+		if t == nil || e.Status == nil {
+			break
+		}
+		text := t.title
+		if group := d.groups[t.group]; group != "" {
+			text = group + ": " + text
+		}
+		switch *e.Status {
+		case progressv2.TaskStatusDone:
+			d.feed.publish(dashboardEvent{At: at, Text: text + " done"})
+		case progressv2.TaskStatusError:
+			text += " failed"
+			if t.message != "" {
+				text += ": " + t.message
+			}
+			d.feed.publish(dashboardEvent{At: at, Level: "error", Text: text})
+		case progressv2.TaskStatusCanceled, progressv2.TaskStatusSkipped:
+			d.feed.publish(dashboardEvent{At: at, Level: "warn", Text: fmt.Sprintf("%s %s", text, *e.Status)})
+		}
+		if slices.Contains([]progressv2.TaskStatus{progressv2.TaskStatusDone, progressv2.TaskStatusError, progressv2.TaskStatusCanceled, progressv2.TaskStatusSkipped}, *e.Status) {
+			delete(d.tasks, e.TaskID)
+		}
+	case progressv2.EventGroupClose:
+		delete(d.groups, e.GroupID)
+	}
+}
+
+func (p *Playground) dashboardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(dashboardHTML)
+}
+
+// eventsHandler streams the dashboard events as server-sent events: the recent
+// ones, then the new ones until the playground terminated. The event IDs are
+// feed positions, so that a reconnecting EventSource resumes where it was.
+func (p *Playground) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if p == nil || p.dashboardEvents == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	rc := http.NewResponseController(w)
+
+	from, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	keepAlive := time.NewTicker(dashboardKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		events, to, _, changed := p.dashboardEvents.feed.next(from)
+		// Each write gets its own deadline: the stream outlives the
+		// server-wide WriteTimeout.
+		_ = rc.SetWriteDeadline(time.Now().Add(defaultCommandTimeout))
+		for i, e := range events {
+			data, _ := json.Marshal(e)
+			if _, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", to-len(events)+i+1, data); err != nil {
+				return
+			}
+		}
+		from = to
+		if err := rc.Flush(); err != nil {
+			return
+		}
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			_ = rc.SetWriteDeadline(time.Now().Add(defaultCommandTimeout))
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-p.terminateDoneCh:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// anyOriginKey marks the requests of pages allowed by "*" only, which
+// commandHandler restricts to the read-only commands: any website could send
+// them, and e.g. a scale-out runs the binary of its choice.
+type anyOriginKey struct{}
+
+// withCORS allows the browser pages of origins ("*" for any) to use the
+// command server, answering their preflight requests. The pages allowed by
+// "*" only may run the read-only commands.
+func withCORS(origins []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		listed := slices.Contains(origins, origin)
+		if origin != "" && (listed || slices.Contains(origins, "*")) {
+			if !listed && origin != "http://"+r.Host {
+				r = r.WithContext(context.WithValue(r.Context(), anyOriginKey{}, true))
+			}
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				h.Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization")
+				h.Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
+	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardEvents_Observe(t *testing.T) {
+	d := newDashboardEvents()
+	str := func(s string) *string { return &s }
+	status := func(s progressv2.TaskStatus) *progressv2.TaskStatus { return &s }
+
+	events := []progressv2.Event{
+		{Type: progressv2.EventGroupAdd, GroupID: 1, Title: str("Start instances")},
+		{Type: progressv2.EventTaskAdd, GroupID: 1, TaskID: 1, Title: str("PD")},
+		{Type: progressv2.EventTaskAdd, GroupID: 1, TaskID: 2, Title: str("TiKV")},
+		{Type: progressv2.EventTaskUpdate, TaskID: 2, Message: str("port 20160 in use")},
+		{Type: progressv2.EventTaskState, TaskID: 1, Status: status(progressv2.TaskStatusRunning)},
+		{Type: progressv2.EventTaskState, TaskID: 1, Status: status(progressv2.TaskStatusDone)},
+		{Type: progressv2.EventTaskState, TaskID: 2, Status: status(progressv2.TaskStatusError)},
+		{Type: progressv2.EventPrintLines, Lines: []string{"\x1b[1mhello\x1b[0m", "  "}, Severity: progressv2.PrintSeverityWarn},
+	}
+	for _, e := range events {
+		d.observe(time.Now(), e)
+	}
+
+	got, to, _, _ := d.feed.next(0)
+	require.Equal(t, 4, to)
+	var texts, levels []string
+	for _, e := range got {
+		texts = append(texts, e.Text)
+		levels = append(levels, e.Level)
+	}
+	require.Equal(t, []string{
+		"Start instances",
+		"Start instances: PD done",
+		"Start instances: TiKV failed: port 20160 in use",
+		"hello",
+	}, texts)
+	require.Equal(t, []string{"group", "", "error", "warn"}, levels)
+}
+
+func TestEventsHandler_StreamsFromLastEventID(t *testing.T) {
+	p := NewPlayground(t.TempDir(), 0)
+	for _, text := range []string{"a", "b"} {
+		p.dashboardEvents.feed.publish(dashboardEvent{Text: text})
+	}
+	srv := httptest.NewServer(http.HandlerFunc(p.eventsHandler))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := make(chan string)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			if line := sc.Text(); line != "" {
+				lines <- line
+			}
+		}
+		close(lines)
+	}()
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+			return ""
+		}
+	}
+
+	require.Equal(t, "id: 2", next())
+	require.True(t, strings.HasPrefix(next(), `data: {"at":`))
+	p.dashboardEvents.feed.publish(dashboardEvent{Level: "warn", Text: "c"})
+	require.Equal(t, "id: 3", next())
+	require.Contains(t, next(), `"level":"warn","text":"c"`)
+
+	close(p.terminateDoneCh)
+	for range lines {
+	}
+}
+
+func TestDashboardHandler(t *testing.T) {
+	p := NewPlayground(t.TempDir(), 0)
+	rec := httptest.NewRecorder()
+	p.dashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/ui", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, rec.Body.String(), `new EventSource("events")`)
+}
+
+func TestWithCORS(t *testing.T) {
+	h := withCORS([]string{"http://localhost:3000"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	serve := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/command", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "http://localhost:3000", false)
+	require.Equal(t, http.StatusTeapot, rec.Code)
+	require.Equal(t, "http://localhost:3000", rec.Header().Get("Access-Control-Allow-Origin"))
+
+	rec = serve(http.MethodOptions, "http://localhost:3000", true)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+
+	rec = serve(http.MethodPost, "http://evil.example", false)
+	require.Equal(t, http.StatusTeapot, rec.Code)
+	require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	rec = serve(http.MethodOptions, "http://evil.example", true)
+	require.Equal(t, http.StatusTeapot, rec.Code)
+
+	h = withCORS([]string{"*"}, http.NotFoundHandler())
+	rec = serve(http.MethodGet, "http://any.example", false)
+	require.Equal(t, "http://any.example", rec.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestWithCORS_AnyOriginIsReadOnly(t *testing.T) {
+	p := NewPlayground(t.TempDir(), 0)
+	h := withCORS([]string{"*", "http://localhost:3000"}, http.HandlerFunc(p.commandHandler))
+	post := func(origin, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/command", strings.NewReader(body))
+		req.Host = "127.0.0.1:9527"
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	scaleIn := `{"type":"scale-in","scale_in":{"name":"tikv-0"}}`
+	rec := post("http://any.example", scaleIn)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.Contains(t, rec.Body.String(), "scale-in is not allowed from an origin only allowed by --cors-origin '*'")
+	require.Contains(t, rec.Body.String(), client.CodeForbidden)
+
+	// Listed origins, and the dashboard served by the command server itself,
+	// keep full control.
+	for _, origin := range []string{"http://localhost:3000", "http://127.0.0.1:9527"} {
+		rec = post(origin, scaleIn)
+		require.NotEqual(t, http.StatusForbidden, rec.Code, origin)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	stdErrors "errors"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+)
+
+// tidbBalancerBasePort is the base port of the TiDB load balancer (--db.lb).
+const tidbBalancerBasePort = 4100
+
+// relayDialTimeout bounds the dial of a backend by a tcpRelay.
+const relayDialTimeout = 3 * time.Second
+
+// tcpRelay accepts the connections of ln and relays each to a backend, until
+// it is closed. The TiDB load balancer and the latency proxies are built on
+// it.
+type tcpRelay struct {
+	ln net.Listener
+	// dial connects a new client to its backend.
+	dial func() (net.Conn, error)
+	// forward copies what the client sends to the backend, io.Copy if nil.
+	// The responses are always copied back as they come.
+	forward func(backend, client net.Conn)
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+// newTCPRelay starts relaying the connections of ln.
+func newTCPRelay(ln net.Listener, dial func() (net.Conn, error), forward func(backend, client net.Conn)) *tcpRelay {
+	r := &tcpRelay{ln: ln, dial: dial, forward: forward, conns: make(map[net.Conn]struct{})}
+	go r.serve()
+	return r
+}
+
+// Close stops accepting connections and closes the relayed ones.
+func (r *tcpRelay) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	conns := r.conns
+	r.conns = nil
+	r.mu.Unlock()
+
+	err := r.ln.Close()
+	for c := range conns {
+		_ = c.Close()
+	}
+	return err
+}
+
+// track records c as an open connection, or reports false if the relay is
+// closed.
+func (r *tcpRelay) track(c net.Conn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return false
+	}
+	r.conns[c] = struct{}{}
+	return true
+}
+
+func (r *tcpRelay) untrack(c net.Conn) {
+	r.mu.Lock()
+	delete(r.conns, c)
+	r.mu.Unlock()
+	_ = c.Close()
+}
+
+func (r *tcpRelay) serve() {
+	for {
+		client, err := r.ln.Accept()
+		if err != nil {
+			if stdErrors.Is(err, net.ErrClosed) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		go r.handle(client)
+	}
+}
+
+func (r *tcpRelay) handle(client net.Conn) {
+	if !r.track(client) {
+		_ = client.Close()
+		return
+	}
+	defer r.untrack(client)
+
+	backend, err := r.dial()
+	if err != nil {
+		return
+	}
+	if !r.track(backend) {
+		_ = backend.Close()
+		return
+	}
+	defer r.untrack(backend)
+
+	forward := r.forward
+	if forward == nil {
+		forward = func(backend, client net.Conn) { _, _ = io.Copy(backend, client) }
+	}
+	done := make(chan struct{}, 2)
+	go func() {
+		forward(backend, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(client, backend)
+		done <- struct{}{}
+	}()
+	// Either side closing ends the session; the deferred untracks close both
+	// connections, which unblocks the other copy.
+	<-done
+}
+
+// tidbBalancer is a TCP round-robin proxy in front of the TiDB instances, so
+// applications that only accept a single endpoint can still exercise a
+// multi-TiDB playground.
+//
+// Each new connection goes to the next backend; a backend that refuses the
+// connection is skipped, so the balancer keeps working while an instance is
+// starting, scaled in or crashed.
+type tidbBalancer struct {
+	relay *tcpRelay
+
+	mu       sync.Mutex
+	backends []string
+	next     int
+}
+
+func listenTiDBBalancer(addr string) (*tidbBalancer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	b := &tidbBalancer{}
+	b.relay = newTCPRelay(ln, b.dial, nil)
+	return b, nil
+}
+
+// Addr returns the address the balancer listens on.
+func (b *tidbBalancer) Addr() string {
+	if b == nil {
+		return ""
+	}
+	return b.relay.ln.Addr().String()
+}
+
+// SetBackends replaces the TiDB addresses new connections are spread over.
+func (b *tidbBalancer) SetBackends(addrs []string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if slices.Equal(b.backends, addrs) {
+		return
+	}
+	b.backends = slices.Clone(addrs)
+	b.next = 0
+}
+
+// Close stops accepting connections and closes the proxied ones.
+func (b *tidbBalancer) Close() error {
+	if b == nil {
+		return nil
+	}
+	return b.relay.Close()
+}
+
+// candidates returns the backends in the order a new connection tries them,
+// starting from the next one in the round-robin.
+func (b *tidbBalancer) candidates() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(b.backends)
+	if n == 0 {
+		return nil
+	}
+	start := b.next % n
+	b.next = (start + 1) % n
+	return append(slices.Clone(b.backends[start:]), b.backends[:start]...)
+}
+
+// dial connects to the first candidate that accepts the connection.
+func (b *tidbBalancer) dial() (net.Conn, error) {
+	err := errors.New("no TiDB backend")
+	for _, addr := range b.candidates() {
+		var c net.Conn
+		if c, err = net.DialTimeout("tcp", addr, relayDialTimeout); err == nil {
+			return c, nil
+		}
+	}
+	return nil, err
+}
+
+// startTiDBBalancer starts the TiDB load balancer on addr. It stops when the
+// playground shuts down.
+func (p *Playground) startTiDBBalancer(addr string) error {
+	lb, err := listenTiDBBalancer(addr)
+	if err != nil {
+		return errors.Annotate(err, "start TiDB load balancer")
+	}
+	p.tidbLB = lb
+	go func() {
+		<-p.processGroup.Closed()
+		_ = lb.Close()
+	}()
+	return nil
+}
+
+// tidbAddrs returns the addresses of the TiDB instances in procs.
+func tidbAddrs(procs []proc.Process) []string {
+	var addrs []string
+	for _, inst := range procs {
+		if db, ok := inst.(*proc.TiDBInstance); ok && db != nil {
+			addrs = append(addrs, db.Addr())
+		}
+	}
+	return addrs
+}
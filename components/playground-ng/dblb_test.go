@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// startNamedServer starts a TCP server that writes name to each connection.
+func startNamedServer(t *testing.T, name string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = c.Write([]byte(name + "\n"))
+			_ = c.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func readBackendName(t *testing.T, addr string) string {
+	t.Helper()
+	c, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+	line, err := bufio.NewReader(c).ReadString('\n')
+	require.NoError(t, err)
+	return line[:len(line)-1]
+}
+
+func TestTiDBBalancer_RoundRobinSkipsDeadBackend(t *testing.T) {
+	a := startNamedServer(t, "a")
+	b := startNamedServer(t, "b")
+
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadAddr := dead.Addr().String()
+	require.NoError(t, dead.Close())
+
+	lb, err := listenTiDBBalancer("127.0.0.1:0")
+	require.NoError(t, err)
+	defer lb.Close()
+
+	lb.SetBackends([]string{a, deadAddr, b})
+	var got []string
+	for range 4 {
+		got = append(got, readBackendName(t, lb.Addr()))
+	}
+	// The dead backend's turn falls through to the next one.
+	require.Equal(t, []string{"a", "b", "b", "a"}, got)
+}
+
+func TestTiDBBalancer_CloseStopsAccepting(t *testing.T) {
+	lb, err := listenTiDBBalancer("127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lb.Addr()
+	require.NoError(t, lb.Close())
+	require.NoError(t, lb.Close())
+
+	_, err = net.Dial("tcp", addr)
+	require.Error(t, err)
+}
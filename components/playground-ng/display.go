@@ -4,12 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/docker/go-units"
 	"github.com/pingcap/tiup/components/playground-ng/proc"
+	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
 	"github.com/pingcap/tiup/pkg/utils"
 )
 
@@ -33,16 +36,31 @@ type displayItem struct {
 	ServiceID string `json:"service"`
 	Component string `json:"component,omitempty"`
 	Addr      string `json:"addr,omitempty"`
-	Status    string `json:"status"`
-	Uptime    string `json:"uptime,omitempty"`
+	// ClientPort is the port of Addr; StatusPort serves the status and
+	// metrics API (the same as ClientPort for PD).
+	ClientPort int    `json:"client_port,omitempty"`
+	StatusPort int    `json:"status_port,omitempty"`
+	DataDir    string `json:"data_dir,omitempty"`
+	Status     string `json:"status"`
+	Uptime     string `json:"uptime,omitempty"`
+	// CPUPercent and RSSBytes are the latest usage sample of a running
+	// process, see usageSampleInterval.
+	CPUPercent *float64 `json:"cpu_percent,omitempty"`
+	RSSBytes   uint64   `json:"rss_bytes,omitempty"`
+	// CrashDir holds the artifacts of the last abnormal exit of an exited
+	// instance, see collectInstanceCrash.
+	CrashDir string `json:"crash_dir,omitempty"`
 
 	PID     int    `json:"pid,omitempty"`
 	Version string `json:"version,omitempty"`
-	Binary  string `json:"binary,omitempty"`
-	Log     string `json:"log,omitempty"`
+	// VersionConstraint is the requested version (e.g. "^7.5" or
+	// "nightly") when it differs from the resolved Version.
+	VersionConstraint string `json:"version_constraint,omitempty"`
+	Binary            string `json:"binary,omitempty"`
+	Log               string `json:"log,omitempty"`
 }
 
-func (p *Playground) handleDisplay(state *controllerState, r io.Writer, verbose, jsonOut bool) error {
+func (p *Playground) handleDisplay(state *controllerState, r io.Writer, req DisplayRequest) error {
 	if p == nil {
 		return fmt.Errorf("playground is nil")
 	}
@@ -52,6 +70,7 @@ func (p *Playground) handleDisplay(state *controllerState, r io.Writer, verbose,
 	if r == nil {
 		r = io.Discard
 	}
+	verbose, wide := req.Verbose, req.Wide
 
 	type addrGetter interface {
 		Addr() string
@@ -84,20 +103,45 @@ func (p *Playground) handleDisplay(state *controllerState, r io.Writer, verbose,
 		}
 
 		addr := ""
+		clientPort := 0
 		if v, ok := ins.(addrGetter); ok {
 			addr = v.Addr()
+			if _, port, err := net.SplitHostPort(addr); err == nil {
+				clientPort, _ = strconv.Atoi(port)
+			}
 		}
 
 		item := &displayItem{
-			Name:      info.Name(),
-			ServiceID: serviceID.String(),
-			Addr:      addr,
-			Status:    status,
-			Uptime:    uptime,
+			Name:       info.Name(),
+			ServiceID:  serviceID.String(),
+			Addr:       addr,
+			ClientPort: clientPort,
+			StatusPort: info.StatusPort,
+			DataDir:    info.Dir,
+			Status:     status,
+			Uptime:     uptime,
+		}
+		switch {
+		case status == "running":
+			if u, ok := p.usage.get(item.Name, pid); ok {
+				item.CPUPercent = &u.CPU
+				item.RSSBytes = u.RSS
+			}
+		case strings.HasPrefix(status, "exited"):
+			item.CrashDir = state.crashDirs[item.Name]
 		}
 		if verbose {
 			item.PID = pid
 			item.Version = info.Version.String()
+			if info.UserBinPath == "" {
+				constraint := p.versionConstraintForService(serviceID, p.bootOptions.BootVersion())
+				if constraint == "" {
+					constraint = utils.LatestVersionAlias
+				}
+				if constraint != item.Version {
+					item.VersionConstraint = constraint
+				}
+			}
 			item.Binary = info.BinPath
 			item.Log = ins.LogFile()
 			item.Component = info.RepoComponentID.String()
@@ -105,7 +149,7 @@ func (p *Playground) handleDisplay(state *controllerState, r io.Writer, verbose,
 		return item, nil
 	}
 
-	if jsonOut {
+	if req.JSON {
 		var items []*displayItem
 		err := state.walkProcs(func(serviceID proc.ServiceID, ins proc.Process) error {
 			item, err := collect(serviceID, ins)
@@ -127,49 +171,85 @@ func (p *Playground) handleDisplay(state *controllerState, r io.Writer, verbose,
 		return enc.Encode(items)
 	}
 
-	header := []string{"NAME", "SERVICE", "ADDR", "STATUS", "UPTIME"}
+	header := []string{"NAME", "SERVICE"}
 	if verbose {
-		header = []string{"NAME", "SERVICE", "COMPONENT", "ADDR", "STATUS", "UPTIME", "PID", "VERSION", "BINARY", "LOG"}
+		header = append(header, "COMPONENT")
+	}
+	header = append(header, "ADDR")
+	if wide {
+		header = append(header, "CLIENT PORT", "STATUS PORT", "DATA DIR")
+	}
+	header = append(header, "STATUS", "UPTIME")
+	if wide {
+		header = append(header, "CPU", "MEM")
+	}
+	if verbose {
+		header = append(header, "PID", "VERSION", "BINARY", "LOG")
+	}
+	td := tuiv2output.Table{Header: header, Plain: req.Plain}
+	if !wide {
+		td.Width = req.Width
 	}
-	td := utils.NewTableDisplayer(r, header)
 
+	var crashes []string
 	if err := state.walkProcs(func(serviceID proc.ServiceID, ins proc.Process) error {
 		item, err := collect(serviceID, ins)
 		if err != nil || item == nil {
 			return err
 		}
 
-		if !verbose {
-			td.AddRow(item.Name, item.ServiceID, item.Addr, item.Status, item.Uptime)
-			return nil
+		row := []string{item.Name, item.ServiceID}
+		if verbose {
+			row = append(row, item.Component)
 		}
-
-		binary := item.Binary
-		if info := ins.Info(); info != nil && info.UserBinPath != "" {
-			binary = info.UserBinPath
+		row = append(row, item.Addr)
+		if wide {
+			row = append(row, portText(item.ClientPort), portText(item.StatusPort), prettifyUserPath(item.DataDir))
+		}
+		row = append(row, item.Status, item.Uptime)
+		if wide {
+			cpu, mem := "-", "-"
+			if item.CPUPercent != nil {
+				cpu = fmt.Sprintf("%.1f%%", *item.CPUPercent)
+				mem = units.BytesSize(float64(item.RSSBytes))
+			}
+			row = append(row, cpu, mem)
 		}
+		if verbose {
+			binary := item.Binary
+			if info := ins.Info(); info != nil && info.UserBinPath != "" {
+				binary = info.UserBinPath
+			}
 
-		td.AddRow(
-			item.Name,
-			item.ServiceID,
-			item.Component,
-			item.Addr,
-			item.Status,
-			item.Uptime,
-			strconv.Itoa(item.PID),
-			item.Version,
-			prettifyUserPath(binary),
-			prettifyUserPath(item.Log),
-		)
+			version := item.Version
+			if item.VersionConstraint != "" {
+				version = fmt.Sprintf("%s (%s)", version, item.VersionConstraint)
+			}
+			row = append(row, strconv.Itoa(item.PID), version, prettifyUserPath(binary), prettifyUserPath(item.Log))
+		}
+		td.AddRow(row...)
+		if item.CrashDir != "" {
+			crashes = append(crashes, fmt.Sprintf("%s crashed, artifacts in %s", item.Name, prettifyUserPath(item.CrashDir)))
+		}
 		return nil
 	}); err != nil {
 		return err
 	}
 
-	td.Display()
+	fmt.Fprint(r, td.Render())
+	if len(crashes) > 0 && !req.Plain {
+		fmt.Fprintf(r, "\n%s\n", strings.Join(crashes, "\n"))
+	}
 	return nil
 }
 
+func portText(port int) string {
+	if port <= 0 {
+		return "-"
+	}
+	return strconv.Itoa(port)
+}
+
 func procTitle(inst proc.Process) string {
 	if inst == nil {
 		return "Instance"
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/pingcap/tiup/components/playground-ng/proc"
@@ -66,9 +67,10 @@ func TestHandleDisplay_JSON_VerboseStatusAndFields(t *testing.T) {
 		},
 	}
 	pg := NewPlayground(t.TempDir(), 0)
+	pg.bootOptions = &BootOptions{Version: "^7.5"}
 
 	var buf bytes.Buffer
-	require.NoError(t, pg.handleDisplay(state, &buf, true, true))
+	require.NoError(t, pg.handleDisplay(state, &buf, DisplayRequest{Verbose: true, JSON: true}))
 
 	var items []displayItem
 	require.NoError(t, json.Unmarshal(buf.Bytes(), &items))
@@ -76,6 +78,9 @@ func TestHandleDisplay_JSON_VerboseStatusAndFields(t *testing.T) {
 
 	require.Equal(t, "svc-a", items[0].ServiceID)
 	require.Equal(t, "not started", items[0].Status)
+	require.Equal(t, 1234, items[0].ClientPort)
+	require.Equal(t, "v7.5.0", items[0].Version)
+	require.Equal(t, "^7.5", items[0].VersionConstraint)
 
 	require.Equal(t, "svc-b", items[1].ServiceID)
 	require.Equal(t, "running", items[1].Status)
@@ -85,6 +90,48 @@ func TestHandleDisplay_JSON_VerboseStatusAndFields(t *testing.T) {
 	require.Equal(t, "exited(3)", items[2].Status)
 }
 
+func TestHandleDisplay_WideShowsPortsAndDataDir(t *testing.T) {
+	info := &proc.ProcessInfo{
+		Service:    proc.ServiceTiDB,
+		Dir:        "/tmp/data/tidb-0",
+		StatusPort: 10080,
+	}
+	state := &controllerState{
+		procs: map[proc.ServiceID][]proc.Process{
+			proc.ServiceTiDB: {&displayAddrProcess{displayProcess: &displayProcess{info: info}, addr: "127.0.0.1:4000"}},
+		},
+	}
+	pg := NewPlayground(t.TempDir(), 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, pg.handleDisplay(state, &buf, DisplayRequest{}))
+	require.NotContains(t, buf.String(), "STATUS PORT")
+
+	buf.Reset()
+	require.NoError(t, pg.handleDisplay(state, &buf, DisplayRequest{Wide: true}))
+	out := buf.String()
+	require.Contains(t, out, "CLIENT PORT")
+	require.Contains(t, out, "STATUS PORT")
+	require.Contains(t, out, "4000")
+	require.Contains(t, out, "10080")
+	require.Contains(t, out, "/tmp/data/tidb-0")
+
+	// --wide keeps the whole values whatever the terminal width.
+	buf.Reset()
+	require.NoError(t, pg.handleDisplay(state, &buf, DisplayRequest{Wide: true, Width: 40}))
+	require.Contains(t, buf.String(), "/tmp/data/tidb-0")
+
+	buf.Reset()
+	require.NoError(t, pg.handleDisplay(state, &buf, DisplayRequest{Width: 40}))
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		require.LessOrEqual(t, len([]rune(line)), 40, line)
+	}
+
+	buf.Reset()
+	require.NoError(t, pg.handleDisplay(state, &buf, DisplayRequest{Plain: true}))
+	require.True(t, strings.HasPrefix(buf.String(), "NAME\tSERVICE\tADDR\tSTATUS\tUPTIME\n"), buf.String())
+}
+
 func TestPrettifyUserPath(t *testing.T) {
 	home, err := os.UserHomeDir()
 	if err != nil || home == "" {
@@ -112,12 +159,13 @@ type displayOSProcess struct {
 	uptime string
 }
 
-func (p *displayOSProcess) Start() error                     { return nil }
-func (p *displayOSProcess) Wait() error                      { return nil }
-func (p *displayOSProcess) Pid() int                         { return p.pid }
-func (p *displayOSProcess) Uptime() string                   { return p.uptime }
-func (p *displayOSProcess) SetOutputFile(fname string) error { return nil }
-func (p *displayOSProcess) Cmd() *exec.Cmd                   { return p.cmd }
+func (p *displayOSProcess) Start(func(*exec.Cmd) error) error { return nil }
+func (p *displayOSProcess) Wait() error                       { return nil }
+func (p *displayOSProcess) Pid() int                          { return p.pid }
+func (p *displayOSProcess) Uptime() string                    { return p.uptime }
+func (p *displayOSProcess) SetOutputFile(fname string) error  { return nil }
+func (p *displayOSProcess) OutputTail(n int) []string         { return nil }
+func (p *displayOSProcess) Cmd() *exec.Cmd                    { return p.cmd }
 
 type displayProcess struct {
 	info    *proc.ProcessInfo
@@ -0,0 +1,575 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
+	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
+	"github.com/pingcap/tiup/pkg/tuiv2/progress"
+	"github.com/pingcap/tiup/pkg/utils"
+	gops "github.com/shirou/gopsutil/process"
+	"github.com/spf13/cobra"
+)
+
+// orphanProcess is a live process recorded in the instance registry of a
+// playground that is no longer running.
+type orphanProcess struct {
+	Tag      string
+	Instance string
+	PID      int
+	BinPath  string
+	// Unverified reports a record without a start time: the pid may have been
+	// recycled by another process, so it is reported but never killed.
+	Unverified bool
+}
+
+// processStartTime returns the start time of pid in ms since epoch. Together
+// with the pid it fingerprints a process, so a recycled pid is not mistaken
+// for the recorded one.
+func processStartTime(pid int) (int64, error) {
+	p, err := gops.NewProcess(int32(pid))
+	if err != nil {
+		return 0, err
+	}
+	return p.CreateTime()
+}
+
+// matchesInstanceRecord reports whether the live process pid is the one
+// recorded by rec: same start time, and a command line running its binary.
+// A record without a start time never matches.
+func matchesInstanceRecord(rec instanceRecord) bool {
+	if rec.StartTime <= 0 {
+		return false
+	}
+	p, err := gops.NewProcess(int32(rec.PID))
+	if err != nil {
+		return false
+	}
+	created, err := p.CreateTime()
+	if err != nil || created != rec.StartTime {
+		return false
+	}
+	return runsBinary(p, rec.BinPath)
+}
+
+// mayMatchInstanceRecord reports whether the live process pid may be the one
+// recorded by rec, which has no start time: it runs its binary, if any.
+func mayMatchInstanceRecord(rec instanceRecord) bool {
+	p, err := gops.NewProcess(int32(rec.PID))
+	if err != nil {
+		return false
+	}
+	return runsBinary(p, rec.BinPath)
+}
+
+// runsBinary reports whether the command line of p runs binPath, true if
+// binPath is empty.
+func runsBinary(p *gops.Process, binPath string) bool {
+	if binPath == "" {
+		return true
+	}
+	cmdline, err := p.Cmdline()
+	return err == nil && strings.Contains(cmdline, filepath.Base(binPath))
+}
+
+// findOrphanProcesses lists the processes recorded in the instance registry
+// of the stopped playgrounds under baseDir that are still running.
+func (r runtimeOps) findOrphanProcesses(baseDir string) ([]orphanProcess, error) {
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.AddStack(err)
+	}
+
+	var out []orphanProcess
+	for _, ent := range entries {
+		if !ent.IsDir() {
+			continue
+		}
+		dir := filepath.Join(baseDir, ent.Name())
+		if r.inspectPlaygroundRuntime(dir).running {
+			continue
+		}
+		out = append(out, orphansOf(dir, ent.Name())...)
+	}
+	slices.SortFunc(out, func(a, b orphanProcess) int {
+		if c := strings.Compare(a.Tag, b.Tag); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Instance, b.Instance)
+	})
+	return out, nil
+}
+
+// orphansOf lists the live processes recorded in the instance registry of
+// the stopped playground in dir.
+func orphansOf(dir, tag string) []orphanProcess {
+	var out []orphanProcess
+	for _, rec := range readInstanceRegistry(dir) {
+		if rec.PID <= 0 {
+			continue
+		}
+		o := orphanProcess{Tag: tag, Instance: rec.Name, PID: rec.PID, BinPath: rec.BinPath}
+		switch {
+		case matchesInstanceRecord(rec):
+		case rec.StartTime <= 0 && mayMatchInstanceRecord(rec):
+			o.Unverified = true
+		default:
+			continue
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+// killOrphanProcess stops an orphan (and its process group) gracefully, and
+// force kills it if it doesn't exit within timeout.
+func (r runtimeOps) killOrphanProcess(o orphanProcess, timeout time.Duration) error {
+	if err := r.procs.Kill(o.PID, syscall.SIGTERM); err != nil {
+		return err
+	}
+	deadline := r.clock.Now().Add(timeout)
+	for r.clock.Now().Before(deadline) {
+		if running, err := r.procs.Running(o.PID); err == nil && !running {
+			return nil
+		}
+		<-r.clock.After(100 * time.Millisecond)
+	}
+	return r.procs.Kill(o.PID, syscall.SIGKILL)
+}
+
+// playgroundRuntime is what the runtime files of a playground dir say about
+// its process, read without changing them (unlike isPlaygroundStopped).
+type playgroundRuntime struct {
+	// pid is the content of the pid file, if it could be read.
+	pid    pidFile
+	pidErr error
+	// running reports whether a playground process may own the dir. Anything
+	// doctor can't tell apart from a running playground counts as running.
+	running bool
+	// reused reports whether the pid of the pid file now belongs to a process
+	// started after the playground.
+	reused bool
+	// port is the command server port of the port file, 0 if there is none.
+	port int
+}
+
+// inspectPlaygroundRuntime reads the pid and port files of dataDir and checks
+// them against the live processes.
+func (r runtimeOps) inspectPlaygroundRuntime(dataDir string) playgroundRuntime {
+	var rt playgroundRuntime
+	if port, err := client.ReadPort(dataDir); err == nil && port > 0 {
+		rt.port = port
+	}
+	pidPath := filepath.Join(dataDir, playgroundPIDFileName)
+	rt.pid, rt.pidErr = readPIDFile(pidPath)
+	switch {
+	case rt.pidErr == nil:
+		running, err := r.procs.Running(rt.pid.pid)
+		rt.running = err != nil || running
+		if running && isPIDReused(rt.pid) {
+			rt.running, rt.reused = false, true
+		}
+	case os.IsNotExist(rt.pidErr):
+		// Without a pid file, the port file is only stale if nothing listens
+		// on its port: it may belong to a data dir of the legacy playground.
+		if rt.port > 0 {
+			_, err := r.probeCommandServer(rt.port)
+			rt.running = err == nil || !stdErrors.Is(err, syscall.ECONNREFUSED)
+		}
+	default:
+		// A corrupted pid file: the playground may still be writing it, or
+		// its command server may still answer.
+		if info, err := os.Stat(pidPath); err == nil && r.clock.Now().Sub(info.ModTime()) < pidFileWriteGracePeriod {
+			rt.running = true
+			break
+		}
+		if rt.port > 0 {
+			ok, err := r.probeCommandServer(rt.port)
+			rt.running = (ok && err == nil) || isTimeoutErr(err)
+		}
+	}
+	return rt
+}
+
+// isPIDReused reports whether the live process pid.pid started after the pid
+// file was written, so it can't be the playground that wrote it.
+func isPIDReused(pid pidFile) bool {
+	if pid.startedAt.IsZero() {
+		return false
+	}
+	created, err := processStartTime(pid.pid)
+	if err != nil {
+		return false
+	}
+	// started_at has a one second precision.
+	return time.UnixMilli(created).After(pid.startedAt.Add(time.Second))
+}
+
+func (r runtimeOps) probeCommandServer(port int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	return r.procs.Probe(ctx, port)
+}
+
+// The issues doctor reports.
+const (
+	issueStaleRuntimeFiles = "stale runtime files"
+	issueServerUnreachable = "command server unreachable"
+	issuePortConflict      = "port conflict"
+	issueOrphanProcess     = "orphan process"
+	issueInterruptedOp     = "interrupted scale-out"
+	issueTruncatedEventLog = "truncated event log"
+	issueStaleStartEntry   = "stale start queue entry"
+	issueLeftNetemQdisc    = "netem qdisc left on lo"
+)
+
+// doctorFinding is an inconsistency between the state files of a playground
+// and the live processes, with how to fix it.
+type doctorFinding struct {
+	Tag    string
+	Issue  string
+	Detail string
+	Fix    string
+	// apply fixes the issue, nil if it has to be fixed by hand.
+	apply func() error
+}
+
+// diagnosePlaygrounds cross-checks the state of the playgrounds under
+// baseDir, or only of tag if it is not empty, and the entries of the start
+// queue in queueDir. It changes nothing: the fixes are left to apply.
+func (r runtimeOps) diagnosePlaygrounds(baseDir, tag, queueDir string) ([]doctorFinding, error) {
+	entries, err := os.ReadDir(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.AddStack(err)
+	}
+
+	var (
+		out   []doctorFinding
+		ports = make(map[int][]string)
+	)
+	for _, ent := range entries {
+		if !ent.IsDir() {
+			continue
+		}
+		dir := filepath.Join(baseDir, ent.Name())
+		rt := r.inspectPlaygroundRuntime(dir)
+		if rt.running && rt.port > 0 {
+			ports[rt.port] = append(ports[rt.port], ent.Name())
+		}
+		if tag == "" || ent.Name() == tag {
+			out = append(out, r.diagnosePlayground(dir, ent.Name(), rt)...)
+		}
+	}
+	for port, tags := range ports {
+		for _, t := range tags {
+			if tag != "" && t != tag {
+				continue
+			}
+			others := slices.DeleteFunc(slices.Clone(tags), func(o string) bool { return o == t })
+			if len(others) == 0 {
+				continue
+			}
+			out = append(out, doctorFinding{
+				Tag:    t,
+				Issue:  issuePortConflict,
+				Detail: fmt.Sprintf("command port %d is also recorded by %s", port, strings.Join(others, ", ")),
+				Fix:    "stop and restart one of them",
+			})
+		}
+	}
+	out = append(out, r.diagnoseStartQueue(queueDir, tag)...)
+
+	slices.SortStableFunc(out, func(a, b doctorFinding) int { return strings.Compare(a.Tag, b.Tag) })
+	return out, nil
+}
+
+// diagnosePlayground cross-checks the state files of the playground tag in
+// dir, whose runtime is rt.
+func (r runtimeOps) diagnosePlayground(dir, tag string, rt playgroundRuntime) []doctorFinding {
+	var out []doctorFinding
+	if rt.running {
+		if rt.pidErr == nil && rt.port > 0 {
+			ok, err := r.probeCommandServer(rt.port)
+			if !ok || err != nil {
+				detail := fmt.Sprintf("no playground answers on port %d", rt.port)
+				if isTimeoutErr(err) {
+					detail = fmt.Sprintf("port %d does not answer in time", rt.port)
+				}
+				fix := "check " + playgroundDaemonLogName + ", or stop the playground"
+				if rt.pidErr == nil {
+					fix += fmt.Sprintf(" (kill %d)", rt.pid.pid)
+				}
+				out = append(out, doctorFinding{Tag: tag, Issue: issueServerUnreachable, Detail: detail, Fix: fix})
+			}
+		}
+		return out
+	}
+
+	var stale []string
+	for _, name := range []string{playgroundPIDFileName, playgroundPortFileName, playgroundReadyFileName} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			stale = append(stale, name)
+		}
+	}
+	if len(stale) > 0 {
+		var detail string
+		switch {
+		case rt.reused:
+			detail = fmt.Sprintf("pid %d now belongs to another process", rt.pid.pid)
+		case rt.pidErr == nil:
+			detail = fmt.Sprintf("pid %d is not running", rt.pid.pid)
+		case os.IsNotExist(rt.pidErr):
+			detail = "no pid file"
+		default:
+			detail = "corrupted pid file: " + rt.pidErr.Error()
+		}
+		out = append(out, doctorFinding{
+			Tag:    tag,
+			Issue:  issueStaleRuntimeFiles,
+			Detail: detail,
+			Fix:    "remove " + strings.Join(stale, ", "),
+			apply: func() error {
+				for _, name := range stale {
+					if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+						return err
+					}
+				}
+				return nil
+			},
+		})
+	}
+
+	for _, o := range orphansOf(dir, tag) {
+		f := doctorFinding{
+			Tag:    tag,
+			Issue:  issueOrphanProcess,
+			Detail: fmt.Sprintf("%s (pid %d, %s)", o.Instance, o.PID, prettifyUserPath(o.BinPath)),
+			Fix:    fmt.Sprintf("kill %d", o.PID),
+			apply:  func() error { return r.killOrphanProcess(o, forceKillAfterDuration) },
+		}
+		if o.Unverified {
+			f.Fix = fmt.Sprintf("no start time recorded, check that pid %d is the instance and kill it", o.PID)
+			f.apply = nil
+		}
+		out = append(out, f)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, playgroundOperationFileName)); err == nil {
+		out = append(out, doctorFinding{
+			Tag:    tag,
+			Issue:  issueInterruptedOp,
+			Detail: playgroundOperationFileName + " left by a killed playground",
+			Fix:    fmt.Sprintf("restart with --interrupted-op=%s or %s", interruptedOpForward, interruptedOpRollback),
+		})
+	}
+
+	if f, ok := diagnoseNetem(dir, tag); ok {
+		out = append(out, f)
+	}
+
+	logPath := filepath.Join(dir, playgroundTUIEventLogName)
+	if end, partial := eventLogEnd(logPath); partial {
+		out = append(out, doctorFinding{
+			Tag:    tag,
+			Issue:  issueTruncatedEventLog,
+			Detail: playgroundTUIEventLogName + " ends with a partial event",
+			Fix:    "drop the partial event",
+			apply:  func() error { return os.Truncate(logPath, end) },
+		})
+	}
+	return out
+}
+
+// eventLogEnd returns the size of the complete events of the event log at
+// path, and whether a partial event (e.g. cut by a kill) follows them.
+//
+// The log is read forward with the framing of its records, JSON lines or
+// binary ones (see progress.EventLogEncoding), so a newline inside a binary
+// record is not taken for the end of an event. For a gzip log (see
+// progress.GzipEventLog) the end is the one of its last complete member,
+// which keeps the rest a valid gzip file. A log that can't be read to the
+// end, e.g. with a corrupted record, is left alone.
+func eventLogEnd(path string) (int64, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		return 0, false
+	}
+	r, err := progress.OpenEventLog(path)
+	if err != nil {
+		return 0, false
+	}
+	defer r.Close()
+	for {
+		_, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, false
+		}
+	}
+	end := r.Offset()
+	return end, end < info.Size()
+}
+
+// diagnoseNetem checks the tc/netem qdisc recorded in the dir of the stopped
+// playground tag: a playground killed before it removed the qdisc leaves it
+// delaying the packets over lo.
+func diagnoseNetem(dir, tag string) (doctorFinding, bool) {
+	path := filepath.Join(dir, playgroundNetemFileName)
+	state, err := readNetemState(path)
+	if state == nil && err == nil {
+		return doctorFinding{}, false
+	}
+	f := doctorFinding{Tag: tag, Issue: issueLeftNetemQdisc}
+	if err != nil {
+		f.Detail = err.Error()
+		f.Fix = "check tc qdisc show dev lo, then remove " + playgroundNetemFileName
+		return f, true
+	}
+	installed, err := state.installed()
+	switch {
+	case err != nil:
+		f.Detail = err.Error()
+		f.Fix = fmt.Sprintf("check tc qdisc show dev %s", state.Dev)
+	case installed:
+		f.Detail = fmt.Sprintf("qdisc %s of --inject-latency still delays %s", state.Handle, state.Dev)
+		f.Fix = fmt.Sprintf("tc qdisc del dev %s root (as root), remove %s", state.Dev, playgroundNetemFileName)
+		f.apply = func() error {
+			n := &netemQdisc{stateFile: path}
+			return n.Close()
+		}
+	default:
+		f.Detail = fmt.Sprintf("%s has no qdisc %s anymore", state.Dev, state.Handle)
+		f.Fix = "remove " + playgroundNetemFileName
+		f.apply = func() error { return os.Remove(path) }
+	}
+	return f, true
+}
+
+// diagnoseStartQueue lists the entries in the start queue dir of the starts
+// that are gone, of tag if it is not empty.
+func (r runtimeOps) diagnoseStartQueue(dir, tag string) []doctorFinding {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*"+startEntrySuffix))
+	var out []doctorFinding
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var e startQueueEntry
+		if json.Unmarshal(data, &e) != nil || e.PID <= 0 || (tag != "" && e.Tag != tag) {
+			continue
+		}
+		if running, err := r.procs.Running(e.PID); err != nil || running {
+			continue
+		}
+		out = append(out, doctorFinding{
+			Tag:    e.Tag,
+			Issue:  issueStaleStartEntry,
+			Detail: fmt.Sprintf("pid %d is not running", e.PID),
+			Fix:    "remove " + prettifyUserPath(path),
+			apply:  func() error { return os.Remove(path) },
+		})
+	}
+	return out
+}
+
+func newDoctor(state *cliState) *cobra.Command {
+	var (
+		apply       bool
+		killOrphans bool
+	)
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Find inconsistencies in the state of playgrounds and fix them",
+		Long: `Cross-check the pid, port and ready files, the event log, the instance
+registry and the scale-out checkpoint of each playground (or only of --tag)
+with the live processes, and report what doesn't match with how to fix it:
+
+  - stale runtime files of a playground that is gone, or whose pid now
+    belongs to another process
+  - a running playground whose command server doesn't answer
+  - playgrounds recording the same command port
+  - orphan processes, left behind by a playground killed with SIGKILL before
+    stopping its instances (matched by the pids, start times and binaries
+    recorded in its instance registry)
+  - a scale-out interrupted by a kill
+  - the tc/netem qdisc of --inject-latency left on lo by a kill
+  - an event log ending with a partial event
+  - entries of the start queue left by starts that are gone
+
+With --apply, fix what can be fixed safely: remove the stale files and
+entries, stop the orphan processes (SIGTERM, then SIGKILL after a timeout),
+remove the netem qdisc and drop the partial events. --kill-orphans only stops the orphan processes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doctor(cmd.OutOrStdout(), apply, killOrphans, state)
+		},
+	}
+	cmd.Flags().BoolVar(&apply, "apply", false, "Fix the issues found that can be fixed automatically")
+	cmd.Flags().BoolVar(&killOrphans, "kill-orphans", false, "Terminate the orphan processes found")
+	return cmd
+}
+
+func doctor(out io.Writer, apply, killOrphans bool, state *cliState) error {
+	if state == nil {
+		return fmt.Errorf("cli state is nil")
+	}
+	baseDir := state.dataDir
+	if state.tag != "" {
+		baseDir = filepath.Dir(state.dataDir)
+	}
+	findings, err := state.ops.diagnosePlaygrounds(baseDir, state.tag, startQueueDir())
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		fmt.Fprint(out, tuiv2output.Callout{
+			Style:   tuiv2output.CalloutSucceeded,
+			Content: "No inconsistencies found.",
+		}.Render(out))
+		return nil
+	}
+
+	td := utils.NewTableDisplayer(out, []string{"TAG", "ISSUE", "DETAIL", "FIX", "STATUS"})
+	fixable, failed := 0, 0
+	for _, f := range findings {
+		status := "manual"
+		if f.apply != nil {
+			status = "fixable"
+			if apply || (killOrphans && f.Issue == issueOrphanProcess) {
+				status = "fixed"
+				if err := f.apply(); err != nil {
+					status = "failed: " + err.Error()
+					failed++
+				}
+			} else {
+				fixable++
+			}
+		}
+		td.AddRow(f.Tag, f.Issue, f.Detail, f.Fix, status)
+	}
+	td.Display()
+
+	if failed > 0 {
+		return fmt.Errorf("failed to fix %d issue(s)", failed)
+	}
+	if fixable > 0 {
+		fmt.Fprintf(out, "\nRun '%s' to fix the %d fixable issue(s).\n", playgroundCLICommand("doctor --apply"), fixable)
+	}
+	return nil
+}
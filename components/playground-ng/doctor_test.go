@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/pingcap/tiup/pkg/tuiv2/progress"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRuntimeFiles creates the playground dir tag under base with the given
+// files.
+func writeRuntimeFiles(t *testing.T, base, tag string, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(base, tag)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestDiagnosePlaygrounds_StaleRuntimeFiles(t *testing.T) {
+	rt, _, ops := newFakeRuntime()
+	ops.spawn(4243)
+	ops.probe = func(ctx context.Context, port int) (bool, error) {
+		if port == 12345 || port == 12346 {
+			return true, nil
+		}
+		return false, syscall.ECONNREFUSED
+	}
+
+	base := t.TempDir()
+	dead := writeRuntimeFiles(t, base, "dead", map[string]string{
+		playgroundPIDFileName:   "pid=4242\n",
+		playgroundPortFileName:  "23456",
+		playgroundReadyFileName: "{}",
+	})
+	writeRuntimeFiles(t, base, "alive", map[string]string{
+		playgroundPIDFileName:  "pid=4243\n",
+		playgroundPortFileName: "12345",
+	})
+	// A port file without pid file whose port answers, e.g. of the legacy
+	// playground, is left alone.
+	writeRuntimeFiles(t, base, "legacy", map[string]string{playgroundPortFileName: "12346"})
+	writeRuntimeFiles(t, base, "gone", map[string]string{playgroundPortFileName: "23457"})
+
+	findings, err := rt.diagnosePlaygrounds(base, "", t.TempDir())
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	require.Equal(t, "dead", findings[0].Tag)
+	require.Equal(t, issueStaleRuntimeFiles, findings[0].Issue)
+	require.Equal(t, "pid 4242 is not running", findings[0].Detail)
+	require.Equal(t, "remove pid, port, ready.json", findings[0].Fix)
+	require.Equal(t, "gone", findings[1].Tag)
+	require.Equal(t, "no pid file", findings[1].Detail)
+
+	require.NoError(t, findings[0].apply())
+	entries, err := os.ReadDir(dead)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	findings, err = rt.diagnosePlaygrounds(base, "alive", t.TempDir())
+	require.NoError(t, err)
+	require.Empty(t, findings)
+}
+
+func TestDiagnosePlaygrounds_PortConflictAndUnreachableServer(t *testing.T) {
+	rt, _, ops := newFakeRuntime()
+	ops.probe = func(ctx context.Context, port int) (bool, error) {
+		if port == 20000 {
+			return true, nil
+		}
+		return false, syscall.ECONNREFUSED
+	}
+
+	base := t.TempDir()
+	for i, tag := range []string{"a", "b", "c"} {
+		pid := 5000 + i
+		ops.spawn(pid)
+		port := "20000"
+		if tag == "c" {
+			port = "20001"
+		}
+		writeRuntimeFiles(t, base, tag, map[string]string{
+			playgroundPIDFileName:  "pid=" + strconv.Itoa(pid) + "\n",
+			playgroundPortFileName: port,
+		})
+	}
+
+	findings, err := rt.diagnosePlaygrounds(base, "", t.TempDir())
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+	require.Equal(t, doctorFinding{Tag: "a", Issue: issuePortConflict, Detail: "command port 20000 is also recorded by b", Fix: "stop and restart one of them"}, findings[0])
+	require.Equal(t, doctorFinding{Tag: "b", Issue: issuePortConflict, Detail: "command port 20000 is also recorded by a", Fix: "stop and restart one of them"}, findings[1])
+	require.Equal(t, doctorFinding{Tag: "c", Issue: issueServerUnreachable, Detail: "no playground answers on port 20001", Fix: "check daemon.log, or stop the playground (kill 5002)"}, findings[2])
+
+	findings, err = rt.diagnosePlaygrounds(base, "b", t.TempDir())
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	require.Equal(t, "b", findings[0].Tag)
+}
+
+func TestDiagnosePlaygrounds_InterruptedOpAndTruncatedEventLog(t *testing.T) {
+	rt, _, _ := newFakeRuntime()
+	base := t.TempDir()
+	dir := writeRuntimeFiles(t, base, "killed", map[string]string{
+		playgroundOperationFileName: "{}",
+		playgroundTUIEventLogName:   "{\"a\":1}\n{\"b\":2}\n{\"c\"",
+	})
+	writeRuntimeFiles(t, base, "clean", map[string]string{
+		playgroundTUIEventLogName: "{\"a\":1}\n",
+	})
+
+	findings, err := rt.diagnosePlaygrounds(base, "", t.TempDir())
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	require.Equal(t, issueInterruptedOp, findings[0].Issue)
+	require.Nil(t, findings[0].apply)
+	require.Equal(t, "restart with --interrupted-op=forward or rollback", findings[0].Fix)
+	require.Equal(t, issueTruncatedEventLog, findings[1].Issue)
+
+	require.NoError(t, findings[1].apply())
+	data, err := os.ReadFile(filepath.Join(dir, playgroundTUIEventLogName))
+	require.NoError(t, err)
+	require.Equal(t, "{\"a\":1}\n{\"b\":2}\n", string(data))
+}
+
+// writeEventLog writes the JSON lines of events printing lines, converted to
+// enc, to path.
+func writeEventLog(t *testing.T, path string, enc progress.EventLogEncoding, lines ...string) []byte {
+	t.Helper()
+	var jsonLines bytes.Buffer
+	for _, line := range lines {
+		data, err := json.Marshal(progress.Event{Type: progress.EventPrintLines, Lines: []string{line}})
+		require.NoError(t, err)
+		jsonLines.Write(append(data, '\n'))
+	}
+	require.NoError(t, os.WriteFile(path, jsonLines.Bytes(), 0o644))
+	var out bytes.Buffer
+	require.NoError(t, progress.ConvertEventLog(&out, path, enc))
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0o644))
+	return out.Bytes()
+}
+
+func TestEventLogEnd_BinaryAndGzip(t *testing.T) {
+	dir := t.TempDir()
+
+	// The payloads of binary records may hold newlines.
+	binaryLog := filepath.Join(dir, "binary")
+	complete := writeEventLog(t, binaryLog, progress.EventLogBinary, "a\nb", "c\nd")
+	f, err := os.OpenFile(binaryLog, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.Write(complete[:3])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	end, partial := eventLogEnd(binaryLog)
+	require.True(t, partial)
+	require.Equal(t, int64(len(complete)), end)
+
+	require.NoError(t, os.Truncate(binaryLog, end))
+	_, partial = eventLogEnd(binaryLog)
+	require.False(t, partial)
+
+	// A gzip log is cut after its last complete member.
+	gzipLog := filepath.Join(dir, "gzip")
+	var data bytes.Buffer
+	gz := progress.NewGzipEventLog(&data, 0)
+	for _, line := range []string{"a", "b", "c"} {
+		event, err := json.Marshal(progress.Event{Type: progress.EventPrintLines, Lines: []string{line}})
+		require.NoError(t, err)
+		_, err = gz.Write(append(event, '\n'))
+		require.NoError(t, err)
+		require.NoError(t, gz.Flush())
+	}
+	members := data.Bytes()
+	cut := len(members) - 5
+	require.NoError(t, os.WriteFile(gzipLog, members[:cut], 0o644))
+	end, partial = eventLogEnd(gzipLog)
+	require.True(t, partial)
+	require.Less(t, end, int64(cut))
+	require.NoError(t, os.Truncate(gzipLog, end))
+
+	r, err := progress.OpenEventLog(gzipLog)
+	require.NoError(t, err)
+	defer r.Close()
+	var got []string
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, e.Lines...)
+	}
+	require.Equal(t, []string{"a", "b"}, got)
+	_, partial = eventLogEnd(gzipLog)
+	require.False(t, partial)
+}
+
+func TestDiagnosePlaygrounds_LeftNetemQdisc(t *testing.T) {
+	rt, _, _ := newFakeRuntime()
+	old := tcCommand
+	t.Cleanup(func() { tcCommand = old })
+	var calls []string
+	qdisc := "qdisc prio 1: root refcnt 2 bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1"
+	tcCommand = func(args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "qdisc" && args[1] == "show" {
+			return []byte(qdisc), nil
+		}
+		return nil, nil
+	}
+
+	base := t.TempDir()
+	dir := writeRuntimeFiles(t, base, "killed", map[string]string{
+		playgroundNetemFileName: `{"dev":"lo","handle":"1:"}`,
+	})
+	findings, err := rt.diagnosePlaygrounds(base, "", t.TempDir())
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	require.Equal(t, issueLeftNetemQdisc, findings[0].Issue)
+	require.Equal(t, "qdisc 1: of --inject-latency still delays lo", findings[0].Detail)
+	require.NoError(t, findings[0].apply())
+	require.Equal(t, []string{"qdisc show dev lo", "qdisc del dev lo root"}, calls)
+	require.NoFileExists(t, filepath.Join(dir, playgroundNetemFileName))
+
+	// Removed by hand: only the state file is left.
+	qdisc = "qdisc noqueue 0: root refcnt 2"
+	writeRuntimeFiles(t, base, "killed", map[string]string{
+		playgroundNetemFileName: `{"dev":"lo","handle":"1:"}`,
+	})
+	findings, err = rt.diagnosePlaygrounds(base, "", t.TempDir())
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	require.Equal(t, "remove "+playgroundNetemFileName, findings[0].Fix)
+	require.NoError(t, findings[0].apply())
+	require.NoFileExists(t, filepath.Join(dir, playgroundNetemFileName))
+}
+
+func TestDiagnoseStartQueue_StaleEntries(t *testing.T) {
+	rt, _, ops := newFakeRuntime()
+	ops.spawn(1)
+	queue := t.TempDir()
+	for name, content := range map[string]string{
+		"1-live" + startEntrySuffix:    `{"pid":1,"tag":"live"}`,
+		"4242-gone" + startEntrySuffix: `{"pid":4242,"tag":"gone"}`,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(queue, name), []byte(content), 0o644))
+	}
+
+	findings := rt.diagnoseStartQueue(queue, "")
+	require.Len(t, findings, 1)
+	require.Equal(t, "gone", findings[0].Tag)
+	require.Equal(t, issueStaleStartEntry, findings[0].Issue)
+	require.Empty(t, rt.diagnoseStartQueue(queue, "live"))
+
+	require.NoError(t, findings[0].apply())
+	require.NoFileExists(t, filepath.Join(queue, "4242-gone"+startEntrySuffix))
+	require.FileExists(t, filepath.Join(queue, "1-live"+startEntrySuffix))
+}
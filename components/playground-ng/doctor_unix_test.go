@@ -0,0 +1,80 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindOrphanProcesses_KillsProcessesOfStoppedPlayground(t *testing.T) {
+	sleepBin, err := exec.LookPath("sleep")
+	require.NoError(t, err)
+	cmd := exec.Command(sleepBin, "1000")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	require.NoError(t, cmd.Start())
+	pid := cmd.Process.Pid
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+	startTime, err := processStartTime(pid)
+	require.NoError(t, err)
+
+	base := t.TempDir()
+	dead := filepath.Join(base, "dead")
+	require.NoError(t, os.MkdirAll(dead, 0o755))
+	require.NoError(t, writeInstanceRecords(dead, []instanceRecord{
+		{Name: "tikv-0", Service: "tikv", PID: pid, StartTime: startTime, BinPath: sleepBin},
+		// Same pid but another start time: a recycled pid must not match.
+		{Name: "tikv-1", Service: "tikv", PID: pid, StartTime: startTime - 1000, BinPath: sleepBin},
+		// Same pid without a start time is reported, but never killed.
+		{Name: "pd-0", Service: "pd", PID: pid, BinPath: sleepBin},
+		// Nor reported if it doesn't run the recorded binary.
+		{Name: "pd-1", Service: "pd", PID: pid, BinPath: "/bin/pd-server"},
+	}))
+
+	// A running playground keeps its processes.
+	alive := filepath.Join(base, "alive")
+	require.NoError(t, os.MkdirAll(alive, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(alive, playgroundPIDFileName), []byte("pid="+strconv.Itoa(os.Getpid())+"\n"), 0o644))
+	require.NoError(t, writeInstanceRecords(alive, []instanceRecord{
+		{Name: "tidb-0", Service: "tidb", PID: pid, StartTime: startTime, BinPath: sleepBin},
+	}))
+
+	orphans, err := newRuntimeOps().findOrphanProcesses(base)
+	require.NoError(t, err)
+	require.Equal(t, []orphanProcess{
+		{Tag: "dead", Instance: "pd-0", PID: pid, BinPath: sleepBin, Unverified: true},
+		{Tag: "dead", Instance: "tikv-0", PID: pid, BinPath: sleepBin},
+	}, orphans)
+
+	findings, err := newRuntimeOps().diagnosePlaygrounds(base, "", t.TempDir())
+	require.NoError(t, err)
+	fixable := make(map[string]bool)
+	for _, f := range findings {
+		if f.Issue == issueOrphanProcess {
+			fixable[strings.Fields(f.Detail)[0]] = f.apply != nil
+		}
+	}
+	require.Equal(t, map[string]bool{"pd-0": false, "tikv-0": true}, fixable, "only the verified orphan can be killed")
+
+	require.NoError(t, newRuntimeOps().killOrphanProcess(orphans[1], time.Second))
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "orphan process not killed")
+	}
+}
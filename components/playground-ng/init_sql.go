@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// playgroundInitializedFileName marks a data dir whose TiDB ran --init-sql
+// and got --root-password. It is kept with the data, so a restarted or cloned
+// playground does not run them again.
+const playgroundInitializedFileName = "initialized"
+
+// readInitSQL reads the statements of the --init-sql file, before anything is
+// started so a missing or malformed file fails the start early.
+func readInitSQL(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "read --init-sql")
+	}
+	stmts, err := splitSQLStatements(string(data))
+	if err != nil {
+		return nil, errors.Annotatef(err, "parse --init-sql %s", path)
+	}
+	return stmts, nil
+}
+
+// splitSQLStatements splits script on the semicolons outside quotes and
+// comments. A comment stays with the statement it precedes; statements with
+// only comments are dropped.
+func splitSQLStatements(script string) ([]string, error) {
+	var (
+		stmts   []string
+		start   int
+		hasCode bool
+		line    = 1
+	)
+	flush := func(end int) {
+		if hasCode {
+			stmts = append(stmts, strings.TrimSpace(script[start:end]))
+		}
+		start, hasCode = end+1, false
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == '\'' || c == '"' || c == '`':
+			open := line
+			hasCode = true
+			for i++; ; i++ {
+				if i >= len(script) {
+					return nil, errors.Errorf("line %d: unterminated %c", open, c)
+				}
+				if script[i] == '\n' {
+					line++
+				}
+				if script[i] == '\\' && c != '`' {
+					i++
+					continue
+				}
+				if script[i] == c {
+					break
+				}
+			}
+		case c == '#' || (c == '-' && strings.HasPrefix(script[i:], "-- ")):
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			line++
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			open := line
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				return nil, errors.Errorf("line %d: unterminated comment", open)
+			}
+			line += strings.Count(script[i:i+2+end], "\n")
+			i += end + 3
+		case c == ';':
+			flush(i)
+		case c != ' ' && c != '\t' && c != '\r':
+			hasCode = true
+		}
+	}
+	flush(len(script))
+	return stmts, nil
+}
+
+// initializeTiDB runs the --init-sql statements on the TiDB at addr, sets a
+// TiFlash replica on the --tiflash.replica targets and then sets the
+// --root-password, reporting each step in the "Initialize TiDB" group. It does
+// nothing if the data dir is already initialized.
+func (p *Playground) initializeTiDB(ctx context.Context, addr string, stmts []string, replicas []sqlTarget, password string) error {
+	marker := filepath.Join(p.dataDir, playgroundInitializedFileName)
+	if (len(stmts) == 0 && len(replicas) == 0 && password == "") || utils.IsExist(marker) {
+		return nil
+	}
+
+	group := p.ui.Group("Initialize TiDB")
+	defer group.Close()
+
+	// The password is not set yet, unless a previous start failed after
+	// setting it.
+	db, err := openInitConn(ctx, addr, password)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	defer conn.Close()
+
+	if len(stmts) > 0 {
+		task := group.Task("Run init SQL")
+		task.SetTotal(int64(len(stmts)))
+		if err := runInitSQL(ctx, conn, stmts, task.SetCurrent); err != nil {
+			task.Error(err.Error())
+			return err
+		}
+		task.Done()
+	}
+	if err := setTiFlashReplicas(ctx, group, conn, replicas, 1); err != nil {
+		return err
+	}
+	if password != "" {
+		task := group.Task("Set root password")
+		if _, err := conn.ExecContext(ctx, "ALTER USER 'root'@'%' IDENTIFIED BY "+sqlQuote(password)); err != nil {
+			task.Error(err.Error())
+			return errors.Annotate(err, "set the root password")
+		}
+		task.Done()
+	}
+	return utils.WriteFile(marker, nil, 0o644)
+}
+
+// setGCLifeTime sets the GC life time of the cluster to the --gc-ttl through
+// the TiDB at addr, on each start so a changed flag takes effect.
+func (p *Playground) setGCLifeTime(ctx context.Context, addr string, lifeTime time.Duration, password string) error {
+	group := p.ui.Group("Tune GC")
+	defer group.Close()
+	task := group.Task("GC life time")
+	task.SetMeta(lifeTime.String())
+
+	db, err := openInitConn(ctx, addr, password)
+	if err != nil {
+		task.Error(err.Error())
+		return err
+	}
+	defer db.Close()
+	stmt := fmt.Sprintf("SET GLOBAL %s = %s", proc.GCLifeTimeVariable, sqlQuote(lifeTime.String()))
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		task.Error(err.Error())
+		return errors.Annotate(err, "set the GC life time")
+	}
+	task.Done()
+	return nil
+}
+
+// openInitConn returns a handle to the TiDB at addr logged in as root,
+// without password or else with password.
+func openInitConn(ctx context.Context, addr, password string) (*sql.DB, error) {
+	var lastErr error
+	for _, pw := range []string{"", password} {
+		db, err := proc.OpenTiDB(addr, pw)
+		if err != nil {
+			return nil, err
+		}
+		if lastErr = db.PingContext(ctx); lastErr == nil {
+			return db, nil
+		}
+		db.Close()
+		if password == "" {
+			break
+		}
+	}
+	return nil, errors.Annotatef(lastErr, "connect to TiDB %s", addr)
+}
+
+// runInitSQL executes stmts in order on conn, so that session state such as
+// USE carries over, reporting the number of statements done to progress.
+func runInitSQL(ctx context.Context, conn *sql.Conn, stmts []string, progress func(int64)) error {
+	for i, stmt := range stmts {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return errors.Errorf("init SQL statement %d (%s): %s", i+1, abbreviateSQL(stmt), err)
+		}
+		progress(int64(i + 1))
+	}
+	return nil
+}
+
+func abbreviateSQL(stmt string) string {
+	stmt = strings.Join(strings.Fields(stmt), " ")
+	if len(stmt) > 60 {
+		return stmt[:57] + "..."
+	}
+	return stmt
+}
+
+// sqlQuote returns s as a single-quoted SQL string literal.
+func sqlQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
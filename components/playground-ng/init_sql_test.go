@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	stmts, err := splitSQLStatements(`
+-- the schema of the app
+CREATE DATABASE app;
+USE app;
+CREATE TABLE t (a VARCHAR(10) COMMENT 'a;b', b INT); # trailing comment
+/* a comment; with a semicolon */
+INSERT INTO t VALUES ('it\'s;', 1), ("x;y", 2);
+SELECT ` + "`a;`" + ` FROM t
+`)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"-- the schema of the app\nCREATE DATABASE app",
+		"USE app",
+		"CREATE TABLE t (a VARCHAR(10) COMMENT 'a;b', b INT)",
+		"# trailing comment\n/* a comment; with a semicolon */\nINSERT INTO t VALUES ('it\\'s;', 1), (\"x;y\", 2)",
+		"SELECT `a;` FROM t",
+	}, stmts)
+
+	stmts, err = splitSQLStatements("-- nothing\n;;\n")
+	require.NoError(t, err)
+	require.Empty(t, stmts)
+
+	_, err = splitSQLStatements("SELECT 1;\nSELECT 'a;\n")
+	require.EqualError(t, err, "line 2: unterminated '")
+	_, err = splitSQLStatements("SELECT 1;\n\n/* open")
+	require.EqualError(t, err, "line 3: unterminated comment")
+}
+
+func TestReadInitSQL(t *testing.T) {
+	stmts, err := readInitSQL("")
+	require.NoError(t, err)
+	require.Nil(t, stmts)
+
+	path := filepath.Join(t.TempDir(), "init.sql")
+	require.NoError(t, os.WriteFile(path, []byte("CREATE USER 'app'@'%';\nGRANT ALL ON *.* TO 'app'@'%';\n"), 0o644))
+	stmts, err = readInitSQL(path)
+	require.NoError(t, err)
+	require.Len(t, stmts, 2)
+
+	_, err = readInitSQL(filepath.Join(t.TempDir(), "missing.sql"))
+	require.ErrorContains(t, err, "read --init-sql")
+}
+
+func TestSQLQuote(t *testing.T) {
+	require.Equal(t, `'it\'s \\ ok'`, sqlQuote(`it's \ ok`))
+}
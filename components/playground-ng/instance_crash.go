@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+const (
+	// playgroundCrashesDirName holds the artifacts of the instances that
+	// exited abnormally, one dir per crash named <instance>-<time>.
+	playgroundCrashesDirName = "crashes"
+	crashReportFileName      = "crash.json"
+	// crashLogTailLines is how many trailing lines of the component log a
+	// crash dir keeps; the captured stdout/stderr is kept whole.
+	crashLogTailLines = 200
+	// crashPanicFileMaxSize bounds the panic files copied into a crash dir.
+	crashPanicFileMaxSize = 1 << 20
+)
+
+// instanceCrash describes an abnormal exit of an instance, written to
+// crash.json in its crash dir.
+type instanceCrash struct {
+	Name     string    `json:"name"`
+	Service  string    `json:"service"`
+	PID      int       `json:"pid"`
+	ExitedAt time.Time `json:"exited_at"`
+	// Exit is the wait status, e.g. "exit status 3" or "signal: segmentation
+	// fault (core dumped)". ExitCode is -1 for a process killed by a signal.
+	Exit     string `json:"exit"`
+	ExitCode int    `json:"exit_code"`
+	Binary   string `json:"binary,omitempty"`
+	LogFile  string `json:"log_file,omitempty"`
+	// Files are the core and panic files found in the instance dirs, moved
+	// (core files) or copied (panic files) into the crash dir.
+	Files []string `json:"files,omitempty"`
+}
+
+// collectInstanceCrash saves what explains the abnormal exit of inst to a new
+// crash dir under dataDir and returns its path: crash.json, the captured
+// stdout/stderr (output.log), the tail of the component log (log-tail.log),
+// and the core and panic files left in the instance dirs.
+//
+// It runs in the controller goroutine before the instance may be restarted,
+// so the files still belong to the crashed process.
+func collectInstanceCrash(dataDir string, inst proc.Process, pid int, exitErr error, now time.Time) (string, error) {
+	info := inst.Info()
+	crash := instanceCrash{
+		Name:     info.Name(),
+		Service:  info.Service.String(),
+		PID:      pid,
+		ExitedAt: now.UTC(),
+		Exit:     exitErr.Error(),
+		ExitCode: -1,
+		Binary:   info.BinPath,
+		LogFile:  inst.LogFile(),
+	}
+	var ee *exec.ExitError
+	if stdErrors.As(exitErr, &ee) && ee.ProcessState != nil {
+		crash.ExitCode = ee.ProcessState.ExitCode()
+	}
+
+	dir := filepath.Join(realDataDir(dataDir), playgroundCrashesDirName, crash.Name+"-"+now.Format(snapshotIDLayout))
+	if err := utils.MkdirAll(dir, 0o755); err != nil {
+		return "", errors.AddStack(err)
+	}
+
+	var output []string
+	if info.Proc != nil {
+		output = info.Proc.OutputTail(0)
+	}
+	if err := writeLines(filepath.Join(dir, "output.log"), output); err != nil {
+		return "", err
+	}
+	if crash.LogFile != "" {
+		if lines, err := utils.TailN(crash.LogFile, crashLogTailLines); err == nil {
+			if err := writeLines(filepath.Join(dir, "log-tail.log"), lines); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	instDirs := []string{info.Dir}
+	if info.LogDir != "" && info.LogDir != info.Dir {
+		instDirs = append(instDirs, info.LogDir)
+	}
+	for _, instDir := range instDirs {
+		entries, err := os.ReadDir(instDir)
+		if err != nil {
+			continue
+		}
+		for _, ent := range entries {
+			if !ent.Type().IsRegular() {
+				continue
+			}
+			name := ent.Name()
+			src, dst := filepath.Join(instDir, name), filepath.Join(dir, name)
+			switch {
+			case name == "core" || strings.HasPrefix(name, "core."):
+				// Core files may be huge: move them, leaving them in place
+				// if the crash dir is on another filesystem.
+				if os.Rename(src, dst) != nil {
+					dst = src
+				}
+			case strings.Contains(strings.ToLower(name), "panic"):
+				// Copy panic files: components may check them on restart,
+				// e.g. the TiKV panic mark file.
+				if fi, err := ent.Info(); err != nil || fi.Size() > crashPanicFileMaxSize || utils.Copy(src, dst) != nil {
+					dst = src
+				}
+			default:
+				continue
+			}
+			crash.Files = append(crash.Files, dst)
+		}
+	}
+
+	data, err := json.MarshalIndent(crash, "", "  ")
+	if err != nil {
+		return "", errors.AddStack(err)
+	}
+	if err := utils.WriteFile(filepath.Join(dir, crashReportFileName), append(data, '\n'), 0o644); err != nil {
+		return "", errors.AddStack(err)
+	}
+	return dir, nil
+}
+
+func writeLines(path string, lines []string) error {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return errors.AddStack(utils.WriteFile(path, []byte(b.String()), 0o644))
+}
+
+// recordInstanceCrash collects the artifacts of an abnormal exit of inst,
+// warns about it through the UI and remembers the crash dir for display.
+// It runs in the controller goroutine.
+func (p *Playground) recordInstanceCrash(state *controllerState, inst proc.Process, pid int, exitErr error) {
+	title := procDisplayName(inst, true)
+	dir, err := collectInstanceCrash(p.dataDir, inst, pid, exitErr, time.Now())
+	if err != nil {
+		fmt.Fprintf(p.terminalWriter(), "Failed to collect the crash artifacts of %s: %v\n", title, err)
+		return
+	}
+	if state.crashDirs == nil {
+		state.crashDirs = make(map[string]string)
+	}
+	state.crashDirs[inst.Info().Name()] = dir
+	lines := []string{fmt.Sprintf("%s crashed (%v), artifacts saved to %s", title, exitErr, prettifyUserPath(dir))}
+	if p.ui != nil {
+		p.ui.WarnLines(lines)
+		return
+	}
+	fmt.Fprintln(p.terminalWriter(), lines[0])
+}
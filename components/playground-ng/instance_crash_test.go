@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectInstanceCrash(t *testing.T) {
+	dataDir := t.TempDir()
+	instDir := filepath.Join(dataDir, "tikv-0")
+	require.NoError(t, os.MkdirAll(instDir, 0o755))
+	logFile := filepath.Join(instDir, "tikv.log")
+	require.NoError(t, os.WriteFile(logFile, []byte("[INFO] start\n[FATAL] boom\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(instDir, "core.42"), []byte("core"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(instDir, "panic_mark_file"), []byte("panic"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(instDir, "tikv.toml"), nil, 0o644))
+
+	exitErr := exec.Command("/bin/sh", "-c", "exit 3").Run()
+	require.Error(t, exitErr)
+	osProc := &stubOSProcess{output: []string{"thread 'main' panicked", "note: backtrace"}}
+	inst := &stubProcess{info: &proc.ProcessInfo{Service: proc.ServiceTiKV, Dir: instDir, BinPath: "/bin/tikv-server", Proc: osProc}, logFile: logFile}
+
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local)
+	dir, err := collectInstanceCrash(dataDir, inst, 42, exitErr, now)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dataDir, playgroundCrashesDirName, "tikv-0-20260102-150405"), dir)
+
+	data, err := os.ReadFile(filepath.Join(dir, crashReportFileName))
+	require.NoError(t, err)
+	var crash instanceCrash
+	require.NoError(t, json.Unmarshal(data, &crash))
+	require.Equal(t, "tikv-0", crash.Name)
+	require.Equal(t, 42, crash.PID)
+	require.Equal(t, 3, crash.ExitCode)
+	require.Equal(t, "exit status 3", crash.Exit)
+	require.Equal(t, logFile, crash.LogFile)
+	require.ElementsMatch(t, []string{filepath.Join(dir, "core.42"), filepath.Join(dir, "panic_mark_file")}, crash.Files)
+
+	data, err = os.ReadFile(filepath.Join(dir, "output.log"))
+	require.NoError(t, err)
+	require.Equal(t, "thread 'main' panicked\nnote: backtrace\n", string(data))
+	data, err = os.ReadFile(filepath.Join(dir, "log-tail.log"))
+	require.NoError(t, err)
+	require.Equal(t, "[INFO] start\n[FATAL] boom\n", string(data))
+
+	// Core files are moved, panic files are copied.
+	require.NoFileExists(t, filepath.Join(instDir, "core.42"))
+	require.FileExists(t, filepath.Join(instDir, "panic_mark_file"))
+	require.NoFileExists(t, filepath.Join(dir, "tikv.toml"))
+}
+
+func TestHandleDisplay_ShowsCrashDir(t *testing.T) {
+	exitCmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess_ExitWithCode", "--", "3")
+	exitCmd.Env = append(os.Environ(), "TIUP_PLAYGROUND_HELPER_PROCESS=1")
+	_ = exitCmd.Run()
+	info := &proc.ProcessInfo{Service: proc.ServiceTiKV}
+	info.Proc = &displayOSProcess{pid: exitCmd.Process.Pid, cmd: exitCmd}
+	state := &controllerState{
+		procs:     map[proc.ServiceID][]proc.Process{proc.ServiceTiKV: {&displayProcess{info: info}}},
+		crashDirs: map[string]string{"tikv-0": "/tmp/data/crashes/tikv-0-20260102-150405"},
+	}
+	pg := NewPlayground(t.TempDir(), 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, pg.handleDisplay(state, &buf, DisplayRequest{}))
+	require.Contains(t, buf.String(), "exited(3)")
+	require.Contains(t, buf.String(), "tikv-0 crashed, artifacts in /tmp/data/crashes/tikv-0-20260102-150405")
+
+	buf.Reset()
+	require.NoError(t, pg.handleDisplay(state, &buf, DisplayRequest{JSON: true}))
+	var items []displayItem
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &items))
+	require.Equal(t, "/tmp/data/crashes/tikv-0-20260102-150405", items[0].CrashDir)
+}
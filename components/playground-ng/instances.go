@@ -11,9 +11,9 @@ import (
 	"time"
 
 	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
 	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
 	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
-	"github.com/pingcap/tiup/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -30,13 +30,25 @@ type playgroundInstanceSummary struct {
 }
 
 func newPS(state *cliState) *cobra.Command {
+	var wide bool
+	var output string
 	cmd := &cobra.Command{
 		Use:   "ps",
 		Short: "List running playground-ng instances",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return ps(cmd.OutOrStdout(), state)
+			plain, err := parseTableOutput(output)
+			if err != nil {
+				return err
+			}
+			td := tuiv2output.Table{Plain: plain}
+			if !wide {
+				td.Width = tuiv2output.FitWidth(cmd.OutOrStdout())
+			}
+			return ps(cmd.OutOrStdout(), state, td)
 		},
 	}
+	cmd.Flags().BoolVarP(&wide, "wide", "w", false, "Show the whole values instead of clipping them to the terminal width")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: table, or plain for tab separated values")
 	return cmd
 }
 
@@ -56,7 +68,8 @@ func newStopAll(state *cliState) *cobra.Command {
 	return cmd
 }
 
-func ps(out io.Writer, state *cliState) error {
+// ps lists the running playgrounds in td, which holds the rendering options.
+func ps(out io.Writer, state *cliState, td tuiv2output.Table) error {
 	if out == nil {
 		out = io.Discard
 	}
@@ -68,7 +81,8 @@ func ps(out io.Writer, state *cliState) error {
 	if err != nil {
 		return err
 	}
-	if len(targets) == 0 {
+	// Plain output is for scripts: no instance is just the header.
+	if len(targets) == 0 && !td.Plain {
 		fmt.Fprint(out, tuiv2output.Callout{
 			Style:   tuiv2output.CalloutWarning,
 			Content: "No running playground-ng instances found.",
@@ -85,7 +99,7 @@ func ps(out io.Writer, state *cliState) error {
 		summaries = append(summaries, summary)
 	}
 
-	td := utils.NewTableDisplayer(out, []string{"TAG", "VERSION", "TIDB", "TIKV", "TIFLASH", "STATUS", "PORT", "START TIME"})
+	td.Header = []string{"TAG", "VERSION", "TIDB", "TIKV", "TIFLASH", "STATUS", "PORT", "START TIME"}
 	for _, s := range summaries {
 		startText := "-"
 		if s.hasStart {
@@ -102,7 +116,7 @@ func ps(out io.Writer, state *cliState) error {
 			startText,
 		)
 	}
-	td.Display()
+	fmt.Fprint(out, td.Render())
 	return nil
 }
 
@@ -129,10 +143,10 @@ func stopAll(out io.Writer, timeout time.Duration, state *cliState) error {
 		return nil
 	}
 
-	return stopAllWithProgressUI(out, targets, timeout)
+	return state.ops.stopAllWithProgressUI(out, targets, timeout)
 }
 
-func stopAllWithProgressUI(out io.Writer, targets []playgroundTarget, timeout time.Duration) error {
+func (r runtimeOps) stopAllWithProgressUI(out io.Writer, targets []playgroundTarget, timeout time.Duration) error {
 	if out == nil {
 		return fmt.Errorf("output writer is nil")
 	}
@@ -181,7 +195,7 @@ func stopAllWithProgressUI(out io.Writer, targets []playgroundTarget, timeout ti
 			t.Start()
 		}
 		go func(index int) {
-			results <- stopResult{index: index, err: stopSinglePlayground(target, timeout)}
+			results <- stopResult{index: index, err: r.stopSinglePlayground(target, timeout)}
 		}(i)
 	}
 
@@ -242,8 +256,7 @@ func inspectPlaygroundInstance(target playgroundTarget) (playgroundInstanceSumma
 	summary.started = start
 	summary.hasStart = hasStart
 
-	addr := "127.0.0.1:" + strconv.Itoa(target.port)
-	items, err := fetchDisplayJSON(addr)
+	items, err := fetchDisplayJSON(target.client())
 	if err != nil {
 		return playgroundInstanceSummary{}, err
 	}
@@ -295,13 +308,13 @@ func loadStartTime(dataDir string) (time.Time, bool) {
 	return f.startedAt, true
 }
 
-func fetchDisplayJSON(addr string) ([]displayItem, error) {
+func fetchDisplayJSON(c *client.Client) ([]displayItem, error) {
 	var buf bytes.Buffer
 	cmd := Command{
 		Type:    DisplayCommandType,
 		Display: &DisplayRequest{Verbose: true, JSON: true},
 	}
-	if err := sendCommandsAndPrintResult(&buf, []Command{cmd}, addr); err != nil {
+	if err := sendCommandsAndPrintResult(&buf, []Command{cmd}, c); err != nil {
 		return nil, err
 	}
 	var items []displayItem
@@ -328,10 +341,9 @@ func pickClusterVersion(items []displayItem) string {
 	return "-"
 }
 
-func stopSinglePlayground(target playgroundTarget, timeout time.Duration) error {
-	addr := "127.0.0.1:" + strconv.Itoa(target.port)
-	if err := sendCommandsAndPrintResult(io.Discard, []Command{{Type: StopCommandType}}, addr); err != nil {
+func (r runtimeOps) stopSinglePlayground(target playgroundTarget, timeout time.Duration) error {
+	if err := sendCommandsAndPrintResult(io.Discard, []Command{{Type: StopCommandType}}, target.client()); err != nil {
 		return err
 	}
-	return waitPlaygroundStopped(target.dir, timeout)
+	return r.waitPlayground(target.dir, playgroundWaitStopped, timeout)
 }
@@ -11,9 +11,11 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
 	"github.com/stretchr/testify/require"
 )
 
@@ -72,9 +74,9 @@ func TestPS_ListsRunningPlaygrounds(t *testing.T) {
 	makePlayground("a", "v8.5.4", 1, 1, 0)
 	makePlayground("b", "v8.5.4", 2, 1, 1)
 
-	state := &cliState{dataDir: base}
+	state := &cliState{dataDir: base, ops: newRuntimeOps()}
 	var buf bytes.Buffer
-	require.NoError(t, ps(&buf, state))
+	require.NoError(t, ps(&buf, state, tuiv2output.Table{}))
 
 	out := buf.String()
 	require.Contains(t, out, "TAG")
@@ -82,13 +84,20 @@ func TestPS_ListsRunningPlaygrounds(t *testing.T) {
 	require.Contains(t, out, "b")
 	require.Contains(t, out, "v8.5.4")
 	require.Contains(t, out, "running")
+
+	buf.Reset()
+	require.NoError(t, ps(&buf, state, tuiv2output.Table{Plain: true}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Equal(t, "TAG\tVERSION\tTIDB\tTIKV\tTIFLASH\tSTATUS\tPORT\tSTART TIME", lines[0])
+	require.True(t, strings.HasPrefix(lines[2], "b\tv8.5.4\t2\t1\t1\trunning\t"), lines[2])
 }
 
 func TestPS_NoInstances_PrintsWarning(t *testing.T) {
 	state := &cliState{dataDir: t.TempDir()}
 
 	var buf bytes.Buffer
-	require.NoError(t, ps(&buf, state))
+	require.NoError(t, ps(&buf, state, tuiv2output.Table{}))
 	require.Contains(t, buf.String(), "No running playground-ng instances found.")
 }
 
@@ -96,7 +105,7 @@ func TestPS_NoDataDir_PrintsWarning(t *testing.T) {
 	state := &cliState{dataDir: filepath.Join(t.TempDir(), "missing")}
 
 	var buf bytes.Buffer
-	require.NoError(t, ps(&buf, state))
+	require.NoError(t, ps(&buf, state, tuiv2output.Table{}))
 	require.Contains(t, buf.String(), "No running playground-ng instances found.")
 }
 
@@ -174,7 +183,7 @@ func TestStopAll_StopsAllPlaygrounds(t *testing.T) {
 	makePlayground("a", "v8.5.4", 1, 1, 0)
 	makePlayground("b", "v8.5.4", 2, 1, 1)
 
-	state := &cliState{dataDir: base}
+	state := &cliState{dataDir: base, ops: newRuntimeOps()}
 	var buf bytes.Buffer
 	require.NoError(t, stopAll(&buf, time.Second, state))
 
@@ -251,7 +260,7 @@ func TestStopAll_StopsAllPlaygroundsInParallel(t *testing.T) {
 	makePlayground("a")
 	makePlayground("b")
 
-	state := &cliState{dataDir: base}
+	state := &cliState{dataDir: base, ops: newRuntimeOps()}
 	require.NoError(t, stopAll(io.Discard, 3*time.Second, state))
 
 	times := make([]time.Time, 0, 2)
@@ -334,7 +343,7 @@ func TestStopAll_NonTTY_UsesTuiv2PlainOutput(t *testing.T) {
 	makePlayground("a", "v8.5.4")
 	makePlayground("b", "v8.5.4")
 
-	state := &cliState{dataDir: base}
+	state := &cliState{dataDir: base, ops: newRuntimeOps()}
 	var buf bytes.Buffer
 	require.NoError(t, stopAll(&buf, time.Second, state))
 	out := buf.String()
@@ -22,3 +22,14 @@ func killProcessOrGroup(pid int, sig syscall.Signal) error {
 	}
 	return syscall.Kill(pid, sig)
 }
+
+// freezeProcessOrGroup suspends the process (group) with SIGSTOP, so its
+// files can be copied while it can't write them. thawProcessOrGroup resumes
+// it.
+func freezeProcessOrGroup(pid int) error {
+	return killProcessOrGroup(pid, syscall.SIGSTOP)
+}
+
+func thawProcessOrGroup(pid int) error {
+	return killProcessOrGroup(pid, syscall.SIGCONT)
+}
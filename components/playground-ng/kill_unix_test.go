@@ -5,14 +5,18 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
 	"testing"
 	"time"
 
+	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
 	"github.com/stretchr/testify/require"
 )
 
@@ -53,3 +57,45 @@ func TestKillProcessOrGroup_KillsChildWhenLeader(t *testing.T) {
 		return syscall.Kill(childPID, 0) != nil
 	}, 2*time.Second, 20*time.Millisecond)
 }
+
+func TestCrash_KillsInstancesAndRemovesRuntimeFiles(t *testing.T) {
+	sleepBin, err := exec.LookPath("sleep")
+	require.NoError(t, err)
+	cmd := exec.Command(sleepBin, "1000")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	require.NoError(t, cmd.Start())
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+	startTime, err := processStartTime(cmd.Process.Pid)
+	require.NoError(t, err)
+
+	dataDir := t.TempDir()
+	require.NoError(t, writeInstanceRecords(dataDir, []instanceRecord{
+		{Name: "tikv-0", Service: "tikv", PID: cmd.Process.Pid, StartTime: startTime, BinPath: sleepBin},
+	}))
+	for _, name := range []string{playgroundPIDFileName, playgroundPortFileName, playgroundReadyFileName} {
+		require.NoError(t, os.WriteFile(filepath.Join(dataDir, name), []byte("1\n"), 0o644))
+	}
+
+	var eventLog bytes.Buffer
+	p := NewPlayground(dataDir, 0)
+	p.ui = progressv2.New(progressv2.Options{Mode: progressv2.ModePlain, Out: io.Discard, EventLog: &eventLog})
+	p.ui.Group("Scale out").Task("TiKV 1")
+
+	p.crash("controller", "boom", nil)
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "instance not killed")
+	}
+	for _, name := range []string{playgroundPIDFileName, playgroundPortFileName, playgroundReadyFileName} {
+		require.NoFileExists(t, filepath.Join(dataDir, name))
+	}
+	require.FileExists(t, filepath.Join(dataDir, playgroundInstancesFileName))
+	require.Contains(t, eventLog.String(), "playground crashed: boom")
+}
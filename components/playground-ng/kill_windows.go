@@ -13,3 +13,12 @@ func killProcessOrGroup(pid int, sig syscall.Signal) error {
 	return nil
 }
 
+func freezeProcessOrGroup(pid int) error {
+	_ = pid
+	return nil
+}
+
+func thawProcessOrGroup(pid int) error {
+	_ = pid
+	return nil
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// maxInjectedLatency bounds --inject-latency: beyond it the components time
+// out rather than run slower.
+const maxInjectedLatency = 5 * time.Second
+
+// parseInjectLatency parses the <service>=<duration> items of
+// --inject-latency.
+func parseInjectLatency(items []string) (map[proc.ServiceID]time.Duration, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	delays := make(map[proc.ServiceID]time.Duration, len(items))
+	for _, item := range items {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --inject-latency %q, expected <service>=<duration>, e.g. tikv=20ms", item)
+		}
+		serviceID := proc.ServiceID(strings.TrimSpace(name))
+		if !slices.Contains(proc.LatencyServices, serviceID) {
+			return nil, fmt.Errorf("--inject-latency does not support %q, only %s", name, joinServiceIDs(proc.LatencyServices))
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 || d > maxInjectedLatency {
+			return nil, fmt.Errorf("invalid --inject-latency %q, expected a duration in (0, %s]", item, maxInjectedLatency)
+		}
+		delays[serviceID] = d
+	}
+	return delays, nil
+}
+
+func joinServiceIDs(ids []proc.ServiceID) string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, id.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// latencyInjector delays the requests to the client port of the instances of
+// some services (--inject-latency), to approximate a cluster spread over
+// zones on a single host.
+//
+// With tc/netem (Linux, as root) the packets to the ports are delayed on the
+// loopback interface. Otherwise each instance listens on another port,
+// behind a latencyProxy on its client port. Either way the instances keep
+// their addresses.
+type latencyInjector struct {
+	delays map[proc.ServiceID]time.Duration
+	netem  *netemQdisc
+
+	mu      sync.Mutex
+	proxies map[string]*latencyProxy
+	closed  bool
+}
+
+// newLatencyInjector sets up the injection of delays, with tc/netem if it can
+// and else with proxies. The netem qdisc is recorded in dataDir.
+func newLatencyInjector(delays map[proc.ServiceID]time.Duration, dataDir string) *latencyInjector {
+	l := &latencyInjector{delays: delays, proxies: make(map[string]*latencyProxy)}
+	netem, err := setupNetem(delays, dataDir)
+	logIfErr(errors.Annotate(err, "inject latency with a userspace proxy instead of tc/netem"))
+	l.netem = netem
+	return l
+}
+
+// Mode returns how the delays are injected.
+func (l *latencyInjector) Mode() string {
+	if l.netem != nil {
+		return "tc/netem"
+	}
+	return "proxy"
+}
+
+// Summary describes the injected delays, e.g. "pd +5ms, tikv +20ms (proxy)".
+func (l *latencyInjector) Summary() string {
+	if l == nil {
+		return ""
+	}
+	var items []string
+	for _, serviceID := range proc.LatencyServices {
+		if d, ok := l.delays[serviceID]; ok {
+			items = append(items, fmt.Sprintf("%s +%s", serviceID, d))
+		}
+	}
+	return fmt.Sprintf("%s (%s)", strings.Join(items, ", "), l.Mode())
+}
+
+// inject delays the requests to the instance of info, before it starts: it
+// filters its client port with netem, or moves the instance to another port
+// behind a proxy. The other port is reserved until release. A restarted
+// instance keeps its proxy.
+func (l *latencyInjector) inject(info *proc.ProcessInfo) error {
+	if l == nil || info == nil {
+		return nil
+	}
+	delay, ok := l.delays[info.Service]
+	port := proc.LatencyClientPort(info)
+	if !ok || port == 0 {
+		return nil
+	}
+	if l.netem != nil {
+		return l.netem.addPort(port, delay)
+	}
+
+	addr := utils.JoinHostPort(info.Host, port)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return errors.New("latency injector is closed")
+	}
+	if p := l.proxies[addr]; p != nil {
+		// Hold the port again for the restart, if the previous process has
+		// released it: else the instance reports the conflict when it binds.
+		if p.reserved == nil {
+			if reserved, err := reservePort(info.Host, p.backendPort); err == nil {
+				p.reserved = reserved
+			}
+		}
+		info.LatencyPort = p.backendPort
+		return nil
+	}
+	reserved, err := reservePort(info.Host, 0)
+	if err != nil {
+		return err
+	}
+	backendPort := reserved.Addr().(*net.TCPAddr).Port
+	p, err := listenLatencyProxy(addr, utils.JoinHostPort(proc.AdvertiseHost(info.Host), backendPort), delay)
+	if err != nil {
+		_ = reserved.Close()
+		return errors.Annotatef(err, "start the latency proxy of %s", info.Name())
+	}
+	p.backendPort = backendPort
+	p.reserved = reserved
+	l.proxies[addr] = p
+	info.LatencyPort = backendPort
+	return nil
+}
+
+// release hands the port reserved by inject over to the instance of info,
+// right before it starts. The instance binds the port itself, so this is as
+// late as the port can be held.
+func (l *latencyInjector) release(info *proc.ProcessInfo) {
+	if l == nil || info == nil || info.LatencyPort == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if p := l.proxies[utils.JoinHostPort(info.Host, proc.LatencyClientPort(info))]; p != nil && p.reserved != nil {
+		_ = p.reserved.Close()
+		p.reserved = nil
+	}
+}
+
+// retain closes the proxies of the instances no longer in procs, e.g. after
+// a scale-in.
+func (l *latencyInjector) retain(procs []proc.Process) {
+	if l == nil {
+		return
+	}
+	keep := make(map[string]bool, len(procs))
+	for _, inst := range procs {
+		if info := inst.Info(); info != nil && proc.LatencyClientPort(info) != 0 {
+			keep[utils.JoinHostPort(info.Host, proc.LatencyClientPort(info))] = true
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for addr, p := range l.proxies {
+		if !keep[addr] {
+			_ = p.Close()
+			delete(l.proxies, addr)
+		}
+	}
+}
+
+// Close stops the proxies, or removes the netem qdisc.
+func (l *latencyInjector) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	l.closed = true
+	proxies := l.proxies
+	l.proxies = nil
+	l.mu.Unlock()
+	for _, p := range proxies {
+		_ = p.Close()
+	}
+	if l.netem != nil {
+		return l.netem.Close()
+	}
+	return nil
+}
+
+// startLatencyInjector starts injecting delays into the instances started
+// from now on. It stops when the playground shuts down.
+func (p *Playground) startLatencyInjector(delays map[proc.ServiceID]time.Duration) {
+	l := newLatencyInjector(delays, p.dataDir)
+	p.latency = l
+	go func() {
+		<-p.processGroup.Closed()
+		logIfErr(l.Close())
+	}()
+}
+
+// reservePort listens on port of host, or on a free port if it is 0, to hold
+// it until the instance that is to bind it starts.
+func reservePort(host string, port int) (net.Listener, error) {
+	ln, err := net.Listen("tcp", utils.JoinHostPort(host, port))
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	return ln, nil
+}
+
+// tcCommand runs tc with args. Tests replace it.
+var tcCommand = func(args ...string) ([]byte, error) {
+	return exec.Command("tc", args...).CombinedOutput()
+}
+
+// netemQdisc delays the packets sent to some ports over the loopback
+// interface: a prio qdisc at its root, whose bands after the three default
+// ones each hold a netem qdisc with one of the delays, and a filter per port
+// sending its packets to the band of its delay.
+//
+// The qdisc outlives a playground killed with SIGKILL, so it is recorded in
+// the state file playgroundNetemFileName until it is removed, for doctor to
+// find it.
+type netemQdisc struct {
+	bands     map[time.Duration]int
+	stateFile string
+}
+
+// netemState is the content of playgroundNetemFileName.
+type netemState struct {
+	Dev    string `json:"dev"`
+	Handle string `json:"handle"`
+}
+
+// setupNetem installs the netem qdisc for delays on lo when it runs as root on
+// Linux with tc, and returns nil otherwise. It fails if lo already has a
+// qdisc, e.g. of another playground.
+func setupNetem(delays map[proc.ServiceID]time.Duration, dataDir string) (*netemQdisc, error) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		return nil, nil
+	}
+	if _, err := exec.LookPath("tc"); err != nil {
+		return nil, nil
+	}
+	var stateFile string
+	if dataDir != "" {
+		stateFile = filepath.Join(dataDir, playgroundNetemFileName)
+	}
+	return installNetem(delays, stateFile)
+}
+
+// installNetem installs the netem qdisc for delays on lo, and records it in
+// stateFile unless it is empty.
+func installNetem(delays map[proc.ServiceID]time.Duration, stateFile string) (*netemQdisc, error) {
+	out, err := tcCommand("qdisc", "show", "dev", "lo")
+	if err != nil {
+		return nil, errors.Errorf("tc qdisc show: %s", strings.TrimSpace(string(out)))
+	}
+	if !strings.HasPrefix(strings.TrimSpace(string(out)), "qdisc noqueue") {
+		return nil, errors.Errorf("lo already has a qdisc: %s (run %s if a playground was killed)",
+			strings.TrimSpace(string(out)), playgroundCLICommand("doctor"))
+	}
+
+	var distinct []time.Duration
+	for _, d := range delays {
+		if !slices.Contains(distinct, d) {
+			distinct = append(distinct, d)
+		}
+	}
+	slices.Sort(distinct)
+	n := &netemQdisc{bands: make(map[time.Duration]int, len(distinct)), stateFile: stateFile}
+	if err := n.tc("qdisc", "add", "dev", "lo", "root", "handle", "1:", "prio", "bands", strconv.Itoa(3+len(distinct))); err != nil {
+		return nil, err
+	}
+	if stateFile != "" {
+		data, err := json.Marshal(netemState{Dev: "lo", Handle: "1:"})
+		if err == nil {
+			err = utils.WriteFile(stateFile, append(data, '\n'), 0o644)
+		}
+		if err != nil {
+			_ = n.Close()
+			return nil, errors.Annotatef(err, "record the netem qdisc")
+		}
+	}
+	for i, d := range distinct {
+		band := 4 + i
+		n.bands[d] = band
+		err := n.tc("qdisc", "add", "dev", "lo", "parent", fmt.Sprintf("1:%d", band), "handle", fmt.Sprintf("%d:", 10*band),
+			"netem", "delay", fmt.Sprintf("%dus", d.Microseconds()))
+		if err != nil {
+			_ = n.Close()
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// addPort delays the packets sent to port by delay.
+func (n *netemQdisc) addPort(port int, delay time.Duration) error {
+	band, ok := n.bands[delay]
+	if !ok {
+		return errors.Errorf("no netem band for %s", delay)
+	}
+	return n.tc("filter", "add", "dev", "lo", "parent", "1:", "protocol", "ip", "prio", "1",
+		"u32", "match", "ip", "dport", strconv.Itoa(port), "0xffff", "flowid", fmt.Sprintf("1:%d", band))
+}
+
+// Close removes the qdisc, with its filters, from lo, and then its state
+// file.
+func (n *netemQdisc) Close() error {
+	if err := n.tc("qdisc", "del", "dev", "lo", "root"); err != nil {
+		return err
+	}
+	if n.stateFile != "" {
+		if err := os.Remove(n.stateFile); err != nil && !os.IsNotExist(err) {
+			return errors.AddStack(err)
+		}
+	}
+	return nil
+}
+
+// readNetemState returns the qdisc recorded in the state file at path, nil if
+// there is no such file.
+func readNetemState(path string) (*netemState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	var s netemState
+	if err := json.Unmarshal(data, &s); err != nil || s.Dev == "" || s.Handle == "" {
+		return nil, errors.Errorf("parse %s: %v", filepath.Base(path), err)
+	}
+	return &s, nil
+}
+
+// installed reports whether the recorded qdisc is still the root qdisc of its
+// device.
+func (s *netemState) installed() (bool, error) {
+	out, err := tcCommand("qdisc", "show", "dev", s.Dev)
+	if err != nil {
+		return false, errors.Errorf("tc qdisc show: %s", strings.TrimSpace(string(out)))
+	}
+	return strings.Contains(string(out), "qdisc prio "+s.Handle+" root"), nil
+}
+
+func (n *netemQdisc) tc(args ...string) error {
+	if out, err := tcCommand(args...); err != nil {
+		return errors.Errorf("tc %s: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// latencyProxy listens on the client address of an instance that listens on
+// backend instead, and forwards what the clients send after delay, so every
+// request takes delay longer. Responses are forwarded right away.
+type latencyProxy struct {
+	relay       *tcpRelay
+	backend     string
+	backendPort int
+	delay       time.Duration
+	// reserved holds backendPort until the instance starts and binds it (see
+	// latencyInjector.release), so nothing else takes it meanwhile.
+	reserved net.Listener
+}
+
+func listenLatencyProxy(addr, backend string, delay time.Duration) (*latencyProxy, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	p := &latencyProxy{backend: backend, delay: delay}
+	p.relay = newTCPRelay(ln, func() (net.Conn, error) {
+		return net.DialTimeout("tcp", p.backend, relayDialTimeout)
+	}, p.forward)
+	return p, nil
+}
+
+// Close stops accepting connections and closes the proxied ones.
+func (p *latencyProxy) Close() error {
+	if p.reserved != nil {
+		_ = p.reserved.Close()
+	}
+	return p.relay.Close()
+}
+
+// delayedChunk is data read from a client, to be forwarded at a given time.
+type delayedChunk struct {
+	data []byte
+	at   time.Time
+}
+
+// forward writes what the client sends to the backend, each chunk delay
+// after it was read.
+func (p *latencyProxy) forward(backend, client net.Conn) {
+	chunks := make(chan delayedChunk, 64)
+	// The reader stamps what the client sends, so the delay does not add up
+	// over the chunks of a request.
+	go func() {
+		defer close(chunks)
+		for {
+			buf := make([]byte, 32*1024)
+			n, err := client.Read(buf)
+			if n > 0 {
+				chunks <- delayedChunk{data: buf[:n], at: time.Now().Add(p.delay)}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	for c := range chunks {
+		time.Sleep(time.Until(c.at))
+		if _, err := backend.Write(c.data); err != nil {
+			// Drain the chunks so the reader never blocks.
+			go func() {
+				for range chunks {
+				}
+			}()
+			return
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInjectLatency(t *testing.T) {
+	delays, err := parseInjectLatency([]string{"tikv=20ms", " pd = 5ms "})
+	require.NoError(t, err)
+	require.Equal(t, map[proc.ServiceID]time.Duration{proc.ServiceTiKV: 20 * time.Millisecond, proc.ServicePD: 5 * time.Millisecond}, delays)
+
+	for item, msg := range map[string]string{
+		"tikv":      "expected <service>=<duration>",
+		"tidb=20ms": `does not support "tidb", only pd, tikv`,
+		"tikv=fast": "expected a duration",
+		"tikv=0s":   "expected a duration",
+		"tikv=1m0s": "expected a duration",
+	} {
+		_, err := parseInjectLatency([]string{item})
+		require.ErrorContains(t, err, msg, item)
+	}
+}
+
+// echoServer accepts connections on addr and sends back what it reads.
+func echoServer(t *testing.T, addr string) {
+	ln, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				_, _ = io.Copy(c, c)
+			}()
+		}
+	}()
+}
+
+func roundTrip(t *testing.T, addr string) time.Duration {
+	c, err := net.DialTimeout("tcp", addr, time.Second)
+	require.NoError(t, err)
+	defer c.Close()
+	start := time.Now()
+	_, err = c.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	require.NoError(t, c.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err = io.ReadFull(c, buf)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(buf))
+	return time.Since(start)
+}
+
+func TestLatencyInjector_Proxy(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	l := &latencyInjector{
+		delays:  map[proc.ServiceID]time.Duration{proc.ServiceTiKV: delay},
+		proxies: make(map[string]*latencyProxy),
+	}
+	defer l.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+	info := &proc.ProcessInfo{Service: proc.ServiceTiKV, Host: "127.0.0.1", Port: port}
+	require.NoError(t, l.inject(info))
+	require.NotZero(t, info.LatencyPort)
+	require.NotEqual(t, port, info.LatencyPort)
+
+	// The port of the instance is held until it starts, and then it listens
+	// on it: here an echo server.
+	backend := utils.JoinHostPort("127.0.0.1", info.LatencyPort)
+	_, err = net.Listen("tcp", backend)
+	require.Error(t, err)
+	l.release(info)
+	echoServer(t, backend)
+	require.GreaterOrEqual(t, roundTrip(t, utils.JoinHostPort("127.0.0.1", port)), delay)
+
+	// A restarted instance keeps its proxy.
+	again := &proc.ProcessInfo{Service: proc.ServiceTiKV, Host: "127.0.0.1", Port: port}
+	require.NoError(t, l.inject(again))
+	require.Equal(t, info.LatencyPort, again.LatencyPort)
+
+	// The other services are left alone.
+	db := &proc.ProcessInfo{Service: proc.ServiceTiDB, Host: "127.0.0.1", Port: 4000}
+	require.NoError(t, l.inject(db))
+	require.Zero(t, db.LatencyPort)
+
+	// Scaled in: the proxy is closed.
+	l.retain(nil)
+	require.Empty(t, l.proxies)
+	_, err = net.DialTimeout("tcp", utils.JoinHostPort("127.0.0.1", port), time.Second)
+	require.Error(t, err)
+}
+
+func TestInstallNetem(t *testing.T) {
+	old := tcCommand
+	t.Cleanup(func() { tcCommand = old })
+	var calls []string
+	qdisc := "qdisc noqueue 0: root refcnt 2"
+	tcCommand = func(args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "qdisc" && args[1] == "show" {
+			return []byte(qdisc), nil
+		}
+		return nil, nil
+	}
+
+	stateFile := filepath.Join(t.TempDir(), playgroundNetemFileName)
+	n, err := installNetem(map[proc.ServiceID]time.Duration{proc.ServiceTiKV: 20 * time.Millisecond, proc.ServicePD: 5 * time.Millisecond}, stateFile)
+	require.NoError(t, err)
+	state, err := readNetemState(stateFile)
+	require.NoError(t, err)
+	require.Equal(t, &netemState{Dev: "lo", Handle: "1:"}, state)
+	require.NoError(t, n.addPort(20160, 20*time.Millisecond))
+	require.NoError(t, n.Close())
+	require.NoFileExists(t, stateFile)
+	require.Equal(t, []string{
+		"qdisc show dev lo",
+		"qdisc add dev lo root handle 1: prio bands 5",
+		"qdisc add dev lo parent 1:4 handle 40: netem delay 5000us",
+		"qdisc add dev lo parent 1:5 handle 50: netem delay 20000us",
+		"filter add dev lo parent 1: protocol ip prio 1 u32 match ip dport 20160 0xffff flowid 1:5",
+		"qdisc del dev lo root",
+	}, calls)
+
+	qdisc = "qdisc prio 1: root refcnt 2 bands 4"
+	_, err = installNetem(map[proc.ServiceID]time.Duration{proc.ServiceTiKV: time.Millisecond}, "")
+	require.ErrorContains(t, err, "lo already has a qdisc")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/spf13/cobra"
+)
+
+// handleLogs writes the last captured stdout/stderr lines of an instance. An
+// instance that exited keeps the lines of its last run until it is restarted,
+// and one being scaled in until its process quits.
+func (p *Playground) handleLogs(state *controllerState, w io.Writer, req *LogsRequest) error {
+	if req == nil {
+		return fmt.Errorf("missing logs request")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("logs requires an instance name")
+	}
+	if req.Lines < 0 {
+		return fmt.Errorf("lines must not be negative")
+	}
+	// procByName only tracks the running processes, including those being
+	// scaled in; an exited instance is only in the topology.
+	var inst proc.Process
+	if rec := state.procByName[name]; rec != nil {
+		inst = rec.inst
+	}
+	if inst == nil {
+		_ = state.walkProcs(func(_ proc.ServiceID, winst proc.Process) error {
+			if winst != nil && winst.Info().Name() == name {
+				inst = winst
+			}
+			return nil
+		})
+	}
+	if inst == nil || inst.Info().Proc == nil {
+		return fmt.Errorf("no instance found with name %q", name)
+	}
+	for _, line := range inst.Info().Proc.OutputTail(req.Lines) {
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
+
+func newLogs(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+
+	req := LogsRequest{}
+	cmd := &cobra.Command{
+		Use:   "logs <instance>",
+		Short: "Show the stdout/stderr of an instance of a running playground",
+		Long: fmt.Sprintf(`Show the last lines an instance printed to stdout/stderr, e.g. a panic
+or a flag error that did not make it to the log of the component.
+
+The playground keeps the last %d lines of each instance in memory. The whole
+output is in %s in the log dir of the instance, rotated to %s.1 once
+it reaches %d MiB. Use '%s display --verbose' for the component log.`,
+			proc.OutputTailLines, proc.OutputFileName, proc.OutputFileName, proc.OutputMaxSize>>20, arg0),
+		Example: fmt.Sprintf("%s logs --tag my-cluster tidb-0 --lines 50", arg0),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if req.Lines < 0 {
+				return fmt.Errorf("--lines must not be negative")
+			}
+			req.Name = args[0]
+			return sendQueueCommand(cmd.OutOrStdout(), Command{Type: LogsCommandType, Logs: &req}, state)
+		},
+	}
+	cmd.Flags().IntVarP(&req.Lines, "lines", "n", 100, "Number of lines to show, 0 shows all the lines kept in memory")
+	return cmd
+}
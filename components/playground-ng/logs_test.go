@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleLogs(t *testing.T) {
+	osProc := &stubOSProcess{pid: 123, cmd: &exec.Cmd{Process: &os.Process{Pid: 123}}, output: []string{"a", "b", "c"}}
+	inst := &stubProcess{info: &proc.ProcessInfo{Service: proc.ServiceTiDB, Proc: osProc}}
+	state := &controllerState{}
+	state.upsertProcRecord(inst)
+	p := &Playground{}
+
+	var out bytes.Buffer
+	require.NoError(t, p.handleLogs(state, &out, &LogsRequest{Name: " tidb-0 "}))
+	require.Equal(t, "a\nb\nc\n", out.String())
+
+	out.Reset()
+	require.NoError(t, p.handleLogs(state, &out, &LogsRequest{Name: "tidb-0", Lines: 2}))
+	require.Equal(t, "b\nc\n", out.String())
+
+	// An instance being scaled in keeps the output of its last run.
+	state.markProcRemoved(inst, 123)
+	out.Reset()
+	require.NoError(t, p.handleLogs(state, &out, &LogsRequest{Name: "tidb-0", Lines: 1}))
+	require.Equal(t, "c\n", out.String())
+
+	// So does an exited instance, which is only in the topology.
+	state.deleteProcRecord(123, "tidb-0")
+	state.procs = map[proc.ServiceID][]proc.Process{proc.ServiceTiDB: {inst}}
+	out.Reset()
+	require.NoError(t, p.handleLogs(state, &out, &LogsRequest{Name: "tidb-0", Lines: 1}))
+	require.Equal(t, "c\n", out.String())
+
+	require.ErrorContains(t, p.handleLogs(state, &out, &LogsRequest{Name: "tikv-0"}), `no instance found with name "tikv-0"`)
+	require.ErrorContains(t, p.handleLogs(state, &out, &LogsRequest{}), "requires an instance name")
+	require.ErrorContains(t, p.handleLogs(state, &out, &LogsRequest{Name: "tidb-0", Lines: -1}), "must not be negative")
+}
+
+func TestExitReason_PrefersCapturedOutput(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "tidb.log")
+	require.NoError(t, os.WriteFile(logFile, []byte("[INFO] starting\n[ERROR] cannot bind\n"), 0o644))
+
+	osProc := &stubOSProcess{output: []string{"panic: runtime error", "goroutine 1 [running]:", ""}}
+	inst := &stubProcess{info: &proc.ProcessInfo{Proc: osProc}, logFile: logFile}
+	require.Equal(t, "panic: runtime error", exitReason(inst))
+
+	// Without an error in the output, the log file explains the exit.
+	osProc.output = []string{"starting"}
+	require.Equal(t, "[ERROR] cannot bind", exitReason(inst))
+
+	inst.logFile = ""
+	require.Equal(t, "starting", exitReason(inst))
+}
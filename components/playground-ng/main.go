@@ -15,6 +15,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	stdErrors "errors"
 	"fmt"
 	_ "net/http/pprof"
@@ -108,13 +109,21 @@ func attachUIOutput(ui *progressv2.UI) (restore func()) {
 	}
 }
 
-func printInterrupt(ui *progressv2.UI, sig syscall.Signal) {
+func printInterrupt(ui *progressv2.UI, sig syscall.Signal, canceledStart bool) {
 	if ui == nil {
 		return
 	}
 
 	msg := fmt.Sprintf("Playground receive signal: %s", sig)
 	colorstr.Fprintf(ui.Writer(), "[red][bold]%s[reset]\n", msg)
+	if sig != syscall.SIGINT {
+		return
+	}
+	hint := "Stopping instances gracefully, press Ctrl+C again to force kill"
+	if canceledStart {
+		hint = "Canceling start and stopping the instances already started, press Ctrl+C again to force kill"
+	}
+	colorstr.Fprintf(ui.Writer(), "[dim]%s[reset]\n", hint)
 }
 
 func startPlaygroundSignalHandler(p *Playground, cancelBoot context.CancelCauseFunc, booted, sigReceived *uint32) {
@@ -205,6 +214,29 @@ Start and manage a TiDB cluster locally for development.
 			}
 
 			isRoot := cmd.Parent() == nil
+			state.tiupHome = tiupHome
+			// Apply the profile or --like first: they may set the tag or the
+			// daemon mode.
+			if isRoot && state.like != "" {
+				if state.profile != "" {
+					return fmt.Errorf("--like and --profile cannot be used together")
+				}
+				inv, err := loadStartInvocation(tiupHome, state.like)
+				if err != nil {
+					return err
+				}
+				if err := applyStartInvocation(cmd.Flags(), inv); err != nil {
+					return fmt.Errorf("--like %s: %w", state.like, err)
+				}
+				state.likeInvocation = inv
+				state.presetVersion = inv.Version
+			} else if isRoot {
+				v, err := applyProfile(cmd.Flags(), playgroundProfilesPath(tiupHome), state.profile)
+				if err != nil {
+					return err
+				}
+				state.presetVersion = v
+			}
 			tagExplicit := false
 			if f := cmd.Flags().Lookup("tag"); f != nil {
 				tagExplicit = f.Changed
@@ -267,23 +299,35 @@ Start and manage a TiDB cluster locally for development.
 
 			if len(args) > 0 {
 				state.options.Version = args[0]
+			} else if state.presetVersion != "" {
+				state.options.Version = state.presetVersion
 			} else if state.options.ShOpt.Mode == proc.ModeNextGen {
 				state.options.Version = fmt.Sprintf("%s-%s", utils.LatestVersionAlias, utils.NextgenVersionAlias)
 			}
 
+			if state.likeInvocation != nil {
+				if err := writeInvocationConfigFiles(cmd.Flags(), state.dataDir, state.likeInvocation); err != nil {
+					return err
+				}
+			}
 			if err := populateDefaultOpt(cmd.Flags(), &state.options); err != nil {
 				return err
 			}
 
-			if state.dryRun {
-				if err := normalizeBootOptionPaths(&state.options); err != nil {
-					return err
-				}
-				if err := ValidateBootOptionsPure(&state.options); err != nil {
-					return err
-				}
+			// Reject incoherent options before claiming the data dir or
+			// downloading anything.
+			if err := normalizeBootOptionPaths(&state.options); err != nil {
+				return err
+			}
+			if err := ValidateBootOptionsPure(&state.options); err != nil {
+				return err
+			}
+			if err := validateInterruptedOpAction(state.interruptedOp); err != nil {
+				return err
+			}
 
-				env, err := environment.InitEnv(repository.Options{}, repository.MirrorOptions{})
+			if state.dryRun {
+				env, err := environment.InitEnv(repository.Options{}, repository.MirrorOptions{Proxy: state.proxy})
 				if err != nil {
 					return err
 				}
@@ -300,15 +344,25 @@ Start and manage a TiDB cluster locally for development.
 				return writeDryRun(tuiv2output.Stdout.Get(), plan, state.dryRunOutput)
 			}
 
-			port := utils.MustGetFreePort("127.0.0.1", 9527, state.options.ShOpt.PortOffset)
-			releasePID, err := claimPlaygroundPIDFile(state.dataDir, state.tag)
+			releasePID, err := state.ops.claimPlaygroundPIDFile(state.dataDir, state.tag)
 			if err != nil {
 				return err
 			}
 			defer releasePID()
 
-			p := NewPlayground(state.dataDir, port)
+			resumeScaleOut, err := resolveInterruptedOperation(state.dataDir, state.interruptedOp)
+			if err != nil {
+				return err
+			}
+
+			// The command server port is picked in the start turn, see
+			// waitStartTurn.
+			p := NewPlayground(state.dataDir, 0)
+			p.ops = state.ops
 			p.destroyDataAfterExit = state.destroyDataAfterExit
+			if p.invocation, err = newStartInvocation(cmd.Flags(), state.options.Version); err != nil {
+				return err
+			}
 
 			var eventLog *os.File
 			if state.runAsDaemon {
@@ -322,16 +376,23 @@ Start and manage a TiDB cluster locally for development.
 			}
 
 			ui := progressv2.New(progressv2.Options{
-				Mode:     progressv2.ModeAuto,
-				Out:      os.Stderr,
-				EventLog: eventLog,
+				Mode:                     progressv2.ModeAuto,
+				Out:                      os.Stderr,
+				EventLog:                 eventLog,
+				CheckpointInterval:       playgroundTUICheckpointInterval,
+				EventLogProgressInterval: playgroundTUIProgressInterval,
+				EventLogSync:             progressv2.EventLogSyncOnStateChange,
+				ActiveTaskLimit:          playgroundActiveTaskLimit,
+				Observer:                 p.dashboardEvents.observe,
 			})
 			defer ui.Close()
 			p.ui = ui
 			p.downloadGroup = ui.Group("Download components")
 			p.downloadGroup.SetHideDetailsOnSuccess(true)
 			p.downloadGroup.SetSortTasksByTitle(true)
+			p.downloadGroup.SetShowDownloadTotals(true)
 			p.startingGroup = ui.Group("Start instances")
+			p.startingGroup.SetShowProgressCount(true)
 			downloadGroup := p.downloadGroup
 			restore := attachUIOutput(ui)
 			defer restore()
@@ -353,6 +414,7 @@ Start and manage a TiDB cluster locally for development.
 			env, err := environment.InitEnv(repository.Options{}, repository.MirrorOptions{
 				Context:  ctx,
 				Progress: downloadProgress,
+				Proxy:    state.proxy,
 			})
 			if err != nil {
 				return err
@@ -385,9 +447,13 @@ Start and manage a TiDB cluster locally for development.
 					} else {
 						fmt.Fprintln(out)
 					}
+					content := []string{fmt.Sprintf("Start cluster failed: %v", bootErr)}
+					for _, hint := range errorHints(bootErr) {
+						content = append(content, "hint: "+hint)
+					}
 					fmt.Fprint(out, tuiv2output.Callout{
 						Style:   tuiv2output.CalloutFailed,
-						Content: fmt.Sprintf("Start cluster failed: %v", bootErr),
+						Content: strings.Join(content, "\n"),
 					}.Render(out))
 
 					bootErr = renderedError{err: fmt.Errorf("Start cluster failed: %w", bootErr)}
@@ -401,6 +467,9 @@ Start and manage a TiDB cluster locally for development.
 			}
 
 			atomic.StoreUint32(&booted, 1)
+			if resumeScaleOut != nil {
+				p.resumeScaleOut(ctx, resumeScaleOut)
+			}
 
 			waitErr := p.wait()
 			if waitErr != nil {
@@ -471,18 +540,35 @@ Start and manage a TiDB cluster locally for development.
 	rootCmd.Flags().StringVar(&state.options.ShOpt.CSE.SecretKey, "cse.secret_key", "minioadmin",
 		fmt.Sprintf("Object store secret key for --mode=%s, --mode=%s, --mode=%s", proc.ModeCSE, proc.ModeDisAgg, proc.ModeNextGen))
 	rootCmd.Flags().BoolVar(&state.options.ShOpt.HighPerf, "perf", false, "Tune default config for better performance instead of debug troubleshooting")
+	rootCmd.Flags().BoolVar(&state.options.ShOpt.TestFriendly, "test-friendly", false, "Tune TiKV for ephemeral test clusters: deleted data is compacted sooner, so the space of dropped tables comes back quickly")
+	rootCmd.Flags().DurationVar(&state.options.ShOpt.GCLifeTime, "gc-ttl", 0, fmt.Sprintf("Set the GC life time of TiDB on each start (at least %s), e.g. 24h to keep the history for stale reads and FLASHBACK", proc.MinGCLifeTime))
+	rootCmd.Flags().StringSliceVar(&state.options.InjectLatency, "inject-latency", nil, "Delay the requests to a service, e.g. tikv=20ms or pd=5ms, to approximate a cluster spread over zones (tc/netem as root on Linux, else a TCP proxy)")
+	rootCmd.Flags().BoolVar(&state.options.ShOpt.TLS, "tls", false, "Enable TLS between the components, with a CA and certificates generated in the data dir")
 	rootCmd.Flags().BoolVar(&state.options.ShOpt.EnableTiKVColumnar, "kv.columnar", false,
 		fmt.Sprintf("Enable TiKV columnar storage engine, only available when --mode=%s", proc.ModeCSE))
 	rootCmd.Flags().BoolVar(&state.options.ShOpt.ForcePull, "force-pull", false, "Force redownload the component. It is useful to manually refresh nightly or broken binaries")
 	rootCmd.Flags().BoolVar(&state.dryRun, "dry-run", false, "Only generate the boot plan and exit")
 	rootCmd.Flags().StringVar(&state.dryRunOutput, "dry-run-output", "text", "Dry-run output format: text|json")
+	rootCmd.Flags().StringVar(&state.profile, "profile", "", fmt.Sprintf("Start with the flags of a named profile in $TIUP_HOME/%s/profiles.yaml; flags given on the command line override it", playgroundComponentName))
+	rootCmd.Flags().StringVar(&state.like, "like", "", "Start with the recorded invocation of another playground: its tag, or a file saved from show-config; flags given on the command line override it")
+	rootCmd.Flags().StringVar(&state.interruptedOp, "interrupted-op", "", "Roll a scale-out interrupted by killing the previous playground of this tag forward or back: forward|rollback")
 	rootCmd.Flags().BoolVarP(&state.background, "background", "d", false, "Start playground-ng in background (daemon mode)")
 	rootCmd.Flags().BoolVar(&state.runAsDaemon, "run-as-daemon", false, "INTERNAL: run as daemon")
 	_ = rootCmd.Flags().MarkHidden("run-as-daemon")
 
 	rootCmd.PersistentFlags().StringVarP(&state.tag, "tag", "T", "", "Specify a tag for playground, data dir of this tag will not be removed after exit")
+	rootCmd.PersistentFlags().StringVar(&state.proxy, "proxy", "", "Proxy URL (http://, https:// or socks5://) for component downloads. Defaults to HTTP_PROXY/HTTPS_PROXY/ALL_PROXY; loopback addresses are never proxied")
 	rootCmd.Flags().Bool("without-monitor", false, "Don't start prometheus and grafana component")
 	rootCmd.Flags().IntVar(&state.options.GrafanaPort, "grafana.port", 3000, "grafana port. If not provided, grafana will use 3000 as its port.")
+	rootCmd.Flags().DurationVar(&state.options.SnapshotEvery, "snapshot-every", 0, "Take a snapshot of the instance dirs at this interval (e.g. 30m), see the snapshot command")
+	rootCmd.Flags().IntVar(&state.options.SnapshotKeep, "snapshot-keep", 5, "Number of automatic snapshots to keep, 0 keeps all of them")
+	rootCmd.Flags().DurationVar(&state.options.StartQueueTimeout, "start-queue-timeout", defaultStartQueueTimeout, "How long to wait for the other playgrounds starting on this machine, which start one at a time; 0 fails at once if one is starting")
+	rootCmd.Flags().StringVar(&state.options.ReadOnlyToken, "read-only-token", "", "Require a token to use the command server, this one only allowing display, logs and command-status; the CLI of this user reads the control token from the data dir")
+	rootCmd.Flags().StringSliceVar(&state.options.CORSOrigins, "cors-origin", nil, "Allow browser pages of this origin (e.g. http://localhost:3000) to use the command server, which listed origins can fully control (stop, scale-out of any binary): only list pages you trust. * allows any website, to the read-only commands only")
+	rootCmd.Flags().StringVar(&state.options.InitSQL, "init-sql", "", "Run the SQL statements of this file on TiDB once it is ready, on the first start of the playground")
+	rootCmd.Flags().StringSliceVar(&state.options.TiFlashReplicas, "tiflash.replica", nil, "Set a TiFlash replica on these databases or db.table once TiDB is ready, after --init-sql, on the first start of the playground")
+	rootCmd.Flags().StringVar(&state.options.ShOpt.RootPassword, "root-password", "", "Set the password of the TiDB root user once TiDB is ready, on the first start of the playground")
+	rootCmd.Flags().BoolVar(&state.options.DBLoadBalancer, "db.lb", false, "Start a TCP round-robin load balancer in front of the TiDB instances, for applications that only accept a single endpoint")
 	rootCmd.Flags().IntVar(&state.options.ShOpt.PortOffset, "port-offset", 0, "If specified, all components will use default_port+port_offset as the port. This argument is useful when you want to start multiple playgrounds on the same host. Recommend to set to 10000, 20000, etc.")
 
 	// NOTE: Do not set default values if they may be changed in different modes.
@@ -495,8 +581,29 @@ Start and manage a TiDB cluster locally for development.
 	rootCmd.AddCommand(newScaleOut(state))
 	rootCmd.AddCommand(newScaleIn(state))
 	rootCmd.AddCommand(newStop(state))
+	rootCmd.AddCommand(newWait(state))
+	rootCmd.AddCommand(newEnv(state))
+	rootCmd.AddCommand(newRetag(state))
+	rootCmd.AddCommand(newClone(state))
+	rootCmd.AddCommand(newSnapshot(state))
+	rootCmd.AddCommand(newLogs(state))
+	rootCmd.AddCommand(newTop(state))
+	rootCmd.AddCommand(newPDCtl(state))
+	rootCmd.AddCommand(newCurl(state))
+	rootCmd.AddCommand(newOpen(state))
+	rootCmd.AddCommand(newTiFlashReplica(state))
+	rootCmd.AddCommand(newPlacement(state))
+	rootCmd.AddCommand(newBackup(state))
+	rootCmd.AddCommand(newRestore(state))
+	rootCmd.AddCommand(newShowConfig(state))
+	rootCmd.AddCommand(newListComponents(state))
+	registerVersionCompletions(rootCmd)
+	rootCmd.AddCommand(newCancel(state))
+	rootCmd.AddCommand(newCommandStatus(state))
 	rootCmd.AddCommand(newStopAll(state))
 	rootCmd.AddCommand(newPS(state))
+	rootCmd.AddCommand(newDoctor(state))
+	rootCmd.AddCommand(newDebug())
 
 	return rootCmd.Execute()
 }
@@ -549,16 +656,6 @@ func dumpPort(fname string, port int) error {
 	return utils.WriteFile(fname, []byte(strconv.Itoa(port)), 0o644)
 }
 
-func loadPort(dir string) (port int, err error) {
-	data, err := os.ReadFile(filepath.Join(dir, "port"))
-	if err != nil {
-		return 0, err
-	}
-
-	port, err = strconv.Atoi(strings.TrimSpace(string(data)))
-	return
-}
-
 func shouldIgnoreSubcommandInstanceDataDir(instanceDir, dataParentDir string) bool {
 	instanceDir = strings.TrimSpace(instanceDir)
 	dataParentDir = strings.TrimSpace(dataParentDir)
@@ -641,7 +738,7 @@ func dumpDSN(fname string, dbs []*proc.TiDBInstance, tdbs []*proc.TiProxyInstanc
 	_ = utils.WriteFile(fname, []byte(strings.Join(dsn, "\n")), 0o644)
 }
 
-func newEtcdClient(endpoint string) (*clientv3.Client, error) {
+func newEtcdClient(endpoint string, tlsCfg *tls.Config) (*clientv3.Client, error) {
 	// Because etcd client does not support setting logger directly,
 	// the configuration of pingcap/log is copied here.
 	zapCfg := zap.NewProductionConfig()
@@ -652,6 +749,7 @@ func newEtcdClient(endpoint string) (*clientv3.Client, error) {
 		Endpoints:   []string{endpoint},
 		DialTimeout: 5 * time.Second,
 		LogConfig:   &zapCfg,
+		TLS:         tlsCfg,
 	})
 	if err != nil {
 		return nil, err
@@ -744,6 +842,9 @@ func (p *repoDownloadProgress) SetExpectedDownloads(downloads []DownloadPlan) {
 		title := proc.ComponentDisplayName(proc.RepoComponentID(componentID))
 		t := p.group.TaskPending(title)
 		t.SetMeta(resolved)
+		if d.Size > 0 {
+			t.SetTotal(d.Size)
+		}
 		t.SetKindDownload()
 		expected[key] = t
 	}
@@ -908,6 +1009,35 @@ func (p *repoDownloadProgress) Error(rawURL string, attempt, maxAttempts int, er
 	t.Error(err.Error())
 }
 
+// UnpackTask starts the sub-task extracting the tarball of rawURL, of the
+// given compressed size, under its download task.
+func (p *repoDownloadProgress) UnpackTask(rawURL string, size int64) *progressv2.Task {
+	t := p.taskForURL(rawURL)
+	if t == nil {
+		return nil
+	}
+	sub := t.Child("Unpack")
+	sub.SetKindTransfer("")
+	if size > 0 {
+		sub.SetTotal(size)
+	}
+	return sub
+}
+
+// CancelRemaining cancels the expected downloads that did not finish, e.g.
+// when the boot is interrupted before they started.
+func (p *repoDownloadProgress) CancelRemaining() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	expected := p.expected
+	p.mu.Unlock()
+	for _, t := range expected {
+		t.Cancel("")
+	}
+}
+
 func (p *repoDownloadProgress) taskForURL(rawURL string) *progressv2.Task {
 	if p == nil {
 		return nil
@@ -1040,11 +1170,22 @@ func main() {
 		if !stdErrors.As(err, &rendered) {
 			out := tuiv2output.Stderr.Get()
 			colorstr.Fprintf(out, "[red][bold]Error:[reset] %v\n", err)
+			for _, hint := range errorHints(err) {
+				colorstr.Fprintf(out, "  [dim]hint:[reset] %s\n", hint)
+			}
 		}
 		code = 1
 	}
-	if state != nil && state.destroyDataAfterExit && state.tiupDataDir == "" && state.dataDir != "" {
-		_ = os.RemoveAll(state.dataDir)
+	if state != nil && state.dataDir != "" {
+		// A retagged playground keeps its data under the new tag.
+		removeRetagLink(state.dataDir)
+	}
+	if state != nil && state.destroyDataAfterExit && state.dataDir != "" {
+		// Instance dirs outside the data dir are not removed by TiUP either.
+		removeExternalInstanceDirs(state.dataDir)
+		if state.tiupDataDir == "" {
+			_ = os.RemoveAll(state.dataDir)
+		}
 	}
 
 	if code != 0 {
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/pingcap/tiup/pkg/localdata"
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
 	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
 	"github.com/stretchr/testify/require"
 )
@@ -78,7 +79,7 @@ func TestLoadPort_TrimsWhitespace(t *testing.T) {
 	base := t.TempDir()
 	require.NoError(t, os.WriteFile(filepath.Join(base, "port"), []byte(" 12345 \n"), 0o644))
 
-	port, err := loadPort(base)
+	port, err := client.ReadPort(base)
 	require.NoError(t, err)
 	require.Equal(t, 12345, port)
 }
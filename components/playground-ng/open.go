@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+)
+
+// openTargets are the services `open` knows, in the order of its help.
+var openTargets = []string{"dashboard", "grafana", "prometheus"}
+
+// canOpenBrowser reports whether a browser can be opened: a desktop session
+// on macOS and Windows, and on the other systems a display and xdg-open. Tests
+// replace it.
+var canOpenBrowser = func() bool {
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return os.Getenv("SSH_CONNECTION") == ""
+	}
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return false
+	}
+	_, err := exec.LookPath("xdg-open")
+	return err == nil
+}
+
+// openBrowser opens url in the default browser. Tests replace it.
+var openBrowser = func(url string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", url)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		c = exec.Command("xdg-open", url)
+	}
+	return errors.AddStack(c.Run())
+}
+
+func newOpen(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+	var printOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "open <" + strings.Join(openTargets, "|") + ">",
+		Short: "Open a monitoring page of a running playground in the browser",
+		Long: `Open the TiDB Dashboard, Grafana or Prometheus of a running playground in
+the default browser. Without a browser (e.g. over SSH), or with --print, the
+URL is printed instead.`,
+		Example:   fmt.Sprintf("%[1]s open dashboard --tag my-cluster\n%[1]s open grafana --print", arg0),
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: openTargets,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+			if err != nil {
+				printDisplayFailureWarning(out, err)
+				return renderedError{err: err}
+			}
+			ready, err := loadReadyFile(target)
+			if err != nil {
+				return err
+			}
+			return openService(out, ready, args[0], printOnly)
+		},
+	}
+	cmd.Flags().BoolVarP(&printOnly, "print", "p", false, "Print the URL instead of opening it")
+	return cmd
+}
+
+// openService opens the URL of the service name recorded in ready, or prints
+// it when no browser can be opened.
+func openService(out io.Writer, ready *playgroundReady, name string, printOnly bool) error {
+	var url string
+	switch name {
+	case "dashboard":
+		url = ready.Dashboard
+	case "grafana":
+		url = ready.Grafana
+	case "prometheus":
+		url = ready.Prometheus
+	default:
+		return fmt.Errorf("unknown service %q, expected one of %s", name, strings.Join(openTargets, ", "))
+	}
+	switch {
+	case url == "" && name == "dashboard":
+		return fmt.Errorf("the playground has no TiDB Dashboard, which needs PD and TiDB")
+	case url == "":
+		return fmt.Errorf("the playground has no %s, is it started with --without-monitor?", name)
+	}
+	if printOnly || !canOpenBrowser() {
+		fmt.Fprintln(out, url)
+		return nil
+	}
+	if err := openBrowser(url); err != nil {
+		fmt.Fprintln(out, url)
+		return errors.Annotate(err, "open the browser")
+	}
+	fmt.Fprintf(out, "Opened %s\n", url)
+	return nil
+}
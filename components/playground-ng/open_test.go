@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenService(t *testing.T) {
+	oldCan, oldOpen := canOpenBrowser, openBrowser
+	t.Cleanup(func() { canOpenBrowser, openBrowser = oldCan, oldOpen })
+	var opened []string
+	openBrowser = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	ready := &playgroundReady{
+		Dashboard:  "http://127.0.0.1:2379/dashboard",
+		Grafana:    "http://127.0.0.1:3000",
+		Prometheus: "http://127.0.0.1:9090",
+	}
+
+	// No browser: the URL is printed.
+	canOpenBrowser = func() bool { return false }
+	var out bytes.Buffer
+	require.NoError(t, openService(&out, ready, "grafana", false))
+	require.Equal(t, "http://127.0.0.1:3000\n", out.String())
+	require.Empty(t, opened)
+
+	canOpenBrowser = func() bool { return true }
+	out.Reset()
+	require.NoError(t, openService(&out, ready, "prometheus", false))
+	require.Equal(t, []string{"http://127.0.0.1:9090"}, opened)
+	require.Equal(t, "Opened http://127.0.0.1:9090\n", out.String())
+
+	out.Reset()
+	require.NoError(t, openService(&out, ready, "dashboard", true))
+	require.Equal(t, "http://127.0.0.1:2379/dashboard\n", out.String())
+	require.Len(t, opened, 1, "--print does not open")
+
+	require.ErrorContains(t, openService(&out, &playgroundReady{}, "grafana", false), "--without-monitor")
+	require.ErrorContains(t, openService(&out, &playgroundReady{}, "dashboard", false), "needs PD and TiDB")
+	require.ErrorContains(t, openService(&out, ready, "pd", false), `unknown service "pd"`)
+}
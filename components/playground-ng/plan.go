@@ -9,6 +9,7 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/docker/go-units"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/components/playground-ng/proc"
 	pgservice "github.com/pingcap/tiup/components/playground-ng/service"
@@ -61,6 +62,9 @@ type BootPlan struct {
 	Monitor     bool
 	GrafanaPort int
 
+	// TiDBLBPort is the port of the TiDB load balancer, 0 if it is disabled.
+	TiDBLBPort int
+
 	// RequiredServices is the minimum running instance count for critical
 	// services. Controller uses it to trigger auto shutdown when critical
 	// services exit unexpectedly.
@@ -175,8 +179,7 @@ func renderDryRunText(out io.Writer, plan BootPlan) string {
 		out = io.Discard
 	}
 
-	tokens := colorstr.DefaultTokens
-	tokens.Disable = !tuiterm.Resolve(out).Color
+	tokens := colorstr.TokensFor(tuiterm.Resolve(out))
 
 	downloaded := make(map[string]struct{}, len(plan.Downloads))
 	for _, d := range plan.Downloads {
@@ -221,12 +224,24 @@ func renderDryRunText(out io.Writer, plan BootPlan) string {
 		if b.Len() > 0 {
 			b.WriteString("\n")
 		}
-		tokens.Fprintf(&b, "[light_magenta]==> [reset][bold]Download Packages:[reset]\n")
+		var totalSize int64
+		for _, d := range plan.Downloads {
+			totalSize += d.Size
+		}
+		if totalSize > 0 {
+			tokens.Fprintf(&b, "[light_magenta]==> [reset][bold]Download Packages:[reset] [dim](%s)[reset]\n", units.BytesSize(float64(totalSize)))
+		} else {
+			tokens.Fprintf(&b, "[light_magenta]==> [reset][bold]Download Packages:[reset]\n")
+		}
 		for _, d := range plan.Downloads {
 			if d.ComponentID == "" || d.ResolvedVersion == "" {
 				continue
 			}
-			tokens.Fprintf(&b, "  [green]+[reset] %s[dim]@%s[reset]\n", d.ComponentID, d.ResolvedVersion)
+			tokens.Fprintf(&b, "  [green]+[reset] %s[dim]@%s[reset]", d.ComponentID, d.ResolvedVersion)
+			if d.Size > 0 {
+				tokens.Fprintf(&b, " [dim](%s)[reset]", units.BytesSize(float64(d.Size)))
+			}
+			b.WriteString("\n")
 		}
 	}
 
@@ -251,6 +266,9 @@ func renderDryRunText(out io.Writer, plan BootPlan) string {
 			}
 			if ver := s.ResolvedVersion; ver != "" {
 				tokens.Fprintf(&b, "%s[dim]@%s[reset]", s.Name, ver)
+				if c := s.DebugConstraint; c != "" && c != ver && s.BinPath == "" {
+					tokens.Fprintf(&b, " [dim](%s)[reset]", c)
+				}
 			} else {
 				tokens.Fprintf(&b, "%s", s.Name)
 			}
@@ -267,6 +285,12 @@ func renderDryRunText(out io.Writer, plan BootPlan) string {
 				}
 				tokens.Fprintf(&b, "    [dim]%s[reset]\n", addr)
 			}
+			if dir := strings.TrimSpace(s.Shared.Dir); dir != "" {
+				tokens.Fprintf(&b, "    [dim]Data: %s[reset]\n", dir)
+			}
+			if dir := strings.TrimSpace(s.Shared.LogDir); dir != "" {
+				tokens.Fprintf(&b, "    [dim]Log: %s[reset]\n", dir)
+			}
 
 			if len(s.StartAfterServices) > 0 {
 				tokens.Fprintf(&b, "    [dim]Start after: %s[reset]\n", strings.Join(s.StartAfterServices, ","))
@@ -282,6 +306,11 @@ type DownloadPlan struct {
 	ComponentID     string
 	ResolvedVersion string
 
+	// Size is the package size in bytes as declared by the repository manifest.
+	// It is informational (dry-run output and progress totals) and is 0 when
+	// unknown.
+	Size int64 `json:",omitempty"`
+
 	DebugConstraint string
 	DebugReason     string
 	DebugSourceURL  string
@@ -415,6 +444,31 @@ func resolveVersionConstraint(serviceID proc.ServiceID, options *BootOptions) (s
 	return constraint, nil
 }
 
+// instanceDirs returns the data and log directories of an instance.
+//
+// By default the instance lives in dataDir/name and writes logs there. The
+// per-service data/log dir overrides are roots shared by playgrounds, so the
+// instance dir under them is namespaced by the playground data dir name
+// (i.e. the tag): <override>/<tag>/<name>.
+func instanceDirs(dataDir string, cfg proc.Config, name string) (dir, logDir string) {
+	under := func(root string) string {
+		if dataDir == "" {
+			return filepath.Join(root, name)
+		}
+		return filepath.Join(root, filepath.Base(dataDir), name)
+	}
+
+	if cfg.DataDir != "" {
+		dir = under(cfg.DataDir)
+	} else if dataDir != "" {
+		dir = filepath.Join(dataDir, name)
+	}
+	if cfg.LogDir != "" {
+		logDir = under(cfg.LogDir)
+	}
+	return dir, logDir
+}
+
 // BuildBootPlan builds a deterministic BootPlan from BootOptions and the
 // current local environment state (via ComponentSource).
 func BuildBootPlan(options *BootOptions, cfg bootPlannerConfig) (BootPlan, error) {
@@ -513,10 +567,7 @@ func buildBootPlanWithProcs(options *BootOptions, cfg bootPlannerConfig, ordered
 
 		for i := 0; i < svcCfg.Num; i++ {
 			name := fmt.Sprintf("%s-%d", serviceID, i)
-			dir := ""
-			if cfg.dataDir != "" {
-				dir = filepath.Join(cfg.dataDir, name)
-			}
+			dir, logDir := instanceDirs(cfg.dataDir, svcCfg, name)
 
 			sp := ServicePlan{
 				Name:               name,
@@ -525,7 +576,7 @@ func buildBootPlanWithProcs(options *BootOptions, cfg bootPlannerConfig, ordered
 				BinPath:            svcCfg.BinPath,
 				DebugConstraint:    constraint,
 				ResolvedVersion:    constraint, // overwritten when resolved from repo
-				Shared:             ServiceSharedPlan{Dir: dir, Host: host, ConfigPath: svcCfg.ConfigPath, UpTimeout: svcCfg.UpTimeout},
+				Shared:             ServiceSharedPlan{Dir: dir, LogDir: logDir, Host: host, ConfigPath: svcCfg.ConfigPath, UpTimeout: svcCfg.UpTimeout},
 			}
 
 			if spec.PlanInstance == nil {
@@ -601,6 +652,15 @@ func buildBootPlanWithProcs(options *BootOptions, cfg bootPlannerConfig, ordered
 		}
 	}
 
+	tidbLBPort := 0
+	if options.DBLoadBalancer {
+		port, err := allocPort(options.Host, tidbBalancerBasePort)
+		if err != nil {
+			return BootPlan{}, err
+		}
+		tidbLBPort = port
+	}
+
 	// Finalize downloads list: stable order, de-duped by component@resolved.
 	downloads := make([]DownloadPlan, 0, len(downloadCache))
 	for _, dp := range downloadCache {
@@ -628,6 +688,7 @@ func buildBootPlanWithProcs(options *BootOptions, cfg bootPlannerConfig, ordered
 		Shared:              options.ShOpt,
 		Monitor:             options.Monitor,
 		GrafanaPort:         options.GrafanaPort,
+		TiDBLBPort:          tidbLBPort,
 		RequiredServices:    required,
 		Downloads:           downloads,
 		Services:            servicePlans,
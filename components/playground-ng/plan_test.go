@@ -737,7 +737,7 @@ func TestWriteDryRun_Text(t *testing.T) {
 		Monitor:     true,
 		GrafanaPort: 3000,
 		Downloads: []DownloadPlan{
-			{ComponentID: "tidb", ResolvedVersion: "v1.0.0", DebugReason: "missing_binary", DebugBinPath: "/home/tidb-server"},
+			{ComponentID: "tidb", ResolvedVersion: "v1.0.0", Size: 12 * 1024 * 1024, DebugReason: "missing_binary", DebugBinPath: "/home/tidb-server"},
 		},
 		Services: []ServicePlan{
 			{
@@ -764,15 +764,17 @@ func TestWriteDryRun_Text(t *testing.T) {
 	require.Equal(t, `==> Existing Packages:
     pd@v1.0.0
 
-==> Download Packages:
-  + tidb@v1.0.0
+==> Download Packages: (12MiB)
+  + tidb@v1.0.0 (12MiB)
 
 ==> Start Services:
   + pd-0@v1.0.0
     127.0.0.1:2380,2379
+    Data: /data/pd-0
     Start after: tikv
   + tidb-0@v1.0.0 (use /usr/local/bin/tidb-server)
     127.0.0.1:4000,10080
+    Data: /data/tidb-0
 `, buf.String())
 }
 
@@ -826,10 +828,12 @@ func TestWriteDryRun_JSON(t *testing.T) {
     "Mode": "tidb",
     "PortOffset": 0,
     "EnableTiKVColumnar": false,
-    "ForcePull": false
+    "ForcePull": false,
+    "TLS": false
   },
   "Monitor": false,
   "GrafanaPort": 0,
+  "TiDBLBPort": 0,
   "Downloads": null,
   "Services": null,
   "RequiredServices": null,
@@ -867,10 +871,12 @@ func TestWriteDryRun_JSON_RedactsSecrets(t *testing.T) {
     "Mode": "tidb-cse",
     "PortOffset": 0,
     "EnableTiKVColumnar": false,
-    "ForcePull": false
+    "ForcePull": false,
+    "TLS": false
   },
   "Monitor": false,
   "GrafanaPort": 0,
+  "TiDBLBPort": 0,
   "Downloads": null,
   "Services": null,
   "RequiredServices": null,
@@ -909,10 +915,12 @@ func TestWriteDryRun_JSON_OmitsNilOneOfFields(t *testing.T) {
     "Mode": "",
     "PortOffset": 0,
     "EnableTiKVColumnar": false,
-    "ForcePull": false
+    "ForcePull": false,
+    "TLS": false
   },
   "Monitor": false,
   "GrafanaPort": 0,
+  "TiDBLBPort": 0,
   "Downloads": null,
   "Services": [
     {
@@ -969,10 +977,12 @@ func TestWriteDryRun_JSON_MapOrderIsStable(t *testing.T) {
     "Mode": "",
     "PortOffset": 0,
     "EnableTiKVColumnar": false,
-    "ForcePull": false
+    "ForcePull": false,
+    "TLS": false
   },
   "Monitor": false,
   "GrafanaPort": 0,
+  "TiDBLBPort": 0,
   "Downloads": null,
   "Services": null,
   "RequiredServices": {
@@ -1173,3 +1183,35 @@ func baseBinaryNameForComponent(component string) string {
 		return component
 	}
 }
+
+func TestBuildBootPlan_ServiceDataAndLogDirOverrides(t *testing.T) {
+	opts := &BootOptions{
+		ShOpt: proc.SharedOptions{
+			Mode:   proc.ModeNormal,
+			PDMode: "pd",
+		},
+		Version: "v8.0.0",
+		Host:    "127.0.0.1",
+	}
+	fastDir := t.TempDir()
+	logDir := t.TempDir()
+	applyServiceDefaultsForTest(t, opts, "--kv.data-dir="+fastDir, "--kv.log-dir="+logDir)
+
+	dataDir := filepath.Join(t.TempDir(), "my-tag")
+	plan, err := BuildBootPlan(opts, bootPlannerConfig{
+		dataDir:            dataDir,
+		portConflictPolicy: PortConflictNone,
+		advertiseHost:      func(listen string) string { return listen },
+		componentSource:    newTestComponentSource(t, nil),
+	})
+	require.NoError(t, err)
+
+	byName := make(map[string]ServicePlan)
+	for _, sp := range plan.Services {
+		byName[sp.Name] = sp
+	}
+	require.Equal(t, filepath.Join(fastDir, "my-tag", "tikv-0"), byName["tikv-0"].Shared.Dir)
+	require.Equal(t, filepath.Join(logDir, "my-tag", "tikv-0"), byName["tikv-0"].Shared.LogDir)
+	require.Equal(t, filepath.Join(dataDir, "pd-0"), byName["pd-0"].Shared.Dir)
+	require.Empty(t, byName["pd-0"].Shared.LogDir)
+}
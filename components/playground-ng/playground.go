@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"sync"
 
 	"github.com/pingcap/tiup/components/playground-ng/proc"
@@ -34,12 +35,40 @@ type Playground struct {
 	bootBaseConfigs      map[proc.ServiceID]proc.Config
 	port                 int
 
+	// ops are the clock and process operations the controller times the
+	// shutdown with and spawns and signals the instances through.
+	ops runtimeOps
+
+	// invocation is recorded in the data dir once boot resolved the component
+	// versions.
+	invocation *startInvocation
+
+	// ready is written to the ready file once the command server listens. It
+	// is set by boot before the command server starts.
+	ready *playgroundReady
+
+	// commandListener is bound to the port of the command server by boot,
+	// before the cluster info lists the dashboard it serves (see
+	// listenCommandServer).
+	commandListener net.Listener
+
+	// tidbLB is the TiDB load balancer (--db.lb). It is set by boot before any
+	// instance is added, and its backends follow the TiDB instances.
+	tidbLB *tidbBalancer
+
+	// latency injects the --inject-latency delays. It is set by boot before
+	// any instance is added.
+	latency *latencyInjector
+
 	// shutdownProcRecords snapshots controller-owned proc records at the moment
 	// shutdown starts. It lets termination logic work after the controller loop
 	// is canceled (no more events/commands).
 	shutdownProcRecords []procRecordSnapshot
 
 	bootCancel context.CancelCauseFunc
+	// startTurn is held from planning until the command server listens, so
+	// the playgrounds starting on the machine take turns (see waitStartTurn).
+	startTurn *startSlot
 
 	shutdownOnce  sync.Once
 	stoppingCh    chan struct{}
@@ -72,10 +101,20 @@ type Playground struct {
 
 	terminateDoneCh   chan struct{}
 	terminateDoneOnce sync.Once
+	// stopFeed reports the termination progress to the clients of the "stop"
+	// command.
+	stopFeed *feed[string]
+	// dashboardEvents feeds the events of the browser dashboard (see /ui).
+	dashboardEvents *dashboardEvents
+	// usage holds the latest CPU/memory samples of the instances.
+	usage *usageSamples
+
+	// crashOnce runs the cleanup of the first panic, see crash.
+	crashOnce sync.Once
 
 	controllerOnce   sync.Once
 	controllerCancel context.CancelFunc
-	cmdReqCh         chan commandRequest
+	commands         *commandQueue
 	evtCh            chan controllerEvent
 	controllerDoneCh chan struct{}
 }
@@ -85,9 +124,13 @@ func NewPlayground(dataDir string, port int) *Playground {
 	return &Playground{
 		dataDir:         dataDir,
 		port:            port,
+		ops:             newRuntimeOps(),
 		stoppingCh:      make(chan struct{}),
 		interruptedCh:   make(chan struct{}),
 		terminateDoneCh: make(chan struct{}),
+		stopFeed:        newFeed[string](0),
+		dashboardEvents: newDashboardEvents(),
+		usage:           newUsageSamples(),
 		processGroup:    NewProcessGroup(),
 	}
 }
@@ -130,23 +173,43 @@ var errProcessGroupClosed = fmt.Errorf("process group closed")
 // Unlike errgroup.Group, Wait() is safe to call early because it blocks until
 // Close() is called. This avoids the classic "wg.Add concurrent with wg.Wait"
 // bug while still allowing dynamic additions during the running phase.
+//
+// Named members can be restarted, replaced or removed while they run. The
+// group does not stop a member itself: the caller makes its current run
+// return (e.g. by killing the process it waits for), and the group then runs
+// it again (Restart), runs the replacement (Replace), or forgets it (Remove).
+// The runs of a member never overlap, and a run ended this way is an expected
+// exit: its error is not reported by Wait.
 type ProcessGroup struct {
 	mu       sync.Mutex
 	closed   bool
 	wg       sync.WaitGroup
 	firstErr error
+	// members are the named members, by name.
+	members map[string]*processGroupMember
 
 	closedCh chan struct{}
 }
 
+// processGroupMember is a goroutine of a ProcessGroup, guarded by its mu.
+type processGroupMember struct {
+	name string
+	wait func() error
+	// next is the wait func of the next run, set by Restart and Replace.
+	next    func() error
+	removed bool
+}
+
 // NewProcessGroup creates a new ProcessGroup.
 func NewProcessGroup() *ProcessGroup {
 	return &ProcessGroup{
+		members:  make(map[string]*processGroupMember),
 		closedCh: make(chan struct{}),
 	}
 }
 
-// Add adds a new goroutine to the group.
+// Add adds a new goroutine to the group. A non-empty name must not be used by
+// another member.
 func (g *ProcessGroup) Add(name string, wait func() error) error {
 	if g == nil {
 		return errProcessGroupClosed
@@ -160,23 +223,122 @@ func (g *ProcessGroup) Add(name string, wait func() error) error {
 		g.mu.Unlock()
 		return errProcessGroupClosed
 	}
+	if _, ok := g.members[name]; ok && name != "" {
+		g.mu.Unlock()
+		return fmt.Errorf("process group member %q already exists", name)
+	}
+	m := &processGroupMember{name: name, wait: wait}
+	if name != "" {
+		if g.members == nil {
+			g.members = make(map[string]*processGroupMember)
+		}
+		g.members[name] = m
+	}
 	g.wg.Add(1)
 	g.mu.Unlock()
 
-	go func() {
-		defer g.wg.Done()
-		if err := wait(); err != nil {
-			g.mu.Lock()
-			if g.firstErr == nil {
-				if name != "" {
-					g.firstErr = fmt.Errorf("%s: %w", name, err)
-				} else {
-					g.firstErr = err
-				}
+	go g.run(m)
+	return nil
+}
+
+// run runs m until a run returns without a restart or replacement pending,
+// or the group is closed.
+func (g *ProcessGroup) run(m *processGroupMember) {
+	defer g.wg.Done()
+	wait := m.wait
+	for {
+		err := wait()
+
+		g.mu.Lock()
+		next := m.next
+		m.next = nil
+		expected := m.removed || next != nil
+		if err != nil && !expected && g.firstErr == nil {
+			if m.name != "" {
+				g.firstErr = fmt.Errorf("%s: %w", m.name, err)
+			} else {
+				g.firstErr = err
+			}
+		}
+		if next == nil || m.removed || g.closed {
+			if !m.removed && m.name != "" {
+				delete(g.members, m.name)
 			}
 			g.mu.Unlock()
+			return
 		}
-	}()
+		m.wait = next
+		wait = next
+		g.mu.Unlock()
+	}
+}
+
+// member returns the member name, with g.mu held.
+func (g *ProcessGroup) member(name string) (*processGroupMember, error) {
+	if g.closed {
+		return nil, errProcessGroupClosed
+	}
+	m, ok := g.members[name]
+	if !ok || name == "" {
+		return nil, fmt.Errorf("process group member %q not found", name)
+	}
+	return m, nil
+}
+
+// Restart runs the member name again once its current run returns. Requests
+// made before then are coalesced into one run, with the wait func of the last
+// Replace, if any. Nothing is restarted once the group is closed.
+func (g *ProcessGroup) Restart(name string) error {
+	if g == nil {
+		return errProcessGroupClosed
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	m, err := g.member(name)
+	if err != nil {
+		return err
+	}
+	if m.next == nil {
+		m.next = m.wait
+	}
+	return nil
+}
+
+// Replace runs wait as the member name once its current run returns, and on
+// its later restarts.
+func (g *ProcessGroup) Replace(name string, wait func() error) error {
+	if g == nil {
+		return errProcessGroupClosed
+	}
+	if wait == nil {
+		return fmt.Errorf("wait func is nil")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	m, err := g.member(name)
+	if err != nil {
+		return err
+	}
+	m.next = wait
+	return nil
+}
+
+// Remove detaches the member name: its current run is the last one, and its
+// error is ignored. Wait still waits for it to return, but its name is free
+// for Add right away.
+func (g *ProcessGroup) Remove(name string) error {
+	if g == nil {
+		return errProcessGroupClosed
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	m, ok := g.members[name]
+	if !ok || name == "" {
+		return fmt.Errorf("process group member %q not found", name)
+	}
+	m.removed = true
+	m.next = nil
+	delete(g.members, name)
 	return nil
 }
 
@@ -323,6 +485,7 @@ func (p *Playground) OnProcsChanged() {
 	p.progressMu.Unlock()
 
 	logIfErr(p.renderSDFile())
+	logIfErr(writeInstanceRegistry(p.dataDir, p.WalkProcs))
 }
 
 var _ pgservice.Runtime = controllerRuntime{}
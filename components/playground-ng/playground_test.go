@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -111,3 +112,160 @@ func TestProcessGroupWait_ReturnsFirstError(t *testing.T) {
 		require.FailNow(t, "Wait did not return")
 	}
 }
+
+func TestProcessGroupAdd_DuplicateNameRejected(t *testing.T) {
+	g := NewProcessGroup()
+	blockCh := make(chan struct{})
+	defer close(blockCh)
+	require.NoError(t, g.Add("x", func() error { <-blockCh; return nil }))
+	require.ErrorContains(t, g.Add("x", func() error { return nil }), `"x" already exists`)
+	// Anonymous members are never in conflict.
+	require.NoError(t, g.Add("", func() error { return nil }))
+	require.NoError(t, g.Add("", func() error { return nil }))
+}
+
+func TestProcessGroupRestart_RerunsAfterReturn(t *testing.T) {
+	g := NewProcessGroup()
+
+	runs := make(chan int, 3)
+	stop := make(chan struct{})
+	n := 0
+	require.NoError(t, g.Add("x", func() error {
+		n++
+		runs <- n
+		<-stop
+		return errors.New("killed")
+	}))
+	require.Equal(t, 1, <-runs)
+
+	require.NoError(t, g.Restart("x"))
+	require.NoError(t, g.Restart("x")) // coalesced with the first one
+	stop <- struct{}{}
+	require.Equal(t, 2, <-runs)
+
+	stop <- struct{}{}
+	doneCh := make(chan error, 1)
+	go func() { doneCh <- g.Wait() }()
+	g.Close()
+	select {
+	case err := <-doneCh:
+		// Only the last run ended on its own: the restarted one was expected.
+		require.EqualError(t, err, "x: killed")
+	case <-time.After(time.Second):
+		require.FailNow(t, "Wait did not return")
+	}
+	require.Empty(t, runs)
+}
+
+func TestProcessGroupReplace_RunsAfterCurrentRun(t *testing.T) {
+	g := NewProcessGroup()
+
+	var running atomic.Int32
+	var overlapped atomic.Bool
+	stop := make(chan struct{})
+	started := make(chan string, 2)
+	member := func(id string) func() error {
+		return func() error {
+			if running.Add(1) != 1 {
+				overlapped.Store(true)
+			}
+			started <- id
+			<-stop
+			running.Add(-1)
+			return nil
+		}
+	}
+	require.NoError(t, g.Add("x", member("old")))
+	require.Equal(t, "old", <-started)
+
+	require.NoError(t, g.Replace("x", member("new")))
+	require.Error(t, g.Replace("x", nil))
+	stop <- struct{}{}
+	require.Equal(t, "new", <-started)
+
+	// Restart reruns the replacement.
+	require.NoError(t, g.Restart("x"))
+	stop <- struct{}{}
+	require.Equal(t, "new", <-started)
+
+	g.Close()
+	close(stop)
+	require.NoError(t, g.Wait())
+	require.False(t, overlapped.Load(), "runs overlap")
+}
+
+func TestProcessGroupRemove_IgnoresErrorAndFreesName(t *testing.T) {
+	g := NewProcessGroup()
+
+	stop := make(chan struct{})
+	require.NoError(t, g.Add("x", func() error {
+		<-stop
+		return errors.New("killed")
+	}))
+	require.NoError(t, g.Remove("x"))
+	require.ErrorContains(t, g.Restart("x"), "not found")
+	require.ErrorContains(t, g.Remove("x"), "not found")
+	require.NoError(t, g.Add("x", func() error { return nil }))
+
+	close(stop)
+	g.Close()
+	require.NoError(t, g.Wait())
+}
+
+func TestProcessGroupRestart_NotAfterClose(t *testing.T) {
+	g := NewProcessGroup()
+
+	runs := 0
+	stop := make(chan struct{})
+	require.NoError(t, g.Add("x", func() error {
+		runs++
+		<-stop
+		return nil
+	}))
+	require.ErrorContains(t, g.Restart("y"), "not found")
+	require.NoError(t, g.Restart("x"))
+	g.Close()
+	require.ErrorIs(t, g.Restart("x"), errProcessGroupClosed)
+
+	close(stop)
+	require.NoError(t, g.Wait())
+	require.Equal(t, 1, runs)
+}
+
+func TestFeed(t *testing.T) {
+	f := newFeed[string](0)
+	lines, to, done, changed := f.next(0)
+	require.Empty(t, lines)
+	require.Equal(t, 0, to)
+	require.False(t, done)
+
+	f.publish("Stopped TiDB (pid=1)\n")
+	<-changed
+	lines, to, done, changed = f.next(0)
+	require.Equal(t, []string{"Stopped TiDB (pid=1)\n"}, lines)
+	require.Equal(t, 1, to)
+	require.False(t, done)
+
+	f.close()
+	<-changed
+	f.publish("late\n")
+	lines, _, done, _ = f.next(to)
+	require.Empty(t, lines)
+	require.True(t, done)
+}
+
+func TestFeed_Limit(t *testing.T) {
+	f := newFeed[int](2)
+	for i := 0; i < 5; i++ {
+		f.publish(i)
+	}
+	items, to, _, _ := f.next(0)
+	require.Equal(t, []int{3, 4}, items)
+	require.Equal(t, 5, to)
+	items, to, _, _ = f.next(4)
+	require.Equal(t, []int{4}, items)
+	require.Equal(t, 5, to)
+	items, to, _, _ = f.next(9)
+	require.Empty(t, items)
+	require.Equal(t, 5, to)
+}
@@ -17,7 +17,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -192,12 +191,12 @@ func (p *Pump) Prepare(ctx context.Context) error {
 
 // LogFile return the log file.
 func (p *Pump) LogFile() string {
-	return filepath.Join(p.Dir, "pump.log")
+	return p.LogPath("pump.log")
 }
 
 // LogFile return the log file name.
 func (d *Drainer) LogFile() string {
-	return filepath.Join(d.Dir, "drainer.log")
+	return d.LogPath("drainer.log")
 }
 
 // Addr return the address of Drainer.
@@ -122,7 +122,7 @@ func (c *TiCDC) Prepare(ctx context.Context) error {
 
 // LogFile return the log file.
 func (c *TiCDC) LogFile() string {
-	return filepath.Join(c.Dir, "ticdc.log")
+	return c.LogPath("ticdc.log")
 }
 
 // Prepare builds the TiKV-CDC process command.
@@ -154,5 +154,5 @@ func (c *TiKVCDCInstance) Prepare(ctx context.Context) error {
 
 // LogFile return the log file.
 func (c *TiKVCDCInstance) LogFile() string {
-	return filepath.Join(c.Dir, "tikv_cdc.log")
+	return c.LogPath("tikv_cdc.log")
 }
@@ -3,7 +3,6 @@ package proc
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -139,7 +138,7 @@ func (m *DMMaster) Prepare(ctx context.Context) error {
 
 // LogFile return the log file path of the instance.
 func (m *DMMaster) LogFile() string {
-	return filepath.Join(m.Dir, "dm-master.log")
+	return m.LogPath("dm-master.log")
 }
 
 // Addr return the address of the instance.
@@ -239,5 +238,5 @@ func (w *DMWorker) Prepare(ctx context.Context) error {
 
 // LogFile return the log file of the instance.
 func (w *DMWorker) LogFile() string {
-	return filepath.Join(w.Dir, "dm-worker.log")
+	return w.LogPath("dm-worker.log")
 }
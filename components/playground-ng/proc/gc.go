@@ -0,0 +1,87 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proc
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
+// MinGCLifeTime is the shortest GC life time TiDB accepts.
+const MinGCLifeTime = 10 * time.Minute
+
+// GCLifeTimeVariable is the TiDB system variable holding the GC life time,
+// which has no config file setting.
+const GCLifeTimeVariable = "tidb_gc_life_time"
+
+// gcTuningConfig returns the config keys --test-friendly sets on the
+// instances of service, as defaults their config file can override: TiKV
+// checks regions for deleted data more often and compacts them sooner, so
+// the space of dropped tables and of GCed versions comes back quickly.
+func (o SharedOptions) gcTuningConfig(service ServiceID) map[string]any {
+	if !o.TestFriendly || service != ServiceTiKV {
+		return nil
+	}
+	return map[string]any{
+		"raftstore.region-compact-check-interval":     "1m",
+		"raftstore.region-compact-min-tombstones":     1000,
+		"raftstore.region-compact-tombstones-percent": 10,
+	}
+}
+
+// GCTuning returns the config values the tuning options give the instances
+// of service, as they run with them: the values set in the user config at
+// userConfigPath win. Nil if the options tune nothing there.
+func (o SharedOptions) GCTuning(service ServiceID, userConfigPath string) (map[string]any, error) {
+	tuning := o.gcTuningConfig(service)
+	if len(tuning) == 0 {
+		return nil, nil
+	}
+	userConfig, err := unmarshalConfig(userConfigPath)
+	if err != nil {
+		return nil, errors.Annotatef(err, "read %s", userConfigPath)
+	}
+	for key := range tuning {
+		if v, ok := lookupConfigKey(userConfig, key); ok {
+			tuning[key] = v
+		}
+	}
+	return tuning, nil
+}
+
+// lookupConfigKey returns the value of the dotted key in config, whether it
+// is written nested (a table per section) or dotted.
+func lookupConfigKey(config map[string]any, key string) (any, bool) {
+	if v, ok := config[key]; ok {
+		return v, true
+	}
+	section, rest, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil, false
+	}
+	sub, ok := config[section].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return lookupConfigKey(sub, rest)
+}
+
+// mergeTuning adds the keys of tuning to config.
+func mergeTuning(config, tuning map[string]any) {
+	for k, v := range tuning {
+		config[k] = v
+	}
+}
@@ -0,0 +1,34 @@
+package proc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCTuningConfig(t *testing.T) {
+	kv := &TiKVInstance{ShOpt: SharedOptions{TestFriendly: true}, ProcessInfo: ProcessInfo{Service: ServiceTiKV}}
+	config := kv.getConfig()
+	require.Equal(t, "1m", config["raftstore.region-compact-check-interval"])
+	require.Equal(t, 256, config["rocksdb.max-open-files"], "the defaults are kept")
+
+	kv.ShOpt.TestFriendly = false
+	require.NotContains(t, kv.getConfig(), "raftstore.region-compact-check-interval")
+
+	// The effective values take the user config, nested or dotted, over the
+	// tuning.
+	shOpt := SharedOptions{TestFriendly: true}
+	cfgPath := filepath.Join(t.TempDir(), "tikv.toml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte("\"raftstore.region-compact-min-tombstones\" = 50\n[raftstore]\nregion-compact-check-interval = \"30s\"\n"), 0o644))
+	tuning, err := shOpt.GCTuning(ServiceTiKV, cfgPath)
+	require.NoError(t, err)
+	require.Equal(t, "30s", tuning["raftstore.region-compact-check-interval"])
+	require.EqualValues(t, 50, tuning["raftstore.region-compact-min-tombstones"])
+	require.Equal(t, 10, tuning["raftstore.region-compact-tombstones-percent"])
+
+	tuning, err = shOpt.GCTuning(ServiceTiDB, "")
+	require.NoError(t, err)
+	require.Nil(t, tuning)
+}
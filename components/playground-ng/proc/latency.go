@@ -0,0 +1,43 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proc
+
+// LatencyServices are the services whose requests --inject-latency can
+// delay.
+var LatencyServices = []ServiceID{ServicePD, ServiceTiKV}
+
+// LatencyClientPort returns the port the clients of the instance connect to,
+// whose requests --inject-latency delays: the client port of PD and the
+// port of TiKV. It is 0 for the other services.
+func LatencyClientPort(info *ProcessInfo) int {
+	if info == nil {
+		return 0
+	}
+	switch info.Service {
+	case ServicePD:
+		return info.StatusPort
+	case ServiceTiKV:
+		return info.Port
+	}
+	return 0
+}
+
+// listenPort returns the port the instance binds port to: its LatencyPort
+// when a latency proxy listens on port instead.
+func (info *ProcessInfo) listenPort(port int) int {
+	if info.LatencyPort != 0 && port == LatencyClientPort(info) {
+		return info.LatencyPort
+	}
+	return port
+}
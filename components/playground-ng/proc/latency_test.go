@@ -0,0 +1,31 @@
+package proc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyListenPort(t *testing.T) {
+	inst := &PDInstance{
+		ProcessInfo: ProcessInfo{
+			Dir:         t.TempDir(),
+			Host:        "127.0.0.1",
+			Port:        2380,
+			StatusPort:  2379,
+			LatencyPort: 31000,
+			Service:     ServicePD,
+		},
+		Plan: PDPlan{InitialCluster: []PDMemberPlan{{Name: "pd-0", PeerAddr: "127.0.0.1:2380"}}},
+	}
+	args, err := inst.prepareNormalArgs("pd.toml", "pd-0")
+	require.NoError(t, err)
+	// PD listens behind the latency proxy and advertises the proxy.
+	require.Contains(t, args, "--client-urls=http://127.0.0.1:31000")
+	require.Contains(t, args, "--advertise-client-urls=http://127.0.0.1:2379")
+	require.Contains(t, args, "--peer-urls=http://127.0.0.1:2380")
+
+	require.Equal(t, 2379, LatencyClientPort(&inst.ProcessInfo))
+	require.Equal(t, 20160, LatencyClientPort(&ProcessInfo{Service: ServiceTiKV, Port: 20160}))
+	require.Zero(t, LatencyClientPort(&ProcessInfo{Service: ServiceTiDB, Port: 4000}))
+}
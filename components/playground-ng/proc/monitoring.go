@@ -34,8 +34,8 @@ const (
 func init() {
 	RegisterComponentDisplayName(ComponentPrometheus, "Prometheus")
 	RegisterServiceDisplayName(ServicePrometheus, "Prometheus")
-	registerPlannedProcessFactory(ServicePrometheus, func(_ ServicePlan, info ProcessInfo, _ SharedOptions, _ string) (Process, error) {
-		return &PrometheusInstance{ProcessInfo: info}, nil
+	registerPlannedProcessFactory(ServicePrometheus, func(_ ServicePlan, info ProcessInfo, shOpt SharedOptions, _ string) (Process, error) {
+		return &PrometheusInstance{ShOpt: shOpt, ProcessInfo: info}, nil
 	})
 
 	RegisterComponentDisplayName(ComponentGrafana, "Grafana")
@@ -50,7 +50,7 @@ func init() {
 
 	RegisterComponentDisplayName(ComponentNGMonitoring, "NG Monitoring")
 	RegisterServiceDisplayName(ServiceNGMonitoring, "NG Monitoring")
-	registerPlannedProcessFactory(ServiceNGMonitoring, func(plan ServicePlan, info ProcessInfo, _ SharedOptions, _ string) (Process, error) {
+	registerPlannedProcessFactory(ServiceNGMonitoring, func(plan ServicePlan, info ProcessInfo, shOpt SharedOptions, _ string) (Process, error) {
 		if plan.NGMonitoring == nil {
 			name := info.Name()
 			if name == "" {
@@ -58,22 +58,24 @@ func init() {
 			}
 			return nil, errors.Errorf("missing ng-monitoring plan for %s", name)
 		}
-		return &NGMonitoringInstance{Plan: *plan.NGMonitoring, ProcessInfo: info}, nil
+		return &NGMonitoringInstance{ShOpt: shOpt, Plan: *plan.NGMonitoring, ProcessInfo: info}, nil
 	})
 }
 
 // PrometheusInstance represents a running Prometheus server.
 type PrometheusInstance struct {
 	ProcessInfo
+	ShOpt SharedOptions
 
 	sdFile string
 }
 
 var _ Process = &PrometheusInstance{}
 
-// LogFile returns the log file path for the instance.
+// LogFile returns the log file path for the instance. Prometheus only logs to
+// stderr, so it is the captured output.
 func (inst *PrometheusInstance) LogFile() string {
-	return filepath.Join(inst.Dir, "prom.log")
+	return inst.LogPath(OutputFileName)
 }
 
 // RenderSDFile writes Prometheus file_sd targets for all instances.
@@ -115,6 +117,9 @@ func (inst *PrometheusInstance) RenderSDFile(sid2targets map[ServiceID]MetricAdd
 			Targets: targets,
 			Labels:  map[string]string{"job": id.String()},
 		}
+		if inst.ShOpt.TLS && tlsServedServices[id] {
+			it.Labels["__scheme__"] = "https"
+		}
 		for k, v := range t.Labels {
 			it.Labels[k] = v
 		}
@@ -173,8 +178,15 @@ scrape_configs:
     file_sd_configs:
     - files:
       - targets.json
-
 `
+	if inst.ShOpt.TLS && inst.ShOpt.TLSDir != "" {
+		// The targets served over TLS are labeled https in the targets file.
+		tmpl += fmt.Sprintf(`    tls_config:
+      ca_file: %s
+      cert_file: %s
+      key_file: %s
+`, TLSCertPath(inst.ShOpt.TLSDir, TLSCAName), TLSCertPath(inst.ShOpt.TLSDir, TLSClientName), TLSKeyPath(inst.ShOpt.TLSDir, TLSClientName))
+	}
 
 	configPath := filepath.Join(inst.Dir, "prometheus.yml")
 	if err := utils.WriteFile(configPath, []byte(tmpl), 0644); err != nil {
@@ -207,9 +219,10 @@ type GrafanaInstance struct {
 
 var _ Process = &GrafanaInstance{}
 
-// LogFile returns the log file path for the instance.
+// LogFile returns the log file path for the instance: the captured output,
+// Grafana's own log goes to the "log" dir.
 func (inst *GrafanaInstance) LogFile() string {
-	return filepath.Join(inst.Dir, "grafana.log")
+	return inst.LogPath(OutputFileName)
 }
 
 const grafanaClusterName = "Test-Cluster"
@@ -441,15 +454,17 @@ type NGMonitoringPlan struct {
 // NGMonitoringInstance represents a running ng-monitoring-server.
 type NGMonitoringInstance struct {
 	ProcessInfo
+	ShOpt SharedOptions
 
 	Plan NGMonitoringPlan
 }
 
 var _ Process = &NGMonitoringInstance{}
 
-// LogFile returns the log file path for the instance.
+// LogFile returns the log file path for the instance: the captured output,
+// NG Monitoring's own log goes to the "logs" dir.
 func (inst *NGMonitoringInstance) LogFile() string {
-	return filepath.Join(inst.Dir, "ng-monitoring.log")
+	return inst.LogPath(OutputFileName)
 }
 
 // Prepare builds the NG Monitoring process command.
@@ -488,6 +503,16 @@ func (inst *NGMonitoringInstance) Prepare(ctx context.Context) error {
 		fmt.Sprintf("--storage.path=%s", filepath.Join(inst.Dir, "data")),
 		fmt.Sprintf("--log.path=%s", filepath.Join(inst.Dir, "logs")),
 	}
+	if inst.ShOpt.TLS {
+		// The security of ng-monitoring is only read from its config file.
+		config := make(map[string]any)
+		inst.ShOpt.tlsConfig(config, TLSClientName, "security.ca-path", "security.cert-path", "security.key-path")
+		configPath := filepath.Join(inst.Dir, "ngmonitoring.toml")
+		if err := prepareConfig(configPath, "", config, nil); err != nil {
+			return err
+		}
+		args = append(args, fmt.Sprintf("--config=%s", configPath))
+	}
 
 	info.Proc = &cmdProcess{cmd: PrepareCommand(ctx, binPath, args, nil, inst.Dir)}
 	return nil
@@ -0,0 +1,145 @@
+package proc
+
+import (
+	"bytes"
+	"os"
+	"sync"
+
+	"github.com/pingcap/errors"
+)
+
+// OutputFileName is the file in the log dir of an instance that captures its
+// stdout/stderr. Once it reaches OutputMaxSize it is rotated to
+// OutputFileName+".1".
+const OutputFileName = "stdout.log"
+
+const (
+	// OutputMaxSize is the size at which OutputFileName is rotated.
+	OutputMaxSize = 64 << 20
+	// OutputTailLines bounds the lines kept in memory for the "logs" command.
+	OutputTailLines = 1000
+	// outputMaxLineLen splits the lines longer than that, so a process never
+	// printing a newline does not grow the buffer forever.
+	outputMaxLineLen = 64 << 10
+)
+
+// outputLog is the stdout/stderr of a process: it appends to a file rotated
+// once it exceeds maxSize and keeps the last lines in memory.
+//
+// Write never fails. The process gets a pipe to it (exec copies the pipe into
+// the writer), and a failing write would break the pipe and kill the process
+// on its next print; a file error only stops the file capture.
+type outputLog struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	f       *os.File
+	size    int64
+
+	lines   []string // ring of the last complete lines
+	start   int      // index of the oldest line once the ring is full
+	limit   int
+	partial []byte
+}
+
+func openOutputLog(path string, maxSize int64, limit int) (*outputLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.AddStack(err)
+	}
+	return &outputLog{path: path, maxSize: maxSize, f: f, size: st.Size(), limit: limit}, nil
+}
+
+func (l *outputLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.writeFile(p)
+	data := p
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			l.partial = append(l.partial, data...)
+			for len(l.partial) >= outputMaxLineLen {
+				l.pushLine(l.partial[:outputMaxLineLen])
+				l.partial = l.partial[outputMaxLineLen:]
+			}
+			break
+		}
+		l.partial = append(l.partial, data[:i]...)
+		l.pushLine(bytes.TrimSuffix(l.partial, []byte("\r")))
+		l.partial = l.partial[:0]
+		data = data[i+1:]
+	}
+	return len(p), nil
+}
+
+func (l *outputLog) writeFile(p []byte) {
+	if l.f == nil {
+		return
+	}
+	if l.size > 0 && l.size+int64(len(p)) > l.maxSize {
+		_ = l.f.Close()
+		l.f = nil
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return
+		}
+		f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+		if err != nil {
+			return
+		}
+		l.f, l.size = f, 0
+	}
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	if err != nil {
+		_ = l.f.Close()
+		l.f = nil
+	}
+}
+
+func (l *outputLog) pushLine(b []byte) {
+	line := string(b)
+	if len(l.lines) < l.limit {
+		l.lines = append(l.lines, line)
+		return
+	}
+	l.lines[l.start] = line
+	l.start = (l.start + 1) % l.limit
+}
+
+// tail returns the last n lines, or all the kept ones if n <= 0. An
+// unterminated last line is included.
+func (l *outputLog) tail(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := make([]string, 0, len(l.lines)+1)
+	out = append(out, l.lines[l.start:]...)
+	out = append(out, l.lines[:l.start]...)
+	if len(l.partial) > 0 {
+		out = append(out, string(l.partial))
+	}
+	if n > 0 && len(out) > n {
+		out = out[len(out)-n:]
+	}
+	return out
+}
+
+// Close closes the file. The kept lines stay readable.
+func (l *outputLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
@@ -0,0 +1,63 @@
+package proc
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputLog_RingAndPartialLine(t *testing.T) {
+	l, err := openOutputLog(filepath.Join(t.TempDir(), OutputFileName), OutputMaxSize, 3)
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, _ = l.Write([]byte("a\nb\r\nc\n"))
+	_, _ = l.Write([]byte("d\npart"))
+	require.Equal(t, []string{"b", "c", "d", "part"}, l.tail(0))
+	require.Equal(t, []string{"d", "part"}, l.tail(2))
+
+	_, _ = l.Write([]byte("ial\n"))
+	require.Equal(t, []string{"c", "d", "partial"}, l.tail(0))
+
+	_, _ = l.Write([]byte(strings.Repeat("x", outputMaxLineLen+1)))
+	tail := l.tail(2)
+	require.Len(t, tail[0], outputMaxLineLen)
+	require.Equal(t, "x", tail[1])
+}
+
+func TestOutputLog_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), OutputFileName)
+	require.NoError(t, os.WriteFile(path, []byte("previous run\n"), 0o644))
+
+	l, err := openOutputLog(path, 20, 10)
+	require.NoError(t, err)
+	_, _ = l.Write([]byte("0123456789\n"))
+	require.NoError(t, l.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789\n", string(data))
+	data, err = os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	require.Equal(t, "previous run\n", string(data))
+	// The lines of earlier runs are only in the files.
+	require.Equal(t, []string{"0123456789"}, l.tail(0))
+}
+
+func TestCmdProcess_CapturesOutput(t *testing.T) {
+	dir := t.TempDir()
+	p := &cmdProcess{cmd: PrepareCommand(context.Background(), "/bin/sh", []string{"-c", "echo out; echo err >&2"}, nil, dir)}
+	require.NoError(t, p.SetOutputFile(filepath.Join(dir, OutputFileName)))
+	require.NoError(t, p.Start((*exec.Cmd).Start))
+	require.NoError(t, p.Wait())
+
+	require.ElementsMatch(t, []string{"out", "err"}, p.OutputTail(0))
+	data, err := os.ReadFile(filepath.Join(dir, OutputFileName))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"out", "err"}, strings.Fields(string(data)))
+}
@@ -148,14 +148,15 @@ func (inst *PDInstance) prepareNormalArgs(configPath, uid string) ([]string, err
 	if inst.Service == ServicePDAPI {
 		args = append(args, "services", "api")
 	}
+	scheme := inst.ShOpt.Scheme()
 	args = append(args,
 		"--name="+uid,
 		fmt.Sprintf("--config=%s", configPath),
 		fmt.Sprintf("--data-dir=%s", filepath.Join(inst.Dir, "data")),
-		fmt.Sprintf("--peer-urls=http://%s", utils.JoinHostPort(inst.Host, inst.Port)),
-		fmt.Sprintf("--advertise-peer-urls=http://%s", utils.JoinHostPort(AdvertiseHost(inst.Host), inst.Port)),
-		fmt.Sprintf("--client-urls=http://%s", utils.JoinHostPort(inst.Host, inst.StatusPort)),
-		fmt.Sprintf("--advertise-client-urls=http://%s", utils.JoinHostPort(AdvertiseHost(inst.Host), inst.StatusPort)),
+		fmt.Sprintf("--peer-urls=%s://%s", scheme, utils.JoinHostPort(inst.Host, inst.Port)),
+		fmt.Sprintf("--advertise-peer-urls=%s://%s", scheme, utils.JoinHostPort(AdvertiseHost(inst.Host), inst.Port)),
+		fmt.Sprintf("--client-urls=%s://%s", scheme, utils.JoinHostPort(inst.Host, inst.listenPort(inst.StatusPort))),
+		fmt.Sprintf("--advertise-client-urls=%s://%s", scheme, utils.JoinHostPort(AdvertiseHost(inst.Host), inst.StatusPort)),
 		fmt.Sprintf("--log-file=%s", inst.LogFile()),
 	)
 
@@ -166,7 +167,7 @@ func (inst *PDInstance) prepareNormalArgs(configPath, uid string) ([]string, err
 			if m.Name == "" || m.PeerAddr == "" {
 				continue
 			}
-			endpoints = append(endpoints, fmt.Sprintf("%s=http://%s", m.Name, m.PeerAddr))
+			endpoints = append(endpoints, fmt.Sprintf("%s=%s://%s", m.Name, scheme, m.PeerAddr))
 		}
 		args = append(args, fmt.Sprintf("--initial-cluster=%s", strings.Join(endpoints, ",")))
 	case len(inst.Plan.JoinAddrs) > 0:
@@ -175,7 +176,7 @@ func (inst *PDInstance) prepareNormalArgs(configPath, uid string) ([]string, err
 			if addr == "" {
 				continue
 			}
-			endpoints = append(endpoints, fmt.Sprintf("http://%s", addr))
+			endpoints = append(endpoints, fmt.Sprintf("%s://%s", scheme, addr))
 		}
 		args = append(args, fmt.Sprintf("--join=%s", strings.Join(endpoints, ",")))
 	default:
@@ -261,7 +262,7 @@ func (inst *PDInstance) LogFile() string {
 	if name == "" {
 		name = "pd"
 	}
-	return filepath.Join(inst.Dir, name+".log")
+	return inst.LogPath(name + ".log")
 }
 
 // Addr return the listen address of PD
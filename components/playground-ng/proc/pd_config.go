@@ -31,6 +31,7 @@ func (inst *PDInstance) getConfig() map[string]any {
 	case ModeNextGen:
 		config["keyspace.pre-alloc"] = []string{"keyspace1"}
 	}
+	inst.ShOpt.tlsConfig(config, "pd", "security.cacert-path", "security.cert-path", "security.key-path")
 
 	return config
 }
@@ -57,6 +57,7 @@ const (
 // ServiceSharedPlan contains common, low-level per-instance inputs.
 type ServiceSharedPlan struct {
 	Dir        string
+	LogDir     string `json:",omitempty"`
 	Host       string
 	Port       int
 	StatusPort int
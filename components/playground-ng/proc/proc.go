@@ -16,11 +16,11 @@ package proc
 import (
 	"context"
 	"fmt"
-	"io"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -28,6 +28,7 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/localdata"
 	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/utils"
 )
@@ -85,6 +86,17 @@ func ComponentDisplayName(componentID RepoComponentID) string {
 	return titleCaseComponentID(componentID.String())
 }
 
+// RepoComponentIDs returns the sorted IDs of the repository components with a
+// registered display name, i.e. every component playground-ng can run.
+func RepoComponentIDs() []RepoComponentID {
+	ids := make([]RepoComponentID, 0, len(componentDisplayNames))
+	for id := range componentDisplayNames {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
 // RegisterServiceDisplayName registers a user-facing name for a service.
 //
 // It is intended to be called from the init() function of each service
@@ -164,6 +176,11 @@ type Config struct {
 	Port       int    `yaml:"port"`
 	UpTimeout  int    `yaml:"up_timeout"`
 	Version    string `yaml:"version"`
+	// DataDir and LogDir move the data and log directories of the instances
+	// out of the playground data dir (e.g. data to a fast disk, logs to
+	// another volume). Empty means the instance directory in the data dir.
+	DataDir string `yaml:"data_dir" json:",omitempty"`
+	LogDir  string `yaml:"log_dir" json:",omitempty"`
 }
 
 // SharedOptions contains some commonly used, tunable options for most components.
@@ -177,6 +194,20 @@ type SharedOptions struct {
 	PortOffset         int        `yaml:"port_offset"`
 	EnableTiKVColumnar bool       `yaml:"enable_tikv_columnar"` // Only available when mode == ModeCSE
 	ForcePull          bool       `yaml:"force_pull"`
+	// TLS enables TLS between all the components, with certificates
+	// generated in TLSDir at boot.
+	TLS bool `yaml:"tls"`
+	// TLSDir is set at boot to the TLS dir in the data dir (see TLSDirName).
+	TLSDir string `yaml:"-" json:",omitempty"`
+	// RootPassword is the password of the TiDB root user, set once TiDB is
+	// ready. It is kept out of the dry-run plan.
+	RootPassword string `yaml:"root_password" json:"-"`
+	// TestFriendly tunes the compaction of TiKV for ephemeral test clusters
+	// (see gcTuningConfig).
+	TestFriendly bool `yaml:"test_friendly" json:",omitempty"`
+	// GCLifeTime is set as the GC life time of TiDB on each start, zero to
+	// keep the one of the cluster.
+	GCLifeTime time.Duration `yaml:"gc_life_time" json:",omitempty"`
 }
 
 // CSEOptions contains configs to run TiDB cluster in CSE mode.
@@ -193,11 +224,16 @@ var (
 
 // OSProcess represents an operating system process started by playground.
 type OSProcess interface {
-	Start() error
+	// Start spawns the process with spawn, e.g. (*exec.Cmd).Start.
+	Start(spawn func(*exec.Cmd) error) error
 	Wait() error
 	Pid() int
 	Uptime() string
+	// SetOutputFile captures stdout/stderr to fname (see OutputFileName).
 	SetOutputFile(fname string) error
+	// OutputTail returns the last n captured lines of stdout/stderr, or all
+	// the ones kept in memory if n <= 0.
+	OutputTail(n int) []string
 	Cmd() *exec.Cmd
 }
 
@@ -210,11 +246,11 @@ type cmdProcess struct {
 	waitOnce sync.Once
 	waitErr  error
 
-	outFile *os.File
+	out *outputLog
 }
 
 // Start the process
-func (p *cmdProcess) Start() error {
+func (p *cmdProcess) Start(spawn func(*exec.Cmd) error) error {
 	if p == nil {
 		return errNotUp
 	}
@@ -222,10 +258,9 @@ func (p *cmdProcess) Start() error {
 	// fmt.Printf("Starting `%s`: %s", filepath.Base(p.cmd.Path), strings.Join(p.cmd.Args, " "))
 	p.startTime = time.Now()
 	p.endTime = time.Time{}
-	if err := p.cmd.Start(); err != nil {
-		if p.outFile != nil {
-			_ = p.outFile.Close()
-			p.outFile = nil
+	if err := spawn(p.cmd); err != nil {
+		if p.out != nil {
+			_ = p.out.Close()
 		}
 		return err
 	}
@@ -241,9 +276,9 @@ func (p *cmdProcess) Wait() error {
 	p.waitOnce.Do(func() {
 		p.waitErr = p.cmd.Wait()
 		p.endTime = time.Now()
-		if p.outFile != nil {
-			_ = p.outFile.Close()
-			p.outFile = nil
+		// Wait returns once the output pipe is drained.
+		if p.out != nil {
+			_ = p.out.Close()
 		}
 	})
 
@@ -288,25 +323,25 @@ func (p *cmdProcess) SetOutputFile(fname string) error {
 		return errors.AddStack(err)
 	}
 
-	f, err := os.OpenFile(fname, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	out, err := openOutputLog(fname, OutputMaxSize, OutputTailLines)
 	if err != nil {
-		return errors.AddStack(err)
+		return err
 	}
-	if p.outFile != nil {
-		_ = p.outFile.Close()
+	if p.out != nil {
+		_ = p.out.Close()
 	}
-	p.outFile = f
-	p.setOutput(f)
+	p.out = out
+	p.cmd.Stdout = out
+	p.cmd.Stderr = out
 	return nil
 }
 
-func (p *cmdProcess) setOutput(w io.Writer) {
-	if p == nil {
-		return
+// OutputTail implements OSProcess.
+func (p *cmdProcess) OutputTail(n int) []string {
+	if p == nil || p.out == nil {
+		return nil
 	}
-
-	p.cmd.Stdout = w
-	p.cmd.Stderr = w
+	return p.out.tail(n)
 }
 
 func (p *cmdProcess) Cmd() *exec.Cmd {
@@ -345,6 +380,9 @@ type ProcessInfo struct {
 	// TiProxy, TiFlash). A value <= 0 means no limit.
 	UpTimeout  int
 	ConfigPath string
+	// LogDir is the directory of the instance log files. If empty, they are
+	// written to Dir.
+	LogDir string
 	// UserBinPath is the binary path provided by the user (if any).
 	//
 	// It is treated as input and must not be overwritten by binary resolution.
@@ -358,11 +396,23 @@ type ProcessInfo struct {
 	Proc            OSProcess
 	RepoComponentID RepoComponentID
 	Service         ServiceID
+	// LatencyPort is the port the instance listens on for its clients when a
+	// latency proxy (--inject-latency) listens on its client port instead, 0
+	// otherwise (see LatencyClientPort).
+	LatencyPort int
 }
 
 // Info returns itself so embedded ProcessInfo can satisfy Process.
 func (info *ProcessInfo) Info() *ProcessInfo { return info }
 
+// LogPath returns the path of the log file name of the instance.
+func (info *ProcessInfo) LogPath(name string) string {
+	if info.LogDir != "" {
+		return filepath.Join(info.LogDir, name)
+	}
+	return filepath.Join(info.Dir, name)
+}
+
 // MetricAddr will be used by prometheus scrape_configs.
 type MetricAddr struct {
 	Targets []string          `json:"targets"`
@@ -396,6 +446,27 @@ type ReadyWaiter interface {
 	WaitReady(ctx context.Context) error
 }
 
+type readyPhaseKey struct{}
+
+// WithReadyPhase returns a context that lets ReadyWaiter implementations report
+// what they are currently waiting for (e.g. "bootstrapping"), so slow phases
+// are visible to users instead of a silent spinner.
+func WithReadyPhase(ctx context.Context, report func(phase string)) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, readyPhaseKey{}, report)
+}
+
+func reportReadyPhase(ctx context.Context, phase string) {
+	if ctx == nil {
+		return
+	}
+	if report, ok := ctx.Value(readyPhaseKey{}).(func(string)); ok && report != nil {
+		report(phase)
+	}
+}
+
 func readyTimeoutError(timeoutSec int) error {
 	return fmt.Errorf("timeout (%ds)", timeoutSec)
 }
@@ -577,6 +648,10 @@ func unmarshalConfig(path string) (map[string]any, error) {
 	if err != nil {
 		return nil, err
 	}
+	data, err = utils.InterpolateEnv(data, utils.AllowedEnv(os.Getenv(localdata.EnvNameInterpolateEnv)))
+	if err != nil {
+		return nil, errors.Annotatef(err, "interpolate %s", path)
+	}
 	c := make(map[string]any)
 	err = toml.Unmarshal(data, &c)
 	if err != nil {
@@ -15,11 +15,16 @@ package proc
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
+	"log"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/utils"
 )
@@ -52,6 +57,10 @@ func init() {
 	for _, serviceID := range []ServiceID{ServiceTiDB, ServiceTiDBSystem} {
 		registerPlannedProcessFactory(serviceID, factory)
 	}
+
+	// The readiness probe expects failed connections while TiDB boots; keep the
+	// driver from printing them over the progress UI.
+	_ = mysql.SetLogger(log.New(io.Discard, "", 0))
 }
 
 // TiDBPlan is the service-specific plan for TiDB.
@@ -98,7 +107,7 @@ func (inst *TiDBInstance) Prepare(ctx context.Context) error {
 		fmt.Sprintf("--host=%s", inst.Host),
 		fmt.Sprintf("--status=%d", inst.StatusPort),
 		fmt.Sprintf("--path=%s", strings.Join(endpoints, ",")),
-		fmt.Sprintf("--log-file=%s", filepath.Join(inst.Dir, "tidb.log")),
+		fmt.Sprintf("--log-file=%s", inst.LogFile()),
 		fmt.Sprintf("--config=%s", configPath),
 	}
 	if inst.Plan.EnableBinlog {
@@ -111,7 +120,7 @@ func (inst *TiDBInstance) Prepare(ctx context.Context) error {
 
 // LogFile return the log file name.
 func (inst *TiDBInstance) LogFile() string {
-	return filepath.Join(inst.Dir, "tidb.log")
+	return inst.LogPath("tidb.log")
 }
 
 // Addr return the listen address of TiDB
@@ -121,7 +130,96 @@ func (inst *TiDBInstance) Addr() string {
 
 // WaitReady implements ReadyWaiter.
 //
-// TiDB is considered ready when its MySQL TCP port is connectable.
+// TiDB is considered ready once its status port is up and it serves SQL with a
+// finished bootstrap. A listening MySQL port alone does not mean client
+// connections will succeed.
 func (inst *TiDBInstance) WaitReady(ctx context.Context) error {
-	return tcpAddrReady(ctx, inst.Addr(), inst.UpTimeout)
+	ctx, cancel := withTimeoutSeconds(ctx, inst.UpTimeout)
+	defer cancel()
+
+	if inst.StatusPort > 0 {
+		statusAddr := utils.JoinHostPort(AdvertiseHost(inst.Host), inst.StatusPort)
+		if err := tcpAddrReady(ctx, statusAddr, 0); err != nil {
+			return inst.readyError(err)
+		}
+	}
+	reportReadyPhase(ctx, "waiting for bootstrap")
+	return inst.readyError(sqlReady(ctx, inst.Addr(), inst.ShOpt.RootPassword))
+}
+
+func (inst *TiDBInstance) readyError(err error) error {
+	if err == context.DeadlineExceeded && inst.UpTimeout > 0 {
+		return readyTimeoutError(inst.UpTimeout)
+	}
+	return err
+}
+
+// tidbBootstrappedQuery reads the flag TiDB writes once the bootstrap of the
+// system tables completes.
+const tidbBootstrappedQuery = "SELECT VARIABLE_VALUE FROM mysql.tidb WHERE VARIABLE_NAME = 'bootstrapped'"
+
+// sqlReady blocks until `SELECT 1` succeeds on addr as root and the cluster is
+// bootstrapped, or ctx is done. With a password, root is also tried without
+// it: the password is only set once the first TiDB is ready.
+func sqlReady(ctx context.Context, addr, password string) error {
+	passwords := []string{""}
+	if password != "" {
+		passwords = []string{password, ""}
+	}
+	dbs := make([]*sql.DB, 0, len(passwords))
+	for _, password := range passwords {
+		db, err := OpenTiDB(addr, password)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		dbs = append(dbs, db)
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		for _, db := range dbs {
+			if sqlBootstrapped(ctx, db) {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// OpenTiDB returns a handle to the TiDB at addr, logged in as root with
+// password.
+func OpenTiDB(addr, password string) (*sql.DB, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = "root"
+	cfg.Passwd = password
+	cfg.Net = "tcp"
+	cfg.Addr = addr
+	cfg.Timeout = time.Second
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	return db, nil
+}
+
+func sqlBootstrapped(ctx context.Context, db *sql.DB) bool {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var one int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return false
+	}
+	var bootstrapped string
+	if err := db.QueryRowContext(ctx, tidbBootstrappedQuery).Scan(&bootstrapped); err != nil {
+		return false
+	}
+	return strings.EqualFold(bootstrapped, "true")
 }
@@ -23,6 +23,13 @@ import (
 func (inst *TiDBInstance) getConfig() (map[string]any, error) {
 	config := make(map[string]any)
 	config["security.auto-tls"] = true
+	if inst.ShOpt.TLS {
+		// MySQL clients verify the TiDB certificate with the CA of the
+		// cluster too, instead of the one auto-tls generates.
+		config["security.auto-tls"] = false
+		inst.ShOpt.tlsConfig(config, "tidb", "security.cluster-ssl-ca", "security.cluster-ssl-cert", "security.cluster-ssl-key")
+		inst.ShOpt.tlsConfig(config, "tidb", "security.ssl-ca", "security.ssl-cert", "security.ssl-key")
+	}
 
 	switch inst.ShOpt.Mode {
 	case ModeCSE:
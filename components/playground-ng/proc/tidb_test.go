@@ -2,6 +2,7 @@ package proc
 
 import (
 	"context"
+	"net"
 	"path/filepath"
 	"testing"
 
@@ -46,3 +47,38 @@ func TestTiDBInstancePrepare_PropagatesEndpointsAndBinlogFlag(t *testing.T) {
 	}
 	require.Equal(t, want, cmd.Args)
 }
+
+func TestTiDBInstanceWaitReady_WaitsForSQLAfterStatusPort(t *testing.T) {
+	listen := func() *net.TCPListener {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = l.Close() })
+		return l.(*net.TCPListener)
+	}
+	status := listen()
+	// The MySQL port accepts connections but never speaks the protocol, like a
+	// TiDB that is still bootstrapping.
+	sqlPort := listen()
+	go func() {
+		for {
+			conn, err := sqlPort.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	inst := &TiDBInstance{ProcessInfo: ProcessInfo{
+		Host:       "127.0.0.1",
+		Port:       sqlPort.Addr().(*net.TCPAddr).Port,
+		StatusPort: status.Addr().(*net.TCPAddr).Port,
+		UpTimeout:  2,
+		Service:    ServiceTiDB,
+	}}
+
+	var phases []string
+	ctx := WithReadyPhase(context.Background(), func(phase string) { phases = append(phases, phase) })
+	require.EqualError(t, inst.WaitReady(ctx), "timeout (2s)")
+	require.Equal(t, []string{"waiting for bootstrap"}, phases)
+}
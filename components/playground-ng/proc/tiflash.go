@@ -22,6 +22,7 @@ import (
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/api"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
 	"github.com/pingcap/tiup/pkg/tidbver"
 	"github.com/pingcap/tiup/pkg/utils"
 )
@@ -93,7 +94,11 @@ func (inst *TiFlashInstance) WaitReady(ctx context.Context) error {
 	defer cancel()
 
 	endpoints := append([]string(nil), inst.Plan.PDAddrs...)
-	pdClient := api.NewPDClient(ctx, endpoints, 10*time.Second, nil)
+	tlsCfg, err := inst.ShOpt.ClientTLSConfig()
+	if err != nil {
+		return err
+	}
+	pdClient := api.NewPDClient(ctx, endpoints, 10*time.Second, tlsCfg)
 
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
@@ -172,7 +177,7 @@ func (inst *TiFlashInstance) Prepare(ctx context.Context) error {
 		{"path", filepath.Join(inst.Dir, "data")},
 		{"listen_host", inst.Host},
 		{"logger.log", inst.LogFile()},
-		{"logger.errorlog", filepath.Join(inst.Dir, "tiflash_error.log")},
+		{"logger.errorlog", inst.LogPath("tiflash_error.log")},
 		{"status.metrics_port", fmt.Sprintf("%d", inst.StatusPort)},
 		{"flash.service_addr", utils.JoinHostPort(AdvertiseHost(inst.Host), inst.Plan.ServicePort)},
 		{"raft.pd_addr", strings.Join(endpoints, ",")},
@@ -180,7 +185,7 @@ func (inst *TiFlashInstance) Prepare(ctx context.Context) error {
 		{"flash.proxy.advertise-addr", utils.JoinHostPort(AdvertiseHost(inst.Host), inst.Plan.ProxyPort)},
 		{"flash.proxy.status-addr", utils.JoinHostPort(inst.Host, inst.Plan.ProxyStatusPort)},
 		{"flash.proxy.data-dir", filepath.Join(inst.Dir, "proxy_data")},
-		{"flash.proxy.log-file", filepath.Join(inst.Dir, "tiflash_tikv.log")},
+		{"flash.proxy.log-file", inst.LogPath("tiflash_tikv.log")},
 	}
 	userConfig, err := unmarshalConfig(configPath)
 	if err != nil {
@@ -188,7 +193,7 @@ func (inst *TiFlashInstance) Prepare(ctx context.Context) error {
 	}
 	for _, arg := range runtimeConfig {
 		// if user has set the config, skip it
-		if !isKeyPresentInMap(userConfig, arg[0]) {
+		if spec.GetValueFromPath(userConfig, arg[0]) == nil {
 			args = append(args, fmt.Sprintf("--%s=%s", arg[0], arg[1]))
 		}
 	}
@@ -197,35 +202,9 @@ func (inst *TiFlashInstance) Prepare(ctx context.Context) error {
 	return nil
 }
 
-func isKeyPresentInMap(m map[string]any, key string) bool {
-	if len(m) == 0 || key == "" {
-		return false
-	}
-
-	keys := strings.Split(key, ".")
-	var current any = m
-	for i, k := range keys {
-		currentMap, ok := current.(map[string]any)
-		if !ok {
-			return false
-		}
-
-		v, ok := currentMap[k]
-		if !ok {
-			return false
-		}
-		if i == len(keys)-1 {
-			return true
-		}
-		current = v
-	}
-
-	return true
-}
-
 // LogFile return the log file name.
 func (inst *TiFlashInstance) LogFile() string {
-	return filepath.Join(inst.Dir, "tiflash.log")
+	return inst.LogPath("tiflash.log")
 }
 
 // StoreAddr return the store address of TiFlash
@@ -53,6 +53,7 @@ func (inst *TiFlashInstance) getProxyConfig() map[string]any {
 		config["dfs.s3-bucket"] = inst.ShOpt.CSE.Bucket
 		config["dfs.s3-region"] = "local"
 	}
+	inst.ShOpt.tlsConfig(config, "tiflash", "security.ca-path", "security.cert-path", "security.key-path")
 
 	return config
 }
@@ -110,6 +111,7 @@ func (inst *TiFlashInstance) getConfig() map[string]any {
 			config["profiles.default.task_scheduler_thread_hard_limit"] = 0
 		}
 	}
+	inst.ShOpt.tlsConfig(config, "tiflash", "security.ca_path", "security.cert_path", "security.key_path")
 
 	return config
 }
@@ -90,10 +90,10 @@ func (inst *TiKVInstance) Prepare(ctx context.Context) error {
 		if addr == "" {
 			continue
 		}
-		endpoints = append(endpoints, "http://"+addr)
+		endpoints = append(endpoints, inst.ShOpt.Scheme()+"://"+addr)
 	}
 	args := []string{
-		fmt.Sprintf("--addr=%s", utils.JoinHostPort(inst.Host, inst.Port)),
+		fmt.Sprintf("--addr=%s", utils.JoinHostPort(inst.Host, inst.listenPort(inst.Port))),
 		fmt.Sprintf("--advertise-addr=%s", utils.JoinHostPort(AdvertiseHost(inst.Host), inst.Port)),
 		fmt.Sprintf("--status-addr=%s", utils.JoinHostPort(inst.Host, inst.StatusPort)),
 		fmt.Sprintf("--pd-endpoints=%s", strings.Join(endpoints, ",")),
@@ -213,7 +213,7 @@ func checkTSOHealth(ctx context.Context, client *http.Client, tsoAddrs []string)
 
 // LogFile return the log file name.
 func (inst *TiKVInstance) LogFile() string {
-	return filepath.Join(inst.Dir, "tikv.log")
+	return inst.LogPath("tikv.log")
 }
 
 // StoreAddr return the store address of TiKV
@@ -37,6 +37,8 @@ func (inst *TiKVInstance) getConfig() map[string]any {
 		config["rfengine.target-file-size"] = "512MB"
 		config["rfengine.wal-chunk-target-file-size"] = "128MB"
 	}
+	inst.ShOpt.tlsConfig(config, "tikv", "security.ca-path", "security.cert-path", "security.key-path")
+	mergeTuning(config, inst.ShOpt.gcTuningConfig(inst.Service))
 
 	return config
 }
@@ -118,7 +118,7 @@ func (inst *TiKVWorkerInstance) Prepare(ctx context.Context) error {
 
 // LogFile return the log file name.
 func (inst *TiKVWorkerInstance) LogFile() string {
-	return filepath.Join(inst.Dir, "tikv_worker.log")
+	return inst.LogPath("tikv_worker.log")
 }
 
 func (inst *TiKVWorkerInstance) getConfig() map[string]any {
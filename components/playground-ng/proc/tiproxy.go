@@ -141,5 +141,5 @@ func (c *TiProxyInstance) WaitReady(ctx context.Context) error {
 
 // LogFile return the log file.
 func (c *TiProxyInstance) LogFile() string {
-	return filepath.Join(c.Dir, "tiproxy.log")
+	return c.LogPath("tiproxy.log")
 }
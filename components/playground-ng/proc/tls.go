@@ -0,0 +1,170 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/crypto"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+const (
+	// TLSDirName is the directory, in the playground data dir, holding the CA
+	// and the certificates of a playground started with --tls.
+	TLSDirName = "tls"
+	// TLSCAName is the name of the CA certificate and key files.
+	TLSCAName = "ca"
+	// TLSClientName is the name of the certificate the playground itself, the
+	// monitoring and the users connect with.
+	TLSClientName = "client"
+)
+
+// tlsCertNames are the certificates GenClusterCerts signs: one per service
+// of the cluster, and one for the clients.
+var tlsCertNames = []string{"pd", "tikv", "tidb", "tiflash", TLSClientName}
+
+// tlsServedServices are the services serving their status port, and so
+// their metrics, over TLS with --tls.
+var tlsServedServices = map[ServiceID]bool{
+	ServicePD:      true,
+	ServiceTiKV:    true,
+	ServiceTiDB:    true,
+	ServiceTiFlash: true,
+}
+
+// TLSCertPath returns the certificate file of name in the TLS dir.
+func TLSCertPath(dir, name string) string {
+	return filepath.Join(dir, name+".crt")
+}
+
+// TLSKeyPath returns the private key file of name in the TLS dir.
+func TLSKeyPath(dir, name string) string {
+	return filepath.Join(dir, name+".key")
+}
+
+// Scheme returns the scheme of the URLs of the cluster: https with --tls.
+func (o SharedOptions) Scheme() string {
+	if o.TLS {
+		return "https"
+	}
+	return "http"
+}
+
+// tlsConfig sets the three config keys of a component pointing to the CA,
+// the certificate and the key of name, when the cluster runs with --tls.
+func (o SharedOptions) tlsConfig(config map[string]any, name, caKey, certKey, keyKey string) {
+	if !o.TLS || o.TLSDir == "" {
+		return
+	}
+	config[caKey] = TLSCertPath(o.TLSDir, TLSCAName)
+	config[certKey] = TLSCertPath(o.TLSDir, name)
+	config[keyKey] = TLSKeyPath(o.TLSDir, name)
+}
+
+// GenClusterCerts creates a CA in dir and signs a certificate for each
+// service and one for the clients, valid for localhost and host. Existing
+// files are kept, so a restarted playground keeps its CA.
+func GenClusterCerts(dir, host string) error {
+	if err := utils.MkdirAll(dir, 0755); err != nil {
+		return errors.AddStack(err)
+	}
+
+	caCert, caKey := TLSCertPath(dir, TLSCAName), TLSKeyPath(dir, TLSCAName)
+	var ca *crypto.CertificateAuthority
+	if _, err := os.Stat(caCert); err == nil {
+		if ca, err = crypto.ReadCA("playground", caCert, caKey); err != nil {
+			return err
+		}
+	} else {
+		if ca, err = crypto.NewCA("playground"); err != nil {
+			return err
+		}
+		if err := utils.WriteFile(caKey, ca.Key.Pem(), 0600); err != nil {
+			return errors.AddStack(err)
+		}
+		if err := utils.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Cert.Raw}), 0644); err != nil {
+			return errors.AddStack(err)
+		}
+	}
+
+	hosts, ips := []string{"localhost"}, []string{"127.0.0.1"}
+	for _, h := range []string{host, AdvertiseHost(host)} {
+		switch {
+		case h == "" || h == "0.0.0.0" || h == "localhost" || h == "127.0.0.1":
+		case net.ParseIP(h) != nil:
+			ips = append(ips, h)
+		default:
+			hosts = append(hosts, h)
+		}
+	}
+
+	for _, name := range tlsCertNames {
+		if _, err := os.Stat(TLSCertPath(dir, name)); err == nil {
+			continue
+		}
+		privKey, err := crypto.NewKeyPair(crypto.KeyTypeRSA, crypto.KeySchemeRSASSAPSSSHA256)
+		if err != nil {
+			return err
+		}
+		csr, err := privKey.CSR(name, name, hosts, ips)
+		if err != nil {
+			return err
+		}
+		cert, err := ca.Sign(csr)
+		if err != nil {
+			return err
+		}
+		if err := utils.WriteFile(TLSKeyPath(dir, name), privKey.Pem(), 0600); err != nil {
+			return errors.AddStack(err)
+		}
+		if err := utils.WriteFile(TLSCertPath(dir, name), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0644); err != nil {
+			return errors.AddStack(err)
+		}
+	}
+	return nil
+}
+
+// ClientTLSConfig returns the config of a client trusting caPath and
+// presenting the certificate certPath with the key keyPath.
+func ClientTLSConfig(caPath, certPath, keyPath string) (*tls.Config, error) {
+	caPEM, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.Errorf("no certificate found in %s", caPath)
+	}
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	return &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// ClientTLSConfig returns the config the playground connects to the cluster
+// with, nil without --tls.
+func (o SharedOptions) ClientTLSConfig() (*tls.Config, error) {
+	if !o.TLS || o.TLSDir == "" {
+		return nil, nil
+	}
+	return ClientTLSConfig(TLSCertPath(o.TLSDir, TLSCAName), TLSCertPath(o.TLSDir, TLSClientName), TLSKeyPath(o.TLSDir, TLSClientName))
+}
@@ -0,0 +1,127 @@
+package proc
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenClusterCerts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), TLSDirName)
+	require.NoError(t, GenClusterCerts(dir, "127.0.0.1"))
+
+	caPEM, err := os.ReadFile(TLSCertPath(dir, TLSCAName))
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(caPEM))
+	for _, name := range tlsCertNames {
+		data, err := os.ReadFile(TLSCertPath(dir, name))
+		require.NoError(t, err)
+		block, _ := pem.Decode(data)
+		require.NotNil(t, block, name)
+		cert, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		_, err = cert.Verify(x509.VerifyOptions{Roots: pool, DNSName: "localhost", KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+		require.NoError(t, err, name)
+		require.NoError(t, cert.VerifyHostname("127.0.0.1"), name)
+	}
+
+	// A restarted playground keeps its CA and certificates.
+	require.NoError(t, GenClusterCerts(dir, "127.0.0.1"))
+	again, err := os.ReadFile(TLSCertPath(dir, TLSCAName))
+	require.NoError(t, err)
+	require.Equal(t, caPEM, again)
+
+	shOpt := SharedOptions{TLS: true, TLSDir: dir}
+	tlsCfg, err := shOpt.ClientTLSConfig()
+	require.NoError(t, err)
+	require.Len(t, tlsCfg.Certificates, 1)
+
+	tlsCfg, err = SharedOptions{}.ClientTLSConfig()
+	require.NoError(t, err)
+	require.Nil(t, tlsCfg)
+}
+
+func TestTLSComponentConfig(t *testing.T) {
+	dir := t.TempDir()
+	shOpt := SharedOptions{Mode: ModeNormal, TLS: true, TLSDir: dir}
+	ca := filepath.Join(dir, "ca.crt")
+
+	pd := &PDInstance{ShOpt: shOpt}
+	config := pd.getConfig()
+	require.Equal(t, ca, config["security.cacert-path"])
+	require.Equal(t, filepath.Join(dir, "pd.crt"), config["security.cert-path"])
+	require.Equal(t, filepath.Join(dir, "pd.key"), config["security.key-path"])
+
+	kv := &TiKVInstance{ShOpt: shOpt}
+	require.Equal(t, ca, kv.getConfig()["security.ca-path"])
+
+	db := &TiDBInstance{ShOpt: shOpt}
+	config, err := db.getConfig()
+	require.NoError(t, err)
+	require.Equal(t, false, config["security.auto-tls"])
+	require.Equal(t, ca, config["security.cluster-ssl-ca"])
+	require.Equal(t, filepath.Join(dir, "tidb.crt"), config["security.ssl-cert"])
+
+	flash := &TiFlashInstance{ShOpt: shOpt, ProcessInfo: ProcessInfo{Service: ServiceTiFlash}}
+	require.Equal(t, ca, flash.getConfig()["security.ca_path"])
+	require.Equal(t, ca, flash.getProxyConfig()["security.ca-path"])
+
+	// Without --tls no security config is set.
+	pd.ShOpt.TLS = false
+	require.NotContains(t, pd.getConfig(), "security.cacert-path")
+}
+
+func TestPDInstancePrepare_TLSUsesHTTPS(t *testing.T) {
+	inst := &PDInstance{
+		ShOpt: SharedOptions{TLS: true, TLSDir: t.TempDir()},
+		ProcessInfo: ProcessInfo{
+			Dir:        t.TempDir(),
+			Host:       "127.0.0.1",
+			Port:       2380,
+			StatusPort: 2379,
+			Service:    ServicePD,
+		},
+		Plan: PDPlan{InitialCluster: []PDMemberPlan{{Name: "pd-0", PeerAddr: "127.0.0.1:2380"}}},
+	}
+	args, err := inst.prepareNormalArgs("pd.toml", "pd-0")
+	require.NoError(t, err)
+	require.Contains(t, args, "--client-urls=https://127.0.0.1:2379")
+	require.Contains(t, args, "--initial-cluster=pd-0=https://127.0.0.1:2380")
+}
+
+func TestPrometheusInstance_TLS(t *testing.T) {
+	tlsDir := t.TempDir()
+	inst := &PrometheusInstance{
+		ShOpt: SharedOptions{TLS: true, TLSDir: tlsDir},
+		ProcessInfo: ProcessInfo{
+			Dir:     t.TempDir(),
+			Host:    "127.0.0.1",
+			Port:    9090,
+			BinPath: "/bin/prometheus",
+		},
+	}
+	require.NoError(t, inst.Prepare(context.Background()))
+	config, err := os.ReadFile(filepath.Join(inst.Dir, "prometheus.yml"))
+	require.NoError(t, err)
+	require.Contains(t, string(config), "ca_file: "+filepath.Join(tlsDir, "ca.crt"))
+	require.Contains(t, string(config), "cert_file: "+filepath.Join(tlsDir, "client.crt"))
+
+	require.NoError(t, inst.RenderSDFile(map[ServiceID]MetricAddr{
+		ServicePD:      {Targets: []string{"127.0.0.1:2379"}},
+		ServiceGrafana: {Targets: []string{"127.0.0.1:3000"}},
+	}))
+	data, err := os.ReadFile(filepath.Join(inst.Dir, "targets.json"))
+	require.NoError(t, err)
+	targets := string(data)
+	// Only the services serving TLS are scraped over https.
+	require.Equal(t, 1, strings.Count(targets, `"__scheme__": "https"`))
+	pd := targets[strings.Index(targets, "2379"):]
+	require.Contains(t, pd[:strings.Index(pd, "}")], `"__scheme__": "https"`)
+}
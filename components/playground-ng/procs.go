@@ -140,9 +140,14 @@ func (p *Playground) addProcInController(state *controllerState, serviceID proc.
 	}
 
 	id := state.allocID(serviceID)
-	dir := filepath.Join(p.dataDir, fmt.Sprintf("%s-%d", serviceID, id))
-	if err = utils.MkdirAll(dir, 0755); err != nil {
-		return nil, err
+	dir, logDir := instanceDirs(p.dataDir, cfg, fmt.Sprintf("%s-%d", serviceID, id))
+	for _, d := range []string{dir, logDir} {
+		if d == "" {
+			continue
+		}
+		if err = utils.MkdirAll(d, 0755); err != nil {
+			return nil, err
+		}
 	}
 	// look more like listen ip?
 	host := ""
@@ -153,7 +158,7 @@ func (p *Playground) addProcInController(state *controllerState, serviceID proc.
 		host = cfg.Host
 	}
 
-	return spec.NewProc(controllerRuntime{pg: p, state: state}, pgservice.NewProcParams{Config: cfg, ID: id, Dir: dir, Host: host})
+	return spec.NewProc(controllerRuntime{pg: p, state: state}, pgservice.NewProcParams{Config: cfg, ID: id, Dir: dir, LogDir: logDir, Host: host})
 }
 
 func (p *Playground) addPlannedProcInController(state *controllerState, plan ServicePlan, binPath string, version utils.Version, shOpt proc.SharedOptions, dataDir string) (proc.Process, error) {
@@ -183,12 +188,23 @@ func (p *Playground) addPlannedProcInController(state *controllerState, plan Ser
 		return nil, fmt.Errorf("planned data dir mismatch: runtime=%q planned=%q", p.dataDir, dataDir)
 	}
 
+	// The dir is baseDir/name unless the service data dir is overridden, in
+	// which case it still ends with the instance name (see instanceDirs).
 	dir := filepath.Join(baseDir, name)
 	if plan.Shared.Dir != "" && plan.Shared.Dir != dir {
-		return nil, fmt.Errorf("planned dir mismatch: expect %q, got %q", dir, plan.Shared.Dir)
+		if filepath.Base(plan.Shared.Dir) != name {
+			return nil, fmt.Errorf("planned dir mismatch: expect %q, got %q", dir, plan.Shared.Dir)
+		}
+		dir = plan.Shared.Dir
 	}
-	if err := utils.MkdirAll(dir, 0o755); err != nil {
-		return nil, err
+	logDir := plan.Shared.LogDir
+	for _, d := range []string{dir, logDir} {
+		if d == "" {
+			continue
+		}
+		if err := utils.MkdirAll(d, 0o755); err != nil {
+			return nil, err
+		}
 	}
 
 	host := strings.TrimSpace(plan.Shared.Host)
@@ -201,6 +217,7 @@ func (p *Playground) addPlannedProcInController(state *controllerState, plan Ser
 		StatusPort:      plan.Shared.StatusPort,
 		UpTimeout:       plan.Shared.UpTimeout,
 		ConfigPath:      plan.Shared.ConfigPath,
+		LogDir:          logDir,
 		UserBinPath:     plan.BinPath,
 		BinPath:         binPath,
 		Version:         version,
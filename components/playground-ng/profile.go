@@ -0,0 +1,389 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	pgservice "github.com/pingcap/tiup/components/playground-ng/service"
+	"github.com/pingcap/tiup/pkg/localdata"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultProfileName is the profile applied when --profile is not given.
+const defaultProfileName = "default"
+
+// profileVersionKey sets the cluster version in a profile, as the positional
+// argument does on the command line.
+const profileVersionKey = "version"
+
+// playgroundProfile is a named set of start flags, keyed by flag name without
+// dashes (e.g. "kv: 3", "mode: tikv-slim").
+type playgroundProfile map[string]any
+
+type playgroundProfilesFile struct {
+	Profiles map[string]playgroundProfile `yaml:"profiles"`
+}
+
+// playgroundProfilesPath returns the profiles file shared by all playgrounds
+// under tiupHome.
+func playgroundProfilesPath(tiupHome string) string {
+	return filepath.Join(tiupHome, playgroundComponentName, "profiles.yaml")
+}
+
+// loadPlaygroundProfiles returns the profiles defined in path, or nil if the
+// file does not exist.
+func loadPlaygroundProfiles(path string) (map[string]playgroundProfile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	data, err = utils.InterpolateEnv(data, utils.AllowedEnv(os.Getenv(localdata.EnvNameInterpolateEnv)))
+	if err != nil {
+		return nil, errors.Annotatef(err, "interpolate %s", path)
+	}
+	var file playgroundProfilesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, errors.Annotatef(err, "parse %s", path)
+	}
+	return file.Profiles, nil
+}
+
+// applyProfile sets the flags of the named profile that are not given on the
+// command line, so flags always override the profile. Without a name, the
+// "default" profile is applied if it is defined. It returns the cluster
+// version of the profile, if any.
+func applyProfile(flagSet *pflag.FlagSet, path, name string) (string, error) {
+	profiles, err := loadPlaygroundProfiles(path)
+	if err != nil {
+		return "", err
+	}
+	profile, ok := profiles[name]
+	if name == "" {
+		name = defaultProfileName
+		profile, ok = profiles[name]
+		if !ok {
+			return "", nil
+		}
+	}
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return "", fmt.Errorf("profile %q not found: no profiles defined in %s", name, path)
+		}
+		return "", fmt.Errorf("profile %q not found in %s (available: %s)", name, path, strings.Join(names, ", "))
+	}
+
+	version := ""
+	if v, ok := profile[profileVersionKey]; ok {
+		version = fmt.Sprint(v)
+	}
+	flags := make(playgroundProfile, len(profile))
+	for k, v := range profile {
+		if k != profileVersionKey {
+			flags[k] = v
+		}
+	}
+	if err := setUnchangedFlags(flagSet, flags); err != nil {
+		return "", fmt.Errorf("profile %q: %w", name, err)
+	}
+	return version, nil
+}
+
+// setUnchangedFlags sets the flags in values that are not given yet. A list
+// sets a repeated flag.
+func setUnchangedFlags(flagSet *pflag.FlagSet, values playgroundProfile) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		f := flagSet.Lookup(key)
+		if f == nil || f.Hidden || key == "profile" || key == "like" || key == "help" || key == "version" {
+			return fmt.Errorf("unknown flag --%s", key)
+		}
+		if f.Changed {
+			continue
+		}
+		list := []any{value}
+		switch v := value.(type) {
+		case []any:
+			list = v
+		case map[string]any:
+			return fmt.Errorf("--%s must be a scalar or a list", key)
+		}
+		for _, v := range list {
+			if err := flagSet.Set(key, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("--%s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// startInvocation is the fully resolved start invocation of a playground,
+// recorded in its data dir so an identical playground can be started later
+// or on another machine (see --like).
+type startInvocation struct {
+	Version string `yaml:"version,omitempty"`
+	// Flags holds the flags given on the command line or by a profile, keyed
+	// like a profile. Paths are absolute.
+	Flags playgroundProfile `yaml:"flags,omitempty"`
+	// ConfigFiles holds the contents of the config files, keyed by flag.
+	ConfigFiles map[string]string `yaml:"config_files,omitempty"`
+	// Versions pins the component versions the boot resolved, keyed by the
+	// flag prefix of the service.
+	Versions map[string]string `yaml:"versions,omitempty"`
+	// Tuning shows the values --test-friendly and --gc-ttl give each service,
+	// keyed by its flag prefix, after its config file overrides them. --like
+	// does not read it: the flags tune the new playground again.
+	Tuning map[string]map[string]any `yaml:"tuning,omitempty"`
+}
+
+// invocationSkipFlags are the flags that select how or where a playground
+// runs rather than what it runs, so they are not recorded.
+var invocationSkipFlags = map[string]bool{
+	"tag":                 true,
+	"background":          true,
+	"run-as-daemon":       true,
+	"profile":             true,
+	"like":                true,
+	"dry-run":             true,
+	"dry-run-output":      true,
+	"interrupted-op":      true,
+	"proxy":               true,
+	"force-pull":          true,
+	"help":                true,
+	"version":             true,
+	"start-queue-timeout": true,
+
+	// A secret, which the playgrounds started --like this one must not get.
+	"read-only-token": true,
+}
+
+func isPathFlag(name string) bool {
+	for _, suffix := range []string{".config", ".binpath", ".data-dir", ".log-dir"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return isFileFlag(name)
+}
+
+// isFileFlag reports whether the flag name points to a file whose contents
+// are recorded with the invocation: a config file or the --init-sql script.
+func isFileFlag(name string) bool {
+	return strings.HasSuffix(name, ".config") || name == "init-sql"
+}
+
+// newStartInvocation records the flags set on flagSet, along with the
+// contents of the config files they refer to.
+func newStartInvocation(flagSet *pflag.FlagSet, version string) (*startInvocation, error) {
+	inv := &startInvocation{Version: version, Flags: playgroundProfile{}}
+	var err error
+	flagSet.Visit(func(f *pflag.Flag) {
+		if err != nil || invocationSkipFlags[f.Name] {
+			return
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			inv.Flags[f.Name] = sv.GetSlice()
+			return
+		}
+		value := f.Value.String()
+		switch f.Value.Type() {
+		case "int":
+			if n, convErr := strconv.Atoi(value); convErr == nil {
+				inv.Flags[f.Name] = n
+				return
+			}
+		case "bool":
+			if b, convErr := strconv.ParseBool(value); convErr == nil {
+				inv.Flags[f.Name] = b
+				return
+			}
+		}
+		if value != "" && isPathFlag(f.Name) {
+			if value, err = getAbsolutePath(value); err != nil {
+				return
+			}
+		}
+		inv.Flags[f.Name] = value
+		if value != "" && isFileFlag(f.Name) {
+			data, readErr := os.ReadFile(value)
+			if readErr != nil {
+				err = errors.Annotatef(readErr, "read --%s", f.Name)
+				return
+			}
+			if inv.ConfigFiles == nil {
+				inv.ConfigFiles = make(map[string]string)
+			}
+			inv.ConfigFiles[f.Name] = string(data)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// pinVersions replaces the version constraints (e.g. "nightly") with the
+// versions resolved by plan: the cluster version, if PD, TiKV and TiDB agree
+// on it, and the version of each service that can override it. Services
+// started from a local binary are left alone.
+func (inv *startInvocation) pinVersions(plan BootPlan) {
+	clusterVersion, consistent := "", true
+	for _, s := range plan.Services {
+		if s.BinPath != "" || s.ResolvedVersion == "" {
+			continue
+		}
+		serviceID := proc.ServiceID(s.ServiceID)
+		spec, ok := pgservice.SpecFor(serviceID)
+		if ok && spec.Catalog.FlagPrefix != "" && spec.Catalog.AllowModifyVersion {
+			if inv.Versions == nil {
+				inv.Versions = make(map[string]string)
+			}
+			inv.Versions[spec.Catalog.FlagPrefix] = s.ResolvedVersion
+			continue
+		}
+		switch serviceID {
+		case proc.ServicePD, proc.ServiceTiKV, proc.ServiceTiDB:
+		default:
+			continue
+		}
+		if s.DebugConstraint != plan.BootVersion {
+			continue
+		}
+		if clusterVersion == "" {
+			clusterVersion = s.ResolvedVersion
+		} else if clusterVersion != s.ResolvedVersion {
+			consistent = false
+		}
+	}
+	if clusterVersion != "" && consistent {
+		inv.Version = clusterVersion
+	}
+}
+
+// recordTuning records the effective values of the tuning flags of options
+// in inv.Tuning.
+func (inv *startInvocation) recordTuning(options *BootOptions) error {
+	inv.Tuning = nil
+	add := func(prefix string, values map[string]any) {
+		if inv.Tuning == nil {
+			inv.Tuning = make(map[string]map[string]any)
+		}
+		inv.Tuning[prefix] = values
+	}
+	for _, spec := range pgservice.AllSpecs() {
+		cfg := options.Service(spec.ServiceID)
+		if spec.Catalog.FlagPrefix == "" || cfg == nil || cfg.Num < 1 {
+			continue
+		}
+		values, err := options.ShOpt.GCTuning(spec.ServiceID, cfg.ConfigPath)
+		if err != nil {
+			return err
+		}
+		if len(values) > 0 {
+			add(spec.Catalog.FlagPrefix, values)
+		}
+	}
+	if options.ShOpt.GCLifeTime != 0 {
+		add("db", map[string]any{proc.GCLifeTimeVariable: options.ShOpt.GCLifeTime.String()})
+	}
+	return nil
+}
+
+// writeStartInvocation atomically replaces the invocation under dataDir.
+func writeStartInvocation(dataDir string, inv *startInvocation) error {
+	data, err := yaml.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dataDir, playgroundInvocationFileName)
+	tmp := path + ".tmp"
+	if err := utils.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadStartInvocation reads the invocation to start --like: like is either a
+// file saved from show-config or the tag of a playground.
+func loadStartInvocation(tiupHome, like string) (*startInvocation, error) {
+	path := like
+	if !strings.ContainsRune(like, filepath.Separator) && !utils.IsExist(like) {
+		path = filepath.Join(tiupHome, localdata.DataParentDir, like, playgroundInvocationFileName)
+		if !utils.IsExist(path) {
+			return nil, fmt.Errorf("playground %q has no recorded start invocation", like)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	var inv startInvocation
+	if err := yaml.Unmarshal(data, &inv); err != nil {
+		return nil, errors.Annotatef(err, "parse %s", path)
+	}
+	return &inv, nil
+}
+
+// applyStartInvocation sets the flags of inv that are not given on the
+// command line, pinning the component versions it resolved. Config files are
+// set later by writeInvocationConfigFiles, once the data dir is known.
+func applyStartInvocation(flagSet *pflag.FlagSet, inv *startInvocation) error {
+	versions := make(playgroundProfile, len(inv.Versions))
+	for prefix, v := range inv.Versions {
+		versions[prefix+".version"] = v
+	}
+	// Pinned versions go first, so they win over the recorded constraints.
+	if err := setUnchangedFlags(flagSet, versions); err != nil {
+		return err
+	}
+	flags := make(playgroundProfile, len(inv.Flags))
+	for k, v := range inv.Flags {
+		if _, ok := inv.ConfigFiles[k]; !ok {
+			flags[k] = v
+		}
+	}
+	return setUnchangedFlags(flagSet, flags)
+}
+
+// writeInvocationConfigFiles writes the config files of inv under dataDir and
+// points their flags to them, unless given on the command line.
+func writeInvocationConfigFiles(flagSet *pflag.FlagSet, dataDir string, inv *startInvocation) error {
+	for flag, content := range inv.ConfigFiles {
+		f := flagSet.Lookup(flag)
+		if f == nil || f.Changed {
+			continue
+		}
+		path := filepath.Join(dataDir, "config", flag+filepath.Ext(fmt.Sprint(inv.Flags[flag])))
+		if err := utils.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := utils.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+		if err := flagSet.Set(flag, path); err != nil {
+			return fmt.Errorf("--%s: %w", flag, err)
+		}
+	}
+	return nil
+}
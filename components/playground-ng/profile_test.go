@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProfile(t *testing.T) {
+	path := playgroundProfilesPath(t.TempDir())
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(`profiles:
+  default:
+    db: 2
+  big:
+    version: v8.5.0
+    kv: 3
+    mode: tikv-slim
+    host: 0.0.0.0
+  bad:
+    nope: 1
+`), 0o644))
+
+	opts := BootOptions{}
+	fs := newTestFlagSet()
+	registerServiceFlags(fs, &opts)
+	fs.StringVar(&opts.ShOpt.Mode, "mode", "tidb", "")
+	fs.StringVar(&opts.Host, "host", "127.0.0.1", "")
+	require.NoError(t, fs.Parse([]string{"--kv=1"}))
+	version, err := applyProfile(fs, path, "big")
+	require.NoError(t, err)
+	require.Equal(t, "v8.5.0", version)
+	require.Equal(t, "tikv-slim", opts.ShOpt.Mode)
+	require.Equal(t, "0.0.0.0", opts.Host)
+	kv, err := fs.GetInt("kv")
+	require.NoError(t, err)
+	require.Equal(t, 1, kv, "command line flags override the profile")
+	require.False(t, fs.Lookup("db").Changed)
+
+	fs = newTestFlagSet()
+	registerServiceFlags(fs, &BootOptions{})
+	version, err = applyProfile(fs, path, "")
+	require.NoError(t, err)
+	require.Empty(t, version)
+	db, err := fs.GetInt("db")
+	require.NoError(t, err)
+	require.Equal(t, 2, db)
+
+	_, err = applyProfile(newTestFlagSet(), path, "small")
+	require.ErrorContains(t, err, `profile "small" not found`)
+	require.ErrorContains(t, err, "available: bad, big, default")
+	_, err = applyProfile(newTestFlagSet(), path, "bad")
+	require.ErrorContains(t, err, `profile "bad": unknown flag --nope`)
+
+	version, err = applyProfile(newTestFlagSet(), filepath.Join(t.TempDir(), "missing.yaml"), "")
+	require.NoError(t, err)
+	require.Empty(t, version)
+}
+
+func TestLoadPlaygroundProfiles_InterpolateEnv(t *testing.T) {
+	t.Setenv("TIUP_INTERPOLATE_ENV", "TEST_PG_KV")
+	t.Setenv("TEST_PG_KV", "3")
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`profiles:
+  ci:
+    kv: ${TEST_PG_KV}
+    host: ${TEST_PG_HOST:-0.0.0.0}
+`), 0o644))
+
+	profiles, err := loadPlaygroundProfiles(path)
+	require.NoError(t, err)
+	require.Equal(t, playgroundProfile{"kv": 3, "host": "0.0.0.0"}, profiles["ci"])
+
+	require.NoError(t, os.WriteFile(path, []byte("profiles:\n  ci:\n    host: ${TEST_PG_HOST}\n"), 0o644))
+	_, err = loadPlaygroundProfiles(path)
+	require.ErrorContains(t, err, "variable TEST_PG_HOST is not set or not allowed")
+}
+
+func TestStartInvocation_RecordAndApply(t *testing.T) {
+	tiupHome := t.TempDir()
+	cfgPath := filepath.Join(t.TempDir(), "tidb.toml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte("[log]\nlevel = \"warn\"\n"), 0o644))
+
+	newFlags := func(opts *BootOptions) *pflag.FlagSet {
+		fs := newTestFlagSet()
+		registerServiceFlags(fs, opts)
+		fs.StringVar(&opts.Host, "host", "127.0.0.1", "")
+		fs.String("tag", "", "")
+		return fs
+	}
+
+	fs := newFlags(&BootOptions{})
+	require.NoError(t, fs.Parse([]string{"--kv=3", "--db.config=" + cfgPath, "--host=0.0.0.0", "--tag=src"}))
+	inv, err := newStartInvocation(fs, "nightly")
+	require.NoError(t, err)
+	require.Equal(t, playgroundProfile{"kv": 3, "db.config": cfgPath, "host": "0.0.0.0"}, inv.Flags)
+	require.Equal(t, map[string]string{"db.config": "[log]\nlevel = \"warn\"\n"}, inv.ConfigFiles)
+
+	inv.pinVersions(BootPlan{BootVersion: "nightly", Services: []ServicePlan{
+		{ServiceID: proc.ServicePD.String(), DebugConstraint: "nightly", ResolvedVersion: "v9.0.0-nightly"},
+		{ServiceID: proc.ServiceTiDB.String(), DebugConstraint: "nightly", ResolvedVersion: "v9.0.0-nightly"},
+		{ServiceID: proc.ServiceTiProxy.String(), DebugConstraint: "nightly", ResolvedVersion: "v1.3.0"},
+		{ServiceID: proc.ServiceTiKV.String(), BinPath: "/opt/tikv-server"},
+	}})
+	require.Equal(t, "v9.0.0-nightly", inv.Version)
+	require.Equal(t, map[string]string{"tiproxy": "v1.3.0"}, inv.Versions)
+
+	srcDir := filepath.Join(tiupHome, "data", "src")
+	require.NoError(t, os.MkdirAll(srcDir, 0o755))
+	require.NoError(t, writeStartInvocation(srcDir, inv))
+
+	loaded, err := loadStartInvocation(tiupHome, "src")
+	require.NoError(t, err)
+	require.Equal(t, inv.Version, loaded.Version)
+	_, err = loadStartInvocation(tiupHome, "missing")
+	require.ErrorContains(t, err, `playground "missing" has no recorded start invocation`)
+
+	opts := BootOptions{}
+	fs = newFlags(&opts)
+	require.NoError(t, fs.Parse([]string{"--kv=5"}))
+	require.NoError(t, applyStartInvocation(fs, loaded))
+	dataDir := t.TempDir()
+	require.NoError(t, writeInvocationConfigFiles(fs, dataDir, loaded))
+
+	require.Equal(t, 5, opts.Service(proc.ServiceTiKV).Num, "command line flags override the recorded ones")
+	require.Equal(t, "0.0.0.0", opts.Host)
+	require.Equal(t, "v1.3.0", opts.Service(proc.ServiceTiProxy).Version)
+	newCfg := opts.Service(proc.ServiceTiDB).ConfigPath
+	require.Equal(t, filepath.Join(dataDir, "config", "db.config.toml"), newCfg)
+	data, err := os.ReadFile(newCfg)
+	require.NoError(t, err)
+	require.Equal(t, "[log]\nlevel = \"warn\"\n", string(data))
+}
+
+func TestStartInvocation_RecordTuning(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "tikv.toml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte("[raftstore]\nregion-compact-check-interval = \"30s\"\n"), 0o644))
+	opts := &BootOptions{ShOpt: proc.SharedOptions{TestFriendly: true, GCLifeTime: 24 * time.Hour}}
+	opts.Service(proc.ServiceTiKV).Num = 1
+	opts.Service(proc.ServiceTiKV).ConfigPath = cfgPath
+
+	inv := &startInvocation{}
+	require.NoError(t, inv.recordTuning(opts))
+	require.Equal(t, map[string]map[string]any{
+		"kv": {
+			"raftstore.region-compact-check-interval":     "30s",
+			"raftstore.region-compact-min-tombstones":     1000,
+			"raftstore.region-compact-tombstones-percent": 10,
+		},
+		"db": {"tidb_gc_life_time": "24h0m0s"},
+	}, inv.Tuning)
+
+	require.NoError(t, inv.recordTuning(&BootOptions{}))
+	require.Nil(t, inv.Tuning)
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
+	"github.com/spf13/cobra"
+)
+
+// sqlTarget is a database, or a table of it, given as db or db.table.
+type sqlTarget struct {
+	DB    string
+	Table string
+}
+
+// parseSQLTarget parses a db or db.table argument.
+func parseSQLTarget(s string) (sqlTarget, error) {
+	db, table, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if db == "" || strings.Contains(table, ".") || (strings.Contains(s, ".") && table == "") {
+		return sqlTarget{}, fmt.Errorf("invalid target %q, expected <db> or <db>.<table>", s)
+	}
+	return sqlTarget{DB: db, Table: table}, nil
+}
+
+// parseSQLTargets parses the db or db.table arguments.
+func parseSQLTargets(args []string) ([]sqlTarget, error) {
+	targets := make([]sqlTarget, 0, len(args))
+	for _, arg := range args {
+		t, err := parseSQLTarget(arg)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+func (t sqlTarget) String() string {
+	if t.Table == "" {
+		return t.DB
+	}
+	return t.DB + "." + t.Table
+}
+
+// alter returns the ALTER DATABASE or ALTER TABLE statement of t, followed by
+// spec.
+func (t sqlTarget) alter(spec string) string {
+	if t.Table == "" {
+		return fmt.Sprintf("ALTER DATABASE %s %s", sqlIdent(t.DB), spec)
+	}
+	return fmt.Sprintf("ALTER TABLE %s.%s %s", sqlIdent(t.DB), sqlIdent(t.Table), spec)
+}
+
+// sqlIdent returns s as a backquoted SQL identifier.
+func sqlIdent(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+// tiflashReplicaStmt returns the statement setting count TiFlash replicas on
+// the tables of t.
+func tiflashReplicaStmt(t sqlTarget, count int) string {
+	return t.alter(fmt.Sprintf("SET TIFLASH REPLICA %d", count))
+}
+
+// placementPolicyOptions returns the options of a placement policy with the
+// given number of followers and learners, followed by the raw options.
+func placementPolicyOptions(followers, learners int, raw string) (string, error) {
+	var opts []string
+	if followers > 0 {
+		opts = append(opts, fmt.Sprintf("FOLLOWERS=%d", followers))
+	}
+	if learners > 0 {
+		opts = append(opts, fmt.Sprintf("LEARNERS=%d", learners))
+	}
+	if raw = strings.TrimSpace(raw); raw != "" {
+		opts = append(opts, raw)
+	}
+	if len(opts) == 0 {
+		return "", fmt.Errorf("no placement option, use --followers, --learners or --options")
+	}
+	return strings.Join(opts, " "), nil
+}
+
+// placementStmts returns the statements creating, or updating, the placement
+// policy name with options and attaching it to targets.
+func placementStmts(name, options string, targets []sqlTarget) []string {
+	stmts := []string{
+		fmt.Sprintf("CREATE PLACEMENT POLICY IF NOT EXISTS %s %s", sqlIdent(name), options),
+		fmt.Sprintf("ALTER PLACEMENT POLICY %s %s", sqlIdent(name), options),
+	}
+	for _, t := range targets {
+		stmts = append(stmts, t.alter("PLACEMENT POLICY = "+sqlIdent(name)))
+	}
+	return stmts
+}
+
+// setTiFlashReplicas sets count TiFlash replicas on targets, one task each in
+// group.
+func setTiFlashReplicas(ctx context.Context, group *progressv2.Group, conn *sql.Conn, targets []sqlTarget, count int) error {
+	for _, t := range targets {
+		task := group.Task("TiFlash replica " + t.String())
+		task.SetMeta(fmt.Sprintf("count %d", count))
+		if err := execInTask(ctx, task, conn, tiflashReplicaStmt(t, count)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tiflashReplicaProgressQuery sums the progress of the TiFlash replicas of a
+// database, or of a table with a second argument.
+const tiflashReplicaProgressQuery = "SELECT COUNT(*), COALESCE(SUM(AVAILABLE), 0), COALESCE(SUM(PROGRESS), 0) FROM information_schema.tiflash_replica WHERE TABLE_SCHEMA = ?"
+
+// waitTiFlashReplicas blocks until the TiFlash replicas of targets are
+// available, reporting their sync progress in group.
+func waitTiFlashReplicas(ctx context.Context, group *progressv2.Group, conn *sql.Conn, targets []sqlTarget) error {
+	for _, t := range targets {
+		task := group.Task("Sync " + t.String())
+		query, args := tiflashReplicaProgressQuery, []any{t.DB}
+		if t.Table != "" {
+			query, args = query+" AND TABLE_NAME = ?", append(args, t.Table)
+		}
+		for {
+			var tables, available int64
+			var progress float64
+			if err := conn.QueryRowContext(ctx, query, args...).Scan(&tables, &available, &progress); err != nil {
+				task.Error(err.Error())
+				return errors.Annotatef(err, "read the TiFlash replica progress of %s", t)
+			}
+			task.SetTotal(tables * 100)
+			task.SetCurrent(int64(progress * 100))
+			if available == tables {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				task.Cancel("")
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+		task.Done()
+	}
+	return nil
+}
+
+// openPlaygroundSQL connects to the first TiDB recorded in ready, as root.
+func openPlaygroundSQL(ctx context.Context, ready *playgroundReady, tag string) (*sql.DB, *sql.Conn, error) {
+	if len(ready.TiDB) == 0 {
+		return nil, nil, fmt.Errorf("playground %q has no TiDB instance", tag)
+	}
+	db, err := proc.OpenTiDB(ready.TiDB[0], ready.RootPassword)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, nil, errors.Annotatef(err, "connect to TiDB %s", ready.TiDB[0])
+	}
+	return db, conn, nil
+}
+
+func newTiFlashReplica(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+	var (
+		count int
+		wait  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tiflash-replica <db|db.table>...",
+		Short: "Set TiFlash replicas on databases or tables of a running playground",
+		Long: `Set TiFlash replicas on the tables of databases, or on single tables, of a
+running playground: ALTER DATABASE|TABLE ... SET TIFLASH REPLICA. A database
+only covers the tables it has now. With --wait, block until the replicas are
+synced and available to queries.`,
+		Example: fmt.Sprintf("%[1]s tiflash-replica --tag my-cluster test\n%[1]s tiflash-replica --tag my-cluster test.orders --wait", arg0),
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targets, err := parseSQLTargets(args)
+			if err != nil {
+				return err
+			}
+			if count < 0 {
+				return fmt.Errorf("invalid --count %d", count)
+			}
+			return runPlaygroundSQL(cmd.OutOrStdout(), state, "Set TiFlash replicas", func(ctx context.Context, group *progressv2.Group, conn *sql.Conn) error {
+				if err := setTiFlashReplicas(ctx, group, conn, targets, count); err != nil {
+					return err
+				}
+				if wait && count > 0 {
+					return waitTiFlashReplicas(ctx, group, conn, targets)
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().IntVar(&count, "count", 1, "Number of TiFlash replicas, at most the number of TiFlash instances; 0 removes them")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until the replicas are available")
+	return cmd
+}
+
+func newPlacement(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+	var (
+		name                string
+		followers, learners int
+		options             string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "placement <db|db.table>...",
+		Short: "Apply a placement policy to databases or tables of a running playground",
+		Long: `Create (or update) a placement policy and attach it to databases or tables of a
+running playground. The number of replicas cannot exceed the number of TiKV
+instances: start with e.g. --kv 3 for two followers.`,
+		Example: fmt.Sprintf("%[1]s placement --tag my-cluster --followers 2 test\n%[1]s placement --tag my-cluster --options 'LEADER_CONSTRAINTS=\"[+zone=a]\"' test.orders", arg0),
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targets, err := parseSQLTargets(args)
+			if err != nil {
+				return err
+			}
+			opts, err := placementPolicyOptions(followers, learners, options)
+			if err != nil {
+				return err
+			}
+			return runPlaygroundSQL(cmd.OutOrStdout(), state, "Apply placement policy "+name, func(ctx context.Context, group *progressv2.Group, conn *sql.Conn) error {
+				stmts := placementStmts(name, opts, targets)
+				task := group.Task("Create policy " + name)
+				task.SetMeta(opts)
+				if err := execInTask(ctx, task, conn, stmts[:2]...); err != nil {
+					return err
+				}
+				for i, t := range targets {
+					if err := execInTask(ctx, group.Task("Attach to "+t.String()), conn, stmts[2+i]); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "playground", "Name of the placement policy")
+	cmd.Flags().IntVar(&followers, "followers", 0, "Number of follower replicas")
+	cmd.Flags().IntVar(&learners, "learners", 0, "Number of learner replicas")
+	cmd.Flags().StringVar(&options, "options", "", "Other placement options, as in CREATE PLACEMENT POLICY")
+	return cmd
+}
+
+// execInTask runs stmts on conn and concludes task with the outcome.
+func execInTask(ctx context.Context, task *progressv2.Task, conn *sql.Conn, stmts ...string) error {
+	for _, stmt := range stmts {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			task.Error(err.Error())
+			return errors.Annotatef(err, "run %s", abbreviateSQL(stmt))
+		}
+	}
+	task.Done()
+	return nil
+}
+
+// runPlaygroundSQL runs fn on a connection to the running playground, with
+// its steps reported in a progress group titled title.
+func runPlaygroundSQL(out io.Writer, state *cliState, title string, fn func(context.Context, *progressv2.Group, *sql.Conn) error) error {
+	target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+	if err != nil {
+		printDisplayFailureWarning(out, err)
+		return renderedError{err: err}
+	}
+	ready, err := loadReadyFile(target)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, conn, err := openPlaygroundSQL(ctx, ready, target.tag)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer conn.Close()
+
+	ui := progressv2.New(progressv2.Options{Mode: progressv2.ModeAuto, Out: os.Stderr})
+	defer ui.Close()
+	group := ui.Group(title)
+	err = fn(ctx, group, conn)
+	group.Close()
+	if err != nil {
+		return renderedError{err: err}
+	}
+	return nil
+}
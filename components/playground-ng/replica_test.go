@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSQLTarget(t *testing.T) {
+	target, err := parseSQLTarget("test")
+	require.NoError(t, err)
+	require.Equal(t, sqlTarget{DB: "test"}, target)
+	target, err = parseSQLTarget("test.orders")
+	require.NoError(t, err)
+	require.Equal(t, sqlTarget{DB: "test", Table: "orders"}, target)
+
+	for _, s := range []string{"", ".orders", "test.", "a.b.c"} {
+		_, err := parseSQLTarget(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestTiFlashReplicaStmt(t *testing.T) {
+	require.Equal(t, "ALTER DATABASE `test` SET TIFLASH REPLICA 1", tiflashReplicaStmt(sqlTarget{DB: "test"}, 1))
+	require.Equal(t, "ALTER TABLE `test`.`or``ders` SET TIFLASH REPLICA 0", tiflashReplicaStmt(sqlTarget{DB: "test", Table: "or`ders"}, 0))
+}
+
+func TestPlacementStmts(t *testing.T) {
+	opts, err := placementPolicyOptions(2, 1, ` PRIMARY_REGION="r1" `)
+	require.NoError(t, err)
+	require.Equal(t, `FOLLOWERS=2 LEARNERS=1 PRIMARY_REGION="r1"`, opts)
+	_, err = placementPolicyOptions(0, 0, "")
+	require.ErrorContains(t, err, "no placement option")
+
+	require.Equal(t, []string{
+		"CREATE PLACEMENT POLICY IF NOT EXISTS `playground` FOLLOWERS=2",
+		"ALTER PLACEMENT POLICY `playground` FOLLOWERS=2",
+		"ALTER DATABASE `test` PLACEMENT POLICY = `playground`",
+		"ALTER TABLE `app`.`t` PLACEMENT POLICY = `playground`",
+	}, placementStmts("playground", "FOLLOWERS=2", []sqlTarget{{DB: "test"}, {DB: "app", Table: "t"}}))
+}
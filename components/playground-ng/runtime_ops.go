@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
+)
+
+// runtimeOps are the clock and the process operations the runtime file checks
+// (daemon_files.go), the daemon starter, doctor and the controller run on.
+// The CLI state and the playground carry theirs, so tests can substitute fakes
+// to simulate elapsed time, stale pids, slow command servers and process
+// exits without sleeping or real processes.
+type runtimeOps struct {
+	clock clock
+	procs processOps
+}
+
+// newRuntimeOps returns the runtime ops of the real clock and processes.
+func newRuntimeOps() runtimeOps {
+	return runtimeOps{clock: realClock{}, procs: osProcessOps{}}
+}
+
+// clock is the time source of the runtime ops.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc runs f in its own goroutine after d; stop cancels it and
+	// reports whether it did.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// processOps are the operations of the runtime ops on the playground and
+// instance processes.
+type processOps interface {
+	// Running reports whether the process pid exists.
+	Running(pid int) (bool, error)
+	// Kill sends sig to the process group of pid, see killProcessOrGroup.
+	Kill(pid int, sig syscall.Signal) error
+	// Start spawns cmd.
+	Start(cmd *exec.Cmd) error
+	// Probe reports whether the command server listening on port answers.
+	Probe(ctx context.Context, port int) (bool, error)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (realClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+type osProcessOps struct{}
+
+func (osProcessOps) Running(pid int) (bool, error)          { return isPIDRunning(pid) }
+func (osProcessOps) Kill(pid int, sig syscall.Signal) error { return killProcessOrGroup(pid, sig) }
+func (osProcessOps) Start(cmd *exec.Cmd) error              { return cmd.Start() }
+
+func (osProcessOps) Probe(ctx context.Context, port int) (bool, error) {
+	return client.Probe(ctx, port)
+}
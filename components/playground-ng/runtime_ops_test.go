@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock only moves when told to. After advances the time by d right away,
+// so polling loops run without sleeping; AfterFunc timers fire on Advance.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.Advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		was := !t.stopped
+		t.stopped = true
+		return was
+	}
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	var due []func()
+	for _, t := range c.timers {
+		if !t.stopped && !t.at.After(c.now) {
+			t.stopped = true
+			due = append(due, t.f)
+		}
+	}
+	c.mu.Unlock()
+	for _, f := range due {
+		go f()
+	}
+}
+
+func (c *fakeClock) pendingTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.timers {
+		if !t.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+type fakeKill struct {
+	pid int
+	sig syscall.Signal
+}
+
+// fakeProcessOps simulates the processes by pid: a pid is running until it
+// gets SIGKILL, or SIGTERM if it is in quitOnTerm.
+type fakeProcessOps struct {
+	mu         sync.Mutex
+	running    map[int]bool
+	quitOnTerm map[int]bool
+	exited     map[int]chan struct{}
+	kills      []fakeKill
+	probe      func(ctx context.Context, port int) (bool, error)
+}
+
+func (o *fakeProcessOps) Running(pid int) (bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.running[pid], nil
+}
+
+func (o *fakeProcessOps) Kill(pid int, sig syscall.Signal) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.kills = append(o.kills, fakeKill{pid: pid, sig: sig})
+	if sig == syscall.SIGKILL || (sig == syscall.SIGTERM && o.quitOnTerm[pid]) {
+		if o.running[pid] {
+			o.running[pid] = false
+			if ch := o.exited[pid]; ch != nil {
+				close(ch)
+			}
+		}
+	}
+	return nil
+}
+
+func (o *fakeProcessOps) Start(cmd *exec.Cmd) error {
+	return syscall.ENOSYS
+}
+
+func (o *fakeProcessOps) Probe(ctx context.Context, port int) (bool, error) {
+	if o.probe == nil {
+		return false, syscall.ECONNREFUSED
+	}
+	return o.probe(ctx, port)
+}
+
+// spawn registers a running pid and returns a channel closed once it exits.
+func (o *fakeProcessOps) spawn(pid int) <-chan struct{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.running == nil {
+		o.running = make(map[int]bool)
+		o.exited = make(map[int]chan struct{})
+	}
+	o.running[pid] = true
+	o.exited[pid] = make(chan struct{})
+	return o.exited[pid]
+}
+
+func (o *fakeProcessOps) killsOf(pid int) []syscall.Signal {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var sigs []syscall.Signal
+	for _, k := range o.kills {
+		if k.pid == pid {
+			sigs = append(sigs, k.sig)
+		}
+	}
+	return sigs
+}
+
+// newFakeRuntime returns runtime ops on a fake clock and fake processes.
+func newFakeRuntime() (runtimeOps, *fakeClock, *fakeProcessOps) {
+	clk := &fakeClock{now: time.Now()}
+	ops := &fakeProcessOps{}
+	return runtimeOps{clock: clk, procs: ops}, clk, ops
+}
+
+func TestCleanupStaleRuntimeFiles_FakeStalePID(t *testing.T) {
+	rt, _, ops := newFakeRuntime()
+	base := t.TempDir()
+	pidPath := filepath.Join(base, playgroundPIDFileName)
+	portPath := filepath.Join(base, playgroundPortFileName)
+
+	ops.spawn(4242)
+	require.NoError(t, os.WriteFile(pidPath, []byte("pid=4242\n"), 0o644))
+	require.NoError(t, os.WriteFile(portPath, []byte("12345"), 0o644))
+	require.ErrorContains(t, rt.cleanupStaleRuntimeFiles(base), "already running (pid=4242)")
+
+	require.NoError(t, ops.Kill(4242, syscall.SIGKILL))
+	require.NoError(t, rt.cleanupStaleRuntimeFiles(base))
+	require.NoFileExists(t, pidPath)
+	require.NoFileExists(t, portPath)
+}
+
+func TestCleanupStaleRuntimeFiles_SlowProbeTreatedAsInUse(t *testing.T) {
+	rt, _, ops := newFakeRuntime()
+	base := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(base, playgroundPortFileName), []byte("12345"), 0o644))
+
+	ops.probe = func(ctx context.Context, port int) (bool, error) {
+		require.Equal(t, 12345, port)
+		return false, context.DeadlineExceeded
+	}
+	require.ErrorContains(t, rt.cleanupStaleRuntimeFiles(base), "probe timed out (port=12345)")
+	require.FileExists(t, filepath.Join(base, playgroundPortFileName))
+}
+
+func TestIsPlaygroundStopped_InvalidPIDGracePeriod(t *testing.T) {
+	rt, clk, _ := newFakeRuntime()
+	base := t.TempDir()
+	pidPath := filepath.Join(base, playgroundPIDFileName)
+	require.NoError(t, os.WriteFile(pidPath, []byte("pid="), 0o644))
+	fi, err := os.Stat(pidPath)
+	require.NoError(t, err)
+	clk.now = fi.ModTime()
+
+	// A partial pid file may still be being written.
+	require.False(t, rt.isPlaygroundStopped(base))
+	require.FileExists(t, pidPath)
+
+	clk.Advance(pidFileWriteGracePeriod)
+	require.True(t, rt.isPlaygroundStopped(base))
+	require.NoFileExists(t, pidPath)
+}
+
+func TestWaitPlayground_FakeClock(t *testing.T) {
+	rt, clk, ops := newFakeRuntime()
+	base := t.TempDir()
+	pid := 4242
+	ops.spawn(pid)
+	require.NoError(t, os.WriteFile(filepath.Join(base, playgroundPIDFileName), []byte("pid="+strconv.Itoa(pid)+"\n"), 0o644))
+
+	start := clk.Now()
+	require.ErrorContains(t, rt.waitPlayground(base, playgroundWaitStopped, time.Hour), "timeout")
+	require.GreaterOrEqual(t, clk.Now().Sub(start), time.Hour)
+
+	require.NoError(t, ops.Kill(pid, syscall.SIGKILL))
+	require.NoError(t, rt.waitPlayground(base, playgroundWaitStopped, time.Hour))
+	require.NoFileExists(t, filepath.Join(base, playgroundPIDFileName))
+}
+
+// exitOnKillOSProcess is a process whose Wait returns once the fake process
+// ops deliver the signal that ends it.
+type exitOnKillOSProcess struct {
+	stubOSProcess
+	exited <-chan struct{}
+}
+
+func (p *exitOnKillOSProcess) Wait() error {
+	<-p.exited
+	return nil
+}
+
+func TestTerminateGracefully_ForceKillsAfterTimeout(t *testing.T) {
+	rt, clk, ops := newFakeRuntime()
+	ops.quitOnTerm = map[int]bool{101: true}
+
+	record := func(pid int, service proc.ServiceID) procRecordSnapshot {
+		osProc := &exitOnKillOSProcess{
+			stubOSProcess: stubOSProcess{pid: pid, cmd: &exec.Cmd{Process: &os.Process{Pid: pid}}},
+			exited:        ops.spawn(pid),
+		}
+		inst := &stubProcess{info: &proc.ProcessInfo{Service: service, Proc: osProc}}
+		return procRecordSnapshot{ServiceID: service, Name: inst.Info().Name(), PID: pid, Inst: inst}
+	}
+	p := &Playground{stopFeed: newFeed[string](0), ops: rt}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.terminateGracefully([]procRecordSnapshot{record(101, proc.ServiceTiDB), record(102, proc.ServiceTiKV)})
+	}()
+
+	// tidb quits on SIGTERM; tikv ignores it until the force kill timer fires.
+	require.Eventually(t, func() bool {
+		running, _ := ops.Running(101)
+		return !running && clk.pendingTimers() == 1
+	}, 5*time.Second, time.Millisecond)
+	require.Equal(t, []syscall.Signal{syscall.SIGTERM}, ops.killsOf(102))
+
+	clk.Advance(forceKillAfterDuration - time.Second)
+	select {
+	case <-done:
+		t.Fatal("terminated before the force kill timeout")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clk.Advance(time.Second)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("force kill did not end the shutdown")
+	}
+	require.Equal(t, []syscall.Signal{syscall.SIGTERM}, ops.killsOf(101))
+	require.Equal(t, []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL}, ops.killsOf(102))
+}
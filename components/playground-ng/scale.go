@@ -6,6 +6,7 @@ import (
 	"io"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/components/playground-ng/proc"
@@ -155,6 +156,12 @@ func (p *Playground) sanitizeConfig(boot proc.Config, cfg *proc.Config) error {
 	if cfg.Host == "" {
 		cfg.Host = boot.Host
 	}
+	if cfg.DataDir == "" {
+		cfg.DataDir = boot.DataDir
+	}
+	if cfg.LogDir == "" {
+		cfg.LogDir = boot.LogDir
+	}
 
 	path, err := getAbsolutePath(cfg.ConfigPath)
 	if err != nil {
@@ -175,51 +182,116 @@ func (p *Playground) sanitizeServiceConfig(serviceID proc.ServiceID, cfg *proc.C
 	return p.sanitizeConfig(base, cfg)
 }
 
-func (p *Playground) handleScaleOut(state *controllerState, w io.Writer, req *ScaleOutRequest) error {
+func (p *Playground) handleScaleOut(ctx context.Context, state *controllerState, w io.Writer, req *ScaleOutRequest) (commandFollowUp, error) {
 	if p == nil {
-		return fmt.Errorf("playground is nil")
+		return nil, fmt.Errorf("playground is nil")
 	}
 	if req == nil {
-		return fmt.Errorf("missing scale_out request")
+		return nil, fmt.Errorf("missing scale_out request")
 	}
 	if state == nil {
-		return fmt.Errorf("playground controller state is nil")
+		return nil, fmt.Errorf("playground controller state is nil")
 	}
 	if req.Count <= 0 {
-		return fmt.Errorf("scale-out count must be greater than 0")
+		return nil, fmt.Errorf("scale-out count must be greater than 0")
+	}
+	wait, err := pgservice.ParseScaleOutWait(string(req.Wait))
+	if err != nil {
+		return nil, err
 	}
 
 	serviceID := req.ServiceID
 	if serviceID == "" {
-		return fmt.Errorf("missing scale-out service")
+		return nil, fmt.Errorf("missing scale-out service")
 	}
 
 	cfg := req.Config
 	cfg.Num = 0
 	if err := p.sanitizeServiceConfig(serviceID, &cfg); err != nil {
-		return err
+		return nil, err
 	}
 	spec, ok := pgservice.SpecFor(serviceID)
 	if !ok {
-		return fmt.Errorf("unknown service %s", serviceID)
+		return nil, fmt.Errorf("unknown service %s", serviceID)
 	}
 	if !spec.Catalog.AllowScaleOut {
-		return fmt.Errorf("service %q does not support scale-out", serviceID)
+		return nil, fmt.Errorf("service %q does not support scale-out", serviceID)
 	}
 
+	rt := controllerRuntime{pg: p, state: state}
+	var waits []func(ctx context.Context, w io.Writer) error
 	startCtx := context.WithValue(context.Background(), logprinter.ContextKeyLogger, log)
+
+	// Checkpoint the operation until it returns: only a killed playground
+	// leaves it behind (see resolveInterruptedOperation).
+	op := &operationCheckpoint{ScaleOut: req}
+	logIfErr(writeOperationCheckpoint(p.dataDir, op))
+	defer removeOperationCheckpoint(p.dataDir)
+	realDir := realDataDir(p.dataDir)
+
 	for i := 0; i < req.Count; i++ {
+		// Canceling the command stops it between instances. The started ones
+		// keep running: startCtx is not bound to the command as it owns them.
+		if err := ctx.Err(); err != nil {
+			rt.OnProcsChanged()
+			return nil, fmt.Errorf("scale-out canceled after %d of %d instance(s): %w", i, req.Count, err)
+		}
 		inst, err := p.addProcInController(state, serviceID, cfg)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		op.Created = append(op.Created, newInstanceRecord(serviceID, inst.Info(), p.dataDir, realDir))
+		logIfErr(writeOperationCheckpoint(p.dataDir, op))
 
 		if _, err := p.startProc(startCtx, state, inst); err != nil {
-			return err
+			return nil, err
 		}
 		spec.PostScaleOut(w, inst)
+		if waitFn := spec.WaitScaleOut(rt, inst, wait); waitFn != nil {
+			waits = append(waits, waitFn)
+		}
 	}
 
-	controllerRuntime{pg: p, state: state}.OnProcsChanged()
-	return nil
+	rt.OnProcsChanged()
+	if len(waits) == 0 {
+		return nil, nil
+	}
+	// Use the requested timeout rather than the one inherited from the boot
+	// config: the client bounds its own wait with it.
+	timeout := scaleOutWaitTimeout(req.Config)
+	return func(ctx context.Context, w io.Writer) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		for _, wait := range waits {
+			if err := wait(ctx, w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// resumeScaleOut runs again a scale-out that the previous playground on the
+// same data dir was killed in the middle of (--interrupted-op=forward). A
+// failure is reported but does not stop the booted playground.
+func (p *Playground) resumeScaleOut(ctx context.Context, req *ScaleOutRequest) {
+	out := p.terminalWriter()
+	fmt.Fprintf(out, "Resuming interrupted scale-out of %d %s instance(s)\n", req.Count, proc.ServiceDisplayName(req.ServiceID))
+	resp := p.doCommand(ctx, &Command{Type: ScaleOutCommandType, ScaleOut: req})
+	_, _ = out.Write(resp.output)
+	resp.topology.Print(out)
+	if resp.err != nil {
+		logprinter.Warnf("Resume interrupted scale-out: %v", resp.err)
+	}
+}
+
+// defaultScaleOutWaitTimeout bounds how long scale-out waits for new instances
+// to join the cluster when --timeout is not set.
+const defaultScaleOutWaitTimeout = 5 * time.Minute
+
+func scaleOutWaitTimeout(cfg proc.Config) time.Duration {
+	if cfg.UpTimeout > 0 {
+		return time.Duration(cfg.UpTimeout) * time.Second
+	}
+	return defaultScaleOutWaitTimeout
 }
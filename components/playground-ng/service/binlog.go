@@ -58,6 +58,7 @@ func init() {
 			DefaultNum:         func(_ BootContext) int { return 0 },
 			IsEnabled:          func(_ BootContext) bool { return true },
 			AllowScaleOut:      true,
+			Requires:           []proc.ServiceID{proc.ServicePump},
 		},
 		StartAfter: []proc.ServiceID{
 			proc.ServicePD,
@@ -103,6 +104,7 @@ func newPumpInstance(rt ControllerRuntime, params NewProcParams) (proc.Process,
 			UserBinPath:     params.Config.BinPath,
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			StatusPort:      shared.StatusPort,
@@ -177,6 +179,7 @@ func newDrainerInstance(rt ControllerRuntime, params NewProcParams) (proc.Proces
 			UserBinPath:     params.Config.BinPath,
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			StatusPort:      shared.StatusPort,
@@ -2,6 +2,7 @@ package service
 
 import (
 	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/tidbver"
 )
 
 func init() {
@@ -22,6 +23,7 @@ func init() {
 			DefaultNum:         func(_ BootContext) int { return 0 },
 			IsEnabled:          func(_ BootContext) bool { return true },
 			AllowScaleOut:      true,
+			Requires:           []proc.ServiceID{proc.ServiceTiKV},
 		},
 		StartAfter: []proc.ServiceID{
 			proc.ServicePD,
@@ -53,9 +55,11 @@ func init() {
 				{Name: proc.PortNamePort, Base: 8600},
 				{Name: proc.PortNameStatusPort, AliasOf: proc.PortNamePort},
 			},
-			DefaultNum:    func(_ BootContext) int { return 0 },
-			IsEnabled:     func(_ BootContext) bool { return true },
-			AllowScaleOut: true,
+			DefaultNum:      func(_ BootContext) int { return 0 },
+			IsEnabled:       func(_ BootContext) bool { return true },
+			AllowScaleOut:   true,
+			Requires:        []proc.ServiceID{proc.ServiceTiKV},
+			SupportsVersion: tidbver.TiKVCDCSupportDeploy,
 		},
 		StartAfter: []proc.ServiceID{
 			proc.ServicePD,
@@ -100,6 +104,7 @@ func newTiCDCInstance(rt ControllerRuntime, params NewProcParams) (proc.Process,
 			UserBinPath:     params.Config.BinPath,
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			StatusPort:      shared.StatusPort,
@@ -137,6 +142,7 @@ func newTiKVCDCInstance(rt ControllerRuntime, params NewProcParams) (proc.Proces
 			UserBinPath:     params.Config.BinPath,
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			StatusPort:      shared.StatusPort,
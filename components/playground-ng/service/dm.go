@@ -66,6 +66,7 @@ func init() {
 			DefaultNum:         func(_ BootContext) int { return 0 },
 			IsEnabled:          func(_ BootContext) bool { return true },
 			AllowScaleOut:      true,
+			Requires:           []proc.ServiceID{proc.ServiceDMMaster},
 		},
 		NewProc:     newDMWorkerInstance,
 		StartAfter:  []proc.ServiceID{proc.ServiceDMMaster},
@@ -137,6 +138,7 @@ func newDMMasterInstance(rt ControllerRuntime, params NewProcParams) (proc.Proce
 			UserBinPath:     params.Config.BinPath,
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			StatusPort:      shared.StatusPort,
@@ -227,6 +229,7 @@ func newDMWorkerInstance(rt ControllerRuntime, params NewProcParams) (proc.Proce
 			UserBinPath:     params.Config.BinPath,
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			ConfigPath:      params.Config.ConfigPath,
@@ -33,10 +33,12 @@ func init() {
 				return nil, err
 			}
 			prom := &proc.PrometheusInstance{
+				ShOpt: shOpt,
 				ProcessInfo: proc.ProcessInfo{
 					UserBinPath:     params.Config.BinPath,
 					ID:              params.ID,
 					Dir:             params.Dir,
+					LogDir:          params.LogDir,
 					Host:            shared.Host,
 					Port:            shared.Port,
 					StatusPort:      shared.StatusPort,
@@ -88,6 +90,7 @@ func init() {
 					UserBinPath:     params.Config.BinPath,
 					ID:              params.ID,
 					Dir:             params.Dir,
+					LogDir:          params.LogDir,
 					Host:            shared.Host,
 					Port:            shared.Port,
 					StatusPort:      shared.StatusPort,
@@ -167,11 +170,13 @@ func init() {
 			}
 
 			ngm := &proc.NGMonitoringInstance{
-				Plan: proc.NGMonitoringPlan{PDAddrs: pdAddrs},
+				ShOpt: shOpt,
+				Plan:  proc.NGMonitoringPlan{PDAddrs: pdAddrs},
 				ProcessInfo: proc.ProcessInfo{
 					UserBinPath:     params.Config.BinPath,
 					ID:              params.ID,
 					Dir:             params.Dir,
+					LogDir:          params.LogDir,
 					Host:            shared.Host,
 					Port:            shared.Port,
 					StatusPort:      shared.StatusPort,
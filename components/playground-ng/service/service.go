@@ -59,6 +59,7 @@ type NewProcParams struct {
 	Config proc.Config
 	ID     int
 	Dir    string
+	LogDir string
 	Host   string
 }
 
@@ -68,6 +69,42 @@ type ScaleInHookFunc func(rt ControllerRuntime, w io.Writer, inst proc.Process,
 // PostScaleOutFunc runs after a scale-out instance is started successfully.
 type PostScaleOutFunc func(w io.Writer, inst proc.Process)
 
+// ScaleOutWait selects what scale-out waits for once an instance is started,
+// beyond its own ready check.
+type ScaleOutWait string
+
+const (
+	// ScaleOutWaitNone returns as soon as the instance is started.
+	ScaleOutWaitNone ScaleOutWait = "none"
+	// ScaleOutWaitUp waits until the instance is registered and up in the
+	// cluster (e.g. a TiKV store is Up in PD).
+	ScaleOutWaitUp ScaleOutWait = "up"
+	// ScaleOutWaitBalance additionally waits until the cluster starts moving
+	// data to the instance (e.g. a TiKV store holds at least one region).
+	ScaleOutWaitBalance ScaleOutWait = "balance"
+)
+
+// ParseScaleOutWait validates a scale-out wait mode. An empty string means
+// ScaleOutWaitNone.
+func ParseScaleOutWait(s string) (ScaleOutWait, error) {
+	switch w := ScaleOutWait(strings.TrimSpace(s)); w {
+	case "":
+		return ScaleOutWaitNone, nil
+	case ScaleOutWaitNone, ScaleOutWaitUp, ScaleOutWaitBalance:
+		return w, nil
+	default:
+		return "", fmt.Errorf("invalid scale-out wait %q (supported: none, up, balance)", s)
+	}
+}
+
+// WaitScaleOutFunc is called by the controller after a scale-out instance is
+// started. It returns a func that waits for the instance according to wait,
+// or nil when there is nothing to wait for.
+//
+// The returned func runs outside the controller goroutine, so it must not use
+// rt; it reports progress to w.
+type WaitScaleOutFunc func(rt ControllerRuntime, inst proc.Process, wait ScaleOutWait) func(ctx context.Context, w io.Writer) error
+
 // PortAllocator allocates a port based on the given base port.
 //
 // It is used by the planner to keep port allocation deterministic (e.g. in
@@ -276,6 +313,14 @@ type Catalog struct {
 	// be started with a deterministic config.
 	PlanConfig func(ctx BootContext) proc.Config
 
+	// Requires lists services that must have at least one planned instance when
+	// this service has one; boot option validation fails otherwise.
+	Requires []proc.ServiceID
+	// SupportsVersion reports whether the service can run in a cluster of the
+	// given version. It is only consulted for release versions (not nightly),
+	// and boot option validation fails when it returns false.
+	SupportsVersion func(version string) bool
+
 	// IsCritical marks a service as "critical" for the current boot context.
 	//
 	// When true and the planned instance count is > 0, the controller will treat
@@ -314,6 +359,9 @@ type Spec struct {
 
 	// PostScaleOut is invoked after a scale-out instance is started successfully.
 	PostScaleOut PostScaleOutFunc
+	// WaitScaleOut is invoked after PostScaleOut to let scale-out wait until the
+	// instance is usable by the cluster.
+	WaitScaleOut WaitScaleOutFunc
 
 	// PlanInstance is invoked during planning for each instance of this service.
 	PlanInstance PlanInstanceFunc
@@ -380,6 +428,11 @@ func Register(spec Spec) error {
 	if spec.PostScaleOut == nil {
 		spec.PostScaleOut = func(w io.Writer, inst proc.Process) {}
 	}
+	if spec.WaitScaleOut == nil {
+		spec.WaitScaleOut = func(rt ControllerRuntime, inst proc.Process, wait ScaleOutWait) func(ctx context.Context, w io.Writer) error {
+			return nil
+		}
+	}
 	if _, ok := specs[spec.ServiceID]; ok {
 		return fmt.Errorf("duplicate service spec: %s", spec.ServiceID)
 	}
@@ -641,6 +694,32 @@ func pollUntil(rt Runtime, interval, timeout time.Duration, probe func() (done b
 	}
 }
 
+// waitUntil calls probe every interval until it reports done or ctx is done.
+// On ctx errors, the last probe error is included to tell why it never passed.
+func waitUntil(ctx context.Context, interval time.Duration, probe func() (done bool, err error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		done, err := probe()
+		if done {
+			return nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
 func pdClient(rt Runtime) (*api.PDClient, error) {
 	pds := ProcsOf[*proc.PDInstance](rt, proc.ServicePD, proc.ServicePDAPI)
 	if len(pds) == 0 {
@@ -650,8 +729,12 @@ func pdClient(rt Runtime) (*api.PDClient, error) {
 	for _, pd := range pds {
 		addrs = append(addrs, pd.Addr())
 	}
+	tlsCfg, err := pds[0].ShOpt.ClientTLSConfig()
+	if err != nil {
+		return nil, err
+	}
 	ctx := context.WithValue(context.Background(), logprinter.ContextKeyLogger, logprinter.NewLogger(""))
-	return api.NewPDClient(ctx, addrs, 10*time.Second, nil), nil
+	return api.NewPDClient(ctx, addrs, 10*time.Second, tlsCfg), nil
 }
 
 func binlogClient(rt Runtime) (*api.BinlogClient, error) {
@@ -1,6 +1,16 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/pingcap/tiup/components/playground-ng/proc"
@@ -257,3 +267,81 @@ func TestAllocPortsForNewProc_NormalizesAndValidatesHost(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "host is empty")
 }
+
+// pdRuntime is a ControllerRuntime that only knows about one PD instance.
+type pdRuntime struct {
+	ControllerRuntime
+	pd *proc.PDInstance
+}
+
+func (rt pdRuntime) Procs(serviceID proc.ServiceID) []proc.Process {
+	if serviceID == proc.ServicePD {
+		return []proc.Process{rt.pd}
+	}
+	return nil
+}
+
+func TestWaitScaleOutTiKV_WaitsForStoreUpAndRegions(t *testing.T) {
+	var storeRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/pd/api/v1/stores") {
+			http.NotFound(w, r)
+			return
+		}
+		// The store shows up on the second poll and gets a region on the third.
+		n := storeRequests.Add(1)
+		switch {
+		case n == 1:
+			fmt.Fprint(w, `{"count":0,"stores":[]}`)
+		case n == 2:
+			fmt.Fprint(w, `{"count":1,"stores":[{"store":{"id":1,"address":"127.0.0.1:20161"},"status":{"region_count":0}}]}`)
+		default:
+			fmt.Fprint(w, `{"count":1,"stores":[{"store":{"id":1,"address":"127.0.0.1:20161"},"status":{"region_count":3}}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	host, portStr, err := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+	rt := pdRuntime{pd: &proc.PDInstance{ProcessInfo: proc.ProcessInfo{Host: host, StatusPort: port, Service: proc.ServicePD}}}
+	kv := &proc.TiKVInstance{ProcessInfo: proc.ProcessInfo{Host: "127.0.0.1", Port: 20161, Service: proc.ServiceTiKV}}
+
+	require.Nil(t, waitScaleOutTiKV(rt, kv, ScaleOutWaitNone))
+
+	wait := waitScaleOutTiKV(rt, kv, ScaleOutWaitBalance)
+	require.NotNil(t, wait)
+	var out bytes.Buffer
+	require.NoError(t, wait(context.Background(), &out))
+	require.Equal(t, strings.Join([]string{
+		"waiting for TiKV store 127.0.0.1:20161 to be up in PD",
+		"TiKV store 127.0.0.1:20161 is up",
+		"waiting for PD to balance regions to TiKV store 127.0.0.1:20161",
+		"TiKV store 127.0.0.1:20161 serves 3 region(s)",
+		"",
+	}, "\n"), out.String())
+}
+
+func TestWaitScaleOutTiKV_ReportsLastErrorWhenCanceled(t *testing.T) {
+	rt := pdRuntime{pd: &proc.PDInstance{ProcessInfo: proc.ProcessInfo{Host: "127.0.0.1", StatusPort: 1, Service: proc.ServicePD}}}
+	kv := &proc.TiKVInstance{ProcessInfo: proc.ProcessInfo{Host: "127.0.0.1", Port: 20161, Service: proc.ServiceTiKV}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := waitScaleOutTiKV(rt, kv, ScaleOutWaitUp)(ctx, io.Discard)
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorContains(t, err, "TiKV store 127.0.0.1:20161 is not up")
+	require.ErrorContains(t, err, "last error")
+}
+
+func TestParseScaleOutWait(t *testing.T) {
+	w, err := ParseScaleOutWait("")
+	require.NoError(t, err)
+	require.Equal(t, ScaleOutWaitNone, w)
+	w, err = ParseScaleOutWait("balance")
+	require.NoError(t, err)
+	require.Equal(t, ScaleOutWaitBalance, w)
+	_, err = ParseScaleOutWait("regions")
+	require.ErrorContains(t, err, "supported: none, up, balance")
+}
@@ -173,6 +173,7 @@ func newTiDBInstance(rt ControllerRuntime, serviceID proc.ServiceID, params NewP
 			UserBinPath:     params.Config.BinPath,
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			StatusPort:      shared.StatusPort,
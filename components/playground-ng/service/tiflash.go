@@ -87,6 +87,7 @@ func init() {
 				return ctx.SharedOptions().Mode == proc.ModeNormal && hasTiDB(ctx)
 			},
 			AllowScaleOut: true,
+			Requires:      []proc.ServiceID{proc.ServiceTiKV},
 		},
 		StartAfter: startAfter,
 		NewProc: func(rt ControllerRuntime, params NewProcParams) (proc.Process, error) {
@@ -128,6 +129,7 @@ func init() {
 				}
 			},
 			AllowScaleOut: true,
+			Requires:      []proc.ServiceID{proc.ServiceTiKV},
 		},
 		StartAfter: startAfter,
 		NewProc: func(rt ControllerRuntime, params NewProcParams) (proc.Process, error) {
@@ -169,6 +171,7 @@ func init() {
 				}
 			},
 			AllowScaleOut: true,
+			Requires:      []proc.ServiceID{proc.ServiceTiKV, proc.ServiceTiFlashWrite},
 		},
 		StartAfter: startAfter,
 		NewProc: func(rt ControllerRuntime, params NewProcParams) (proc.Process, error) {
@@ -253,6 +256,7 @@ func newTiFlashInstance(rt ControllerRuntime, serviceID proc.ServiceID, params N
 			UserBinPath:     params.Config.BinPath,
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			StatusPort:      shared.StatusPort,
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
@@ -35,8 +36,9 @@ func init() {
 			proc.ServicePDAPI,
 			proc.ServicePDTSO,
 		},
-		NewProc:     newTiKVInstance,
-		ScaleInHook: scaleInTiKVByTombstone,
+		NewProc:      newTiKVInstance,
+		ScaleInHook:  scaleInTiKVByTombstone,
+		WaitScaleOut: waitScaleOutTiKV,
 		PlanInstance: func(_ BootContext, _ proc.Config, plan *proc.ServicePlan) error {
 			plan.ComponentID = proc.ComponentTiKV.String()
 			return nil
@@ -157,6 +159,7 @@ func newTiKVInstance(rt ControllerRuntime, params NewProcParams) (proc.Process,
 			UserBinPath:     params.Config.BinPath,
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			StatusPort:      shared.StatusPort,
@@ -202,6 +205,51 @@ func scaleInTiKVByTombstone(rt ControllerRuntime, w io.Writer, inst proc.Process
 	return true, nil
 }
 
+// waitScaleOutTiKV waits for a new TiKV store to be up in PD and, with
+// ScaleOutWaitBalance, to receive its first region, so that requests sent
+// right after scale-out do not hit "store not ready" errors.
+func waitScaleOutTiKV(rt ControllerRuntime, inst proc.Process, wait ScaleOutWait) func(ctx context.Context, w io.Writer) error {
+	kv, ok := inst.(*proc.TiKVInstance)
+	if !ok || wait == "" || wait == ScaleOutWaitNone {
+		return nil
+	}
+	c, err := pdClient(rt)
+	if err != nil {
+		return func(context.Context, io.Writer) error { return err }
+	}
+	addr := kv.StoreAddr()
+
+	return func(ctx context.Context, w io.Writer) error {
+		fmt.Fprintf(w, "waiting for TiKV store %s to be up in PD\n", addr)
+		if err := waitUntil(ctx, time.Second, func() (bool, error) {
+			return c.IsUp(addr)
+		}); err != nil {
+			return fmt.Errorf("TiKV store %s is not up: %w", addr, err)
+		}
+		fmt.Fprintf(w, "TiKV store %s is up\n", addr)
+		if wait != ScaleOutWaitBalance {
+			return nil
+		}
+
+		fmt.Fprintf(w, "waiting for PD to balance regions to TiKV store %s\n", addr)
+		regions := 0
+		if err := waitUntil(ctx, time.Second, func() (bool, error) {
+			store, err := c.GetCurrentStore(addr)
+			if err != nil {
+				return false, err
+			}
+			if store.Status != nil {
+				regions = store.Status.RegionCount
+			}
+			return regions > 0, nil
+		}); err != nil {
+			return fmt.Errorf("TiKV store %s received no region: %w", addr, err)
+		}
+		fmt.Fprintf(w, "TiKV store %s serves %d region(s)\n", addr, regions)
+		return nil
+	}
+}
+
 func newTiKVWorkerInstance(rt ControllerRuntime, params NewProcParams) (proc.Process, error) {
 	pds := ProcsOf[*proc.PDInstance](rt, proc.ServicePD, proc.ServicePDAPI)
 	shOpt := rt.SharedOptions()
@@ -231,6 +279,7 @@ func newTiKVWorkerInstance(rt ControllerRuntime, params NewProcParams) (proc.Pro
 			UserBinPath:     proc.ResolveTiKVWorkerBinPath(params.Config.BinPath),
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			ConfigPath:      params.Config.ConfigPath,
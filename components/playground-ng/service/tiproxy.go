@@ -6,6 +6,7 @@ import (
 	"net"
 
 	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/tidbver"
 )
 
 func init() {
@@ -29,6 +30,8 @@ func init() {
 			DefaultNum:         func(_ BootContext) int { return 0 },
 			IsEnabled:          func(_ BootContext) bool { return true },
 			AllowScaleOut:      true,
+			Requires:           []proc.ServiceID{proc.ServiceTiDB},
+			SupportsVersion:    tidbver.TiDBSupportTiproxy,
 		},
 		StartAfter: []proc.ServiceID{
 			proc.ServicePD,
@@ -83,6 +86,7 @@ func newTiProxyInstance(rt ControllerRuntime, params NewProcParams) (proc.Proces
 			UserBinPath:     params.Config.BinPath,
 			ID:              params.ID,
 			Dir:             params.Dir,
+			LogDir:          params.LogDir,
 			Host:            shared.Host,
 			Port:            shared.Port,
 			StatusPort:      shared.StatusPort,
@@ -2,6 +2,10 @@ package main
 
 import (
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"syscall"
@@ -59,17 +63,23 @@ func (p *Playground) startShutdownWithControllerState(state *controllerState, ca
 		}
 
 		// Freeze any in-progress boot UI so it stops redrawing while we terminate.
-		p.abandonActiveGroupsWithStartedRecords(procRecords)
+		// An interrupted boot first goes through this cancel phase; processes
+		// are only force killed on a second signal (or after
+		// forceKillAfterDuration).
+		canceledStart := p.abandonActiveGroupsWithStartedRecords(procRecords) && cause == stopCauseSignal
 
 		if cause == stopCauseSignal && sig != 0 {
-			printInterrupt(p.ui, sig)
+			printInterrupt(p.ui, sig, canceledStart)
 		}
 
 		p.progressMu.Lock()
 		ui := p.ui
 		if ui != nil && p.shutdownGroup == nil {
 			title := "Shutdown"
-			if cause == stopCauseSignal {
+			switch {
+			case canceledStart:
+				title = "Cancel start"
+			case cause == stopCauseSignal:
 				title = "Shutdown gracefully"
 			}
 			p.shutdownGroup = ui.Group(title)
@@ -80,6 +90,7 @@ func (p *Playground) startShutdownWithControllerState(state *controllerState, ca
 
 		go func() {
 			defer p.terminateDoneOnce.Do(func() {
+				p.stopFeed.close()
 				close(p.terminateDoneCh)
 			})
 			p.terminateGracefully(p.shutdownProcRecords)
@@ -314,6 +325,10 @@ func (p *Playground) terminateGracefully(records []procRecordSnapshot) {
 		}
 	}
 
+	if len(targets) > 0 {
+		p.stopFeed.publish(fmt.Sprintf("Stopping %d instance(s)...\n", len(targets)))
+	}
+
 	// Send stop signals first (in dependency-derived order) so fast-exiting
 	// processes can quit early even if some components take longer.
 	for i := range targets {
@@ -327,7 +342,7 @@ func (p *Playground) terminateGracefully(records []procRecordSnapshot) {
 			task.Start()
 		}
 
-		_ = killProcessOrGroup(t.pid, syscall.SIGTERM)
+		_ = p.ops.procs.Kill(t.pid, syscall.SIGTERM)
 	}
 
 	var wg sync.WaitGroup
@@ -336,17 +351,18 @@ func (p *Playground) terminateGracefully(records []procRecordSnapshot) {
 		go func(t shutdownTarget) {
 			defer wg.Done()
 
-			timer := time.AfterFunc(forceKillAfterDuration, func() {
-				_ = killProcessOrGroup(t.pid, syscall.SIGKILL)
+			stopForceKill := p.ops.clock.AfterFunc(forceKillAfterDuration, func() {
+				_ = p.ops.procs.Kill(t.pid, syscall.SIGKILL)
 			})
 			// On shutdown, process may exit with non-zero due to the signal.
 			// Consider it a successful shutdown once it quits.
 			_ = t.wait()
-			timer.Stop()
+			stopForceKill()
 
 			if shutdownGroup != nil && t.task != nil {
 				t.task.Done()
 			}
+			p.stopFeed.publish(fmt.Sprintf("Stopped %s (pid=%d)\n", t.title, t.pid))
 		}(t)
 	}
 	wg.Wait()
@@ -380,10 +396,61 @@ func (p *Playground) terminateForceKill(records []procRecordSnapshot) {
 		if pid <= 0 {
 			continue
 		}
-		_ = killProcessOrGroup(pid, syscall.SIGKILL)
+		_ = p.ops.procs.Kill(pid, syscall.SIGKILL)
 	}
 }
 
+// crashExitCode is the exit code of a playground that crashed, as for an
+// unrecovered panic.
+const crashExitCode = 2
+
+// recoverCrash is deferred by the goroutines whose panic would leave a
+// half-dead playground behind (the controller, the command server handlers).
+// It cleans up with crash and exits.
+func (p *Playground) recoverCrash(where string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if r == http.ErrAbortHandler {
+		// Not a crash: a handler aborting its reply, see http.ErrAbortHandler.
+		panic(r)
+	}
+	p.crash(where, r, debug.Stack())
+	os.Exit(crashExitCode)
+}
+
+// crash is the last-resort cleanup of a panic: it logs the stack (to
+// daemon.log in daemon mode), fails the tasks in progress so that the event
+// log doesn't end with tasks running forever, kills the instances and removes
+// the runtime files, so the playground is seen stopped rather than hanging.
+func (p *Playground) crash(where string, r any, stack []byte) {
+	fmt.Fprintf(os.Stderr, "playground-ng: panic in %s: %v\n%s\n", where, r, stack)
+	if p == nil {
+		return
+	}
+	p.crashOnce.Do(func() {
+		if p.ui != nil {
+			p.ui.Abort(fmt.Sprintf("playground crashed: %v", r))
+			_ = p.ui.Close()
+		}
+
+		// The controller state may be the one that panicked: rely on the
+		// instance registry instead.
+		for _, rec := range readInstanceRegistry(p.dataDir) {
+			if rec.PID > 0 && matchesInstanceRecord(rec) {
+				_ = p.ops.procs.Kill(rec.PID, syscall.SIGKILL)
+			}
+		}
+
+		if p.dataDir != "" {
+			for _, name := range []string{playgroundPIDFileName, playgroundPortFileName, playgroundReadyFileName} {
+				_ = os.Remove(filepath.Join(p.dataDir, name))
+			}
+		}
+	})
+}
+
 func (p *Playground) interrupted() bool {
 	if p == nil {
 		return false
@@ -399,3 +466,66 @@ func (p *Playground) interrupted() bool {
 		return false
 	}
 }
+
+// feed is an append-only log that readers follow from any position, e.g.
+// the termination progress followed by each "stop" client. With a limit, only
+// the last limit items are kept: readers lagging behind skip the others.
+type feed[T any] struct {
+	mu    sync.Mutex
+	limit int
+	// base is the position of items[0].
+	base    int
+	items   []T
+	done    bool
+	changed chan struct{}
+}
+
+func newFeed[T any](limit int) *feed[T] {
+	return &feed[T]{limit: limit, changed: make(chan struct{})}
+}
+
+func (f *feed[T]) publish(item T) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done {
+		return
+	}
+	f.items = append(f.items, item)
+	if f.limit > 0 && len(f.items) > f.limit {
+		drop := len(f.items) - f.limit
+		f.items = slices.Clone(f.items[drop:])
+		f.base += drop
+	}
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// close marks the feed as completed.
+func (f *feed[T]) close() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done {
+		return
+	}
+	f.done = true
+	close(f.changed)
+}
+
+// next returns the items published from position from on, the position
+// following them, whether the feed is completed, and a channel closed on the
+// next change.
+func (f *feed[T]) next(from int) (items []T, to int, done bool, changed <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	from = min(max(from, f.base), f.base+len(f.items))
+	if i := from - f.base; i < len(f.items) {
+		items = slices.Clone(f.items[i:])
+	}
+	return items, from + len(items), f.done, f.changed
+}
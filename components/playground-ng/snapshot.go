@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// playgroundSnapshotsDirName holds the snapshots of a playground, one dir
+	// per snapshot named after its ID.
+	playgroundSnapshotsDirName = "snapshots"
+	snapshotMetaFileName       = "snapshot.json"
+	snapshotIDLayout           = "20060102-150405"
+)
+
+// playgroundSnapshot describes a copy of the instance dirs of a playground,
+// stored as <data dir>/snapshots/<id>/<instance name>.
+type playgroundSnapshot struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Auto is set for the snapshots taken by --snapshot-every, which are the
+	// only ones pruned by --snapshot-keep.
+	Auto bool  `json:"auto,omitempty"`
+	Size int64 `json:"size"`
+	// DataDir is the data dir at snapshot time: the instance dirs under it
+	// are restored under the current one, even if the playground was retagged.
+	DataDir   string           `json:"data_dir"`
+	Instances []instanceRecord `json:"instances"`
+}
+
+func snapshotsDir(dataDir string) string {
+	return filepath.Join(realDataDir(dataDir), playgroundSnapshotsDirName)
+}
+
+// createSnapshot copies the dirs of instances to a new snapshot of the
+// playground in dataDir. The snapshot only appears once complete.
+func createSnapshot(dataDir string, instances []instanceRecord, auto bool, now time.Time) (*playgroundSnapshot, error) {
+	id := now.Format(snapshotIDLayout)
+	final := filepath.Join(snapshotsDir(dataDir), id)
+	if utils.IsExist(final) {
+		return nil, fmt.Errorf("snapshot %s already exists", id)
+	}
+	tmp := final + ".tmp"
+	_ = os.RemoveAll(tmp)
+
+	snap := &playgroundSnapshot{ID: id, CreatedAt: now.UTC(), Auto: auto, DataDir: realDataDir(dataDir), Instances: instances}
+	for _, inst := range instances {
+		n, err := copyDataTree(inst.Dir, filepath.Join(tmp, inst.Name), nil)
+		if err != nil {
+			_ = os.RemoveAll(tmp)
+			return nil, errors.Annotatef(err, "snapshot %s", inst.Name)
+		}
+		snap.Size += n
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err == nil {
+		err = utils.WriteFile(filepath.Join(tmp, snapshotMetaFileName), append(data, '\n'), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, final)
+	}
+	if err != nil {
+		_ = os.RemoveAll(tmp)
+		return nil, errors.AddStack(err)
+	}
+	return snap, nil
+}
+
+// listSnapshots returns the snapshots of the playground in dataDir, oldest
+// first.
+func listSnapshots(dataDir string) ([]playgroundSnapshot, error) {
+	entries, err := os.ReadDir(snapshotsDir(dataDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	var out []playgroundSnapshot
+	for _, ent := range entries {
+		if !ent.IsDir() || strings.HasSuffix(ent.Name(), ".tmp") {
+			continue
+		}
+		snap, err := loadSnapshot(dataDir, ent.Name())
+		if err != nil {
+			continue
+		}
+		out = append(out, *snap)
+	}
+	slices.SortFunc(out, func(a, b playgroundSnapshot) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return out, nil
+}
+
+func loadSnapshot(dataDir, id string) (*playgroundSnapshot, error) {
+	if id == "" || id != filepath.Base(id) || id == "." || id == ".." {
+		return nil, fmt.Errorf("invalid snapshot ID %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(snapshotsDir(dataDir), id, snapshotMetaFileName))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot %s not found", id)
+	}
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	var snap playgroundSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, errors.Annotatef(err, "parse snapshot %s", id)
+	}
+	return &snap, nil
+}
+
+// pruneAutoSnapshots removes the oldest automatic snapshots beyond the keep
+// most recent ones, and returns their IDs.
+func pruneAutoSnapshots(dataDir string, keep int) ([]string, error) {
+	snaps, err := listSnapshots(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	snaps = slices.DeleteFunc(snaps, func(s playgroundSnapshot) bool { return !s.Auto })
+	var removed []string
+	for len(snaps) > keep {
+		if err := os.RemoveAll(filepath.Join(snapshotsDir(dataDir), snaps[0].ID)); err != nil {
+			return removed, errors.AddStack(err)
+		}
+		removed = append(removed, snaps[0].ID)
+		snaps = snaps[1:]
+	}
+	return removed, nil
+}
+
+// restoreSnapshot replaces the instance dirs of the stopped playground in
+// dataDir with those of snapshot id. Instance dirs created since the snapshot
+// are removed: their data doesn't match the restored cluster.
+func restoreSnapshot(dataDir, id string) (*playgroundSnapshot, error) {
+	snap, err := loadSnapshot(dataDir, id)
+	if err != nil {
+		return nil, err
+	}
+	realDir := realDataDir(dataDir)
+	restored := make(map[string]bool, len(snap.Instances))
+	for _, inst := range snap.Instances {
+		restored[inst.Name] = true
+	}
+	for _, r := range readInstanceRegistry(dataDir) {
+		if !restored[r.Name] && r.Dir != "" {
+			if err := os.RemoveAll(r.Dir); err != nil {
+				return nil, errors.AddStack(err)
+			}
+		}
+	}
+	for _, inst := range snap.Instances {
+		dir := rebaseDir(inst.Dir, snap.DataDir, realDir)
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, errors.AddStack(err)
+		}
+		if _, err := copyDataTree(filepath.Join(snapshotsDir(dataDir), id, inst.Name), dir, nil); err != nil {
+			return nil, errors.Annotatef(err, "restore %s", inst.Name)
+		}
+	}
+	return snap, nil
+}
+
+// handleSnapshot runs in the controller goroutine, so no scale command
+// changes the instances while they are copied. The instances are frozen
+// meanwhile: the snapshot is what a crash at that instant would leave.
+func (p *Playground) handleSnapshot(state *controllerState, w io.Writer, req *SnapshotRequest) error {
+	if req == nil {
+		req = &SnapshotRequest{}
+	}
+	if p.dataDir == "" {
+		return fmt.Errorf("playground data dir is unknown")
+	}
+
+	realDir := realDataDir(p.dataDir)
+	var instances []instanceRecord
+	var pids []int
+	_ = state.walkProcs(func(serviceID proc.ServiceID, inst proc.Process) error {
+		info := inst.Info()
+		if info == nil || info.Dir == "" {
+			return nil
+		}
+		instances = append(instances, instanceRecord{
+			Name:    filepath.Base(info.Dir),
+			Service: serviceID.String(),
+			Dir:     rebaseDir(info.Dir, p.dataDir, realDir),
+		})
+		if info.Proc != nil && info.Proc.Pid() > 0 {
+			pids = append(pids, info.Proc.Pid())
+		}
+		return nil
+	})
+	if len(instances) == 0 {
+		return fmt.Errorf("no instance to snapshot")
+	}
+
+	for _, pid := range pids {
+		logIfErr(freezeProcessOrGroup(pid))
+	}
+	snap, err := createSnapshot(p.dataDir, instances, req.Auto, time.Now())
+	for _, pid := range pids {
+		logIfErr(thawProcessOrGroup(pid))
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Snapshot %s created: %d instances, %s\n", snap.ID, len(snap.Instances), units.BytesSize(float64(snap.Size)))
+
+	if req.Auto && req.Keep > 0 {
+		removed, err := pruneAutoSnapshots(p.dataDir, req.Keep)
+		for _, id := range removed {
+			fmt.Fprintf(w, "Snapshot %s removed\n", id)
+		}
+		return err
+	}
+	return nil
+}
+
+// startSnapshotScheduler takes a snapshot every interval (--snapshot-every)
+// until the playground stops, keeping the keep most recent ones.
+func (p *Playground) startSnapshotScheduler(every time.Duration, keep int) {
+	if p == nil || every <= 0 || p.processGroup == nil {
+		return
+	}
+	_ = p.processGroup.Add("snapshot scheduler", func() error {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.processGroup.Closed():
+				return nil
+			case <-ticker.C:
+			}
+			resp := p.doCommand(context.Background(), &Command{
+				Type:     SnapshotCommandType,
+				Snapshot: &SnapshotRequest{Auto: true, Keep: keep},
+			})
+			out := p.terminalWriter()
+			if resp.err != nil {
+				fmt.Fprintf(out, "Auto snapshot failed: %s\n", resp.err)
+				continue
+			}
+			_, _ = out.Write(resp.output)
+		}
+	})
+}
+
+func newSnapshot(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Take, list and restore snapshots of the playground data",
+		Long: `Snapshots are copies of the instance dirs of a playground, stored in its
+data dir. A running playground takes them with 'snapshot create', or every
+interval when started with --snapshot-every; its instances are frozen while
+they are copied.
+
+Restore a snapshot on a stopped playground, then start it again with the
+same tag.`,
+		Example: fmt.Sprintf("%[1]s --tag my-cluster --snapshot-every 30m --snapshot-keep 5\n%[1]s snapshot list --tag my-cluster\n%[1]s stop --tag my-cluster\n%[1]s snapshot restore --tag my-cluster 20260102-150405", arg0),
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "create",
+		Short: "Take a snapshot of a running playground",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sendQueueCommand(cmd.OutOrStdout(), Command{Type: SnapshotCommandType, Snapshot: &SnapshotRequest{}}, state)
+		},
+	})
+
+	var jsonOut bool
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the snapshots of a playground",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dataDir, err := snapshotTargetDir(state)
+			if err != nil {
+				return err
+			}
+			snaps, err := listSnapshots(dataDir)
+			if err != nil {
+				return err
+			}
+			return writeSnapshots(cmd.OutOrStdout(), snaps, jsonOut)
+		},
+	}
+	list.Flags().BoolVar(&jsonOut, "json", false, "Output in JSON format")
+	cmd.AddCommand(list)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "restore <id>",
+		Short: "Restore a snapshot of a stopped playground",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if state.tag == "" && state.tiupDataDir == "" {
+				return fmt.Errorf("specify the tag of the playground to restore with --tag")
+			}
+			if !state.ops.isPlaygroundStopped(state.dataDir) {
+				return fmt.Errorf("playground %q is running, stop it first: %s", state.tag, playgroundCLICommand("stop --tag "+state.tag))
+			}
+			snap, err := restoreSnapshot(state.dataDir, args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Snapshot %s restored, start the playground with --tag %s\n", snap.ID, state.tag)
+			return nil
+		},
+	})
+	return cmd
+}
+
+// snapshotTargetDir returns the data dir of the playground selected by --tag,
+// or of the only running one.
+func snapshotTargetDir(state *cliState) (string, error) {
+	if state.tag != "" || state.tiupDataDir != "" {
+		return state.dataDir, nil
+	}
+	target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+	if err != nil {
+		return "", err
+	}
+	return target.dir, nil
+}
+
+func writeSnapshots(out io.Writer, snaps []playgroundSnapshot, jsonOut bool) error {
+	if jsonOut {
+		if snaps == nil {
+			snaps = []playgroundSnapshot{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snaps)
+	}
+	if len(snaps) == 0 {
+		fmt.Fprintln(out, "No snapshots.")
+		return nil
+	}
+	td := utils.NewTableDisplayer(out, []string{"ID", "CREATED", "KIND", "INSTANCES", "SIZE"})
+	for _, s := range snaps {
+		kind := "manual"
+		if s.Auto {
+			kind = "auto"
+		}
+		td.AddRow(s.ID, s.CreatedAt.Local().Format(time.DateTime), kind, strconv.Itoa(len(s.Instances)), units.BytesSize(float64(s.Size)))
+	}
+	td.Display()
+	return nil
+}
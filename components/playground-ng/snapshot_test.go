@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_CreateListRestore(t *testing.T) {
+	dataDir := t.TempDir()
+	pdDir := filepath.Join(dataDir, "pd-0")
+	kvDir := filepath.Join(dataDir, "tikv-0")
+	for path, content := range map[string]string{
+		filepath.Join(pdDir, "data", "member"): "v1",
+		filepath.Join(kvDir, "db", "CURRENT"):  "v1",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	instances := []instanceRecord{
+		{Name: "pd-0", Service: "pd", Dir: pdDir},
+		{Name: "tikv-0", Service: "tikv", Dir: kvDir},
+	}
+
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local)
+	snap, err := createSnapshot(dataDir, instances, false, now)
+	require.NoError(t, err)
+	require.Equal(t, "20260102-150405", snap.ID)
+	require.Equal(t, int64(4), snap.Size)
+	_, err = createSnapshot(dataDir, instances, false, now)
+	require.ErrorContains(t, err, "already exists")
+
+	// A scale-out after the snapshot, and changed data.
+	kv1Dir := filepath.Join(dataDir, "tikv-1")
+	require.NoError(t, os.MkdirAll(kv1Dir, 0o755))
+	require.NoError(t, writeInstanceRecords(dataDir, append(instances, instanceRecord{Name: "tikv-1", Service: "tikv", Dir: kv1Dir})))
+	require.NoError(t, os.WriteFile(filepath.Join(kvDir, "db", "CURRENT"), []byte("v2"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(kvDir, "db", "000002.log"), []byte("wal"), 0o644))
+
+	snaps, err := listSnapshots(dataDir)
+	require.NoError(t, err)
+	require.Len(t, snaps, 1)
+	require.Equal(t, snap.ID, snaps[0].ID)
+
+	_, err = restoreSnapshot(dataDir, snap.ID)
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(kvDir, "db", "CURRENT"))
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(data))
+	require.NoFileExists(t, filepath.Join(kvDir, "db", "000002.log"))
+	require.NoDirExists(t, kv1Dir)
+
+	_, err = restoreSnapshot(dataDir, "../x")
+	require.ErrorContains(t, err, "invalid snapshot ID")
+}
+
+func TestPruneAutoSnapshots_KeepsManualOnes(t *testing.T) {
+	dataDir := t.TempDir()
+	instDir := filepath.Join(dataDir, "pd-0")
+	require.NoError(t, os.MkdirAll(instDir, 0o755))
+	instances := []instanceRecord{{Name: "pd-0", Service: "pd", Dir: instDir}}
+
+	start := time.Date(2026, 1, 2, 15, 0, 0, 0, time.Local)
+	for i := range 4 {
+		_, err := createSnapshot(dataDir, instances, i != 1, start.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+	}
+
+	removed, err := pruneAutoSnapshots(dataDir, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"20260102-150000"}, removed)
+
+	snaps, err := listSnapshots(dataDir)
+	require.NoError(t, err)
+	var ids []string
+	for _, s := range snaps {
+		ids = append(ids, s.ID)
+	}
+	require.Equal(t, []string{"20260102-150100", "20260102-150200", "20260102-150300"}, ids)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 
 type progressTask interface {
 	SetMeta(meta string)
+	SetMessage(msg string)
 	Start()
 	Done()
 	Error(msg string)
@@ -28,6 +30,10 @@ type hideIfFastProgressTask interface {
 	SetHideIfFast(revealAfter time.Duration)
 }
 
+type childProgressTask interface {
+	Child(title string) *progressv2.Task
+}
+
 type trackedProgressTask struct {
 	inner progressTask
 
@@ -67,6 +73,13 @@ func (t *trackedProgressTask) SetMeta(meta string) {
 	t.inner.SetMeta(meta)
 }
 
+func (t *trackedProgressTask) SetMessage(msg string) {
+	if t == nil || t.inner == nil {
+		return
+	}
+	t.inner.SetMessage(msg)
+}
+
 func (t *trackedProgressTask) Start() {
 	if t == nil || t.inner == nil {
 		return
@@ -108,8 +121,22 @@ func (t *trackedProgressTask) SetHideIfFast(revealAfter time.Duration) {
 	}
 }
 
+func (t *trackedProgressTask) Child(title string) *progressv2.Task {
+	if t == nil || t.inner == nil {
+		return nil
+	}
+	if inner, ok := t.inner.(childProgressTask); ok && inner != nil {
+		return inner.Child(title)
+	}
+	return nil
+}
+
 const hideInProgressRevealAfterStart = 5 * time.Second
 
+// renderConfigRevealAfter hides the config-render sub-task of an instance
+// unless rendering is slow (e.g. on a cold, slow disk).
+const renderConfigRevealAfter = 500 * time.Millisecond
+
 func applyHideInProgressPolicy(task progressTask, serviceID proc.ServiceID, revealAfter time.Duration) {
 	if task == nil || serviceID == "" {
 		return
@@ -135,19 +162,76 @@ var taskStartedOKCh = func() <-chan struct{} {
 	return ch
 }()
 
-func startProgressTask(task progressTask, meta string) <-chan struct{} {
-	if task == nil {
-		return taskStartedOKCh
+// taskUpdates applies updates to a progress task in order, without blocking
+// the caller on progress rendering.
+type taskUpdates struct {
+	task progressTask
+
+	mu   sync.Mutex
+	last <-chan struct{} // closed once the previous update is applied
+}
+
+// then runs fn after all previously queued updates.
+func (u *taskUpdates) then(fn func()) {
+	if u == nil || u.task == nil {
+		return
 	}
-	started := make(chan struct{})
+	done := make(chan struct{})
+	u.mu.Lock()
+	prev := u.last
+	u.last = done
+	u.mu.Unlock()
 	go func() {
+		<-prev
+		fn()
+		close(done)
+	}()
+}
+
+// setPhase shows the current start phase of the instance (e.g. "spawning")
+// as the task message.
+func (u *taskUpdates) setPhase(phase string) {
+	u.then(func() { u.task.SetMessage(phase) })
+}
+
+// subtask queues a sub-task of the task, shown in TTY mode only if it runs for
+// at least revealAfter, and returns the func finishing it with err.
+func (u *taskUpdates) subtask(title string, revealAfter time.Duration) (finish func(err error)) {
+	var sub *progressv2.Task
+	u.then(func() {
+		if parent, ok := u.task.(childProgressTask); ok {
+			sub = parent.Child(title)
+			sub.SetHideIfFast(revealAfter)
+		}
+	})
+	return func(err error) {
+		u.then(func() {
+			if err != nil {
+				sub.Error(err.Error())
+				return
+			}
+			sub.Done()
+		})
+	}
+}
+
+// done clears the phase and marks the task as done.
+func (u *taskUpdates) done() {
+	u.then(func() {
+		u.task.SetMessage("")
+		u.task.Done()
+	})
+}
+
+func startProgressTask(task progressTask, meta string) *taskUpdates {
+	u := &taskUpdates{task: task, last: taskStartedOKCh}
+	u.then(func() {
 		if meta != "" {
 			task.SetMeta(meta)
 		}
 		task.Start()
-		close(started)
-	}()
-	return started
+	})
+	return u
 }
 
 // initBootStartingTasks pre-creates one pending task per planned process so the
@@ -252,31 +336,36 @@ func (p *Playground) closeStartingGroup() {
 // After calling it, the abandoned groups will no longer be updated or redrawn.
 // This is primarily used when the user interrupts booting (Ctrl+C) so shutdown
 // can be rendered in a separate group without interleaving progress output.
-func (p *Playground) abandonActiveGroups() {
-	p.abandonActiveGroupsWithStartedRecords(nil)
+//
+// It reports whether a boot was in progress.
+func (p *Playground) abandonActiveGroups() bool {
+	return p.abandonActiveGroupsWithStartedRecords(nil)
 }
 
-func (p *Playground) abandonActiveGroupsWithStartedRecords(procRecords []procRecordSnapshot) {
+func (p *Playground) abandonActiveGroupsWithStartedRecords(procRecords []procRecordSnapshot) bool {
 	if p == nil {
-		return
+		return false
 	}
 
 	p.progressMu.Lock()
 	startingGroup := p.startingGroup
 	startingTasks := p.startingTasks
 	downloadGroup := p.downloadGroup
+	downloadProgress := p.downloadProgress
 
 	p.startingGroup = nil
 	p.startingTasks = nil
 	p.downloadGroup = nil
 	p.progressMu.Unlock()
 
-	// Mark instances that have already been started as canceled, while keeping
-	// not-yet-started ones (e.g. TiFlash) as pending in the snapshot.
+	// Cancel what the boot left unfinished, so the snapshot doesn't show
+	// spinners forever: started instances are stopped by the shutdown, the
+	// others never start. Tasks that already finished keep their status.
 	if startingTasks != nil {
 		if len(procRecords) == 0 {
 			procRecords = p.procRecordsSnapshot()
 		}
+		started := make(map[string]bool, len(procRecords))
 		for _, rec := range procRecords {
 			name := rec.Name
 			if name == "" && rec.Inst != nil {
@@ -284,14 +373,21 @@ func (p *Playground) abandonActiveGroupsWithStartedRecords(procRecords []procRec
 					name = info.Name()
 				}
 			}
-			if name == "" {
-				continue
+			if name != "" {
+				started[name] = true
 			}
-			if t := startingTasks[name]; t != nil {
+		}
+		for name, t := range startingTasks {
+			if started[name] {
 				t.Cancel("")
+			} else {
+				t.Cancel("not started")
 			}
 		}
 	}
+	if downloadGroup != nil {
+		downloadProgress.CancelRemaining()
+	}
 
 	// Preserve workflow order in the history output: download, then start.
 	if downloadGroup != nil {
@@ -300,6 +396,7 @@ func (p *Playground) abandonActiveGroupsWithStartedRecords(procRecords []procRec
 	if startingGroup != nil {
 		startingGroup.Seal()
 	}
+	return startingGroup != nil || downloadGroup != nil
 }
 
 func (p *Playground) getOrCreateStartingTask(inst proc.Process) progressTask {
@@ -479,7 +576,7 @@ func (p *Playground) startProc(ctx context.Context, state *controllerState, inst
 			constraint = utils.LatestVersionAlias
 		}
 
-		v, err := environment.GlobalEnv().V1Repository().ResolveComponentVersion(component, constraint)
+		v, err := resolveComponentVersion(environment.GlobalEnv().V1Repository(), component, constraint)
 		if err != nil {
 			p.markStartingTaskError(inst, constraint, err)
 			return nil, err
@@ -523,34 +620,46 @@ func (p *Playground) startProcWithControllerState(ctx context.Context, state *co
 	}
 	// Do not block process startup on progress rendering (e.g. heavy download
 	// progress callbacks). UI updates are best-effort.
-	taskStarted := startProgressTask(task, meta)
-
-	if err := inst.Prepare(ctx); err != nil {
-		p.markStartingTaskError(inst, "", err)
+	updates := startProgressTask(task, meta)
+	fail := func(err error) (<-chan error, error) {
+		// Queue the error after the phases so none of them overwrites it.
+		if task == nil {
+			p.markStartingTaskError(inst, "", err)
+		} else {
+			updates.then(func() { p.markStartingTaskError(inst, "", err) })
+		}
 		return nil, err
 	}
 
-	proc := info.Proc
-	if proc == nil {
-		err := fmt.Errorf("process not prepared for %s", info.Name())
-		p.markStartingTaskError(inst, "", err)
-		return nil, err
+	if err := p.latency.inject(info); err != nil {
+		return fail(err)
+	}
+	renderDone := updates.subtask("Render config", renderConfigRevealAfter)
+	err = inst.Prepare(ctx)
+	renderDone(err)
+	if err != nil {
+		return fail(err)
 	}
+	updates.setPhase("spawning")
 
-	if err := proc.SetOutputFile(inst.LogFile()); err != nil {
-		p.markStartingTaskError(inst, "", err)
-		return nil, err
+	osProc := info.Proc
+	if osProc == nil {
+		return fail(fmt.Errorf("process not prepared for %s", info.Name()))
 	}
 
-	if err := proc.Start(); err != nil {
-		p.markStartingTaskError(inst, "", err)
-		return nil, err
+	if err := osProc.SetOutputFile(info.LogPath(proc.OutputFileName)); err != nil {
+		return fail(err)
+	}
+
+	p.latency.release(info)
+	if err := osProc.Start(p.ops.procs.Start); err != nil {
+		return fail(err)
 	}
 
 	p.handleProcStarted(state, inst)
 
 	exitCh := p.addWaitProc(inst)
-	readyCh = p.startReadyCheck(ctx, inst, task, taskStarted, exitCh)
+	readyCh = p.startReadyCheck(ctx, inst, updates, exitCh)
 	return readyCh, nil
 }
 
@@ -638,7 +747,7 @@ func binaryExists(binPath string) bool {
 	return !st.IsDir()
 }
 
-func (p *Playground) startReadyCheck(ctx context.Context, inst proc.Process, task progressTask, taskStarted <-chan struct{}, exitCh <-chan error) <-chan error {
+func (p *Playground) startReadyCheck(ctx context.Context, inst proc.Process, updates *taskUpdates, exitCh <-chan error) <-chan error {
 	if inst == nil {
 		return readyOKCh
 	}
@@ -648,18 +757,21 @@ func (p *Playground) startReadyCheck(ctx context.Context, inst proc.Process, tas
 
 	waiter, ok := inst.(proc.ReadyWaiter)
 	if !ok {
-		if task != nil {
-			go func() {
-				<-taskStarted
-				task.Done()
-			}()
-		}
+		updates.done()
 		return readyOKCh
 	}
+	pid := inst.Info().Proc.Pid()
+	setPhase := func(phase string) {
+		if pid > 0 {
+			phase = fmt.Sprintf("pid %d, %s", pid, phase)
+		}
+		updates.setPhase(phase)
+	}
+	setPhase("waiting for ready")
 
 	ch := make(chan error, 1)
 	go func() {
-		readyCtx, cancel := context.WithCancel(ctx)
+		readyCtx, cancel := context.WithCancel(proc.WithReadyPhase(ctx, setPhase))
 		defer cancel()
 
 		readyErrCh := make(chan error, 1)
@@ -674,6 +786,9 @@ func (p *Playground) startReadyCheck(ctx context.Context, inst proc.Process, tas
 			if err == nil {
 				err = fmt.Errorf("%s exited before ready", inst.Info().Name())
 			}
+			if reason := exitReason(inst); reason != "" {
+				err = fmt.Errorf("%w: %s", err, reason)
+			}
 		case <-ctx.Done():
 			err = ctx.Err()
 		}
@@ -689,24 +804,75 @@ func (p *Playground) startReadyCheck(ctx context.Context, inst proc.Process, tas
 		ch <- err
 		close(ch)
 
-		if task == nil {
+		if updates == nil || updates.task == nil {
 			return
 		}
 		if err == nil {
-			go func() {
-				<-taskStarted
-				task.Done()
-			}()
+			updates.done()
 			return
 		}
 		if errors.Cause(err) == context.Canceled {
-			go func() {
-				<-taskStarted
-				task.Cancel("")
-			}()
+			updates.then(func() { updates.task.Cancel("") })
 			return
 		}
-		p.markStartingTaskError(inst, "", err)
+		updates.then(func() { p.markStartingTaskError(inst, "", err) })
 	}()
 	return ch
 }
+
+// exitReasonTailLines is how many trailing log lines exitReason looks at.
+const exitReasonTailLines = 20
+
+// exitReasonMaxLen caps the reason so it fits in a single task line.
+const exitReasonMaxLen = 200
+
+// exitReason returns the line that most likely explains why inst exited: the
+// last error-looking line among the trailing ones of its captured
+// stdout/stderr (e.g. a runtime panic or a flag error), then of its log file
+// (e.g. a "[FATAL]" entry), or the last non-empty line otherwise.
+func exitReason(inst proc.Process) string {
+	var output []string
+	if osProc := inst.Info().Proc; osProc != nil {
+		output = osProc.OutputTail(exitReasonTailLines)
+	}
+	reason, isErr := lastReasonLine(output)
+	if !isErr && inst.LogFile() != "" {
+		if lines, err := utils.TailN(inst.LogFile(), exitReasonTailLines); err == nil {
+			if logReason, logIsErr := lastReasonLine(lines); logIsErr || reason == "" {
+				reason = logReason
+			}
+		}
+	}
+	if r := []rune(reason); len(r) > exitReasonMaxLen {
+		reason = string(r[:exitReasonMaxLen]) + "..."
+	}
+	return reason
+}
+
+// lastReasonLine returns the last error-looking line of lines, or the last
+// non-empty one.
+func lastReasonLine(lines []string) (reason string, isErr bool) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		if isErrorLogLine(line) {
+			return line, true
+		}
+		if reason == "" {
+			reason = line
+		}
+	}
+	return reason, false
+}
+
+func isErrorLogLine(line string) bool {
+	lower := strings.ToLower(line)
+	for _, marker := range []string{"panic", "[fatal]", "[error]", "fatal error", "error:"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/pingcap/errors"
+	progressv2 "github.com/pingcap/tiup/pkg/tuiv2/progress"
+)
+
+// The playgrounds starting on a machine take turns: each one picks its free
+// ports and starts its instances holding the start lock, so two starts never
+// pick the same port, nor saturate the machine by starting their instances
+// at once. The lock and the entries of the starts waiting for it live in a
+// directory shared by all the users of the machine.
+const (
+	startLockFileName = "start.lock"
+	// startEntrySuffix names the entry of a start, holding its
+	// startQueueEntry while it waits for or holds the lock.
+	startEntrySuffix = ".start.json"
+	// startQueuePollInterval is how often a waiting start tries the lock and
+	// refreshes its progress.
+	startQueuePollInterval = 500 * time.Millisecond
+	// defaultStartQueueTimeout is how long a start waits for the others by
+	// default.
+	defaultStartQueueTimeout = 10 * time.Minute
+)
+
+// startQueueDir returns the directory of the start lock, shared by the users
+// of the machine.
+func startQueueDir() string {
+	return filepath.Join(os.TempDir(), "tiup-playground-ng")
+}
+
+// startQueueEntry describes a start waiting for, or holding, the start lock.
+type startQueueEntry struct {
+	PID   int       `json:"pid"`
+	Tag   string    `json:"tag"`
+	Since time.Time `json:"since"`
+}
+
+func (e startQueueEntry) String() string {
+	if e.Tag == "" {
+		return fmt.Sprintf("pid %d", e.PID)
+	}
+	return fmt.Sprintf("%s (pid %d)", e.Tag, e.PID)
+}
+
+// startSlot is the turn of a start, from acquireStartSlot until Release.
+type startSlot struct {
+	lock      *flock.Flock
+	entryPath string
+	once      sync.Once
+}
+
+// Release lets the next start go. It is safe to call more than once, and on
+// a nil slot.
+func (s *startSlot) Release() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		_ = s.lock.Unlock()
+		_ = os.Remove(s.entryPath)
+	})
+}
+
+// acquireStartSlot waits until no other start holds the start lock in dir,
+// at most timeout (0 does not wait), calling onWait with the other starts
+// once per poll while it waits.
+func (r runtimeOps) acquireStartSlot(ctx context.Context, dir, tag string, timeout time.Duration, onWait func(others []startQueueEntry)) (*startSlot, error) {
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return nil, errors.Annotate(err, "create the start lock dir")
+	}
+	// Let the other users of the machine take turns too; only the owner of
+	// the dir can set this, and only the owner of an entry remove it.
+	_ = os.Chmod(dir, 0o777|os.ModeSticky)
+	lockPath := filepath.Join(dir, startLockFileName)
+	if f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o666); err == nil {
+		_ = f.Chmod(0o666)
+		_ = f.Close()
+	}
+
+	self := startQueueEntry{PID: os.Getpid(), Tag: tag, Since: time.Now()}
+	entryPath := filepath.Join(dir, strconv.Itoa(self.PID)+"-"+tag+startEntrySuffix)
+	data, err := json.Marshal(self)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	if err := os.WriteFile(entryPath, data, 0o644); err != nil {
+		return nil, errors.Annotate(err, "register the start")
+	}
+	slot := &startSlot{lock: flock.New(lockPath), entryPath: entryPath}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := slot.lock.TryLock()
+		if err != nil {
+			_ = os.Remove(entryPath)
+			return nil, errors.Annotatef(err, "lock %s", lockPath)
+		}
+		if ok {
+			return slot, nil
+		}
+		others := r.otherStarts(dir, entryPath)
+		if !time.Now().Before(deadline) {
+			_ = os.Remove(entryPath)
+			return nil, &bootOptionError{
+				msg:   startQueueTimeoutMessage(timeout, others),
+				hints: []string{"retry once they are up, or raise --start-queue-timeout"},
+			}
+		}
+		if onWait != nil {
+			onWait(others)
+		}
+		select {
+		case <-ctx.Done():
+			_ = os.Remove(entryPath)
+			return nil, ctx.Err()
+		case <-time.After(startQueuePollInterval):
+		}
+	}
+}
+
+// otherStarts returns the entries in dir of the live starts but the one of
+// entryPath, oldest first. It removes the stale entries it may.
+func (r runtimeOps) otherStarts(dir, entryPath string) []startQueueEntry {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*"+startEntrySuffix))
+	var out []startQueueEntry
+	for _, path := range paths {
+		if path == entryPath {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var e startQueueEntry
+		if json.Unmarshal(data, &e) != nil || e.PID <= 0 {
+			continue
+		}
+		if running, err := r.procs.Running(e.PID); err == nil && !running {
+			_ = os.Remove(path)
+			continue
+		}
+		out = append(out, e)
+	}
+	slices.SortFunc(out, func(a, b startQueueEntry) int { return a.Since.Compare(b.Since) })
+	return out
+}
+
+// startQueueWaitMessage is the progress of a start waiting for others, e.g.
+// "waiting for 1 other start to finish: my-cluster (pid 1234)".
+func startQueueWaitMessage(others []startQueueEntry) string {
+	if len(others) == 0 {
+		return "waiting for another start to finish"
+	}
+	names := make([]string, 0, len(others))
+	for _, e := range others {
+		names = append(names, e.String())
+	}
+	noun := "start"
+	if len(others) > 1 {
+		noun = "starts"
+	}
+	return fmt.Sprintf("waiting for %d other %s to finish: %s", len(others), noun, strings.Join(names, ", "))
+}
+
+func startQueueTimeoutMessage(timeout time.Duration, others []startQueueEntry) string {
+	msg := startQueueWaitMessage(others)
+	if timeout <= 0 {
+		return "another playground is starting, " + msg
+	}
+	return fmt.Sprintf("timed out after %s %s", timeout, msg)
+}
+
+// waitStartTurn takes the turn of this playground to start, reporting the
+// wait in a "Wait for other starts" group if another start holds it. The
+// turn ends once the command server listens, or the boot fails (see
+// releaseStartTurn).
+func (p *Playground) waitStartTurn(ctx context.Context, tag string, timeout time.Duration) error {
+	var (
+		group *progressv2.Group
+		task  *progressv2.Task
+	)
+	slot, err := p.ops.acquireStartSlot(ctx, startQueueDir(), tag, timeout, func(others []startQueueEntry) {
+		if group == nil && p.ui != nil {
+			group = p.ui.Group("Wait for other starts")
+			task = group.Task("Start queue")
+		}
+		task.SetMeta(startQueueWaitMessage(others))
+	})
+	if group != nil {
+		switch {
+		case err == nil:
+			task.Done()
+		case ctx.Err() != nil:
+			task.Cancel("")
+		default:
+			task.Error(err.Error())
+		}
+		group.Close()
+	}
+	if err != nil {
+		return err
+	}
+	p.startTurn = slot
+	return nil
+}
+
+// releaseStartTurn lets the next playground start.
+func (p *Playground) releaseStartTurn() {
+	if p != nil {
+		p.startTurn.Release()
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireStartSlot_TakesTurns(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	first, err := newRuntimeOps().acquireStartSlot(ctx, dir, "first", 0, nil)
+	require.NoError(t, err)
+
+	_, err = newRuntimeOps().acquireStartSlot(ctx, dir, "busy", 0, nil)
+	require.EqualError(t, err, fmt.Sprintf("another playground is starting, waiting for 1 other start to finish: first (pid %d)", os.Getpid()))
+	require.Equal(t, []string{"retry once they are up, or raise --start-queue-timeout"}, errorHints(err))
+
+	waited := make(chan []startQueueEntry, 100)
+	acquired := make(chan *startSlot, 1)
+	go func() {
+		slot, err := newRuntimeOps().acquireStartSlot(ctx, dir, "second", 5*time.Second, func(others []startQueueEntry) { waited <- others })
+		require.NoError(t, err)
+		acquired <- slot
+	}()
+	others := <-waited
+	require.Len(t, others, 1)
+	require.Equal(t, "first", others[0].Tag)
+
+	first.Release()
+	first.Release()
+	second := <-acquired
+	second.Release()
+	entries, err := filepath.Glob(filepath.Join(dir, "*"+startEntrySuffix))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestAcquireStartSlot_Canceled(t *testing.T) {
+	dir := t.TempDir()
+	first, err := newRuntimeOps().acquireStartSlot(context.Background(), dir, "first", 0, nil)
+	require.NoError(t, err)
+	defer first.Release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = newRuntimeOps().acquireStartSlot(ctx, dir, "second", time.Minute, func([]startQueueEntry) { cancel() })
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, newRuntimeOps().otherStarts(dir, ""), 1)
+}
+
+func TestOtherStarts_RemovesStaleEntries(t *testing.T) {
+	dir := t.TempDir()
+	rt := runtimeOps{clock: realClock{}, procs: &fakeProcessOps{running: map[int]bool{1: true}}}
+
+	write := func(name string, e startQueueEntry) string {
+		data, err := json.Marshal(e)
+		require.NoError(t, err)
+		path := filepath.Join(dir, name+startEntrySuffix)
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		return path
+	}
+	now := time.Now()
+	self := write("self", startQueueEntry{PID: 1, Tag: "self", Since: now})
+	write("b", startQueueEntry{PID: 1, Tag: "b", Since: now.Add(time.Second)})
+	write("a", startQueueEntry{PID: 1, Tag: "a", Since: now.Add(-time.Second)})
+	stale := write("dead", startQueueEntry{PID: 2, Tag: "dead", Since: now})
+
+	others := rt.otherStarts(dir, self)
+	require.Len(t, others, 2)
+	require.Equal(t, "a", others[0].Tag)
+	require.Equal(t, "b", others[1].Tag)
+	require.NoFileExists(t, stale)
+	require.Equal(t, "waiting for 2 other starts to finish: a (pid 1), b (pid 1)", startQueueWaitMessage(others))
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -200,11 +202,12 @@ func newBlockingProgressTask(blockCh <-chan struct{}) *blockingProgressTask {
 	return &blockingProgressTask{blockCh: blockCh, doneCh: make(chan struct{})}
 }
 
-func (t *blockingProgressTask) SetMeta(meta string)  { <-t.blockCh }
-func (t *blockingProgressTask) Start()               { <-t.blockCh }
-func (t *blockingProgressTask) Done()                { t.doneOnce.Do(func() { close(t.doneCh) }) }
-func (t *blockingProgressTask) Error(msg string)     {}
-func (t *blockingProgressTask) Cancel(reason string) {}
+func (t *blockingProgressTask) SetMeta(meta string)   { <-t.blockCh }
+func (t *blockingProgressTask) SetMessage(msg string) {}
+func (t *blockingProgressTask) Start()                { <-t.blockCh }
+func (t *blockingProgressTask) Done()                 { t.doneOnce.Do(func() { close(t.doneCh) }) }
+func (t *blockingProgressTask) Error(msg string)      {}
+func (t *blockingProgressTask) Cancel(reason string)  {}
 
 type fakeOSProcess struct {
 	startedOnce sync.Once
@@ -215,7 +218,7 @@ func newFakeOSProcess() *fakeOSProcess {
 	return &fakeOSProcess{startedCh: make(chan struct{})}
 }
 
-func (p *fakeOSProcess) Start() error {
+func (p *fakeOSProcess) Start(func(*exec.Cmd) error) error {
 	p.startedOnce.Do(func() { close(p.startedCh) })
 	return nil
 }
@@ -224,6 +227,7 @@ func (p *fakeOSProcess) Wait() error                      { return nil }
 func (p *fakeOSProcess) Pid() int                         { return 123 }
 func (p *fakeOSProcess) Uptime() string                   { return "" }
 func (p *fakeOSProcess) SetOutputFile(fname string) error { return nil }
+func (p *fakeOSProcess) OutputTail(n int) []string        { return nil }
 func (p *fakeOSProcess) Cmd() *exec.Cmd                   { return &exec.Cmd{} }
 
 type fakeProcess struct {
@@ -412,3 +416,172 @@ func TestWaitBootStartingTasksSettled_EnsuresTaskDoneBeforePrintLines(t *testing
 	require.NotEqual(t, -1, blankIdx, "expected to see a blank PrintLines event in event log")
 	require.Less(t, doneIdx, blankIdx, "expected task done to be logged before PrintLines output")
 }
+
+func TestAbandonActiveGroups_CancelsUnfinishedTasks(t *testing.T) {
+	var eventBuf bytes.Buffer
+	ui := progressv2.New(progressv2.Options{
+		Mode:     progressv2.ModePlain,
+		Out:      io.Discard,
+		EventLog: &eventBuf,
+	})
+
+	pg := NewPlayground(t.TempDir(), 0)
+	pg.ui = ui
+	pg.startingGroup = ui.Group("Start instances")
+	tikv := newTrackedProgressTask(pg.startingGroup.TaskPending("TiKV"))
+	tiflash := newTrackedProgressTask(pg.startingGroup.TaskPending("TiFlash"))
+	pd := newTrackedProgressTask(pg.startingGroup.TaskPending("PD"))
+	pg.startingTasks = map[string]progressTask{"tikv-0": tikv, "tiflash-0": tiflash, "pd-0": pd}
+	pd.Start()
+	pd.Done()
+	tikv.Start()
+
+	require.True(t, pg.abandonActiveGroupsWithStartedRecords([]procRecordSnapshot{{Name: "pd-0"}, {Name: "tikv-0"}}))
+	require.False(t, pg.abandonActiveGroups())
+	require.NoError(t, ui.Close())
+
+	titles := make(map[uint64]string)
+	final := make(map[string]progressv2.TaskStatus)
+	messages := make(map[string]string)
+	for _, line := range bytes.Split(bytes.TrimSpace(eventBuf.Bytes()), []byte("\n")) {
+		e, err := progressv2.DecodeEvent(line)
+		require.NoError(t, err)
+		if e.Type == progressv2.EventTaskAdd && e.Title != nil {
+			titles[e.TaskID] = *e.Title
+		}
+		if e.Type == progressv2.EventTaskState && e.Status != nil {
+			title := titles[e.TaskID]
+			if _, ok := final[title]; ok && final[title] != progressv2.TaskStatusRunning {
+				// The engine ignores transitions out of a terminal status.
+				continue
+			}
+			final[title] = *e.Status
+			if e.Message != nil {
+				messages[title] = *e.Message
+			}
+		}
+	}
+	require.Equal(t, progressv2.TaskStatusDone, final["PD"])
+	require.Equal(t, progressv2.TaskStatusCanceled, final["TiKV"])
+	require.Equal(t, progressv2.TaskStatusCanceled, final["TiFlash"])
+	require.Equal(t, "not started", messages["TiFlash"])
+}
+
+type recordingProgressTask struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (t *recordingProgressTask) record(call string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls = append(t.calls, call)
+}
+
+func (t *recordingProgressTask) recorded() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.calls...)
+}
+
+func (t *recordingProgressTask) SetMeta(meta string)   {}
+func (t *recordingProgressTask) SetMessage(msg string) { t.record("message:" + msg) }
+func (t *recordingProgressTask) Start()                { t.record("start") }
+func (t *recordingProgressTask) Done()                 { t.record("done") }
+func (t *recordingProgressTask) Error(msg string)      { t.record("error:" + msg) }
+func (t *recordingProgressTask) Cancel(reason string)  { t.record("cancel") }
+
+// runningOSProcess is a fakeOSProcess that keeps running until exitCh is closed.
+type runningOSProcess struct {
+	*fakeOSProcess
+	exitCh chan struct{}
+}
+
+func (p *runningOSProcess) Wait() error {
+	<-p.exitCh
+	return nil
+}
+
+type fakeReadyProcess struct {
+	fakeProcess
+	readyCh chan struct{}
+}
+
+func (p *fakeReadyProcess) WaitReady(ctx context.Context) error {
+	select {
+	case <-p.readyCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestStartProcWithControllerState_ReportsStartPhases(t *testing.T) {
+	task := &recordingProgressTask{}
+	info := &proc.ProcessInfo{
+		Service: proc.ServiceTiKV,
+		ID:      0,
+		BinPath: "/tmp/tikv-server",
+	}
+	osProc := &runningOSProcess{fakeOSProcess: newFakeOSProcess(), exitCh: make(chan struct{})}
+	defer close(osProc.exitCh)
+	inst := &fakeReadyProcess{
+		fakeProcess: fakeProcess{info: info, osProc: osProc, logFile: filepath.Join(t.TempDir(), "tikv.log")},
+		readyCh:     make(chan struct{}),
+	}
+
+	pg := NewPlayground(t.TempDir(), 0)
+	pg.startingGroup = &progressv2.Group{}
+	pg.startingTasks = map[string]progressTask{info.Name(): task}
+	pg.controllerDoneCh = make(chan struct{})
+	close(pg.controllerDoneCh)
+
+	readyCh, err := pg.startProcWithControllerState(context.Background(), &controllerState{}, inst)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(task.recorded()) == 3
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"start", "message:spawning", "message:pid 123, waiting for ready"}, task.recorded())
+
+	close(inst.readyCh)
+	require.NoError(t, <-readyCh)
+	require.Eventually(t, func() bool {
+		return len(task.recorded()) == 5
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"message:", "done"}, task.recorded()[3:])
+}
+
+func TestStartProcWithControllerState_ExitBeforeReadyShowsLogReason(t *testing.T) {
+	task := &recordingProgressTask{}
+	info := &proc.ProcessInfo{
+		Service: proc.ServiceTiKV,
+		ID:      0,
+		BinPath: "/tmp/tikv-server",
+	}
+	logFile := filepath.Join(t.TempDir(), "tikv.log")
+	require.NoError(t, os.WriteFile(logFile, []byte(strings.Join([]string{
+		"[INFO] [server.rs:1] [\"starting\"]",
+		"[FATAL] [lib.rs:2] [\"invalid configuration: storage.reserve-space\"]",
+		"thread 'main' exited",
+		"",
+	}, "\n")), 0o644))
+	inst := &fakeReadyProcess{
+		fakeProcess: fakeProcess{info: info, osProc: newFakeOSProcess(), logFile: logFile},
+		readyCh:     make(chan struct{}),
+	}
+
+	pg := NewPlayground(t.TempDir(), 0)
+	pg.startingGroup = &progressv2.Group{}
+	pg.startingTasks = map[string]progressTask{info.Name(): task}
+	pg.controllerDoneCh = make(chan struct{})
+	close(pg.controllerDoneCh)
+
+	readyCh, err := pg.startProcWithControllerState(context.Background(), &controllerState{}, inst)
+	require.NoError(t, err)
+	err = <-readyCh
+	require.ErrorContains(t, err, "tikv-0 exited before ready: [FATAL] [lib.rs:2] [\"invalid configuration: storage.reserve-space\"]")
+	require.Eventually(t, func() bool {
+		recorded := task.recorded()
+		return len(recorded) > 0 && strings.HasPrefix(recorded[len(recorded)-1], "error:")
+	}, time.Second, 10*time.Millisecond)
+}
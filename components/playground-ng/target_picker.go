@@ -0,0 +1,63 @@
+package main
+
+import (
+	stdErrors "errors"
+	"os"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
+	"github.com/pingcap/tiup/pkg/tuiv2/picker"
+	xterm "golang.org/x/term"
+)
+
+// canPickPlayground reports whether the user can be asked which playground to
+// control: stdin is a terminal, and so is stderr, where the picker renders so
+// stdout stays the output of the command. Tests replace it.
+var canPickPlayground = func() bool {
+	return xterm.IsTerminal(int(os.Stdin.Fd())) && tuiterm.ResolveFile(os.Stderr).Control
+}
+
+// pickRow runs the picker, see picker.Pick. Tests replace it.
+var pickRow = func(opts picker.Options) (int, error) {
+	return picker.Pick(os.Stdin, os.Stderr, opts)
+}
+
+// pickAmongPlaygrounds lets the user choose the playground to control when
+// several are running and the command names none. The error of ResolveTarget
+// is kept when the user cannot answer (scripts, CI) or cancels.
+func pickAmongPlaygrounds(err error) (playgroundTarget, error) {
+	var multiple client.MultipleTargetsError
+	if !stdErrors.As(err, &multiple) || !canPickPlayground() {
+		return playgroundTarget{}, err
+	}
+
+	targets := make([]playgroundTarget, 0, len(multiple.Targets))
+	rows := make([][]string, 0, len(multiple.Targets))
+	for _, t := range multiple.Targets {
+		target := newPlaygroundTarget(t)
+		version, uptime := "-", "-"
+		if summary, err := inspectPlaygroundInstance(target); err == nil && summary.version != "" {
+			version = summary.version
+		}
+		if start, ok := loadStartTime(target.dir); ok {
+			uptime = time.Since(start).Round(time.Second).String()
+		}
+		targets = append(targets, target)
+		rows = append(rows, []string{target.tag, version, uptime})
+	}
+
+	idx, pickErr := pickRow(picker.Options{
+		Title:  "Several playgrounds are running, select one:",
+		Header: []string{"TAG", "VERSION", "UPTIME"},
+		Rows:   rows,
+	})
+	if stdErrors.Is(pickErr, picker.ErrCanceled) {
+		return playgroundTarget{}, err
+	}
+	if pickErr != nil {
+		return playgroundTarget{}, errors.AddStack(pickErr)
+	}
+	return targets[idx], nil
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/playgroundng/client"
+	"github.com/pingcap/tiup/pkg/tui/colorstr"
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
+	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/spf13/cobra"
+	xterm "golang.org/x/term"
+)
+
+// topQPSMetrics are the counters whose rate is the QPS of a service, read
+// from the status port: the statements TiDB runs and the gRPC requests TiKV
+// serves. The other services show no QPS.
+var topQPSMetrics = map[string]string{
+	"tidb": "tidb_server_query_total",
+	"tikv": "tikv_grpc_msg_duration_seconds",
+}
+
+// topMetricsTimeout bounds a read of the metrics of an instance, so a busy
+// instance does not stall the refresh.
+const topMetricsTimeout = 2 * time.Second
+
+// canRunTop reports whether the live view can run: stdin and stdout are
+// terminals. Tests replace it.
+var canRunTop = func() bool {
+	return xterm.IsTerminal(int(os.Stdin.Fd())) && tuiterm.ResolveFile(os.Stdout).Control
+}
+
+// topRow is the usage of an instance in a refresh of top.
+type topRow struct {
+	Name    string
+	Service string
+	Status  string
+	// CPU is nil, and RSS 0, until the playground sampled the process, see
+	// usageSampleInterval.
+	CPU *float64
+	RSS uint64
+	// QPS is nil for the services without a metric in topQPSMetrics, on
+	// the first refresh and when the metrics cannot be read.
+	QPS *float64
+}
+
+type topCounter struct {
+	pid   int
+	value float64
+	at    time.Time
+}
+
+// topSampler computes the rows of each refresh. It keeps the counters of the
+// previous refresh to turn them into rates.
+type topSampler struct {
+	items   func() ([]displayItem, error)
+	counter func(url, metric string) (float64, error)
+	// scheme is the scheme of the status ports, see playgroundReady.scheme.
+	scheme string
+	prev   map[string]topCounter
+}
+
+func newTopSampler(c *client.Client, ready *playgroundReady) (*topSampler, error) {
+	httpClient, err := ready.httpClient(topMetricsTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &topSampler{
+		scheme: ready.scheme(),
+		items:  func() ([]displayItem, error) { return fetchDisplayJSON(c) },
+		counter: func(url, metric string) (float64, error) {
+			return fetchCounter(httpClient, url, metric)
+		},
+	}, nil
+}
+
+func (s *topSampler) sample(now time.Time) ([]topRow, error) {
+	items, err := s.items()
+	if err != nil {
+		return nil, err
+	}
+	next := make(map[string]topCounter, len(items))
+	rows := make([]topRow, 0, len(items))
+	for _, item := range items {
+		row := topRow{Name: item.Name, Service: item.ServiceID, Status: item.Status, CPU: item.CPUPercent, RSS: item.RSSBytes}
+		metric, ok := topQPSMetrics[item.ServiceID]
+		if ok && item.PID > 0 && item.StatusPort > 0 {
+			if value, err := s.counter(statusURL(s.scheme, item, "/metrics"), metric); err == nil {
+				cur := topCounter{pid: item.PID, value: value, at: now}
+				// A restarted instance starts its counters over.
+				if prev, ok := s.prev[item.Name]; ok && prev.pid == cur.pid && now.After(prev.at) && value >= prev.value {
+					qps := (value - prev.value) / now.Sub(prev.at).Seconds()
+					row.QPS = &qps
+				}
+				next[item.Name] = cur
+			}
+		}
+		rows = append(rows, row)
+	}
+	s.prev = next
+	return rows, nil
+}
+
+// fetchCounter returns the sum of the samples of the counter (or histogram
+// count) metric served at url in the Prometheus text format.
+func fetchCounter(client *http.Client, url, metric string) (float64, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, errors.AddStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return sumCounter(resp.Body, metric)
+}
+
+func sumCounter(r io.Reader, metric string) (float64, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return 0, errors.Annotate(err, "parse metrics")
+	}
+	family, ok := families[metric]
+	if !ok {
+		return 0, errors.Errorf("metric %s not found", metric)
+	}
+	var sum float64
+	for _, m := range family.GetMetric() {
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			sum += m.GetCounter().GetValue()
+		case dto.MetricType_HISTOGRAM:
+			sum += float64(m.GetHistogram().GetSampleCount())
+		case dto.MetricType_UNTYPED:
+			sum += m.GetUntyped().GetValue()
+		}
+	}
+	return sum, nil
+}
+
+// topSortKey is the column the rows are sorted by, the largest first.
+type topSortKey int
+
+const (
+	topSortCPU topSortKey = iota
+	topSortMem
+	topSortQPS
+	topSortName
+	topSortKeys
+)
+
+func (k topSortKey) String() string {
+	return [...]string{"CPU", "MEM", "QPS", "NAME"}[k]
+}
+
+func sortTopRows(rows []topRow, key topSortKey) {
+	ptr := func(v *float64) float64 {
+		if v == nil {
+			return -1
+		}
+		return *v
+	}
+	slices.SortStableFunc(rows, func(a, b topRow) int {
+		var c int
+		switch key {
+		case topSortCPU:
+			c = -cmpFloat(ptr(a.CPU), ptr(b.CPU))
+		case topSortMem:
+			c = -cmpFloat(float64(a.RSS), float64(b.RSS))
+		case topSortQPS:
+			c = -cmpFloat(ptr(a.QPS), ptr(b.QPS))
+		}
+		if c == 0 {
+			c = strings.Compare(a.Name, b.Name)
+		}
+		return c
+	})
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// topTable renders rows sorted by key.
+func topTable(rows []topRow, key topSortKey, width int) tuiv2output.Table {
+	rows = slices.Clone(rows)
+	sortTopRows(rows, key)
+	td := tuiv2output.Table{Header: []string{"NAME", "SERVICE", "STATUS", "CPU", "MEM", "QPS"}, Width: width}
+	for _, row := range rows {
+		cpu, mem, qps := "-", "-", "-"
+		if row.CPU != nil {
+			cpu = fmt.Sprintf("%.1f%%", *row.CPU)
+			mem = units.BytesSize(float64(row.RSS))
+		}
+		if row.QPS != nil {
+			qps = fmt.Sprintf("%.1f", *row.QPS)
+		}
+		td.AddRow(row.Name, row.Service, row.Status, cpu, mem, qps)
+	}
+	return td
+}
+
+type topSampleMsg struct {
+	rows []topRow
+	err  error
+	at   time.Time
+}
+
+type topTickMsg struct{}
+
+// topModel is the live view: it refreshes the rows every interval, one
+// refresh at a time, and keeps the last rows when a refresh fails.
+type topModel struct {
+	tag      string
+	sampler  *topSampler
+	interval time.Duration
+	mode     tuiterm.OutputMode
+
+	rows  []topRow
+	err   error
+	at    time.Time
+	sort  topSortKey
+	width int
+}
+
+func (m topModel) sampleCmd() tea.Cmd {
+	sampler := m.sampler
+	return func() tea.Msg {
+		now := time.Now()
+		rows, err := sampler.sample(now)
+		return topSampleMsg{rows: rows, err: err, at: now}
+	}
+}
+
+func (m topModel) Init() tea.Cmd { return m.sampleCmd() }
+
+func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case topSampleMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.rows, m.at = msg.rows, msg.at
+		}
+		return m, tea.Tick(m.interval, func(time.Time) tea.Msg { return topTickMsg{} })
+	case topTickMsg:
+		return m, m.sampleCmd()
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "s", "tab":
+			m.sort = (m.sort + 1) % topSortKeys
+		case "c":
+			m.sort = topSortCPU
+		case "m":
+			m.sort = topSortMem
+		case "p":
+			m.sort = topSortQPS
+		case "n":
+			m.sort = topSortName
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m topModel) View() string {
+	tokens := colorstr.TokensFor(m.mode)
+	var b strings.Builder
+	title := "playground-ng top"
+	if m.tag != "" {
+		title += " - " + m.tag
+	}
+	b.WriteString(tokens.Sprintf("[bold]%s[reset]", title))
+	if !m.at.IsZero() {
+		b.WriteString(tokens.Sprintf("  [dim]%s, every %s[reset]", m.at.Format(time.TimeOnly), m.interval))
+	}
+	b.WriteString("\n")
+
+	var cpu, qps float64
+	var rss uint64
+	for _, row := range m.rows {
+		if row.CPU != nil {
+			cpu += *row.CPU
+		}
+		if row.QPS != nil {
+			qps += *row.QPS
+		}
+		rss += row.RSS
+	}
+	fmt.Fprintf(&b, "Total: CPU %.1f%%, MEM %s, QPS %.1f\n\n", cpu, units.BytesSize(float64(rss)), qps)
+
+	if m.rows == nil && m.err == nil {
+		b.WriteString("Loading...\n")
+	}
+	for i, line := range topTable(m.rows, m.sort, m.width).Lines() {
+		if i < 2 {
+			line = tokens.Sprintf("[dim]%s[reset]", line)
+		}
+		b.WriteString(line + "\n")
+	}
+	if m.err != nil {
+		b.WriteString(tokens.Sprintf("\n[red]Refresh failed: %s[reset]\n", m.err))
+	}
+	b.WriteString(tokens.Sprintf("\n[dim]Sorted by %s: s to change (c/m/p/n), q to quit[reset]\n", m.sort))
+	return b.String()
+}
+
+// topSnapshot prints the rows once, for a stdout that is not a terminal. The
+// QPS is the rate over interval.
+func (r runtimeOps) topSnapshot(out io.Writer, sampler *topSampler, interval time.Duration) error {
+	if _, err := sampler.sample(r.clock.Now()); err != nil {
+		return err
+	}
+	<-r.clock.After(interval)
+	rows, err := sampler.sample(r.clock.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(out, topTable(rows, topSortCPU, 0).Render())
+	return nil
+}
+
+func newTop(state *cliState) *cobra.Command {
+	arg0 := playgroundCLIArg0()
+
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Show the live CPU, memory and QPS of the instances of a running playground",
+		Long: fmt.Sprintf(`Show the CPU, memory and QPS of each instance of a running playground,
+refreshed live, to spot the instance saturating the machine during a load test.
+
+CPU and memory are sampled by the playground every %s. QPS is read from the
+status port: the statements of TiDB and the gRPC requests of TiKV.
+
+When stdout is not a terminal, the usage is printed once, the QPS measured
+over one interval.`, usageSampleInterval),
+		Example: fmt.Sprintf("%s top --tag my-cluster --interval 5s", arg0),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval < time.Second {
+				return fmt.Errorf("--interval must be at least 1s")
+			}
+			out := cmd.OutOrStdout()
+			target, err := resolvePlaygroundTarget(state.tag, state.tiupDataDir, state.dataDir)
+			if err != nil {
+				printDisplayFailureWarning(out, err)
+				return renderedError{err: err}
+			}
+			ready, err := loadReadyFile(target)
+			if err != nil {
+				return err
+			}
+			sampler, err := newTopSampler(target.client(), ready)
+			if err != nil {
+				return err
+			}
+			if !canRunTop() {
+				return state.ops.topSnapshot(out, sampler, interval)
+			}
+			m := topModel{tag: target.tag, sampler: sampler, interval: interval, mode: tuiterm.Resolve(out)}
+			_, err = tea.NewProgram(m, tea.WithAltScreen(), tea.WithOutput(out)).Run()
+			return errors.AddStack(err)
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "Refresh interval")
+	return cmd
+}
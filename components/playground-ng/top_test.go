@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSumCounter(t *testing.T) {
+	text := `# TYPE tidb_server_query_total counter
+tidb_server_query_total{result="OK",type="Select"} 10
+tidb_server_query_total{result="Error",type="Insert"} 2
+# TYPE tikv_grpc_msg_duration_seconds histogram
+tikv_grpc_msg_duration_seconds_bucket{type="kv_get",le="+Inf"} 7
+tikv_grpc_msg_duration_seconds_sum{type="kv_get"} 0.5
+tikv_grpc_msg_duration_seconds_count{type="kv_get"} 7
+tikv_grpc_msg_duration_seconds_bucket{type="kv_scan",le="+Inf"} 3
+tikv_grpc_msg_duration_seconds_sum{type="kv_scan"} 0.1
+tikv_grpc_msg_duration_seconds_count{type="kv_scan"} 3
+`
+	v, err := sumCounter(strings.NewReader(text), "tidb_server_query_total")
+	require.NoError(t, err)
+	require.Equal(t, 12.0, v)
+
+	v, err = sumCounter(strings.NewReader(text), "tikv_grpc_msg_duration_seconds")
+	require.NoError(t, err)
+	require.Equal(t, 10.0, v)
+
+	_, err = sumCounter(strings.NewReader(text), "pd_missing")
+	require.ErrorContains(t, err, "not found")
+}
+
+func TestTopSampler_QPS(t *testing.T) {
+	cpu := 150.0
+	items := []displayItem{
+		{Name: "tidb-0", ServiceID: "tidb", Addr: "127.0.0.1:4000", StatusPort: 10080, Status: "running", PID: 11, CPUPercent: &cpu, RSSBytes: 1 << 30},
+		{Name: "pd-0", ServiceID: "pd", Addr: "127.0.0.1:2379", StatusPort: 2379, Status: "running", PID: 12},
+	}
+	counters := map[string]float64{}
+	var urls []string
+	s := &topSampler{
+		scheme: "http",
+		items:  func() ([]displayItem, error) { return items, nil },
+		counter: func(url, metric string) (float64, error) {
+			urls = append(urls, url)
+			require.Equal(t, "tidb_server_query_total", metric)
+			return counters[url], nil
+		},
+	}
+
+	now := time.Now()
+	counters["http://127.0.0.1:10080/metrics"] = 100
+	rows, err := s.sample(now)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Nil(t, rows[0].QPS, "no rate on the first refresh")
+	require.Equal(t, []string{"http://127.0.0.1:10080/metrics"}, urls)
+
+	counters["http://127.0.0.1:10080/metrics"] = 300
+	rows, err = s.sample(now.Add(2 * time.Second))
+	require.NoError(t, err)
+	require.NotNil(t, rows[0].QPS)
+	require.Equal(t, 100.0, *rows[0].QPS)
+	require.Nil(t, rows[1].QPS)
+
+	// A restart starts the counter over.
+	items[0].PID = 21
+	counters["http://127.0.0.1:10080/metrics"] = 5
+	rows, err = s.sample(now.Add(4 * time.Second))
+	require.NoError(t, err)
+	require.Nil(t, rows[0].QPS)
+}
+
+func TestTopSnapshot(t *testing.T) {
+	rt, clk, _ := newFakeRuntime()
+	start := clk.Now()
+	cpu := 12.5
+	var value float64
+	s := &topSampler{
+		scheme: "http",
+		items: func() ([]displayItem, error) {
+			return []displayItem{{Name: "tidb-0", ServiceID: "tidb", Addr: "127.0.0.1:4000", StatusPort: 10080, Status: "running", PID: 11, CPUPercent: &cpu, RSSBytes: 512 << 20}}, nil
+		},
+		counter: func(url, metric string) (float64, error) {
+			value += 40
+			return value, nil
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, rt.topSnapshot(&out, s, 4*time.Second))
+	require.Equal(t, 4*time.Second, clk.Now().Sub(start))
+	require.Equal(t, ""+
+		"NAME    SERVICE  STATUS     CPU     MEM   QPS\n"+
+		"----    -------  ------     ---     ---   ---\n"+
+		"tidb-0  tidb     running  12.5%  512MiB  10.0\n", out.String())
+}
+
+func TestTopModel_SortAndQuit(t *testing.T) {
+	cpuA, cpuB := 10.0, 90.0
+	m := topModel{tag: "demo", interval: time.Second}
+	next, cmd := m.Update(topSampleMsg{rows: []topRow{
+		{Name: "tidb-0", Service: "tidb", Status: "running", CPU: &cpuA, RSS: 2 << 30},
+		{Name: "tikv-0", Service: "tikv", Status: "running", CPU: &cpuB, RSS: 1 << 30},
+	}, at: time.Now()})
+	require.NotNil(t, cmd, "schedules the next refresh")
+	m = next.(topModel)
+
+	view := m.View()
+	require.Contains(t, view, "playground-ng top - demo")
+	require.Contains(t, view, "Total: CPU 100.0%, MEM 3GiB, QPS 0.0")
+	require.Less(t, strings.Index(view, "tikv-0"), strings.Index(view, "tidb-0"), "sorted by CPU")
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	m = next.(topModel)
+	view = m.View()
+	require.Contains(t, view, "Sorted by MEM")
+	require.Less(t, strings.Index(view, "tidb-0"), strings.Index(view, "tikv-0"), "sorted by memory")
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	require.NotNil(t, cmd)
+	require.Equal(t, tea.Quit(), cmd())
+}
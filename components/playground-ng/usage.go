@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	gops "github.com/shirou/gopsutil/process"
+)
+
+// usageSampleInterval is how often the playground samples the CPU and memory
+// usage of its instances.
+const usageSampleInterval = 5 * time.Second
+
+// instanceUsage is the latest CPU and memory sample of an instance process.
+type instanceUsage struct {
+	Service string
+	PID     int
+	// CPU is the usage since the previous sample, in percent of one core. It
+	// is 0 on the first sample of a process.
+	CPU float64
+	RSS uint64
+
+	cpuTime float64 // user+system seconds at the sample time
+	at      time.Time
+}
+
+// usageSamples holds the latest samples by instance name. The sampler
+// goroutine writes them; display and /metrics read them.
+type usageSamples struct {
+	mu     sync.Mutex
+	byName map[string]instanceUsage
+}
+
+func newUsageSamples() *usageSamples {
+	return &usageSamples{byName: make(map[string]instanceUsage)}
+}
+
+// get returns the sample of the instance name, if it is about its process
+// pid: a restarted instance has no sample until the next round.
+func (s *usageSamples) get(name string, pid int) (instanceUsage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.byName[name]
+	return u, ok && u.PID == pid
+}
+
+// update samples the running processes of records, replacing the previous
+// samples. The instances being scaled in and the processes that are gone
+// are dropped.
+func (s *usageSamples) update(records []procRecordSnapshot, now time.Time) {
+	next := make(map[string]instanceUsage, len(records))
+	for _, rec := range records {
+		if rec.Removed {
+			continue
+		}
+		cpuTime, rss, err := readProcUsage(rec.PID)
+		if err != nil {
+			continue
+		}
+		u := instanceUsage{Service: rec.ServiceID.String(), PID: rec.PID, RSS: rss, cpuTime: cpuTime, at: now}
+		if prev, ok := s.get(rec.Name, rec.PID); ok && now.After(prev.at) {
+			u.CPU = max(0, (cpuTime-prev.cpuTime)/now.Sub(prev.at).Seconds()*100)
+		}
+		next[rec.Name] = u
+	}
+	s.mu.Lock()
+	s.byName = next
+	s.mu.Unlock()
+}
+
+// readProcUsage returns the CPU time (user+system, in seconds) and the
+// resident memory of the process pid.
+func readProcUsage(pid int) (cpuTime float64, rss uint64, err error) {
+	p, err := gops.NewProcess(int32(pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	times, err := p.Times()
+	if err != nil {
+		return 0, 0, err
+	}
+	mem, err := p.MemoryInfo()
+	if err != nil {
+		return 0, 0, err
+	}
+	return times.User + times.System, mem.RSS, nil
+}
+
+// startUsageSampler samples the instances every usageSampleInterval until the
+// playground stops. Reading the processes happens outside the controller, so
+// slow reads never hold commands.
+func (p *Playground) startUsageSampler() {
+	if p == nil || p.processGroup == nil {
+		return
+	}
+	_ = p.processGroup.Add("usage sampler", func() error {
+		ticker := time.NewTicker(usageSampleInterval)
+		defer ticker.Stop()
+		for {
+			p.usage.update(p.procRecordsSnapshot(), time.Now())
+			select {
+			case <-p.processGroup.Closed():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// metricsHandler serves the latest samples in the Prometheus text format.
+func (p *Playground) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p.usage.mu.Lock()
+	names := make([]string, 0, len(p.usage.byName))
+	for name := range p.usage.byName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var cpu, rss strings.Builder
+	for _, name := range names {
+		u := p.usage.byName[name]
+		labels := fmt.Sprintf(`{instance=%q,service=%q,pid="%d"}`, name, u.Service, u.PID)
+		fmt.Fprintf(&cpu, "playground_instance_cpu_percent%s %g\n", labels, u.CPU)
+		fmt.Fprintf(&rss, "playground_instance_memory_rss_bytes%s %d\n", labels, u.RSS)
+	}
+	p.usage.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprintf(w, "# HELP playground_instance_cpu_percent CPU usage of the instance process over the last %s, in percent of one core.\n", usageSampleInterval)
+	fmt.Fprintln(w, "# TYPE playground_instance_cpu_percent gauge")
+	_, _ = fmt.Fprint(w, cpu.String())
+	fmt.Fprintln(w, "# HELP playground_instance_memory_rss_bytes Resident memory of the instance process.")
+	fmt.Fprintln(w, "# TYPE playground_instance_memory_rss_bytes gauge")
+	_, _ = fmt.Fprint(w, rss.String())
+}
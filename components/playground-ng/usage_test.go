@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/components/playground-ng/proc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageSamples_Update(t *testing.T) {
+	s := newUsageSamples()
+	pid := os.Getpid()
+	records := []procRecordSnapshot{
+		{ServiceID: proc.ServiceTiDB, Name: "tidb-0", PID: pid},
+		{ServiceID: proc.ServiceTiKV, Name: "tikv-0", PID: pid, Removed: true},
+		{ServiceID: proc.ServicePD, Name: "pd-0", PID: 1 << 30},
+	}
+
+	now := time.Now()
+	s.update(records, now)
+	u, ok := s.get("tidb-0", pid)
+	require.True(t, ok)
+	require.Equal(t, "tidb", u.Service)
+	require.Greater(t, u.RSS, uint64(0))
+	require.Zero(t, u.CPU)
+	_, ok = s.get("tidb-0", pid+1)
+	require.False(t, ok, "sample of another process")
+	_, ok = s.get("tikv-0", pid)
+	require.False(t, ok, "instance being scaled in")
+	_, ok = s.get("pd-0", 1<<30)
+	require.False(t, ok, "process gone")
+
+	// Burn some CPU so the next sample has a usage.
+	for deadline := time.Now().Add(50 * time.Millisecond); time.Now().Before(deadline); {
+	}
+	s.update(records, now.Add(time.Second))
+	u, ok = s.get("tidb-0", pid)
+	require.True(t, ok)
+	require.Greater(t, u.CPU, 0.0)
+
+	s.update(nil, now.Add(2*time.Second))
+	_, ok = s.get("tidb-0", pid)
+	require.False(t, ok)
+}
+
+func TestHandleDisplay_WideShowsUsage(t *testing.T) {
+	info := &proc.ProcessInfo{Service: proc.ServiceTiDB}
+	info.Proc = &displayOSProcess{pid: 123, cmd: &exec.Cmd{Process: &os.Process{Pid: 123}}, uptime: "1s"}
+	state := &controllerState{
+		procs: map[proc.ServiceID][]proc.Process{
+			proc.ServiceTiDB: {&displayProcess{info: info}},
+		},
+	}
+	pg := NewPlayground(t.TempDir(), 0)
+	pg.usage.byName["tidb-0"] = instanceUsage{Service: "tidb", PID: 123, CPU: 12.34, RSS: 512 << 20}
+
+	var buf bytes.Buffer
+	require.NoError(t, pg.handleDisplay(state, &buf, DisplayRequest{Wide: true}))
+	require.Contains(t, buf.String(), "CPU")
+	require.Contains(t, buf.String(), "12.3%")
+	require.Contains(t, buf.String(), "512MiB")
+
+	// No sample of a restarted process.
+	pg.usage.byName["tidb-0"] = instanceUsage{Service: "tidb", PID: 122, CPU: 12.34}
+	buf.Reset()
+	require.NoError(t, pg.handleDisplay(state, &buf, DisplayRequest{Wide: true}))
+	require.NotContains(t, buf.String(), "12.3%")
+}
+
+func TestMetricsHandler(t *testing.T) {
+	pg := NewPlayground(t.TempDir(), 0)
+	pg.usage.byName["tikv-0"] = instanceUsage{Service: "tikv", PID: 2, CPU: 50, RSS: 2048}
+	pg.usage.byName["pd-0"] = instanceUsage{Service: "pd", PID: 1, CPU: 1.5, RSS: 1024}
+
+	rec := httptest.NewRecorder()
+	pg.metricsHandler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	body := rec.Body.String()
+	require.Contains(t, body, "# TYPE playground_instance_cpu_percent gauge\n"+
+		`playground_instance_cpu_percent{instance="pd-0",service="pd",pid="1"} 1.5`+"\n"+
+		`playground_instance_cpu_percent{instance="tikv-0",service="tikv",pid="2"} 50`+"\n")
+	require.Contains(t, body, `playground_instance_memory_rss_bytes{instance="tikv-0",service="tikv",pid="2"} 2048`)
+
+	rec = httptest.NewRecorder()
+	pg.metricsHandler(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
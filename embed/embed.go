@@ -19,3 +19,11 @@ var embedExamples goembed.FS
 func ReadExample(path string) ([]byte, error) {
 	return embedExamples.ReadFile(path)
 }
+
+//go:embed schemas
+var embedSchemas goembed.FS
+
+// ReadConfigSchema read the server config key schema of a component
+func ReadConfigSchema(comp string) ([]byte, error) {
+	return embedSchemas.ReadFile("schemas/" + comp + ".json")
+}
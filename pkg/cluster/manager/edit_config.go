@@ -151,7 +151,25 @@ func (m *Manager) editTopo(origTopo spec.Topology, data []byte, opt EditConfigOp
 		return nil, nil
 	}
 
-	utils.ShowDiff(string(origData), string(newData), os.Stdout)
+	// Hide the sensitive values from the diff, it may end up in a terminal
+	// recording or a ticket.
+	redactor, err := spec.DefaultRedactor()
+	if err != nil {
+		return nil, err
+	}
+	origShown, err := redactor.RedactYAML(origData)
+	if err != nil {
+		return nil, err
+	}
+	newShown, err := redactor.RedactYAML(newData)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(origShown, newShown) {
+		m.logger.Infof("Only redacted values or formatting changed")
+	} else {
+		utils.ShowDiff(string(origShown), string(newShown), os.Stdout)
+	}
 
 	if !skipConfirm {
 		if err := tui.PromptForConfirmOrAbortError(
@@ -19,12 +19,14 @@ import (
 
 	perrs "github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
 	"github.com/pingcap/tiup/pkg/meta"
 	"gopkg.in/yaml.v3"
 )
 
-// ShowConfig shows the cluster's config.
-func (m *Manager) ShowConfig(name string) error {
+// ShowConfig shows the cluster's config, with the sensitive values hidden
+// unless noRedact is set.
+func (m *Manager) ShowConfig(name string, noRedact bool) error {
 	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
 		return err
 	}
@@ -40,6 +42,15 @@ func (m *Manager) ShowConfig(name string) error {
 	if err != nil {
 		return perrs.AddStack(err)
 	}
+	if !noRedact {
+		redactor, err := spec.DefaultRedactor()
+		if err != nil {
+			return err
+		}
+		if data, err = redactor.RedactYAML(data); err != nil {
+			return err
+		}
+	}
 
 	fmt.Print(string(data))
 	return nil
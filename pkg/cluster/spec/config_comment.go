@@ -0,0 +1,337 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigComments are the comments of config keys, by flattened key (see
+// FlattenMap), e.g. "log.file.max-size". A comment holds its lines without
+// the leading '#'.
+type ConfigComments map[string]string
+
+// Merge returns the comments of the config merging overwrite into the config
+// commented by c (see MergeConfig): a key keeps its comment in c unless
+// overwrite comments it too.
+func (c ConfigComments) Merge(overwrite ConfigComments) ConfigComments {
+	if len(overwrite) == 0 {
+		return c
+	}
+	merged := make(ConfigComments, len(c)+len(overwrite))
+	for k, v := range c {
+		merged[k] = v
+	}
+	for k, v := range overwrite {
+		merged[k] = v
+	}
+	return merged
+}
+
+// LoadConfigComments fills the comment fields of the topology v, a pointer to
+// the struct just decoded by unmarshal, the function passed to its
+// UnmarshalYAML, with the comments of the document. A field of type
+// ConfigComments tagged `comments:"<key>"` receives the comments of the
+// config map <key> of its struct, e.g. the "config" of an instance, and a
+// field of type map[string]ConfigComments tagged `comments:"*"` those of all
+// the config maps of its struct by key, e.g. of server_configs. The fields,
+// slices and pointers of v are walked.
+func LoadConfigComments(unmarshal func(any) error, v any) error {
+	var node configNode
+	if err := unmarshal(&node); err != nil {
+		return err
+	}
+	walkConfigComments(node.node, reflect.ValueOf(v), true)
+	return nil
+}
+
+// configNode keeps the node it is decoded from, the unmarshal function of an
+// obsolete yaml.Unmarshaler does not fill a *yaml.Node.
+type configNode struct {
+	node *yaml.Node
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface
+func (n *configNode) UnmarshalYAML(node *yaml.Node) error {
+	n.node = node
+	return nil
+}
+
+// AttachConfigComments writes the comment fields of the topology v (see
+// LoadConfigComments) into node, the encoding of v, as the head comments of
+// their keys, so they survive the meta file.
+func AttachConfigComments(node *yaml.Node, v any) {
+	walkConfigComments(node, reflect.ValueOf(v), false)
+}
+
+var (
+	configCommentsType    = reflect.TypeOf(ConfigComments{})
+	configCommentsMapType = reflect.TypeOf(map[string]ConfigComments{})
+)
+
+func walkConfigComments(node *yaml.Node, v reflect.Value, load bool) {
+	if node == nil {
+		return
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			walkConfigComments(node.Content[0], v, load)
+		}
+		return
+	case yaml.AliasNode:
+		if load {
+			walkConfigComments(node.Alias, v, load)
+		}
+		return
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i := 0; i < v.Len() && i < len(node.Content); i++ {
+			walkConfigComments(node.Content[i], v.Index(i), load)
+		}
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		values := make(map[string]*yaml.Node, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			values[node.Content[i].Value] = node.Content[i+1]
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if key, ok := f.Tag.Lookup("comments"); ok {
+				walkCommentField(node, values, key, v.Field(i), load)
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			switch {
+			case name == "-":
+			case opts == "inline":
+				walkConfigComments(node, v.Field(i), load)
+			default:
+				if name == "" {
+					name = strings.ToLower(f.Name)
+				}
+				walkConfigComments(values[name], v.Field(i), load)
+			}
+		}
+	}
+}
+
+// walkCommentField loads or attaches the comments of the comment field f,
+// tagged `comments:"<key>"`, of the struct encoded as node.
+func walkCommentField(node *yaml.Node, values map[string]*yaml.Node, key string, f reflect.Value, load bool) {
+	switch {
+	case key == "*" && f.Type() == configCommentsMapType:
+		all := map[string]ConfigComments{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name := node.Content[i].Value
+			if load {
+				comments := ConfigComments{}
+				collectConfigComments(node.Content[i+1], "", comments)
+				if len(comments) > 0 {
+					all[name] = comments
+				}
+			} else if comments := f.Interface().(map[string]ConfigComments)[name]; len(comments) > 0 {
+				attachConfigComments(node.Content[i+1], "", comments)
+			}
+		}
+		if load && f.CanSet() {
+			f.Set(reflect.Zero(f.Type()))
+			if len(all) > 0 {
+				f.Set(reflect.ValueOf(all))
+			}
+		}
+	case f.Type() == configCommentsType:
+		value := values[key]
+		if !load {
+			if value != nil {
+				attachConfigComments(value, "", f.Interface().(ConfigComments))
+			}
+			return
+		}
+		comments := ConfigComments{}
+		if value != nil {
+			collectConfigComments(value, "", comments)
+		}
+		if f.CanSet() {
+			f.Set(reflect.Zero(f.Type()))
+			if len(comments) > 0 {
+				f.Set(reflect.ValueOf(comments))
+			}
+		}
+	}
+}
+
+// collectConfigComments adds the comments of the keys of the mapping node to
+// comments, the keys are prefixed with prefix.
+func collectConfigComments(node *yaml.Node, prefix string, comments ConfigComments) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Kind != yaml.ScalarNode {
+			continue
+		}
+		path := prefix + key.Value
+		var lines []string
+		for _, text := range []string{key.HeadComment, key.LineComment, value.LineComment} {
+			lines = append(lines, commentLines(text)...)
+		}
+		if len(lines) > 0 {
+			comments[path] = strings.Join(lines, "\n")
+		}
+		collectConfigComments(value, path+".", comments)
+	}
+}
+
+// attachConfigComments sets the comments of the keys of the mapping node as
+// their head comments, the keys are prefixed with prefix.
+func attachConfigComments(node *yaml.Node, prefix string, comments ConfigComments) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		path := prefix + key.Value
+		if comment, ok := comments[path]; ok {
+			key.HeadComment = "# " + strings.ReplaceAll(comment, "\n", "\n# ")
+		}
+		attachConfigComments(node.Content[i+1], path+".", comments)
+	}
+}
+
+// commentLines returns the lines of a YAML comment without their '#'.
+func commentLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+	}
+	return lines
+}
+
+// commentToml writes the comments above the lines of their keys and tables in
+// data, the TOML written by the encoder of Merge2Toml.
+func commentToml(data []byte, comments ConfigComments) []byte {
+	if len(comments) == 0 {
+		return data
+	}
+
+	var out bytes.Buffer
+	var table []string
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		var key []string
+		switch {
+		case strings.HasPrefix(trimmed, "[["):
+			if parts, rest := splitTomlKey(trimmed[2:]); strings.HasPrefix(rest, "]]") {
+				table, key = parts, parts
+			}
+		case strings.HasPrefix(trimmed, "["):
+			if parts, rest := splitTomlKey(trimmed[1:]); strings.HasPrefix(rest, "]") {
+				table, key = parts, parts
+			}
+		case trimmed != "" && !strings.HasPrefix(trimmed, "#"):
+			if parts, rest := splitTomlKey(trimmed); strings.HasPrefix(rest, "=") {
+				key = append(append([]string{}, table...), parts...)
+			}
+		}
+
+		if comment, ok := comments[strings.Join(key, ".")]; ok && len(key) > 0 {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			for _, l := range strings.Split(comment, "\n") {
+				out.WriteString(strings.TrimRight(indent+"# "+l, " ") + "\n")
+			}
+		}
+		out.WriteString(line)
+	}
+	return out.Bytes()
+}
+
+// splitTomlKey splits the dotted key at the start of s, e.g. `a."b.c" = 1`,
+// and returns the rest of s. The parts are nil if s does not start with a key.
+func splitTomlKey(s string) ([]string, string) {
+	var parts []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		var part string
+		switch {
+		case strings.HasPrefix(s, `"`):
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, s
+			}
+			unquoted, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, s
+			}
+			part, s = unquoted, s[end+1:]
+		case strings.HasPrefix(s, "'"):
+			end := strings.IndexByte(s[1:], '\'')
+			if end < 0 {
+				return nil, s
+			}
+			part, s = s[1:end+1], s[end+2:]
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+			})
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, s
+			}
+			part, s = s[:end], s[end:]
+		}
+		parts = append(parts, part)
+
+		s = strings.TrimLeft(s, " \t")
+		if !strings.HasPrefix(s, ".") {
+			return parts, s
+		}
+		s = s[1:]
+	}
+}
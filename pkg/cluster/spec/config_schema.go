@@ -0,0 +1,182 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/tiup/embed"
+)
+
+// configSchema lists the sections of the server_configs of a component and
+// the keys worth checking the type of. The schemas are shipped in
+// embed/schemas. They don't list every key a version accepts: a key they
+// don't list is only reported if it looks like a typo of one they do.
+type configSchema struct {
+	// Open are the sections whose sub keys are never reported as unknown,
+	// e.g. the performance section of TiDB or the RocksDB sections of TiKV.
+	// Every section a component has is open.
+	Open []string `json:"open"`
+	// Keys maps the flattened keys to their type: bool, int, float (an int
+	// is accepted), string, size (a string like "10GB" or a number of
+	// bytes), array or any.
+	Keys map[string]string `json:"keys"`
+}
+
+var configSchemas sync.Map // component name -> *configSchema, nil if none
+
+func loadConfigSchema(comp string) *configSchema {
+	if s, ok := configSchemas.Load(comp); ok {
+		return s.(*configSchema)
+	}
+	var schema *configSchema
+	if data, err := embed.ReadConfigSchema(comp); err == nil {
+		schema = new(configSchema)
+		if err := json.Unmarshal(data, schema); err != nil {
+			panic(fmt.Sprintf("invalid config schema of %s: %v", comp, err))
+		}
+	}
+	configSchemas.Store(comp, schema)
+	return schema
+}
+
+// ConfigIssue is a server_configs key that looks like a typo of a key of the
+// component or has a value of the wrong type.
+type ConfigIssue struct {
+	// Key is the flattened key, e.g. "performance.max-procs".
+	Key   string
+	Value any
+	// Message explains the issue, with a suggestion for a likely typo.
+	Message string
+}
+
+func (i ConfigIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Key, i.Message)
+}
+
+// ValidateServerConfig checks the keys of a merged server config of comp
+// against its schema, sorted by key. Components without a schema are not
+// checked.
+//
+// The issues are meant to be warnings: the schemas may lag behind a new
+// component version. A key the schema doesn't list, e.g. "enable-global-kill",
+// is only reported if it is close to a key or a section it does, e.g.
+// "preformance.max-procs".
+func ValidateServerConfig(comp string, conf map[string]any) []ConfigIssue {
+	schema := loadConfigSchema(comp)
+	if schema == nil {
+		return nil
+	}
+
+	flat := FlattenMap(FoldMap(conf))
+	var issues []ConfigIssue
+	for _, key := range sortedKeys(flat) {
+		val := flat[key]
+		typ, known := schema.Keys[key]
+		switch {
+		case known:
+			if !configValueHasType(val, typ) {
+				issues = append(issues, ConfigIssue{Key: key, Value: val, Message: fmt.Sprintf("expect %s, got %T (%v)", typ, val, val)})
+			}
+		case !schema.isOpen(key):
+			if s := schema.suggest(key); s != "" {
+				issues = append(issues, ConfigIssue{Key: key, Value: val, Message: fmt.Sprintf("unknown key, did you mean %q?", s)})
+			}
+		}
+	}
+	return issues
+}
+
+func (s *configSchema) isOpen(key string) bool {
+	for _, sec := range s.Open {
+		if key == sec || strings.HasPrefix(key, sec+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns the known key closest to key, if it is a likely typo:
+// "preformance.max-procs" gives "performance.max-procs". A key under an open
+// section compares by the section: "rocksdbb.x" gives "rocksdb.x".
+func (s *configSchema) suggest(key string) string {
+	best, bestDist := "", 3
+	try := func(candidate string, dist int) {
+		if dist < bestDist || (dist == bestDist && candidate < best) {
+			best, bestDist = candidate, dist
+		}
+	}
+	for k := range s.Keys {
+		try(k, editDistance(key, k))
+	}
+	parts := strings.Split(key, ".")
+	for _, sec := range s.Open {
+		n := strings.Count(sec, ".") + 1
+		if len(parts) < n {
+			continue
+		}
+		prefix := strings.Join(parts[:n], ".")
+		try(sec+key[len(prefix):], editDistance(prefix, sec))
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func configValueHasType(val any, typ string) bool {
+	_, isNum := configNumber(val)
+	switch typ {
+	case "bool":
+		_, ok := val.(bool)
+		return ok
+	case "int":
+		// A float would be written as such in the TOML and rejected.
+		kind := reflect.ValueOf(val).Kind()
+		return kind >= reflect.Int && kind <= reflect.Uint64
+	case "float":
+		return isNum
+	case "string":
+		_, ok := val.(string)
+		return ok
+	case "size":
+		_, ok := val.(string)
+		return ok || isNum
+	case "array":
+		return val != nil && reflect.TypeOf(val).Kind() == reflect.Slice
+	}
+	return true
+}
@@ -40,6 +40,7 @@ type DashboardSpec struct {
 	Source          string               `yaml:"source,omitempty" validate:"source:editable"`
 	NumaNode        string               `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
 	Config          map[string]any       `yaml:"config,omitempty" validate:"config:ignore"`
+	ConfigComments  ConfigComments       `yaml:"-" validate:"config_comments:ignore" comments:"config"`
 	ResourceControl meta.ResourceControl `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
 	Arch            string               `yaml:"arch,omitempty"`
 	OS              string               `yaml:"os,omitempty"`
@@ -232,7 +233,7 @@ func (i *DashboardInstance) InitConfig(
 
 	globalConfig := topo.ServerConfigs.Dashboard
 
-	if err := i.MergeServerConfig(ctx, e, globalConfig, spec.Config, paths); err != nil {
+	if err := i.MergeServerConfig(ctx, e, globalConfig, spec.Config, topo.ServerConfigs.Comments["tidb_dashboard"].Merge(spec.ConfigComments), paths); err != nil {
 		return err
 	}
 
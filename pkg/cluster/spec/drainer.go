@@ -44,6 +44,7 @@ type DrainerSpec struct {
 	Source          string               `yaml:"source,omitempty" validate:"source:editable"`
 	NumaNode        string               `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
 	Config          map[string]any       `yaml:"config,omitempty" validate:"config:ignore"`
+	ConfigComments  ConfigComments       `yaml:"-" validate:"config_comments:ignore" comments:"config"`
 	ResourceControl meta.ResourceControl `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
 	Arch            string               `yaml:"arch,omitempty"`
 	OS              string               `yaml:"os,omitempty"`
@@ -256,7 +257,7 @@ func (i *DrainerInstance) InitConfig(
 		return err
 	}
 
-	if err := i.MergeServerConfig(ctx, e, globalConfig, spec.Config, paths); err != nil {
+	if err := i.MergeServerConfig(ctx, e, globalConfig, spec.Config, topo.ServerConfigs.Comments["drainer"].Merge(spec.ConfigComments), paths); err != nil {
 		return err
 	}
 
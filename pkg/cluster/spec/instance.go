@@ -30,6 +30,7 @@ import (
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
 	"github.com/pingcap/tiup/pkg/cluster/module"
 	system "github.com/pingcap/tiup/pkg/cluster/template/systemd"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/meta"
 	"github.com/pingcap/tiup/pkg/utils"
 	"go.uber.org/zap"
@@ -307,10 +308,16 @@ func (i *BaseInstance) IteratorLocalConfigDir(ctx context.Context, local string,
 	return nil
 }
 
-// MergeServerConfig merges the server configuration and overwrite the global configuration
-func (i *BaseInstance) MergeServerConfig(ctx context.Context, e ctxt.Executor, globalConf, instanceConf map[string]any, paths meta.DirPaths) error {
+// MergeServerConfig merges the server configuration and overwrite the global configuration,
+// comments are written above their keys
+func (i *BaseInstance) MergeServerConfig(ctx context.Context, e ctxt.Executor, globalConf, instanceConf map[string]any, comments ConfigComments, paths meta.DirPaths) error {
 	fp := filepath.Join(paths.Cache, fmt.Sprintf("%s-%s-%d.toml", i.ComponentName(), i.GetHost(), i.GetPort()))
-	conf, err := Merge2Toml(i.ComponentName(), globalConf, instanceConf)
+	if logger, ok := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger); ok {
+		for _, issue := range ValidateServerConfig(i.ComponentName(), MergeConfig(globalConf, instanceConf)) {
+			logger.Warnf("Suspicious config of %s %s: %s", i.ComponentName(), i.ID(), issue)
+		}
+	}
+	conf, err := Merge2TomlWithComments(i.ComponentName(), globalConf, instanceConf, comments)
 	if err != nil {
 		return err
 	}
@@ -324,9 +331,9 @@ func (i *BaseInstance) MergeServerConfig(ctx context.Context, e ctxt.Executor, g
 }
 
 // mergeTiFlashLearnerServerConfig merges the server configuration and overwrite the global configuration
-func (i *BaseInstance) mergeTiFlashLearnerServerConfig(ctx context.Context, e ctxt.Executor, globalConf, instanceConf map[string]any, paths meta.DirPaths) error {
+func (i *BaseInstance) mergeTiFlashLearnerServerConfig(ctx context.Context, e ctxt.Executor, globalConf, instanceConf map[string]any, comments ConfigComments, paths meta.DirPaths) error {
 	fp := filepath.Join(paths.Cache, fmt.Sprintf("%s-learner-%s-%d.toml", i.ComponentName(), i.GetHost(), i.GetPort()))
-	conf, err := Merge2Toml(i.ComponentName()+"-learner", globalConf, instanceConf)
+	conf, err := Merge2TomlWithComments(i.ComponentName()+"-learner", globalConf, instanceConf, comments)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,276 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pingcap/errors"
+)
+
+// remotePresetKey names the preset of a remote_write or remote_read entry.
+// The preset sets the fields the entry does not.
+const remotePresetKey = "preset"
+
+var (
+	remoteWritePresets = map[string]map[string]any{
+		// low-latency ships the samples at most a second after the scrape.
+		"low-latency": {"queue_config": map[string]any{
+			"batch_send_deadline":  "1s",
+			"max_samples_per_send": 500,
+		}},
+		// high-throughput is for a remote storage ingesting a large cluster.
+		"high-throughput": {"queue_config": map[string]any{
+			"capacity":             10000,
+			"max_shards":           200,
+			"max_samples_per_send": 2000,
+			"batch_send_deadline":  "5s",
+		}},
+	}
+	remoteReadPresets = map[string]map[string]any{
+		// recent also reads the time range Prometheus has locally.
+		"recent": {"read_recent": true},
+	}
+)
+
+// remoteFieldKind is the expected kind of a field of a remote entry.
+type remoteFieldKind int
+
+const (
+	remoteFieldAny remoteFieldKind = iota
+	remoteFieldString
+	remoteFieldBool
+	remoteFieldInt
+	remoteFieldDuration
+	remoteFieldMap
+)
+
+var (
+	remoteCommonFields = map[string]remoteFieldKind{
+		"url":                    remoteFieldString,
+		"name":                   remoteFieldString,
+		"remote_timeout":         remoteFieldDuration,
+		"headers":                remoteFieldMap,
+		"basic_auth":             remoteFieldMap,
+		"authorization":          remoteFieldMap,
+		"oauth2":                 remoteFieldMap,
+		"tls_config":             remoteFieldMap,
+		"proxy_url":              remoteFieldString,
+		"no_proxy":               remoteFieldString,
+		"proxy_from_environment": remoteFieldBool,
+		"proxy_connect_header":   remoteFieldMap,
+		"follow_redirects":       remoteFieldBool,
+		"enable_http2":           remoteFieldBool,
+	}
+	remoteWriteFields = map[string]remoteFieldKind{
+		"write_relabel_configs":  remoteFieldAny,
+		"send_exemplars":         remoteFieldBool,
+		"send_native_histograms": remoteFieldBool,
+		"sigv4":                  remoteFieldMap,
+		"azuread":                remoteFieldMap,
+		"google_iam":             remoteFieldMap,
+		"queue_config":           remoteFieldMap,
+		"metadata_config":        remoteFieldMap,
+		"protobuf_message":       remoteFieldString,
+		"round_robin_dns":        remoteFieldBool,
+	}
+	remoteReadFields = map[string]remoteFieldKind{
+		"read_recent":            remoteFieldBool,
+		"required_matchers":      remoteFieldMap,
+		"filter_external_labels": remoteFieldBool,
+	}
+	remoteNestedFields = map[string]map[string]remoteFieldKind{
+		"queue_config": {
+			"capacity":             remoteFieldInt,
+			"max_shards":           remoteFieldInt,
+			"min_shards":           remoteFieldInt,
+			"max_samples_per_send": remoteFieldInt,
+			"batch_send_deadline":  remoteFieldDuration,
+			"min_backoff":          remoteFieldDuration,
+			"max_backoff":          remoteFieldDuration,
+			"retry_on_http_429":    remoteFieldBool,
+			"sample_age_limit":     remoteFieldDuration,
+		},
+		"metadata_config": {
+			"send":                 remoteFieldBool,
+			"send_interval":        remoteFieldDuration,
+			"max_samples_per_send": remoteFieldInt,
+		},
+	}
+)
+
+// promDurationRegexp matches the durations of the Prometheus config, e.g.
+// "1m30s".
+var promDurationRegexp = regexp.MustCompile(`^(\d+y)?(\d+w)?(\d+d)?(\d+h)?(\d+m)?(\d+s)?(\d+ms)?$`)
+
+// resolve returns the remote config with the presets applied, and an error
+// naming the first entry Prometheus would reject.
+func (r Remote) resolve() (Remote, error) {
+	var out Remote
+	var err error
+	if out.RemoteWrite, err = resolveRemoteEntries("remote_write", r.RemoteWrite, remoteWritePresets, remoteWriteFields); err != nil {
+		return Remote{}, err
+	}
+	if out.RemoteRead, err = resolveRemoteEntries("remote_read", r.RemoteRead, remoteReadPresets, remoteReadFields); err != nil {
+		return Remote{}, err
+	}
+	return out, nil
+}
+
+func resolveRemoteEntries(section string, entries []map[string]any, presets map[string]map[string]any, fields map[string]remoteFieldKind) ([]map[string]any, error) {
+	if entries == nil {
+		return nil, nil
+	}
+	out := make([]map[string]any, 0, len(entries))
+	names := make(map[string]int)
+	for i, entry := range entries {
+		where := fmt.Sprintf("%s[%d]", section, i)
+		resolved := make(map[string]any, len(entry))
+		for k, v := range entry {
+			resolved[k] = v
+		}
+		if p, ok := resolved[remotePresetKey]; ok {
+			name, _ := p.(string)
+			preset, ok := presets[name]
+			if !ok {
+				known := make([]string, 0, len(presets))
+				for n := range presets {
+					known = append(known, n)
+				}
+				slices.Sort(known)
+				return nil, errors.Errorf("%s: unknown preset %v, expect one of %s", where, p, strings.Join(known, ", "))
+			}
+			delete(resolved, remotePresetKey)
+			mergeRemoteDefaults(resolved, preset)
+		}
+
+		if err := checkRemoteEntry(resolved, fields); err != nil {
+			return nil, errors.Annotate(err, where)
+		}
+		if name, ok := resolved["name"].(string); ok {
+			if j, dup := names[name]; dup {
+				return nil, errors.Errorf("%s: name %q is already used by %s[%d]", where, name, section, j)
+			}
+			names[name] = i
+		}
+		out = append(out, resolved)
+	}
+	return out, nil
+}
+
+// mergeRemoteDefaults sets the fields of defaults that entry does not set,
+// one level deep into the nested configs like queue_config.
+func mergeRemoteDefaults(entry, defaults map[string]any) {
+	for k, dv := range defaults {
+		v, ok := entry[k]
+		if !ok {
+			entry[k] = dv
+			continue
+		}
+		sub, subOk := strKeyMap(v).(map[string]any)
+		subDefaults, defOk := dv.(map[string]any)
+		if !subOk || !defOk {
+			continue
+		}
+		merged := make(map[string]any, len(sub)+len(subDefaults))
+		for sk, sv := range subDefaults {
+			merged[sk] = sv
+		}
+		for sk, sv := range sub {
+			merged[sk] = sv
+		}
+		entry[k] = merged
+	}
+}
+
+func checkRemoteEntry(entry map[string]any, fields map[string]remoteFieldKind) error {
+	raw, ok := entry["url"]
+	if !ok {
+		return errors.New("url is required")
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return errors.Errorf("url must be a string, got %T", raw)
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return errors.Errorf("invalid url %q: %v", s, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid url %q: expect http(s)://host[:port]/path", s)
+	}
+
+	for k, v := range entry {
+		kind, ok := fields[k]
+		if !ok {
+			kind, ok = remoteCommonFields[k]
+		}
+		if !ok {
+			return errors.Errorf("unknown field %q", k)
+		}
+		if err := checkRemoteField(k, v, kind); err != nil {
+			return err
+		}
+		nested, ok := remoteNestedFields[k]
+		if !ok {
+			continue
+		}
+		sub, _ := strKeyMap(v).(map[string]any)
+		for sk, sv := range sub {
+			skind, ok := nested[sk]
+			if !ok {
+				return errors.Errorf("unknown field %q", k+"."+sk)
+			}
+			if err := checkRemoteField(k+"."+sk, sv, skind); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkRemoteField(key string, val any, kind remoteFieldKind) error {
+	var ok bool
+	var expect string
+	switch kind {
+	case remoteFieldString:
+		_, ok = val.(string)
+		expect = "a string"
+	case remoteFieldBool:
+		_, ok = val.(bool)
+		expect = "a bool"
+	case remoteFieldInt:
+		k := reflect.ValueOf(val).Kind()
+		ok = k >= reflect.Int && k <= reflect.Uint64
+		expect = "an integer"
+	case remoteFieldDuration:
+		s, isStr := val.(string)
+		ok = isStr && s != "" && promDurationRegexp.MatchString(s)
+		expect = `a duration like "30s" or "1m"`
+	case remoteFieldMap:
+		_, ok = strKeyMap(val).(map[string]any)
+		expect = "a map"
+	default:
+		ok = true
+	}
+	if !ok {
+		return errors.Errorf("%s must be %s, got %v", key, expect, val)
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/joomcode/errorx"
+	"github.com/pingcap/tiup/pkg/localdata"
 	"github.com/pingcap/tiup/pkg/tui"
 	"github.com/pingcap/tiup/pkg/utils"
 	"go.uber.org/zap"
@@ -36,7 +37,9 @@ var (
 	ErrTopologyParseFailed = errNSTopolohy.NewType("parse_failed", utils.ErrTraitPreCheck)
 )
 
-// ReadYamlFile read yaml content from file`
+// ReadYamlFile read yaml content from file`, with the ${VAR} references to
+// the environment variables allowed by TIUP_INTERPOLATE_ENV resolved (see
+// utils.InterpolateEnv)
 func ReadYamlFile(file string) ([]byte, error) {
 	suggestionProps := map[string]string{
 		"File": file,
@@ -51,6 +54,17 @@ Please check whether your topology file {{ColorKeyword}}{{.File}}{{ColorReset}}
 
 To generate a sample topology file:
   {{ColorCommand}}{{OsArgs0}} template topology > topo.yaml{{ColorReset}}
+`, suggestionProps))
+	}
+	yamlFile, err = utils.InterpolateEnv(yamlFile, utils.AllowedEnv(os.Getenv(localdata.EnvNameInterpolateEnv)))
+	if err != nil {
+		suggestionProps["Env"] = localdata.EnvNameInterpolateEnv
+		return nil, ErrTopologyParseFailed.
+			Wrap(err, "Failed to interpolate the environment variables in topology file %s", file).
+			WithProperty(tui.SuggestionFromTemplate(`
+Only the variables listed in {{ColorKeyword}}{{.Env}}{{ColorReset}} (comma separated) are read, e.g.
+  {{ColorCommand}}export {{.Env}}=DEPLOY_USER,TIDB_HOST{{ColorReset}}
+Use ${VAR:-default} for a default value, and $${ for a literal ${.
 `, suggestionProps))
 	}
 	return yamlFile, nil
@@ -59,16 +73,21 @@ To generate a sample topology file:
 // ParseTopologyYaml read yaml content from `file` and unmarshal it to `out`
 // ignoreGlobal ignore global variables in file, only ignoreGlobal with a index of 0 is effective
 func ParseTopologyYaml(file string, out Topology, ignoreGlobal ...bool) error {
-	suggestionProps := map[string]string{
-		"File": file,
-	}
-
 	zap.L().Debug("Parse topology file", zap.String("file", file))
 
 	yamlFile, err := ReadYamlFile(file)
 	if err != nil {
 		return err
 	}
+	return decodeTopologyYaml(file, yamlFile, out, ignoreGlobal...)
+}
+
+// decodeTopologyYaml unmarshals the yaml content of `file` to `out`, see
+// ParseTopologyYaml
+func decodeTopologyYaml(file string, yamlFile []byte, out Topology, ignoreGlobal ...bool) error {
+	suggestionProps := map[string]string{
+		"File": file,
+	}
 
 	// keep the global config in out
 	if len(ignoreGlobal) > 0 && ignoreGlobal[0] {
@@ -89,7 +108,7 @@ func ParseTopologyYaml(file string, out Topology, ignoreGlobal ...bool) error {
 
 	decoder := yaml.NewDecoder(bytes.NewReader(yamlFile))
 	decoder.KnownFields(true)
-	if err = decoder.Decode(out); err != nil {
+	if err := decoder.Decode(out); err != nil {
 		return ErrTopologyParseFailed.
 			Wrap(err, "Failed to parse topology file %s", file).
 			WithProperty(tui.SuggestionFromTemplate(`
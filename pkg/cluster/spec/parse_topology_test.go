@@ -56,6 +56,38 @@ func TestParseTopologyYamlIgnoreGlobal(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestParseTopologyYamlInterpolateEnv(t *testing.T) {
+	t.Setenv("TIUP_INTERPOLATE_ENV", "TEST_TIDB_HOST,TEST_TIDB_PORT")
+	t.Setenv("TEST_TIDB_HOST", "172.16.5.140")
+	t.Setenv("TEST_DEPLOY_DIR", "/not-allowed")
+
+	withTempFile(t, `
+global:
+  deploy_dir: ${TEST_DEPLOY_DIR:-/home/tidb/deploy}
+tidb_servers:
+  - host: ${TEST_TIDB_HOST}
+    port: ${TEST_TIDB_PORT:-4001}
+    config:
+      log.slow-query-file: $${not-a-var}.log
+`, func(file string) {
+		topo := Specification{}
+		require.NoError(t, ParseTopologyYaml(file, &topo))
+		require.Equal(t, "/home/tidb/deploy", topo.GlobalOptions.DeployDir)
+		require.Equal(t, "172.16.5.140", topo.TiDBServers[0].Host)
+		require.Equal(t, 4001, topo.TiDBServers[0].Port)
+		require.Equal(t, "${not-a-var}.log", topo.TiDBServers[0].Config["log.slow-query-file"])
+	})
+
+	withTempFile(t, `
+tidb_servers:
+  - host: ${TEST_DEPLOY_DIR}
+`, func(file string) {
+		topo := Specification{}
+		err := ParseTopologyYaml(file, &topo)
+		require.ErrorContains(t, err, "variable TEST_DEPLOY_DIR is not set or not allowed")
+	})
+}
+
 func TestRelativePath(t *testing.T) {
 	// test relative path
 	withTempFile(t, `
@@ -52,6 +52,7 @@ type PDSpec struct {
 	Source          string               `yaml:"source,omitempty" validate:"source:editable"`
 	NumaNode        string               `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
 	Config          map[string]any       `yaml:"config,omitempty" validate:"config:ignore"`
+	ConfigComments  ConfigComments       `yaml:"-" validate:"config_comments:ignore" comments:"config"`
 	ResourceControl meta.ResourceControl `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
 	Arch            string               `yaml:"arch,omitempty"`
 	OS              string               `yaml:"os,omitempty"`
@@ -273,7 +274,7 @@ func (i *PDInstance) InitConfig(
 		return err
 	}
 
-	if err := i.MergeServerConfig(ctx, e, globalConfig, spec.Config, paths); err != nil {
+	if err := i.MergeServerConfig(ctx, e, globalConfig, spec.Config, topo.ServerConfigs.Comments["pd"].Merge(spec.ConfigComments), paths); err != nil {
 		return err
 	}
 
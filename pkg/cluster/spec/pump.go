@@ -43,6 +43,7 @@ type PumpSpec struct {
 	Source          string               `yaml:"source,omitempty" validate:"source:editable"`
 	NumaNode        string               `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
 	Config          map[string]any       `yaml:"config,omitempty" validate:"config:ignore"`
+	ConfigComments  ConfigComments       `yaml:"-" validate:"config_comments:ignore" comments:"config"`
 	ResourceControl meta.ResourceControl `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
 	Arch            string               `yaml:"arch,omitempty"`
 	OS              string               `yaml:"os,omitempty"`
@@ -256,7 +257,7 @@ func (i *PumpInstance) InitConfig(
 		return err
 	}
 
-	return i.MergeServerConfig(ctx, e, globalConfig, spec.Config, paths)
+	return i.MergeServerConfig(ctx, e, globalConfig, spec.Config, topo.ServerConfigs.Comments["pump"].Merge(spec.ConfigComments), paths)
 }
 
 // setTLSConfig set TLS Config to support enable/disable TLS
@@ -0,0 +1,198 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/localdata"
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue replaces the values of the sensitive config keys.
+const RedactedValue = "******"
+
+// DefaultRedactPatterns are the config keys always treated as sensitive.
+var DefaultRedactPatterns = []string{
+	"*password*",
+	"*passwd*",
+	"*secret*",
+	"*token*",
+	"*credentials*",
+	"*access-key*",
+	"*ssl-key",
+}
+
+// Redactor hides the values of the config keys matching its patterns. A
+// pattern is a path.Match pattern on the dotted key, compared case
+// insensitively with the key and each of its dotted suffixes, so both
+// "security.ssl-key" and "*ssl-key" match "server_configs.tidb.security.ssl-key".
+type Redactor struct {
+	patterns []string
+}
+
+// NewRedactor returns a Redactor for the patterns.
+func NewRedactor(patterns ...string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, perrs.Annotatef(err, "invalid redact pattern %q", p)
+		}
+		r.patterns = append(r.patterns, p)
+	}
+	return r, nil
+}
+
+// DefaultRedactor returns a Redactor for DefaultRedactPatterns and the
+// patterns listed in TIUP_REDACT_KEYS.
+func DefaultRedactor() (*Redactor, error) {
+	patterns := append([]string{}, DefaultRedactPatterns...)
+	if extra := os.Getenv(localdata.EnvNameRedactKeys); extra != "" {
+		patterns = append(patterns, strings.Split(extra, ",")...)
+	}
+	return NewRedactor(patterns...)
+}
+
+// Match reports whether the value of the dotted key is sensitive.
+func (r *Redactor) Match(key string) bool {
+	key = strings.ToLower(key)
+	for {
+		for _, p := range r.patterns {
+			if ok, _ := path.Match(p, key); ok {
+				return true
+			}
+		}
+		idx := strings.IndexByte(key, '.')
+		if idx < 0 {
+			return false
+		}
+		key = key[idx+1:]
+	}
+}
+
+// RedactConfig returns a copy of conf with the sensitive values replaced by
+// RedactedValue. The entries of lists of tables, e.g. remote_write, are
+// matched without their index.
+func (r *Redactor) RedactConfig(conf map[string]any) map[string]any {
+	return r.redactMap("", conf)
+}
+
+func (r *Redactor) redactMap(prefix string, m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = r.redactValue(joinConfigKey(prefix, k), v)
+	}
+	return out
+}
+
+func (r *Redactor) redactValue(key string, v any) any {
+	switch val := strKeyMap(v).(type) {
+	case map[string]any:
+		return r.redactMap(key, val)
+	case []any:
+		if r.Match(key) && !hasTable(val) {
+			return RedactedValue
+		}
+		items := make([]any, len(val))
+		for i, item := range val {
+			items[i] = r.redactValue(key, item)
+		}
+		return items
+	default:
+		if r.Match(key) {
+			return RedactedValue
+		}
+		return v
+	}
+}
+
+func hasTable(items []any) bool {
+	for _, item := range items {
+		if _, ok := strKeyMap(item).(map[string]any); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactYAML replaces the sensitive values of a YAML document, e.g. a
+// topology, keeping its order and comments.
+func (r *Redactor) RedactYAML(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, perrs.AddStack(err)
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+	r.redactNode("", &doc)
+
+	buf := bytes.NewBuffer(nil)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(4)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, perrs.AddStack(err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, perrs.AddStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Redactor) redactNode(key string, n *yaml.Node) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			r.redactNode(key, c)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			r.redactNode(joinConfigKey(key, n.Content[i].Value), n.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			if c.Kind == yaml.MappingNode {
+				r.redactNode(key, c)
+				continue
+			}
+			if r.Match(key) {
+				*n = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: RedactedValue, LineComment: n.LineComment}
+				return
+			}
+			r.redactNode(key, c)
+		}
+	case yaml.ScalarNode, yaml.AliasNode:
+		if r.Match(key) {
+			*n = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: RedactedValue, LineComment: n.LineComment}
+		}
+	}
+}
+
+func joinConfigKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
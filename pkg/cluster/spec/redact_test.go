@@ -0,0 +1,98 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"testing"
+
+	"github.com/pingcap/tiup/pkg/localdata"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactConfig(t *testing.T) {
+	r, err := NewRedactor(append(DefaultRedactPatterns, "log.file.filename")...)
+	require.NoError(t, err)
+
+	require.True(t, r.Match("security.ssl-key"))
+	require.True(t, r.Match("server_configs.tidb.security.ssl-key"))
+	require.True(t, r.Match("remote_write.basic_auth.Password"))
+	require.False(t, r.Match("security.ssl-cert"))
+	require.False(t, r.Match("filename"))
+
+	conf := map[string]any{
+		"security.ssl-key": "/path/to/key",
+		"log": map[string]any{
+			"level": "info",
+			"file":  map[any]any{"filename": "tidb.log"},
+		},
+		"remote_write": []map[string]any{
+			{"url": "http://h/write", "basic_auth": map[string]any{"username": "u", "password": "p"}},
+		},
+		"auth-tokens": []string{"a", "b"},
+	}
+	require.Equal(t, map[string]any{
+		"security.ssl-key": RedactedValue,
+		"log": map[string]any{
+			"level": "info",
+			"file":  map[string]any{"filename": RedactedValue},
+		},
+		"remote_write": []any{
+			map[string]any{"url": "http://h/write", "basic_auth": map[string]any{"username": "u", "password": RedactedValue}},
+		},
+		"auth-tokens": RedactedValue,
+	}, r.RedactConfig(conf))
+	// The input is left untouched.
+	require.Equal(t, "/path/to/key", conf["security.ssl-key"])
+
+	_, err = NewRedactor("[")
+	require.ErrorContains(t, err, `invalid redact pattern "["`)
+}
+
+func TestRedactYAML(t *testing.T) {
+	t.Setenv(localdata.EnvNameRedactKeys, "*.sasl-user")
+	r, err := DefaultRedactor()
+	require.NoError(t, err)
+
+	data, err := r.RedactYAML([]byte(`server_configs:
+    tidb:
+        # the key of the TLS certificate
+        security.ssl-key: /path/to/key
+        security.ssl-cert: /path/to/cert
+    cdc:
+        sink.sasl-user: admin
+monitoring_servers:
+    - host: 10.0.1.1
+      remote_config:
+        remote_write:
+            - url: http://h/write
+              basic_auth:
+                password: 123456
+`))
+	require.NoError(t, err)
+	require.Equal(t, `server_configs:
+    tidb:
+        # the key of the TLS certificate
+        security.ssl-key: '******'
+        security.ssl-cert: /path/to/cert
+    cdc:
+        sink.sasl-user: '******'
+monitoring_servers:
+    - host: 10.0.1.1
+      remote_config:
+        remote_write:
+            - url: http://h/write
+              basic_auth:
+                password: '******'
+`, string(data))
+}
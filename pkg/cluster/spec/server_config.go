@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"path"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -208,8 +209,79 @@ func searchValue(m map[any]any, ss []string) any {
 	return nil
 }
 
+// GetIntFromPath returns the integer at path p of m, ok is false if it is
+// missing or not an integer.
+func GetIntFromPath(m map[string]any, p string) (int64, bool) {
+	rv := reflect.ValueOf(GetValueFromPath(m, p))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// GetBoolFromPath returns the bool at path p of m, ok is false if it is
+// missing or not a bool.
+func GetBoolFromPath(m map[string]any, p string) (bool, bool) {
+	v, ok := GetValueFromPath(m, p).(bool)
+	return v, ok
+}
+
+// GetStringFromPath returns the string at path p of m, ok is false if it is
+// missing or not a string.
+func GetStringFromPath(m map[string]any, p string) (string, bool) {
+	v, ok := GetValueFromPath(m, p).(string)
+	return v, ok
+}
+
+// SetValueAtPath sets the value at path p of the folded map m (see FoldMap),
+// creating the missing tables on the way and replacing the values that are
+// not tables.
+func SetValueAtPath(m map[string]any, p string, val any) {
+	key, rest, nested := strings.Cut(p, ".")
+	if !nested {
+		m[key] = strKeyMap(val)
+		return
+	}
+	sub, ok := m[key].(map[string]any)
+	if !ok {
+		sub = map[string]any{}
+		m[key] = sub
+	}
+	SetValueAtPath(sub, rest, val)
+}
+
+// DeleteValueAtPath removes the value at path p of the folded map m (see
+// FoldMap), and the tables it leaves empty. It reports whether the value was
+// present.
+func DeleteValueAtPath(m map[string]any, p string) bool {
+	key, rest, nested := strings.Cut(p, ".")
+	if !nested {
+		_, ok := m[key]
+		delete(m, key)
+		return ok
+	}
+	sub, ok := m[key].(map[string]any)
+	if !ok || !DeleteValueAtPath(sub, rest) {
+		return false
+	}
+	if len(sub) == 0 {
+		delete(m, key)
+	}
+	return true
+}
+
 // Merge2Toml merge the config of global.
 func Merge2Toml(comp string, global, overwrite map[string]any) ([]byte, error) {
+	return Merge2TomlWithComments(comp, global, overwrite, nil)
+}
+
+// Merge2TomlWithComments merges the config of global like Merge2Toml, and
+// writes the comments above their keys, e.g. the comments of server_configs
+// kept in ServerConfigs.Comments.
+func Merge2TomlWithComments(comp string, global, overwrite map[string]any, comments ConfigComments) ([]byte, error) {
 	lhs := MergeConfig(global, overwrite)
 	buf := bytes.NewBufferString(fmt.Sprintf(`# WARNING: This file is auto-generated. Do not edit! All your modification will be overwritten!
 # You can use 'tiup cluster edit-config' and 'tiup cluster reload' to update the configuration
@@ -220,23 +292,174 @@ func Merge2Toml(comp string, global, overwrite map[string]any) ([]byte, error) {
 #     aa.b2.c4: value
 `, comp))
 
-	enc := toml.NewEncoder(buf)
+	var conf bytes.Buffer
+	enc := toml.NewEncoder(&conf)
 	enc.Indent = ""
 	err := enc.Encode(lhs)
 	if err != nil {
 		return nil, perrs.Trace(err)
 	}
+	buf.Write(commentToml(conf.Bytes(), comments))
 	return buf.Bytes(), nil
 }
 
+// ConfigOrigin is the layer a merged config value comes from.
+type ConfigOrigin string
+
+// Config layers, from the lowest to the highest precedence.
+const (
+	ConfigOriginGlobal   ConfigOrigin = "global"
+	ConfigOriginInstance ConfigOrigin = "instance"
+)
+
+// ConfigProvenance is a key of a merged config and where its value comes from.
+type ConfigProvenance struct {
+	// Key is the flattened key, e.g. "log.file.max-size".
+	Key    string
+	Value  any
+	Origin ConfigOrigin
+	// Overridden is set if the instance value replaces a different global one,
+	// which is kept in GlobalValue.
+	Overridden  bool
+	GlobalValue any
+}
+
+// MergeWithProvenance merges the global config of comp with the instance
+// overwrite like Merge2Toml, and reports the origin of every key of the
+// result, sorted by key.
+//
+// Global keys replaced as a whole by an instance value (e.g. an instance
+// sets "log" to a string while the global config has "log.level") are not in
+// the merged config and not reported; DiffConfigs(global, overwrite) lists
+// them.
+func MergeWithProvenance(comp string, global, overwrite map[string]any) ([]byte, []ConfigProvenance, error) {
+	data, err := Merge2Toml(comp, global, overwrite)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	globalFlat := FlattenMap(FoldMap(global))
+	instanceFlat := FlattenMap(FoldMap(overwrite))
+	merged := FlattenMap(MergeConfig(global, overwrite))
+	report := make([]ConfigProvenance, 0, len(merged))
+	for _, key := range sortedKeys(merged) {
+		p := ConfigProvenance{Key: key, Value: merged[key], Origin: ConfigOriginGlobal}
+		if _, ok := instanceFlat[key]; ok {
+			p.Origin = ConfigOriginInstance
+			if gv, ok := globalFlat[key]; ok && !configValueEqual(gv, p.Value) {
+				p.Overridden = true
+				p.GlobalValue = gv
+			}
+		}
+		report = append(report, p)
+	}
+	return data, report, nil
+}
+
+// ConfigDiffKind is the kind of change of a config key.
+type ConfigDiffKind string
+
+// Kinds of config key changes.
+const (
+	ConfigKeyAdded   ConfigDiffKind = "added"
+	ConfigKeyRemoved ConfigDiffKind = "removed"
+	ConfigKeyChanged ConfigDiffKind = "changed"
+)
+
+// ConfigDiff is a changed key between two configs.
+type ConfigDiff struct {
+	// Key is the flattened key, e.g. "log.file.max-size".
+	Key  string
+	Kind ConfigDiffKind
+	// Old is nil for an added key, New for a removed one.
+	Old any
+	New any
+}
+
+// DiffConfigs compares two configs key by key, sorted by key. Both are
+// normalized first, so "a.b: 1" and "a: {b: 1}" are the same config, and
+// numbers compare by value whatever their type (YAML and TOML decode
+// integers differently).
+func DiffConfigs(from, to map[string]any) []ConfigDiff {
+	lhs := FlattenMap(FoldMap(from))
+	rhs := FlattenMap(FoldMap(to))
+
+	var diffs []ConfigDiff
+	for _, key := range sortedKeys(lhs) {
+		nv, ok := rhs[key]
+		switch {
+		case !ok:
+			diffs = append(diffs, ConfigDiff{Key: key, Kind: ConfigKeyRemoved, Old: lhs[key]})
+		case !configValueEqual(lhs[key], nv):
+			diffs = append(diffs, ConfigDiff{Key: key, Kind: ConfigKeyChanged, Old: lhs[key], New: nv})
+		}
+	}
+	for key, nv := range rhs {
+		if _, ok := lhs[key]; !ok {
+			diffs = append(diffs, ConfigDiff{Key: key, Kind: ConfigKeyAdded, New: nv})
+		}
+	}
+	sort.SliceStable(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+	return diffs
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configValueEqual compares two config values, numbers by value.
+func configValueEqual(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	af, aok := configNumber(a)
+	bf, bok := configNumber(b)
+	if aok && bok {
+		return af == bf
+	}
+	as, aok := strKeyMap(a).([]any)
+	bs, bok := strKeyMap(b).([]any)
+	if !aok || !bok || len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		if !configValueEqual(as[i], bs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func configNumber(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
 func encodeRemoteCfg2Yaml(remote Remote) ([]byte, error) {
 	if len(remote.RemoteRead) == 0 && len(remote.RemoteWrite) == 0 {
 		return []byte{}, nil
 	}
+	remote, err := remote.resolve()
+	if err != nil {
+		return nil, err
+	}
 
 	buf := bytes.NewBufferString("")
 	enc := yaml.NewEncoder(buf)
-	err := enc.Encode(remote)
+	err = enc.Encode(remote)
 	if err != nil {
 		return nil, err
 	}
@@ -165,3 +165,317 @@ remote_read:
 	require.NoError(t, err)
 	require.Equal(t, yamlData, bs)
 }
+
+func TestEncodeRemoteCfgPreset(t *testing.T) {
+	bs, err := encodeRemoteCfg2Yaml(Remote{
+		RemoteWrite: []map[string]any{
+			{
+				"url":    "https://vm.example.com/api/v1/write",
+				"preset": "low-latency",
+				"queue_config": map[string]any{
+					"max_samples_per_send": 100,
+				},
+			},
+		},
+		RemoteRead: []map[string]any{
+			{"url": "http://127.0.0.1:8086/read", "preset": "recent"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `remote_write:
+    - queue_config:
+        batch_send_deadline: 1s
+        max_samples_per_send: 100
+      url: https://vm.example.com/api/v1/write
+remote_read:
+    - read_recent: true
+      url: http://127.0.0.1:8086/read
+`, string(bs))
+}
+
+func TestEncodeRemoteCfgInvalid(t *testing.T) {
+	for _, c := range []struct {
+		remote Remote
+		err    string
+	}{
+		{Remote{RemoteWrite: []map[string]any{{"queue_config": map[string]any{}}}}, "remote_write[0]: url is required"},
+		{Remote{RemoteWrite: []map[string]any{{"url": "127.0.0.1:8086/write"}}}, `invalid url "127.0.0.1:8086/write"`},
+		{Remote{RemoteRead: []map[string]any{{"url": "ftp://127.0.0.1/read"}}}, `remote_read[0]: invalid url "ftp://127.0.0.1/read"`},
+		{Remote{RemoteWrite: []map[string]any{{"url": "http://h/write", "preset": "fast"}}}, "unknown preset fast, expect one of high-throughput, low-latency"},
+		{Remote{RemoteWrite: []map[string]any{{"url": "http://h/write", "queue_cfg": map[string]any{}}}}, `unknown field "queue_cfg"`},
+		{Remote{RemoteWrite: []map[string]any{{"url": "http://h/write", "queue_config": map[string]any{"capacity": "10k"}}}}, "queue_config.capacity must be an integer, got 10k"},
+		{Remote{RemoteWrite: []map[string]any{{"url": "http://h/write", "queue_config": map[string]any{"batch_send_deadline": "5 min"}}}}, "queue_config.batch_send_deadline must be a duration"},
+		{Remote{RemoteRead: []map[string]any{{"url": "http://h/read", "read_recent": "yes"}}}, "read_recent must be a bool"},
+		{Remote{RemoteWrite: []map[string]any{{"url": "http://a/write", "name": "vm"}, {"url": "http://b/write", "name": "vm"}}}, `remote_write[1]: name "vm" is already used by remote_write[0]`},
+	} {
+		_, err := encodeRemoteCfg2Yaml(c.remote)
+		require.ErrorContains(t, err, c.err)
+	}
+}
+
+func TestMergeWithProvenance(t *testing.T) {
+	yamlData := []byte(`
+server_configs:
+  tikv:
+    log.level: info
+    storage:
+      reserve-space: 0
+    raftstore.capacity: 10GB
+tikv_servers:
+  - host: 127.0.0.1
+    config:
+      log:
+        level: debug
+      raftstore.capacity: 10GB
+      server.grpc-concurrency: 4
+`)
+	topo := new(Specification)
+	require.NoError(t, yaml.Unmarshal(yamlData, topo))
+
+	data, report, err := MergeWithProvenance("tikv", topo.ServerConfigs.TiKV, topo.TiKVServers[0].Config)
+	require.NoError(t, err)
+	want, err := Merge2Toml("tikv", topo.ServerConfigs.TiKV, topo.TiKVServers[0].Config)
+	require.NoError(t, err)
+	require.Equal(t, want, data)
+
+	require.Equal(t, []ConfigProvenance{
+		{Key: "log.level", Value: "debug", Origin: ConfigOriginInstance, Overridden: true, GlobalValue: "info"},
+		{Key: "raftstore.capacity", Value: "10GB", Origin: ConfigOriginInstance},
+		{Key: "server.grpc-concurrency", Value: 4, Origin: ConfigOriginInstance},
+		{Key: "storage.reserve-space", Value: 0, Origin: ConfigOriginGlobal},
+	}, report)
+}
+
+func TestDiffConfigs(t *testing.T) {
+	from := map[string]any{
+		"a.b":     1,
+		"a.c":     "x",
+		"d":       []any{1, 2},
+		"removed": true,
+	}
+	to := map[string]any{
+		"a": map[string]any{
+			"b": int64(1),
+			"c": "y",
+		},
+		"d":     []any{int64(1), 2.0},
+		"e.f.g": false,
+	}
+
+	require.Equal(t, []ConfigDiff{
+		{Key: "a.c", Kind: ConfigKeyChanged, Old: "x", New: "y"},
+		{Key: "e.f.g", Kind: ConfigKeyAdded, New: false},
+		{Key: "removed", Kind: ConfigKeyRemoved, Old: true},
+	}, DiffConfigs(from, to))
+	require.Empty(t, DiffConfigs(from, from))
+}
+
+func TestValidateServerConfig(t *testing.T) {
+	yamlData := []byte(`
+server_configs:
+  tidb:
+    preformance.max-procs: 4
+    performance.txn-total-size-limit: "10GB"
+    performance.feedback-probability: 1
+    log.slow-threshold: 300
+    labels.zone: z1
+    enable-global-kill: true
+    server-memory-quota: 0
+    status.record-db-label: true
+  tikv:
+    storage.reserve-space: 0
+    raftstore.capacity: 10GB
+    raftstor.sync-log: true
+    rocksdbb.defaultcf.block-size: 64KB
+    rocksdb.max-background-jobs: 4.0
+  tiflash:
+    anything: 1
+`)
+	topo := new(Specification)
+	require.NoError(t, yaml.Unmarshal(yamlData, topo))
+
+	require.Equal(t, []ConfigIssue{
+		{Key: "performance.txn-total-size-limit", Value: "10GB", Message: "expect int, got string (10GB)"},
+		{Key: "preformance.max-procs", Value: 4, Message: `unknown key, did you mean "performance.max-procs"?`},
+	}, ValidateServerConfig(ComponentTiDB, topo.ServerConfigs.TiDB))
+
+	require.Equal(t, []ConfigIssue{
+		{Key: "raftstor.sync-log", Value: true, Message: `unknown key, did you mean "raftstore.sync-log"?`},
+		{Key: "rocksdb.max-background-jobs", Value: 4.0, Message: "expect int, got float64 (4)"},
+		{Key: "rocksdbb.defaultcf.block-size", Value: "64KB", Message: `unknown key, did you mean "rocksdb.defaultcf.block-size"?`},
+	}, ValidateServerConfig(ComponentTiKV, topo.ServerConfigs.TiKV))
+
+	require.Empty(t, ValidateServerConfig(ComponentTiFlash, topo.ServerConfigs.TiFlash))
+	// The keys the schema doesn't list are only reported if they look like a
+	// typo of one it does.
+	require.Equal(t, []ConfigIssue{
+		{Key: "tso-save-intervall", Value: "3s", Message: `unknown key, did you mean "tso-save-interval"?`},
+	}, ValidateServerConfig(ComponentPD, map[string]any{
+		"replication.max-replicas":           1,
+		"replication.enable-placement-rules": true,
+		"no-such-key":                        1,
+		"tso-save-intervall":                 "3s",
+	}))
+}
+
+func TestTypedValueFromPath(t *testing.T) {
+	yamlData := []byte(`
+server_configs:
+  tidb:
+    performance.max-procs: 4
+    log:
+      level: info
+      enable-slow-log: false
+`)
+	topo := new(Specification)
+	require.NoError(t, yaml.Unmarshal(yamlData, topo))
+	conf := topo.ServerConfigs.TiDB
+
+	n, ok := GetIntFromPath(conf, "performance.max-procs")
+	require.True(t, ok)
+	require.Equal(t, int64(4), n)
+	_, ok = GetIntFromPath(conf, "log.level")
+	require.False(t, ok)
+
+	b, ok := GetBoolFromPath(conf, "log.enable-slow-log")
+	require.True(t, ok)
+	require.False(t, b)
+	_, ok = GetBoolFromPath(conf, "log.missing")
+	require.False(t, ok)
+
+	s, ok := GetStringFromPath(conf, "log.level")
+	require.True(t, ok)
+	require.Equal(t, "info", s)
+	_, ok = GetStringFromPath(conf, "performance")
+	require.False(t, ok)
+}
+
+func TestSetAndDeleteValueAtPath(t *testing.T) {
+	m := FoldMap(map[string]any{
+		"log.level":   "info",
+		"log.file":    map[string]any{"max-size": 300},
+		"port":        4000,
+		"labels.zone": "z1",
+	})
+
+	SetValueAtPath(m, "log.file.max-days", 7)
+	SetValueAtPath(m, "performance.max-procs", 4)
+	// A value that is not a table is replaced.
+	SetValueAtPath(m, "port.inner", 1)
+	require.Equal(t, map[string]any{
+		"log": map[string]any{
+			"level": "info",
+			"file":  map[string]any{"max-size": 300, "max-days": 7},
+		},
+		"port":        map[string]any{"inner": 1},
+		"labels":      map[string]any{"zone": "z1"},
+		"performance": map[string]any{"max-procs": 4},
+	}, m)
+
+	require.True(t, DeleteValueAtPath(m, "labels.zone"))
+	require.True(t, DeleteValueAtPath(m, "log.file"))
+	require.False(t, DeleteValueAtPath(m, "log.file.max-size"))
+	require.False(t, DeleteValueAtPath(m, "log.level.x"))
+	require.False(t, DeleteValueAtPath(m, "missing"))
+	require.Equal(t, map[string]any{
+		"log":         map[string]any{"level": "info"},
+		"port":        map[string]any{"inner": 1},
+		"performance": map[string]any{"max-procs": 4},
+	}, m)
+}
+
+func TestMerge2TomlWithComments(t *testing.T) {
+	yamlData := []byte(`
+server_configs:
+  tidb:
+    # raised for the nightly bulk load
+    performance.txn-total-size-limit: 10737418240
+    log:
+      # audited 2026-03
+      level: warn # keep quiet
+    "a.b": 1
+`)
+
+	topo := new(Specification)
+	require.NoError(t, yaml.Unmarshal(yamlData, topo))
+	require.Equal(t, map[string]ConfigComments{
+		"tidb": {
+			"performance.txn-total-size-limit": "raised for the nightly bulk load",
+			"log.level":                        "audited 2026-03\nkeep quiet",
+		},
+	}, topo.ServerConfigs.Comments)
+
+	// The comments survive the meta file.
+	data, err := yaml.Marshal(topo)
+	require.NoError(t, err)
+	reloaded := new(Specification)
+	require.NoError(t, yaml.Unmarshal(data, reloaded))
+	require.Equal(t, topo.ServerConfigs.Comments, reloaded.ServerConfigs.Comments)
+
+	got, err := Merge2TomlWithComments("tidb", topo.ServerConfigs.TiDB, map[string]any{"log.level": "info"}, topo.ServerConfigs.Comments["tidb"])
+	require.NoError(t, err)
+	require.Contains(t, string(got), "\n[log]\n# audited 2026-03\n# keep quiet\nlevel = \"info\"\n")
+	require.Contains(t, string(got), "\n[performance]\n# raised for the nightly bulk load\ntxn-total-size-limit = 10737418240\n")
+
+	plain, err := Merge2Toml("tidb", topo.ServerConfigs.TiDB, map[string]any{"log.level": "info"})
+	require.NoError(t, err)
+	require.NotContains(t, string(plain), "audited")
+}
+
+func TestInstanceConfigComments(t *testing.T) {
+	yamlData := []byte(`
+server_configs:
+  tikv:
+    # shared by all the stores
+    storage.reserve-space: 2GB
+    log.level: warn # global level
+tikv_servers:
+  - host: 172.16.5.140
+    config:
+      # the store on the small disk
+      storage.reserve-space: 1GB
+tiflash_servers:
+  - host: 172.16.5.141
+    config:
+      logger.level: debug # tracing a replica
+    learner_config:
+      # investigated with the vendor
+      log-level: debug
+`)
+
+	topo := new(Specification)
+	require.NoError(t, yaml.Unmarshal(yamlData, topo))
+	require.Equal(t, ConfigComments{"storage.reserve-space": "the store on the small disk"}, topo.TiKVServers[0].ConfigComments)
+	require.Equal(t, ConfigComments{"logger.level": "tracing a replica"}, topo.TiFlashServers[0].ConfigComments)
+	require.Equal(t, ConfigComments{"log-level": "investigated with the vendor"}, topo.TiFlashServers[0].LearnerConfigComments)
+
+	// The comments survive the meta file.
+	data, err := yaml.Marshal(topo)
+	require.NoError(t, err)
+	reloaded := new(Specification)
+	require.NoError(t, yaml.Unmarshal(data, reloaded))
+	require.Equal(t, topo.TiKVServers[0].ConfigComments, reloaded.TiKVServers[0].ConfigComments)
+	require.Equal(t, topo.TiFlashServers[0].ConfigComments, reloaded.TiFlashServers[0].ConfigComments)
+	require.Equal(t, topo.TiFlashServers[0].LearnerConfigComments, reloaded.TiFlashServers[0].LearnerConfigComments)
+
+	// The comment of an instance key replaces the global one.
+	spec := topo.TiKVServers[0]
+	got, err := Merge2TomlWithComments("tikv", topo.ServerConfigs.TiKV, spec.Config, topo.ServerConfigs.Comments["tikv"].Merge(spec.ConfigComments))
+	require.NoError(t, err)
+	require.Contains(t, string(got), "\n[log]\n# global level\nlevel = \"warn\"\n")
+	require.Contains(t, string(got), "\n[storage]\n# the store on the small disk\nreserve-space = \"1GB\"\n")
+	require.NotContains(t, string(got), "shared by all the stores")
+}
+
+func TestSplitTomlKey(t *testing.T) {
+	parts, rest := splitTomlKey(`a."b.c" . 'd' = 1`)
+	require.Equal(t, []string{"a", "b.c", "d"}, parts)
+	require.Equal(t, "= 1", rest)
+
+	parts, rest = splitTomlKey(`"x\"y"]`)
+	require.Equal(t, []string{`x"y`}, parts)
+	require.Equal(t, "]", rest)
+
+	parts, _ = splitTomlKey(`= 1`)
+	require.Nil(t, parts)
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/pingcap/tiup/pkg/utils"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -134,6 +135,9 @@ type (
 		CDC             map[string]any    `yaml:"cdc"`
 		TiKVCDC         map[string]any    `yaml:"kvcdc"`
 		Grafana         map[string]string `yaml:"grafana"`
+		// Comments are the comments of the config keys in the topology, by
+		// component, e.g. "tidb", they are written to the generated configs.
+		Comments map[string]ConfigComments `yaml:"-" comments:"*"`
 	}
 
 	// ComponentVersions represents the versions of components
@@ -390,12 +394,16 @@ func AllComponentNames() (roles []string) {
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface,
-// it sets the default values when unmarshaling the topology file
+// it sets the default values when unmarshaling the topology file,
+// and keeps the comments of the configs (see LoadConfigComments)
 func (s *Specification) UnmarshalYAML(unmarshal func(any) error) error {
 	type topology Specification
 	if err := unmarshal((*topology)(s)); err != nil {
 		return err
 	}
+	if err := LoadConfigComments(unmarshal, s); err != nil {
+		return err
+	}
 
 	// set default values from tag
 	if err := defaults.Set(s); err != nil {
@@ -447,6 +455,18 @@ func (s *Specification) UnmarshalYAML(unmarshal func(any) error) error {
 	return s.Validate()
 }
 
+// MarshalYAML implements the yaml.Marshaler interface,
+// it writes the comments of the configs back above their keys
+func (s Specification) MarshalYAML() (any, error) {
+	type topology Specification
+	var node yaml.Node
+	if err := node.Encode(topology(s)); err != nil {
+		return nil, err
+	}
+	AttachConfigComments(&node, &s)
+	return &node, nil
+}
+
 func findField(v reflect.Value, fieldName string) (int, bool) {
 	for i := 0; i < reflect.Indirect(v).NumField(); i++ {
 		if reflect.Indirect(v).Type().Field(i).Name == fieldName {
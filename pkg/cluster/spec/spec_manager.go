@@ -216,10 +216,13 @@ func (s *SpecManager) ScaleOutLock(clusterName string) (Topology, error) {
 
 	fname := s.Path(clusterName, ScaleOutLockName)
 
-	// UnMarshal file lock
-	topo := &Specification{}
-	err := ParseTopologyYaml(fname, topo)
+	// UnMarshal file lock, written by us: never interpolate it
+	data, err := os.ReadFile(fname)
 	if err != nil {
+		return nil, ErrTopologyReadFailed.Wrap(err, "Failed to read scale-out file lock %s", fname)
+	}
+	topo := &Specification{}
+	if err := decodeTopologyYaml(fname, data, topo); err != nil {
 		return nil, err
 	}
 	return topo, nil
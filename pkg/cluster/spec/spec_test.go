@@ -16,10 +16,13 @@ package spec
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/BurntSushi/toml"
+	"github.com/pingcap/tiup/embed"
 	"github.com/pingcap/tiup/pkg/cluster/template/scripts"
 	"github.com/pingcap/tiup/pkg/meta"
 	"github.com/pingcap/tiup/pkg/tidbver"
@@ -863,3 +866,100 @@ tidb_servers:
 	require.Error(t, err)
 	require.True(t, strings.Contains(err.Error(), "not found"))
 }
+
+// checkTopologySchema is a minimal JSON Schema checker, enough for the
+// keywords TopologySchema uses.
+func checkTopologySchema(defs map[string]any, schema map[string]any, val any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: unknown ref %s", path, ref)
+		}
+		schema = def
+	}
+	switch schema["type"] {
+	case "object":
+		m, ok := val.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expect object, got %T", path, val)
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for k, v := range m {
+			ps, ok := props[k].(map[string]any)
+			if !ok {
+				switch ap := schema["additionalProperties"].(type) {
+				case bool:
+					if !ap {
+						return fmt.Errorf("%s: unknown field %q", path, k)
+					}
+					continue
+				case map[string]any:
+					ps = ap
+				default:
+					continue
+				}
+			}
+			if err := checkTopologySchema(defs, ps, v, path+"."+k); err != nil {
+				return err
+			}
+		}
+		required, _ := schema["required"].([]any)
+		for _, r := range required {
+			if _, ok := m[r.(string)]; !ok {
+				return fmt.Errorf("%s: missing %q", path, r)
+			}
+		}
+	case "array":
+		items, ok := val.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expect array, got %T", path, val)
+		}
+		is, _ := schema["items"].(map[string]any)
+		for i, item := range items {
+			if err := checkTopologySchema(defs, is, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string", "boolean", "integer":
+		want := map[any]string{"string": "string", "boolean": "bool", "integer": "int"}[schema["type"]]
+		if got := fmt.Sprintf("%T", val); got != want {
+			return fmt.Errorf("%s: expect %s, got %s", path, want, got)
+		}
+	}
+	return nil
+}
+
+func TestTopologySchema(t *testing.T) {
+	data, err := json.Marshal(TopologySchema())
+	require.NoError(t, err)
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+	defs := schema["$defs"].(map[string]any)
+
+	tidb := defs["TiDBSpec"].(map[string]any)
+	require.Equal(t, []any{"host"}, tidb["required"])
+	require.Equal(t, false, tidb["additionalProperties"])
+	props := tidb["properties"].(map[string]any)
+	require.Equal(t, 4000.0, props["port"].(map[string]any)["default"])
+	require.Equal(t, "#/$defs/tidb-config", props["config"].(map[string]any)["$ref"])
+	require.Equal(t, "#/$defs/tidb-config", defs["ServerConfigs"].(map[string]any)["properties"].(map[string]any)["tidb"].(map[string]any)["$ref"])
+	require.Equal(t, map[string]any{"type": "integer"}, defs["tidb-config"].(map[string]any)["properties"].(map[string]any)["performance.max-procs"])
+
+	for _, name := range []string{"minimal.yaml", "multi-dc.yaml", "topology.example.yaml"} {
+		example, err := embed.ReadExample("examples/cluster/" + name)
+		require.NoError(t, err)
+		var topo map[string]any
+		require.NoError(t, yaml.Unmarshal(example, &topo))
+		require.NoError(t, checkTopologySchema(defs, schema, topo, name))
+	}
+
+	for _, bad := range []string{
+		"tidb_servers:\n  - host: 10.0.1.1\n    prot: 4000\n",
+		"tidb_servers:\n  - port: 4000\n",
+		"server_configs:\n  tidb:\n    performance.max-procs: four\n",
+	} {
+		var topo map[string]any
+		require.NoError(t, yaml.Unmarshal([]byte(bad), &topo))
+		require.Error(t, checkTopologySchema(defs, schema, topo, "bad"), bad)
+	}
+}
@@ -39,30 +39,32 @@ import (
 
 // TiFlashSpec represents the TiFlash topology specification in topology.yaml
 type TiFlashSpec struct {
-	Host                 string               `yaml:"host"`
-	ManageHost           string               `yaml:"manage_host,omitempty" validate:"manage_host:editable"`
-	SSHPort              int                  `yaml:"ssh_port,omitempty" validate:"ssh_port:editable"`
-	Patched              bool                 `yaml:"patched,omitempty"`
-	IgnoreExporter       bool                 `yaml:"ignore_exporter,omitempty"`
-	TCPPort              int                  `yaml:"tcp_port" default:"9000"`
-	HTTPPort             int                  `yaml:"http_port" default:"8123"` // Deprecated since v7.1.0
-	FlashServicePort     int                  `yaml:"flash_service_port" default:"3930"`
-	FlashProxyPort       int                  `yaml:"flash_proxy_port" default:"20170"`
-	FlashProxyStatusPort int                  `yaml:"flash_proxy_status_port" default:"20292"`
-	StatusPort           int                  `yaml:"metrics_port" default:"8234"`
-	DeployDir            string               `yaml:"deploy_dir,omitempty"`
-	DataDir              string               `yaml:"data_dir,omitempty" validate:"data_dir:expandable"`
-	LogDir               string               `yaml:"log_dir,omitempty"`
-	TmpDir               string               `yaml:"tmp_path,omitempty"`
-	Offline              bool                 `yaml:"offline,omitempty"`
-	Source               string               `yaml:"source,omitempty" validate:"source:editable"`
-	NumaNode             string               `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
-	NumaCores            string               `yaml:"numa_cores,omitempty" validate:"numa_cores:editable"`
-	Config               map[string]any       `yaml:"config,omitempty" validate:"config:ignore"`
-	LearnerConfig        map[string]any       `yaml:"learner_config,omitempty" validate:"learner_config:ignore"`
-	ResourceControl      meta.ResourceControl `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
-	Arch                 string               `yaml:"arch,omitempty"`
-	OS                   string               `yaml:"os,omitempty"`
+	Host                  string               `yaml:"host"`
+	ManageHost            string               `yaml:"manage_host,omitempty" validate:"manage_host:editable"`
+	SSHPort               int                  `yaml:"ssh_port,omitempty" validate:"ssh_port:editable"`
+	Patched               bool                 `yaml:"patched,omitempty"`
+	IgnoreExporter        bool                 `yaml:"ignore_exporter,omitempty"`
+	TCPPort               int                  `yaml:"tcp_port" default:"9000"`
+	HTTPPort              int                  `yaml:"http_port" default:"8123"` // Deprecated since v7.1.0
+	FlashServicePort      int                  `yaml:"flash_service_port" default:"3930"`
+	FlashProxyPort        int                  `yaml:"flash_proxy_port" default:"20170"`
+	FlashProxyStatusPort  int                  `yaml:"flash_proxy_status_port" default:"20292"`
+	StatusPort            int                  `yaml:"metrics_port" default:"8234"`
+	DeployDir             string               `yaml:"deploy_dir,omitempty"`
+	DataDir               string               `yaml:"data_dir,omitempty" validate:"data_dir:expandable"`
+	LogDir                string               `yaml:"log_dir,omitempty"`
+	TmpDir                string               `yaml:"tmp_path,omitempty"`
+	Offline               bool                 `yaml:"offline,omitempty"`
+	Source                string               `yaml:"source,omitempty" validate:"source:editable"`
+	NumaNode              string               `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
+	NumaCores             string               `yaml:"numa_cores,omitempty" validate:"numa_cores:editable"`
+	Config                map[string]any       `yaml:"config,omitempty" validate:"config:ignore"`
+	ConfigComments        ConfigComments       `yaml:"-" validate:"config_comments:ignore" comments:"config"`
+	LearnerConfig         map[string]any       `yaml:"learner_config,omitempty" validate:"learner_config:ignore"`
+	LearnerConfigComments ConfigComments       `yaml:"-" validate:"learner_config_comments:ignore" comments:"learner_config"`
+	ResourceControl       meta.ResourceControl `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
+	Arch                  string               `yaml:"arch,omitempty"`
+	OS                    string               `yaml:"os,omitempty"`
 }
 
 // Status queries current status of the instance
@@ -788,7 +790,7 @@ func (i *TiFlashInstance) InitConfig(
 		return err
 	}
 
-	err = i.mergeTiFlashLearnerServerConfig(ctx, e, conf, spec.LearnerConfig, paths)
+	err = i.mergeTiFlashLearnerServerConfig(ctx, e, conf, spec.LearnerConfig, topo.ServerConfigs.Comments["tiflash-learner"].Merge(spec.LearnerConfigComments), paths)
 	if err != nil {
 		return err
 	}
@@ -803,7 +805,7 @@ func (i *TiFlashInstance) InitConfig(
 		return err
 	}
 
-	return i.MergeServerConfig(ctx, e, conf, nil, paths)
+	return i.MergeServerConfig(ctx, e, conf, nil, topo.ServerConfigs.Comments["tiflash"].Merge(spec.ConfigComments), paths)
 }
 
 // ScaleConfig deploy temporary config on scaling
@@ -64,6 +64,7 @@ type TiKVSpec struct {
 	NumaNode            string               `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
 	NumaCores           string               `yaml:"numa_cores,omitempty" validate:"numa_cores:editable"`
 	Config              map[string]any       `yaml:"config,omitempty" validate:"config:ignore"`
+	ConfigComments      ConfigComments       `yaml:"-" validate:"config_comments:ignore" comments:"config"`
 	ResourceControl     meta.ResourceControl `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
 	Arch                string               `yaml:"arch,omitempty"`
 	OS                  string               `yaml:"os,omitempty"`
@@ -295,7 +296,7 @@ func (i *TiKVInstance) InitConfig(
 		return err
 	}
 
-	if err := i.MergeServerConfig(ctx, e, globalConfig, spec.Config, paths); err != nil {
+	if err := i.MergeServerConfig(ctx, e, globalConfig, spec.Config, topo.ServerConfigs.Comments["tikv"].Merge(spec.ConfigComments), paths); err != nil {
 		return err
 	}
 
@@ -48,6 +48,7 @@ type TiKVCDCSpec struct {
 	Source          string               `yaml:"source,omitempty" validate:"source:editable"`
 	NumaNode        string               `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
 	Config          map[string]any       `yaml:"config,omitempty" validate:"config:ignore"`
+	ConfigComments  ConfigComments       `yaml:"-" validate:"config_comments:ignore" comments:"config"`
 	ResourceControl meta.ResourceControl `yaml:"resource_control,omitempty" validate:"resource_control:editable"`
 	Arch            string               `yaml:"arch,omitempty"`
 	OS              string               `yaml:"os,omitempty"`
@@ -243,7 +244,7 @@ func (i *TiKVCDCInstance) InitConfig(
 		return err
 	}
 
-	return i.MergeServerConfig(ctx, e, globalConfig, instanceConfig, paths)
+	return i.MergeServerConfig(ctx, e, globalConfig, instanceConfig, topo.ServerConfigs.Comments["kvcdc"].Merge(spec.ConfigComments), paths)
 }
 
 // setTLSConfig set TLS Config to support enable/disable TLS
@@ -0,0 +1,175 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TopologySchema returns a JSON Schema (draft 2020-12) of the topology YAML
+// of a TiDB cluster, generated from the yaml tags of Specification. Editors
+// and CI can use it to check a topology file before deploying it.
+//
+// Unknown fields are rejected like ParseTopologyYaml does, the default tags
+// become defaults, and the hosts of the instances are required. The server
+// configs of the components with a config schema (see ValidateServerConfig)
+// list their known keys, in the flattened form, without rejecting the other
+// ones.
+func TopologySchema() map[string]any {
+	g := &topologySchemaGenerator{defs: make(map[string]any)}
+	root := g.schema(reflect.TypeOf(Specification{}))
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "TiDB cluster topology"
+	root["$defs"] = g.defs
+	return root
+}
+
+type topologySchemaGenerator struct {
+	defs map[string]any
+}
+
+func (g *topologySchemaGenerator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		ref := map[string]any{"$ref": "#/$defs/" + t.Name()}
+		if _, ok := g.defs[t.Name()]; !ok {
+			// Registered before building, for recursive types.
+			g.defs[t.Name()] = nil
+			g.defs[t.Name()] = g.object(t)
+		}
+		return ref
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *topologySchemaGenerator) object(t reflect.Type) map[string]any {
+	// The config of an instance has the shape of the server configs of its
+	// component.
+	var role string
+	if spec, ok := reflect.New(t).Interface().(InstanceSpec); ok {
+		role = spec.Role()
+	}
+
+	props := make(map[string]any)
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		var s map[string]any
+		switch {
+		case t == reflect.TypeOf(ServerConfigs{}):
+			s = g.serverConfig(name, f.Type)
+		case role != "" && name == "config":
+			s = g.serverConfig(role, f.Type)
+		default:
+			s = g.schema(f.Type)
+		}
+		def, hasDefault := f.Tag.Lookup("default")
+		if hasDefault {
+			if v, ok := schemaDefault(f.Type, def); ok {
+				s["default"] = v
+			}
+		}
+		props[name] = s
+		if name == "host" {
+			required = append(required, name)
+		}
+	}
+	s := map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// serverConfig returns the schema of the server configs of comp, with the
+// keys of its config schema if it has one.
+func (g *topologySchemaGenerator) serverConfig(comp string, t reflect.Type) map[string]any {
+	config := loadConfigSchema(comp)
+	if config == nil {
+		return g.schema(t)
+	}
+	name := comp + "-config"
+	if _, ok := g.defs[name]; !ok {
+		props := make(map[string]any, len(config.Keys))
+		for key, typ := range config.Keys {
+			props[key] = configKeySchema(typ)
+		}
+		g.defs[name] = map[string]any{"type": "object", "properties": props}
+	}
+	return map[string]any{"$ref": "#/$defs/" + name}
+}
+
+func configKeySchema(typ string) map[string]any {
+	switch typ {
+	case "bool":
+		return map[string]any{"type": "boolean"}
+	case "int":
+		return map[string]any{"type": "integer"}
+	case "float":
+		return map[string]any{"type": "number"}
+	case "string":
+		return map[string]any{"type": "string"}
+	case "size":
+		return map[string]any{"type": []string{"string", "integer"}}
+	case "array":
+		return map[string]any{"type": "array"}
+	}
+	return map[string]any{}
+}
+
+func schemaDefault(t reflect.Type, def string) (any, bool) {
+	switch t.Kind() {
+	case reflect.String:
+		return def, true
+	case reflect.Bool:
+		v, err := strconv.ParseBool(def)
+		return v, err == nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseInt(def, 10, 64)
+		return v, err == nil
+	}
+	return nil, false
+}
@@ -41,16 +41,17 @@ type TSOSpec struct {
 	SSHPort             int    `yaml:"ssh_port,omitempty" validate:"ssh_port:editable"`
 	IgnoreExporter      bool   `yaml:"ignore_exporter,omitempty"`
 	// Use Name to get the name with a default value if it's empty.
-	Name      string         `yaml:"name,omitempty"`
-	Port      int            `yaml:"port" default:"3379"`
-	DeployDir string         `yaml:"deploy_dir,omitempty"`
-	DataDir   string         `yaml:"data_dir,omitempty"`
-	LogDir    string         `yaml:"log_dir,omitempty"`
-	Source    string         `yaml:"source,omitempty" validate:"source:editable"`
-	NumaNode  string         `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
-	Config    map[string]any `yaml:"config,omitempty" validate:"config:ignore"`
-	Arch      string         `yaml:"arch,omitempty"`
-	OS        string         `yaml:"os,omitempty"`
+	Name           string         `yaml:"name,omitempty"`
+	Port           int            `yaml:"port" default:"3379"`
+	DeployDir      string         `yaml:"deploy_dir,omitempty"`
+	DataDir        string         `yaml:"data_dir,omitempty"`
+	LogDir         string         `yaml:"log_dir,omitempty"`
+	Source         string         `yaml:"source,omitempty" validate:"source:editable"`
+	NumaNode       string         `yaml:"numa_node,omitempty" validate:"numa_node:editable"`
+	Config         map[string]any `yaml:"config,omitempty" validate:"config:ignore"`
+	ConfigComments ConfigComments `yaml:"-" validate:"config_comments:ignore" comments:"config"`
+	Arch           string         `yaml:"arch,omitempty"`
+	OS             string         `yaml:"os,omitempty"`
 }
 
 // Status queries current status of the instance
@@ -258,7 +259,7 @@ func (i *TSOInstance) InitConfig(
 		return err
 	}
 
-	if err := i.MergeServerConfig(ctx, e, globalConfig, spec.Config, paths); err != nil {
+	if err := i.MergeServerConfig(ctx, e, globalConfig, spec.Config, topo.ServerConfigs.Comments["tso"].Merge(spec.ConfigComments), paths); err != nil {
 		return err
 	}
 
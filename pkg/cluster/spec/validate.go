@@ -1036,6 +1036,18 @@ func (s *Specification) validateTiFlashConfigs() error {
 	return nil
 }
 
+// validateMonitorRemoteConfig checks the remote_write and remote_read entries
+// of the monitoring servers, so a mistake fails the deployment instead of
+// Prometheus later
+func (s *Specification) validateMonitorRemoteConfig() error {
+	for _, m := range s.Monitors {
+		if _, err := m.RemoteConfig.resolve(); err != nil {
+			return errors.Annotatef(err, "invalid remote_config of monitoring server %s", m.Host)
+		}
+	}
+	return nil
+}
+
 // validateMonitorAgent checks for conflicts in topology for different ignore_exporter
 // settings for multiple instances on the same host / IP
 func (s *Specification) validateMonitorAgent() error {
@@ -1108,6 +1120,7 @@ func (s *Specification) Validate() error {
 		s.validateTiSparkSpec,
 		s.validateTiFlashConfigs,
 		s.validateMonitorAgent,
+		s.validateMonitorRemoteConfig,
 	}
 
 	for _, v := range validators {
@@ -94,6 +94,14 @@ const (
 	// EnvNameSCPPath is the variable name by which user can specific the executable scp binary path
 	EnvNameSCPPath = "TIUP_SCP_PATH"
 
+	// EnvNameInterpolateEnv lists, comma separated, the environment variables
+	// that ${VAR} references in topology and config files may read
+	EnvNameInterpolateEnv = "TIUP_INTERPOLATE_ENV"
+
+	// EnvNameRedactKeys lists, comma separated, the config key patterns whose
+	// values are hidden when configs are printed, in addition to the defaults
+	EnvNameRedactKeys = "TIUP_REDACT_KEYS"
+
 	// EnvNameKeepSourceTarget is the variable name by which user can keep the source target or not
 	EnvNameKeepSourceTarget = "TIUP_KEEP_SOURCE_TARGET"
 
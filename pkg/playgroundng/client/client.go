@@ -0,0 +1,254 @@
+// Package client controls running playground-ng clusters through their local
+// HTTP command server, for the playground-ng CLI itself as well as for test
+// frameworks and tools that would otherwise shell out to it.
+//
+//	target, err := client.Find("my-cluster")
+//	...
+//	c := client.New(target.Addr()).WithToken(target.Token)
+//	reply, err := c.ScaleOut(ctx, client.ScaleOutRequest{Service: "tikv", Count: 1, Wait: "up"})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
+// Client sends commands to the command server of a playground.
+type Client struct {
+	addr  string
+	token string
+	http  *http.Client
+}
+
+// New returns a client of the command server listening on addr (host:port),
+// see Target.Addr.
+func New(addr string) *Client {
+	return &Client{addr: addr, http: &http.Client{}}
+}
+
+// WithToken returns a copy of c sending token, which a playground started
+// with a read-only token requires: see Target.Token.
+func (c *Client) WithToken(token string) *Client {
+	cc := *c
+	cc.token = token
+	return &cc
+}
+
+// CommandError is a command the playground replied to with a failure.
+type CommandError struct {
+	Reply  Reply
+	Status string
+}
+
+func (e *CommandError) Error() string {
+	if e.Reply.Error != "" {
+		return e.Reply.Error
+	}
+	return fmt.Sprintf("command failed (status: %s)", e.Status)
+}
+
+// IsStopping reports whether err is a command rejected because the playground
+// is stopping.
+func IsStopping(err error) bool {
+	var cmdErr *CommandError
+	return stdErrors.As(err, &cmdErr) && cmdErr.Reply.Code == CodeStopping
+}
+
+// IsTimeout reports whether err is a command that exceeded its deadline.
+func IsTimeout(err error) bool {
+	var cmdErr *CommandError
+	return stdErrors.As(err, &cmdErr) && cmdErr.Reply.Code == CodeTimeout
+}
+
+// IsUnauthorized reports whether err is a request rejected because the client
+// has no token, or an invalid one.
+func IsUnauthorized(err error) bool {
+	var cmdErr *CommandError
+	return stdErrors.As(err, &cmdErr) && cmdErr.Reply.Code == CodeUnauthorized
+}
+
+// IsForbidden reports whether err is a command rejected because the token of
+// the client is read-only, or because the browser page sending it is from an
+// origin only allowed by --cors-origin '*'.
+func IsForbidden(err error) bool {
+	var cmdErr *CommandError
+	return stdErrors.As(err, &cmdErr) && cmdErr.Reply.Code == CodeForbidden
+}
+
+// deadlineReplyMargin is how much earlier than the deadline of its context
+// Send asks the playground to give up a command, leaving time for the reply.
+const deadlineReplyMargin = 500 * time.Millisecond
+
+// Send sends cmd and calls onReply, if set, with each reply: one, or a stream
+// of them for "stop". A failed reply ends with a *CommandError; the command
+// server failing to answer with an UnreachableError.
+//
+// Unless cmd has a Deadline, the deadline of ctx, if any, is sent as the
+// deadline of the command, so that a client giving up gets a CodeTimeout
+// reply rather than leaving the command running.
+//
+// Once "stop" is accepted, a stream cut short is not an error: the command
+// server went away, which is what stopping is about.
+func (c *Client) Send(ctx context.Context, cmd Command, onReply func(*Reply)) error {
+	if deadline, ok := ctx.Deadline(); ok && cmd.Deadline == nil && honorsDeadline(cmd.Type) {
+		deadline = deadline.Add(-deadlineReplyMargin)
+		cmd.Deadline = &deadline
+	}
+	data, err := json.Marshal(&cmd)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/command", c.addr), bytes.NewReader(data))
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if cmd.Type == StopCommandType {
+		req.Header.Set("Accept", StreamContentType+", application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return UnreachableError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), StreamContentType) {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		var reply Reply
+		if err := json.Unmarshal(body, &reply); err != nil {
+			return errors.Annotatef(err, "invalid command server response (status: %s)", resp.Status)
+		}
+		return handleReply(&reply, resp.Status, onReply)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	accepted := false
+	for {
+		var reply Reply
+		if err := dec.Decode(&reply); err != nil {
+			if accepted {
+				return nil
+			}
+			if err == io.EOF {
+				return errors.Errorf("empty command server response (status: %s)", resp.Status)
+			}
+			return errors.Annotatef(err, "invalid command server response (status: %s)", resp.Status)
+		}
+		if err := handleReply(&reply, resp.Status, onReply); err != nil {
+			return err
+		}
+		accepted = true
+	}
+}
+
+// honorsDeadline reports whether commands of type t can be bounded by a
+// deadline.
+func honorsDeadline(t CommandType) bool {
+	return t != StopCommandType && t != CancelCommandType && t != CommandStatusCommandType
+}
+
+func handleReply(reply *Reply, status string, onReply func(*Reply)) error {
+	if onReply != nil {
+		onReply(reply)
+	}
+	if !reply.OK {
+		return &CommandError{Reply: *reply, Status: status}
+	}
+	return nil
+}
+
+// do sends cmd, which gets a single reply.
+func (c *Client) do(ctx context.Context, cmd Command) (*Reply, error) {
+	var last *Reply
+	err := c.Send(ctx, cmd, func(r *Reply) { last = r })
+	return last, err
+}
+
+// Display returns the instance table of the playground, or its JSON encoding
+// with req.JSON.
+func (c *Client) Display(ctx context.Context, req DisplayRequest) (string, error) {
+	reply, err := c.do(ctx, Command{Type: DisplayCommandType, Display: &req})
+	if err != nil {
+		return "", err
+	}
+	return reply.Message, nil
+}
+
+// ScaleIn removes the instance selected by req. The reply lists the removed
+// instance in its Topology.
+func (c *Client) ScaleIn(ctx context.Context, req ScaleInRequest) (*Reply, error) {
+	return c.do(ctx, Command{Type: ScaleInCommandType, ScaleIn: &req})
+}
+
+// ScaleOut adds instances. The reply lists them in its Topology, which is
+// also set along with a *CommandError when the scale-out failed halfway.
+func (c *Client) ScaleOut(ctx context.Context, req ScaleOutRequest) (*Reply, error) {
+	return c.do(ctx, Command{Type: ScaleOutCommandType, ScaleOut: &req})
+}
+
+// Retag moves the playground to tag.
+func (c *Client) Retag(ctx context.Context, tag string) error {
+	_, err := c.do(ctx, Command{Type: RetagCommandType, Retag: &RetagRequest{Tag: tag}})
+	return err
+}
+
+// Snapshot takes a snapshot of the instance dirs and returns the message
+// naming it.
+func (c *Client) Snapshot(ctx context.Context) (string, error) {
+	reply, err := c.do(ctx, Command{Type: SnapshotCommandType, Snapshot: &SnapshotRequest{}})
+	if err != nil {
+		return "", err
+	}
+	return reply.Message, nil
+}
+
+// Stop stops the playground, calling onProgress, if set, with each line of
+// the termination progress. It returns once the instances quit, but the
+// playground process may still be cleaning up.
+func (c *Client) Stop(ctx context.Context, onProgress func(line string)) error {
+	return c.Send(ctx, Command{Type: StopCommandType}, func(r *Reply) {
+		if onProgress != nil && r.Message != "" {
+			onProgress(r.Message)
+		}
+	})
+}
+
+// Cancel cancels the queued or running command id.
+func (c *Client) Cancel(ctx context.Context, id uint64) (string, error) {
+	reply, err := c.do(ctx, Command{Type: CancelCommandType, Command: &CommandRef{ID: id}})
+	if err != nil {
+		return "", err
+	}
+	return reply.Message, nil
+}
+
+// CommandStatus returns the status of the command id, or of all known
+// commands when id is 0.
+func (c *Client) CommandStatus(ctx context.Context, id uint64) ([]CommandInfo, error) {
+	reply, err := c.do(ctx, Command{Type: CommandStatusCommandType, Command: &CommandRef{ID: id, JSON: true}})
+	if err != nil {
+		return nil, err
+	}
+	var infos []CommandInfo
+	if err := json.Unmarshal([]byte(reply.Message), &infos); err != nil {
+		return nil, errors.Annotate(err, "invalid command status")
+	}
+	return infos, nil
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, handler func(w http.ResponseWriter, cmd Command)) *Client {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var cmd Command
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&cmd))
+		handler(w, cmd)
+	}))
+	t.Cleanup(s.Close)
+	return New(strings.TrimPrefix(s.URL, "http://"))
+}
+
+func TestSend_StoppingIsCommandError(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, cmd Command) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(Reply{Error: "playground is stopping", Code: CodeStopping})
+	})
+
+	_, err := c.Display(context.Background(), DisplayRequest{})
+	require.EqualError(t, err, "playground is stopping")
+	require.True(t, IsStopping(err))
+}
+
+func TestSend_PropagatesContextDeadline(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, cmd Command) {
+		if cmd.Type == StopCommandType {
+			require.Nil(t, cmd.Deadline)
+			_ = json.NewEncoder(w).Encode(Reply{OK: true})
+			return
+		}
+		require.NotNil(t, cmd.Deadline)
+		w.WriteHeader(http.StatusGatewayTimeout)
+		_ = json.NewEncoder(w).Encode(Reply{Error: "command 1 exceeded its deadline", Code: CodeTimeout})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err := c.ScaleIn(ctx, ScaleInRequest{Name: "tikv-0"})
+	require.EqualError(t, err, "command 1 exceeded its deadline")
+	require.True(t, IsTimeout(err))
+	require.False(t, IsStopping(err))
+
+	require.NoError(t, c.Stop(ctx, nil))
+}
+
+func TestStop_StreamCutShortAfterAccepted(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, cmd Command) {
+		require.Equal(t, StopCommandType, cmd.Type)
+		w.Header().Set("Content-Type", StreamContentType)
+		_ = json.NewEncoder(w).Encode(Reply{OK: true, Message: "Stopping playground...\n"})
+		_ = json.NewEncoder(w).Encode(Reply{OK: true, Message: "Stopped TiDB (pid=1)\n"})
+		_, _ = w.Write([]byte(`{"ok":true,"mess`))
+	})
+
+	var lines []string
+	require.NoError(t, c.Stop(context.Background(), func(line string) { lines = append(lines, line) }))
+	require.Equal(t, []string{"Stopping playground...\n", "Stopped TiDB (pid=1)\n"}, lines)
+}
+
+func TestStop_EmptyStreamIsError(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, cmd Command) {
+		w.Header().Set("Content-Type", StreamContentType)
+	})
+	require.ErrorContains(t, c.Stop(context.Background(), nil), "empty command server response")
+}
+
+func TestCommandStatus(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, cmd Command) {
+		require.Equal(t, CommandStatusCommandType, cmd.Type)
+		require.Equal(t, &CommandRef{ID: 2, JSON: true}, cmd.Command)
+		data, _ := json.Marshal([]CommandInfo{{ID: 2, Type: ScaleOutCommandType, Status: CommandStatusRunning}})
+		_ = json.NewEncoder(w).Encode(Reply{OK: true, Message: string(data)})
+	})
+
+	infos, err := c.CommandStatus(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, []CommandInfo{{ID: 2, Type: ScaleOutCommandType, Status: CommandStatusRunning}}, infos)
+}
+
+func TestScaleOut_TopologyOnFailure(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, cmd Command) {
+		require.Equal(t, &ScaleOutRequest{Service: "tikv", Count: 2, Wait: "up"}, cmd.ScaleOut)
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Reply{
+			Error:    "start tikv-2: exit status 1",
+			Topology: &TopologyChange{Added: []TopologyInstance{{Name: "tikv-1", Service: "tikv", Host: "127.0.0.1", Port: 20161}}},
+		})
+	})
+
+	reply, err := c.ScaleOut(context.Background(), ScaleOutRequest{Service: "tikv", Count: 2, Wait: "up"})
+	require.EqualError(t, err, "start tikv-2: exit status 1")
+	require.False(t, IsStopping(err))
+
+	var buf bytes.Buffer
+	reply.Topology.Print(&buf)
+	require.Equal(t, "+ tikv-1 (tikv) 127.0.0.1:20161\n", buf.String())
+}
+
+func TestResolveTarget(t *testing.T) {
+	base := t.TempDir()
+
+	_, err := ResolveTarget("", "", base)
+	require.True(t, IsNotRunning(err))
+	_, err = ResolveTarget("missing", "", filepath.Join(base, "missing"))
+	require.True(t, IsNotRunning(err))
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Reply{OK: true, Message: "pong"})
+	}))
+	defer s.Close()
+	port := strconv.Itoa(s.Listener.Addr().(*net.TCPAddr).Port)
+	dir := filepath.Join(base, "only")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PortFileName), []byte(port), 0o644))
+
+	target, err := ResolveTarget("", "", base)
+	require.NoError(t, err)
+	require.Equal(t, "only", target.Tag)
+	require.Equal(t, dir, target.Dir)
+	require.Equal(t, fmt.Sprintf("127.0.0.1:%s", port), target.Addr())
+	require.Equal(t, port, strconv.Itoa(target.Port))
+}
+
+func TestSend_Token(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var cmd Command
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&cmd))
+		switch {
+		case r.Header.Get("Authorization") != "Bearer viewer":
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(Reply{Error: "missing or invalid token", Code: CodeUnauthorized})
+		case !cmd.Type.ReadOnly():
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(Reply{Error: "read-only token", Code: CodeForbidden})
+		default:
+			_ = json.NewEncoder(w).Encode(Reply{OK: true, Message: "table"})
+		}
+	}))
+	defer s.Close()
+	c := New(strings.TrimPrefix(s.URL, "http://"))
+
+	_, err := c.Display(context.Background(), DisplayRequest{})
+	require.EqualError(t, err, "missing or invalid token")
+	require.True(t, IsUnauthorized(err))
+	require.False(t, IsForbidden(err))
+
+	viewer := c.WithToken("viewer")
+	msg, err := viewer.Display(context.Background(), DisplayRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "table", msg)
+	_, err = viewer.ScaleIn(context.Background(), ScaleInRequest{Name: "tikv-0"})
+	require.True(t, IsForbidden(err))
+	require.False(t, IsUnauthorized(err))
+	require.False(t, IsStopping(err))
+}
+
+func TestReadToken(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(TokenEnv, "")
+	require.Empty(t, ReadToken(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, TokenFileName), []byte("control\n"), 0o600))
+	require.Equal(t, "control", ReadToken(dir))
+
+	t.Setenv(TokenEnv, "viewer")
+	require.Equal(t, "viewer", ReadToken(dir))
+}
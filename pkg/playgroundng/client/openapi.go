@@ -0,0 +1,224 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// APIVersion is the version of the command server protocol described by
+// OpenAPI. Adding optional fields keeps it; anything else bumps it.
+const APIVersion = "1.1.0"
+
+// schemaEnums lists the values of the string types that are enums.
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(CommandType("")): {
+		string(ScaleInCommandType), string(ScaleOutCommandType), string(DisplayCommandType), string(StopCommandType),
+		string(RetagCommandType), string(SnapshotCommandType), string(CancelCommandType), string(CommandStatusCommandType),
+		string(LogsCommandType),
+	},
+	reflect.TypeOf(CommandStatus("")): {
+		string(CommandStatusQueued), string(CommandStatusRunning), string(CommandStatusDone),
+		string(CommandStatusFailed), string(CommandStatusCanceled),
+	},
+}
+
+// schemaDescriptions describes the types in the OpenAPI components.
+var schemaDescriptions = map[string]string{
+	"Command":          "A request to the playground. Exactly the payload of its type is set: display, scale_in, scale_out, retag, snapshot, logs, or command for cancel and command_status. deadline, if set, bounds the command (except stop, cancel and command_status).",
+	"Reply":            "The response of the command server. A streamed reply is one Reply per line.",
+	"CommandType":      "The type of a command.",
+	"CommandStatus":    "The state of a command in the command queue.",
+	"CommandInfo":      "The status of a command. command_status with json encodes a list of them in the message.",
+	"TopologyChange":   "The instances added and removed by a scale command.",
+	"ScaleOutRequest":  "Adds count instances of service. wait is none (default), up or balance.",
+	"InstanceConfig":   "The config of the instances added by a scale-out. Zero values take the defaults of the playground.",
+	"CommandRef":       "Selects a command by ID; for command_status, 0 lists all known commands.",
+	"DisplayRequest":   "Options of the instance table.",
+	"ScaleInRequest":   "Selects the instance to remove by name or pid.",
+	"RetagRequest":     "The new tag of the playground.",
+	"SnapshotRequest":  "Options of a snapshot.",
+	"LogsRequest":      "Selects the instance by name; lines bounds the returned lines, 0 returns all the ones kept in memory.",
+	"TopologyInstance": "An instance in a TopologyChange.",
+}
+
+// schemaRequired lists the required properties of the types in the OpenAPI
+// components; the others may be left out.
+var schemaRequired = map[string][]string{
+	"Command":          {"type"},
+	"Reply":            {"ok"},
+	"CommandInfo":      {"id", "type", "status"},
+	"ScaleOutRequest":  {"service", "count"},
+	"RetagRequest":     {"tag"},
+	"LogsRequest":      {"name"},
+	"TopologyInstance": {"name", "service"},
+}
+
+// OpenAPI returns the OpenAPI 3 specification of the command server, which
+// it serves at /openapi.json. The schemas are generated from the wire types
+// of this package.
+func OpenAPI() map[string]any {
+	g := schemaGenerator{schemas: make(map[string]any)}
+	commandRef := g.schema(reflect.TypeOf(Command{}))
+	replyRef := g.schema(reflect.TypeOf(Reply{}))
+	g.schema(reflect.TypeOf(CommandInfo{}))
+
+	unauthorized := map[string]any{"description": "The playground requires a token and the request has none or an invalid one."}
+	reply := func(desc string, streamed bool) map[string]any {
+		content := map[string]any{"application/json": map[string]any{"schema": replyRef}}
+		if streamed {
+			content[StreamContentType] = map[string]any{"schema": replyRef}
+		}
+		return map[string]any{"description": desc, "content": content}
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "TiUP playground-ng command server",
+			"version":     APIVersion,
+			"description": "Local HTTP control plane of a running playground-ng, listening on 127.0.0.1 at the port written in <data dir>/port. A playground started with --read-only-token requires a bearer token on every path but /ping and /openapi.json: the read-only token, or the control token written in <data dir>/" + TokenFileName + ". The token may also be given as the token query parameter, e.g. for /ui.",
+		},
+		"security": []any{map[string]any{}, map[string]any{"token": []string{}}},
+		"paths": map[string]any{
+			"/ping": map[string]any{
+				"get": map[string]any{
+					"summary":   "Liveness probe, replies ok with message \"pong\".",
+					"responses": map[string]any{"200": reply("The command server is up.", false)},
+				},
+			},
+			"/command": map[string]any{
+				"post": map[string]any{
+					"summary":     "Run a command.",
+					"description": "Commands run one at a time in submission order. A stop request accepting " + StreamContentType + " gets the termination progress streamed until every instance quit.",
+					"requestBody": map[string]any{
+						"required": true,
+						"content":  map[string]any{"application/json": map[string]any{"schema": commandRef}},
+					},
+					"responses": map[string]any{
+						"200": reply("The command succeeded.", true),
+						"400": reply("The request is invalid or the command failed; error says why.", false),
+						"401": reply("The playground requires a token and the request has none or an invalid one (code \""+CodeUnauthorized+"\").", false),
+						"403": reply("The token is read-only and the command is not display, logs or command_status (code \""+CodeForbidden+"\").", false),
+						"503": reply("The playground is stopping (code \""+CodeStopping+"\").", false),
+						"504": reply("The command exceeded its deadline and was canceled (code \""+CodeTimeout+"\").", false),
+					},
+				},
+			},
+			"/events": map[string]any{
+				"get": map[string]any{
+					"summary":     "Follow the progress of the playground as server-sent events.",
+					"description": "Each event is a JSON object {at, level, text}; level is group, warn, error or empty. The recent events come first; a Last-Event-ID resumes after that event.",
+					"responses": map[string]any{
+						"200": map[string]any{"description": "The event stream.", "content": map[string]any{"text/event-stream": map[string]any{}}},
+						"401": unauthorized,
+					},
+				},
+			},
+			"/metrics": map[string]any{
+				"get": map[string]any{
+					"summary":     "The latest CPU and memory usage of the instances in the Prometheus text format.",
+					"description": "playground_instance_cpu_percent and playground_instance_memory_rss_bytes gauges, labeled by instance, service and pid, sampled every few seconds.",
+					"responses": map[string]any{
+						"200": map[string]any{"description": "The metrics.", "content": map[string]any{"text/plain": map[string]any{}}},
+						"401": unauthorized,
+					},
+				},
+			},
+			"/ui": map[string]any{
+				"get": map[string]any{
+					"summary": "The browser dashboard of the playground.",
+					"responses": map[string]any{
+						"200": map[string]any{"description": "The dashboard page.", "content": map[string]any{"text/html": map[string]any{}}},
+						"401": unauthorized,
+					},
+				},
+			},
+			"/openapi.json": map[string]any{
+				"get": map[string]any{
+					"summary": "This specification.",
+					"responses": map[string]any{
+						"200": map[string]any{"description": "The OpenAPI specification.", "content": map[string]any{"application/json": map[string]any{}}},
+					},
+				},
+			},
+		},
+		"components": map[string]any{
+			"schemas":         g.schemas,
+			"securitySchemes": map[string]any{"token": map[string]any{"type": "http", "scheme": "bearer"}},
+		},
+	}
+}
+
+type schemaGenerator struct {
+	schemas map[string]any
+}
+
+// schema returns the schema of t, registering the named structs and enums in
+// the components and referring to them.
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if values, ok := schemaEnums[t]; ok {
+		return g.component(t, func() map[string]any {
+			return map[string]any{"type": "string", "enum": values}
+		})
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.component(t, func() map[string]any { return g.object(t) })
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *schemaGenerator) component(t reflect.Type, build func() map[string]any) map[string]any {
+	ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	if _, ok := g.schemas[t.Name()]; ok {
+		return ref
+	}
+	// Registered before building, for recursive types.
+	g.schemas[t.Name()] = nil
+	s := build()
+	if desc, ok := schemaDescriptions[t.Name()]; ok {
+		s["description"] = desc
+	}
+	g.schemas[t.Name()] = s
+	return ref
+}
+
+func (g *schemaGenerator) object(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+	}
+	s := map[string]any{"type": "object", "properties": props}
+	if required, ok := schemaRequired[t.Name()]; ok {
+		s["required"] = required
+	}
+	return s
+}
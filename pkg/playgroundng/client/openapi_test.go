@@ -0,0 +1,48 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI(t *testing.T) {
+	data, err := json.Marshal(OpenAPI())
+	require.NoError(t, err)
+	var spec struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]struct {
+				Type       string                     `json:"type"`
+				Enum       []string                   `json:"enum"`
+				Properties map[string]json.RawMessage `json:"properties"`
+				Required   []string                   `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(data, &spec))
+	require.Equal(t, "3.0.3", spec.OpenAPI)
+	schemas := spec.Components.Schemas
+
+	// Every reference resolves.
+	for _, part := range strings.Split(string(data), `"$ref":"#/components/schemas/`)[1:] {
+		name := part[:strings.IndexByte(part, '"')]
+		require.Contains(t, schemas, name)
+	}
+	for name := range schemaDescriptions {
+		require.Contains(t, schemas, name)
+	}
+	for name, required := range schemaRequired {
+		for _, prop := range required {
+			require.Contains(t, schemas[name].Properties, prop, name)
+		}
+	}
+
+	require.Contains(t, schemas["Command"].Properties, "scale_out")
+	require.Contains(t, schemas["Reply"].Properties, "code")
+	require.Contains(t, schemas["InstanceConfig"].Properties, "BinPath")
+	require.Contains(t, schemas["CommandType"].Enum, string(CommandStatusCommandType))
+	require.Len(t, schemas["CommandStatus"].Enum, 5)
+}
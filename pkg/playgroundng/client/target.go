@@ -0,0 +1,299 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/localdata"
+)
+
+// PortFileName is the file in the data dir of a running playground holding
+// the port of its command server.
+const PortFileName = "port"
+
+// TokenFileName is the file in the data dir of a running playground holding
+// the token controlling it, if it was started with a read-only token. Only
+// its owner can read it.
+const TokenFileName = "token"
+
+// TokenEnv is the environment variable holding the token to send instead of
+// the one of TokenFileName, e.g. a read-only token given by the owner of the
+// playground.
+const TokenEnv = "TIUP_PLAYGROUND_TOKEN"
+
+// probeTimeout bounds probing the command server of a playground.
+const probeTimeout = 500 * time.Millisecond
+
+// Target is a running playground.
+type Target struct {
+	Tag string
+	// Dir is the data dir of the playground.
+	Dir string
+	// Port is the port of its command server on 127.0.0.1.
+	Port int
+	// Token is the token to send to the command server, see ReadToken.
+	Token string
+}
+
+// Addr returns the address of the command server of t.
+func (t Target) Addr() string {
+	return "127.0.0.1:" + strconv.Itoa(t.Port)
+}
+
+// NotRunningError reports that the playground to control is not running.
+type NotRunningError struct {
+	Err error
+}
+
+func (e NotRunningError) Error() string {
+	if e.Err == nil {
+		return "no playground running"
+	}
+	return e.Err.Error()
+}
+
+func (e NotRunningError) Unwrap() error { return e.Err }
+
+// IsNotRunning reports whether err is a NotRunningError.
+func IsNotRunning(err error) bool {
+	var notRunning NotRunningError
+	return stdErrors.As(err, &notRunning)
+}
+
+// UnreachableError reports that the command server of a playground did not
+// answer as expected.
+type UnreachableError struct {
+	Err error
+}
+
+func (e UnreachableError) Error() string {
+	if e.Err == nil {
+		return "playground is unreachable"
+	}
+	return e.Err.Error()
+}
+
+func (e UnreachableError) Unwrap() error { return e.Err }
+
+// MultipleTargetsError reports that several playgrounds are running and none
+// was chosen.
+type MultipleTargetsError struct {
+	// Targets are the running playgrounds, sorted by tag.
+	Targets []Target
+}
+
+func (e MultipleTargetsError) Error() string {
+	items := make([]string, 0, len(e.Targets))
+	for _, t := range e.Targets {
+		items = append(items, fmt.Sprintf("%s(%d)", t.Tag, t.Port))
+	}
+	slices.Sort(items)
+	return fmt.Sprintf("multiple playgrounds found: %s; please specify --tag", strings.Join(items, ", "))
+}
+
+// Find resolves the running playground with tag in the TiUP home of the
+// current user ($TIUP_HOME, or ~/.tiup). Without tag, it resolves the only
+// running playground.
+func Find(tag string) (Target, error) {
+	base := localdata.InitProfile().Path(localdata.DataParentDir)
+	if tag == "" {
+		return ResolveTarget("", "", base)
+	}
+	return ResolveTarget(tag, "", filepath.Join(base, tag))
+}
+
+// ResolveTarget resolves the running playground to control.
+//
+// With an explicit tag or TiUP instance data dir, dataDir is the data dir of
+// that playground, and no other is considered. Otherwise dataDir holds the data
+// dirs of all playgrounds, and exactly one of them must be running.
+func ResolveTarget(explicitTag, instanceDataDir, dataDir string) (Target, error) {
+	if explicitTag != "" || instanceDataDir != "" {
+		tag := explicitTag
+		if tag == "" {
+			tag = filepath.Base(dataDir)
+		}
+		port, err := ReadPort(dataDir)
+		if err != nil {
+			return Target{}, NotRunningError{Err: errors.Annotatef(err, "no playground running for tag %q", tag)}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		defer cancel()
+		ok, probeErr := Probe(ctx, port)
+		if ok && probeErr == nil {
+			return Target{Tag: tag, Dir: dataDir, Port: port, Token: ReadToken(dataDir)}, nil
+		}
+
+		switch {
+		case isTimeoutErr(probeErr):
+			return Target{}, UnreachableError{Err: errors.Annotatef(probeErr, "probe playground %q command server timed out (port=%d)", tag, port)}
+		case stdErrors.Is(probeErr, syscall.ECONNREFUSED):
+			return Target{}, NotRunningError{Err: errors.Errorf("no playground running for tag %q", tag)}
+		default:
+			return Target{}, UnreachableError{Err: errors.Annotatef(probeErr, "probe playground %q command server (port=%d)", tag, port)}
+		}
+	}
+
+	if dataDir == "" {
+		return Target{}, NotRunningError{Err: errors.Errorf("no playground running")}
+	}
+	targets, err := ListTargets(dataDir)
+	if err != nil {
+		return Target{}, errors.AddStack(err)
+	}
+	if len(targets) == 0 {
+		return Target{}, NotRunningError{Err: errors.Errorf("no playground running")}
+	}
+	if len(targets) == 1 {
+		// Single running playground: implicit selection is unambiguous.
+		return targets[0], nil
+	}
+
+	return Target{}, MultipleTargetsError{Targets: targets}
+}
+
+// ListTargets returns the running playgrounds whose data dirs are in baseDir,
+// sorted by tag.
+func ListTargets(baseDir string) ([]Target, error) {
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.AddStack(err)
+	}
+
+	var out []Target
+	for _, ent := range entries {
+		if !ent.IsDir() {
+			continue
+		}
+		dir := filepath.Join(baseDir, ent.Name())
+		port, err := ReadPort(dir)
+		if err != nil || port <= 0 {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		ok, probeErr := Probe(ctx, port)
+		cancel()
+		if ok && probeErr == nil {
+			out = append(out, Target{Tag: ent.Name(), Dir: dir, Port: port, Token: ReadToken(dir)})
+		}
+	}
+
+	slices.SortStableFunc(out, func(a, b Target) int {
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return out, nil
+}
+
+// ReadPort reads the command server port from the data dir of a playground.
+func ReadPort(dir string) (port int, err error) {
+	data, err := os.ReadFile(filepath.Join(dir, PortFileName))
+	if err != nil {
+		return 0, err
+	}
+
+	port, err = strconv.Atoi(strings.TrimSpace(string(data)))
+	return
+}
+
+// ReadToken returns the token to control the playground of the data dir dir
+// with: $TIUP_PLAYGROUND_TOKEN if set, else the content of its TokenFileName.
+// It is empty if neither is set or readable, which is all a playground started
+// without a read-only token needs.
+func ReadToken(dir string) string {
+	if token := strings.TrimSpace(os.Getenv(TokenEnv)); token != "" {
+		return token
+	}
+	data, err := os.ReadFile(filepath.Join(dir, TokenFileName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Probe reports whether a playground command server listens on port, which
+// answers "/ping", or "/command" for the servers predating it.
+func Probe(ctx context.Context, port int) (bool, error) {
+	if port <= 0 {
+		return false, fmt.Errorf("invalid port %d", port)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	client := &http.Client{}
+
+	pingReq, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/ping", port), nil)
+	if err != nil {
+		return false, errors.AddStack(err)
+	}
+	pingResp, err := client.Do(pingReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, err
+		}
+	} else {
+		defer pingResp.Body.Close()
+		if pingResp.StatusCode == http.StatusOK {
+			var reply Reply
+			if err := json.NewDecoder(pingResp.Body).Decode(&reply); err != nil {
+				return false, err
+			}
+			if reply.OK && strings.TrimSpace(reply.Message) == "pong" {
+				return true, nil
+			}
+			return false, fmt.Errorf("unexpected ping response")
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/command", port), nil)
+	if err != nil {
+		return false, errors.AddStack(err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var reply Reply
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return false, err
+	}
+
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		return false, fmt.Errorf("unexpected probe status: %s", resp.Status)
+	}
+
+	if !reply.OK && reply.Error == "method not allowed" {
+		return true, nil
+	}
+
+	return false, fmt.Errorf("unexpected probe response")
+}
+
+func isTimeoutErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if stdErrors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr interface{ Timeout() bool }
+	return stdErrors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// CommandType is the type of a command sent to a playground.
+type CommandType string
+
+// types of CommandType
+const (
+	ScaleInCommandType  CommandType = "scale-in"
+	ScaleOutCommandType CommandType = "scale-out"
+	DisplayCommandType  CommandType = "display"
+	StopCommandType     CommandType = "stop"
+	RetagCommandType    CommandType = "retag"
+	// SnapshotCommandType takes a snapshot of the instance dirs.
+	SnapshotCommandType CommandType = "snapshot"
+	// CancelCommandType cancels a queued or running command by ID.
+	CancelCommandType CommandType = "cancel"
+	// CommandStatusCommandType reports the status of one or all commands.
+	CommandStatusCommandType CommandType = "command_status"
+	// LogsCommandType returns the last captured stdout/stderr lines of an
+	// instance.
+	LogsCommandType CommandType = "logs"
+)
+
+// ReadOnly reports whether commands of type t only inspect the playground,
+// which a read-only token allows.
+func (t CommandType) ReadOnly() bool {
+	switch t {
+	case DisplayCommandType, LogsCommandType, CommandStatusCommandType:
+		return true
+	}
+	return false
+}
+
+// CodeStopping is the reply code of the commands rejected because the
+// playground is stopping.
+const CodeStopping = "stopping"
+
+// CodeTimeout is the reply code of the commands that exceeded their deadline.
+const CodeTimeout = "timeout"
+
+// CodeUnauthorized is the reply code of the requests without a valid token,
+// to a playground started with a read-only token.
+const CodeUnauthorized = "unauthorized"
+
+// CodeForbidden is the reply code of the commands a read-only token, or an
+// origin only allowed by --cors-origin '*', does not allow.
+const CodeForbidden = "forbidden"
+
+// StreamContentType is the content type of a streamed reply: one Reply per
+// line. The "stop" command streams the termination progress to the clients
+// accepting it.
+const StreamContentType = "application/x-ndjson"
+
+// DisplayRequest is the request payload for the "display" command.
+type DisplayRequest struct {
+	Verbose bool `json:"verbose,omitempty"`
+	// Wide adds the client/status port and data dir columns to the table,
+	// and keeps the whole values regardless of Width.
+	Wide bool `json:"wide,omitempty"`
+	JSON bool `json:"json,omitempty"`
+	// Plain renders the table as tab separated values.
+	Plain bool `json:"plain,omitempty"`
+	// Width is the width of the terminal of the client the table is clipped
+	// to, 0 for no clipping.
+	Width int `json:"width,omitempty"`
+}
+
+// ScaleInRequest is the request payload for the "scale-in" command.
+type ScaleInRequest struct {
+	Name string `json:"name,omitempty"`
+	PID  int    `json:"pid,omitempty"`
+}
+
+// ScaleOutRequest is the request payload for the "scale-out" command.
+type ScaleOutRequest struct {
+	// Service is the service ID, e.g. "tikv" or "tidb".
+	Service string         `json:"service"`
+	Count   int            `json:"count"`
+	Config  InstanceConfig `json:"config"`
+	// Wait selects what to wait for once the new instances are started:
+	// "none" (the default), "up" or "balance".
+	Wait string `json:"wait,omitempty"`
+}
+
+// InstanceConfig is the config of the instances added by a scale-out. Zero
+// values take the defaults of the playground.
+type InstanceConfig struct {
+	ConfigPath string
+	BinPath    string
+	Num        int
+	Host       string
+	Port       int
+	UpTimeout  int
+	Version    string
+	DataDir    string `json:",omitempty"`
+	LogDir     string `json:",omitempty"`
+}
+
+// RetagRequest is the request payload for the "retag" command.
+type RetagRequest struct {
+	Tag string `json:"tag"`
+}
+
+// SnapshotRequest is the request payload for the "snapshot" command.
+type SnapshotRequest struct {
+	// Auto marks a scheduled snapshot (--snapshot-every); Keep bounds how
+	// many of those are kept.
+	Auto bool `json:"auto,omitempty"`
+	Keep int  `json:"keep,omitempty"`
+}
+
+// LogsRequest is the request payload for the "logs" command.
+type LogsRequest struct {
+	Name string `json:"name"`
+	// Lines bounds the returned lines; 0 returns all the lines kept in
+	// memory.
+	Lines int `json:"lines,omitempty"`
+}
+
+// CommandRef is the request payload for the "cancel" and "command_status"
+// commands.
+type CommandRef struct {
+	// ID selects a command. For "command_status", 0 lists all known commands.
+	ID   uint64 `json:"id,omitempty"`
+	JSON bool   `json:"json,omitempty"`
+}
+
+// Command is a request to a running playground. Exactly the payload of its
+// Type is set.
+type Command struct {
+	Type     CommandType      `json:"type"`
+	Display  *DisplayRequest  `json:"display,omitempty"`
+	ScaleIn  *ScaleInRequest  `json:"scale_in,omitempty"`
+	ScaleOut *ScaleOutRequest `json:"scale_out,omitempty"`
+	Retag    *RetagRequest    `json:"retag,omitempty"`
+	Snapshot *SnapshotRequest `json:"snapshot,omitempty"`
+	Command  *CommandRef      `json:"command,omitempty"`
+	Logs     *LogsRequest     `json:"logs,omitempty"`
+	// Deadline, if set, bounds the command: the playground cancels it once
+	// exceeded and replies with CodeTimeout. "stop", "cancel" and
+	// "command_status" ignore it.
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+// Reply is the response of the playground command server.
+type Reply struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// CommandID identifies the command in the command queue, for the "cancel"
+	// and "command_status" commands.
+	CommandID uint64 `json:"command_id,omitempty"`
+	// Topology is what a scale command changed. It is also set when the
+	// command failed halfway.
+	Topology *TopologyChange `json:"topology,omitempty"`
+	// Code classifies a failure, e.g. CodeStopping or CodeTimeout.
+	Code string `json:"code,omitempty"`
+}
+
+// TopologyChange lists the instances added and removed by a command.
+type TopologyChange struct {
+	Added   []TopologyInstance `json:"added,omitempty"`
+	Removed []TopologyInstance `json:"removed,omitempty"`
+}
+
+// TopologyInstance describes one instance in a TopologyChange.
+type TopologyInstance struct {
+	Name       string `json:"name"`
+	Service    string `json:"service"`
+	Host       string `json:"host,omitempty"`
+	Port       int    `json:"port,omitempty"`
+	StatusPort int    `json:"status_port,omitempty"`
+	PID        int    `json:"pid,omitempty"`
+}
+
+// Print writes one line per changed instance, e.g.
+// "+ tikv-1 (tikv) 127.0.0.1:20161, status port 20181".
+func (c *TopologyChange) Print(out io.Writer) {
+	if c == nil {
+		return
+	}
+	line := func(sign string, inst TopologyInstance) {
+		desc := fmt.Sprintf("%s %s (%s)", sign, inst.Name, inst.Service)
+		if inst.Port > 0 {
+			desc += " " + utils.JoinHostPort(inst.Host, inst.Port)
+		}
+		if inst.StatusPort > 0 {
+			desc += fmt.Sprintf(", status port %d", inst.StatusPort)
+		}
+		if inst.PID > 0 {
+			desc += fmt.Sprintf(", pid %d", inst.PID)
+		}
+		fmt.Fprintln(out, desc)
+	}
+	for _, inst := range c.Added {
+		line("+", inst)
+	}
+	for _, inst := range c.Removed {
+		line("-", inst)
+	}
+}
+
+// CommandStatus is the state of a command sent to a playground.
+type CommandStatus string
+
+// Command statuses.
+const (
+	CommandStatusQueued   CommandStatus = "queued"
+	CommandStatusRunning  CommandStatus = "running"
+	CommandStatusDone     CommandStatus = "done"
+	CommandStatusFailed   CommandStatus = "failed"
+	CommandStatusCanceled CommandStatus = "canceled"
+)
+
+// CommandInfo is the status of a command, as reported by "command_status".
+type CommandInfo struct {
+	ID     uint64        `json:"id"`
+	Type   CommandType   `json:"type"`
+	Status CommandStatus `json:"status"`
+	Error  string        `json:"error,omitempty"`
+}
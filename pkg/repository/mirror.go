@@ -93,6 +93,9 @@ type (
 		Progress DownloadProgress
 		Upstream string
 		KeyDir   string
+		// Proxy is an explicit proxy URL (http://, https:// or socks5://) used
+		// for downloads. When empty, proxy environment variables are honored.
+		Proxy string
 	}
 
 	// Mirror represents a repository mirror, which can be remote HTTP
@@ -351,7 +354,7 @@ func (l *httpMirror) downloadFile(url string, to string, maxSize int64) (io.Read
 
 	// workaround to resolve cdn error "tls: protocol version not supported"
 	client.HTTPClient.(*http.Client).Transport = &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
+		Proxy: utils.ProxyFunc(l.options.Proxy),
 		// avoid using http/2 by setting non-nil TLSClientConfig
 		TLSClientConfig: &tls.Config{},
 	}
@@ -21,6 +21,10 @@
 //	colorstr.DefaultTokens.Printf("[ahh]")         ==> "[ahh]"
 //
 // Color tokens in the Print arguments will never be interpreted. It can be useful to pass user inputs there.
+//
+// Besides the named colors, `[color_208]` is a color of the 256 color palette and `[rgb_ff8800]` a 24-bit
+// color, `[bg_color_208]` and `[bg_rgb_ff8800]` their background variants. They are rendered with the
+// nearest color of the terminal's ColorDepth, down to the basic 16 colors.
 package colorstr
 
 import (
@@ -35,6 +39,16 @@ import (
 
 type colorTokens struct {
 	colorstring.Colorize
+
+	// Depth is the color depth the 256-color and truecolor tokens are
+	// downgraded to.
+	Depth tuiterm.ColorDepth
+}
+
+// Color interprets the color tokens of format.
+func (c colorTokens) Color(format string) string {
+	c.Colors = withExtendedTokens(c.Colors, format, c.Depth)
+	return c.Colorize.Color(format)
 }
 
 // Note: Print, Println, Fprint, Fprintln are intentionally not implemented, as we would like to
@@ -73,12 +87,16 @@ var DefaultTokens = (func() colorTokens {
 	}
 })()
 
-var colorEnabled atomic.Bool
+var (
+	colorEnabled atomic.Bool
+	colorDepth   tuiterm.ColorDepth
+)
 
 func init() {
 	// Enable color when either stdout or stderr supports it. This avoids surprising
 	// "no color on stderr" behavior when stdout is piped but stderr is still a TTY.
 	colorEnabled.Store(tuiterm.ResolveFile(os.Stdout).Color || tuiterm.ResolveFile(os.Stderr).Color)
+	colorDepth = tuiterm.DetectColorDepth()
 }
 
 // SetColorEnabled sets whether ANSI styling output is enabled globally.
@@ -97,6 +115,17 @@ func ColorEnabled() bool {
 func tokens() colorTokens {
 	tokens := DefaultTokens
 	tokens.Disable = !ColorEnabled()
+	tokens.Depth = colorDepth
+	return tokens
+}
+
+// TokensFor returns the default color tokens rendered for a writer of the
+// output mode: no color if it disallows them, and the 256-color and truecolor
+// tokens downgraded to its color depth.
+func TokensFor(mode tuiterm.OutputMode) colorTokens {
+	tokens := DefaultTokens
+	tokens.Disable = !mode.Color
+	tokens.Depth = mode.ColorDepth
 	return tokens
 }
 
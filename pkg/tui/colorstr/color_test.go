@@ -16,6 +16,7 @@ package colorstr
 import (
 	"testing"
 
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,3 +30,31 @@ func TestDefaultTokens(t *testing.T) {
 	require.Equal(t, "\x1B[34mhello [blue]\x1B[0m", DefaultTokens.Sprintf("[blue]hello %s", "[blue]"))
 	require.Equal(t, "[ahh]hello", DefaultTokens.Sprintf("[ahh]hello"))
 }
+
+func TestExtendedTokens(t *testing.T) {
+	tokens := DefaultTokens
+	tokens.Depth = tuiterm.ColorDepthTrueColor
+	require.Equal(t, "\x1B[38;2;255;136;0mhi\x1B[0m", tokens.Sprintf("[rgb_ff8800]hi"))
+	require.Equal(t, "\x1B[48;5;208mhi\x1B[0m", tokens.Sprintf("[bg_color_208]hi"))
+	require.Equal(t, "\x1B[91mhi\x1B[0m", tokens.Sprintf("[color_9]hi"))
+	require.Equal(t, "[color_256]hi", tokens.Sprintf("[color_256]hi"))
+
+	// Downgraded to the nearest color of the 256 color palette.
+	tokens.Depth = tuiterm.ColorDepth256
+	require.Equal(t, "\x1B[38;5;208mhi\x1B[0m", tokens.Sprintf("[rgb_ff8800]hi"))
+	require.Equal(t, "\x1B[38;5;244mhi\x1B[0m", tokens.Sprintf("[rgb_808080]hi"))
+	require.Equal(t, "\x1B[38;5;208mhi\x1B[0m", tokens.Sprintf("[color_208]hi"))
+
+	// Downgraded to the nearest basic color.
+	tokens.Depth = tuiterm.ColorDepth16
+	require.Equal(t, "\x1B[33mhi\x1B[0m", tokens.Sprintf("[color_172]hi"))
+	require.Equal(t, "\x1B[104mhi\x1B[0m", tokens.Sprintf("[bg_rgb_5f5fff]hi"))
+	require.Equal(t, "\x1B[91m\x1B[1mhi\x1B[0m", tokens.Sprintf("[rgb_ff1010][bold]hi"))
+
+	// No color at all.
+	require.Equal(t, "hi [rgb_ff8800]", TokensFor(tuiterm.OutputMode{ColorDepth: tuiterm.ColorDepthTrueColor}).Sprintf("[rgb_ff8800]hi %s", "[rgb_ff8800]"))
+	require.Equal(t, "\x1B[38;5;208mhi\x1B[0m", TokensFor(tuiterm.OutputMode{Color: true, ColorDepth: tuiterm.ColorDepth256}).Sprintf("[color_208]hi"))
+
+	// The default tokens are left untouched.
+	require.NotContains(t, DefaultTokens.Colors, "color_208")
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package colorstr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
+)
+
+// extendedTokenRe matches the 256-color tokens `[color_208]` and the
+// truecolor tokens `[rgb_ff8800]`, with a `bg_` prefix for the background.
+var extendedTokenRe = regexp.MustCompile(`\[(bg_)?(?:color_(\d{1,3})|rgb_([0-9a-fA-F]{6}))\]`)
+
+// ansi16RGB are the colors of the basic 16 color palette, as xterm renders
+// them.
+var ansi16RGB = [16][3]int{
+	{0x00, 0x00, 0x00}, {0xcd, 0x00, 0x00}, {0x00, 0xcd, 0x00}, {0xcd, 0xcd, 0x00},
+	{0x00, 0x00, 0xee}, {0xcd, 0x00, 0xcd}, {0x00, 0xcd, 0xcd}, {0xe5, 0xe5, 0xe5},
+	{0x7f, 0x7f, 0x7f}, {0xff, 0x00, 0x00}, {0x00, 0xff, 0x00}, {0xff, 0xff, 0x00},
+	{0x5c, 0x5c, 0xff}, {0xff, 0x00, 0xff}, {0x00, 0xff, 0xff}, {0xff, 0xff, 0xff},
+}
+
+// cubeLevels are the channel values of the 6x6x6 color cube of the 256 color
+// palette.
+var cubeLevels = [6]int{0x00, 0x5f, 0x87, 0xaf, 0xd7, 0xff}
+
+// withExtendedTokens returns colors with the extended tokens of format added,
+// each mapped to the nearest color the depth renders. colors is returned
+// as is when format has none.
+func withExtendedTokens(colors map[string]string, format string, depth tuiterm.ColorDepth) map[string]string {
+	matches := extendedTokenRe.FindAllStringSubmatch(format, -1)
+	if len(matches) == 0 {
+		return colors
+	}
+	extended := make(map[string]string, len(colors)+len(matches))
+	for k, v := range colors {
+		extended[k] = v
+	}
+	for _, m := range matches {
+		name := m[0][1 : len(m[0])-1]
+		if _, ok := extended[name]; ok {
+			continue
+		}
+		bg := m[1] != ""
+		var code string
+		if m[2] != "" {
+			idx, _ := strconv.Atoi(m[2])
+			if idx > 255 {
+				// Not a color: keep the token as text, like unknown tokens.
+				continue
+			}
+			code = color256Code(idx, bg, depth)
+		} else {
+			v, _ := strconv.ParseUint(m[3], 16, 32)
+			code = rgbCode(int(v>>16), int(v>>8&0xff), int(v&0xff), bg, depth)
+		}
+		extended[name] = code
+	}
+	return extended
+}
+
+func color256Code(idx int, bg bool, depth tuiterm.ColorDepth) string {
+	switch {
+	case idx < 16:
+		return color16Code(idx, bg)
+	case depth >= tuiterm.ColorDepth256:
+		return fmt.Sprintf("%d;5;%d", selectCode(bg, 38, 48), idx)
+	}
+	r, g, b := color256RGB(idx)
+	return color16Code(nearest16(r, g, b), bg)
+}
+
+func rgbCode(r, g, b int, bg bool, depth tuiterm.ColorDepth) string {
+	switch depth {
+	case tuiterm.ColorDepthTrueColor:
+		return fmt.Sprintf("%d;2;%d;%d;%d", selectCode(bg, 38, 48), r, g, b)
+	case tuiterm.ColorDepth256:
+		return fmt.Sprintf("%d;5;%d", selectCode(bg, 38, 48), nearest256(r, g, b))
+	}
+	return color16Code(nearest16(r, g, b), bg)
+}
+
+func color16Code(idx int, bg bool) string {
+	base := selectCode(bg, 30, 40)
+	if idx >= 8 {
+		base += 60
+		idx -= 8
+	}
+	return strconv.Itoa(base + idx)
+}
+
+func selectCode(bg bool, fg, bgCode int) int {
+	if bg {
+		return bgCode
+	}
+	return fg
+}
+
+// color256RGB returns the color of the index of the 256 color palette.
+func color256RGB(idx int) (r, g, b int) {
+	switch {
+	case idx < 16:
+		c := ansi16RGB[idx]
+		return c[0], c[1], c[2]
+	case idx < 232:
+		idx -= 16
+		return cubeLevels[idx/36], cubeLevels[idx/6%6], cubeLevels[idx%6]
+	default:
+		v := 8 + (idx-232)*10
+		return v, v, v
+	}
+}
+
+// nearest256 returns the index of the 256 color palette, in the color cube
+// or the gray ramp, nearest to the color.
+func nearest256(r, g, b int) int {
+	level := func(v int) int {
+		best := 0
+		for i, l := range cubeLevels {
+			if abs(v-l) < abs(v-cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	cube := 16 + 36*level(r) + 6*level(g) + level(b)
+	gray := 232 + min(23, max(0, ((r+g+b)/3-8+5)/10))
+	cr, cg, cb := color256RGB(cube)
+	gr, gg, gb := color256RGB(gray)
+	if distance(r, g, b, gr, gg, gb) < distance(r, g, b, cr, cg, cb) {
+		return gray
+	}
+	return cube
+}
+
+// nearest16 returns the index of the basic 16 color palette nearest to the
+// color.
+func nearest16(r, g, b int) int {
+	best, bestDist := 0, -1
+	for i, c := range ansi16RGB {
+		if d := distance(r, g, b, c[0], c[1], c[2]); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+func distance(r1, g1, b1, r2, g2, b2 int) int {
+	return (r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
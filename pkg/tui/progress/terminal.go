@@ -20,8 +20,8 @@ import (
 	"os/signal"
 	"syscall"
 
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
 	"go.uber.org/atomic"
-	"golang.org/x/sys/unix"
 )
 
 var (
@@ -29,14 +29,13 @@ var (
 	termSizeHeight = atomic.Int32{}
 )
 
-func updateTerminalSize() error {
-	ws, err := unix.IoctlGetWinsize(syscall.Stdout, unix.TIOCGWINSZ)
-	if err != nil {
-		return err
-	}
-	termSizeWidth.Store(int32(ws.Col))
-	termSizeHeight.Store(int32(ws.Row))
-	return nil
+// updateTerminalSize refreshes the size of the terminal stdout writes to. It
+// stays 0 (no truncation) when neither the terminal nor COLUMNS/LINES
+// report it, e.g. when the output is piped.
+func updateTerminalSize() {
+	width, height := tuiterm.Size(os.Stdout, 0, 0)
+	termSizeWidth.Store(int32(width))
+	termSizeHeight.Store(int32(height))
 }
 
 func moveCursorUp(w io.Writer, n int) {
@@ -56,7 +55,7 @@ func clearLine(w io.Writer) {
 }
 
 func init() {
-	_ = updateTerminalSize()
+	updateTerminalSize()
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGWINCH)
@@ -66,7 +65,7 @@ func init() {
 			if _, ok := <-sigCh; !ok {
 				return
 			}
-			_ = updateTerminalSize()
+			updateTerminalSize()
 		}
 	}()
 }
@@ -0,0 +1,70 @@
+package term
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// hyperlinkTermPrograms are the TERM_PROGRAM values of the terminals known to
+// render OSC 8 hyperlinks.
+var hyperlinkTermPrograms = map[string]bool{
+	"iterm.app": true,
+	"wezterm":   true,
+	"vscode":    true,
+	"ghostty":   true,
+	"hyper":     true,
+}
+
+// SupportsHyperlinks reports whether the terminal is expected to render OSC 8
+// hyperlinks, from the variables the terminals set. Terminals that do not
+// understand OSC 8 may print the escape sequences, so unknown terminals and
+// multiplexers (tmux, screen) are treated as not supported.
+func SupportsHyperlinks() bool {
+	if os.Getenv("TMUX") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		return false
+	}
+	if hyperlinkTermPrograms[strings.ToLower(os.Getenv("TERM_PROGRAM"))] {
+		return true
+	}
+	switch {
+	case os.Getenv("WT_SESSION") != "", // Windows Terminal
+		os.Getenv("KITTY_WINDOW_ID") != "",
+		os.Getenv("DOMTERM") != "":
+		return true
+	}
+	switch os.Getenv("TERM") {
+	case "xterm-kitty", "xterm-ghostty", "foot", "alacritty":
+		return true
+	}
+	// GNOME Terminal and the other VTE based terminals since VTE 0.50.
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	// Konsole since 21.04 reports KONSOLE_VERSION as e.g. 210400.
+	if v, err := strconv.Atoi(os.Getenv("KONSOLE_VERSION")); err == nil && v >= 210400 {
+		return true
+	}
+	return false
+}
+
+// Link renders text pointing to url: an OSC 8 hyperlink if the mode allows
+// them, plain text otherwise. The plain form keeps the url next to a text
+// that differs from it, e.g. "Grafana (http://127.0.0.1:3000)".
+func (m OutputMode) Link(text, url string) string {
+	switch {
+	case url == "":
+		return text
+	case text == "":
+		text = url
+	}
+	if m.Hyperlink {
+		return ansi.SetHyperlink(url) + text + ansi.ResetHyperlink()
+	}
+	if text == url {
+		return text
+	}
+	return text + " (" + url + ")"
+}
@@ -3,6 +3,7 @@ package term
 import (
 	"io"
 	"os"
+	"strings"
 
 	xterm "golang.org/x/term"
 )
@@ -11,12 +12,19 @@ import (
 //
 // Precedence (highest first):
 //  1. NO_COLOR    -> disable color + control sequences
-//  2. FORCE_TTY   -> enable color + control sequences
-//  3. FORCE_COLOR -> enable color, control sequences follow TTY detection
+//  2. TIUP_COLOR  -> "never"/"always" override color only, control sequences
+//     follow the rules below ("auto" or any other value is ignored)
+//  3. FORCE_TTY   -> enable color + control sequences
+//  4. FORCE_COLOR -> enable color, control sequences follow TTY detection
+//
+// Hyperlinks are off with NO_COLOR, and otherwise FORCE_HYPERLINK turns them
+// on ("1") or off ("0") regardless of the terminal, see SupportsHyperlinks.
 const (
-	EnvNoColor    = "NO_COLOR"
-	EnvForceColor = "FORCE_COLOR"
-	EnvForceTTY   = "FORCE_TTY"
+	EnvNoColor        = "NO_COLOR"
+	EnvTiUPColor      = "TIUP_COLOR"
+	EnvForceColor     = "FORCE_COLOR"
+	EnvForceTTY       = "FORCE_TTY"
+	EnvForceHyperlink = "FORCE_HYPERLINK"
 )
 
 // OutputMode controls what kind of output is allowed for a writer.
@@ -24,9 +32,41 @@ const (
 // Color: whether ANSI styling (colors/bold) is allowed.
 // Control: whether ANSI control sequences that rewrite the terminal output
 // (spinners, multi-line live updates) are allowed.
+// Hyperlink: whether OSC 8 hyperlinks are allowed, see Link.
+// ColorDepth: the colors the terminal renders, only meaningful with Color.
 type OutputMode struct {
-	Color   bool
-	Control bool
+	Color      bool
+	Control    bool
+	Hyperlink  bool
+	ColorDepth ColorDepth
+}
+
+// ColorDepth is the number of colors a terminal renders.
+type ColorDepth int
+
+// Color depths, the zero value being the basic 16 colors every color
+// terminal renders.
+const (
+	ColorDepth16 ColorDepth = iota
+	ColorDepth256
+	ColorDepthTrueColor
+)
+
+// DetectColorDepth guesses the color depth of the terminal: COLORTERM is set
+// to "truecolor" or "24bit" by the terminals rendering 24-bit colors, and
+// TERM names the 256-color ones, e.g. "xterm-256color".
+func DetectColorDepth() ColorDepth {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ColorDepthTrueColor
+	}
+	if os.Getenv("WT_SESSION") != "" {
+		return ColorDepthTrueColor
+	}
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return ColorDepth256
+	}
+	return ColorDepth16
 }
 
 // ModeProvider allows a writer to declare its effective output mode.
@@ -62,6 +102,28 @@ func resolveModeForFile(out *os.File) OutputMode {
 		return OutputMode{}
 	}
 
+	mode := resolveTTYModeForFile(out)
+	switch strings.ToLower(os.Getenv(EnvTiUPColor)) {
+	case "never", "0", "false", "off":
+		mode.Color = false
+	case "always", "1", "true", "on":
+		mode.Color = true
+	}
+	if mode.Color {
+		mode.ColorDepth = DetectColorDepth()
+	}
+	switch strings.ToLower(os.Getenv(EnvForceHyperlink)) {
+	case "0", "false", "off":
+		mode.Hyperlink = false
+	case "1", "true", "on":
+		mode.Hyperlink = true
+	default:
+		mode.Hyperlink = mode.Control && SupportsHyperlinks()
+	}
+	return mode
+}
+
+func resolveTTYModeForFile(out *os.File) OutputMode {
 	if os.Getenv(EnvForceTTY) != "" {
 		return OutputMode{Color: true, Control: true}
 	}
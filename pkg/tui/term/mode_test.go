@@ -2,6 +2,7 @@ package term
 
 import (
 	"bytes"
+	"os"
 	"testing"
 )
 
@@ -48,3 +49,173 @@ func TestResolve_FORCE_TTY_NonTTY(t *testing.T) {
 		t.Fatalf("expected FORCE_TTY(non-tty) => color+control on, got %+v", got)
 	}
 }
+
+func TestResolve_TIUP_COLOR(t *testing.T) {
+	t.Setenv(EnvNoColor, "")
+	t.Setenv(EnvForceColor, "")
+	t.Setenv(EnvForceTTY, "1")
+
+	t.Setenv(EnvTiUPColor, "never")
+	got := Resolve(&bytes.Buffer{})
+	if got.Color || !got.Control {
+		t.Fatalf("expected TIUP_COLOR=never => color off, control on, got %+v", got)
+	}
+
+	t.Setenv(EnvForceTTY, "")
+	t.Setenv(EnvTiUPColor, "always")
+	got = Resolve(&bytes.Buffer{})
+	if !got.Color || got.Control {
+		t.Fatalf("expected TIUP_COLOR=always(non-tty) => color on, control off, got %+v", got)
+	}
+
+	t.Setenv(EnvNoColor, "1")
+	got = Resolve(&bytes.Buffer{})
+	if got.Color || got.Control {
+		t.Fatalf("expected NO_COLOR to win over TIUP_COLOR, got %+v", got)
+	}
+}
+
+func TestSupportsUnicode(t *testing.T) {
+	for _, c := range []struct {
+		lcAll, lang, term string
+		want              bool
+	}{
+		{lang: "en_US.UTF-8", want: true},
+		{lang: "zh_CN.utf8", want: true},
+		{lang: "C", want: false},
+		{lcAll: "POSIX", lang: "en_US.UTF-8", want: false},
+		{term: "xterm-256color", want: true},
+		{term: "linux", want: false},
+	} {
+		t.Setenv("LC_ALL", c.lcAll)
+		t.Setenv("LC_CTYPE", "")
+		t.Setenv("LANG", c.lang)
+		t.Setenv("TERM", c.term)
+		if got := SupportsUnicode(); got != c.want {
+			t.Fatalf("SupportsUnicode() with %+v = %v, want %v", c, got, c.want)
+		}
+	}
+}
+
+func clearHyperlinkEnv(t *testing.T) {
+	for _, key := range []string{"TMUX", "TERM", "TERM_PROGRAM", "WT_SESSION", "KITTY_WINDOW_ID", "DOMTERM", "VTE_VERSION", "KONSOLE_VERSION", EnvForceHyperlink} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestSupportsHyperlinks(t *testing.T) {
+	for _, c := range []struct {
+		env  map[string]string
+		want bool
+	}{
+		{map[string]string{}, false},
+		{map[string]string{"TERM": "xterm-256color"}, false},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, true},
+		{map[string]string{"TERM_PROGRAM": "Apple_Terminal"}, false},
+		{map[string]string{"WT_SESSION": "1b2c"}, true},
+		{map[string]string{"TERM": "xterm-kitty"}, true},
+		{map[string]string{"VTE_VERSION": "6800"}, true},
+		{map[string]string{"VTE_VERSION": "4600"}, false},
+		{map[string]string{"KONSOLE_VERSION": "230805"}, true},
+		{map[string]string{"TERM_PROGRAM": "WezTerm", "TMUX": "/tmp/tmux-0/default,1,0"}, false},
+	} {
+		clearHyperlinkEnv(t)
+		for k, v := range c.env {
+			t.Setenv(k, v)
+		}
+		if got := SupportsHyperlinks(); got != c.want {
+			t.Fatalf("SupportsHyperlinks() with %v = %v, want %v", c.env, got, c.want)
+		}
+	}
+}
+
+func TestResolve_Hyperlink(t *testing.T) {
+	clearHyperlinkEnv(t)
+	t.Setenv(EnvNoColor, "")
+	t.Setenv(EnvForceColor, "")
+	t.Setenv(EnvForceTTY, "1")
+	t.Setenv("TERM_PROGRAM", "vscode")
+
+	if got := Resolve(&bytes.Buffer{}); !got.Hyperlink {
+		t.Fatalf("expected a capable terminal => hyperlink on, got %+v", got)
+	}
+
+	t.Setenv(EnvForceHyperlink, "0")
+	if got := Resolve(&bytes.Buffer{}); got.Hyperlink {
+		t.Fatalf("expected FORCE_HYPERLINK=0 => hyperlink off, got %+v", got)
+	}
+
+	t.Setenv(EnvForceTTY, "")
+	if got := Resolve(&bytes.Buffer{}); got.Hyperlink {
+		t.Fatalf("expected non-tty => hyperlink off, got %+v", got)
+	}
+
+	t.Setenv(EnvForceHyperlink, "1")
+	if got := Resolve(&bytes.Buffer{}); !got.Hyperlink {
+		t.Fatalf("expected FORCE_HYPERLINK=1 => hyperlink on, got %+v", got)
+	}
+
+	t.Setenv(EnvNoColor, "1")
+	if got := Resolve(&bytes.Buffer{}); got.Hyperlink {
+		t.Fatalf("expected NO_COLOR to disable hyperlinks, got %+v", got)
+	}
+}
+
+func TestOutputModeLink(t *testing.T) {
+	url := "http://127.0.0.1:3000"
+	for _, c := range []struct {
+		mode      OutputMode
+		text, url string
+		want      string
+	}{
+		{OutputMode{}, url, url, url},
+		{OutputMode{}, "Grafana", url, "Grafana (" + url + ")"},
+		{OutputMode{}, "Grafana", "", "Grafana"},
+		{OutputMode{Hyperlink: true}, "", url, "\x1b]8;;" + url + "\x07" + url + "\x1b]8;;\x07"},
+		{OutputMode{Hyperlink: true}, "Grafana", url, "\x1b]8;;" + url + "\x07Grafana\x1b]8;;\x07"},
+	} {
+		if got := c.mode.Link(c.text, c.url); got != c.want {
+			t.Fatalf("%+v.Link(%q, %q) = %q, want %q", c.mode, c.text, c.url, got, c.want)
+		}
+	}
+}
+
+func TestSize_Fallback(t *testing.T) {
+	t.Setenv(EnvColumns, "")
+	t.Setenv(EnvLines, "")
+	if w, h := Size(&bytes.Buffer{}, 80, 24); w != 80 || h != 24 {
+		t.Fatalf("expected the default size for a non-terminal, got %dx%d", w, h)
+	}
+
+	t.Setenv(EnvColumns, "132")
+	t.Setenv(EnvLines, "0")
+	if w, h := Size(&bytes.Buffer{}, 80, 24); w != 132 || h != 24 {
+		t.Fatalf("expected COLUMNS and the default height, got %dx%d", w, h)
+	}
+
+	t.Setenv(EnvLines, "abc")
+	var f *os.File
+	if w, h := Size(f, 0, 0); w != 132 || h != 0 {
+		t.Fatalf("expected a nil file to fall back to COLUMNS, got %dx%d", w, h)
+	}
+}
+
+func TestDetectColorDepth(t *testing.T) {
+	for _, c := range []struct {
+		colorterm, term string
+		want            ColorDepth
+	}{
+		{"", "", ColorDepth16},
+		{"", "xterm", ColorDepth16},
+		{"", "xterm-256color", ColorDepth256},
+		{"truecolor", "xterm-256color", ColorDepthTrueColor},
+		{"24bit", "", ColorDepthTrueColor},
+	} {
+		t.Setenv("COLORTERM", c.colorterm)
+		t.Setenv("TERM", c.term)
+		t.Setenv("WT_SESSION", "")
+		if got := DetectColorDepth(); got != c.want {
+			t.Fatalf("DetectColorDepth() with %+v = %v, want %v", c, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package term
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	xterm "golang.org/x/term"
+)
+
+// Environment variables that report the terminal size when it cannot be
+// queried, e.g. a shell exporting them to a program whose output is piped.
+const (
+	EnvColumns = "COLUMNS"
+	EnvLines   = "LINES"
+)
+
+// Size returns the width and height of the terminal out writes to. Each of
+// them is, in order, the first positive of: the size queried from the
+// terminal (ioctl), COLUMNS/LINES, and defWidth/defHeight.
+//
+// Some CI pseudo-terminals answer the query with a zero size, which is thus
+// treated as unknown rather than as a terminal 0 columns wide.
+func Size(out io.Writer, defWidth, defHeight int) (width, height int) {
+	if f, ok := out.(*os.File); ok && f != nil {
+		if w, h, err := xterm.GetSize(int(f.Fd())); err == nil {
+			width, height = w, h
+		}
+	}
+	if width <= 0 {
+		width = envSize(EnvColumns, defWidth)
+	}
+	if height <= 0 {
+		height = envSize(EnvLines, defHeight)
+	}
+	return width, height
+}
+
+func envSize(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
@@ -0,0 +1,23 @@
+package term
+
+import (
+	"os"
+	"strings"
+)
+
+// SupportsUnicode reports whether the terminal is expected to render Unicode
+// symbols (check marks, box-drawing characters, braille spinners).
+//
+// It follows the locale, using the first non-empty of LC_ALL, LC_CTYPE and
+// LANG: a non UTF-8 locale (e.g. "C" or "POSIX") means no. An unset locale is
+// common in containers whose terminal is still UTF-8 capable, so it is treated
+// as supported, except on the Linux virtual console (TERM=linux).
+func SupportsUnicode() bool {
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return os.Getenv("TERM") != "linux"
+}
@@ -0,0 +1,186 @@
+package output
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
+)
+
+// tableGap is the number of spaces between two columns of a Table.
+const tableGap = 2
+
+// numericCellRe matches the cells of a numeric column: counts, percents and
+// sizes like "512MiB". "-" and empty cells stand for a missing value.
+var numericCellRe = regexp.MustCompile(`^[-+]?\d+(\.\d+)?(%|[KMGTPE]?i?B)?$`)
+
+// Table renders rows under a header, each column as wide as its widest cell,
+// followed by a line of dashes under the header.
+//
+// Numeric columns (see numericCellRe) are right-aligned. When the table is
+// wider than Width, the widest other columns are clipped, down to the width
+// of their header, and their cells end with an ellipsis.
+type Table struct {
+	Header []string
+	Rows   [][]string
+
+	// Width is the width the lines must fit in, 0 means unlimited. See
+	// FitWidth for the width of a writer.
+	Width int
+
+	// Plain renders tab separated values instead, with the header as the
+	// first line, for scripts: no alignment, no clipping, no styling.
+	Plain bool
+}
+
+// FitWidth returns the width a table written to out should fit in: the width
+// of the terminal if out is an interactive terminal, unlimited otherwise, so
+// redirected output keeps whole values.
+func FitWidth(out io.Writer) int {
+	if !tuiterm.Resolve(out).Control {
+		return 0
+	}
+	width, _ := tuiterm.Size(out, 0, 0)
+	return width
+}
+
+// AddRow appends a row, cut to the number of columns of the header.
+func (t *Table) AddRow(row ...string) {
+	if len(row) > len(t.Header) {
+		row = row[:len(t.Header)]
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// Lines renders the table as a list of lines.
+func (t Table) Lines() []string {
+	if len(t.Header) == 0 {
+		return nil
+	}
+	if t.Plain {
+		lines := make([]string, 0, 1+len(t.Rows))
+		for _, row := range append([][]string{t.Header}, t.Rows...) {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = strings.NewReplacer("\t", " ", "\n", " ", "\r", "").Replace(ansi.Strip(cell))
+			}
+			lines = append(lines, strings.Join(cells, "\t"))
+		}
+		return lines
+	}
+
+	widths := make([]int, len(t.Header))
+	minWidths := make([]int, len(t.Header))
+	numeric := make([]bool, len(t.Header))
+	for i, h := range t.Header {
+		widths[i] = ansi.StringWidth(h)
+		minWidths[i] = max(widths[i], 3)
+		numeric[i] = t.isNumericColumn(i)
+	}
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], ansi.StringWidth(cell))
+		}
+	}
+	for i := range numeric {
+		// A clipped number would be misread.
+		if numeric[i] {
+			minWidths[i] = widths[i]
+		}
+	}
+	t.clip(widths, minWidths)
+
+	tail := "..."
+	if tuiterm.SupportsUnicode() {
+		tail = "…"
+	}
+	renderRow := func(row []string) string {
+		var b strings.Builder
+		for i := range t.Header {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			if ansi.StringWidth(cell) > widths[i] {
+				cell = ansi.Truncate(cell, widths[i], tail)
+			}
+			pad := strings.Repeat(" ", widths[i]-ansi.StringWidth(cell))
+			last := i == len(t.Header)-1
+			switch {
+			case numeric[i]:
+				b.WriteString(pad + cell)
+			case !last:
+				b.WriteString(cell + pad)
+			default:
+				// No trailing spaces on the last column.
+				b.WriteString(cell)
+			}
+			if !last {
+				b.WriteString(strings.Repeat(" ", tableGap))
+			}
+		}
+		return b.String()
+	}
+
+	dashes := make([]string, len(t.Header))
+	for i, h := range t.Header {
+		dashes[i] = strings.Repeat("-", ansi.StringWidth(h))
+	}
+	lines := make([]string, 0, 2+len(t.Rows))
+	lines = append(lines, renderRow(t.Header), renderRow(dashes))
+	for _, row := range t.Rows {
+		lines = append(lines, renderRow(row))
+	}
+	return lines
+}
+
+// Render renders the table as a single string, each line ending with a
+// newline.
+func (t Table) Render() string {
+	lines := t.Lines()
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (t Table) isNumericColumn(col int) bool {
+	found := false
+	for _, row := range t.Rows {
+		if col >= len(row) || row[col] == "" || row[col] == "-" {
+			continue
+		}
+		if !numericCellRe.MatchString(row[col]) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// clip narrows the widest columns, one cell at a time, until the table fits
+// in t.Width or no column can be narrowed.
+func (t Table) clip(widths, minWidths []int) {
+	if t.Width <= 0 {
+		return
+	}
+	total := tableGap * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for total > t.Width {
+		widest := -1
+		for i, w := range widths {
+			if w > minWidths[i] && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
@@ -0,0 +1,38 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable(t *testing.T) {
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+	tbl := Table{Header: []string{"NAME", "PID", "CPU", "LOG"}}
+	tbl.AddRow("tidb-0", "123", "12.5%", "/tmp/tidb-0/tidb.log", "extra")
+	tbl.AddRow("tikv-0", "4567", "-", "/tmp/tikv-0/tikv.log")
+
+	require.Equal(t, []string{
+		"NAME     PID    CPU  LOG",
+		"----     ---    ---  ---",
+		"tidb-0   123  12.5%  /tmp/tidb-0/tidb.log",
+		"tikv-0  4567      -  /tmp/tikv-0/tikv.log",
+	}, tbl.Lines())
+
+	// The widest column is clipped first, never below its header; numbers are
+	// never clipped.
+	tbl.Width = 30
+	require.Equal(t, []string{
+		"NAME     PID    CPU  LOG",
+		"----     ---    ---  ---",
+		"tidb-0   123  12.5%  /tmp/tid…",
+		"tikv-0  4567      -  /tmp/tik…",
+	}, tbl.Lines())
+	tbl.Width = 5
+	require.Equal(t, "tid…   123  12.5%  /t…", tbl.Lines()[2])
+
+	tbl.Plain = true
+	require.Equal(t, "NAME\tPID\tCPU\tLOG\ntidb-0\t123\t12.5%\t/tmp/tidb-0/tidb.log\ntikv-0\t4567\t-\t/tmp/tikv-0/tikv.log\n", tbl.Render())
+
+	require.Equal(t, "", Table{}.Render())
+}
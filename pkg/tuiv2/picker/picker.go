@@ -0,0 +1,112 @@
+// Package picker asks the user to choose one row of a table in the terminal.
+package picker
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pingcap/tiup/pkg/tui/colorstr"
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
+	tuiv2output "github.com/pingcap/tiup/pkg/tuiv2/output"
+)
+
+// ErrCanceled is returned by Pick when the user quits without choosing.
+var ErrCanceled = errors.New("no choice made")
+
+// Options describes the choice to make.
+type Options struct {
+	// Title is printed above the table, e.g. "Select a playground:".
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+// Pick shows the rows of opts read from in, the focused one highlighted, and
+// returns the index of the row the user chooses with Enter. Up/Down (or k/j)
+// move the focus; Esc, q and Ctrl+C return ErrCanceled.
+//
+// The caller makes sure in and out are terminals.
+func Pick(in io.Reader, out io.Writer, opts Options) (int, error) {
+	if len(opts.Rows) == 0 {
+		return -1, ErrCanceled
+	}
+	m := newModel(opts, tuiterm.Resolve(out))
+	final, err := tea.NewProgram(m, tea.WithInput(in), tea.WithOutput(out)).Run()
+	if err != nil {
+		return -1, err
+	}
+	if fm := final.(model); fm.chosen {
+		return fm.cursor, nil
+	}
+	return -1, ErrCanceled
+}
+
+type model struct {
+	title string
+	// lines are the rendered header and dashes, then one line per row.
+	lines  []string
+	mode   tuiterm.OutputMode
+	cursor int
+	chosen bool
+	done   bool
+}
+
+func newModel(opts Options, mode tuiterm.OutputMode) model {
+	tbl := tuiv2output.Table{Header: opts.Header, Rows: opts.Rows}
+	return model{title: opts.Title, lines: tbl.Lines(), mode: mode}
+}
+
+func (m model) rows() int { return len(m.lines) - 2 }
+
+func (m model) Init() tea.Cmd { return nil }
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "up", "k", "shift+tab":
+		m.cursor = (m.cursor + m.rows() - 1) % m.rows()
+	case "down", "j", "tab":
+		m.cursor = (m.cursor + 1) % m.rows()
+	case "enter":
+		m.chosen, m.done = true, true
+		return m, tea.Quit
+	case "esc", "q", "ctrl+c":
+		m.done = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	tokens := colorstr.TokensFor(m.mode)
+	var b strings.Builder
+	if m.done {
+		// Leave the choice in the terminal, not the whole table.
+		if m.chosen {
+			b.WriteString(tokens.Sprintf("%s [bold]%s[reset]\n", m.title, strings.TrimSpace(m.lines[m.cursor+2])))
+		}
+		return b.String()
+	}
+	b.WriteString(m.title + "\n\n")
+	for i, line := range m.lines {
+		switch {
+		case i < 2:
+			b.WriteString(tokens.Sprintf("    [dim]%s[reset]\n", line))
+		case i-2 == m.cursor:
+			b.WriteString(tokens.Sprintf("[light_cyan][bold]  > %s[reset]\n", line))
+		default:
+			b.WriteString("    " + line + "\n")
+		}
+	}
+	arrows := "Up/Down"
+	if tuiterm.SupportsUnicode() {
+		arrows = "↑/↓"
+	}
+	b.WriteString(tokens.Sprintf("\n[dim]%s to move, Enter to select, Esc to cancel[reset]\n", arrows))
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package picker
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel(t *testing.T) {
+	m := newModel(Options{
+		Title:  "Select a playground:",
+		Header: []string{"TAG", "VERSION"},
+		Rows:   [][]string{{"a", "v8.5.4"}, {"b", "nightly"}, {"c", "v7.5.0"}},
+	}, tuiterm.OutputMode{})
+	press := func(key tea.KeyType) tea.Cmd {
+		next, cmd := m.Update(tea.KeyMsg{Type: key})
+		m = next.(model)
+		return cmd
+	}
+
+	require.Contains(t, m.View(), "  > a    v8.5.4")
+	require.Nil(t, press(tea.KeyUp))
+	require.Equal(t, 2, m.cursor)
+	press(tea.KeyDown)
+	press(tea.KeyDown)
+	require.Equal(t, 1, m.cursor)
+	require.Contains(t, m.View(), "  > b    nightly")
+
+	require.NotNil(t, press(tea.KeyEnter))
+	require.True(t, m.chosen)
+	require.Equal(t, "Select a playground: b    nightly\n", m.View())
+
+	m.chosen, m.done = false, false
+	require.NotNil(t, press(tea.KeyEsc))
+	require.False(t, m.chosen)
+	require.Equal(t, "", m.View())
+}
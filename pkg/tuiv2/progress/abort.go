@@ -0,0 +1,62 @@
+package progress
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// Abort marks every task that is not finished yet as failed with msg, and
+// seals every group that is not closed yet.
+//
+// It is meant for a program about to exit abnormally (e.g. on a panic), so
+// neither its output nor its event log ends with tasks running forever. Call
+// Close afterwards to flush them.
+func (ui *UI) Abort(msg string) {
+	if ui == nil || ui.closed.Load() {
+		return
+	}
+	ui.liveMu.Lock()
+	tasks := slices.SortedFunc(maps.Values(ui.liveTasks), func(a, b *Task) int { return cmp.Compare(a.id, b.id) })
+	groups := slices.SortedFunc(maps.Values(ui.liveGroups), func(a, b *Group) int { return cmp.Compare(a.id, b.id) })
+	ui.liveMu.Unlock()
+
+	for _, t := range tasks {
+		t.Error(msg)
+	}
+	for _, g := range groups {
+		g.Seal()
+	}
+}
+
+// trackTask and trackGroup record the handles Abort finishes, until they are
+// finished (untrackTask) or closed (untrackGroup).
+func (ui *UI) trackTask(t *Task) {
+	ui.liveMu.Lock()
+	defer ui.liveMu.Unlock()
+	if ui.liveTasks == nil {
+		ui.liveTasks = make(map[uint64]*Task)
+	}
+	ui.liveTasks[t.id] = t
+}
+
+func (ui *UI) untrackTask(id uint64) {
+	ui.liveMu.Lock()
+	defer ui.liveMu.Unlock()
+	delete(ui.liveTasks, id)
+}
+
+func (ui *UI) trackGroup(g *Group) {
+	ui.liveMu.Lock()
+	defer ui.liveMu.Unlock()
+	if ui.liveGroups == nil {
+		ui.liveGroups = make(map[uint64]*Group)
+	}
+	ui.liveGroups[g.id] = g
+}
+
+func (ui *UI) untrackGroup(id uint64) {
+	ui.liveMu.Lock()
+	defer ui.liveMu.Unlock()
+	delete(ui.liveGroups, id)
+}
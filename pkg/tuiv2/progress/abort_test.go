@@ -0,0 +1,42 @@
+package progress
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUIAbort_FailsUnfinishedTasksAndSealsOpenGroups(t *testing.T) {
+	var log bytes.Buffer
+	ui := New(Options{Mode: ModePlain, Out: io.Discard, EventLog: &log})
+
+	closed := ui.Group("Download")
+	closed.Task("pd").Done()
+	closed.Close()
+
+	open := ui.Group("Start")
+	done := open.Task("PD")
+	done.Done()
+	running := open.Task("TiKV")
+	pending := open.TaskPending("TiDB")
+
+	ui.Abort("playground crashed")
+	require.NoError(t, ui.Close())
+
+	failed := make(map[uint64]string)
+	var sealed []uint64
+	for _, line := range bytes.Split(bytes.TrimSpace(log.Bytes()), []byte("\n")) {
+		e, err := DecodeEvent(line)
+		require.NoError(t, err)
+		switch {
+		case e.Type == EventTaskState && *e.Status == TaskStatusError:
+			failed[e.TaskID] = *e.Message
+		case e.Type == EventGroupClose && e.Finished != nil && !*e.Finished:
+			sealed = append(sealed, e.GroupID)
+		}
+	}
+	require.Equal(t, map[uint64]string{running.id: "playground crashed", pending.id: "playground crashed"}, failed)
+	require.Equal(t, []uint64{open.id}, sealed)
+}
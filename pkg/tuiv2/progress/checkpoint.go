@@ -0,0 +1,323 @@
+package progress
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+)
+
+// Checkpoint is the payload of EventCheckpoint: a full snapshot of the groups
+// and tasks that are not sealed yet.
+//
+// Groups and tasks are listed in creation order, parents before children, so
+// they can be restored in a single pass.
+type Checkpoint struct {
+	StartedAt time.Time         `json:"started_at"`
+	Groups    []CheckpointGroup `json:"groups,omitempty"`
+	Tasks     []CheckpointTask  `json:"tasks,omitempty"`
+}
+
+// CheckpointGroup is the state of one group in a Checkpoint.
+type CheckpointGroup struct {
+	ID       uint64    `json:"gid"`
+	ParentID uint64    `json:"parent_gid,omitempty"`
+	Title    string    `json:"title,omitempty"`
+	Started  time.Time `json:"started_at"`
+	Closed   time.Time `json:"closed_at,omitempty"`
+
+	ShowMeta             bool `json:"show_meta,omitempty"`
+	HideDetailsOnSuccess bool `json:"hide_details_on_success,omitempty"`
+	SortTasksByTitle     bool `json:"sort_tasks_by_title,omitempty"`
+	ShowDownloadTotals   bool `json:"show_download_totals,omitempty"`
+	ShowProgressCount    bool `json:"show_progress_count,omitempty"`
+}
+
+// CheckpointTask is the state of one task in a Checkpoint.
+type CheckpointTask struct {
+	ID       uint64 `json:"tid"`
+	GroupID  uint64 `json:"gid"`
+	ParentID uint64 `json:"parent_tid,omitempty"`
+	Title    string `json:"title,omitempty"`
+
+	Kind      TaskKind          `json:"kind"`
+	Direction TransferDirection `json:"direction,omitempty"`
+	Status    TaskStatus        `json:"status"`
+
+	Meta    string   `json:"meta,omitempty"`
+	Message string   `json:"message,omitempty"`
+	Hints   []string `json:"hints,omitempty"`
+
+	HideIfFast    bool    `json:"hide_if_fast,omitempty"`
+	RevealAfterMs int64   `json:"reveal_after_ms,omitempty"`
+	Weight        float64 `json:"weight,omitempty"`
+
+	Current int64 `json:"current,omitempty"`
+	Total   int64 `json:"total,omitempty"`
+
+	StartAt time.Time `json:"start_at,omitempty"`
+	EndAt   time.Time `json:"end_at,omitempty"`
+}
+
+// checkpoint snapshots the groups and tasks that are not sealed yet.
+func (s *engineState) checkpoint() *Checkpoint {
+	cp := &Checkpoint{StartedAt: s.startedAt}
+	var addTask func(t *taskState)
+	addTask = func(t *taskState) {
+		ct := CheckpointTask{
+			ID:            t.id,
+			GroupID:       t.g.id,
+			Title:         t.title,
+			Kind:          t.kind.exported(),
+			Direction:     t.direction,
+			Status:        t.status.exported(),
+			Meta:          t.meta,
+			Message:       t.message,
+			Hints:         t.hints,
+			HideIfFast:    t.hideIfFast,
+			RevealAfterMs: t.revealAfter.Milliseconds(),
+			Weight:        t.weight,
+			Current:       t.current,
+			Total:         t.total,
+			StartAt:       t.startAt,
+			EndAt:         t.endAt,
+		}
+		if t.parent != nil {
+			ct.ParentID = t.parent.id
+		}
+		cp.Tasks = append(cp.Tasks, ct)
+		for _, c := range t.children {
+			addTask(c)
+		}
+	}
+	var addGroup func(g *groupState)
+	addGroup = func(g *groupState) {
+		cg := CheckpointGroup{
+			ID:                   g.id,
+			Title:                g.title,
+			Started:              g.startedAt,
+			Closed:               g.closedAt,
+			ShowMeta:             g.showMeta,
+			HideDetailsOnSuccess: g.hideDetailsOnSuccess,
+			SortTasksByTitle:     g.sortTasksByTitle,
+			ShowDownloadTotals:   g.showDownloadTotals,
+			ShowProgressCount:    g.showProgressCount,
+		}
+		if g.parent != nil {
+			cg.ParentID = g.parent.id
+		}
+		cp.Groups = append(cp.Groups, cg)
+		for _, t := range g.tasks {
+			addTask(t)
+		}
+		for _, c := range g.children {
+			addGroup(c)
+		}
+	}
+	for _, g := range s.groups {
+		if !g.sealed {
+			addGroup(g)
+		}
+	}
+	return cp
+}
+
+// applyCheckpoint replaces the whole state with the checkpoint.
+//
+// Restored tasks are marked as already reported by the line-oriented
+// renderers, so a reader attaching at a checkpoint only prints transitions
+// that happen after it.
+func (s *engineState) applyCheckpoint(cp *Checkpoint) {
+	if cp == nil {
+		return
+	}
+	*s = *newEngineState()
+	s.startedAt = cp.StartedAt
+
+	for _, cg := range cp.Groups {
+		g := &groupState{
+			id:                   cg.ID,
+			title:                cg.Title,
+			startedAt:            cg.Started,
+			closedAt:             cg.Closed,
+			closed:               !cg.Closed.IsZero(),
+			showMeta:             cg.ShowMeta,
+			hideDetailsOnSuccess: cg.HideDetailsOnSuccess,
+			sortTasksByTitle:     cg.SortTasksByTitle,
+			showDownloadTotals:   cg.ShowDownloadTotals,
+			showProgressCount:    cg.ShowProgressCount,
+			jsonClosedReported:   !cg.Closed.IsZero(),
+		}
+		if parent := s.groupByID[cg.ParentID]; parent != nil {
+			g.parent = parent
+			parent.children = append(parent.children, g)
+		} else {
+			s.groups = append(s.groups, g)
+		}
+		s.groupByID[g.id] = g
+	}
+
+	for _, ct := range cp.Tasks {
+		g := s.groupByID[ct.GroupID]
+		if g == nil {
+			continue
+		}
+		t := &taskState{
+			id:          ct.ID,
+			g:           g,
+			title:       ct.Title,
+			kind:        parseTaskKind(ct.Kind),
+			direction:   ct.Direction,
+			status:      parseTaskStatus(ct.Status),
+			meta:        ct.Meta,
+			message:     ct.Message,
+			hints:       ct.Hints,
+			hideIfFast:  ct.HideIfFast,
+			revealAfter: time.Duration(ct.RevealAfterMs) * time.Millisecond,
+			weight:      ct.Weight,
+			current:     ct.Current,
+			total:       ct.Total,
+			startAt:     ct.StartAt,
+			endAt:       ct.EndAt,
+		}
+		t.plainStartPrinted = t.status != taskStatusPending
+		t.downloadStartPrinted = t.plainStartPrinted
+		t.plainEndPrinted = !t.endAt.IsZero()
+		t.jsonReported = true
+		t.jsonStatus = t.status
+
+		if parent := s.taskByID[ct.ParentID]; parent != nil && parent.g == g {
+			t.parent = parent
+			parent.children = append(parent.children, t)
+		} else {
+			g.tasks = append(g.tasks, t)
+		}
+		s.taskByID[t.id] = t
+	}
+}
+
+// maybeWriteCheckpoint writes an EventCheckpoint of st to the event log once
+// per Options.CheckpointInterval. It must only be called from the render loop.
+func (ui *UI) maybeWriteCheckpoint(now time.Time, st *engineState) {
+	if ui.eventLog == nil || ui.checkpointInterval <= 0 {
+		return
+	}
+	if ui.lastCheckpointAt.IsZero() {
+		ui.lastCheckpointAt = now
+		return
+	}
+	if now.Sub(ui.lastCheckpointAt) < ui.checkpointInterval {
+		return
+	}
+	ui.lastCheckpointAt = now
+	ui.eventLog.write(now, Event{Type: EventCheckpoint, At: now, Checkpoint: st.checkpoint()})
+}
+
+// checkpointPrefix is how encoded checkpoint lines start: Type is the first
+// field of Event.
+var checkpointPrefix = []byte(`{"type":"` + string(EventCheckpoint) + `"`)
+
+// LastCheckpointOffset returns the offset of the last EventCheckpoint record
+// in the event log at path, or 0 if there is none, so readers attaching to a
+// long-running event log can start replaying from there.
+//
+// EventLogJSON logs are scanned backwards, so the cost depends on the distance
+// to the last checkpoint rather than on the file size. EventLogBinary records
+// have no delimiter to scan backwards for: logs starting with one are read
+// from the beginning.
+//
+// Compressed logs (see GzipEventLog) are not indexed: they always yield 0.
+func LastCheckpointOffset(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var magic [2]byte
+	n, _ := f.ReadAt(magic[:], 0)
+	switch {
+	case n == len(magic) && magic == [2]byte{0x1f, 0x8b}:
+		return 0, nil
+	case n > 0 && magic[0] == binaryRecordMarker:
+		return scanLastCheckpoint(f)
+	}
+
+	const chunkSize = 64 * 1024
+	end := st.Size()
+	// tail holds the bytes after the last newline seen so far, i.e. the
+	// beginning of the line following the current chunk.
+	var tail []byte
+	for end > 0 {
+		start := max(end-chunkSize, 0)
+		buf := make([]byte, end-start, end-start+int64(len(tail)))
+		if _, err := f.ReadAt(buf, start); err != nil && err != io.EOF {
+			return 0, err
+		}
+		buf = append(buf, tail...)
+
+		for {
+			i := bytes.LastIndexByte(buf, '\n')
+			if i < 0 {
+				break
+			}
+			if bytes.HasPrefix(buf[i+1:], checkpointPrefix) {
+				return start + int64(i) + 1, nil
+			}
+			buf = buf[:i]
+		}
+		if start == 0 && bytes.HasPrefix(buf, checkpointPrefix) {
+			return 0, nil
+		}
+		tail = buf
+		end = start
+	}
+	return 0, nil
+}
+
+// scanLastCheckpoint reads the records of the event log f from the beginning
+// and returns the offset of the last checkpoint.
+func scanLastCheckpoint(f *os.File) (int64, error) {
+	var (
+		last, offset int64
+		pending      []byte
+		buf          = make([]byte, 64*1024)
+	)
+	for {
+		n, err := f.ReadAt(buf, offset+int64(len(pending)))
+		pending = append(pending, buf[:n]...)
+		for {
+			record, size, splitErr := splitEventRecord(pending)
+			if splitErr != nil {
+				return last, splitErr
+			}
+			if record == nil {
+				break
+			}
+			if isCheckpointRecord(record) {
+				last = offset
+			}
+			pending = pending[size:]
+			offset += int64(size)
+		}
+		if err == io.EOF || n == 0 {
+			return last, nil
+		}
+		if err != nil {
+			return last, err
+		}
+	}
+}
+
+func isCheckpointRecord(record []byte) bool {
+	if len(record) == 0 || record[0] != binaryRecordMarker {
+		return bytes.HasPrefix(record, checkpointPrefix)
+	}
+	e, err := decodeEventRecord(record)
+	return err == nil && e.Type == EventCheckpoint
+}
@@ -0,0 +1,152 @@
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
+	"github.com/stretchr/testify/require"
+)
+
+func readEventLog(t *testing.T, path string, offset int64) []Event {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var events []Event
+	sc := bufio.NewScanner(bytes.NewReader(data[offset:]))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		e, err := DecodeEvent(sc.Bytes())
+		require.NoError(t, err)
+		events = append(events, e)
+	}
+	require.NoError(t, sc.Err())
+	return events
+}
+
+func TestCheckpoint_AttachFromLastCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{
+		Mode:               ModePlain,
+		Out:                &bytes.Buffer{},
+		EventLog:           f,
+		CheckpointInterval: time.Second,
+		Now:                func() time.Time { return now },
+	})
+
+	g := ui.Group("Download components")
+	done := g.Task("pd")
+	done.Start()
+	dl := g.Task("tidb")
+	dl.SetKindDownload()
+	dl.SetTotal(100)
+	dl.Start()
+	sub := g.Group("Verify")
+	sub.Task("checksum").Start()
+	ui.Sync()
+
+	now = now.Add(2 * time.Second)
+	done.Done()
+	dl.SetCurrent(40)
+	ui.Sync()
+
+	// Push the checkpoint more than one read chunk away from the end.
+	ui.PrintLines([]string{strings.Repeat("x", 100*1024)})
+	now = now.Add(500 * time.Millisecond)
+	dl.SetCurrent(60)
+	require.NoError(t, ui.Close())
+
+	offset, err := LastCheckpointOffset(path)
+	require.NoError(t, err)
+	require.Positive(t, offset)
+
+	all := readEventLog(t, path, 0)
+	tail := readEventLog(t, path, offset)
+	require.Equal(t, EventCheckpoint, tail[0].Type)
+	require.Len(t, tail[0].Checkpoint.Groups, 2)
+	require.Len(t, tail[0].Checkpoint.Tasks, 3)
+	require.Less(t, len(tail), len(all))
+
+	opts := FrameOptions{Now: now, Width: 80, Height: 24}
+	require.Equal(t, RenderFrame(all, opts).Active, RenderFrame(tail, opts).Active)
+}
+
+func TestCheckpoint_RestoredTasksAreNotReprinted(t *testing.T) {
+	st := newEngineState()
+	now := time.Unix(1_000_000, 0)
+	title := "Start"
+	st.applyEvent(now, Event{Type: EventGroupAdd, GroupID: 1, Title: &title})
+	pd, tikv := "pd", "tikv"
+	st.applyEvent(now, Event{Type: EventTaskAdd, GroupID: 1, TaskID: 2, Title: &pd})
+	st.applyEvent(now, Event{Type: EventTaskAdd, GroupID: 1, TaskID: 3, Title: &tikv})
+	running, done := TaskStatusRunning, TaskStatusDone
+	st.applyEvent(now, Event{Type: EventTaskState, TaskID: 2, Status: &running})
+	st.applyEvent(now, Event{Type: EventTaskState, TaskID: 3, Status: &done})
+
+	restored := newEngineState()
+	restored.applyEvent(now, Event{Type: EventCheckpoint, Checkpoint: st.checkpoint()})
+	require.Equal(t, st.checkpoint(), restored.checkpoint())
+
+	var out bytes.Buffer
+	r := newPlainRenderer(&out, tuiterm.OutputMode{}, true)
+	r.renderEvent(now, Event{Type: EventTaskState, TaskID: 3, Status: &done}, restored)
+	require.Empty(t, out.String())
+}
+
+func TestLastCheckpointOffset_NoCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"type":"print_lines","lines":["hi"]}`+"\n"), 0o644))
+
+	offset, err := LastCheckpointOffset(path)
+	require.NoError(t, err)
+	require.Zero(t, offset)
+}
+
+func TestLastCheckpointOffset_Binary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.bin")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{
+		Mode:               ModePlain,
+		Out:                &bytes.Buffer{},
+		EventLog:           f,
+		EventLogEncoding:   EventLogBinary,
+		CheckpointInterval: time.Second,
+		Now:                func() time.Time { return now },
+	})
+	task := ui.Group("Start").Task("pd")
+	task.Start()
+	ui.Sync()
+	now = now.Add(2 * time.Second)
+	task.Done()
+	ui.Sync()
+	ui.PrintLines([]string{"after the checkpoint"})
+	require.NoError(t, ui.Close())
+
+	offset, err := LastCheckpointOffset(path)
+	require.NoError(t, err)
+	require.Positive(t, offset)
+
+	r, err := OpenEventLog(path)
+	require.NoError(t, err)
+	defer r.Close()
+	require.NoError(t, r.SetOffset(offset))
+	e, err := r.Next()
+	require.NoError(t, err)
+	require.Equal(t, EventCheckpoint, e.Type)
+	require.Len(t, e.Checkpoint.Tasks, 1)
+}
@@ -14,10 +14,12 @@ const (
 	//
 	// A blank line can be represented as `EventPrintLines{Lines: []string{""}}`.
 	EventPrintLines EventType = "print_lines"
-	// EventSync is an internal barrier event.
+	// EventSync is a barrier event.
 	//
 	// It is emitted by UI.Sync and allows callers to wait until all previously
 	// emitted events are processed (and persisted to the event log when enabled).
+	// It is written to the event log once they are, so tailers of the log know
+	// that everything before it is consistent.
 	//
 	// Renderers should ignore it.
 	EventSync         EventType = "sync"
@@ -28,6 +30,24 @@ const (
 	EventTaskUpdate   EventType = "task_update"
 	EventTaskProgress EventType = "task_progress"
 	EventTaskState    EventType = "task_state"
+	// EventSummary is emitted by UI.Close when Options.Summary is set.
+	//
+	// Its Summary payload is resolved by the engine from the final state before
+	// the event reaches the event log.
+	EventSummary EventType = "summary"
+	// EventCheckpoint carries a full snapshot of the state. It is only written
+	// to the event log (see Options.CheckpointInterval), so readers attaching
+	// to a long-running log can start from the last checkpoint.
+	EventCheckpoint EventType = "checkpoint"
+)
+
+// PrintSeverity is the stable string representation of a PrintLines severity.
+type PrintSeverity string
+
+// Print severities. An empty severity means regular output.
+const (
+	PrintSeverityWarn  PrintSeverity = "warn"
+	PrintSeverityError PrintSeverity = "error"
 )
 
 // TaskStatus is the stable string representation of a task status.
@@ -51,6 +71,19 @@ type TaskKind string
 const (
 	TaskKindGeneric  TaskKind = "generic"
 	TaskKindDownload TaskKind = "download"
+	// TaskKindTransfer is a generic byte-moving task (e.g. loading sample data
+	// or archiving a data dir), rendered like downloads.
+	TaskKindTransfer TaskKind = "transfer"
+)
+
+// TransferDirection is the stable string representation of the direction of a
+// TaskKindTransfer task.
+type TransferDirection string
+
+// Transfer directions.
+const (
+	TransferUpload   TransferDirection = "upload"
+	TransferDownload TransferDirection = "download"
 )
 
 // Event is the canonical, append-only input to the tuiv2 progress engine.
@@ -63,24 +96,37 @@ type Event struct {
 	Type EventType `json:"type"`
 	// At is the event timestamp.
 	At time.Time `json:"at,omitempty"`
+	// Source names the stream the event comes from when several streams are
+	// replayed into one UI (see UI.ReplayEvent). IDs are unique per source.
+	Source string `json:"src,omitempty"`
+	// Seq numbers the events of an event log, from 1 for each writing UI, so
+	// readers can detect missing events (see EventLogReader.Gaps).
+	Seq uint64 `json:"seq,omitempty"`
 
 	// IDs (stable).
 	GroupID uint64 `json:"gid,omitempty"`
 	TaskID  uint64 `json:"tid,omitempty"`
 
 	// PrintLines payload.
-	Lines []string `json:"lines,omitempty"`
+	Lines    []string      `json:"lines,omitempty"`
+	Severity PrintSeverity `json:"severity,omitempty"`
 
 	// Sync payload.
 	SyncID uint64 `json:"sync_id,omitempty"`
 
-	// Common "title" field (group/task add, group update).
+	// Group add. ParentGroupID makes the added group a sub-group of another
+	// group.
+	ParentGroupID uint64 `json:"parent_gid,omitempty"`
+
+	// Common "title" field (group/task add, group/task update).
 	Title *string `json:"title,omitempty"`
 
 	// Group options (group update).
 	ShowMeta             *bool `json:"show_meta,omitempty"`
 	HideDetailsOnSuccess *bool `json:"hide_details_on_success,omitempty"`
 	SortTasksByTitle     *bool `json:"sort_tasks_by_title,omitempty"`
+	ShowDownloadTotals   *bool `json:"show_download_totals,omitempty"`
+	ShowProgressCount    *bool `json:"show_progress_count,omitempty"`
 	// Group close.
 	//
 	// Finished=false means "seal snapshot": the group is moved from Active to
@@ -90,6 +136,9 @@ type Event struct {
 
 	// Task add.
 	Pending bool `json:"pending,omitempty"`
+	// ParentID makes the added task a sub-task of another task in the same
+	// group.
+	ParentID uint64 `json:"parent_tid,omitempty"`
 
 	// Task update.
 	Kind          *TaskKind `json:"kind,omitempty"`
@@ -97,6 +146,10 @@ type Event struct {
 	Message       *string   `json:"message,omitempty"`
 	HideIfFast    *bool     `json:"hide_if_fast,omitempty"`
 	RevealAfterMs *int64    `json:"reveal_after_ms,omitempty"`
+	// Direction is set along with Kind for TaskKindTransfer.
+	Direction *TransferDirection `json:"direction,omitempty"`
+	// Weight is the relative share of the task in group progress.
+	Weight *float64 `json:"weight,omitempty"`
 
 	// Task progress.
 	Current *int64 `json:"current,omitempty"`
@@ -104,6 +157,27 @@ type Event struct {
 
 	// Task state transition.
 	Status *TaskStatus `json:"status,omitempty"`
+	// Hints are remediation suggestions attached to an error transition.
+	Hints []string `json:"hints,omitempty"`
+
+	// Summary payload.
+	Summary *Summary `json:"summary,omitempty"`
+
+	// Checkpoint payload.
+	Checkpoint *Checkpoint `json:"checkpoint,omitempty"`
+}
+
+// coalesceProgress merges two EventTaskProgress events of the same task into
+// one equivalent to applying both: fields of next win, fields it omits are
+// kept from prev.
+func coalesceProgress(prev, next Event) Event {
+	if next.Current == nil {
+		next.Current = prev.Current
+	}
+	if next.Total == nil {
+		next.Total = prev.Total
+	}
+	return next
 }
 
 func parseEventLine(line []byte) (Event, error) {
@@ -40,7 +40,7 @@ func TestEventLogDrivenTTYRender_AutoSealMovesGroupToHistory(t *testing.T) {
 
 	// Simulate the TTY engine behavior: seal finished groups and print snapshot.
 	ctx := ttyRenderContext{
-		styles:  newTTYStyles(io.Discard),
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
 		width:   200,
 		spinner: "",
 		now:     now,
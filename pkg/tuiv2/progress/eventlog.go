@@ -1,31 +1,202 @@
 package progress
 
 import (
-	"encoding/json"
 	"io"
+	"slices"
+	"sync"
 	"time"
 )
 
+// EventLogSync decides when the event log is fsynced, i.e. which events
+// survive a crash of the process writing it (or of its machine).
+type EventLogSync int
+
+const (
+	// EventLogSyncNever leaves writing the event log to disk to the OS.
+	EventLogSyncNever EventLogSync = iota
+	// EventLogSyncOnStateChange fsyncs after every state transition (tasks
+	// finishing, groups closing, printed lines and summaries), so the error
+	// lines explaining why a daemon died are on disk before it can crash.
+	EventLogSyncOnStateChange
+	// EventLogSyncEveryN fsyncs after every Options.EventLogSyncEvery events.
+	EventLogSyncEveryN
+)
+
 type eventLogSink struct {
-	enc *json.Encoder
+	// mu guards the sink, written by the UI engine and by progressTimer.
+	mu     sync.Mutex
+	encode func(Event) error
+
+	// progressInterval is Options.EventLogProgressInterval. lastProgress is
+	// when the last progress event of each task was written, and
+	// skippedProgress the coalesced progress skipped since then.
+	// progressTimer writes the skipped progress once the interval elapses, so
+	// readers tailing the log see it even if no other event follows (e.g. a
+	// download stalls).
+	progressInterval time.Duration
+	lastProgress     map[uint64]time.Time
+	skippedProgress  map[uint64]Event
+	progressTimer    *time.Timer
+
+	// flusher is set when the writer buffers events (e.g. GzipEventLog), to
+	// persist them on Sync and Close.
+	flusher interface{ Flush() error }
+
+	// syncer is set when the writer can fsync (e.g. *os.File and
+	// GzipEventLog). unsynced counts the events written since the last fsync.
+	syncer     interface{ Sync() error }
+	syncPolicy EventLogSync
+	syncEvery  int
+	unsynced   int
+
+	// seq is the sequence number of the last written event.
+	seq uint64
 }
 
-func newEventLogSink(w io.Writer) *eventLogSink {
+func newEventLogSink(w io.Writer, opts Options) (*eventLogSink, error) {
 	if w == nil {
-		return nil
+		return nil, nil
+	}
+	encode, err := newEventEncoder(w, opts.EventLogEncoding)
+	if err != nil {
+		return nil, err
 	}
-	return &eventLogSink{
-		enc: json.NewEncoder(w),
+	s := &eventLogSink{
+		encode:           encode,
+		progressInterval: opts.EventLogProgressInterval,
+		lastProgress:     make(map[uint64]time.Time),
+		skippedProgress:  make(map[uint64]Event),
+		syncPolicy:       opts.EventLogSync,
+		syncEvery:        max(opts.EventLogSyncEvery, 1),
 	}
+	s.flusher, _ = w.(interface{ Flush() error })
+	s.syncer, _ = w.(interface{ Sync() error })
+	return s, nil
 }
 
 func (s *eventLogSink) write(now time.Time, e Event) {
-	if s == nil || s.enc == nil {
+	if s == nil || s.encode == nil {
 		return
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if e.At.IsZero() {
 		e.At = now
 	}
 
-	_ = s.enc.Encode(e)
+	if s.progressInterval > 0 {
+		if e.Type == EventTaskProgress {
+			if prev, ok := s.skippedProgress[e.TaskID]; ok {
+				e = coalesceProgress(prev, e)
+			}
+			if last, ok := s.lastProgress[e.TaskID]; ok && e.At.Sub(last) < s.progressInterval {
+				s.skippedProgress[e.TaskID] = e
+				if s.progressTimer == nil {
+					s.progressTimer = time.AfterFunc(s.progressInterval, s.flushSkippedProgress)
+				}
+				return
+			}
+			delete(s.skippedProgress, e.TaskID)
+			s.lastProgress[e.TaskID] = e.At
+		} else {
+			// Keep the log ordered: progress that happened before e is
+			// written before it.
+			s.writeSkippedProgress()
+		}
+	}
+
+	s.append(e)
+	switch s.syncPolicy {
+	case EventLogSyncOnStateChange:
+		if isStateTransition(e.Type) {
+			s.sync()
+		}
+	case EventLogSyncEveryN:
+		if s.unsynced >= s.syncEvery {
+			s.sync()
+		}
+	}
+}
+
+// isStateTransition reports whether events of type t end something (a task,
+// a group, the whole run) or print lines, which are what readers of the log
+// of a crashed process need the most.
+func isStateTransition(t EventType) bool {
+	switch t {
+	case EventTaskState, EventGroupClose, EventPrintLines, EventSummary:
+		return true
+	default:
+		return false
+	}
+}
+
+// flushSkippedProgress writes the progress held since progressTimer was set,
+// and makes it available to the readers of the log.
+func (s *eventLogSink) flushSkippedProgress() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.skippedProgress) == 0 {
+		return
+	}
+	s.writeSkippedProgress()
+	if s.flusher != nil {
+		_ = s.flusher.Flush()
+	}
+}
+
+// writeSkippedProgress writes the progress skipped by downsampling, ordered by
+// task ID.
+func (s *eventLogSink) writeSkippedProgress() {
+	if s.progressTimer != nil {
+		s.progressTimer.Stop()
+		s.progressTimer = nil
+	}
+	if len(s.skippedProgress) == 0 {
+		return
+	}
+	ids := make([]uint64, 0, len(s.skippedProgress))
+	for id := range s.skippedProgress {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		e := s.skippedProgress[id]
+		delete(s.skippedProgress, id)
+		s.lastProgress[id] = e.At
+		s.append(e)
+	}
+}
+
+// append numbers and encodes e.
+func (s *eventLogSink) append(e Event) {
+	s.seq++
+	e.Seq = s.seq
+	_ = s.encode(e)
+	s.unsynced++
+}
+
+func (s *eventLogSink) flush() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writeSkippedProgress()
+	if s.flusher != nil {
+		_ = s.flusher.Flush()
+	}
+	if s.syncPolicy != EventLogSyncNever && s.unsynced > 0 {
+		s.sync()
+	}
+}
+
+// sync writes the events buffered by the writer to disk.
+func (s *eventLogSink) sync() {
+	if s.flusher != nil {
+		_ = s.flusher.Flush()
+	}
+	if s.syncer != nil {
+		_ = s.syncer.Sync()
+	}
+	s.unsynced = 0
 }
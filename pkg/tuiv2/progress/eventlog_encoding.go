@@ -0,0 +1,136 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// EventLogEncoding selects how events are encoded in the event log.
+type EventLogEncoding string
+
+// Event log encodings.
+const (
+	// EventLogJSON writes one JSON object per line. It is the default.
+	EventLogJSON EventLogEncoding = "json"
+	// EventLogBinary writes length-prefixed MessagePack records with the same
+	// schema (field names) as EventLogJSON, which is cheaper to encode for
+	// high-frequency progress events. ConvertEventLog turns such logs back
+	// into JSON lines.
+	EventLogBinary EventLogEncoding = "binary"
+)
+
+// binaryRecordMarker starts every EventLogBinary record, followed by the
+// uvarint length of the MessagePack payload. It can never start a JSON line,
+// so logs appended to by processes with different encodings stay readable.
+const binaryRecordMarker = 0x01
+
+// maxBinaryRecordSize bounds the length prefix of EventLogBinary records, so a
+// corrupted prefix is reported instead of waiting for data that never comes.
+const maxBinaryRecordSize = 64 << 20
+
+// newEventEncoder returns a function writing events to w with enc.
+func newEventEncoder(w io.Writer, enc EventLogEncoding) (func(Event) error, error) {
+	switch enc {
+	case "", EventLogJSON:
+		je := json.NewEncoder(w)
+		return func(e Event) error { return je.Encode(e) }, nil
+	case EventLogBinary:
+		var payload bytes.Buffer
+		me := msgpack.NewEncoder(&payload)
+		me.SetCustomStructTag("json")
+		me.UseCompactInts(true)
+		var record []byte
+		return func(e Event) error {
+			payload.Reset()
+			if err := me.Encode(e); err != nil {
+				return err
+			}
+			record = append(record[:0], binaryRecordMarker)
+			record = binary.AppendUvarint(record, uint64(payload.Len()))
+			record = append(record, payload.Bytes()...)
+			_, err := w.Write(record)
+			return err
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown event log encoding %q", enc)
+	}
+}
+
+// splitEventRecord returns the first complete record of data and its length
+// in data. A nil record means there is no complete record yet. Blank JSON
+// lines yield an empty record.
+func splitEventRecord(data []byte) (record []byte, n int, err error) {
+	if len(data) == 0 {
+		return nil, 0, nil
+	}
+	if data[0] != binaryRecordMarker {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return nil, 0, nil
+		}
+		line := bytes.TrimSpace(data[:i])
+		if line == nil {
+			// Blank lines are complete, empty records.
+			line = data[:0]
+		}
+		return line, i + 1, nil
+	}
+
+	size, k := binary.Uvarint(data[1:])
+	if k < 0 || size > maxBinaryRecordSize {
+		return nil, 0, fmt.Errorf("corrupted event log record")
+	}
+	if k == 0 {
+		return nil, 0, nil
+	}
+	end := 1 + k + int(size)
+	if end > len(data) {
+		return nil, 0, nil
+	}
+	return data[:end], end, nil
+}
+
+// decodeEventRecord decodes a record returned by splitEventRecord.
+func decodeEventRecord(record []byte) (Event, error) {
+	if len(record) == 0 || record[0] != binaryRecordMarker {
+		return DecodeEvent(record)
+	}
+	_, k := binary.Uvarint(record[1:])
+	var e Event
+	dec := msgpack.NewDecoder(bytes.NewReader(record[1+k:]))
+	dec.SetCustomStructTag("json")
+	err := dec.Decode(&e)
+	return e, err
+}
+
+// ConvertEventLog re-encodes the event log at path (in any encoding, possibly
+// compressed) into w with enc, e.g. to inspect a binary log as JSON lines.
+func ConvertEventLog(w io.Writer, path string, enc EventLogEncoding) error {
+	encode, err := newEventEncoder(w, enc)
+	if err != nil {
+		return err
+	}
+	r, err := OpenEventLog(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := encode(e); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package progress
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLogBinary_RoundTrip(t *testing.T) {
+	now := time.Unix(1_000_000, 0).UTC()
+	title, current := "PD", int64(42)
+	status := TaskStatusError
+	events := []Event{
+		{Type: EventTaskAdd, At: now, GroupID: 1, TaskID: 2, Title: &title},
+		{Type: EventTaskProgress, At: now, TaskID: 2, Current: &current},
+		{Type: EventTaskState, At: now, TaskID: 2, Status: &status, Hints: []string{"retry"}},
+		{Type: EventCheckpoint, At: now, Checkpoint: &Checkpoint{
+			StartedAt: now,
+			Tasks:     []CheckpointTask{{ID: 2, GroupID: 1, Title: "PD", Kind: TaskKindGeneric, Status: TaskStatusRunning, StartAt: now}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	encode, err := newEventEncoder(&buf, EventLogBinary)
+	require.NoError(t, err)
+	for _, e := range events {
+		require.NoError(t, encode(e))
+	}
+	// JSON lines may follow binary records in the same log.
+	jsonEncode, err := newEventEncoder(&buf, EventLogJSON)
+	require.NoError(t, err)
+	require.NoError(t, jsonEncode(Event{Type: EventPrintLines, At: now, Lines: []string{"json"}}))
+
+	data := buf.Bytes()
+	var decoded []Event
+	for len(data) > 0 {
+		record, n, err := splitEventRecord(data)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		e, err := decodeEventRecord(record)
+		require.NoError(t, err)
+		decoded = append(decoded, e)
+		data = data[n:]
+	}
+	require.Len(t, decoded, len(events)+1)
+	for i, e := range events {
+		require.True(t, e.At.Equal(decoded[i].At))
+		decoded[i].At = e.At
+		if cp := decoded[i].Checkpoint; cp != nil {
+			require.True(t, now.Equal(cp.StartedAt))
+			require.True(t, now.Equal(cp.Tasks[0].StartAt))
+			require.True(t, cp.Tasks[0].EndAt.IsZero())
+			continue
+		}
+		require.Equal(t, e, decoded[i])
+	}
+	require.Equal(t, []string{"json"}, decoded[len(events)].Lines)
+
+	// Incomplete records are not split.
+	record, _, err := splitEventRecord(buf.Bytes()[:3])
+	require.NoError(t, err)
+	require.Nil(t, record)
+}
+
+func TestEventLogBinary_UIAndConvert(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.bin.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	sink := NewGzipEventLog(f, time.Hour)
+
+	var recorded bytes.Buffer
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{
+		Mode:             ModePlain,
+		Out:              &recorded,
+		EventLog:         sink,
+		EventLogEncoding: EventLogBinary,
+		Now:              func() time.Time { return now },
+	})
+	task := ui.Group("Start").Task("PD")
+	task.Start()
+	now = now.Add(time.Second)
+	task.Error("exit 1")
+	ui.PrintLines([]string{"bye"})
+	require.NoError(t, ui.Close())
+	require.NoError(t, sink.Close())
+	require.NoError(t, f.Close())
+
+	var replayed bytes.Buffer
+	require.NoError(t, Replay(t.Context(), path, ReplayOptions{Options: Options{Mode: ModePlain, Out: &replayed}}))
+	require.Equal(t, recorded.String(), replayed.String())
+
+	var converted bytes.Buffer
+	require.NoError(t, ConvertEventLog(&converted, path, EventLogJSON))
+	require.Contains(t, converted.String(), `{"type":"task_state"`)
+	require.Contains(t, converted.String(), `"lines":["bye"]`)
+
+	require.Error(t, ConvertEventLog(&converted, path, "xml"))
+}
+
+func TestEventLogEncoding_UnknownIsRejected(t *testing.T) {
+	opts := Options{Mode: ModePlain, Out: &bytes.Buffer{}, EventLog: &bytes.Buffer{}, EventLogEncoding: "xml"}
+	require.EqualError(t, opts.Validate(), `unknown event log encoding "xml"`)
+	_, err := newEventLogSink(opts.EventLog, opts)
+	require.Error(t, err)
+
+	// New falls back to JSON, and says so.
+	var out, eventLog bytes.Buffer
+	opts.Out, opts.EventLog = &out, &eventLog
+	ui := New(opts)
+	ui.PrintLines([]string{"hello"})
+	require.NoError(t, ui.Close())
+	require.Contains(t, out.String(), `progress: unknown event log encoding "xml", using "json"`)
+	require.Contains(t, eventLog.String(), `"lines":["hello"]`)
+}
@@ -0,0 +1,108 @@
+package progress
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+	"time"
+)
+
+// defaultGzipFlushInterval is the flush interval of NewGzipEventLog when none
+// is given.
+const defaultGzipFlushInterval = time.Second
+
+// GzipEventLog is a gzip-compressing Options.EventLog sink for long-lived
+// processes, whose event logs are dominated by repetitive progress events.
+//
+// The output is a sequence of gzip members (a valid multi-member gzip file):
+// a member is completed at every flush point, which makes all events written
+// so far readable by OpenEventLog while the file is still growing. Flush
+// points happen at most one flush interval after a write, on Flush (which the
+// UI calls on Sync and Close), and on Close.
+//
+// GzipEventLog is safe for concurrent use.
+type GzipEventLog struct {
+	mu       sync.Mutex
+	w        io.Writer
+	interval time.Duration
+
+	// zw is the gzip member being written, nil between flush points.
+	zw *gzip.Writer
+	// flushTimer completes the current member once the interval elapses.
+	flushTimer *time.Timer
+	err        error
+}
+
+// NewGzipEventLog returns a sink compressing events into w. If flushInterval
+// is <= 0, it defaults to one second.
+func NewGzipEventLog(w io.Writer, flushInterval time.Duration) *GzipEventLog {
+	if flushInterval <= 0 {
+		flushInterval = defaultGzipFlushInterval
+	}
+	return &GzipEventLog{w: w, interval: flushInterval}
+}
+
+// Write compresses p into the current gzip member, starting one if needed.
+func (g *GzipEventLog) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.err != nil {
+		return 0, g.err
+	}
+	if g.zw == nil {
+		g.zw = gzip.NewWriter(g.w)
+		g.flushTimer = time.AfterFunc(g.interval, func() { _ = g.Flush() })
+	}
+	n, err := g.zw.Write(p)
+	if err != nil {
+		g.err = err
+	}
+	return n, err
+}
+
+// Flush completes the current gzip member, if any.
+func (g *GzipEventLog) Flush() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.flushLocked()
+}
+
+func (g *GzipEventLog) flushLocked() error {
+	if g.zw == nil {
+		return g.err
+	}
+	g.flushTimer.Stop()
+	if err := g.zw.Close(); err != nil && g.err == nil {
+		g.err = err
+	}
+	g.zw, g.flushTimer = nil, nil
+	return g.err
+}
+
+// Sync completes the current gzip member and fsyncs the underlying writer, if
+// it has a Sync() error method (e.g. *os.File).
+func (g *GzipEventLog) Sync() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.flushLocked(); err != nil {
+		return err
+	}
+	if s, ok := g.w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close flushes pending events. It does not close the underlying writer.
+func (g *GzipEventLog) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	err := g.flushLocked()
+	if g.err == nil {
+		g.err = io.ErrClosedPipe
+	}
+	return err
+}
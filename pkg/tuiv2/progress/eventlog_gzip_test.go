@@ -0,0 +1,76 @@
+package progress
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipEventLog_ReadableAtFlushPoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// A long interval leaves flush points to Sync and Close.
+	sink := NewGzipEventLog(f, time.Hour)
+	ui := New(Options{Mode: ModePlain, Out: &bytes.Buffer{}, EventLog: sink})
+
+	r, err := OpenEventLog(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	ui.PrintLines([]string{"one"})
+	ui.PrintLines([]string{"two"})
+	ui.Sync()
+	require.Equal(t, []string{"one", "two"}, readAvailable(t, r))
+	flushed := r.Offset()
+	require.Positive(t, flushed)
+
+	// Not flushed yet: the member is incomplete.
+	ui.PrintLines([]string{"three"})
+	require.Eventually(t, func() bool {
+		st, err := f.Stat()
+		return err == nil && st.Size() > flushed
+	}, time.Second, 10*time.Millisecond)
+	require.Empty(t, readAvailable(t, r))
+
+	require.NoError(t, ui.Close())
+	require.Equal(t, []string{"three"}, readAvailable(t, r))
+	require.NoError(t, sink.Close())
+
+	// Readers can resume at a member boundary.
+	r2, err := OpenEventLog(path)
+	require.NoError(t, err)
+	defer r2.Close()
+	require.NoError(t, r2.SetOffset(flushed))
+	require.Equal(t, []string{"three"}, readAvailable(t, r2))
+
+	offset, err := LastCheckpointOffset(path)
+	require.NoError(t, err)
+	require.Zero(t, offset)
+}
+
+func TestGzipEventLog_FlushesAfterInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	sink := NewGzipEventLog(f, 20*time.Millisecond)
+	defer sink.Close()
+	_, err = sink.Write(eventLogLine(t, "idle"))
+	require.NoError(t, err)
+
+	r, err := OpenEventLog(path)
+	require.NoError(t, err)
+	defer r.Close()
+	require.Eventually(t, func() bool {
+		lines := readAvailable(t, r)
+		return len(lines) == 1 && lines[0] == "idle"
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,485 @@
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of HTTPEventSinkOptions.
+const (
+	defaultHTTPSinkBatchSize     = 100
+	defaultHTTPSinkFlushInterval = time.Second
+	defaultHTTPSinkRetries       = 3
+	defaultHTTPSinkTimeout       = 10 * time.Second
+	defaultHTTPSinkSpillMaxBytes = 64 << 20
+	defaultHTTPSinkCloseTimeout  = 10 * time.Second
+	httpSinkRetryBackoff         = 200 * time.Millisecond
+)
+
+// HTTPEventSinkOptions configures NewHTTPEventSink.
+type HTTPEventSinkOptions struct {
+	// URL is the endpoint receiving the events. Each batch is POSTed as
+	// JSON lines (application/x-ndjson), and any 2xx response acknowledges it.
+	URL string
+	// Header is added to every request (e.g. Authorization).
+	Header http.Header
+	// Client sends the requests. If nil, a client with a 10s timeout is used.
+	Client *http.Client
+
+	// BatchSize is the number of events that triggers a delivery before
+	// FlushInterval elapses. If <= 0, it defaults to 100.
+	BatchSize int
+	// FlushInterval is how long events may wait for delivery. If <= 0, it
+	// defaults to one second.
+	FlushInterval time.Duration
+	// Retries is the number of retries of a failed delivery, with exponential
+	// backoff. If < 0, failed deliveries are not retried; if 0, it defaults
+	// to 3.
+	Retries int
+
+	// SpillPath is a local JSON-lines file keeping batches that could not be
+	// delivered. Spilled events are delivered again, in order, before any new
+	// batch. If empty, undelivered batches are dropped.
+	SpillPath string
+	// SpillMaxBytes caps the size of the spill file while the endpoint is
+	// down: the oldest events are dropped to make room for new ones (see
+	// HTTPEventSink.Dropped). If <= 0, it defaults to 64 MiB.
+	SpillMaxBytes int64
+
+	// CloseTimeout bounds how long Close waits for the delivery of the
+	// remaining events: then the delivery in flight is canceled, and what is
+	// left is spilled or dropped. If <= 0, it defaults to 10 seconds.
+	CloseTimeout time.Duration
+}
+
+// HTTPEventSink is an Options.EventLog sink delivering events to an HTTP
+// endpoint in batches, e.g. for a dashboard observing progress on many CI
+// machines.
+//
+// Delivery happens in the background, so a slow or unreachable endpoint never
+// blocks rendering. Call Close after UI.Close to deliver the remaining events.
+//
+// HTTPEventSink is safe for concurrent use.
+type HTTPEventSink struct {
+	opts HTTPEventSinkOptions
+
+	mu sync.Mutex
+	// batch holds complete event lines waiting for delivery, partial the
+	// trailing bytes of an incomplete line.
+	batch   bytes.Buffer
+	lines   int
+	partial []byte
+	// err is the last delivery error, reset by a successful delivery.
+	err error
+	// dropped counts the events that will never be delivered.
+	dropped atomic.Int64
+
+	// spillOffset is the offset in the spill file of the first event to
+	// deliver: the ones before it are delivered or dropped. It is only
+	// accessed by run.
+	spillOffset int64
+
+	// ctx is canceled once Close gives up waiting, stopping the requests and
+	// the retries in flight.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	wakeCh  chan struct{}
+	closeCh chan struct{}
+	doneCh  chan struct{}
+	once    sync.Once
+}
+
+// NewHTTPEventSink validates opts and starts delivering events written to the
+// returned sink.
+func NewHTTPEventSink(opts HTTPEventSinkOptions) (*HTTPEventSink, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event sink URL %q: %w", opts.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid event sink URL %q: scheme must be http or https", opts.URL)
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: defaultHTTPSinkTimeout}
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultHTTPSinkBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultHTTPSinkFlushInterval
+	}
+	if opts.SpillMaxBytes <= 0 {
+		opts.SpillMaxBytes = defaultHTTPSinkSpillMaxBytes
+	}
+	if opts.CloseTimeout <= 0 {
+		opts.CloseTimeout = defaultHTTPSinkCloseTimeout
+	}
+	switch {
+	case opts.Retries < 0:
+		opts.Retries = 0
+	case opts.Retries == 0:
+		opts.Retries = defaultHTTPSinkRetries
+	}
+
+	s := &HTTPEventSink{
+		opts:    opts,
+		wakeCh:  make(chan struct{}, 1),
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.run()
+	return s, nil
+}
+
+// Write queues the complete event lines of p for delivery. It never blocks on
+// the network.
+func (s *HTTPEventSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := append(s.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		s.batch.Write(data[:i+1])
+		s.lines++
+		data = data[i+1:]
+	}
+	s.partial = append([]byte(nil), data...)
+
+	if s.lines >= s.opts.BatchSize {
+		s.wake()
+	}
+	return len(p), nil
+}
+
+// Flush requests the delivery of the queued events without waiting for it.
+func (s *HTTPEventSink) Flush() error {
+	s.wake()
+	return nil
+}
+
+// Close delivers the queued events and stops the sink, waiting at most
+// CloseTimeout. It returns the error of the last delivery, if it failed.
+func (s *HTTPEventSink) Close() error {
+	s.once.Do(func() { close(s.closeCh) })
+	timer := time.NewTimer(s.opts.CloseTimeout)
+	defer timer.Stop()
+	select {
+	case <-s.doneCh:
+	case <-timer.C:
+		s.cancel()
+		<-s.doneCh
+	}
+	s.cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Dropped returns the number of events that could not be delivered and were
+// dropped: batches failing without SpillPath, and the oldest spilled events
+// once the spill file reached SpillMaxBytes.
+func (s *HTTPEventSink) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *HTTPEventSink) wake() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *HTTPEventSink) run() {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closeCh:
+			s.deliver()
+			if err := s.compactSpill(); err != nil {
+				s.mu.Lock()
+				s.err = err
+				s.mu.Unlock()
+			}
+			return
+		case <-s.wakeCh:
+		case <-ticker.C:
+		}
+		s.deliver()
+	}
+}
+
+// deliver sends the spilled events, then the queued ones. Whatever cannot be
+// delivered is spilled, so the endpoint receives events in order.
+func (s *HTTPEventSink) deliver() {
+	s.mu.Lock()
+	body := bytes.Clone(s.batch.Bytes())
+	s.batch.Reset()
+	s.lines = 0
+	s.mu.Unlock()
+
+	spilled, err := s.deliverSpilled()
+	if err == nil && len(body) > 0 {
+		err = s.post(body)
+	}
+	if err != nil && len(body) > 0 {
+		if s.opts.SpillPath == "" {
+			s.dropped.Add(int64(bytes.Count(body, []byte("\n"))))
+		} else if spillErr := s.spill(body); spillErr != nil {
+			err = spillErr
+		}
+	}
+
+	if err != nil || len(body) > 0 || spilled {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+	}
+}
+
+// deliverSpilled sends the spill file in batches from spillOffset, the file
+// is removed once it is all delivered. It reports whether there were spilled
+// events.
+func (s *HTTPEventSink) deliverSpilled() (bool, error) {
+	if s.opts.SpillPath == "" {
+		return false, nil
+	}
+	f, err := os.Open(s.opts.SpillPath)
+	if err != nil {
+		s.spillOffset = 0
+		return false, nil
+	}
+	defer f.Close()
+	if _, err := f.Seek(s.spillOffset, io.SeekStart); err != nil {
+		return true, err
+	}
+
+	r := bufio.NewReader(f)
+	spilled := false
+	for {
+		batch, err := readEventLines(r, s.opts.BatchSize)
+		if len(batch) > 0 {
+			spilled = true
+			if err := s.post(batch); err != nil {
+				return true, err
+			}
+			s.spillOffset += int64(len(batch))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return true, err
+		}
+	}
+	s.spillOffset = 0
+	return spilled, os.Remove(s.opts.SpillPath)
+}
+
+// readEventLines reads at most n lines from r. The error is io.EOF once r is
+// exhausted.
+func readEventLines(r *bufio.Reader, n int) ([]byte, error) {
+	var lines []byte
+	for i := 0; i < n; i++ {
+		line, err := r.ReadBytes('\n')
+		lines = append(lines, line...)
+		if err != nil {
+			return lines, err
+		}
+	}
+	return lines, nil
+}
+
+// spill appends body to the spill file, dropping its oldest events if it
+// would hold more than SpillMaxBytes to deliver.
+func (s *HTTPEventSink) spill(body []byte) error {
+	size := int64(0)
+	if info, err := os.Stat(s.opts.SpillPath); err == nil {
+		size = info.Size()
+	} else {
+		s.spillOffset = 0
+	}
+
+	// Drop whole events, from the oldest, until the rest fits.
+	if over := size - s.spillOffset + int64(len(body)) - s.opts.SpillMaxBytes; over > 0 {
+		if over >= size-s.spillOffset {
+			s.dropped.Add(int64(s.countSpilled(size)))
+			s.spillOffset = size
+			if cut := len(body) - int(s.opts.SpillMaxBytes); cut > 0 {
+				if i := bytes.IndexByte(body[cut:], '\n'); i >= 0 {
+					cut += i + 1
+				} else {
+					cut = len(body)
+				}
+				s.dropped.Add(int64(bytes.Count(body[:cut], []byte("\n"))))
+				body = body[cut:]
+			}
+		} else if err := s.dropSpilled(over); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(s.opts.SpillPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// The delivered and dropped events are only rewritten away once they
+	// take as much room as the cap, not on every spill.
+	if s.spillOffset >= s.opts.SpillMaxBytes {
+		return s.compactSpill()
+	}
+	return nil
+}
+
+// countSpilled counts the events of the spill file between spillOffset and
+// end.
+func (s *HTTPEventSink) countSpilled(end int64) int {
+	f, err := os.Open(s.opts.SpillPath)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	n := 0
+	r := bufio.NewReader(io.NewSectionReader(f, s.spillOffset, end-s.spillOffset))
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			n++
+		}
+		if err != nil {
+			return n
+		}
+	}
+}
+
+// dropSpilled moves spillOffset past the oldest spilled events taking at
+// least n bytes.
+func (s *HTTPEventSink) dropSpilled(n int64) error {
+	f, err := os.Open(s.opts.SpillPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(s.spillOffset, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(f)
+	for skipped := int64(0); skipped < n; {
+		line, err := r.ReadBytes('\n')
+		skipped += int64(len(line))
+		s.spillOffset += int64(len(line))
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			s.dropped.Add(1)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// compactSpill rewrites the spill file without the events before
+// spillOffset, so they are not delivered again by the next sink.
+func (s *HTTPEventSink) compactSpill() error {
+	if s.opts.SpillPath == "" || s.spillOffset == 0 {
+		return nil
+	}
+	src, err := os.Open(s.opts.SpillPath)
+	if err != nil {
+		s.spillOffset = 0
+		return nil
+	}
+	defer src.Close()
+	if _, err := src.Seek(s.spillOffset, io.SeekStart); err != nil {
+		return err
+	}
+	tmp := s.opts.SpillPath + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, s.opts.SpillPath); err != nil {
+		return err
+	}
+	s.spillOffset = 0
+	return nil
+}
+
+// post sends one batch, retrying failures with exponential backoff until
+// Close gives up.
+func (s *HTTPEventSink) post(body []byte) error {
+	var err error
+	for attempt := 0; attempt <= s.opts.Retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(httpSinkRetryBackoff << (attempt - 1))
+			select {
+			case <-timer.C:
+			case <-s.ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("event sink %s: %w (last error: %v)", s.opts.URL, s.ctx.Err(), err)
+			}
+		}
+		if err = s.postOnce(body); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (s *HTTPEventSink) postOnce(body []byte) error {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, vs := range s.opts.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("event sink %s: unexpected status %s", s.opts.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package progress
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type eventCollector struct {
+	mu     sync.Mutex
+	lines  []string
+	header http.Header
+	fail   atomic.Bool
+}
+
+func (c *eventCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if c.fail.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header = r.Header.Clone()
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		e, err := DecodeEvent([]byte(line))
+		if err == nil && len(e.Lines) > 0 {
+			c.lines = append(c.lines, e.Lines...)
+		}
+	}
+}
+
+func (c *eventCollector) received() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.lines...)
+}
+
+func TestHTTPEventSink_DeliversBatches(t *testing.T) {
+	c := &eventCollector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	sink, err := NewHTTPEventSink(HTTPEventSinkOptions{
+		URL:           srv.URL,
+		Header:        http.Header{"Authorization": []string{"Bearer t"}},
+		FlushInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	ui := New(Options{Mode: ModePlain, Out: &bytes.Buffer{}, EventLog: sink})
+	ui.PrintLines([]string{"one"})
+	ui.PrintLines([]string{"two"})
+	// Sync flushes the sink.
+	ui.Sync()
+	require.Eventually(t, func() bool { return len(c.received()) == 2 }, time.Second, 10*time.Millisecond)
+
+	ui.PrintLines([]string{"three"})
+	require.NoError(t, ui.Close())
+	require.NoError(t, sink.Close())
+	require.Equal(t, []string{"one", "two", "three"}, c.received())
+	require.Equal(t, "Bearer t", c.header.Get("Authorization"))
+	require.Equal(t, "application/x-ndjson", c.header.Get("Content-Type"))
+}
+
+func TestHTTPEventSink_SpillsAndRedelivers(t *testing.T) {
+	c := &eventCollector{}
+	c.fail.Store(true)
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	spillPath := filepath.Join(t.TempDir(), "spill.jsonl")
+	sink, err := NewHTTPEventSink(HTTPEventSinkOptions{
+		URL:           srv.URL,
+		FlushInterval: time.Hour,
+		Retries:       -1,
+		SpillPath:     spillPath,
+	})
+	require.NoError(t, err)
+
+	_, err = sink.Write(eventLogLine(t, "a"))
+	require.NoError(t, err)
+	_, err = sink.Write(eventLogLine(t, "b")[:4])
+	require.NoError(t, err)
+	require.NoError(t, sink.Flush())
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(spillPath)
+		return err == nil && bytes.Equal(data, eventLogLine(t, "a"))
+	}, time.Second, 10*time.Millisecond)
+
+	c.fail.Store(false)
+	_, err = sink.Write(eventLogLine(t, "b")[4:])
+	require.NoError(t, err)
+	require.NoError(t, sink.Close())
+	require.Equal(t, []string{"a", "b"}, c.received())
+	require.NoFileExists(t, spillPath)
+}
+
+func TestHTTPEventSink_CapsSpillFile(t *testing.T) {
+	c := &eventCollector{}
+	c.fail.Store(true)
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	line := eventLogLine(t, "a")
+	spillPath := filepath.Join(t.TempDir(), "spill.jsonl")
+	sink, err := NewHTTPEventSink(HTTPEventSinkOptions{
+		URL:           srv.URL,
+		FlushInterval: time.Hour,
+		Retries:       -1,
+		SpillPath:     spillPath,
+		SpillMaxBytes: int64(3*len(line) + 1),
+	})
+	require.NoError(t, err)
+
+	for _, text := range []string{"a", "b", "c", "d", "e"} {
+		_, err = sink.Write(eventLogLine(t, text))
+		require.NoError(t, err)
+		require.NoError(t, sink.Flush())
+		require.Eventually(t, func() bool {
+			data, err := os.ReadFile(spillPath)
+			return err == nil && bytes.HasSuffix(data, eventLogLine(t, text))
+		}, time.Second, 10*time.Millisecond)
+	}
+	require.Equal(t, int64(2), sink.Dropped())
+
+	c.fail.Store(false)
+	require.NoError(t, sink.Close())
+	require.Equal(t, []string{"c", "d", "e"}, c.received())
+}
+
+func TestHTTPEventSink_ReportsFailedDelivery(t *testing.T) {
+	c := &eventCollector{}
+	c.fail.Store(true)
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	sink, err := NewHTTPEventSink(HTTPEventSinkOptions{URL: srv.URL, Retries: -1})
+	require.NoError(t, err)
+	_, err = sink.Write(eventLogLine(t, "lost"))
+	require.NoError(t, err)
+	require.ErrorContains(t, sink.Close(), "503")
+	require.Equal(t, int64(1), sink.Dropped())
+
+	_, err = NewHTTPEventSink(HTTPEventSinkOptions{URL: "ftp://example.com"})
+	require.Error(t, err)
+}
+
+func TestHTTPEventSink_DeliversSpillIncrementally(t *testing.T) {
+	c := &eventCollector{}
+	c.fail.Store(true)
+	var accepted atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Take a single batch once back, then fail again.
+		if !c.fail.Load() && accepted.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		c.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	spillPath := filepath.Join(t.TempDir(), "spill.jsonl")
+	newSink := func() *HTTPEventSink {
+		sink, err := NewHTTPEventSink(HTTPEventSinkOptions{
+			URL:           srv.URL,
+			FlushInterval: time.Hour,
+			BatchSize:     1,
+			Retries:       -1,
+			SpillPath:     spillPath,
+		})
+		require.NoError(t, err)
+		return sink
+	}
+
+	sink := newSink()
+	for _, text := range []string{"a", "b", "c"} {
+		_, err := sink.Write(eventLogLine(t, text))
+		require.NoError(t, err)
+	}
+	require.NoError(t, sink.Flush())
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(spillPath)
+		return err == nil && bytes.Count(data, []byte("\n")) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	// "a" is delivered, "b" fails: the file is kept, read from "b" on.
+	c.fail.Store(false)
+	require.ErrorContains(t, sink.Close(), "503")
+	require.Equal(t, []string{"a"}, c.received())
+	// Close drops the delivered events from the file, so the next sink
+	// doesn't deliver them again.
+	data, err := os.ReadFile(spillPath)
+	require.NoError(t, err)
+	require.Equal(t, append(eventLogLine(t, "b"), eventLogLine(t, "c")...), data)
+
+	accepted.Store(-10)
+	sink = newSink()
+	require.NoError(t, sink.Close())
+	require.Equal(t, []string{"a", "b", "c"}, c.received())
+	require.NoFileExists(t, spillPath)
+}
+
+func TestHTTPEventSink_CloseIsBounded(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	spillPath := filepath.Join(t.TempDir(), "spill.jsonl")
+	sink, err := NewHTTPEventSink(HTTPEventSinkOptions{
+		URL:           srv.URL,
+		FlushInterval: time.Hour,
+		SpillPath:     spillPath,
+		CloseTimeout:  100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	_, err = sink.Write(eventLogLine(t, "late"))
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.Error(t, sink.Close())
+	require.Less(t, time.Since(start), 2*time.Second)
+	// The events are kept for the next sink.
+	data, err := os.ReadFile(spillPath)
+	require.NoError(t, err)
+	require.Equal(t, eventLogLine(t, "late"), data)
+}
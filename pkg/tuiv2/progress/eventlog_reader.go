@@ -0,0 +1,338 @@
+package progress
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// eventLogPollInterval bounds how long EventLogReader.Wait sleeps without a
+// file system notification, so tailing keeps working where notifications are
+// unavailable or unreliable (e.g. network file systems).
+const eventLogPollInterval = 100 * time.Millisecond
+
+// EventLogReader reads the events of an event log file (see Options.EventLog)
+// and can follow it while another process appends to it.
+//
+// Only complete records are decoded: a partially written trailing record is
+// kept until it is complete. If the file is truncated, or replaced by a new
+// file at the same path (log rotation), reading restarts from the beginning
+// of the new content. Lines that fail to decode are skipped.
+//
+// Logs written through GzipEventLog are decompressed transparently, one gzip
+// member (flush point) at a time, and both EventLogJSON and EventLogBinary
+// records are decoded.
+//
+// EventLogReader is not safe for concurrent use.
+type EventLogReader struct {
+	path   string
+	f      *os.File
+	format eventLogFormat
+
+	// offset is the file offset right after the last consumed line. For gzip
+	// logs it only moves at member boundaries, once all lines decoded from
+	// the member are consumed.
+	offset int64
+	// readPos is the file offset of the first byte not decoded into pending.
+	readPos int64
+	pending []byte
+	buf     []byte
+
+	// watcher is nil if file system notifications are unavailable, in which
+	// case Wait polls.
+	watcher *fsnotify.Watcher
+
+	// lastSeq is the Seq of the last event returned by Next, and gaps the
+	// number of discontinuities seen so far.
+	lastSeq uint64
+	gaps    int
+}
+
+type eventLogFormat int
+
+const (
+	// eventLogFormatUnknown is used until the first bytes of the file are
+	// written.
+	eventLogFormatUnknown eventLogFormat = iota
+	eventLogFormatPlain
+	eventLogFormatGzip
+)
+
+// OpenEventLog opens the event log at path for reading from the beginning.
+func OpenEventLog(path string) (*EventLogReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &EventLogReader{
+		path: filepath.Clean(path),
+		f:    f,
+		buf:  make([]byte, 32*1024),
+	}
+	// Watch the directory rather than the file, so a file re-created by log
+	// rotation is noticed too.
+	if w, err := fsnotify.NewWatcher(); err == nil {
+		if err := w.Add(filepath.Dir(r.path)); err == nil {
+			r.watcher = w
+		} else {
+			_ = w.Close()
+		}
+	}
+	return r, nil
+}
+
+// SetOffset moves the reader to offset, which must be the beginning of a line
+// (e.g. a size recorded earlier, or LastCheckpointOffset), or of a gzip member
+// for compressed logs.
+func (r *EventLogReader) SetOffset(offset int64) error {
+	if offset < 0 {
+		return fmt.Errorf("invalid event log offset %d", offset)
+	}
+	r.offset = offset
+	r.readPos = offset
+	r.pending = r.pending[:0]
+	r.lastSeq = 0
+	return nil
+}
+
+// Offset returns the file offset right after the last event returned by Next.
+func (r *EventLogReader) Offset() int64 {
+	return r.offset
+}
+
+// Next returns the next event of the log. It returns io.EOF when no complete
+// line is available yet; more events may be returned after Wait.
+func (r *EventLogReader) Next() (Event, error) {
+	for {
+		record, n, err := splitEventRecord(r.pending)
+		if err != nil {
+			return Event{}, err
+		}
+		if record != nil {
+			r.pending = r.pending[n:]
+			switch {
+			case r.format != eventLogFormatGzip:
+				r.offset += int64(n)
+			case len(r.pending) == 0:
+				r.offset = r.readPos
+			}
+			if len(record) == 0 {
+				continue
+			}
+			if e, err := decodeEventRecord(record); err == nil {
+				r.checkSeq(e.Seq)
+				return e, nil
+			}
+			continue
+		}
+
+		filled, err := r.fill()
+		if err != nil {
+			return Event{}, err
+		}
+		if filled {
+			continue
+		}
+		reopened, err := r.reopenIfReplaced()
+		if err != nil {
+			return Event{}, err
+		}
+		if !reopened {
+			return Event{}, io.EOF
+		}
+	}
+}
+
+// Gaps returns the number of discontinuities in the sequence numbers of the
+// events returned by Next so far, caused e.g. by a truncated log, concurrent
+// writers, or a rotation the reader missed. State replayed from a log with
+// gaps may be incomplete.
+func (r *EventLogReader) Gaps() int {
+	return r.gaps
+}
+
+func (r *EventLogReader) checkSeq(seq uint64) {
+	switch {
+	case seq == 0:
+		// Written without sequence numbers.
+		return
+	case seq == 1:
+		// Another UI started appending to the log.
+	case r.lastSeq != 0 && seq != r.lastSeq+1:
+		r.gaps++
+	}
+	r.lastSeq = seq
+}
+
+// fill decodes more of the file into pending. It reports false if no new
+// complete data is available.
+func (r *EventLogReader) fill() (bool, error) {
+	if r.format == eventLogFormatUnknown {
+		var magic [2]byte
+		if n, _ := r.f.ReadAt(magic[:], 0); n < len(magic) {
+			return false, nil
+		}
+		r.format = eventLogFormatPlain
+		if magic == [2]byte{0x1f, 0x8b} {
+			r.format = eventLogFormatGzip
+		}
+	}
+
+	if r.format == eventLogFormatPlain {
+		n, err := r.f.ReadAt(r.buf, r.readPos)
+		if n > 0 {
+			r.pending = append(r.pending, r.buf[:n]...)
+			r.readPos += int64(n)
+			return true, nil
+		}
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		return false, nil
+	}
+
+	// Decode the next gzip member as a whole. A member that is still being
+	// written fails with an unexpected EOF and is decoded again later.
+	cr := &countingByteReader{r: bufio.NewReader(io.NewSectionReader(r.f, r.readPos, math.MaxInt64-r.readPos))}
+	zr, err := gzip.NewReader(cr)
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		return false, err
+	}
+	zr.Multistream(false)
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		return false, err
+	}
+	r.pending = append(r.pending, data...)
+	r.readPos += cr.n
+	if len(r.pending) == 0 {
+		r.offset = r.readPos
+	}
+	return true, nil
+}
+
+// reopenIfReplaced restarts reading from the beginning if the file at path was
+// truncated or replaced since it was opened. It must only be called once the
+// current file is read to the end.
+func (r *EventLogReader) reopenIfReplaced() (bool, error) {
+	pathInfo, err := os.Stat(r.path)
+	if err != nil {
+		// The file may be in the middle of a rotation: keep the current one
+		// until a new file shows up.
+		return false, nil
+	}
+	curInfo, err := r.f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	if !os.SameFile(curInfo, pathInfo) {
+		f, err := os.Open(r.path)
+		if err != nil {
+			return false, nil
+		}
+		_ = r.f.Close()
+		r.f = f
+	} else if curInfo.Size() >= r.readPos {
+		return false, nil
+	}
+	r.format = eventLogFormatUnknown
+	return true, r.SetOffset(0)
+}
+
+// Wait blocks until the log may have new content or ctx is done, in which
+// case it returns ctx.Err().
+func (r *EventLogReader) Wait(ctx context.Context) error {
+	timer := time.NewTimer(eventLogPollInterval)
+	defer timer.Stop()
+
+	var events chan fsnotify.Event
+	var errs chan error
+	if r.watcher != nil {
+		events, errs = r.watcher.Events, r.watcher.Errors
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case ev := <-events:
+			if filepath.Clean(ev.Name) == r.path {
+				return nil
+			}
+		case <-errs:
+			// Notifications may have been lost: fall back to the next poll.
+		}
+	}
+}
+
+// Follow returns an iterator over the events of the log that keeps waiting
+// for new events until ctx is done. The iteration ends with ctx.Err() when ctx
+// is done, or with the first read error.
+func (r *EventLogReader) Follow(ctx context.Context) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		for {
+			e, err := r.Next()
+			switch {
+			case err == nil:
+				if !yield(e, nil) {
+					return
+				}
+				continue
+			case err != io.EOF:
+				yield(Event{}, err)
+				return
+			}
+			if err := r.Wait(ctx); err != nil {
+				yield(Event{}, err)
+				return
+			}
+		}
+	}
+}
+
+// Close releases the file and the watcher.
+func (r *EventLogReader) Close() error {
+	if r.watcher != nil {
+		_ = r.watcher.Close()
+	}
+	return r.f.Close()
+}
+
+// countingByteReader counts the bytes consumed by a gzip.Reader. It implements
+// io.ByteReader so that the decompressor does not read ahead, which makes the
+// count end exactly at the member boundary.
+type countingByteReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingByteReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingByteReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
@@ -0,0 +1,152 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func eventLogLine(t *testing.T, text string) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(Event{Type: EventPrintLines, Lines: []string{text}})
+	require.NoError(t, err)
+	return append(data, '\n')
+}
+
+func appendFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func readAvailable(t *testing.T, r *EventLogReader) []string {
+	t.Helper()
+
+	var lines []string
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return lines
+		}
+		require.NoError(t, err)
+		lines = append(lines, e.Lines...)
+	}
+}
+
+func TestEventLogReader_PartialLinesAndOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	first := eventLogLine(t, "first")
+	appendFile(t, path, first)
+
+	r, err := OpenEventLog(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	second := eventLogLine(t, "second")
+	appendFile(t, path, append([]byte("not json\n"), second[:5]...))
+	require.Equal(t, []string{"first"}, readAvailable(t, r))
+	require.Equal(t, int64(len(first)+len("not json\n")), r.Offset())
+
+	appendFile(t, path, second[5:])
+	require.Equal(t, []string{"second"}, readAvailable(t, r))
+
+	require.NoError(t, r.SetOffset(int64(len(first))))
+	require.Equal(t, []string{"second"}, readAvailable(t, r))
+}
+
+func TestEventLogReader_TruncateAndRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	appendFile(t, path, append(eventLogLine(t, "a"), eventLogLine(t, "b")...))
+
+	r, err := OpenEventLog(path)
+	require.NoError(t, err)
+	defer r.Close()
+	require.Equal(t, []string{"a", "b"}, readAvailable(t, r))
+
+	require.NoError(t, os.Truncate(path, 0))
+	appendFile(t, path, eventLogLine(t, "c"))
+	require.Equal(t, []string{"c"}, readAvailable(t, r))
+
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendFile(t, path+".1", eventLogLine(t, "late"))
+	appendFile(t, path, eventLogLine(t, "d"))
+	require.Equal(t, []string{"late", "d"}, readAvailable(t, r))
+}
+
+func TestEventLogReader_Follow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	appendFile(t, path, nil)
+
+	r, err := OpenEventLog(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		appendFile(t, path, eventLogLine(t, "one"))
+		appendFile(t, path, eventLogLine(t, "two"))
+	}()
+
+	var lines []string
+	for e, err := range r.Follow(ctx) {
+		require.NoError(t, err)
+		lines = append(lines, e.Lines...)
+		if len(lines) == 2 {
+			break
+		}
+	}
+	require.Equal(t, []string{"one", "two"}, lines)
+
+	cancel()
+	for _, err := range r.Follow(ctx) {
+		require.ErrorIs(t, err, context.Canceled)
+	}
+}
+
+func TestEventLogReader_Gaps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	var buf bytes.Buffer
+	sink, err := newEventLogSink(&buf, Options{})
+	require.NoError(t, err)
+	for _, text := range []string{"a", "b", "c"} {
+		sink.write(time.Now(), Event{Type: EventPrintLines, Lines: []string{text}})
+	}
+	lines := bytes.SplitAfter(buf.Bytes(), []byte("\n"))
+	require.Contains(t, string(lines[2]), `"seq":3`)
+
+	// A new writer appending to the log starts over from 1.
+	appendFile(t, path, buf.Bytes())
+	appendFile(t, path, buf.Bytes())
+
+	r, err := OpenEventLog(path)
+	require.NoError(t, err)
+	defer r.Close()
+	require.Equal(t, []string{"a", "b", "c", "a", "b", "c"}, readAvailable(t, r))
+	require.Equal(t, 0, r.Gaps())
+
+	// "b" is lost.
+	appendFile(t, path, slices.Concat(lines[0], lines[2]))
+	require.Equal(t, []string{"a", "c"}, readAvailable(t, r))
+	require.Equal(t, 1, r.Gaps())
+
+	// Nothing is known about the events before an offset.
+	require.NoError(t, r.SetOffset(int64(len(lines[0]))))
+	require.Equal(t, []string{"b", "c", "a", "b", "c", "a", "c"}, readAvailable(t, r))
+	require.Equal(t, 2, r.Gaps())
+}
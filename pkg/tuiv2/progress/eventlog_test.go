@@ -2,6 +2,8 @@ package progress
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,7 +12,8 @@ import (
 
 func TestEventLogSink_WritesAllEvents(t *testing.T) {
 	var buf bytes.Buffer
-	sink := newEventLogSink(&buf)
+	sink, err := newEventLogSink(&buf, Options{})
+	require.NoError(t, err)
 	require.NotNil(t, sink)
 
 	t0 := time.Unix(1_000_000, 0)
@@ -37,3 +40,124 @@ func TestEventLogSink_WritesAllEvents(t *testing.T) {
 	require.NotNil(t, e2.Current)
 	require.Equal(t, int64(2), *e2.Current)
 }
+
+func TestEventLogSink_DownsamplesProgress(t *testing.T) {
+	var buf bytes.Buffer
+	sink, err := newEventLogSink(&buf, Options{EventLogProgressInterval: 500 * time.Millisecond})
+	require.NoError(t, err)
+
+	t0 := time.Unix(1_000_000, 0)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+	i64 := func(v int64) *int64 { return &v }
+	done := TaskStatusDone
+
+	sink.write(at(0), Event{Type: EventTaskProgress, TaskID: 1, Current: i64(1)})
+	sink.write(at(100), Event{Type: EventTaskProgress, TaskID: 1, Total: i64(100)})
+	sink.write(at(200), Event{Type: EventTaskProgress, TaskID: 1, Current: i64(20)})
+	sink.write(at(300), Event{Type: EventTaskProgress, TaskID: 2, Current: i64(5)})
+	sink.write(at(600), Event{Type: EventTaskProgress, TaskID: 1, Current: i64(60)})
+	sink.write(at(700), Event{Type: EventTaskProgress, TaskID: 1, Current: i64(70)})
+	sink.write(at(800), Event{Type: EventTaskState, TaskID: 1, Status: &done})
+
+	var got []string
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		e, err := DecodeEvent(line)
+		require.NoError(t, err)
+		s := string(e.Type)
+		if e.Type == EventTaskProgress {
+			s += fmt.Sprintf(" %d %d", e.TaskID, *e.Current)
+			if e.Total != nil {
+				s += fmt.Sprintf("/%d", *e.Total)
+			}
+		}
+		got = append(got, s)
+	}
+	require.Equal(t, []string{
+		"task_progress 1 1",
+		"task_progress 2 5",
+		// Skipped updates are coalesced, so Total is not lost.
+		"task_progress 1 60/100",
+		"task_progress 1 70",
+		"task_state",
+	}, got)
+}
+
+// syncRecorder records the number of events written at each fsync.
+type syncRecorder struct {
+	bytes.Buffer
+	syncedAt []int
+}
+
+func (r *syncRecorder) Sync() error {
+	r.syncedAt = append(r.syncedAt, bytes.Count(r.Bytes(), []byte("\n")))
+	return nil
+}
+
+func TestEventLogSink_WritesHeldProgressAfterInterval(t *testing.T) {
+	var out syncBuffer
+	sink, err := newEventLogSink(&out, Options{EventLogProgressInterval: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	t0 := time.Unix(1_000_000, 0)
+	i64 := func(v int64) *int64 { return &v }
+	sink.write(t0, Event{Type: EventTaskProgress, TaskID: 1, Current: i64(1)})
+	sink.write(t0.Add(10*time.Millisecond), Event{Type: EventTaskProgress, TaskID: 1, Current: i64(2)})
+	require.Equal(t, 1, strings.Count(out.String(), "\n"))
+
+	// Nothing else happens (e.g. the download stalls): the held progress is
+	// still written once the interval elapses.
+	require.Eventually(t, func() bool { return strings.Count(out.String(), "\n") == 2 }, 2*time.Second, 10*time.Millisecond)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	e, err := DecodeEvent([]byte(lines[1]))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), *e.Current)
+}
+
+func TestEventLogSink_SyncPolicy(t *testing.T) {
+	current := int64(1)
+	done := TaskStatusDone
+	events := []Event{
+		{Type: EventTaskAdd, TaskID: 1},
+		{Type: EventTaskProgress, TaskID: 1, Current: &current},
+		{Type: EventTaskState, TaskID: 1, Status: &done},
+		{Type: EventPrintLines, Lines: []string{"bye"}},
+		{Type: EventTaskAdd, TaskID: 2},
+	}
+	for _, tc := range []struct {
+		name string
+		opts Options
+		want []int
+	}{
+		{name: "never", opts: Options{}},
+		{name: "state change", opts: Options{EventLogSync: EventLogSyncOnStateChange}, want: []int{3, 4, 5}},
+		{name: "every 2", opts: Options{EventLogSync: EventLogSyncEveryN, EventLogSyncEvery: 2}, want: []int{2, 4, 5}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var w syncRecorder
+			sink, err := newEventLogSink(&w, tc.opts)
+			require.NoError(t, err)
+			for i, e := range events {
+				sink.write(time.Unix(int64(i), 0), e)
+			}
+			// Sync and Close sync the remaining events.
+			sink.flush()
+			require.Equal(t, tc.want, w.syncedAt)
+		})
+	}
+}
+
+func TestEventLog_PersistsSyncBarriers(t *testing.T) {
+	var buf bytes.Buffer
+	ui := New(Options{Mode: ModePlain, Out: &bytes.Buffer{}, EventLog: &buf})
+	ui.PrintLines([]string{"ready"})
+	ui.Sync()
+
+	var types []EventType
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		e, err := DecodeEvent(line)
+		require.NoError(t, err)
+		types = append(types, e.Type)
+	}
+	require.Equal(t, []EventType{EventPrintLines, EventSync}, types)
+	require.NoError(t, ui.Close())
+}
@@ -0,0 +1,113 @@
+package progress
+
+import "time"
+
+// EventFilter drops or rewrites an event before it is rendered or written to
+// the event log, e.g. to redact absolute paths or tokens from logs meant to be
+// shared. It returns the event to use and false to drop it.
+//
+// Filters run on the render loop, one event at a time. Dropping events that
+// create groups or tasks makes the later events about them no-ops.
+type EventFilter func(e Event) (Event, bool)
+
+// filterEvent applies Options.Filter to e. Sync barriers are internal and are
+// never filtered.
+func (ui *UI) filterEvent(e Event) (Event, bool) {
+	if ui.filter == nil || e.Type == EventSync {
+		return e, true
+	}
+	return ui.filter(e)
+}
+
+// EventObserver is told about every event the UI applies, once Filter kept
+// it, with the time the UI applies it at, e.g. to mirror the progress in
+// another view. Sync barriers are internal and are never observed.
+//
+// Observers run on the render loop, one event at a time: they must not block
+// or call back into the UI.
+type EventObserver func(at time.Time, e Event)
+
+// observeEvent tells Options.Observer about e.
+func (ui *UI) observeEvent(at time.Time, e Event) {
+	if ui.observer == nil || e.Type == EventSync {
+		return
+	}
+	ui.observer(at, e)
+}
+
+// ChainFilters returns a filter applying filters in order, stopping at the
+// first one dropping the event.
+func ChainFilters(filters ...EventFilter) EventFilter {
+	return func(e Event) (Event, bool) {
+		for _, f := range filters {
+			if f == nil {
+				continue
+			}
+			var ok bool
+			if e, ok = f(e); !ok {
+				return e, false
+			}
+		}
+		return e, true
+	}
+}
+
+// RedactFilter returns a filter rewriting every user-visible text of events
+// (titles, output lines, messages, meta, hints and summary titles) with
+// redact.
+func RedactFilter(redact func(string) string) EventFilter {
+	return func(e Event) (Event, bool) {
+		return e.mapText(redact), true
+	}
+}
+
+// mapText returns e with f applied to its user-visible texts. Slices and
+// pointers are copied, so the original event is left untouched.
+func (e Event) mapText(f func(string) string) Event {
+	mapPtr := func(s *string) *string {
+		if s == nil {
+			return nil
+		}
+		v := f(*s)
+		return &v
+	}
+	mapSlice := func(ss []string) []string {
+		if ss == nil {
+			return nil
+		}
+		out := make([]string, len(ss))
+		for i, s := range ss {
+			out[i] = f(s)
+		}
+		return out
+	}
+
+	e.Title = mapPtr(e.Title)
+	e.Meta = mapPtr(e.Meta)
+	e.Message = mapPtr(e.Message)
+	e.Lines = mapSlice(e.Lines)
+	e.Hints = mapSlice(e.Hints)
+	if e.Summary != nil {
+		summary := *e.Summary
+		summary.Groups = append([]GroupSummary(nil), summary.Groups...)
+		for i := range summary.Groups {
+			summary.Groups[i].Title = f(summary.Groups[i].Title)
+		}
+		e.Summary = &summary
+	}
+	if e.Checkpoint != nil {
+		cp := *e.Checkpoint
+		cp.Groups = append([]CheckpointGroup(nil), cp.Groups...)
+		for i := range cp.Groups {
+			cp.Groups[i].Title = f(cp.Groups[i].Title)
+		}
+		cp.Tasks = append([]CheckpointTask(nil), cp.Tasks...)
+		for i := range cp.Tasks {
+			t := &cp.Tasks[i]
+			t.Title, t.Meta, t.Message = f(t.Title), f(t.Meta), f(t.Message)
+			t.Hints = mapSlice(t.Hints)
+		}
+		e.Checkpoint = &cp
+	}
+	return e
+}
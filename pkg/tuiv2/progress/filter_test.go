@@ -0,0 +1,90 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUI_FilterRedactsAndDrops(t *testing.T) {
+	var out, eventLog bytes.Buffer
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{
+		Mode:     ModePlain,
+		Out:      &out,
+		EventLog: &eventLog,
+		Now:      func() time.Time { return now },
+		Filter: ChainFilters(
+			func(e Event) (Event, bool) {
+				return e, !(e.Type == EventPrintLines && strings.Contains(strings.Join(e.Lines, "\n"), "token="))
+			},
+			RedactFilter(func(s string) string { return strings.ReplaceAll(s, "/home/alice", "~") }),
+		),
+	})
+
+	ui.PrintLines([]string{"token=abc"})
+	ui.PrintLines([]string{"data dir: /home/alice/.tiup"})
+	task := ui.Group("Deploy /home/alice/.tiup").Task("PD")
+	task.Start()
+	task.ErrorWithHint("open /home/alice/.tiup/pd.toml: denied", "check /home/alice")
+	require.NoError(t, ui.Close())
+
+	for _, s := range []string{out.String(), eventLog.String()} {
+		require.NotContains(t, s, "token=")
+		require.NotContains(t, s, "/home/alice")
+	}
+	require.Contains(t, out.String(), "data dir: ~/.tiup\n")
+	require.Contains(t, out.String(), "Deploy ~/.tiup | ERR - PD: open ~/.tiup/pd.toml: denied")
+	require.Contains(t, out.String(), "hint: check ~")
+}
+
+func TestUI_ObserverSeesFilteredEvents(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	var observed []string
+	ui := New(Options{
+		Mode: ModePlain,
+		Out:  &bytes.Buffer{},
+		Now:  func() time.Time { return now },
+		Filter: func(e Event) (Event, bool) {
+			return e, !(e.Type == EventPrintLines && e.Lines[0] == "dropped")
+		},
+		Observer: func(at time.Time, e Event) {
+			require.Equal(t, now, at)
+			require.NotEqual(t, EventSync, e.Type)
+			if e.Type == EventPrintLines {
+				observed = append(observed, e.Lines...)
+			}
+		},
+	})
+
+	ui.PrintLines([]string{"dropped"})
+	ui.PrintLines([]string{"kept"})
+	ui.Sync()
+	require.NoError(t, ui.Close())
+
+	require.Equal(t, []string{"kept"}, observed)
+}
+
+func TestEventMapText_CopiesPayloads(t *testing.T) {
+	title := "a"
+	e := Event{
+		Title:      &title,
+		Lines:      []string{"a"},
+		Summary:    &Summary{Groups: []GroupSummary{{Title: "a"}}},
+		Checkpoint: &Checkpoint{Tasks: []CheckpointTask{{Title: "a", Hints: []string{"a"}}}},
+	}
+	upper := e.mapText(strings.ToUpper)
+
+	require.Equal(t, "A", *upper.Title)
+	require.Equal(t, []string{"A"}, upper.Lines)
+	require.Equal(t, "A", upper.Summary.Groups[0].Title)
+	require.Equal(t, []string{"A"}, upper.Checkpoint.Tasks[0].Hints)
+
+	require.Equal(t, "a", *e.Title)
+	require.Equal(t, []string{"a"}, e.Lines)
+	require.Equal(t, "a", e.Summary.Groups[0].Title)
+	require.Equal(t, []string{"a"}, e.Checkpoint.Tasks[0].Hints)
+}
@@ -27,6 +27,19 @@ func formatDuration(d time.Duration) string {
 	return d.Round(time.Second).String()
 }
 
+// formatETA estimates the remaining time to transfer the remaining bytes at the
+// given speed. It returns "" when the estimate is not meaningful.
+func formatETA(remaining int64, bps float64) string {
+	if remaining <= 0 || bps <= 0 {
+		return ""
+	}
+	d := time.Duration(float64(remaining) / bps * float64(time.Second))
+	if d < time.Second {
+		d = time.Second
+	}
+	return "~" + d.Round(time.Second).String() + " left"
+}
+
 func formatBytes(n int64) string {
 	if n < 0 {
 		n = 0
@@ -0,0 +1,78 @@
+package progress
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Frame is a TTY render produced by RenderFrame, without ANSI sequences.
+type Frame struct {
+	// History holds the lines printed above the Active area: output blocks and
+	// snapshots of sealed groups.
+	History []string
+	// Active holds the lines of the Active area.
+	Active []string
+}
+
+// FrameOptions configures RenderFrame.
+type FrameOptions struct {
+	// Now is the time the Active area is rendered at. If zero, it defaults to
+	// the time of the last event.
+	Now time.Time
+	// Width and Height are the terminal size. If <= 0, they default to 80x24.
+	Width  int
+	Height int
+	// Theme defaults to DefaultTheme().
+	Theme *Theme
+}
+
+// RenderFrame applies events to a fresh TTY renderer and returns what it
+// printed and its Active area, deterministically and without a terminal.
+//
+// It backs golden tests of progress flows (see package progresstest).
+func RenderFrame(events []Event, opts FrameOptions) Frame {
+	now := opts.Now
+	if now.IsZero() && len(events) > 0 {
+		now = events[len(events)-1].At
+	}
+	theme := DefaultTheme()
+	if opts.Theme != nil {
+		theme = *opts.Theme
+	}
+	ui := &UI{
+		out:    io.Discard,
+		now:    func() time.Time { return now },
+		theme:  theme,
+		width:  opts.Width,
+		height: opts.Height,
+	}
+
+	var f Frame
+	m := newTTYModel(ui)
+	for _, e := range events {
+		if e.Type == EventSync {
+			continue
+		}
+		ackCh := make(chan ttyEventAck, 1)
+		next, _ := m.Update(ttyEventMsg{Event: e, Ack: ackCh})
+		m = next.(ttyModel)
+		for _, p := range (<-ackCh).Prints {
+			f.History = append(f.History, frameLines(p)...)
+		}
+	}
+	f.Active = frameLines(m.render())
+	return f
+}
+
+// frameLines strips control sequences from rendered output and splits it into
+// lines, dropping trailing empty ones.
+func frameLines(s string) []string {
+	lines := strings.Split(ansi.Strip(strings.ReplaceAll(s, "\r", "")), "\n")
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -74,6 +74,56 @@ func (g *Group) SetSortTasksByTitle(sort bool) {
 	})
 }
 
+// SetShowDownloadTotals configures whether the group header should include
+// aggregate bytes downloaded / total and an ETA across its download tasks (TTY
+// mode only).
+func (g *Group) SetShowDownloadTotals(show bool) {
+	if g == nil || g.ui == nil || g.ui.closed.Load() {
+		return
+	}
+	v := show
+	g.ui.emit(Event{
+		Type:               EventGroupUpdate,
+		At:                 g.ui.now(),
+		GroupID:            g.id,
+		ShowDownloadTotals: &v,
+	})
+}
+
+// SetShowProgressCount configures whether the group header should include the
+// number of finished tasks out of all tasks (e.g. "3/7"), plus a percentage
+// while the group is still running. The percentage is weighted by
+// Task.SetWeight.
+//
+// In ModePlain, the count and percentage are printed each time a task of the
+// group finishes.
+func (g *Group) SetShowProgressCount(show bool) {
+	if g == nil || g.ui == nil || g.ui.closed.Load() {
+		return
+	}
+	v := show
+	g.ui.emit(Event{
+		Type:              EventGroupUpdate,
+		At:                g.ui.now(),
+		GroupID:           g.id,
+		ShowProgressCount: &v,
+	})
+}
+
+// Group creates a sub-group under this group (e.g. "Start instances" >
+// "TiKV").
+//
+// Sub-groups are rendered nested inside their parent: indented below the
+// parent's tasks in ModeTTY, and with chained prefixes
+// ("Start instances | TiKV | ...") in ModePlain. Closing or sealing the parent
+// also closes or seals its sub-groups.
+func (g *Group) Group(title string) *Group {
+	if g == nil || g.ui == nil || g.ui.closed.Load() {
+		return &Group{title: title}
+	}
+	return g.ui.newGroup(g.id, title)
+}
+
 // Task creates a new running task under this group.
 func (g *Group) Task(title string) *Task {
 	return g.newTask(title, false)
@@ -88,18 +138,7 @@ func (g *Group) newTask(title string, pending bool) *Task {
 	if g == nil || g.ui == nil || g.ui.closed.Load() {
 		return &Task{title: title}
 	}
-	tid := g.ui.nextID.Add(1)
-	t := &Task{ui: g.ui, id: tid, groupID: g.id, title: title}
-	tt := title
-	g.ui.emit(Event{
-		Type:    EventTaskAdd,
-		At:      g.ui.now(),
-		GroupID: g.id,
-		TaskID:  tid,
-		Title:   &tt,
-		Pending: pending,
-	})
-	return t
+	return g.ui.newTask(g.id, 0, title, pending)
 }
 
 // Close marks the group as closed.
@@ -109,6 +148,7 @@ func (g *Group) Close() {
 	if g == nil || g.ui == nil || g.ui.closed.Load() {
 		return
 	}
+	g.ui.untrackGroup(g.id)
 	g.ui.emit(Event{
 		Type:    EventGroupClose,
 		At:      g.ui.now(),
@@ -124,6 +164,7 @@ func (g *Group) Seal() {
 	if g == nil || g.ui == nil || g.ui.closed.Load() {
 		return
 	}
+	g.ui.untrackGroup(g.id)
 	finished := false
 	g.ui.emit(Event{
 		Type:     EventGroupClose,
@@ -0,0 +1,162 @@
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JSONRecordType is the stable string representation of a ModeJSON record kind.
+type JSONRecordType string
+
+// JSON record types.
+const (
+	// JSONRecordOutput carries lines printed via UI.PrintLines or UI.Writer.
+	JSONRecordOutput JSONRecordType = "output"
+	// JSONRecordGroup reports a group being started or finished.
+	JSONRecordGroup JSONRecordType = "group"
+	// JSONRecordTask reports a task status transition.
+	JSONRecordTask JSONRecordType = "task"
+	// JSONRecordSummary carries the final summary (see Options.Summary).
+	JSONRecordSummary JSONRecordType = "summary"
+)
+
+// JSONRecord is one line written to Options.Out in ModeJSON.
+//
+// Unlike the raw event log (Options.EventLog), records carry resolved state:
+// current titles, statuses after the transition, and durations of finished
+// tasks/groups. Only status transitions are reported, progress updates are not.
+type JSONRecord struct {
+	Type JSONRecordType `json:"type"`
+	At   time.Time      `json:"at"`
+
+	// Group is the group title, prefixed with its parent titles for sub-groups
+	// (e.g. "Start instances > TiKV"). It is set for group and task records.
+	Group string `json:"group,omitempty"`
+	// Task is the task title, prefixed with its parent titles for sub-tasks
+	// (e.g. "TiKV-0 > Health check").
+	Task string `json:"task,omitempty"`
+
+	// Status is the task status, or for groups "running", "done" or "error".
+	Status TaskStatus `json:"status,omitempty"`
+	Meta   string     `json:"meta,omitempty"`
+	// Message is the task message (error/skip reason, retry hint).
+	Message string `json:"message,omitempty"`
+	// Hints are remediation suggestions of a failed task.
+	Hints []string `json:"hints,omitempty"`
+
+	// DurationMs is set once a task or group finished.
+	DurationMs int64 `json:"duration_ms,omitempty"`
+
+	// Current and Total are byte counters of download and transfer tasks.
+	Current int64 `json:"current,omitempty"`
+	Total   int64 `json:"total,omitempty"`
+	// Direction is set for transfer tasks.
+	Direction TransferDirection `json:"direction,omitempty"`
+
+	// Lines is the output block of an output record.
+	Lines []string `json:"lines,omitempty"`
+	// Severity is set for output blocks printed via UI.WarnLines/ErrorLines.
+	Severity PrintSeverity `json:"severity,omitempty"`
+
+	// Summary is the payload of a summary record.
+	Summary *Summary `json:"summary,omitempty"`
+}
+
+type jsonRenderer struct {
+	enc *json.Encoder
+}
+
+func newJSONRenderer(out io.Writer) *jsonRenderer {
+	if out == nil {
+		out = io.Discard
+	}
+	return &jsonRenderer{enc: json.NewEncoder(out)}
+}
+
+func (r *jsonRenderer) renderEvent(now time.Time, e Event, st *engineState) {
+	if r == nil || st == nil {
+		return
+	}
+
+	switch e.Type {
+	case EventPrintLines:
+		if len(e.Lines) == 0 {
+			return
+		}
+		r.write(JSONRecord{Type: JSONRecordOutput, At: now, Lines: e.Lines, Severity: e.Severity})
+	case EventSummary:
+		r.write(JSONRecord{Type: JSONRecordSummary, At: now, Summary: e.Summary})
+	case EventGroupAdd:
+		if g := st.groupByID[e.GroupID]; g != nil {
+			r.write(JSONRecord{Type: JSONRecordGroup, At: now, Group: g.qualifiedTitle(), Status: TaskStatusRunning})
+		}
+	case EventGroupClose:
+		if g := st.groupByID[e.GroupID]; g != nil {
+			r.maybeWriteGroupClosed(now, g)
+		}
+	case EventTaskAdd, EventTaskState:
+		if t := st.taskByID[e.TaskID]; t != nil {
+			r.maybeWriteTask(now, t)
+		}
+	default:
+	}
+}
+
+// maybeWriteGroupClosed reports g and its sub-groups (closed along with it)
+// once they are closed, sub-groups first.
+func (r *jsonRenderer) maybeWriteGroupClosed(now time.Time, g *groupState) {
+	for _, c := range g.children {
+		r.maybeWriteGroupClosed(now, c)
+	}
+	if !g.closed || g.jsonClosedReported {
+		// Seal snapshots and repeated closes are not state transitions.
+		return
+	}
+	g.jsonClosedReported = true
+	status := TaskStatusDone
+	if g.hasFailed() {
+		status = TaskStatusError
+	}
+	r.write(JSONRecord{
+		Type:       JSONRecordGroup,
+		At:         now,
+		Group:      g.qualifiedTitle(),
+		Status:     status,
+		DurationMs: g.elapsed(now).Milliseconds(),
+	})
+}
+
+func (r *jsonRenderer) maybeWriteTask(now time.Time, t *taskState) {
+	if t.jsonReported && t.jsonStatus == t.status {
+		return
+	}
+	t.jsonReported = true
+	t.jsonStatus = t.status
+
+	rec := JSONRecord{
+		Type:    JSONRecordTask,
+		At:      now,
+		Task:    t.qualifiedTitle(),
+		Status:  t.status.exported(),
+		Meta:    t.meta,
+		Message: t.message,
+		Hints:   t.hints,
+	}
+	if t.g != nil {
+		rec.Group = t.g.qualifiedTitle()
+	}
+	if !t.endAt.IsZero() && !t.startAt.IsZero() {
+		rec.DurationMs = t.endAt.Sub(t.startAt).Milliseconds()
+	}
+	if t.movesBytes() {
+		rec.Current = t.current
+		rec.Total = t.total
+		rec.Direction = t.direction
+	}
+	r.write(rec)
+}
+
+func (r *jsonRenderer) write(rec JSONRecord) {
+	_ = r.enc.Encode(rec)
+}
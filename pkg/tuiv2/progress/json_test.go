@@ -0,0 +1,57 @@
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONOutput_ReportsStateTransitions(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{
+		Mode: ModeJSON,
+		Out:  &out,
+		Now: func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		},
+	})
+	require.Equal(t, ModeJSON, ui.Mode())
+
+	g := ui.Group("Start instances")
+	t1 := g.Task("PD")
+	t1.Start()
+	t1.SetMeta("v8.5.0")
+	t1.Done()
+	t2 := g.Task("TiKV")
+	t2.Error("boom")
+	ui.PrintLines([]string{"hello"})
+	g.Close()
+	g.Close()
+	require.NoError(t, ui.Close())
+
+	var records []JSONRecord
+	sc := bufio.NewScanner(&out)
+	for sc.Scan() {
+		var rec JSONRecord
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &rec))
+		rec.At = time.Time{}
+		records = append(records, rec)
+	}
+	require.NoError(t, sc.Err())
+
+	require.Equal(t, []JSONRecord{
+		{Type: JSONRecordGroup, Group: "Start instances", Status: TaskStatusRunning},
+		{Type: JSONRecordTask, Group: "Start instances", Task: "PD", Status: TaskStatusRunning},
+		{Type: JSONRecordTask, Group: "Start instances", Task: "PD", Status: TaskStatusDone, Meta: "v8.5.0", DurationMs: 3000},
+		{Type: JSONRecordTask, Group: "Start instances", Task: "TiKV", Status: TaskStatusRunning},
+		{Type: JSONRecordTask, Group: "Start instances", Task: "TiKV", Status: TaskStatusError, Message: "boom", DurationMs: 1000},
+		{Type: JSONRecordOutput, Lines: []string{"hello"}},
+		{Type: JSONRecordGroup, Group: "Start instances", Status: TaskStatusError, DurationMs: 8000},
+	}, records)
+}
@@ -0,0 +1,89 @@
+package progress
+
+import (
+	"sync"
+
+	legacyprogress "github.com/pingcap/tiup/pkg/tui/progress"
+)
+
+// LegacyBar implements the pkg/tui/progress Bar interface on top of a Task, so
+// call sites written against SingleBar/MultiBarItem (e.g. cluster steps) can
+// report into the tuiv2 engine without being rewritten.
+//
+// DisplayProps are mapped as follows:
+//   - ModeSpinner/ModeProgress start the task and show Suffix as its message.
+//   - ModeDone/ModeError finish the task, with Detail as the message.
+//   - A changed Prefix renames the task.
+type LegacyBar struct {
+	task *Task
+
+	mu     sync.Mutex
+	prefix string
+}
+
+var _ legacyprogress.Bar = (*LegacyBar)(nil)
+
+// LegacyBar creates a pending task under this group, driven through the legacy
+// Bar interface.
+func (g *Group) LegacyBar(prefix string) *LegacyBar {
+	return &LegacyBar{task: g.TaskPending(prefix), prefix: prefix}
+}
+
+// UpdateDisplay implements legacyprogress.Bar. It is safe to call from any
+// goroutine.
+func (b *LegacyBar) UpdateDisplay(dp *legacyprogress.DisplayProps) {
+	if b == nil || dp == nil {
+		return
+	}
+
+	b.mu.Lock()
+	if dp.Prefix != "" && dp.Prefix != b.prefix {
+		b.prefix = dp.Prefix
+		b.task.SetTitle(dp.Prefix)
+	}
+	b.mu.Unlock()
+
+	switch dp.Mode {
+	case legacyprogress.ModeDone:
+		if dp.Detail != "" {
+			b.task.SetMessage(dp.Detail)
+		}
+		b.task.Done()
+	case legacyprogress.ModeError:
+		b.task.Error(dp.Detail)
+	default:
+		b.task.SetMessage(dp.Suffix)
+		b.task.Start()
+	}
+}
+
+// LegacyMultiBar implements the pkg/tui/progress MultiBar API on top of a
+// Group: each bar added is a task of the group.
+type LegacyMultiBar struct {
+	group *Group
+}
+
+// LegacyMultiBar creates a group driven through the legacy MultiBar API.
+func (ui *UI) LegacyMultiBar(prefix string) *LegacyMultiBar {
+	return &LegacyMultiBar{group: ui.Group(prefix)}
+}
+
+// LegacyMultiBar creates a sub-group driven through the legacy MultiBar API,
+// e.g. for a parallel step inside a larger stage.
+func (g *Group) LegacyMultiBar(prefix string) *LegacyMultiBar {
+	return &LegacyMultiBar{group: g.Group(prefix)}
+}
+
+// AddBar adds a new bar item. Unlike MultiBar.AddBar, it is safe to call at
+// any time.
+func (b *LegacyMultiBar) AddBar(prefix string) *LegacyBar {
+	return b.group.LegacyBar(prefix)
+}
+
+// StartRenderLoop is a no-op: the UI renders continuously.
+func (b *LegacyMultiBar) StartRenderLoop() {}
+
+// StopRenderLoop closes the group.
+func (b *LegacyMultiBar) StopRenderLoop() {
+	b.group.Close()
+}
@@ -0,0 +1,33 @@
+package progress
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	legacyprogress "github.com/pingcap/tiup/pkg/tui/progress"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLegacyMultiBar_Plain(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{Mode: ModePlain, Out: &out, PlainDone: true, Now: func() time.Time { return now }})
+
+	mb := ui.LegacyMultiBar("Copy files")
+	var bar legacyprogress.Bar = mb.AddBar("host-1")
+	failed := mb.AddBar("host-2")
+	mb.StartRenderLoop()
+
+	bar.UpdateDisplay(&legacyprogress.DisplayProps{Prefix: "host-1", Suffix: "mkdir"})
+	failed.UpdateDisplay(&legacyprogress.DisplayProps{Prefix: "host-2", Mode: legacyprogress.ModeError, Detail: "permission denied"})
+	bar.UpdateDisplay(&legacyprogress.DisplayProps{Prefix: "host-1 (renamed)", Mode: legacyprogress.ModeDone})
+	mb.StopRenderLoop()
+	require.NoError(t, ui.Close())
+
+	require.Equal(t,
+		"Copy files | host-1 mkdir\n"+
+			"Copy files | ERR - host-2: permission denied (0.0s)\n"+
+			"Copy files | DONE - host-1 (renamed): mkdir (0.0s)\n",
+		out.String())
+}
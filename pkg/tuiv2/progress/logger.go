@@ -0,0 +1,76 @@
+package progress
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Logger is a minimal structured logger writing through the UI, so log output
+// of components (e.g. bridged from zap or logrus) never corrupts the TTY
+// Active area.
+//
+// Messages are formatted as "msg key=value ...", with values quoted when they
+// contain spaces, quotes or "=". Warn and Error use UI.WarnLines/ErrorLines.
+//
+// Logger is immutable and safe to use from any goroutine.
+type Logger struct {
+	ui     *UI
+	fields []any
+}
+
+// WithFields returns a Logger attaching fields to every message. Fields are
+// alternating keys and values, like log/slog.
+func (ui *UI) WithFields(fields ...any) *Logger {
+	return &Logger{ui: ui, fields: fields}
+}
+
+// WithFields returns a Logger with fields appended to the ones of l.
+func (l *Logger) WithFields(fields ...any) *Logger {
+	merged := make([]any, 0, len(l.fields)+len(fields))
+	merged = append(merged, l.fields...)
+	merged = append(merged, fields...)
+	return &Logger{ui: l.ui, fields: merged}
+}
+
+// Info prints msg with the logger fields and the given ones.
+func (l *Logger) Info(msg string, fields ...any) {
+	l.ui.PrintLines(l.lines(msg, fields))
+}
+
+// Warn prints msg labeled WARN.
+func (l *Logger) Warn(msg string, fields ...any) {
+	l.ui.WarnLines(l.lines(msg, fields))
+}
+
+// Error prints msg labeled ERR.
+func (l *Logger) Error(msg string, fields ...any) {
+	l.ui.ErrorLines(l.lines(msg, fields))
+}
+
+// lines formats one log entry. Fields go on the first line, so multi-line
+// messages keep their continuation lines intact.
+func (l *Logger) lines(msg string, fields []any) []string {
+	lines := strings.Split(strings.TrimRight(msg, "\n"), "\n")
+
+	var b strings.Builder
+	b.WriteString(lines[0])
+	all := append(append([]any(nil), l.fields...), fields...)
+	for i := 0; i < len(all); i += 2 {
+		key, value := "!BADKEY", all[i]
+		if i+1 < len(all) {
+			key, value = fmt.Sprint(all[i]), all[i+1]
+		}
+		b.WriteString(" " + key + "=" + formatFieldValue(value))
+	}
+	lines[0] = b.String()
+	return lines
+}
+
+func formatFieldValue(v any) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
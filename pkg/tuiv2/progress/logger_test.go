@@ -0,0 +1,26 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Plain(t *testing.T) {
+	var out bytes.Buffer
+	ui := New(Options{Mode: ModePlain, Out: &out})
+
+	log := ui.WithFields("component", "tikv").WithFields("port", 20160)
+	log.Info("instance started")
+	log.Warn("slow start", "elapsed", "12.5 s")
+	log.Error("exited", "err", errors.New("signal: killed"), "dangling")
+	require.NoError(t, ui.Close())
+
+	require.Equal(t,
+		"instance started component=tikv port=20160\n"+
+			"WARN - slow start component=tikv port=20160 elapsed=\"12.5 s\"\n"+
+			"ERR - exited component=tikv port=20160 err=\"signal: killed\" !BADKEY=dangling\n",
+		out.String())
+}
@@ -2,14 +2,22 @@ package progress
 
 import (
 	"fmt"
+	"os"
+	"strings"
 )
 
+// EnvAccessible makes ModeAuto resolve to ModeAccessible when set to "1",
+// "true", "on" or "yes".
+const EnvAccessible = "TIUP_ACCESSIBLE"
+
 // Mode decides how the progress UI renders.
 //
 // - ModeAuto: choose ModeTTY when output is a TTY, otherwise ModePlain.
 // - ModeTTY: dynamic multi-line progress display (ANSI).
 // - ModePlain: stable event logs, no ANSI overwrite.
 // - ModeOff: no progress output.
+// - ModeJSON: machine-readable JSON lines of group/task state transitions.
+// - ModeAccessible: discrete, uncolored state change lines, even on a TTY.
 type Mode int
 
 const (
@@ -21,6 +29,14 @@ const (
 	ModePlain
 	// ModeOff disables progress output.
 	ModeOff
+	// ModeJSON writes group/task state transitions as JSON lines (see
+	// JSONRecord), intended for CI systems that parse progress.
+	ModeJSON
+	// ModeAccessible writes every task state change (including successes) as a
+	// discrete line, without spinners, colors or in-place rewriting, even on a
+	// TTY. It is intended for screen readers and terminals where cursor control
+	// is unreliable.
+	ModeAccessible
 )
 
 func (m Mode) String() string {
@@ -33,7 +49,34 @@ func (m Mode) String() string {
 		return "plain"
 	case ModeOff:
 		return "off"
+	case ModeJSON:
+		return "json"
+	case ModeAccessible:
+		return "accessible"
 	default:
 		return fmt.Sprintf("Mode(%d)", int(m))
 	}
 }
+
+// HistoryMode decides what a sealed group flushes into the TTY History area
+// (and thus the terminal scrollback).
+type HistoryMode int
+
+const (
+	// HistoryFull prints the group header and all its tasks.
+	HistoryFull HistoryMode = iota
+	// HistoryHeaderOnly prints the group header only.
+	HistoryHeaderOnly
+	// HistoryFailedOnly prints the group header and its failed tasks, so long
+	// runs with many successful tasks don't flood the scrollback.
+	HistoryFailedOnly
+)
+
+func accessibleFromEnv() bool {
+	switch strings.ToLower(os.Getenv(EnvAccessible)) {
+	case "1", "true", "on", "yes":
+		return true
+	default:
+		return false
+	}
+}
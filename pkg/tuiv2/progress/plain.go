@@ -12,19 +12,19 @@ import (
 type plainRenderer struct {
 	out     io.Writer
 	outMode tuiterm.OutputMode
+	// doneLines enables DONE lines for successful tasks (Options.PlainDone).
+	doneLines bool
 }
 
-func newPlainRenderer(out io.Writer, outMode tuiterm.OutputMode) *plainRenderer {
+func newPlainRenderer(out io.Writer, outMode tuiterm.OutputMode, doneLines bool) *plainRenderer {
 	if out == nil {
 		out = io.Discard
 	}
-	return &plainRenderer{out: out, outMode: outMode}
+	return &plainRenderer{out: out, outMode: outMode, doneLines: doneLines}
 }
 
 func (r *plainRenderer) plainSprintf(format string, args ...any) string {
-	tokens := colorstr.DefaultTokens
-	tokens.Disable = !r.outMode.Color
-	return tokens.Sprintf(format, args...)
+	return colorstr.TokensFor(r.outMode).Sprintf(format, args...)
 }
 
 func (r *plainRenderer) groupPrefix(title string) string {
@@ -34,15 +34,29 @@ func (r *plainRenderer) groupPrefix(title string) string {
 	return r.plainSprintf("[light_magenta][bold]%s[reset]", title)
 }
 
+// groupChainPrefix chains the prefixes of g and its parent groups
+// (e.g. "Start instances | TiKV").
+func (r *plainRenderer) groupChainPrefix(g *groupState) string {
+	if g == nil {
+		return ""
+	}
+	prefix := r.groupPrefix(g.title)
+	parent := r.groupChainPrefix(g.parent)
+	switch {
+	case parent == "":
+		return prefix
+	case prefix == "":
+		return parent
+	default:
+		return parent + " | " + prefix
+	}
+}
+
 func (r *plainRenderer) printlnWithGroup(g *groupState, details string) {
 	if r == nil || r.out == nil {
 		return
 	}
-	title := ""
-	if g != nil {
-		title = g.title
-	}
-	prefix := r.groupPrefix(title)
+	prefix := r.groupChainPrefix(g)
 	if prefix == "" {
 		_, _ = fmt.Fprintln(r.out, details)
 		return
@@ -65,7 +79,18 @@ func (r *plainRenderer) renderEvent(now time.Time, e Event, st *engineState) {
 
 	switch e.Type {
 	case EventPrintLines:
-		for _, line := range e.Lines {
+		lines := e.Lines
+		switch e.Severity {
+		case PrintSeverityWarn:
+			lines = labelLines(r.warnLabel(), len("WARN"), lines)
+		case PrintSeverityError:
+			lines = labelLines(r.errLabel(), len("ERR"), lines)
+		}
+		for _, line := range lines {
+			_, _ = fmt.Fprintln(r.out, line)
+		}
+	case EventSummary:
+		for _, line := range e.Summary.lines() {
 			_, _ = fmt.Fprintln(r.out, line)
 		}
 	case EventTaskUpdate:
@@ -87,8 +112,8 @@ func (r *plainRenderer) renderEvent(now time.Time, e Event, st *engineState) {
 		}
 
 		if t.status == taskStatusRunning {
-			switch t.kind {
-			case taskKindDownload:
+			switch {
+			case t.movesBytes():
 				r.maybePrintDownloadStart(now, t)
 			default:
 				r.maybePrintGenericStart(now, t)
@@ -99,18 +124,27 @@ func (r *plainRenderer) renderEvent(now time.Time, e Event, st *engineState) {
 			r.printRetry(now, t)
 			return
 		}
-		if t.status == taskStatusError {
-			r.printError(now, t)
+		if t.plainEndPrinted {
 			return
 		}
-		if t.status == taskStatusSkipped {
+		switch t.status {
+		case taskStatusDone:
+			if r.doneLines {
+				r.printDone(now, t)
+			}
+		case taskStatusError:
+			r.printError(now, t)
+		case taskStatusSkipped:
 			r.printSkipped(now, t)
-			return
-		}
-		if t.status == taskStatusCanceled {
+		case taskStatusCanceled:
 			r.printCanceled(now, t)
+		default:
 			return
 		}
+		t.plainEndPrinted = true
+		if t.parent == nil {
+			r.printGroupProgress(t.g)
+		}
 	default:
 	}
 }
@@ -124,7 +158,7 @@ func (r *plainRenderer) maybePrintGenericStart(now time.Time, t *taskState) {
 		t.startAt = now
 	}
 
-	title := r.plainSprintf("[green]%s[reset]", t.title)
+	title := r.plainSprintf("[green]%s[reset]", t.qualifiedTitle())
 	details := ""
 	switch {
 	case t.meta != "" && t.message != "":
@@ -140,7 +174,7 @@ func (r *plainRenderer) maybePrintGenericStart(now time.Time, t *taskState) {
 }
 
 func (r *plainRenderer) maybePrintDownloadStart(now time.Time, t *taskState) {
-	if r == nil || t == nil || t.downloadStartPrinted || t.kind != taskKindDownload {
+	if r == nil || t == nil || t.downloadStartPrinted || !t.movesBytes() {
 		return
 	}
 	if t.status != taskStatusRunning {
@@ -151,11 +185,12 @@ func (r *plainRenderer) maybePrintDownloadStart(now time.Time, t *taskState) {
 		t.startAt = now
 	}
 
-	title := r.plainSprintf("[green]%s[reset]", t.title)
+	title := r.plainSprintf("[green]%s[reset]", t.qualifiedTitle())
 	size := "?"
 	if t.total > 0 {
 		size = formatBytes(t.total)
 	}
+	size = t.sizeLabel(size)
 	details := ""
 	switch {
 	case t.meta != "":
@@ -166,13 +201,25 @@ func (r *plainRenderer) maybePrintDownloadStart(now time.Time, t *taskState) {
 	r.printlnWithGroup(t.g, details)
 }
 
+// printGroupProgress prints the weighted progress of g and its parents that
+// enabled Group.SetShowProgressCount, once one of their tasks finished.
+func (r *plainRenderer) printGroupProgress(g *groupState) {
+	for ; g != nil; g = g.parent {
+		if !g.showProgressCount {
+			continue
+		}
+		finished, total := g.finishedCount()
+		r.printlnWithGroup(g, r.plainSprintf("[dim]%d/%d (%d%%)[reset]", finished, total, int(g.progressRatio()*100)))
+	}
+}
+
 func (r *plainRenderer) printRetry(_ time.Time, t *taskState) {
 	if r == nil || t == nil {
 		return
 	}
 	label := r.warnLabel()
 
-	title := t.title
+	title := t.qualifiedTitle()
 	if t.meta != "" {
 		title += " " + t.meta
 	}
@@ -190,15 +237,35 @@ func (r *plainRenderer) printError(_ time.Time, t *taskState) {
 
 	errLabel := r.errLabel()
 	elapsed := t.endAt.Sub(t.startAt)
-	title := t.title
+	title := t.qualifiedTitle()
 	if t.meta != "" {
 		title += " " + t.meta
 	}
 	if t.message != "" {
 		r.printlnWithGroup(t.g, fmt.Sprintf("%s - %s: %s (%s)", errLabel, title, t.message, formatDuration(elapsed)))
+	} else {
+		r.printlnWithGroup(t.g, fmt.Sprintf("%s - %s (%s)", errLabel, title, formatDuration(elapsed)))
+	}
+	for _, hint := range t.hints {
+		r.printlnWithGroup(t.g, "  hint: "+hint)
+	}
+}
+
+func (r *plainRenderer) printDone(_ time.Time, t *taskState) {
+	if r == nil || t == nil {
+		return
+	}
+
+	elapsed := t.endAt.Sub(t.startAt)
+	title := t.qualifiedTitle()
+	if t.meta != "" {
+		title += " " + t.meta
+	}
+	if t.message != "" {
+		r.printlnWithGroup(t.g, fmt.Sprintf("DONE - %s: %s (%s)", title, t.message, formatDuration(elapsed)))
 		return
 	}
-	r.printlnWithGroup(t.g, fmt.Sprintf("%s - %s (%s)", errLabel, title, formatDuration(elapsed)))
+	r.printlnWithGroup(t.g, fmt.Sprintf("DONE - %s (%s)", title, formatDuration(elapsed)))
 }
 
 func (r *plainRenderer) printSkipped(_ time.Time, t *taskState) {
@@ -207,7 +274,7 @@ func (r *plainRenderer) printSkipped(_ time.Time, t *taskState) {
 	}
 
 	elapsed := t.endAt.Sub(t.startAt)
-	title := t.title
+	title := t.qualifiedTitle()
 	if t.meta != "" {
 		title += " " + t.meta
 	}
@@ -224,7 +291,7 @@ func (r *plainRenderer) printCanceled(_ time.Time, t *taskState) {
 	}
 
 	elapsed := t.endAt.Sub(t.startAt)
-	title := t.title
+	title := t.qualifiedTitle()
 	if t.meta != "" {
 		title += " " + t.meta
 	}
@@ -1,8 +1,14 @@
 package progress
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -192,3 +198,311 @@ func TestPendingTask_Cancel_PrintsInPlain(t *testing.T) {
 
 	require.Contains(t, got, "Start instances | CANCEL - TiDB (0.0s)\n")
 }
+
+func TestPlainOutput_SubTasksArePrefixedWithParent(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = r.Close() })
+	t.Cleanup(func() { _ = w.Close() })
+
+	ui := New(Options{Mode: ModePlain, Out: w})
+
+	g := ui.Group("Start instances")
+	parent := g.Task("TiKV-0")
+	parent.Start()
+	step := parent.Child("Health check")
+	step.Start()
+	step.Error("timeout")
+	parent.Done()
+	g.Close()
+
+	require.NoError(t, ui.Close())
+	_ = w.Close()
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	got := string(out)
+
+	for _, want := range []string{
+		"Start instances | TiKV-0\n",
+		"Start instances | TiKV-0 > Health check\n",
+		"Start instances | ERR - TiKV-0 > Health check: timeout (",
+	} {
+		require.Contains(t, got, want)
+	}
+}
+
+func TestPlainOutput_SeverityLines(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = r.Close() })
+	t.Cleanup(func() { _ = w.Close() })
+
+	ui := New(Options{Mode: ModePlain, Out: w})
+	ui.WarnLines([]string{"port 4000 is in use", "falling back to 4001"})
+	ui.ErrorLines([]string{"failed to start TiDB"})
+	ui.PrintLines([]string{"done"})
+
+	require.NoError(t, ui.Close())
+	_ = w.Close()
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	require.Equal(t, "WARN - port 4000 is in use\n"+
+		"       falling back to 4001\n"+
+		"ERR - failed to start TiDB\n"+
+		"done\n", string(out))
+}
+
+func TestPlainOutput_SummaryOnClose(t *testing.T) {
+	var out, eventLog bytes.Buffer
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{
+		Mode:     ModePlain,
+		Out:      &out,
+		EventLog: &eventLog,
+		Summary:  true,
+		Now:      func() time.Time { return now },
+	})
+
+	g := ui.Group("Start instances")
+	pd := g.Task("PD")
+	pd.Start()
+	tidb := g.Task("TiDB")
+	tidb.Start()
+	now = now.Add(2 * time.Second)
+	pd.Done()
+	tidb.Error("exit 1")
+	g.Task("TiFlash").Skip("disabled")
+	g.Close()
+	ui.Group("Empty")
+	now = now.Add(time.Second)
+
+	require.NoError(t, ui.Close())
+	require.True(t, strings.HasSuffix(out.String(), "Summary:\n"+
+		"  Start instances  1 succeeded, 1 failed, 1 skipped (2.0s)\n"+
+		"Total: 3.0s\n"), out.String())
+
+	logLines := strings.Split(strings.TrimSpace(eventLog.String()), "\n")
+	e, err := DecodeEvent([]byte(logLines[len(logLines)-1]))
+	require.NoError(t, err)
+	require.Equal(t, EventSummary, e.Type)
+	require.Equal(t, &Summary{
+		ElapsedMs: 3000,
+		Groups: []GroupSummary{
+			{Title: "Start instances", DurationMs: 2000, Succeeded: 1, Failed: 1, Skipped: 1},
+		},
+	}, e.Summary)
+}
+
+// syncBuffer is a bytes.Buffer safe to read while the UI is writing.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestTaskWatchCtx(t *testing.T) {
+	var out syncBuffer
+	ui := New(Options{Mode: ModePlain, Out: &out})
+
+	g := ui.Group("Start instances")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := g.Task("PD")
+	done.WatchCtx(ctx)
+	done.Done()
+
+	canceled := g.Task("TiDB")
+	canceled.WatchCtx(ctx)
+	canceled.Cancel("")
+
+	watched := g.Task("TiKV")
+	watched.WatchCtx(ctx)
+
+	stopped := g.Task("TiFlash")
+	stop := stopped.WatchCtx(ctx)
+	require.True(t, stop())
+
+	cancel()
+	// The watchers fire asynchronously after cancel.
+	require.Eventually(t, func() bool {
+		ui.Sync()
+		return strings.Contains(out.String(), "Start instances | CANCEL - TiKV: context canceled (")
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, ui.Close())
+
+	got := out.String()
+	require.Equal(t, 1, strings.Count(got, "CANCEL - TiDB"))
+	require.NotContains(t, got, "CANCEL - PD")
+	require.NotContains(t, got, "CANCEL - TiFlash")
+}
+
+func TestTaskRun_RetriesWithBackoff(t *testing.T) {
+	var out bytes.Buffer
+	ui := New(Options{Mode: ModePlain, Out: &out})
+	g := ui.Group("Start instances")
+
+	calls := 0
+	err := g.Task("TiDB").Run(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("port %d not ready", 4000+calls)
+		}
+		return nil
+	}, RetryOptions{Attempts: 3, Backoff: time.Millisecond})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	calls = 0
+	errPermanent := errors.New("bad config")
+	err = g.Task("PD").Run(context.Background(), func(context.Context) error {
+		calls++
+		return errPermanent
+	}, RetryOptions{
+		Attempts:  5,
+		Retryable: func(err error) bool { return !errors.Is(err, errPermanent) },
+	})
+	require.ErrorIs(t, err, errPermanent)
+	require.Equal(t, 1, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err = g.Task("TiKV").Run(ctx, func(context.Context) error {
+		cancel()
+		return errors.New("dial failed")
+	}, RetryOptions{Attempts: 3, Backoff: time.Hour})
+	require.Error(t, err)
+
+	require.NoError(t, ui.Close())
+	got := out.String()
+	for _, want := range []string{
+		"Start instances | WARN - TiDB: retrying 2/3: port 4001 not ready\n",
+		"Start instances | WARN - TiDB: retrying 3/3: port 4002 not ready\n",
+		"Start instances | ERR - PD: bad config (",
+		"Start instances | CANCEL - TiKV: dial failed (",
+	} {
+		require.Contains(t, got, want)
+	}
+	require.NotContains(t, got, "ERR - TiDB")
+}
+
+func TestPrompt_Plain(t *testing.T) {
+	var out bytes.Buffer
+	ui := New(Options{Mode: ModePlain, Out: &out, In: strings.NewReader("Yes\nsome answer\r\n")})
+
+	ok, err := ui.Confirm("Destroy cluster data?")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ans, err := ui.Prompt("Name:")
+	require.NoError(t, err)
+	require.Equal(t, "some answer", ans)
+
+	_, err = ui.Prompt("More:")
+	require.ErrorIs(t, err, io.EOF)
+
+	require.NoError(t, ui.Close())
+	require.Equal(t, "Destroy cluster data? [y/N] Name: More: ", out.String())
+
+	_, err = ui.Prompt("Closed:")
+	require.Error(t, err)
+}
+
+func TestPlainOutput_DoneLines(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var out bytes.Buffer
+		now := time.Unix(1_000_000, 0)
+		ui := New(Options{Mode: ModePlain, Out: &out, PlainDone: enabled, Now: func() time.Time { return now }})
+
+		g := ui.Group("Start instances")
+		pd := g.Task("PD")
+		pd.SetMeta("v8.5.0")
+		pd.Start()
+		now = now.Add(1500 * time.Millisecond)
+		pd.Done()
+		pd.Done()
+		require.NoError(t, ui.Close())
+
+		if !enabled {
+			require.NotContains(t, out.String(), "DONE")
+			continue
+		}
+		require.Equal(t, 1, strings.Count(out.String(), "DONE"))
+		require.Contains(t, out.String(), "Start instances | DONE - PD v8.5.0 (1.5s)\n")
+	}
+}
+
+func TestPlainOutput_ErrorHints(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{Mode: ModePlain, Out: &out, Now: func() time.Time { return now }})
+
+	g := ui.Group("Start instances")
+	pd := g.Task("PD")
+	pd.Start()
+	pd.ErrorWithHint("port 2379 already in use", "use --port-offset to shift all ports", "or stop the process listening on 2379")
+	require.NoError(t, ui.Close())
+
+	require.Contains(t, out.String(),
+		"Start instances | ERR - PD: port 2379 already in use (0.0s)\n"+
+			"Start instances |   hint: use --port-offset to shift all ports\n"+
+			"Start instances |   hint: or stop the process listening on 2379\n")
+}
+
+func TestPlainOutput_SubGroupsChainPrefixes(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{Mode: ModePlain, Out: &out, PlainDone: true, Now: func() time.Time { return now }})
+
+	g := ui.Group("Start instances")
+	tikv := g.Group("TiKV")
+	t0 := tikv.Task("tikv-0")
+	t0.Start()
+	t0.Done()
+	g.Close()
+	require.NoError(t, ui.Close())
+
+	require.Contains(t, out.String(), "Start instances | TiKV | tikv-0\n")
+	require.Contains(t, out.String(), "Start instances | TiKV | DONE - tikv-0 (0.0s)\n")
+}
+
+func TestPlainOutput_WeightedGroupProgress(t *testing.T) {
+	var out bytes.Buffer
+	ui := New(Options{Mode: ModePlain, Out: &out})
+
+	g := ui.Group("Download")
+	g.SetShowProgressCount(true)
+	small := g.Task("tidb")
+	big := g.Task("tiflash")
+	big.SetWeight(3)
+	small.Done()
+	big.Done()
+	require.NoError(t, ui.Close())
+
+	require.Equal(t, "Download | 1/2 (25%)\nDownload | 2/2 (100%)\n", out.String())
+}
+
+func TestTransferTask_Plain(t *testing.T) {
+	var out bytes.Buffer
+	ui := New(Options{Mode: ModePlain, Out: &out})
+
+	g := ui.Group("Prepare data")
+	load := g.Task("Load sample data")
+	load.SetTotal(2 * 1024 * 1024)
+	load.SetKindTransfer(TransferUpload)
+	load.SetCurrent(1024 * 1024)
+	load.Done()
+	require.NoError(t, ui.Close())
+
+	require.Equal(t, "Prepare data | Load sample data (upload 2.0MiB)\n", out.String())
+}
@@ -0,0 +1,107 @@
+// Package progresstest provides helpers to snapshot-test progress flows built
+// on package progress, with a fixed clock and without a terminal.
+package progresstest
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/tuiv2/progress"
+)
+
+// Recorder records the events of a progress UI driven by the code under test.
+//
+// The UI's clock only moves through Advance, so renders are deterministic.
+type Recorder struct {
+	// UI is the UI to pass to the code under test.
+	UI *progress.UI
+
+	mu  sync.Mutex
+	now time.Time
+	log bytes.Buffer
+}
+
+// NewRecorder creates a Recorder whose clock starts at start.
+func NewRecorder(start time.Time) *Recorder {
+	r := &Recorder{now: start}
+	r.UI = progress.New(progress.Options{
+		Mode:     progress.ModePlain,
+		Out:      io.Discard,
+		EventLog: &lockedWriter{mu: &r.mu, w: &r.log},
+		Now:      r.Now,
+	})
+	return r
+}
+
+// Now returns the current time of the recorder clock.
+func (r *Recorder) Now() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.now
+}
+
+// Advance moves the recorder clock forward by d.
+func (r *Recorder) Advance(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now = r.now.Add(d)
+}
+
+// Events returns the events recorded so far, after waiting for the UI to
+// process everything emitted before the call.
+func (r *Recorder) Events() []progress.Event {
+	r.UI.Sync()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []progress.Event
+	sc := bufio.NewScanner(bytes.NewReader(r.log.Bytes()))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		if e, err := progress.DecodeEvent(sc.Bytes()); err == nil {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// TTY renders the recorded events like the TTY renderer would at the current
+// recorder time, on a terminal of the given size.
+func (r *Recorder) TTY(width, height int) progress.Frame {
+	return progress.RenderFrame(r.Events(), progress.FrameOptions{
+		Now:    r.Now(),
+		Width:  width,
+		Height: height,
+	})
+}
+
+// Plain renders the recorded events like ModePlain would, without colors.
+func (r *Recorder) Plain() string {
+	events := r.Events()
+	var out bytes.Buffer
+	now := r.Now()
+	ui := progress.New(progress.Options{
+		Mode: progress.ModePlain,
+		Out:  &out,
+		Now:  func() time.Time { return now },
+	})
+	for _, e := range events {
+		ui.ReplayEvent(e)
+	}
+	_ = ui.Close()
+	return out.String()
+}
+
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  *bytes.Buffer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
@@ -0,0 +1,40 @@
+package progresstest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	g := rec.UI.Group("Start instances")
+	pd := g.Task("PD")
+	tikv := g.Task("TiKV")
+	rec.Advance(1500 * time.Millisecond)
+	pd.Done()
+	rec.Advance(time.Second)
+
+	frame := rec.TTY(60, 10)
+	require.Empty(t, frame.History)
+	require.Equal(t, []string{
+		"• Start instances  3s",
+		"  ┃  ✔︎ PD",
+		"  ┃  ⠋ TiKV",
+	}, frame.Active)
+
+	tikv.Error("exited")
+	g.Close()
+	frame = rec.TTY(60, 10)
+	require.Empty(t, frame.Active)
+	require.Equal(t, []string{
+		"✘ Start instances  3s",
+		"  ┃  ✔︎ PD",
+		"  ┃  ✘ TiKV exited",
+	}, frame.History)
+
+	require.Equal(t, "Start instances | ERR - TiKV: exited (2.5s)\n", rec.Plain())
+	require.NoError(t, rec.UI.Close())
+}
@@ -0,0 +1,98 @@
+package progress
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Suspend hands the terminal over to the caller, e.g. to run a child process
+// that writes to the TTY directly, until Resume is called.
+//
+// Pending output is flushed first. In TTY mode the Active area is cleared and
+// stops repainting, and History output (including UI.Writer lines) is held
+// back until Resume. Calls may be nested; the display is restored by the
+// Resume matching the outermost Suspend.
+func (ui *UI) Suspend() {
+	if ui == nil || ui.closed.Load() {
+		return
+	}
+	ui.suspendMu.Lock()
+	defer ui.suspendMu.Unlock()
+
+	ui.suspendDepth++
+	if ui.suspendDepth > 1 {
+		return
+	}
+	ui.Sync()
+	if ui.mode == ModeTTY {
+		ui.suspendTTY()
+	}
+}
+
+// Resume restores the progress display after Suspend.
+func (ui *UI) Resume() {
+	if ui == nil {
+		return
+	}
+	ui.suspendMu.Lock()
+	defer ui.suspendMu.Unlock()
+
+	if ui.suspendDepth == 0 {
+		return
+	}
+	ui.suspendDepth--
+	if ui.suspendDepth == 0 && ui.mode == ModeTTY {
+		ui.resumeTTY()
+	}
+}
+
+// Prompt prints question and reads one line of input from Options.In. The
+// returned answer has its line ending trimmed.
+//
+// In TTY mode the Active area is cleared and History output is held back
+// while waiting for input, so repaints don't overwrite the question. In
+// ModeJSON the question is written as an output record.
+func (ui *UI) Prompt(question string) (string, error) {
+	if ui == nil || ui.closed.Load() {
+		return "", errors.New("progress UI is closed")
+	}
+	ui.promptMu.Lock()
+	defer ui.promptMu.Unlock()
+
+	ui.Suspend()
+	defer ui.Resume()
+	switch ui.mode {
+	case ModeJSON:
+		// Keep Out a valid JSON-lines stream.
+		ui.PrintLines([]string{question})
+		ui.Sync()
+		question = ""
+	default:
+	}
+	if question != "" {
+		_, _ = fmt.Fprint(ui.out, question+" ")
+	}
+
+	line, err := ui.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Confirm asks a yes/no question via Prompt. It returns true only if the
+// answer is "y" or "yes" (case-insensitive).
+func (ui *UI) Confirm(question string) (bool, error) {
+	ans, err := ui.Prompt(question + " [y/N]")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(ans)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,108 @@
+package progress
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// Options configures the UI rendering the log. Its Now is replaced by the
+	// clock of the log, so elapsed times match the recording.
+	Options Options
+
+	// Speed scales the delays between events: 1 replays in real time, 2 twice
+	// as fast. If <= 0, events are replayed instantly, which together with
+	// ModePlain produces the final transcript.
+	Speed float64
+	// MaxDelay caps the delay between two events, so idle periods of long logs
+	// do not stall the playback. If <= 0, delays are not capped.
+	MaxDelay time.Duration
+}
+
+// Replay renders the event log at path (see Options.EventLog) after the fact,
+// e.g. to debug UI issues from a log submitted by a user. It returns once the
+// whole log is rendered and the UI is closed, or when ctx is done. A warning is
+// printed where the log turns out to miss events (see EventLogReader.Gaps).
+func Replay(ctx context.Context, path string, opts ReplayOptions) error {
+	r, err := OpenEventLog(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	clock := &replayClock{speed: opts.Speed}
+	uiOpts := opts.Options
+	uiOpts.Now = clock.now
+	ui := New(uiOpts)
+	defer ui.Close()
+
+	var prevAt time.Time
+	warned := false
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !warned && r.Gaps() > 0 {
+			warned = true
+			ui.WarnLines([]string{"The event log has missing events: the replayed state may be incomplete."})
+		}
+
+		if opts.Speed > 0 && !prevAt.IsZero() && e.At.After(prevAt) {
+			delay := time.Duration(float64(e.At.Sub(prevAt)) / opts.Speed)
+			if opts.MaxDelay > 0 && delay > opts.MaxDelay {
+				delay = opts.MaxDelay
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if !e.At.IsZero() {
+			prevAt = e.At
+			clock.set(e.At)
+		}
+		ui.ReplayEvent(e)
+	}
+	ui.Sync()
+	return ui.Close()
+}
+
+// replayClock is the clock of a replayed log: it is at the time of the last
+// replayed event and, during timed playback, moves on with the scaled wall
+// clock until the next one.
+type replayClock struct {
+	speed float64
+
+	mu       sync.Mutex
+	at       time.Time
+	anchored time.Time
+}
+
+func (c *replayClock) set(at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.at = at
+	c.anchored = time.Now()
+}
+
+func (c *replayClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.at.IsZero() {
+		return time.Now()
+	}
+	if c.speed <= 0 {
+		return c.at
+	}
+	return c.at.Add(time.Duration(float64(time.Since(c.anchored)) * c.speed))
+}
@@ -0,0 +1,96 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func recordEventLog(t *testing.T, path string, out *bytes.Buffer) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{
+		Mode:     ModePlain,
+		Out:      out,
+		EventLog: f,
+		Now:      func() time.Time { return now },
+	})
+	g := ui.Group("Start instances")
+	pd := g.Task("PD")
+	pd.Start()
+	tikv := g.Task("TiKV")
+	tikv.Start()
+	ui.Sync()
+	now = now.Add(1500 * time.Millisecond)
+	pd.Done()
+	tikv.Error("exit 1")
+	g.Close()
+	ui.Sync()
+	ui.PrintLines([]string{"bye"})
+	require.NoError(t, ui.Close())
+}
+
+func TestReplay_InstantPlainTranscript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	var recorded bytes.Buffer
+	recordEventLog(t, path, &recorded)
+
+	var replayed bytes.Buffer
+	err := Replay(context.Background(), path, ReplayOptions{
+		Options: Options{Mode: ModePlain, Out: &replayed},
+	})
+	require.NoError(t, err)
+	require.Equal(t, recorded.String(), replayed.String())
+	require.Contains(t, replayed.String(), "1.5s")
+}
+
+func TestReplay_ScaledPlayback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	recordEventLog(t, path, &bytes.Buffer{})
+
+	// The 1.5s gap is capped by MaxDelay.
+	start := time.Now()
+	err := Replay(context.Background(), path, ReplayOptions{
+		Options:  Options{Mode: ModePlain, Out: &bytes.Buffer{}},
+		Speed:    1,
+		MaxDelay: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	require.Less(t, time.Since(start), time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Replay(ctx, path, ReplayOptions{
+		Options: Options{Mode: ModePlain, Out: &bytes.Buffer{}},
+		Speed:   1,
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReplay_WarnsAboutGaps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	recordEventLog(t, path, &bytes.Buffer{})
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	require.NoError(t, os.WriteFile(path, bytes.Join(slices.Delete(lines, 2, 3), nil), 0o644))
+
+	var replayed bytes.Buffer
+	require.NoError(t, Replay(context.Background(), path, ReplayOptions{
+		Options: Options{Mode: ModePlain, Out: &replayed},
+	}))
+	require.Equal(t, 1, bytes.Count(replayed.Bytes(), []byte("missing events")))
+}
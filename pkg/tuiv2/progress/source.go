@@ -0,0 +1,160 @@
+package progress
+
+import "sync"
+
+// sourceIDs maps the group and task IDs of one replayed source to IDs of the
+// replaying UI.
+type sourceIDs struct {
+	// mu also serializes the emission of the source's events, so the group
+	// of the source is added before its first event.
+	mu     sync.Mutex
+	root   uint64
+	groups map[uint64]uint64
+	tasks  map[uint64]uint64
+}
+
+// replaySourceEvent replays e, an event of the stream named e.Source, with its
+// IDs moved to a namespace of their own: streams replayed into the same UI
+// (e.g. the event logs of several daemons) never collide. The top-level
+// groups of a source are rendered as sub-groups of a group titled after it.
+func (ui *UI) replaySourceEvent(e Event) {
+	ui.sourcesMu.Lock()
+	if ui.sources == nil {
+		ui.sources = make(map[string]*sourceIDs)
+	}
+	src := ui.sources[e.Source]
+	if src == nil {
+		src = &sourceIDs{groups: make(map[uint64]uint64), tasks: make(map[uint64]uint64)}
+		ui.sources[e.Source] = src
+	}
+	ui.sourcesMu.Unlock()
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if src.root == 0 {
+		src.root = ui.nextID.Add(1)
+		title := e.Source
+		ui.emit(Event{Type: EventGroupAdd, At: e.At, GroupID: src.root, Title: &title})
+	}
+
+	// A checkpoint describes the whole state of its own stream only: it must
+	// not replace the state of the other sources.
+	if e.Type == EventCheckpoint {
+		for _, ce := range e.Checkpoint.events() {
+			ui.emit(src.translate(ui, ce))
+		}
+		return
+	}
+	ui.emit(src.translate(ui, e))
+}
+
+// translate returns e with its IDs mapped to the UI namespace, allocating IDs
+// for the groups and tasks it adds.
+func (src *sourceIDs) translate(ui *UI, e Event) Event {
+	e.Source = ""
+	mapID := func(ids map[uint64]uint64, id uint64, add bool) uint64 {
+		if id == 0 {
+			return 0
+		}
+		if local, ok := ids[id]; ok {
+			return local
+		}
+		if !add {
+			// Unknown IDs (e.g. events before the attach point) must not
+			// match another source's group or task.
+			return 0
+		}
+		local := ui.nextID.Add(1)
+		ids[id] = local
+		return local
+	}
+
+	switch e.Type {
+	case EventGroupAdd:
+		e.GroupID = mapID(src.groups, e.GroupID, true)
+		if e.ParentGroupID == 0 {
+			e.ParentGroupID = src.root
+		} else {
+			e.ParentGroupID = mapID(src.groups, e.ParentGroupID, false)
+		}
+	case EventGroupUpdate, EventGroupClose:
+		e.GroupID = mapID(src.groups, e.GroupID, false)
+	case EventTaskAdd:
+		e.GroupID = mapID(src.groups, e.GroupID, false)
+		e.ParentID = mapID(src.tasks, e.ParentID, false)
+		e.TaskID = mapID(src.tasks, e.TaskID, true)
+	case EventTaskUpdate, EventTaskProgress, EventTaskState:
+		e.TaskID = mapID(src.tasks, e.TaskID, false)
+	}
+	return e
+}
+
+// events returns events rebuilding the state of cp from scratch, for UIs that
+// can only apply it incrementally.
+func (cp *Checkpoint) events() []Event {
+	if cp == nil {
+		return nil
+	}
+	var events []Event
+	var closes []Event
+	for _, g := range cp.Groups {
+		title := g.Title
+		events = append(events,
+			Event{Type: EventGroupAdd, At: g.Started, GroupID: g.ID, ParentGroupID: g.ParentID, Title: &title},
+			Event{
+				Type:                 EventGroupUpdate,
+				At:                   g.Started,
+				GroupID:              g.ID,
+				ShowMeta:             &g.ShowMeta,
+				HideDetailsOnSuccess: &g.HideDetailsOnSuccess,
+				SortTasksByTitle:     &g.SortTasksByTitle,
+				ShowDownloadTotals:   &g.ShowDownloadTotals,
+				ShowProgressCount:    &g.ShowProgressCount,
+			},
+		)
+		if !g.Closed.IsZero() {
+			closes = append(closes, Event{Type: EventGroupClose, At: g.Closed, GroupID: g.ID})
+		}
+	}
+	for _, t := range cp.Tasks {
+		title, kind, meta, message := t.Title, t.Kind, t.Meta, t.Message
+		direction, weight := t.Direction, t.Weight
+		hideIfFast, revealAfterMs := t.HideIfFast, t.RevealAfterMs
+		current, total := t.Current, t.Total
+		add := Event{
+			Type:     EventTaskAdd,
+			At:       t.StartAt,
+			GroupID:  t.GroupID,
+			TaskID:   t.ID,
+			ParentID: t.ParentID,
+			Title:    &title,
+			Pending:  t.StartAt.IsZero(),
+		}
+		update := Event{
+			Type:          EventTaskUpdate,
+			At:            t.StartAt,
+			TaskID:        t.ID,
+			Kind:          &kind,
+			Meta:          &meta,
+			Message:       &message,
+			HideIfFast:    &hideIfFast,
+			RevealAfterMs: &revealAfterMs,
+			Weight:        &weight,
+		}
+		if direction != "" {
+			update.Direction = &direction
+		}
+		events = append(events, add, update,
+			Event{Type: EventTaskProgress, At: t.StartAt, TaskID: t.ID, Current: &current, Total: &total})
+		if t.Status != TaskStatusPending && t.Status != TaskStatusRunning {
+			status := t.Status
+			at := t.EndAt
+			if at.IsZero() {
+				at = t.StartAt
+			}
+			events = append(events, Event{Type: EventTaskState, At: at, TaskID: t.ID, Status: &status, Message: &message, Hints: t.Hints})
+		}
+	}
+	return append(events, closes...)
+}
@@ -0,0 +1,83 @@
+package progress
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayEvent_SourcesDoNotCollide(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{Mode: ModePlain, Out: &out, Now: func() time.Time { return now }})
+
+	title := func(s string) *string { return &s }
+	status := func(s TaskStatus) *TaskStatus { return &s }
+	stream := func(src, task string, final TaskStatus) []Event {
+		return []Event{
+			{Source: src, Type: EventGroupAdd, At: now, GroupID: 1, Title: title("Start")},
+			{Source: src, Type: EventTaskAdd, At: now, GroupID: 1, TaskID: 2, Title: title(task)},
+			{Source: src, Type: EventTaskState, At: now, TaskID: 2, Status: status(TaskStatusRunning)},
+			{Source: src, Type: EventTaskState, At: now.Add(time.Second), TaskID: 2, Status: status(final), Message: title("boom")},
+		}
+	}
+	a := stream("a", "PD", TaskStatusDone)
+	b := stream("b", "TiKV", TaskStatusError)
+	for i := range a {
+		ui.ReplayEvent(a[i])
+		ui.ReplayEvent(b[i])
+	}
+	require.NoError(t, ui.Close())
+
+	require.Equal(t, "a | Start | PD\n"+
+		"b | Start | TiKV\n"+
+		"b | Start | ERR - TiKV: boom (1.0s)\n", out.String())
+}
+
+func TestReplayEvent_SourceCheckpointKeepsOtherSources(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Unix(1_000_000, 0)
+	ui := New(Options{Mode: ModePlain, Out: &out, Now: func() time.Time { return now }})
+
+	title, task := "Start", "PD"
+	ui.ReplayEvent(Event{Source: "a", Type: EventGroupAdd, At: now, GroupID: 1, Title: &title})
+
+	// b attaches at a checkpoint, reusing the IDs of a.
+	ui.ReplayEvent(Event{Source: "b", Type: EventCheckpoint, At: now, Checkpoint: &Checkpoint{
+		Groups: []CheckpointGroup{{ID: 1, Title: "Deploy", Started: now}},
+		Tasks: []CheckpointTask{
+			{ID: 2, GroupID: 1, Title: "TiDB", Kind: TaskKindGeneric, Status: TaskStatusDone, StartAt: now, EndAt: now},
+			{ID: 3, GroupID: 1, Title: "TiKV", Kind: TaskKindGeneric, Status: TaskStatusRunning, StartAt: now},
+		},
+	}})
+
+	failed := TaskStatusError
+	ui.ReplayEvent(Event{Source: "a", Type: EventTaskAdd, At: now, GroupID: 1, TaskID: 2, Title: &task})
+	ui.ReplayEvent(Event{Source: "a", Type: EventTaskState, At: now, TaskID: 2, Status: &failed})
+	ui.ReplayEvent(Event{Source: "b", Type: EventTaskState, At: now, TaskID: 3, Status: &failed})
+	require.NoError(t, ui.Close())
+
+	require.Contains(t, out.String(), "a | Start | ERR - PD")
+	require.Contains(t, out.String(), "b | Deploy | ERR - TiKV")
+}
+
+func TestCheckpointEvents_RebuildState(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	st := newEngineState()
+	title, task := "Deploy", "TiKV"
+	st.applyEvent(now, Event{Type: EventGroupAdd, GroupID: 1, Title: &title})
+	st.applyEvent(now, Event{Type: EventTaskAdd, GroupID: 1, TaskID: 2, Title: &task})
+	kind, dir, total := TaskKindTransfer, TransferUpload, int64(100)
+	st.applyEvent(now, Event{Type: EventTaskUpdate, TaskID: 2, Kind: &kind, Direction: &dir})
+	st.applyEvent(now, Event{Type: EventTaskProgress, TaskID: 2, Total: &total})
+	st.applyEvent(now, Event{Type: EventTaskAdd, GroupID: 1, TaskID: 3, Title: &title, Pending: true})
+	cp := st.checkpoint()
+
+	rebuilt := newEngineState()
+	for _, e := range cp.events() {
+		rebuilt.applyEvent(e.At, e)
+	}
+	require.Equal(t, cp, rebuilt.checkpoint())
+}
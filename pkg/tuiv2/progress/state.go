@@ -19,12 +19,18 @@ type taskKind int
 const (
 	taskKindGeneric taskKind = iota
 	taskKindDownload
+	taskKindTransfer
 )
 
 type groupState struct {
 	id    uint64
 	title string
 
+	// parent is nil for top-level groups. Sub-groups are only reachable through
+	// their parent's children; engineState.groups holds top-level groups only.
+	parent   *groupState
+	children []*groupState
+
 	startedAt time.Time
 	closedAt  time.Time
 	closed    bool
@@ -35,23 +41,95 @@ type groupState struct {
 	showMeta             bool
 	hideDetailsOnSuccess bool
 	sortTasksByTitle     bool
+	showDownloadTotals   bool
+	showProgressCount    bool
+
+	jsonClosedReported bool
 }
 
 func (g *groupState) canAutoSeal() bool {
-	if g == nil || g.sealed || !g.closed || len(g.tasks) == 0 {
+	if g == nil || g.sealed || !g.closed {
 		return false
 	}
-	for _, t := range g.tasks {
-		if t == nil {
-			continue
-		}
-		if t.status == taskStatusRunning || t.status == taskStatusRetrying {
+	tasks := g.subtreeTasks()
+	if len(tasks) == 0 {
+		return false
+	}
+	for _, t := range tasks {
+		if t.isActive() {
 			return false
 		}
 	}
 	return true
 }
 
+// subtreeTasks returns the top-level tasks of g followed by those of its
+// sub-groups (depth-first), so aggregates of a parent group cover the whole
+// stage.
+func (g *groupState) subtreeTasks() []*taskState {
+	if g == nil {
+		return nil
+	}
+	if len(g.children) == 0 {
+		return g.tasks
+	}
+	tasks := append([]*taskState(nil), g.tasks...)
+	for _, c := range g.children {
+		tasks = append(tasks, c.subtreeTasks()...)
+	}
+	return tasks
+}
+
+// hasFailed reports whether a top-level task of g or of its sub-groups failed.
+func (g *groupState) hasFailed() bool {
+	for _, t := range g.subtreeTasks() {
+		if t != nil && t.status == taskStatusError {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifiedTitle prefixes sub-group titles with their parent chain
+// (e.g. "Start instances > TiKV").
+func (g *groupState) qualifiedTitle() string {
+	if g == nil {
+		return ""
+	}
+	if g.parent == nil {
+		return g.title
+	}
+	return g.parent.qualifiedTitle() + " > " + g.title
+}
+
+// root returns the top-level group g belongs to.
+func (g *groupState) root() *groupState {
+	for g.parent != nil {
+		g = g.parent
+	}
+	return g
+}
+
+// close closes g and its sub-groups: closing a stage finishes its sub-stages.
+func (g *groupState) close(now time.Time) {
+	if !g.closed {
+		g.closed = true
+		g.closedAt = now
+	}
+	for _, c := range g.children {
+		c.close(now)
+	}
+}
+
+// seal seals g and its sub-groups. Sub-groups are rendered as part of their
+// top-level group, so they are never sealed on their own.
+func (g *groupState) seal() {
+	g.sealed = true
+	for _, c := range g.children {
+		c.seal()
+	}
+}
+
 func (g *groupState) elapsed(now time.Time) time.Duration {
 	if g == nil || g.startedAt.IsZero() {
 		return 0
@@ -62,7 +140,7 @@ func (g *groupState) elapsed(now time.Time) time.Duration {
 
 	hasRunning := false
 	var lastEnd time.Time
-	for _, t := range g.tasks {
+	for _, t := range g.subtreeTasks() {
 		if t == nil {
 			continue
 		}
@@ -87,20 +165,110 @@ func (g *groupState) elapsed(now time.Time) time.Duration {
 	return now.Sub(g.startedAt)
 }
 
+// finishedCount returns the number of top-level tasks (including those of
+// sub-groups) that reached a terminal status, and the number of such tasks.
+func (g *groupState) finishedCount() (finished, total int) {
+	if g == nil {
+		return 0, 0
+	}
+	for _, t := range g.subtreeTasks() {
+		if t == nil {
+			continue
+		}
+		total++
+		switch t.status {
+		case taskStatusDone, taskStatusError, taskStatusSkipped, taskStatusCanceled:
+			finished++
+		}
+	}
+	return finished, total
+}
+
+// progressRatio returns the weighted share of finished work in this group and
+// its sub-groups, in [0, 1]. Finished tasks count fully, running downloads
+// with a known total count by their downloaded fraction.
+func (g *groupState) progressRatio() float64 {
+	var done, total float64
+	for _, t := range g.subtreeTasks() {
+		if t == nil {
+			continue
+		}
+		w := t.weight
+		if w <= 0 {
+			w = 1
+		}
+		total += w
+		switch t.status {
+		case taskStatusDone, taskStatusError, taskStatusSkipped, taskStatusCanceled:
+			done += w
+		case taskStatusRunning, taskStatusRetrying:
+			if t.movesBytes() && t.total > 0 {
+				done += w * float64(min(t.current, t.total)) / float64(t.total)
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return done / total
+}
+
+// downloadTotals aggregates progress across the download tasks of this group
+// and its sub-groups.
+//
+// Only tasks with a known total contribute, so the ratio stays meaningful
+// while some downloads have not reported their size yet. speedBps is the sum
+// of smoothed speeds of the running tasks.
+func (g *groupState) downloadTotals() (current, total int64, speedBps float64) {
+	if g == nil {
+		return 0, 0, 0
+	}
+	for _, t := range g.subtreeTasks() {
+		if t == nil || t.kind != taskKindDownload || t.total <= 0 {
+			continue
+		}
+		switch t.status {
+		case taskStatusDone:
+			current += t.total
+		case taskStatusPending, taskStatusRunning, taskStatusRetrying:
+			current += min(t.current, t.total)
+		default:
+			continue
+		}
+		total += t.total
+		if t.status == taskStatusRunning {
+			speedBps += t.speedBps
+		}
+	}
+	return current, total, speedBps
+}
+
 type taskState struct {
 	id uint64
 	g  *groupState
 
+	// parent is nil for top-level tasks. Sub-tasks are only reachable through
+	// their parent's children; groupState.tasks holds top-level tasks only.
+	parent   *taskState
+	children []*taskState
+
 	title string
 
 	kind   taskKind
 	status taskStatus
+	// direction is set for taskKindTransfer.
+	direction TransferDirection
 
 	hideIfFast  bool
 	revealAfter time.Duration
 
+	// weight is the share of the task in group progress. Zero means 1.
+	weight float64
+
 	meta    string
 	message string
+	// hints are remediation suggestions of a failed task.
+	hints []string
 
 	current int64
 	total   int64
@@ -115,9 +283,132 @@ type taskState struct {
 
 	plainStartPrinted    bool
 	downloadStartPrinted bool
+	// plainEndPrinted dedupes terminal lines: the engine keeps the first
+	// terminal status, but the ignored state event (e.g. a late cancel from
+	// Task.WatchCtx) still reaches the renderer.
+	plainEndPrinted bool
+
+	// jsonReported/jsonStatus dedupe ModeJSON records: state events that do not
+	// change the status (e.g. repeated Start) are not reported again.
+	jsonReported bool
+	jsonStatus   taskStatus
+}
+
+// movesBytes reports whether t reports byte progress (downloads and
+// transfers), rendered with a bar and speed.
+func (t *taskState) movesBytes() bool {
+	return t.kind == taskKindDownload || t.kind == taskKindTransfer
+}
+
+// sizeLabel prefixes a formatted size with the transfer direction, if any
+// (e.g. "upload 12.0MiB").
+func (t *taskState) sizeLabel(size string) string {
+	if t.direction == "" {
+		return size
+	}
+	return string(t.direction) + " " + size
+}
+
+// isActive reports whether t or any of its sub-tasks is still running.
+func (t *taskState) isActive() bool {
+	if t == nil {
+		return false
+	}
+	if t.status == taskStatusRunning || t.status == taskStatusRetrying {
+		return true
+	}
+	for _, c := range t.children {
+		if c.isActive() {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifiedTitle prefixes sub-task titles with their parent chain, so the title
+// stays self-contained in line-oriented output (e.g. "TiKV-0 > Health check").
+func (t *taskState) qualifiedTitle() string {
+	if t == nil {
+		return ""
+	}
+	if t.parent == nil {
+		return t.title
+	}
+	return t.parent.qualifiedTitle() + " > " + t.title
+}
+
+// exported returns the stable string representation of s.
+func (s taskStatus) exported() TaskStatus {
+	switch s {
+	case taskStatusPending:
+		return TaskStatusPending
+	case taskStatusRunning:
+		return TaskStatusRunning
+	case taskStatusRetrying:
+		return TaskStatusRetrying
+	case taskStatusDone:
+		return TaskStatusDone
+	case taskStatusError:
+		return TaskStatusError
+	case taskStatusSkipped:
+		return TaskStatusSkipped
+	case taskStatusCanceled:
+		return TaskStatusCanceled
+	default:
+		return ""
+	}
+}
+
+// parseTaskStatus is the inverse of taskStatus.exported. Unknown statuses
+// map to pending.
+func parseTaskStatus(s TaskStatus) taskStatus {
+	switch s {
+	case TaskStatusRunning:
+		return taskStatusRunning
+	case TaskStatusRetrying:
+		return taskStatusRetrying
+	case TaskStatusDone:
+		return taskStatusDone
+	case TaskStatusError:
+		return taskStatusError
+	case TaskStatusSkipped:
+		return taskStatusSkipped
+	case TaskStatusCanceled:
+		return taskStatusCanceled
+	default:
+		return taskStatusPending
+	}
+}
+
+// exported returns the stable string representation of k.
+func (k taskKind) exported() TaskKind {
+	switch k {
+	case taskKindDownload:
+		return TaskKindDownload
+	case taskKindTransfer:
+		return TaskKindTransfer
+	default:
+		return TaskKindGeneric
+	}
+}
+
+// parseTaskKind is the inverse of taskKind.exported. Unknown kinds map to
+// generic.
+func parseTaskKind(k TaskKind) taskKind {
+	switch k {
+	case TaskKindDownload:
+		return taskKindDownload
+	case TaskKindTransfer:
+		return taskKindTransfer
+	default:
+		return taskKindGeneric
+	}
 }
 
 type engineState struct {
+	// startedAt is the time of the first applied event.
+	startedAt time.Time
+
 	groups    []*groupState
 	groupByID map[uint64]*groupState
 	taskByID  map[uint64]*taskState
@@ -138,11 +429,8 @@ func (s *engineState) hasRunning() bool {
 		if g == nil || g.sealed {
 			continue
 		}
-		for _, t := range g.tasks {
-			if t == nil {
-				continue
-			}
-			if t.status == taskStatusRunning || t.status == taskStatusRetrying {
+		for _, t := range g.subtreeTasks() {
+			if t.isActive() {
 				return true
 			}
 		}
@@ -157,6 +445,9 @@ func (s *engineState) applyEvent(now time.Time, e Event) {
 	if now.IsZero() {
 		now = time.Now()
 	}
+	if s.startedAt.IsZero() {
+		s.startedAt = now
+	}
 
 	switch e.Type {
 	case EventGroupAdd:
@@ -173,11 +464,25 @@ func (s *engineState) applyEvent(now time.Time, e Event) {
 		s.applyTaskProgress(now, e)
 	case EventTaskState:
 		s.applyTaskState(now, e)
+	case EventCheckpoint:
+		s.applyCheckpoint(e.Checkpoint)
 	default:
 		return
 	}
 }
 
+// resolveSummary fills the Summary of an EventSummary emitted by UI.Close from
+// the current state. Replayed summaries already carry one and are kept as-is.
+//
+// It must run before the event is written to the event log, so the log ends
+// with the resolved result.
+func (s *engineState) resolveSummary(now time.Time, e *Event) {
+	if e.Type != EventSummary || e.Summary != nil {
+		return
+	}
+	e.Summary = s.summary(now)
+}
+
 func (s *engineState) applyGroupAdd(now time.Time, e Event) {
 	id := e.GroupID
 	if id == 0 {
@@ -187,18 +492,30 @@ func (s *engineState) applyGroupAdd(now time.Time, e Event) {
 		// Idempotent best-effort.
 		return
 	}
+	var parent *groupState
+	if e.ParentGroupID != 0 {
+		parent = s.groupByID[e.ParentGroupID]
+		if parent == nil || parent.sealed {
+			return
+		}
+	}
 	title := ""
 	if e.Title != nil {
 		title = *e.Title
 	}
 	g := &groupState{
 		id:        id,
+		parent:    parent,
 		title:     title,
 		showMeta:  true,
 		startedAt: now,
 	}
 	s.groupByID[id] = g
-	s.groups = append(s.groups, g)
+	if parent != nil {
+		parent.children = append(parent.children, g)
+	} else {
+		s.groups = append(s.groups, g)
+	}
 }
 
 func (s *engineState) applyGroupUpdate(e Event) {
@@ -218,6 +535,12 @@ func (s *engineState) applyGroupUpdate(e Event) {
 	if e.SortTasksByTitle != nil {
 		g.sortTasksByTitle = *e.SortTasksByTitle
 	}
+	if e.ShowDownloadTotals != nil {
+		g.showDownloadTotals = *e.ShowDownloadTotals
+	}
+	if e.ShowProgressCount != nil {
+		g.showProgressCount = *e.ShowProgressCount
+	}
 }
 
 func (s *engineState) applyGroupClose(now time.Time, e Event) {
@@ -231,15 +554,11 @@ func (s *engineState) applyGroupClose(now time.Time, e Event) {
 		finished = *e.Finished
 	}
 	if !finished {
-		g.sealed = true
+		g.root().seal()
 		return
 	}
 
-	if g.closed {
-		return
-	}
-	g.closed = true
-	g.closedAt = now
+	g.close(now)
 }
 
 func (s *engineState) applyTaskAdd(now time.Time, e Event) {
@@ -254,6 +573,13 @@ func (s *engineState) applyTaskAdd(now time.Time, e Event) {
 	if _, ok := s.taskByID[id]; ok {
 		return
 	}
+	var parent *taskState
+	if e.ParentID != 0 {
+		parent = s.taskByID[e.ParentID]
+		if parent == nil || parent.g != g {
+			return
+		}
+	}
 	title := ""
 	if e.Title != nil {
 		title = *e.Title
@@ -261,6 +587,7 @@ func (s *engineState) applyTaskAdd(now time.Time, e Event) {
 	t := &taskState{
 		id:     id,
 		g:      g,
+		parent: parent,
 		title:  title,
 		kind:   taskKindGeneric,
 		status: taskStatusRunning,
@@ -271,7 +598,11 @@ func (s *engineState) applyTaskAdd(now time.Time, e Event) {
 		t.startAt = now
 	}
 	s.taskByID[id] = t
-	g.tasks = append(g.tasks, t)
+	if parent != nil {
+		parent.children = append(parent.children, t)
+	} else {
+		g.tasks = append(g.tasks, t)
+	}
 	if g.startedAt.IsZero() {
 		g.startedAt = now
 	}
@@ -283,12 +614,13 @@ func (s *engineState) applyTaskUpdate(e Event) {
 		return
 	}
 	if e.Kind != nil {
-		switch *e.Kind {
-		case TaskKindDownload:
-			t.kind = taskKindDownload
-		default:
-			t.kind = taskKindGeneric
-		}
+		t.kind = parseTaskKind(*e.Kind)
+	}
+	if e.Title != nil {
+		t.title = *e.Title
+	}
+	if e.Direction != nil {
+		t.direction = *e.Direction
 	}
 	if e.Meta != nil {
 		t.meta = *e.Meta
@@ -306,6 +638,9 @@ func (s *engineState) applyTaskUpdate(e Event) {
 		}
 		t.revealAfter = d
 	}
+	if e.Weight != nil {
+		t.weight = max(*e.Weight, 0)
+	}
 }
 
 func (s *engineState) applyTaskProgress(now time.Time, e Event) {
@@ -332,7 +667,7 @@ func (s *engineState) applyTaskProgress(now time.Time, e Event) {
 		return
 	}
 
-	if t.kind != taskKindDownload {
+	if !t.movesBytes() {
 		return
 	}
 
@@ -443,11 +778,14 @@ func (s *engineState) applyTaskState(now time.Time, e Event) {
 	if e.Message != nil {
 		t.message = *e.Message
 	}
+	if t.status == taskStatusError {
+		t.hints = e.Hints
+	}
 
 	if t.status != taskStatusDone && t.status != taskStatusError && t.status != taskStatusSkipped && t.status != taskStatusCanceled {
 		return
 	}
-	if t.kind != taskKindDownload || t.speedBps > 0 || t.startAt.IsZero() || !now.After(t.startAt) {
+	if !t.movesBytes() || t.speedBps > 0 || t.startAt.IsZero() || !now.After(t.startAt) {
 		return
 	}
 
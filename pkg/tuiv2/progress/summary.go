@@ -0,0 +1,100 @@
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Summary is the at-a-glance result carried by EventSummary.
+type Summary struct {
+	// ElapsedMs is the time between the first event and UI.Close.
+	ElapsedMs int64          `json:"elapsed_ms"`
+	Groups    []GroupSummary `json:"groups,omitempty"`
+}
+
+// GroupSummary counts the final statuses of the top-level tasks of a group,
+// including those of its sub-groups. Tasks still pending or running when the
+// UI closed are not counted.
+type GroupSummary struct {
+	Title      string `json:"title"`
+	DurationMs int64  `json:"duration_ms"`
+	Succeeded  int    `json:"succeeded,omitempty"`
+	Failed     int    `json:"failed,omitempty"`
+	Skipped    int    `json:"skipped,omitempty"`
+	Canceled   int    `json:"canceled,omitempty"`
+}
+
+// summary builds the Summary of the current state. Groups without tasks are
+// left out, matching the TTY renderer.
+func (s *engineState) summary(now time.Time) *Summary {
+	sum := &Summary{}
+	if s == nil {
+		return sum
+	}
+	if !s.startedAt.IsZero() {
+		sum.ElapsedMs = now.Sub(s.startedAt).Milliseconds()
+	}
+	for _, g := range s.groups {
+		tasks := g.subtreeTasks()
+		if len(tasks) == 0 {
+			continue
+		}
+		gs := GroupSummary{Title: g.title, DurationMs: g.elapsed(now).Milliseconds()}
+		for _, t := range tasks {
+			if t == nil {
+				continue
+			}
+			switch t.status {
+			case taskStatusDone:
+				gs.Succeeded++
+			case taskStatusError:
+				gs.Failed++
+			case taskStatusSkipped:
+				gs.Skipped++
+			case taskStatusCanceled:
+				gs.Canceled++
+			}
+		}
+		sum.Groups = append(sum.Groups, gs)
+	}
+	return sum
+}
+
+// lines renders the summary as an aligned text block shared by the plain and
+// TTY renderers.
+func (s *Summary) lines() []string {
+	if s == nil {
+		return nil
+	}
+	titleWidth := 0
+	for _, g := range s.Groups {
+		titleWidth = max(titleWidth, len(g.Title))
+	}
+
+	lines := make([]string, 0, len(s.Groups)+2)
+	lines = append(lines, "Summary:")
+	for _, g := range s.Groups {
+		var counts []string
+		for _, c := range []struct {
+			n     int
+			label string
+		}{
+			{g.Succeeded, "succeeded"},
+			{g.Failed, "failed"},
+			{g.Skipped, "skipped"},
+			{g.Canceled, "canceled"},
+		} {
+			if c.n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", c.n, c.label))
+			}
+		}
+		if len(counts) == 0 {
+			counts = append(counts, "no finished tasks")
+		}
+		lines = append(lines, fmt.Sprintf("  %-*s  %s (%s)",
+			titleWidth, g.Title, strings.Join(counts, ", "), formatDuration(time.Duration(g.DurationMs)*time.Millisecond)))
+	}
+	lines = append(lines, fmt.Sprintf("Total: %s", formatDuration(time.Duration(s.ElapsedMs)*time.Millisecond)))
+	return lines
+}
@@ -1,6 +1,11 @@
 package progress
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
 
 // Task represents one line item in a group.
 //
@@ -16,6 +21,43 @@ type Task struct {
 	title string
 }
 
+func (ui *UI) newTask(groupID, parentID uint64, title string, pending bool) *Task {
+	tid := ui.nextID.Add(1)
+	t := &Task{ui: ui, id: tid, groupID: groupID, title: title}
+	ui.trackTask(t)
+	tt := title
+	ui.emit(Event{
+		Type:     EventTaskAdd,
+		At:       ui.now(),
+		GroupID:  groupID,
+		TaskID:   tid,
+		ParentID: parentID,
+		Title:    &tt,
+		Pending:  pending,
+	})
+	return t
+}
+
+// Child creates a new running sub-task under this task.
+//
+// Sub-tasks are rendered as an indented tree below their parent in TTY mode,
+// and prefixed with the parent title in plain mode.
+func (t *Task) Child(title string) *Task {
+	return t.newChild(title, false)
+}
+
+// ChildPending creates a new sub-task under this task in a "pending" state.
+func (t *Task) ChildPending(title string) *Task {
+	return t.newChild(title, true)
+}
+
+func (t *Task) newChild(title string, pending bool) *Task {
+	if t == nil || t.ui == nil || t.ui.closed.Load() {
+		return &Task{title: title}
+	}
+	return t.ui.newTask(t.groupID, t.id, title, pending)
+}
+
 // SetHideIfFast configures this task to be hidden in TTY mode unless it runs for
 // at least revealAfter, or errors.
 func (t *Task) SetHideIfFast(revealAfter time.Duration) {
@@ -36,6 +78,40 @@ func (t *Task) SetHideIfFast(revealAfter time.Duration) {
 	})
 }
 
+// SetWeight sets the share of this task in group-level progress (see
+// Group.SetShowProgressCount), relative to other tasks of the group; e.g. a
+// download can be weighted by its size. Tasks default to a weight of 1, and
+// non-positive weights reset it.
+func (t *Task) SetWeight(weight float64) {
+	if t == nil || t.ui == nil || t.ui.closed.Load() {
+		return
+	}
+	w := weight
+	t.ui.emit(Event{
+		Type:   EventTaskUpdate,
+		At:     t.ui.now(),
+		TaskID: t.id,
+		Weight: &w,
+	})
+}
+
+// SetKindTransfer marks this task as moving bytes in the given direction, so
+// SetTotal/SetCurrent get the same bar and speed treatment as downloads.
+func (t *Task) SetKindTransfer(direction TransferDirection) {
+	if t == nil || t.ui == nil || t.ui.closed.Load() {
+		return
+	}
+	kind := TaskKindTransfer
+	dir := direction
+	t.ui.emit(Event{
+		Type:      EventTaskUpdate,
+		At:        t.ui.now(),
+		TaskID:    t.id,
+		Kind:      &kind,
+		Direction: &dir,
+	})
+}
+
 // SetKindDownload marks this task as a download task.
 func (t *Task) SetKindDownload() {
 	if t == nil || t.ui == nil || t.ui.closed.Load() {
@@ -94,6 +170,21 @@ func (t *Task) Retrying(msg string) {
 	})
 }
 
+// SetTitle updates the task title.
+func (t *Task) SetTitle(title string) {
+	if t == nil || t.ui == nil || t.ui.closed.Load() {
+		return
+	}
+	t.title = title
+	tt := title
+	t.ui.emit(Event{
+		Type:   EventTaskUpdate,
+		At:     t.ui.now(),
+		TaskID: t.id,
+		Title:  &tt,
+	})
+}
+
 // SetMeta sets stable, user-facing metadata for this task (e.g. component
 // version for downloads).
 func (t *Task) SetMeta(meta string) {
@@ -142,6 +233,7 @@ func (t *Task) Done() {
 	if t == nil || t.ui == nil || t.ui.closed.Load() {
 		return
 	}
+	t.ui.untrackTask(t.id)
 	status := TaskStatusDone
 	t.ui.emit(Event{
 		Type:   EventTaskState,
@@ -153,9 +245,17 @@ func (t *Task) Done() {
 
 // Error marks the task as failed with a message.
 func (t *Task) Error(msg string) {
+	t.ErrorWithHint(msg)
+}
+
+// ErrorWithHint marks the task as failed with a message and remediation hints
+// (e.g. "use --port-offset to avoid port conflicts"). Hints are rendered as
+// indented "hint:" lines below the error.
+func (t *Task) ErrorWithHint(msg string, hints ...string) {
 	if t == nil || t.ui == nil || t.ui.closed.Load() {
 		return
 	}
+	t.ui.untrackTask(t.id)
 	status := TaskStatusError
 	m := msg
 	t.ui.emit(Event{
@@ -164,6 +264,7 @@ func (t *Task) Error(msg string) {
 		TaskID:  t.id,
 		Status:  &status,
 		Message: &m,
+		Hints:   hints,
 	})
 }
 
@@ -172,6 +273,7 @@ func (t *Task) Skip(reason string) {
 	if t == nil || t.ui == nil || t.ui.closed.Load() {
 		return
 	}
+	t.ui.untrackTask(t.id)
 	status := TaskStatusSkipped
 	r := reason
 	t.ui.emit(Event{
@@ -188,6 +290,7 @@ func (t *Task) Cancel(reason string) {
 	if t == nil || t.ui == nil || t.ui.closed.Load() {
 		return
 	}
+	t.ui.untrackTask(t.id)
 	status := TaskStatusCanceled
 	r := reason
 	t.ui.emit(Event{
@@ -198,3 +301,77 @@ func (t *Task) Cancel(reason string) {
 		Message: &r,
 	})
 }
+
+// WatchCtx marks the task canceled, with the context's cause as the message,
+// once ctx is done. Tasks that already reached a terminal status keep it: the
+// engine ignores late cancels.
+//
+// The returned stop function detaches the watcher; call it once the task
+// finishes if ctx may outlive the task. It reports whether it stopped the
+// watcher before it fired.
+func (t *Task) WatchCtx(ctx context.Context) (stop func() bool) {
+	if t == nil || t.ui == nil || t.ui.closed.Load() {
+		return func() bool { return false }
+	}
+	return context.AfterFunc(ctx, func() {
+		t.Cancel(context.Cause(ctx).Error())
+	})
+}
+
+// RetryOptions controls how Task.Run retries a failing function.
+type RetryOptions struct {
+	// Attempts is the maximum number of attempts. Values < 1 mean one attempt.
+	Attempts int
+	// Backoff is the delay before the second attempt. It doubles after each
+	// failed attempt, capped by MaxBackoff when MaxBackoff > 0.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retryable reports whether err is worth another attempt. If nil, every
+	// error is retried. Errors after ctx is done are never retried.
+	Retryable func(err error) bool
+}
+
+// Run starts the task and runs fn until it succeeds or attempts run out.
+//
+// Failed attempts mark the task Retrying with the attempt counter and the
+// error in the message; the final result marks it Done, Error, or Canceled
+// when ctx is done. It returns the last error of fn (or the context cause
+// when canceled during a backoff).
+func (t *Task) Run(ctx context.Context, fn func(ctx context.Context) error, opts RetryOptions) error {
+	attempts := max(opts.Attempts, 1)
+	backoff := opts.Backoff
+
+	t.Start()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			t.Done()
+			return nil
+		}
+		if ctx.Err() != nil {
+			t.Cancel(err.Error())
+			return err
+		}
+		if attempt >= attempts || (opts.Retryable != nil && !opts.Retryable(err)) {
+			t.Error(err.Error())
+			return err
+		}
+
+		t.Retrying(fmt.Sprintf("retrying %d/%d: %v", attempt+1, attempts, err))
+		if backoff > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				cause := context.Cause(ctx)
+				t.Cancel(cause.Error())
+				return errors.Join(cause, err)
+			case <-timer.C:
+			}
+			backoff *= 2
+			if opts.MaxBackoff > 0 && backoff > opts.MaxBackoff {
+				backoff = opts.MaxBackoff
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package progress
+
+import (
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme customizes the glyphs and colors used by the TTY renderer.
+//
+// Start from DefaultTheme or ASCIITheme and override fields as needed. Colors
+// are ignored when color output is disabled (see tuiterm.Resolve).
+type Theme struct {
+	// Spinner animates running tasks. A spinner without frames disables the
+	// animation: running tasks show RunningIcon instead.
+	Spinner spinner.Spinner
+	// RunningIcon is the static glyph of running tasks, used when the spinner
+	// is disabled and in History snapshots of interrupted groups.
+	RunningIcon string
+	// GroupRunningIcon is shown before a group header while it has active tasks.
+	GroupRunningIcon string
+	SuccessIcon      string
+	ErrorIcon        string
+	PendingIcon      string
+	SkippedIcon      string
+	// WarningIcon is shown for retrying and canceled tasks.
+	WarningIcon string
+
+	// Guide is the vertical bar drawn to the left of a group's tasks.
+	Guide string
+	// ProgressFilled and ProgressTrack are repeated to draw the filled part and
+	// the remaining track of a download progress bar.
+	ProgressFilled string
+	ProgressTrack  string
+	// Ellipsis prefixes "and N more" notices for truncated output.
+	Ellipsis string
+
+	SuccessColor lipgloss.TerminalColor
+	ErrorColor   lipgloss.TerminalColor
+	WarningColor lipgloss.TerminalColor
+	// MutedColor is used for secondary glyphs such as pending/skipped icons,
+	// guides of running groups and progress bar tracks.
+	MutedColor lipgloss.TerminalColor
+	// AccentColor is used for the running task spinner.
+	AccentColor lipgloss.TerminalColor
+}
+
+// DefaultTheme returns the default Unicode theme.
+func DefaultTheme() Theme {
+	return Theme{
+		Spinner:     spinner.MiniDot,
+		RunningIcon: "⠦",
+
+		GroupRunningIcon: "•",
+		SuccessIcon:      "✔︎",
+		ErrorIcon:        "✘",
+		PendingIcon:      "·",
+		SkippedIcon:      "↷",
+		WarningIcon:      "!",
+
+		Guide:          "┃",
+		ProgressFilled: "━",
+		ProgressTrack:  "━",
+		Ellipsis:       "…",
+
+		SuccessColor: lipgloss.ANSIColor(termenv.ANSIGreen),
+		ErrorColor:   lipgloss.ANSIColor(termenv.ANSIRed),
+		WarningColor: lipgloss.ANSIColor(termenv.ANSIYellow),
+		MutedColor:   lipgloss.ANSIColor(termenv.ANSIBrightBlack),
+		AccentColor:  lipgloss.ANSIColor(termenv.ANSICyan),
+	}
+}
+
+// ASCIITheme returns a theme that only uses ASCII glyphs, for terminals or
+// fonts that render the default icons and box-drawing characters badly.
+//
+// It is the default when the terminal is not expected to support Unicode (see
+// tuiterm.SupportsUnicode).
+func ASCIITheme() Theme {
+	t := DefaultTheme()
+	t.Spinner = spinner.Line
+	t.RunningIcon = "-"
+	t.GroupRunningIcon = "*"
+	t.SuccessIcon = "v"
+	t.ErrorIcon = "x"
+	t.PendingIcon = "."
+	t.SkippedIcon = ">"
+	t.Guide = "|"
+	t.ProgressFilled = "#"
+	t.ProgressTrack = "-"
+	t.Ellipsis = "..."
+	return t
+}
@@ -2,14 +2,20 @@ package progress
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/ansi"
-	"golang.org/x/term"
+	tuiterm "github.com/pingcap/tiup/pkg/tui/term"
 )
 
+// ttyFPS is the maximum repaint rate of the Active area.
+const ttyFPS = 10
+
 type ttyEventMsg struct {
 	Event Event
 	Ack   chan ttyEventAck
@@ -17,6 +23,13 @@ type ttyEventMsg struct {
 
 type ttyShutdownMsg struct{}
 
+// ttySuspendMsg hides (Suspend=true) or restores the Active area. While
+// suspended, History prints are held back and released by the resume ack.
+type ttySuspendMsg struct {
+	Suspend bool
+	Ack     chan ttyEventAck
+}
+
 type ttyEventAck struct {
 	Prints []string
 }
@@ -31,17 +44,43 @@ type ttyModel struct {
 
 	spinner       spinner.Model
 	spinnerActive bool
+
+	suspended bool
+	// held is shared by model copies, so prints held from the deferred ack in
+	// Update survive the value receiver.
+	held *[]string
+
+	frame *ttyFrame
 }
 
+// ttyFrame caches the rendered Active area. It is shared by model copies.
+//
+// bubbletea calls View after every message, which would re-render all groups
+// for each of the (possibly hundreds per second) progress events. Instead,
+// messages only mark the frame dirty, and View re-renders at most once per
+// frame interval; a frame tick makes sure the last change is rendered.
+type ttyFrame struct {
+	view          string
+	dirty         bool
+	renderedAt    time.Time
+	tickScheduled bool
+}
+
+type ttyFrameMsg struct{}
+
+const ttyFrameInterval = time.Second / ttyFPS
+
 func newTTYModel(ui *UI) ttyModel {
 	m := ttyModel{
 		ui:    ui,
 		state: newEngineState(),
+		held:  new([]string),
+		frame: &ttyFrame{dirty: true},
 	}
 	if ui != nil {
-		m.styles = newTTYStyles(ui.out)
+		m.styles = newTTYStyles(ui.out, ui.outMode.Color, ui.theme)
 		m.spinner = spinner.New(
-			spinner.WithSpinner(spinner.MiniDot),
+			spinner.WithSpinner(ui.theme.Spinner),
 			spinner.WithStyle(m.styles.spinner),
 		)
 	}
@@ -53,6 +92,23 @@ func (m ttyModel) Init() tea.Cmd {
 }
 
 func (m ttyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	switch msg.(type) {
+	case ttyFrameMsg:
+		m.frame.tickScheduled = false
+	case tea.WindowSizeMsg, ttyEventMsg, ttySuspendMsg, spinner.TickMsg:
+		m.frame.dirty = true
+	default:
+		return next, cmd
+	}
+	if m.frame.dirty && !m.frame.tickScheduled {
+		m.frame.tickScheduled = true
+		cmd = tea.Batch(cmd, tea.Tick(ttyFrameInterval, func(time.Time) tea.Msg { return ttyFrameMsg{} }))
+	}
+	return next, cmd
+}
+
+func (m ttyModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -60,40 +116,71 @@ func (m ttyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case ttyShutdownMsg:
 		return m, tea.Quit
+	case ttySuspendMsg:
+		m.suspended = msg.Suspend
+		var prints []string
+		if !msg.Suspend {
+			prints, *m.held = *m.held, nil
+		}
+		if msg.Ack != nil {
+			msg.Ack <- ttyEventAck{Prints: prints}
+		}
+		return m, nil
 	case ttyEventMsg:
 		ui := m.ui
 		prints := []string(nil)
 		if msg.Ack != nil {
 			defer func() {
+				if m.suspended {
+					*m.held = append(*m.held, prints...)
+					prints = nil
+				}
 				msg.Ack <- ttyEventAck{Prints: prints}
 			}()
 		}
 		if ui == nil {
 			return m, nil
 		}
-		e := msg.Event
+		e, ok := ui.filterEvent(msg.Event)
+		if !ok {
+			return m, nil
+		}
 		now := e.At
 		if now.IsZero() && ui.now != nil {
 			now = ui.now()
 		}
 
-		if ui.eventLog != nil && e.Type != EventSync {
+		m.state.resolveSummary(now, &e)
+		if ui.eventLog != nil {
 			ui.eventLog.write(now, e)
 		}
+		ui.observeEvent(now, e)
 
 		if e.Type == EventSync {
+			ui.eventLog.flush()
 			ui.fulfillSync(e.SyncID)
 			return m, m.ensureSpinnerTick()
 		}
 
-		// PrintLines is a pure output event: it does not affect progress state.
+		// PrintLines and Summary are pure output events: they do not affect
+		// progress state.
 		switch e.Type {
-		case EventPrintLines:
-			if len(e.Lines) == 0 {
+		case EventPrintLines, EventSummary:
+			src := e.Lines
+			if e.Type == EventSummary {
+				src = e.Summary.lines()
+			}
+			if len(src) == 0 {
 				return m, m.ensureSpinnerTick()
 			}
-			lines := make([]string, 0, len(e.Lines))
-			for _, line := range e.Lines {
+			switch e.Severity {
+			case PrintSeverityWarn:
+				src = labelLines(m.styles.warnLabel.Render("WARN"), len("WARN"), src)
+			case PrintSeverityError:
+				src = labelLines(m.styles.errLabel.Render("ERR"), len("ERR"), src)
+			}
+			lines := make([]string, 0, len(src))
+			for _, line := range src {
 				if line == "" {
 					lines = append(lines, "\r"+ansi.EraseLineRight)
 					continue
@@ -110,7 +197,7 @@ func (m ttyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Seal snapshots (explicit).
 		if e.Type == EventGroupClose && e.Finished != nil && !*e.Finished {
 			if g := m.state.groupByID[e.GroupID]; g != nil && g.sealed {
-				if lines := m.snapshotLines(g, true); len(lines) > 0 {
+				if lines := m.snapshotLines(g.root(), true); len(lines) > 0 {
 					prints = append(prints, "\r"+strings.Join(lines, "\n"))
 				}
 			}
@@ -121,12 +208,13 @@ func (m ttyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if g == nil || !g.canAutoSeal() {
 				continue
 			}
-			g.sealed = true
+			g.seal()
 			if lines := m.snapshotLines(g, false); len(lines) > 0 {
 				prints = append(prints, "\r"+strings.Join(lines, "\n"))
 			}
 		}
 
+		m.ui.maybeWriteCheckpoint(now, m.state)
 		return m, m.ensureSpinnerTick()
 	case spinner.TickMsg:
 		var cmd tea.Cmd
@@ -143,7 +231,7 @@ func (m ttyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *ttyModel) ensureSpinnerTick() tea.Cmd {
-	if m == nil || m.state == nil {
+	if m == nil || m.state == nil || len(m.spinner.Spinner.Frames) == 0 {
 		return nil
 	}
 	if !m.state.hasRunning() {
@@ -157,32 +245,63 @@ func (m *ttyModel) ensureSpinnerTick() tea.Cmd {
 	return func() tea.Msg { return m.spinner.Tick() }
 }
 
+// spinnerGlyph returns the styled glyph for running tasks in the Active area.
+func (m ttyModel) spinnerGlyph() string {
+	if len(m.spinner.Spinner.Frames) == 0 {
+		return m.styles.spinner.Render(m.styles.theme.RunningIcon)
+	}
+	return m.spinner.View()
+}
+
 func (m ttyModel) View() string {
-	ui := m.ui
-	if ui == nil {
+	if m.ui == nil || m.suspended {
 		return ""
 	}
-
-	width := m.width
-	height := m.height
-	if (width <= 0 || height <= 0) && ui.outFile != nil && term.IsTerminal(int(ui.outFile.Fd())) {
-		if w, h, err := term.GetSize(int(ui.outFile.Fd())); err == nil {
-			if width <= 0 && w > 0 {
-				width = w
-			}
-			if height <= 0 && h > 0 {
-				height = h
-			}
-		}
+	f := m.frame
+	if now := time.Now(); f.dirty && now.Sub(f.renderedAt) >= ttyFrameInterval {
+		f.view = m.render()
+		f.dirty = false
+		f.renderedAt = now
 	}
+	return f.view
+}
+
+// size returns the terminal size used for layout: Options.Width/Height, then
+// the last reported window size, then tuiterm.Size (the size queried from the
+// terminal, COLUMNS/LINES, and finally 80x24).
+func (m ttyModel) size() (width, height int) {
+	ui := m.ui
+
+	width, height = ui.width, ui.height
 	if width <= 0 {
-		width = 80
+		width = m.width
 	}
 	if height <= 0 {
-		height = 24
+		height = m.height
+	}
+	if width <= 0 || height <= 0 {
+		w, h := tuiterm.Size(ui.outFile, 80, 24)
+		if width <= 0 {
+			width = w
+		}
+		if height <= 0 {
+			height = h
+		}
 	}
+	return width, height
+}
+
+// render renders the Active area from the current state.
+func (m ttyModel) render() string {
+	ui := m.ui
+
+	width, height := m.size()
 
 	maxLines := height - 1
+	if ui.activeMaxLines > 0 && ui.activeMaxLines+1 < maxLines {
+		// +1 for the trailing empty line that keeps the cursor below the area.
+		maxLines = ui.activeMaxLines + 1
+	}
 	if maxLines < 3 {
 		maxLines = 3
 	}
@@ -191,11 +310,14 @@ func (m ttyModel) View() string {
 		styles:  m.styles,
 		width:   width,
 		height:  height,
-		spinner: m.spinner.View(),
+		spinner: m.spinnerGlyph(),
 		now:     ui.now(),
 	}
 
 	activeLimit := 1_000_000
+	if ui.activeTaskLimit > 0 {
+		activeLimit = ui.activeTaskLimit
+	}
 	blocks := renderTTYBlocks(m.state, ctx, activeLimit)
 	lines := flattenBlocks(blocks)
 	if len(lines) == 0 {
@@ -219,7 +341,7 @@ func (m ttyModel) View() string {
 			lines = flattenBlocks(blocks)
 		}
 		if dropped > 0 {
-			notice := ctx.styles.notice.Render(fmt.Sprintf("… and %d more", dropped))
+			notice := ctx.styles.notice.Render(fmt.Sprintf("%s and %d more", ctx.styles.theme.Ellipsis, dropped))
 			lines = append([]string{ctx.styles.clipLine(width, notice)}, lines...)
 		}
 	}
@@ -239,21 +361,13 @@ func (m ttyModel) snapshotLines(g *groupState, freezeSpinner bool) []string {
 	// Keep snapshot behavior consistent with the Active area render: groups with
 	// no tasks are not meaningful to users, and sealing them would otherwise
 	// produce "ghost" stages on interrupts (e.g. Ctrl+C in playground).
-	if len(g.tasks) == 0 {
+	if len(g.subtreeTasks()) == 0 {
 		return nil
 	}
-	width := m.width
-	if width <= 0 {
-		width = 80
-		if m.ui.outFile != nil && term.IsTerminal(int(m.ui.outFile.Fd())) {
-			if w, _, err := term.GetSize(int(m.ui.outFile.Fd())); err == nil && w > 0 {
-				width = w
-			}
-		}
-	}
+	width, _ := m.size()
 	sp := ""
 	if freezeSpinner {
-		sp = m.styles.spinner.Render("⠦")
+		sp = m.styles.spinner.Render(m.styles.theme.RunningIcon)
 	}
 	ctx := ttyRenderContext{
 		styles:  m.styles,
@@ -261,7 +375,11 @@ func (m ttyModel) snapshotLines(g *groupState, freezeSpinner bool) []string {
 		spinner: sp,
 		now:     m.ui.now(),
 	}
-	return ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	lines := ttyGroupComponent{group: g, failedOnly: m.ui.history == HistoryFailedOnly}.Lines(ctx, 1_000_000)
+	if m.ui.history == HistoryHeaderOnly {
+		lines = lines[:1]
+	}
+	return lines
 }
 
 func (ui *UI) startTTY() {
@@ -270,41 +388,37 @@ func (ui *UI) startTTY() {
 		return
 	}
 
+	// The program never reads input, but can only release and restore the
+	// terminal (see suspendTTY) with one: give it a pipe nobody writes to.
+	var input io.Reader
+	inR, inW, err := os.Pipe()
+	if err == nil {
+		input, ui.ttyInput = inR, inR
+	}
+
 	model := newTTYModel(ui)
 	p := tea.NewProgram(
 		model,
 		tea.WithOutput(ui.out),
-		tea.WithInput(nil),
+		tea.WithInput(input),
 		tea.WithoutSignalHandler(),
-		tea.WithFPS(10),
+		tea.WithFPS(ttyFPS),
 	)
 	ui.ttyProgram = p
 
 	go func() {
 		defer close(ui.ttyDoneCh)
 		_, _ = p.Run()
+		if inW != nil {
+			_ = inW.Close()
+			_ = inR.Close()
+		}
 	}()
 
 	sendEvent := func(e Event) bool {
-		ackCh := make(chan ttyEventAck)
-		p.Send(ttyEventMsg{Event: e, Ack: ackCh})
-
-		var ack ttyEventAck
-		select {
-		case ack = <-ackCh:
-		case <-ui.ttyDoneCh:
-			return false
-		}
-
-		for _, block := range ack.Prints {
-			select {
-			case <-ui.ttyDoneCh:
-				return false
-			default:
-			}
-			p.Println(block)
-		}
-		return true
+		return ui.sendTTY(func(ack chan ttyEventAck) tea.Msg {
+			return ttyEventMsg{Event: e, Ack: ack}
+		})
 	}
 
 	go func() {
@@ -319,6 +433,11 @@ func (ui *UI) startTTY() {
 							return
 						}
 					default:
+						for _, e := range ui.takePendingProgress() {
+							if !sendEvent(e) {
+								return
+							}
+						}
 						p.Send(ttyShutdownMsg{})
 						return
 					}
@@ -329,7 +448,66 @@ func (ui *UI) startTTY() {
 				if !sendEvent(e) {
 					return
 				}
+			case <-ui.pendingCh:
+				for _, e := range ui.takePendingProgress() {
+					if !sendEvent(e) {
+						return
+					}
+				}
 			}
 		}
 	}()
 }
+
+// sendTTY sends the message built by newMsg to the TTY program, waits for its
+// ack and prints the returned History blocks. It returns false once the
+// program has exited.
+func (ui *UI) sendTTY(newMsg func(ack chan ttyEventAck) tea.Msg) bool {
+	p := ui.ttyProgram
+	ackCh := make(chan ttyEventAck)
+	p.Send(newMsg(ackCh))
+
+	var ack ttyEventAck
+	select {
+	case ack = <-ackCh:
+	case <-ui.ttyDoneCh:
+		return false
+	}
+
+	for _, block := range ack.Prints {
+		select {
+		case <-ui.ttyDoneCh:
+			return false
+		default:
+		}
+		p.Println(block)
+	}
+	return true
+}
+
+// suspendTTY clears the Active area and holds back History output until
+// resumeTTY, so callers can write to the terminal directly. It is called with
+// suspendMu held.
+func (ui *UI) suspendTTY() {
+	suspend := func(ack chan ttyEventAck) tea.Msg { return ttySuspendMsg{Suspend: true, Ack: ack} }
+	if !ui.sendTTY(suspend) {
+		return
+	}
+	// The program hands View() to the renderer right after Update returns, so
+	// a second ack proves the cleared frame was queued.
+	if !ui.sendTTY(suspend) || ui.ttyInput == nil {
+		return
+	}
+	// Releasing the terminal stops the renderer, which writes the queued frame
+	// first: once it returns, the Active area is cleared.
+	ui.ttyReleased = ui.ttyProgram.ReleaseTerminal() == nil
+}
+
+// resumeTTY restores what suspendTTY did. It is called with suspendMu held.
+func (ui *UI) resumeTTY() {
+	if ui.ttyReleased {
+		ui.ttyReleased = false
+		_ = ui.ttyProgram.RestoreTerminal()
+	}
+	ui.sendTTY(func(ack chan ttyEventAck) tea.Msg { return ttySuspendMsg{Suspend: false, Ack: ack} })
+}
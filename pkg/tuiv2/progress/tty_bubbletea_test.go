@@ -3,9 +3,11 @@ package progress
 import (
 	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/stretchr/testify/require"
 )
@@ -81,3 +83,231 @@ func TestTTYModel_SealEmptyGroup_DoesNotPrintSnapshot(t *testing.T) {
 	printed = apply(Event{Type: EventGroupClose, At: now.Add(time.Second), GroupID: 1, Finished: &finished})
 	require.Empty(t, printed, "sealed group without tasks should not produce a snapshot")
 }
+
+func TestTTYModel_ActiveLimits(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ui := &UI{
+		out:             io.Discard,
+		now:             func() time.Time { return now },
+		theme:           DefaultTheme(),
+		activeTaskLimit: 2,
+	}
+
+	m := newTTYModel(ui)
+	m.width = 80
+	m.height = 40
+
+	apply := func(e Event) {
+		ackCh := make(chan ttyEventAck, 1)
+		next, _ := m.Update(ttyEventMsg{Event: e, Ack: ackCh})
+		m = next.(ttyModel)
+		<-ackCh
+	}
+
+	title := "Start instances"
+	running := TaskStatusRunning
+	apply(Event{Type: EventGroupAdd, At: now, GroupID: 1, Title: &title})
+	for i, name := range []string{"PD-0", "TiKV-0", "TiKV-1", "TiDB-0"} {
+		id := uint64(10 + i)
+		apply(Event{Type: EventTaskAdd, At: now, GroupID: 1, TaskID: id, Title: &name})
+		apply(Event{Type: EventTaskState, At: now, TaskID: id, Status: &running})
+	}
+
+	view := ansi.Strip(m.render())
+	require.Contains(t, view, "PD-0")
+	require.Contains(t, view, "TiKV-0")
+	require.NotContains(t, view, "TiDB-0")
+	require.Contains(t, view, "… and 2 more")
+
+	// The line cap shrinks groups further, down to one task per group.
+	ui.activeTaskLimit = 0
+	ui.activeMaxLines = 3
+	view = ansi.Strip(m.render())
+	require.Contains(t, view, "PD-0")
+	require.NotContains(t, view, "TiKV-0")
+	require.Contains(t, view, "… and 3 more")
+}
+
+func TestTTYModel_SuspendHoldsPrints(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ui := &UI{
+		out:   io.Discard,
+		now:   func() time.Time { return now },
+		theme: DefaultTheme(),
+	}
+
+	m := newTTYModel(ui)
+	m.width = 80
+	m.height = 24
+
+	send := func(msg func(ack chan ttyEventAck) tea.Msg) []string {
+		ackCh := make(chan ttyEventAck, 1)
+		next, _ := m.Update(msg(ackCh))
+		m = next.(ttyModel)
+		return (<-ackCh).Prints
+	}
+	apply := func(e Event) []string {
+		return send(func(ack chan ttyEventAck) tea.Msg { return ttyEventMsg{Event: e, Ack: ack} })
+	}
+
+	title := "Start instances"
+	task := "PD"
+	apply(Event{Type: EventGroupAdd, At: now, GroupID: 1, Title: &title})
+	apply(Event{Type: EventTaskAdd, At: now, GroupID: 1, TaskID: 10, Title: &task})
+	require.Contains(t, ansi.Strip(m.render()), "PD")
+
+	require.Empty(t, send(func(ack chan ttyEventAck) tea.Msg { return ttySuspendMsg{Suspend: true, Ack: ack} }))
+	require.Empty(t, m.View())
+	require.Empty(t, apply(Event{Type: EventPrintLines, At: now, Lines: []string{"held"}}))
+
+	prints := send(func(ack chan ttyEventAck) tea.Msg { return ttySuspendMsg{Suspend: false, Ack: ack} })
+	require.Equal(t, []string{"\rheld" + ansi.EraseLineRight}, prints)
+	require.Contains(t, ansi.Strip(m.render()), "PD")
+}
+
+func TestTTYModel_ViewIsThrottled(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ui := &UI{
+		out:   io.Discard,
+		now:   func() time.Time { return now },
+		theme: DefaultTheme(),
+	}
+
+	m := newTTYModel(ui)
+	m.width = 80
+	m.height = 24
+
+	apply := func(e Event) tea.Cmd {
+		ackCh := make(chan ttyEventAck, 1)
+		next, cmd := m.Update(ttyEventMsg{Event: e, Ack: ackCh})
+		m = next.(ttyModel)
+		<-ackCh
+		return cmd
+	}
+
+	title := "Start instances"
+	pd, tidb := "PD", "TiDB"
+	require.NotNil(t, apply(Event{Type: EventGroupAdd, At: now, GroupID: 1, Title: &title}), "expected a frame tick")
+	apply(Event{Type: EventTaskAdd, At: now, GroupID: 1, TaskID: 10, Title: &pd})
+	require.Contains(t, ansi.Strip(m.View()), "PD")
+
+	// Changes within the frame interval reuse the cached frame...
+	apply(Event{Type: EventTaskAdd, At: now, GroupID: 1, TaskID: 11, Title: &tidb})
+	require.NotContains(t, ansi.Strip(m.View()), "TiDB")
+	require.True(t, m.frame.dirty)
+
+	// ...until the next frame.
+	m.frame.renderedAt = m.frame.renderedAt.Add(-ttyFrameInterval)
+	next, _ := m.Update(ttyFrameMsg{})
+	m = next.(ttyModel)
+	require.Contains(t, ansi.Strip(m.View()), "TiDB")
+	require.False(t, m.frame.dirty)
+}
+
+func TestTTYModel_DisabledSpinner(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	theme := ASCIITheme()
+	theme.Spinner.Frames = nil
+	ui := &UI{
+		out:   io.Discard,
+		now:   func() time.Time { return now },
+		theme: theme,
+	}
+
+	m := newTTYModel(ui)
+	m.width = 80
+	m.height = 24
+
+	title := "Start instances"
+	task := "PD"
+	running := TaskStatusRunning
+	for _, e := range []Event{
+		{Type: EventGroupAdd, At: now, GroupID: 1, Title: &title},
+		{Type: EventTaskAdd, At: now, GroupID: 1, TaskID: 10, Title: &task},
+		{Type: EventTaskState, At: now, TaskID: 10, Status: &running},
+	} {
+		ackCh := make(chan ttyEventAck, 1)
+		next, _ := m.Update(ttyEventMsg{Event: e, Ack: ackCh})
+		m = next.(ttyModel)
+		<-ackCh
+	}
+
+	require.Nil(t, m.ensureSpinnerTick(), "disabled spinner must not tick")
+	require.Contains(t, ansi.Strip(m.render()), "  |  - PD")
+}
+
+func TestNew_FallsBackToASCIITheme(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+	ui := New(Options{Mode: ModeOff})
+	require.Equal(t, ASCIITheme().Guide, ui.theme.Guide)
+
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+	ui = New(Options{Mode: ModeOff})
+	require.Equal(t, DefaultTheme().Guide, ui.theme.Guide)
+}
+
+func TestTTYModel_SizeOverride(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ui := &UI{
+		out:    io.Discard,
+		now:    func() time.Time { return now },
+		theme:  DefaultTheme(),
+		width:  30,
+		height: 4,
+	}
+
+	m := newTTYModel(ui)
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 60})
+	m = next.(ttyModel)
+
+	apply := func(e Event) {
+		ackCh := make(chan ttyEventAck, 1)
+		next, _ := m.Update(ttyEventMsg{Event: e, Ack: ackCh})
+		m = next.(ttyModel)
+		<-ackCh
+	}
+	title := "Start instances"
+	running := TaskStatusRunning
+	apply(Event{Type: EventGroupAdd, At: now, GroupID: 1, Title: &title})
+	for i, name := range []string{"A task with a rather long title", "PD-0", "TiKV-0"} {
+		id := uint64(10 + i)
+		apply(Event{Type: EventTaskAdd, At: now, GroupID: 1, TaskID: id, Title: &name})
+		apply(Event{Type: EventTaskState, At: now, TaskID: id, Status: &running})
+	}
+
+	lines := strings.Split(ansi.Strip(strings.TrimPrefix(m.render(), "\r")), "\n")
+	require.LessOrEqual(t, len(lines), 4)
+	for _, line := range lines {
+		require.LessOrEqual(t, ansi.StringWidth(line), 30, line)
+	}
+}
+
+func TestTTYModel_HistoryModes(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := &groupState{title: "Start instances", closed: true}
+	g.tasks = []*taskState{
+		{title: "PD-0", status: taskStatusDone, g: g},
+		{title: "TiKV-0", status: taskStatusError, message: "exited", g: g},
+		{title: "TiDB-0", status: taskStatusDone, g: g},
+	}
+
+	for _, tc := range []struct {
+		history HistoryMode
+		want    []string
+	}{
+		{HistoryFull, []string{"PD-0", "TiKV-0", "TiDB-0"}},
+		{HistoryHeaderOnly, nil},
+		{HistoryFailedOnly, []string{"TiKV-0"}},
+	} {
+		ui := &UI{out: io.Discard, now: func() time.Time { return now }, theme: DefaultTheme(), history: tc.history}
+		m := newTTYModel(ui)
+		m.width = 80
+
+		lines := m.snapshotLines(g, false)
+		require.Len(t, lines, 1+len(tc.want))
+		require.Contains(t, ansi.Strip(lines[0]), "Start instances")
+		for i, title := range tc.want {
+			require.Contains(t, ansi.Strip(lines[i+1]), title)
+		}
+	}
+}
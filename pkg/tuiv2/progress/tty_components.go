@@ -25,6 +25,8 @@ type ttyRenderContext struct {
 
 type ttyGroupComponent struct {
 	group *groupState
+	// failedOnly restricts task lines to failed tasks (HistoryFailedOnly).
+	failedOnly bool
 }
 
 func ttyTaskVisible(t *taskState, now time.Time) bool {
@@ -81,15 +83,16 @@ func (c ttyGroupComponent) Lines(ctx ttyRenderContext, activeLimit int) []string
 
 	visibleTasks := make([]*taskState, 0, len(tasks))
 	for _, t := range tasks {
-		if ttyTaskVisible(t, now) {
+		if ttyTaskVisible(t, now) && (!c.failedOnly || t.status == taskStatusError) {
 			visibleTasks = append(visibleTasks, t)
 		}
 	}
 
+	// The header summarizes the whole stage, including sub-groups.
 	active := 0
 	hasError := false
-	for _, t := range visibleTasks {
-		if t == nil {
+	for _, t := range g.subtreeTasks() {
+		if !ttyTaskVisible(t, now) {
 			continue
 		}
 		if t.status == taskStatusRunning || t.status == taskStatusRetrying {
@@ -106,16 +109,34 @@ func (c ttyGroupComponent) Lines(ctx ttyRenderContext, activeLimit int) []string
 	if g.showMeta {
 		header += "  " + ctx.styles.meta.Render(meta)
 	}
+	if g.showProgressCount {
+		if finished, total := g.finishedCount(); total > 0 {
+			count := fmt.Sprintf("%d/%d", finished, total)
+			if active > 0 {
+				count += fmt.Sprintf(" (%d%%)", int(g.progressRatio()*100))
+			}
+			header += "  " + ctx.styles.meta.Render(count)
+		}
+	}
+	if active > 0 && g.showDownloadTotals {
+		if current, total, speed := g.downloadTotals(); total > 0 {
+			totals := fmt.Sprintf("%s/%s", formatBytes(current), formatBytes(total))
+			if eta := formatETA(total-current, speed); eta != "" {
+				totals += "  " + eta
+			}
+			header += "  " + ctx.styles.meta.Render(totals)
+		}
+	}
 	if active > 0 && !g.showMeta {
 		header += " ..."
 	}
 
-	icon := ctx.styles.groupRunningIcon.Render("•")
+	icon := ctx.styles.groupRunningIcon.Render(ctx.styles.theme.GroupRunningIcon)
 	if g.closed && active == 0 {
 		if hasError {
-			icon = ctx.styles.groupErrorIcon.Render("✘")
+			icon = ctx.styles.groupErrorIcon.Render(ctx.styles.theme.ErrorIcon)
 		} else {
-			icon = ctx.styles.groupSuccessIcon.Render("✔︎")
+			icon = ctx.styles.groupSuccessIcon.Render(ctx.styles.theme.SuccessIcon)
 		}
 	}
 
@@ -135,13 +156,36 @@ func (c ttyGroupComponent) Lines(ctx ttyRenderContext, activeLimit int) []string
 		shown = activeLimit
 	}
 
+	lines = append(lines, ttyTaskLines(ctx, visibleTasks[:shown], guide, 0)...)
+	if len(visibleTasks) > shown {
+		lines = append(lines, ctx.styles.clipLine(ctx.width, fmt.Sprintf("  %s and %d more", ctx.styles.theme.Ellipsis, len(visibleTasks)-shown)))
+	}
+
+	// Sub-groups are rendered as nested blocks behind this group's guide bar.
+	prefix := "  " + guide.Render(ctx.styles.theme.Guide) + "  "
+	childCtx := ctx
+	childCtx.width = max(ctx.width-lipgloss.Width(prefix), 1)
+	for _, child := range g.children {
+		if len(child.subtreeTasks()) == 0 || (c.failedOnly && !child.hasFailed()) {
+			continue
+		}
+		for _, line := range (ttyGroupComponent{group: child, failedOnly: c.failedOnly}).Lines(childCtx, activeLimit) {
+			lines = append(lines, prefix+line)
+		}
+	}
+
+	return lines
+}
+
+// ttyTaskLines renders sibling tasks followed by their visible sub-tasks, one
+// indentation level deeper. Title/label columns are aligned per sibling list.
+func ttyTaskLines(ctx ttyRenderContext, tasks []*taskState, guide lipgloss.Style, depth int) []string {
 	maxTitleWidth := 0
-	for i := 0; i < shown; i++ {
-		t := visibleTasks[i]
+	for _, t := range tasks {
 		if t == nil {
 			continue
 		}
-		if t.kind == taskKindDownload || t.meta != "" || t.message != "" || t.status == taskStatusError {
+		if t.movesBytes() || t.meta != "" || t.message != "" || t.status == taskStatusError {
 			if w := lipgloss.Width(t.title); w > maxTitleWidth {
 				maxTitleWidth = w
 			}
@@ -150,9 +194,8 @@ func (c ttyGroupComponent) Lines(ctx ttyRenderContext, activeLimit int) []string
 
 	maxDownloadLabelWidth := 0
 	if maxTitleWidth > 0 {
-		for i := 0; i < shown; i++ {
-			t := visibleTasks[i]
-			if t == nil || t.kind != taskKindDownload {
+		for _, t := range tasks {
+			if t == nil || !t.movesBytes() {
 				continue
 			}
 			label := ttyDownloadLabel(t, ctx, maxTitleWidth)
@@ -162,24 +205,62 @@ func (c ttyGroupComponent) Lines(ctx ttyRenderContext, activeLimit int) []string
 		}
 	}
 
-	for i := 0; i < shown; i++ {
+	lines := make([]string, 0, len(tasks))
+	for _, t := range tasks {
 		lines = append(lines, ttyTaskComponent{
-			task:               visibleTasks[i],
+			task:               t,
 			guide:              guide,
+			depth:              depth,
 			titleWidth:         maxTitleWidth,
 			downloadLabelWidth: maxDownloadLabelWidth,
 		}.Line(ctx))
+		lines = append(lines, ttyHintLines(ctx, t, guide, depth)...)
+		if children := ttyVisibleChildren(t, ctx.now); len(children) > 0 {
+			lines = append(lines, ttyTaskLines(ctx, children, guide, depth+1)...)
+		}
 	}
-	if len(visibleTasks) > shown {
-		lines = append(lines, ctx.styles.clipLine(ctx.width, fmt.Sprintf("  … and %d more", len(visibleTasks)-shown)))
-	}
+	return lines
+}
 
+// ttyHintLines renders the hints of a failed task, indented below its title.
+func ttyHintLines(ctx ttyRenderContext, t *taskState, guide lipgloss.Style, depth int) []string {
+	if t == nil || t.status != taskStatusError || len(t.hints) == 0 {
+		return nil
+	}
+	prefix := "  " + guide.Render(ctx.styles.theme.Guide) + "  " + strings.Repeat("  ", depth+1)
+	lines := make([]string, 0, len(t.hints))
+	for _, hint := range t.hints {
+		lines = append(lines, ctx.styles.clipLine(ctx.width, prefix+ctx.styles.message.Render("hint: "+hint)))
+	}
 	return lines
 }
 
+// ttyVisibleChildren returns the sub-tasks to render below t.
+//
+// Sub-steps of a task that completed (or was skipped) are collapsed: once the
+// parent is done they are noise. They stay visible on errors and cancels so
+// users can see which step failed.
+func ttyVisibleChildren(t *taskState, now time.Time) []*taskState {
+	if t == nil || len(t.children) == 0 {
+		return nil
+	}
+	if t.status == taskStatusDone || t.status == taskStatusSkipped {
+		return nil
+	}
+	children := make([]*taskState, 0, len(t.children))
+	for _, c := range t.children {
+		if ttyTaskVisible(c, now) {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
 type ttyTaskComponent struct {
 	task  *taskState
 	guide lipgloss.Style
+	// depth is the sub-task nesting level (0 for top-level tasks).
+	depth int
 
 	titleWidth         int
 	downloadLabelWidth int
@@ -194,30 +275,30 @@ func (c ttyTaskComponent) Line(ctx ttyRenderContext) string {
 	var symbol string
 	switch t.status {
 	case taskStatusPending:
-		symbol = ctx.styles.taskPendingIcon.Render("·")
+		symbol = ctx.styles.taskPendingIcon.Render(ctx.styles.theme.PendingIcon)
 	case taskStatusRunning:
 		symbol = ctx.spinner
 	case taskStatusRetrying:
-		symbol = ctx.styles.taskCanceledIcon.Render("!")
+		symbol = ctx.styles.taskCanceledIcon.Render(ctx.styles.theme.WarningIcon)
 	case taskStatusDone:
-		symbol = ctx.styles.taskSuccessIcon.Render("✔︎")
+		symbol = ctx.styles.taskSuccessIcon.Render(ctx.styles.theme.SuccessIcon)
 	case taskStatusError:
-		symbol = ctx.styles.taskErrorIcon.Render("✘")
+		symbol = ctx.styles.taskErrorIcon.Render(ctx.styles.theme.ErrorIcon)
 	case taskStatusSkipped:
-		symbol = ctx.styles.taskSkippedIcon.Render("↷")
+		symbol = ctx.styles.taskSkippedIcon.Render(ctx.styles.theme.SkippedIcon)
 	case taskStatusCanceled:
-		symbol = ctx.styles.taskCanceledIcon.Render("!")
+		symbol = ctx.styles.taskCanceledIcon.Render(ctx.styles.theme.WarningIcon)
 	default:
 		symbol = "-"
 	}
 
-	guideBar := c.guide.Render("┃")
-	prefix := "  " + guideBar + "  " + symbol + " "
+	guideBar := c.guide.Render(ctx.styles.theme.Guide)
+	prefix := "  " + guideBar + "  " + strings.Repeat("  ", c.depth) + symbol + " "
 	prefixWidth := lipgloss.Width(prefix)
 
 	content := ""
 	switch {
-	case t.kind == taskKindDownload:
+	case t.movesBytes():
 		content = ttyDownloadContent(t, ctx, c.titleWidth, c.downloadLabelWidth)
 	case t.status == taskStatusError:
 		if t.meta == "" && t.message != "" {
@@ -332,7 +413,11 @@ func ttyDownloadContent(t *taskState, ctx ttyRenderContext, titleWidth, labelWid
 
 			parts = append(parts, fmt.Sprintf("%d%%", percent))
 			if t.speedBps > 0 {
-				parts = append(parts, ctx.styles.meta.Render(fmt.Sprintf("(%s)", formatSpeed(t.speedBps))))
+				speed := formatSpeed(t.speedBps)
+				if eta := ttyDownloadETA(t, ctx.now); eta != "" {
+					speed += ", " + eta
+				}
+				parts = append(parts, ctx.styles.meta.Render(fmt.Sprintf("(%s)", speed)))
 			}
 		} else if t.current > 0 {
 			parts = append(parts, formatBytes(t.current))
@@ -361,6 +446,23 @@ func ttyDownloadContent(t *taskState, ctx ttyRenderContext, titleWidth, labelWid
 	}
 }
 
+// ttyDownloadETA returns a remaining-time estimate for a running download with a
+// known total, or "" while the smoothed speed has not settled yet (estimates
+// from the first seconds of a transfer swing wildly).
+func ttyDownloadETA(t *taskState, now time.Time) string {
+	const settleAfter = 3 * time.Second
+	if t == nil || t.total <= 0 || t.speedBps <= 0 || t.startAt.IsZero() {
+		return ""
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if now.Sub(t.startAt) < settleAfter {
+		return ""
+	}
+	return formatETA(t.total-t.current, t.speedBps)
+}
+
 func ttyDownloadMeta(t *taskState) string {
 	if t == nil {
 		return ""
@@ -368,9 +470,9 @@ func ttyDownloadMeta(t *taskState) string {
 
 	parts := make([]string, 0, 1)
 	if t.total > 0 {
-		parts = append(parts, fmt.Sprintf("(%s)", formatBytes(t.total)))
+		parts = append(parts, fmt.Sprintf("(%s)", t.sizeLabel(formatBytes(t.total))))
 	} else if t.status != taskStatusRunning && t.status != taskStatusRetrying && t.current > 0 {
-		parts = append(parts, fmt.Sprintf("(%s)", formatBytes(t.current)))
+		parts = append(parts, fmt.Sprintf("(%s)", t.sizeLabel(formatBytes(t.current))))
 	}
 	return strings.Join(parts, " ")
 }
@@ -392,7 +494,7 @@ func renderProgressBar(styles ttyStyles, current, total int64, width int) string
 	if filled > width {
 		filled = width
 	}
-	bar := styles.progressFilled.Render(strings.Repeat("━", filled)) + styles.progressTrack.Render(strings.Repeat("━", width-filled))
+	bar := styles.progressFilled.Render(strings.Repeat(styles.theme.ProgressFilled, filled)) + styles.progressTrack.Render(strings.Repeat(styles.theme.ProgressTrack, width-filled))
 	return bar
 }
 
@@ -405,7 +507,7 @@ func renderTTYBlocks(st *engineState, ctx ttyRenderContext, activeLimit int) [][
 		if g == nil || g.sealed {
 			continue
 		}
-		if len(g.tasks) == 0 {
+		if len(g.subtreeTasks()) == 0 {
 			continue
 		}
 		blocks = append(blocks, ttyGroupComponent{group: g}.Lines(ctx, activeLimit))
@@ -19,7 +19,7 @@ func TestTTYTaskMetaAlignmentForCanceledTasks(t *testing.T) {
 	}
 
 	ctx := ttyRenderContext{
-		styles:  newTTYStyles(io.Discard),
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
 		width:   200,
 		spinner: "⠦",
 		now:     time.Now(),
@@ -44,7 +44,7 @@ func TestTTYTaskHideIfFast(t *testing.T) {
 	now := time.Now()
 
 	ctx := ttyRenderContext{
-		styles:  newTTYStyles(io.Discard),
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
 		width:   200,
 		spinner: "⠦",
 		now:     now,
@@ -102,7 +102,7 @@ func TestTTYDownloadTask_ShowsRetryingMessage(t *testing.T) {
 	}
 
 	ctx := ttyRenderContext{
-		styles:  newTTYStyles(io.Discard),
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
 		width:   200,
 		spinner: "⠦",
 		now:     time.Now(),
@@ -111,3 +111,241 @@ func TestTTYDownloadTask_ShowsRetryingMessage(t *testing.T) {
 	got := ansi.Strip(strings.Join(lines, "\n"))
 	require.Contains(t, got, "! Prometheus v8.5.4 (126MiB)  retrying 1/5...")
 }
+
+func TestTTYDownloadGroup_ShowsAggregateTotalsAndETA(t *testing.T) {
+	const mib = 1024 * 1024
+	g := &groupState{title: "Download components", showDownloadTotals: true}
+	g.tasks = []*taskState{
+		{title: "PD", kind: taskKindDownload, status: taskStatusDone, total: 20 * mib},
+		{title: "TiDB", kind: taskKindDownload, status: taskStatusRunning, current: 10 * mib, total: 40 * mib, speedBps: 2 * mib},
+		{title: "TiKV", kind: taskKindDownload, status: taskStatusRunning, current: 10 * mib, total: 40 * mib, speedBps: 1 * mib},
+		{title: "TiFlash", kind: taskKindDownload, status: taskStatusPending},
+	}
+
+	ctx := ttyRenderContext{
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
+		width:   200,
+		spinner: "⠦",
+		now:     time.Now(),
+	}
+	lines := ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	header := ansi.Strip(lines[0])
+	require.Contains(t, header, "40MiB/100MiB")
+	require.Contains(t, header, "~20s left")
+
+	g.showDownloadTotals = false
+	lines = ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	require.NotContains(t, ansi.Strip(lines[0]), "100MiB")
+}
+
+func TestTTYSubTasks_RenderedAsIndentedTree(t *testing.T) {
+	g := &groupState{title: "Start instances"}
+	parent := &taskState{title: "TiKV-0", status: taskStatusRunning, g: g}
+	parent.children = []*taskState{
+		{title: "Render config", status: taskStatusDone, g: g, parent: parent},
+		{title: "Health check", status: taskStatusRunning, g: g, parent: parent},
+	}
+	g.tasks = []*taskState{parent, {title: "PD-0", status: taskStatusDone, g: g}}
+
+	ctx := ttyRenderContext{
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
+		width:   200,
+		spinner: "⠦",
+		now:     time.Now(),
+	}
+	lines := ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	got := make([]string, 0, len(lines))
+	for _, line := range lines {
+		got = append(got, ansi.Strip(line))
+	}
+	require.Equal(t, []string{
+		"  ┃  ⠦ TiKV-0",
+		"  ┃    ✔︎ Render config",
+		"  ┃    ⠦ Health check",
+		"  ┃  ✔︎ PD-0",
+	}, got[1:])
+
+	// Sub-steps collapse once the parent completes.
+	parent.status = taskStatusDone
+	parent.children[1].status = taskStatusDone
+	lines = ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	require.NotContains(t, ansi.Strip(strings.Join(lines, "\n")), "Health check")
+}
+
+func TestTTYDownloadTask_ShowsETAOnceSpeedSettles(t *testing.T) {
+	const mib = 1024 * 1024
+	now := time.Now()
+	task := &taskState{
+		title:    "TiKV",
+		kind:     taskKindDownload,
+		status:   taskStatusRunning,
+		current:  10 * mib,
+		total:    100 * mib,
+		speedBps: 2 * mib,
+		startAt:  now.Add(-time.Second),
+	}
+	g := &groupState{title: "Download components", tasks: []*taskState{task}}
+
+	ctx := ttyRenderContext{
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
+		width:   200,
+		spinner: "⠦",
+		now:     now,
+	}
+	lines := ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	require.NotContains(t, ansi.Strip(lines[1]), "left")
+
+	task.startAt = now.Add(-5 * time.Second)
+	lines = ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	require.Contains(t, ansi.Strip(lines[1]), "(2.0MiB/s, ~45s left)")
+}
+
+func TestTTYGroupHeader_ShowsProgressCount(t *testing.T) {
+	g := &groupState{title: "Start instances", showProgressCount: true}
+	g.tasks = []*taskState{
+		{title: "PD-0", status: taskStatusDone},
+		{title: "TiKV-0", status: taskStatusDone},
+		{title: "TiKV-1", status: taskStatusError},
+		{title: "TiDB-0", status: taskStatusRunning},
+	}
+
+	ctx := ttyRenderContext{
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
+		width:   200,
+		spinner: "⠦",
+		now:     time.Now(),
+	}
+	lines := ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	require.Contains(t, ansi.Strip(lines[0]), "3/4 (75%)")
+
+	g.tasks[3].status = taskStatusDone
+	g.closed = true
+	lines = ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	header := ansi.Strip(lines[0])
+	require.Contains(t, header, "4/4")
+	require.NotContains(t, header, "%")
+}
+
+func TestTTYASCIITheme_NoColor(t *testing.T) {
+	g := &groupState{title: "Start instances", closed: true}
+	g.tasks = []*taskState{
+		{title: "PD", status: taskStatusDone},
+		{title: "TiDB", status: taskStatusError, message: "exit 1"},
+	}
+
+	ctx := ttyRenderContext{
+		styles:  newTTYStyles(io.Discard, false, ASCIITheme()),
+		width:   200,
+		spinner: "-",
+		now:     time.Now(),
+	}
+	lines := ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	require.Equal(t, []string{
+		"x Start instances",
+		"  |  v PD",
+		"  |  x TiDB exit 1",
+	}, lines)
+
+	require.Equal(t, "###-", renderProgressBar(ctx.styles, 3, 4, 4))
+}
+
+func TestTTYTaskError_RendersHints(t *testing.T) {
+	g := &groupState{title: "Start instances"}
+	g.tasks = []*taskState{
+		{title: "PD-0", status: taskStatusError, message: "port 2379 already in use", hints: []string{"use --port-offset"}, g: g},
+		{title: "TiKV-0", status: taskStatusDone, g: g},
+	}
+
+	ctx := ttyRenderContext{
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
+		width:   200,
+		spinner: "⠦",
+		now:     time.Now(),
+	}
+	lines := ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	got := make([]string, 0, len(lines))
+	for _, line := range lines {
+		got = append(got, ansi.Strip(line))
+	}
+	require.Len(t, got, 4)
+	require.Equal(t, "  ┃    hint: use --port-offset", got[2])
+	require.Contains(t, got[3], "TiKV-0")
+}
+
+func TestTTYSubGroups_RenderedNested(t *testing.T) {
+	parent := &groupState{title: "Start instances", showMeta: false}
+	tikv := &groupState{title: "TiKV", parent: parent}
+	tikv.tasks = []*taskState{
+		{title: "tikv-0", status: taskStatusDone, g: tikv},
+		{title: "tikv-1", status: taskStatusRunning, g: tikv},
+	}
+	empty := &groupState{title: "TiFlash", parent: parent}
+	parent.children = []*groupState{tikv, empty}
+	parent.tasks = []*taskState{{title: "PD-0", status: taskStatusDone, g: parent}}
+
+	ctx := ttyRenderContext{
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
+		width:   200,
+		spinner: "⠦",
+		now:     time.Now(),
+	}
+	lines := ttyGroupComponent{group: parent}.Lines(ctx, 1_000_000)
+	got := make([]string, 0, len(lines))
+	for _, line := range lines {
+		got = append(got, ansi.Strip(line))
+	}
+	require.Equal(t, []string{
+		"  ┃  ✔︎ PD-0",
+		"  ┃  • TiKV ...",
+		"  ┃    ┃  ✔︎ tikv-0",
+		"  ┃    ┃  ⠦ tikv-1",
+	}, got[1:])
+
+	// The parent header reflects running tasks of its sub-groups.
+	require.Equal(t, 1, strings.Count(got[0], "..."))
+	finished, total := parent.finishedCount()
+	require.Equal(t, 2, finished)
+	require.Equal(t, 3, total)
+}
+
+func TestTTYGroupHeader_ProgressIsWeighted(t *testing.T) {
+	g := &groupState{title: "Download components", showProgressCount: true}
+	g.tasks = []*taskState{
+		{title: "tidb", status: taskStatusDone, weight: 1},
+		{title: "tiflash", status: taskStatusRunning, kind: taskKindDownload, weight: 3, current: 50, total: 100},
+	}
+
+	ctx := ttyRenderContext{
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
+		width:   200,
+		spinner: "⠦",
+		now:     time.Now(),
+	}
+	lines := ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	// (1 + 3*0.5) / 4
+	require.Contains(t, ansi.Strip(lines[0]), "1/2 (62%)")
+}
+
+func TestTTYTransferTask_RendersLikeDownload(t *testing.T) {
+	g := &groupState{title: "Prepare data"}
+	g.tasks = []*taskState{{
+		title:     "Archive data dir",
+		status:    taskStatusRunning,
+		kind:      taskKindTransfer,
+		direction: TransferUpload,
+		current:   512,
+		total:     1024,
+		g:         g,
+	}}
+
+	ctx := ttyRenderContext{
+		styles:  newTTYStyles(io.Discard, true, DefaultTheme()),
+		width:   200,
+		spinner: "⠦",
+		now:     time.Now(),
+	}
+	lines := ttyGroupComponent{group: g}.Lines(ctx, 1_000_000)
+	line := ansi.Strip(lines[1])
+	require.Contains(t, line, "Archive data dir (upload 1.0KiB)")
+	require.Contains(t, line, "50%")
+}
@@ -9,6 +9,7 @@ import (
 
 type ttyStyles struct {
 	renderer *lipgloss.Renderer
+	theme    Theme
 
 	groupRunningIcon lipgloss.Style
 	groupSuccessIcon lipgloss.Style
@@ -31,43 +32,50 @@ type ttyStyles struct {
 	guideSuccess lipgloss.Style
 
 	notice lipgloss.Style
+
+	warnLabel lipgloss.Style
+	errLabel  lipgloss.Style
 }
 
-func newTTYStyles(out io.Writer) ttyStyles {
+// newTTYStyles builds the TTY styles for theme. When color is false, all
+// styling (colors, bold, faint) is dropped so NO_COLOR/TIUP_COLOR are honored
+// even though cursor control stays enabled.
+func newTTYStyles(out io.Writer, color bool, theme Theme) ttyStyles {
 	r := lipgloss.NewRenderer(out, termenv.WithTTY(true))
-
-	gray := lipgloss.ANSIColor(termenv.ANSIBrightBlack)
-	green := lipgloss.ANSIColor(termenv.ANSIGreen)
-	red := lipgloss.ANSIColor(termenv.ANSIRed)
-	yellow := lipgloss.ANSIColor(termenv.ANSIYellow)
-	cyan := lipgloss.ANSIColor(termenv.ANSICyan)
+	if !color {
+		r.SetColorProfile(termenv.Ascii)
+	}
 
 	return ttyStyles{
 		renderer: r,
+		theme:    theme,
 
-		groupRunningIcon: r.NewStyle().Foreground(gray),
-		groupSuccessIcon: r.NewStyle().Foreground(green).Bold(true),
-		groupErrorIcon:   r.NewStyle().Foreground(red).Bold(true),
+		groupRunningIcon: r.NewStyle().Foreground(theme.MutedColor),
+		groupSuccessIcon: r.NewStyle().Foreground(theme.SuccessColor).Bold(true),
+		groupErrorIcon:   r.NewStyle().Foreground(theme.ErrorColor).Bold(true),
 
-		taskSuccessIcon:  r.NewStyle().Foreground(green).Bold(true),
-		taskErrorIcon:    r.NewStyle().Foreground(red).Bold(true),
-		taskSkippedIcon:  r.NewStyle().Foreground(gray),
-		taskCanceledIcon: r.NewStyle().Foreground(yellow).Bold(true),
-		taskPendingIcon:  r.NewStyle().Foreground(gray).Faint(true),
-		spinner:          r.NewStyle().Foreground(cyan).Bold(true),
+		taskSuccessIcon:  r.NewStyle().Foreground(theme.SuccessColor).Bold(true),
+		taskErrorIcon:    r.NewStyle().Foreground(theme.ErrorColor).Bold(true),
+		taskSkippedIcon:  r.NewStyle().Foreground(theme.MutedColor),
+		taskCanceledIcon: r.NewStyle().Foreground(theme.WarningColor).Bold(true),
+		taskPendingIcon:  r.NewStyle().Foreground(theme.MutedColor).Faint(true),
+		spinner:          r.NewStyle().Foreground(theme.AccentColor).Bold(true),
 
-		progressFilled: r.NewStyle().Foreground(green),
+		progressFilled: r.NewStyle().Foreground(theme.SuccessColor),
 		// Use a "dimmed" gray for the progress track so it stays readable on
 		// both dark and light terminal themes (palette mappings vary widely).
-		progressTrack: r.NewStyle().Foreground(gray).Faint(true),
+		progressTrack: r.NewStyle().Foreground(theme.MutedColor).Faint(true),
 
 		meta:    r.NewStyle().Faint(true),
 		message: r.NewStyle().Faint(true),
 
-		guideRunning: r.NewStyle().Foreground(gray),
-		guideSuccess: r.NewStyle().Foreground(green),
+		guideRunning: r.NewStyle().Foreground(theme.MutedColor),
+		guideSuccess: r.NewStyle().Foreground(theme.SuccessColor),
+
+		notice: r.NewStyle().Foreground(theme.MutedColor),
 
-		notice: r.NewStyle().Foreground(gray),
+		warnLabel: r.NewStyle().Foreground(theme.WarningColor).Bold(true),
+		errLabel:  r.NewStyle().Foreground(theme.ErrorColor).Bold(true),
 	}
 }
 
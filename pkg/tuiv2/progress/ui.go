@@ -1,8 +1,13 @@
 package progress
 
 import (
+	"bufio"
+	"cmp"
+	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -25,11 +30,85 @@ type Options struct {
 	// plain output.
 	Out io.Writer
 
+	// In is the input reader used by UI.Prompt and UI.Confirm.
+	//
+	// If nil, it defaults to os.Stdin.
+	In io.Reader
+
 	// EventLog is an optional JSON-lines sink of the event stream.
 	//
 	// It is primarily intended for daemon mode: the daemon process writes event
 	// logs to a file, and the starter process replays them in a real TTY.
+	// Wrap it with NewGzipEventLog to compress the log; writers with a
+	// Flush() error method are flushed on Sync and Close.
 	EventLog io.Writer
+	// EventLogEncoding selects the encoding of EventLog. It defaults to
+	// EventLogJSON; unknown encodings fail Validate, and New falls back to
+	// EventLogJSON for them. Sinks that split events on newlines, like
+	// HTTPEventSink, need EventLogJSON.
+	EventLogEncoding EventLogEncoding
+	// EventLogProgressInterval downsamples EventTaskProgress in EventLog to at
+	// most one event per task per interval (e.g. 500ms), keeping logs of fast
+	// downloads small. The latest skipped progress of a task is still written
+	// before any other event, so replays end with the same state, and at most
+	// one interval after it was held, so readers tailing the log don't see
+	// stale progress while nothing else happens.
+	// If <= 0, every progress event is written.
+	EventLogProgressInterval time.Duration
+	// EventLogSync decides when EventLog is fsynced, if it has a Sync() error
+	// method (e.g. *os.File). Unless it is EventLogSyncNever (the default),
+	// EventLog is also fsynced on Sync and Close. Fsyncing blocks rendering,
+	// so prefer EventLogSyncOnStateChange to syncing every few events.
+	EventLogSync EventLogSync
+	// EventLogSyncEvery is the number of events between two fsyncs with
+	// EventLogSyncEveryN. If <= 0, every event is fsynced.
+	EventLogSyncEvery int
+	// CheckpointInterval makes the UI write an EventCheckpoint with the full
+	// state to EventLog at most once per interval, so a reader attaching to a
+	// long-running log can start from the last checkpoint (see
+	// LastCheckpointOffset) instead of replaying it from the beginning.
+	// If <= 0, no checkpoints are written.
+	CheckpointInterval time.Duration
+
+	// ActiveMaxLines caps the number of lines the TTY Active area may occupy.
+	// If <= 0, the Active area may use the whole terminal height.
+	ActiveMaxLines int
+	// ActiveTaskLimit is the default number of tasks rendered per group in the
+	// TTY Active area; the rest are folded into an "… and N more" line. If <= 0,
+	// groups are only truncated when the Active area runs out of lines.
+	//
+	// It does not truncate History: what a sealed group leaves there, all its
+	// tasks, only the failed ones or only its header, is decided by History.
+	ActiveTaskLimit int
+	// History controls what a group leaves in the TTY History area once it is
+	// sealed. It defaults to HistoryFull.
+	History HistoryMode
+
+	// Width and Height force the terminal size used to lay out the TTY Active
+	// area and History snapshots, instead of the size reported by the terminal
+	// (e.g. behind multiplexers reporting wrong sizes). If <= 0, the reported
+	// size is used.
+	Width  int
+	Height int
+
+	// PlainDone makes plain mode print a "DONE - <title> (<elapsed>)" line for
+	// each successfully completed task, so logs capture per-task timing.
+	PlainDone bool
+
+	// Summary makes UI.Close end the output with a per-group summary of task
+	// results and the total elapsed time (see EventSummary).
+	Summary bool
+
+	// Theme customizes the glyphs, colors and spinner of the TTY renderer.
+	// If nil, it defaults to DefaultTheme(), or ASCIITheme() when the terminal
+	// is not expected to support Unicode.
+	Theme *Theme
+
+	// Filter drops or rewrites events before they are rendered or written to
+	// EventLog (see EventFilter).
+	Filter EventFilter
+	// Observer is told about the events the UI applies (see EventObserver).
+	Observer EventObserver
 
 	// Now returns the current time.
 	// If nil, it defaults to time.Now.
@@ -38,6 +117,16 @@ type Options struct {
 	Now func() time.Time
 }
 
+// Validate reports the options New can't honor.
+func (opts Options) Validate() error {
+	switch opts.EventLogEncoding {
+	case "", EventLogJSON, EventLogBinary:
+		return nil
+	default:
+		return fmt.Errorf("unknown event log encoding %q", opts.EventLogEncoding)
+	}
+}
+
 // UI is a unified progress display for both TTY users and non-TTY logs/CI.
 //
 // Create a UI via New, then create Group/Task objects and update them from any goroutine.
@@ -48,7 +137,23 @@ type UI struct {
 	mode    Mode
 	outMode tuiterm.OutputMode
 
-	now func() time.Time
+	now   func() time.Time
+	theme Theme
+
+	activeMaxLines  int
+	activeTaskLimit int
+	history         HistoryMode
+	width           int
+	height          int
+	summary         bool
+	plainDone       bool
+
+	filter   EventFilter
+	observer EventObserver
+
+	checkpointInterval time.Duration
+	// lastCheckpointAt is only accessed by the render loop.
+	lastCheckpointAt time.Time
 
 	closed atomic.Bool
 	nextID atomic.Uint64
@@ -56,14 +161,44 @@ type UI struct {
 	syncMu      sync.Mutex
 	syncWaiters map[uint64]chan struct{}
 
+	in       *bufio.Reader
+	promptMu sync.Mutex
+
+	// liveMu guards the tasks and groups not finished yet, see Abort.
+	liveMu     sync.Mutex
+	liveTasks  map[uint64]*Task
+	liveGroups map[uint64]*Group
+
+	suspendMu    sync.Mutex
+	suspendDepth int
+	// ttyReleased reports whether Suspend released the terminal of the TTY
+	// program, to be restored by Resume.
+	ttyReleased bool
+
 	eventsCh chan Event
 	closeCh  chan struct{}
 	doneCh   chan struct{}
 
+	// pendingProgress holds, per task, the latest EventTaskProgress that could
+	// not be queued without blocking; pendingCh wakes the render loop to
+	// drain it. See emitProgress.
+	pendingMu       sync.Mutex
+	pendingProgress map[uint64]Event
+	pendingCh       chan struct{}
+	droppedProgress atomic.Uint64
+
 	writer *uiWriter
 
+	// sources holds the ID namespaces of replayed sources, see
+	// replaySourceEvent.
+	sourcesMu sync.Mutex
+	sources   map[string]*sourceIDs
+
 	ttyProgram *tea.Program
 	ttyDoneCh  chan struct{}
+	// ttyInput is the input of the TTY program, a pipe nobody writes to, nil
+	// if it could not be created (see startTTY).
+	ttyInput *os.File
 
 	plainDoneCh chan struct{}
 
@@ -72,20 +207,41 @@ type UI struct {
 
 const defaultEventBuffer = 4096
 
-// New creates a new progress UI.
+// New creates a new progress UI. Callers passing options from user input
+// should call Validate first: New falls back to EventLogJSON for an unknown
+// EventLogEncoding, and warns about it.
 func New(opts Options) *UI {
+	invalid := opts.Validate()
+	if invalid != nil {
+		opts.EventLogEncoding = EventLogJSON
+	}
 	out := opts.Out
 	if out == nil {
 		out = os.Stderr
 	}
 	outFile, _ := out.(*os.File)
+	in := opts.In
+	if in == nil {
+		in = os.Stdin
+	}
 
 	now := opts.Now
 	if now == nil {
 		now = time.Now
 	}
 
+	theme := DefaultTheme()
+	switch {
+	case opts.Theme != nil:
+		theme = *opts.Theme
+	case !tuiterm.SupportsUnicode():
+		theme = ASCIITheme()
+	}
+
 	requested := opts.Mode
+	if requested == ModeAuto && accessibleFromEnv() {
+		requested = ModeAccessible
+	}
 	termCap := tuiterm.Resolve(out)
 
 	actual := resolveMode(requested, termCap)
@@ -94,6 +250,14 @@ func New(opts Options) *UI {
 		actual = ModePlain
 	}
 	termCap.Control = actual == ModeTTY
+	switch actual {
+	case ModeJSON:
+		// Lines written via UI.Writer end up inside JSON strings.
+		termCap.Color = false
+	case ModeAccessible:
+		// Statuses are spelled out (ERR, DONE, ...), never conveyed by color.
+		termCap.Color = false
+	}
 
 	ui := &UI{
 		out:     out,
@@ -101,15 +265,33 @@ func New(opts Options) *UI {
 		mode:    actual,
 		outMode: termCap,
 		now:     now,
+		theme:   theme,
+		in:      bufio.NewReader(in),
+
+		activeMaxLines:  opts.ActiveMaxLines,
+		activeTaskLimit: opts.ActiveTaskLimit,
+		history:         opts.History,
+		width:           opts.Width,
+		height:          opts.Height,
+		summary:         opts.Summary,
+		plainDone:       opts.PlainDone,
+
+		filter:             opts.Filter,
+		observer:           opts.Observer,
+		checkpointInterval: opts.CheckpointInterval,
 
 		eventsCh: make(chan Event, defaultEventBuffer),
 		closeCh:  make(chan struct{}),
 		doneCh:   make(chan struct{}),
+
+		pendingProgress: make(map[uint64]Event),
+		pendingCh:       make(chan struct{}, 1),
 	}
 	ui.writer = &uiWriter{ui: ui}
 
 	if opts.EventLog != nil {
-		ui.eventLog = newEventLogSink(opts.EventLog)
+		// The encoding is valid, see above.
+		ui.eventLog, _ = newEventLogSink(opts.EventLog, opts)
 	}
 
 	switch actual {
@@ -118,14 +300,23 @@ func New(opts Options) *UI {
 		ui.startTTY()
 	case ModePlain:
 		ui.plainDoneCh = make(chan struct{})
-		go ui.runPlain()
+		go ui.runPlain(newPlainRenderer(ui.out, ui.outMode, ui.plainDone))
+	case ModeJSON:
+		ui.plainDoneCh = make(chan struct{})
+		go ui.runPlain(newJSONRenderer(ui.out))
+	case ModeAccessible:
+		ui.plainDoneCh = make(chan struct{})
+		go ui.runPlain(newPlainRenderer(ui.out, ui.outMode, true))
 	case ModeOff:
 		close(ui.doneCh)
 	default:
 		ui.plainDoneCh = make(chan struct{})
-		go ui.runPlain()
+		go ui.runPlain(newPlainRenderer(ui.out, ui.outMode, ui.plainDone))
 	}
 
+	if invalid != nil {
+		ui.WarnLines([]string{fmt.Sprintf("progress: %s, using %q", invalid, EventLogJSON)})
+	}
 	return ui
 }
 
@@ -159,6 +350,9 @@ func (ui *UI) Close() error {
 			})
 		}
 	}
+	if ui.summary {
+		ui.emitForced(Event{Type: EventSummary, At: ui.now()})
+	}
 
 	close(ui.closeCh)
 
@@ -167,7 +361,7 @@ func (ui *UI) Close() error {
 		if ui.ttyDoneCh != nil {
 			<-ui.ttyDoneCh
 		}
-	case ModePlain:
+	case ModePlain, ModeJSON, ModeAccessible:
 		if ui.plainDoneCh != nil {
 			<-ui.plainDoneCh
 		}
@@ -175,6 +369,7 @@ func (ui *UI) Close() error {
 	}
 
 	<-ui.doneCh
+	ui.eventLog.flush()
 	return nil
 }
 
@@ -183,14 +378,20 @@ func (ui *UI) Group(title string) *Group {
 	if ui == nil || ui.closed.Load() {
 		return &Group{ui: nil, title: title}
 	}
+	return ui.newGroup(0, title)
+}
+
+func (ui *UI) newGroup(parentID uint64, title string) *Group {
 	id := ui.nextID.Add(1)
 	g := &Group{ui: ui, id: id, title: title}
+	ui.trackGroup(g)
 	t := title
 	ui.emit(Event{
-		Type:    EventGroupAdd,
-		At:      ui.now(),
-		GroupID: id,
-		Title:   &t,
+		Type:          EventGroupAdd,
+		At:            ui.now(),
+		GroupID:       id,
+		ParentGroupID: parentID,
+		Title:         &t,
 	})
 	return g
 }
@@ -289,6 +490,22 @@ func (ui *UI) fulfillSync(id uint64) {
 // It flushes any pending partial line from UI.Writer() first, then appends an
 // output block.
 func (ui *UI) PrintLines(lines []string) {
+	ui.printLines(lines, "")
+}
+
+// WarnLines prints one or more text lines as a single output block labeled
+// WARN, in both plain output and the TTY History area.
+func (ui *UI) WarnLines(lines []string) {
+	ui.printLines(lines, PrintSeverityWarn)
+}
+
+// ErrorLines prints one or more text lines as a single output block labeled
+// ERR, in both plain output and the TTY History area.
+func (ui *UI) ErrorLines(lines []string) {
+	ui.printLines(lines, PrintSeverityError)
+}
+
+func (ui *UI) printLines(lines []string, severity PrintSeverity) {
 	if ui == nil || ui.closed.Load() {
 		return
 	}
@@ -302,18 +519,48 @@ func (ui *UI) PrintLines(lines []string) {
 			outLines = append(outLines, line)
 		}
 	}
+	if severity != "" && len(outLines) > 0 {
+		// The pending partial line is unrelated output: keep it out of the
+		// labeled block.
+		ui.emit(Event{
+			Type:  EventPrintLines,
+			At:    ui.now(),
+			Lines: outLines,
+		})
+		outLines = nil
+	}
 	outLines = append(outLines, lines...)
 	if len(outLines) == 0 {
 		return
 	}
 
 	ui.emit(Event{
-		Type:  EventPrintLines,
-		At:    ui.now(),
-		Lines: outLines,
+		Type:     EventPrintLines,
+		At:       ui.now(),
+		Lines:    outLines,
+		Severity: severity,
 	})
 }
 
+// labelLines prefixes the first line with label and indents continuation lines
+// by the label's visible width, so multi-line blocks stay aligned.
+func labelLines(label string, labelWidth int, lines []string) []string {
+	out := make([]string, 0, len(lines))
+	indent := strings.Repeat(" ", labelWidth+len(" - "))
+	for i, line := range lines {
+		if i == 0 {
+			out = append(out, label+" - "+line)
+			continue
+		}
+		if line == "" {
+			out = append(out, line)
+			continue
+		}
+		out = append(out, indent+line)
+	}
+	return out
+}
+
 func resolveMode(requested Mode, termCap tuiterm.OutputMode) Mode {
 	if requested == ModeOff {
 		return ModeOff
@@ -321,6 +568,12 @@ func resolveMode(requested Mode, termCap tuiterm.OutputMode) Mode {
 	if requested == ModePlain {
 		return ModePlain
 	}
+	if requested == ModeJSON {
+		return ModeJSON
+	}
+	if requested == ModeAccessible {
+		return ModeAccessible
+	}
 	if requested == ModeTTY {
 		if termCap.Control {
 			return ModeTTY
@@ -351,12 +604,88 @@ func (ui *UI) emit(e Event) {
 		return
 	default:
 	}
+	if e.Type == EventTaskProgress {
+		ui.emitProgress(e)
+		return
+	}
+	// Keep per-task ordering: progress coalesced earlier must be applied
+	// before e (engine state ignores progress once a task finished).
+	if e.TaskID != 0 {
+		ui.pendingMu.Lock()
+		prev, ok := ui.pendingProgress[e.TaskID]
+		delete(ui.pendingProgress, e.TaskID)
+		ui.pendingMu.Unlock()
+		if ok {
+			select {
+			case ui.eventsCh <- prev:
+			case <-ui.closeCh:
+				return
+			}
+		}
+	}
 	select {
 	case ui.eventsCh <- e:
 	case <-ui.closeCh:
 	}
 }
 
+// emitProgress queues a progress event without ever blocking the caller.
+//
+// When the event channel is full, the event is parked in pendingProgress and
+// merged with later progress of the same task (the newest values win); each
+// merge counts as a dropped event. Progress of a task with a parked event is
+// always merged, so the render loop never applies an older value after a
+// newer one.
+func (ui *UI) emitProgress(e Event) {
+	ui.pendingMu.Lock()
+	if prev, ok := ui.pendingProgress[e.TaskID]; ok {
+		ui.pendingProgress[e.TaskID] = coalesceProgress(prev, e)
+		ui.pendingMu.Unlock()
+		ui.droppedProgress.Add(1)
+		return
+	}
+	select {
+	case ui.eventsCh <- e:
+		ui.pendingMu.Unlock()
+		return
+	default:
+	}
+	ui.pendingProgress[e.TaskID] = e
+	ui.pendingMu.Unlock()
+
+	select {
+	case ui.pendingCh <- struct{}{}:
+	default:
+	}
+}
+
+// takePendingProgress removes and returns the parked progress events, ordered
+// by task ID.
+func (ui *UI) takePendingProgress() []Event {
+	ui.pendingMu.Lock()
+	defer ui.pendingMu.Unlock()
+	if len(ui.pendingProgress) == 0 {
+		return nil
+	}
+	events := make([]Event, 0, len(ui.pendingProgress))
+	for _, e := range ui.pendingProgress {
+		events = append(events, e)
+	}
+	clear(ui.pendingProgress)
+	slices.SortFunc(events, func(a, b Event) int { return cmp.Compare(a.TaskID, b.TaskID) })
+	return events
+}
+
+// DroppedProgressEvents returns the number of task progress updates that were
+// superseded by a newer update of the same task before being rendered,
+// because the UI could not keep up. It is intended for diagnostics.
+func (ui *UI) DroppedProgressEvents() uint64 {
+	if ui == nil {
+		return 0
+	}
+	return ui.droppedProgress.Load()
+}
+
 func (ui *UI) emitForced(e Event) {
 	if ui == nil {
 		return
@@ -379,7 +708,12 @@ func (ui *UI) emitForced(e Event) {
 	}
 }
 
-func (ui *UI) runPlain() {
+// eventRenderer renders engine state changes for the non-TTY modes.
+type eventRenderer interface {
+	renderEvent(now time.Time, e Event, st *engineState)
+}
+
+func (ui *UI) runPlain(r eventRenderer) {
 	defer func() {
 		if ui.plainDoneCh != nil {
 			close(ui.plainDoneCh)
@@ -393,7 +727,6 @@ func (ui *UI) runPlain() {
 	}
 
 	st := newEngineState()
-	r := newPlainRenderer(ui.out, ui.outMode)
 
 	for {
 		select {
@@ -403,32 +736,47 @@ func (ui *UI) runPlain() {
 				case e := <-ui.eventsCh:
 					ui.processPlainEvent(e, st, r)
 				default:
+					for _, e := range ui.takePendingProgress() {
+						ui.processPlainEvent(e, st, r)
+					}
 					return
 				}
 			}
 		case e := <-ui.eventsCh:
 			ui.processPlainEvent(e, st, r)
+		case <-ui.pendingCh:
+			for _, e := range ui.takePendingProgress() {
+				ui.processPlainEvent(e, st, r)
+			}
 		}
 	}
 }
 
-func (ui *UI) processPlainEvent(e Event, st *engineState, r *plainRenderer) {
+func (ui *UI) processPlainEvent(e Event, st *engineState, r eventRenderer) {
+	e, ok := ui.filterEvent(e)
+	if !ok {
+		return
+	}
 	now := e.At
 	if now.IsZero() {
 		now = ui.now()
 	}
 
-	if ui.eventLog != nil && e.Type != EventSync {
+	st.resolveSummary(now, &e)
+	if ui.eventLog != nil {
 		ui.eventLog.write(now, e)
 	}
+	ui.observeEvent(now, e)
 
 	if e.Type == EventSync {
+		ui.eventLog.flush()
 		ui.fulfillSync(e.SyncID)
 		return
 	}
 
 	st.applyEvent(now, e)
 	r.renderEvent(now, e, st)
+	ui.maybeWriteCheckpoint(now, st)
 }
 
 // DecodeEvent decodes a single JSON event line.
@@ -439,9 +787,22 @@ func DecodeEvent(line []byte) (Event, error) {
 // ReplayEvent injects a single Event into this UI.
 //
 // It is intended for daemon mode starter processes that tail an event log file.
+//
+// Events with a Source are namespaced by it, so several streams can be
+// replayed into the same UI concurrently: each source is rendered as a group
+// titled after it, holding the groups of the stream.
 func (ui *UI) ReplayEvent(e Event) {
 	if ui == nil {
 		return
 	}
+	if e.Type == EventSync {
+		// The barrier of the replayed stream must not release waiters of
+		// UI.Sync with the same ID.
+		e.SyncID = 0
+	}
+	if e.Source != "" {
+		ui.replaySourceEvent(e)
+		return
+	}
 	ui.emit(e)
 }
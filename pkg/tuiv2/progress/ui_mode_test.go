@@ -22,3 +22,25 @@ func TestUI_ModeAuto_NonFileWriter_ForcesPlain(t *testing.T) {
 	require.Equal(t, ModePlain, ui.Mode())
 	require.NoError(t, ui.Close())
 }
+
+func TestUI_ModeAccessible(t *testing.T) {
+	t.Setenv(EnvAccessible, "1")
+	t.Setenv(tuiterm.EnvForceColor, "1")
+
+	out := &fakeTTYWriter{}
+	ui := New(Options{Mode: ModeAuto, Out: out})
+	require.Equal(t, ModeAccessible, ui.Mode())
+
+	g := ui.Group("Start instances")
+	pd := g.Task("PD")
+	pd.Start()
+	pd.Done()
+	tikv := g.Task("TiKV")
+	tikv.Start()
+	tikv.Error("exited")
+	require.NoError(t, ui.Close())
+
+	require.NotContains(t, out.String(), "\x1b")
+	require.Contains(t, out.String(), "Start instances | DONE - PD (")
+	require.Contains(t, out.String(), "Start instances | ERR - TiKV: exited (")
+}
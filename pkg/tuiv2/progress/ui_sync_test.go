@@ -1,6 +1,8 @@
 package progress
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"sync"
 	"testing"
@@ -72,3 +74,93 @@ func TestUI_Sync_WaitsForEventLogWrites(t *testing.T) {
 		require.FailNow(t, "timeout waiting for Sync to return")
 	}
 }
+
+func TestUI_SuspendResume_TTY(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_TTY", "1")
+	outFile, err := os.CreateTemp(t.TempDir(), "ui-out")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = outFile.Close() })
+
+	ui := New(Options{Mode: ModeTTY, Out: outFile})
+	require.Equal(t, ModeTTY, ui.Mode())
+
+	ui.Suspend()
+	ui.Suspend()
+	ui.PrintLines([]string{"held-line"})
+	ui.Sync()
+	ui.Resume()
+	out, err := os.ReadFile(outFile.Name())
+	require.NoError(t, err)
+	require.NotContains(t, string(out), "held-line", "nested Suspend must keep output held")
+
+	ui.Resume()
+	require.NoError(t, ui.Close())
+	out, err = os.ReadFile(outFile.Name())
+	require.NoError(t, err)
+	require.Contains(t, string(out), "held-line")
+}
+
+func TestUI_Suspend_TTYReleasesTerminal(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_TTY", "1")
+	outFile, err := os.CreateTemp(t.TempDir(), "ui-out")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = outFile.Close() })
+
+	ui := New(Options{Mode: ModeTTY, Out: outFile})
+	task := ui.Group("Deploy").Task("PD")
+	task.Start()
+	require.Eventually(t, func() bool {
+		out, _ := os.ReadFile(outFile.Name())
+		return bytes.Contains(out, []byte("PD"))
+	}, time.Second, 10*time.Millisecond)
+
+	// Once Suspend returns, the cleared frame is written and nothing is
+	// repainted until Resume.
+	ui.Suspend()
+	suspended, err := os.ReadFile(outFile.Name())
+	require.NoError(t, err)
+	task.SetMessage("while suspended")
+	time.Sleep(3 * time.Second / ttyFPS)
+	out, err := os.ReadFile(outFile.Name())
+	require.NoError(t, err)
+	require.Equal(t, string(suspended), string(out))
+
+	ui.Resume()
+	require.Eventually(t, func() bool {
+		out, _ := os.ReadFile(outFile.Name())
+		return bytes.Contains(out[len(suspended):], []byte("PD"))
+	}, time.Second, 10*time.Millisecond, "Resume repaints the Active area")
+	task.Done()
+	require.NoError(t, ui.Close())
+}
+
+func TestUI_ProgressCoalescingNeverBlocks(t *testing.T) {
+	var out bytes.Buffer
+	bw := &blockingEventLogWriter{unblockCh: make(chan struct{})}
+	t.Cleanup(bw.Unblock)
+
+	ui := New(Options{Mode: ModeJSON, Out: &out, EventLog: bw})
+	task := ui.Group("Download components").Task("TiDB")
+	task.SetKindDownload()
+
+	// The render loop is stuck on the event log: progress updates beyond the
+	// channel capacity must be coalesced instead of blocking.
+	const updates = defaultEventBuffer * 2
+	for i := 1; i <= updates; i++ {
+		task.SetCurrent(int64(i))
+	}
+	require.Positive(t, ui.DroppedProgressEvents())
+
+	bw.Unblock()
+	task.Done()
+	require.NoError(t, ui.Close())
+
+	var last JSONRecord
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		require.NoError(t, json.Unmarshal(line, &last))
+	}
+	require.Equal(t, TaskStatusDone, last.Status)
+	require.EqualValues(t, updates, last.Current)
+}
@@ -24,6 +24,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"golang.org/x/net/http/httpproxy"
 )
 
 // HTTPClient is a wrap of http.Client
@@ -47,8 +49,8 @@ func NewHTTPClient(timeout time.Duration, tlsConfig *tls.Config) *HTTPClient {
 		httpProxy = os.Getenv("HTTP_PROXY")
 	}
 	if len(httpProxy) > 0 {
-		if proxyURL, err := url.Parse(httpProxy); err == nil {
-			tr.Proxy = http.ProxyURL(proxyURL)
+		if _, err := url.Parse(httpProxy); err == nil {
+			tr.Proxy = ProxyFunc(httpProxy)
 		}
 	}
 	return &HTTPClient{
@@ -59,6 +61,41 @@ func NewHTTPClient(timeout time.Duration, tlsConfig *tls.Config) *HTTPClient {
 	}
 }
 
+// ProxyFunc returns a proxy selector for http.Transport.
+//
+// A non-empty proxy (http://, https:// or socks5://) is used for all schemes.
+// Otherwise the standard environment variables are honored: HTTP_PROXY and
+// HTTPS_PROXY, falling back to ALL_PROXY so SOCKS proxies configured for other
+// tools work as well. NO_PROXY is always honored, and requests to localhost and
+// loopback addresses are never proxied.
+func ProxyFunc(proxy string) func(*http.Request) (*url.URL, error) {
+	cfg := httpproxy.FromEnvironment()
+	if proxy != "" {
+		cfg.HTTPProxy = proxy
+		cfg.HTTPSProxy = proxy
+	} else if all := firstNonEmptyEnv("ALL_PROXY", "all_proxy"); all != "" {
+		if cfg.HTTPProxy == "" {
+			cfg.HTTPProxy = all
+		}
+		if cfg.HTTPSProxy == "" {
+			cfg.HTTPSProxy = all
+		}
+	}
+	fn := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return fn(req.URL)
+	}
+}
+
+func firstNonEmptyEnv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 // SetRequestHeader set http request header
 func (c *HTTPClient) SetRequestHeader(key, value string) {
 	if c.header == nil {
@@ -0,0 +1,56 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyFunc(t *testing.T) {
+	for _, k := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "ALL_PROXY", "all_proxy", "NO_PROXY", "no_proxy"} {
+		t.Setenv(k, "")
+	}
+
+	proxyOf := func(fn func(*http.Request) (*url.URL, error), rawURL string) string {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		u, err := fn(req)
+		require.NoError(t, err)
+		if u == nil {
+			return ""
+		}
+		return u.String()
+	}
+
+	fn := ProxyFunc("socks5://proxy.example.com:1080")
+	require.Equal(t, "socks5://proxy.example.com:1080", proxyOf(fn, "https://tiup-mirrors.pingcap.com/root.json"))
+	require.Equal(t, "socks5://proxy.example.com:1080", proxyOf(fn, "http://tiup-mirrors.pingcap.com/root.json"))
+	require.Empty(t, proxyOf(fn, "http://127.0.0.1:9527/command"))
+	require.Empty(t, proxyOf(fn, "http://localhost:2379/pd/api/v1/health"))
+
+	require.Empty(t, proxyOf(ProxyFunc(""), "https://tiup-mirrors.pingcap.com/root.json"))
+
+	t.Setenv("ALL_PROXY", "socks5://all.example.com:1080")
+	require.Equal(t, "socks5://all.example.com:1080", proxyOf(ProxyFunc(""), "https://tiup-mirrors.pingcap.com/root.json"))
+
+	t.Setenv("HTTPS_PROXY", "http://https.example.com:3128")
+	require.Equal(t, "http://https.example.com:3128", proxyOf(ProxyFunc(""), "https://tiup-mirrors.pingcap.com/root.json"))
+
+	t.Setenv("NO_PROXY", "pingcap.com")
+	require.Empty(t, proxyOf(ProxyFunc(""), "https://tiup-mirrors.pingcap.com/root.json"))
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// InterpolateEnv replaces the ${VAR} and ${VAR:-default} references in data
+// with the values given by lookup, so one file can serve several
+// environments. The default is used when VAR is unset or empty. "$${" is an
+// escaped "${". Other uses of "$" are kept as is.
+//
+// A reference to a variable that lookup does not give and without a default
+// is an error, as is a malformed reference.
+func InterpolateEnv(data []byte, lookup func(name string) (string, bool)) ([]byte, error) {
+	if !bytes.Contains(data, []byte("${")) {
+		return data, nil
+	}
+
+	var out bytes.Buffer
+	for line := 1; len(data) > 0; {
+		i := bytes.IndexByte(data, '$')
+		if i < 0 {
+			out.Write(data)
+			break
+		}
+		line += bytes.Count(data[:i], []byte("\n"))
+		out.Write(data[:i])
+		data = data[i:]
+		switch {
+		case bytes.HasPrefix(data, []byte("$${")):
+			out.WriteString("${")
+			data = data[3:]
+			continue
+		case !bytes.HasPrefix(data, []byte("${")):
+			out.WriteByte('$')
+			data = data[1:]
+			continue
+		}
+
+		end := bytes.IndexByte(data, '}')
+		if end < 0 || bytes.IndexByte(data[:end], '\n') >= 0 {
+			return nil, fmt.Errorf("line %d: unterminated ${", line)
+		}
+		ref := string(data[2:end])
+		data = data[end+1:]
+		name, def, hasDef := strings.Cut(ref, ":-")
+		if !isEnvName(name) {
+			return nil, fmt.Errorf("line %d: invalid variable reference ${%s}", line, ref)
+		}
+		val, ok := lookup(name)
+		switch {
+		case ok && val != "":
+		case hasDef:
+			val = def
+		case !ok:
+			return nil, fmt.Errorf("line %d: variable %s is not set or not allowed", line, name)
+		}
+		out.WriteString(val)
+	}
+	return out.Bytes(), nil
+}
+
+// AllowedEnv returns a lookup for InterpolateEnv that only reads the
+// environment variables listed, comma separated, in allowlist.
+func AllowedEnv(allowlist string) func(name string) (string, bool) {
+	allowed := make(map[string]bool)
+	for _, name := range strings.Split(allowlist, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed[name] = true
+		}
+	}
+	return func(name string) (string, bool) {
+		if !allowed[name] {
+			return "", false
+		}
+		return os.LookupEnv(name)
+	}
+}
+
+func isEnvName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolateEnv(t *testing.T) {
+	env := map[string]string{"USER": "tidb", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	got, err := InterpolateEnv([]byte(`user: ${USER}
+dir: ${DIR:-/data}/${USER}
+empty: "${EMPTY:-x}${EMPTY}"
+price: $5 and $HOME
+literal: $${USER}
+`), lookup)
+	require.NoError(t, err)
+	require.Equal(t, `user: tidb
+dir: /data/tidb
+empty: "x"
+price: $5 and $HOME
+literal: ${USER}
+`, string(got))
+
+	_, err = InterpolateEnv([]byte("a: 1\nb: ${MISSING}\n"), lookup)
+	require.EqualError(t, err, "line 2: variable MISSING is not set or not allowed")
+	_, err = InterpolateEnv([]byte("a: ${USER\n}"), lookup)
+	require.EqualError(t, err, "line 1: unterminated ${")
+	_, err = InterpolateEnv([]byte("a: ${1X}"), lookup)
+	require.EqualError(t, err, "line 1: invalid variable reference ${1X}")
+}
+
+func TestAllowedEnv(t *testing.T) {
+	t.Setenv("TIUP_TEST_ALLOWED", "yes")
+	t.Setenv("TIUP_TEST_DENIED", "no")
+	lookup := AllowedEnv(" TIUP_TEST_ALLOWED, TIUP_TEST_UNSET ")
+
+	v, ok := lookup("TIUP_TEST_ALLOWED")
+	require.True(t, ok)
+	require.Equal(t, "yes", v)
+	_, ok = lookup("TIUP_TEST_DENIED")
+	require.False(t, ok)
+	_, ok = lookup("TIUP_TEST_UNSET")
+	require.False(t, ok)
+}